@@ -12,6 +12,7 @@
 package config
 
 import (
+	"net"
 	"os"
 
 	"github.com/eclipse/codewind-installer/pkg/utils"
@@ -19,22 +20,37 @@ import (
 
 // PFEHost is the host at which PFE is running, e.g. "127.0.0.1:9090"
 func PFEHost() string {
-	hostname, port := utils.GetPFEHostAndPort()
-	return hostname + ":" + port
+	return ProfilePFEHost(utils.ActiveProfile())
 }
 
 // PFEOrigin is the origin from which PFE is running, e.g. "http://127.0.0.1:9090"
 func PFEOrigin() string {
+	return ProfilePFEOrigin(utils.ActiveProfile())
+}
+
+// PFEApiRoute is the API route at which the PFE REST API can be accessed, e.g. "http://127.0.0.1:9090/api/v1/"
+func PFEApiRoute() string {
+	return ProfilePFEApiRoute(utils.ActiveProfile())
+}
+
+// ProfilePFEHost is the host at which the PFE of a local Codewind instance is running
+func ProfilePFEHost(profile utils.LocalProfile) string {
+	hostname, port := utils.GetProfilePFEHostAndPort(profile)
+	return net.JoinHostPort(hostname, port)
+}
+
+// ProfilePFEOrigin is the origin from which the PFE of a local Codewind instance is running
+func ProfilePFEOrigin(profile utils.LocalProfile) string {
 	val, ok := os.LookupEnv("CHE_API_EXTERNAL")
 
 	if ok && (val != "") {
-		return "https://" + PFEHost()
+		return "https://" + ProfilePFEHost(profile)
 	}
 
-	return "http://" + PFEHost()
+	return "http://" + ProfilePFEHost(profile)
 }
 
-// PFEApiRoute is the API route at which the PFE REST API can be accessed, e.g. "http://127.0.0.1:9090/api/v1/"
-func PFEApiRoute() string {
-	return PFEOrigin() + "/api/v1/"
+// ProfilePFEApiRoute is the API route of the PFE of a local Codewind instance
+func ProfilePFEApiRoute(profile utils.LocalProfile) string {
+	return ProfilePFEOrigin(profile) + "/api/v1/"
 }
@@ -0,0 +1,60 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// AdoptCommand : Records the running Codewind containers that cwctl did not start, e.g. by an older installer or
+// docker-compose by hand, as the deployment of the profile selected with --profile, so that status, stop and
+// start manage them instead of starting a second Codewind
+func AdoptCommand(c *cli.Context) {
+	profile := utils.ActiveProfile()
+	if utils.CheckContainerStatus() {
+		exitWithUsageError("Codewind is already running and managed by cwctl, stop it before adopting other containers")
+	}
+	containers, err := utils.SelectDeployment(utils.FindUnmanagedContainers(), strings.TrimSpace(c.String("container")))
+	if err != nil {
+		exitWithUsageError(err.Error())
+	}
+	deployment, err := profile.Adopt(containers)
+	if err != nil {
+		errors.Exit(errors.Filesystem, "", "Unable to record the adopted deployment: "+err.Error())
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(deployment)
+	} else {
+		fmt.Println("Adopted the Codewind containers " + strings.Join(utils.ContainerNames(containers), ", "))
+	}
+	exitSuccess()
+}
+
+// checkUnmanagedContainers : Stops the default profile from starting a second Codewind beside one that cwctl did
+// not start. Profiles are meant to run beside other instances, so starting one only warns
+func checkUnmanagedContainers(profile utils.LocalProfile) {
+	unmanaged := utils.FindUnmanagedContainers()
+	if len(unmanaged) == 0 {
+		return
+	}
+	msg := "Codewind is already running in containers that cwctl did not start: " + strings.Join(utils.ContainerNames(unmanaged), ", ")
+	if profile.IsDefault() {
+		errors.Exit(errors.Docker, "", msg+". Run 'cwctl adopt' to manage them, or stop them before starting")
+	}
+	logr.Warnln(msg)
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils/apply"
+	"github.com/urfave/cli"
+)
+
+// ApplyCommand : Reconciles the connections, template repositories, registry secrets and projects with an environment file
+func ApplyCommand(c *cli.Context) {
+	env, applyErr := apply.LoadEnvironment(c.String("file"))
+	if applyErr != nil {
+		exitWithError(applyErr)
+	}
+	options := apply.Options{DryRun: c.Bool("dry-run"), Prune: c.Bool("prune")}
+	ctx, stop := interruptContext()
+	defer stop()
+	changes, applyErr := apply.Reconcile(ctx, env, options)
+	if c.GlobalBool("json") {
+		type Output struct {
+			DryRun  bool           `json:"dryRun"`
+			Changes []apply.Change `json:"changes"`
+			Error   *string        `json:"error,omitempty"`
+		}
+		output := Output{DryRun: options.DryRun, Changes: changes}
+		if applyErr != nil {
+			errText := applyErr.Error()
+			output.Error = &errText
+		}
+		jsonResponse, _ := json.Marshal(output)
+		fmt.Println(string(jsonResponse))
+	} else {
+		printApplySummary(changes, options.DryRun)
+		if applyErr != nil {
+			fmt.Println(applyErr.Error())
+		}
+	}
+	if applyErr != nil {
+		os.Exit(errors.CategoryForOp(applyErr.Op).ExitCode)
+	}
+	exitSuccess()
+}
+
+func printApplySummary(changes []apply.Change, dryRun bool) {
+	counts := map[string]int{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACTION\tKIND\tNAME\tDETAIL")
+	for _, change := range changes {
+		counts[change.Action]++
+		fmt.Fprintln(w, change.Action+"\t"+change.Kind+"\t"+change.Name+"\t"+change.Detail)
+	}
+	w.Flush()
+	summary := fmt.Sprintf("%d to create, %d to update, %d to delete, %d unchanged",
+		counts[apply.ActionCreate], counts[apply.ActionUpdate], counts[apply.ActionDelete], counts[apply.ActionUnchanged])
+	if dryRun {
+		fmt.Println("\nDry run, no changes were made: " + summary)
+	} else {
+		fmt.Println("\n" + summary)
+	}
+}
@@ -0,0 +1,48 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+)
+
+// broadcastToConnections : Runs a command on every connection, printing the results as one JSON or YAML document, or
+// under a heading for each connection with printResult. Exits 1 when the command failed on any of them
+func broadcastToConnections(format string, command func(connection connections.Connection) (interface{}, error), printResult func(result interface{})) {
+	all, conErr := connections.GetAllConnections()
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	response := connections.Broadcast(all, command)
+	if format != outputText {
+		printOutput(format, response)
+	} else {
+		for i, result := range response.Connections {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println("== " + result.ConnectionID + " (" + result.Label + ") ==")
+			if result.Error != "" {
+				fmt.Println("Error: " + result.Error)
+			} else {
+				printResult(result.Result)
+			}
+		}
+	}
+	if response.Failed() > 0 {
+		os.Exit(1)
+	}
+	exitSuccess()
+}
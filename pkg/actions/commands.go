@@ -12,40 +12,171 @@
 package actions
 
 import (
-	"crypto/tls"
-	"net/http"
 	"os"
 
 	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
 
 	"github.com/urfave/cli"
 )
 
-var tempFilePath = "codewind-docker-compose.yaml"
-
-const versionNum = "x.x.dev"
+// versionNum is a variable so that releases can set it with -ldflags -X, as with the build details in version.go
+var versionNum = "x.x.dev"
 
 const healthEndpoint = "/api/v1/environment"
 
+// secretFlags : The flags, by the first of their names, whose values are passwords, tokens or other secrets, which
+// are never saved for redo. A flag taking a secret must be added here when it is defined
+var secretFlags = map[string]bool{
+	"password":          true,
+	"accesstoken":       true,
+	"newpw":             true,
+	"kadminpass":        true,
+	"kdevpass":          true,
+	"session":           true,
+	"github-token":      true,
+	"api-key":           true,
+	"secret-access-key": true,
+	"session-token":     true,
+	"webhook":           true,
+}
+
 //Commands for the controller
 func Commands() {
 	app := cli.NewApp()
 	app.Name = "cwctl"
 	app.Version = versionNum
 	app.Usage = "Start, Stop and Remove Codewind"
+	app.EnableBashCompletion = true
 
 	// Global Flags
 	app.Flags = []cli.Flag{
 		cli.BoolFlag{
 			Name:  "insecure",
-			Usage: "disable certificate checking",
+			Usage: "disable certificate checking for the hosts of all connections, for this command only",
 		},
 		cli.BoolFlag{
 			Name:  "json, j",
 			Usage: "ouput as JSON",
 		},
+		cli.StringFlag{
+			Name:  "output",
+			Usage: "print status, connections list, templates list and project list as json or yaml",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "print what remove, stop-all, remove remote, gc and connections reset would change, without changing it",
+		},
+		cli.StringFlag{
+			Name:  "loglevel",
+			Usage: "set the log level (error, warn, info, debug, trace)",
+		},
+		cli.BoolFlag{
+			Name:   "non-interactive",
+			Usage:  "never prompt or draw progress bars, failing instead of prompting (default: on when stdout is not a terminal)",
+			EnvVar: "CW_NON_INTERACTIVE",
+		},
+		cli.StringFlag{
+			Name:   "profile",
+			Usage:  "name of the local Codewind instance that start, stop, remove and status operate on (default: the default instance)",
+			EnvVar: "CW_PROFILE",
+		},
+		cli.StringFlag{
+			Name:  "proxy",
+			Usage: "proxy for outbound HTTP and HTTPS requests, overriding HTTP_PROXY and HTTPS_PROXY",
+		},
+		cli.StringFlag{
+			Name:  "https-proxy",
+			Usage: "proxy for outbound HTTPS requests, overriding --proxy and HTTPS_PROXY",
+		},
+		cli.StringFlag{
+			Name:  "no-proxy",
+			Usage: "comma separated hosts to reach without a proxy, overriding NO_PROXY",
+		},
+		cli.StringFlag{
+			Name:   "secret-backend",
+			Usage:  "where credentials are kept (auto, keyring, file), auto uses an encrypted file when no keyring is available",
+			EnvVar: "CW_SECRET_BACKEND",
+		},
+		cli.BoolFlag{
+			Name:  "trace-http",
+			Usage: "log the method, URL, status and duration of every outbound HTTP request to stderr",
+		},
+		cli.BoolFlag{
+			Name:  "trace-body",
+			Usage: "with --trace-http, also log the request and response bodies, with passwords and tokens redacted",
+		},
+		cli.StringFlag{
+			Name:  "trace-file",
+			Usage: "append the --trace-http log to this file instead of stderr",
+		},
+	}
+
+	// Flags overriding where the Codewind images come from, shared by install, start and remove
+	imageFlags := []cli.Flag{
+		cli.StringFlag{Name: "registry", Usage: "registry to pull the Codewind images from (default: docker.io)"},
+		cli.StringFlag{Name: "org", Usage: "registry organization of the Codewind images (default: eclipse)"},
+		cli.StringFlag{Name: "pfe-image", Usage: "name of the PFE image (default: " + utils.LocalPFEImage + ")"},
+		cli.StringFlag{Name: "performance-image", Usage: "name of the performance image (default: " + utils.LocalPerformanceImage + ")"},
+		cli.StringFlag{Name: "pfe-digest", Usage: "pin the PFE image to this sha256 digest"},
+		cli.StringFlag{Name: "performance-digest", Usage: "pin the performance image to this sha256 digest"},
+		cli.StringFlag{Name: "arch", Usage: "architecture of the images, amd64, arm64, ppc64le or s390x (default: the architecture Docker runs containers on)"},
+	}
+
+	// Flags selecting the Kubernetes cluster of the remote commands, which otherwise use KUBECONFIG, or else
+	// ~/.kube/config, and its current context as kubectl does
+	kubeFlags := []cli.Flag{
+		cli.StringFlag{Name: "kubeconfig", Usage: "path of the kubeconfig file (default: KUBECONFIG, or ~/.kube/config)"},
+		cli.StringFlag{Name: "context", Usage: "name of the kubeconfig context to use (default: the current context, or the context recorded in the connection)"},
+	}
+
+	// Default timeouts of the phases of start
+	startTimeouts := utils.DefaultStartTimeouts()
+
+	// The timeouts and progress of the phases of starting Codewind, for the commands which start it
+	startPhaseFlags := []cli.Flag{
+		cli.DurationFlag{
+			Name:   "compose-timeout",
+			Value:  startTimeouts.ComposeUp,
+			Usage:  "how long docker-compose may take to create the containers",
+			EnvVar: "CW_START_COMPOSE_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "network-timeout",
+			Value:  startTimeouts.Network,
+			Usage:  "how long to wait for the codewind network",
+			EnvVar: "CW_START_NETWORK_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "pfe-timeout",
+			Value:  startTimeouts.PFEHealth,
+			Usage:  "how long to wait for PFE to report it is healthy",
+			EnvVar: "CW_START_PFE_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "performance-timeout",
+			Value:  startTimeouts.PerformanceHealth,
+			Usage:  "how long to wait for the performance container to respond",
+			EnvVar: "CW_START_PERFORMANCE_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "timeout",
+			Usage:  "how long the whole start may take, cutting short the phase running when it is reached (default: no limit beyond the phase timeouts)",
+			EnvVar: "CW_START_TIMEOUT",
+		},
+		cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "report each phase as it runs",
+		},
 	}
 
+	// The connection used when --conid is not given
+	defaultConnection := getDefaultConnection()
+
 	// create commands
 	app.Commands = []cli.Command{
 
@@ -61,6 +192,10 @@ func Commands() {
 
 					Flags: []cli.Flag{
 						cli.StringFlag{Name: "url, u", Usage: "URL of project to download"},
+						cli.StringFlag{Name: "ref, branch, b", Usage: "branch, tag or commit to download or clone when the URL is a GitHub or git repository (default: its default branch)"},
+						cli.StringFlag{Name: "github-token", EnvVar: "GITHUB_TOKEN", Usage: "GitHub personal access token to download a private GitHub repository with"},
+						cli.StringFlag{Name: "sha256", Usage: "checksum the downloaded .tar.gz or .zip archive must match"},
+						cli.BoolFlag{Name: "verify", Usage: "fail unless the archive matches --sha256, or the sha256 its template repository index gives"},
 						cli.StringFlag{Name: "type, t", Usage: "Known type and subtype of project (`type:subtype`). Ignored when URL is given"},
 					},
 					Action: func(c *cli.Context) error {
@@ -71,16 +206,49 @@ func Commands() {
 						return nil
 					},
 				},
+				{
+					Name:  "validate",
+					Usage: "Report the language, type and extension of a project without changing it",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "path, p", Usage: "the path to the project", Required: true},
+						cli.StringFlag{Name: "type, t", Usage: "known type and subtype of the project (`type:subtype`) to match an extension by"},
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "the connection whose extensions to check"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectValidateCheck(c)
+						return nil
+					},
+				},
+				{
+					Name:  "add",
+					Usage: "validate, configure and bind an existing project in one step",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "path, p", Usage: "the path to the project", Required: true},
+						cli.StringFlag{Name: "name, n", Usage: "the name of the project (default: the name of its directory)"},
+						cli.StringFlag{Name: "type, t", Usage: "known type and subtype of the project (`type:subtype`) to match an extension by"},
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "the connection to add the project to"},
+						cli.IntFlag{Name: "bandwidth-limit", Usage: "the most KB per second to upload, 0 for no limit"},
+						cli.StringFlag{Name: "transfer-mode", Value: "http", Usage: "how to transfer the project files: http uploads them to Codewind, kube copies them into the Codewind pod with your cluster credentials"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectAdd(c)
+						return nil
+					},
+				},
 				{
 					Name:    "bind",
 					Aliases: []string{""},
 					Usage:   "bind a project to codewind for building and running",
 					Flags: []cli.Flag{
-						cli.StringFlag{Name: "name, n", Usage: "the name of the project", Required: true},
-						cli.StringFlag{Name: "language, l", Usage: "the project language", Required: true},
-						cli.StringFlag{Name: "type, t", Usage: "the type of the project", Required: true},
+						cli.StringFlag{Name: "name, n", Usage: "the name of the project"},
+						cli.StringFlag{Name: "language, l", Usage: "the project language"},
+						cli.StringFlag{Name: "type, t", Usage: "the type of the project"},
 						cli.StringFlag{Name: "path, p", Usage: "the path to the project", Required: true},
 						cli.StringFlag{Name: "conid", Usage: "the connection id for the project", Required: false},
+						cli.BoolFlag{Name: "resume", Usage: "complete an interrupted bind of the project at --path"},
+						cli.BoolFlag{Name: "rename-on-conflict", Usage: "when a project with the name already exists, bind as the name with the first free -2, -3, ... suffix"},
+						cli.IntFlag{Name: "bandwidth-limit", Usage: "the most KB per second to upload, 0 for no limit"},
+						cli.StringFlag{Name: "transfer-mode", Value: "http", Usage: "how to transfer the project files: http uploads them to Codewind, kube copies them into the Codewind pod with your cluster credentials"},
 					},
 					Action: func(c *cli.Context) error {
 						ProjectBind(c)
@@ -92,15 +260,195 @@ func Commands() {
 					Aliases: []string{""},
 					Usage:   "synchronize a project to codewind for building and running",
 					Flags: []cli.Flag{
-						cli.StringFlag{Name: "path, p", Usage: "the path to the project", Required: true},
+						cli.StringFlag{Name: "path, p", Usage: "the path to the project, by default the one it was last bound or synced from", Required: false},
 						cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
-						cli.StringFlag{Name: "time, t", Usage: "time of the last sync for the given project", Required: true},
+						cli.StringFlag{Name: "time, t", Usage: "time of the last sync for the given project, by default the one recorded by it", Required: false},
+						cli.IntFlag{Name: "bandwidth-limit", Usage: "the most KB per second to upload, 0 for no limit"},
 					},
 					Action: func(c *cli.Context) error {
 						ProjectSync(c)
 						return nil
 					},
 				},
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the projects on a connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "the connection id to list the projects of, or all to list those of every connection"},
+						cli.BoolFlag{Name: "all, a", Usage: "include projects without the connection's project prefix"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectList(c)
+						return nil
+					},
+				},
+				{
+					Name:  "grep",
+					Usage: "Search the files of the projects bound from this machine",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "pattern", Usage: "the regular expression to search for", Required: true},
+						cli.StringFlag{Name: "conid", Usage: "only search the projects on this connection", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectGrep(c)
+						return nil
+					},
+				},
+				{
+					Name:  "settings",
+					Usage: "Read or change the .cw-settings of a project",
+					Subcommands: []cli.Command{
+						{
+							Name:      "get",
+							Usage:     "Print the .cw-settings of a project, or one setting",
+							ArgsUsage: "[setting]",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+								cli.StringFlag{Name: "path, p", Usage: "the project path (default: the path the project was last synced from)"},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectSettingsGet(c)
+								return nil
+							},
+						},
+						{
+							Name:      "set",
+							Usage:     "Change a setting in the .cw-settings of a project and apply it in Codewind",
+							ArgsUsage: "<setting> <value>",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+								cli.StringFlag{Name: "path, p", Usage: "the project path (default: the path the project was last synced from)"},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectSettingsSet(c)
+								return nil
+							},
+						},
+					},
+				},
+				{
+					Name:  "metrics",
+					Usage: "Add or remove the metrics collector of a Node.js or Java project",
+					Subcommands: []cli.Command{
+						{
+							Name:  "inject",
+							Usage: "Add appmetrics or javametrics to the project's build, so its performance can be monitored",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectMetricsInject(c)
+								return nil
+							},
+						},
+						{
+							Name:    "remove",
+							Aliases: []string{"rm"},
+							Usage:   "Take the injected metrics collector back out of the project's build",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectMetricsRemove(c)
+								return nil
+							},
+						},
+					},
+				},
+				{
+					Name:  "link",
+					Usage: "Manage the links of a project to the projects it depends on",
+					Subcommands: []cli.Command{
+						{
+							Name:  "add",
+							Usage: "Link a project to another, injecting the URL of the other project as an environment variable",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+								cli.StringFlag{Name: "targetID, t", Usage: "the id of the project to link to", Required: true},
+								cli.StringFlag{Name: "env, e", Usage: "the environment variable the URL of the target project is injected as", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectLinkAdd(c)
+								return nil
+							},
+						},
+						{
+							Name:    "remove",
+							Aliases: []string{"rm"},
+							Usage:   "Remove the link injected as an environment variable",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+								cli.StringFlag{Name: "env, e", Usage: "the environment variable of the link", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectLinkRemove(c)
+								return nil
+							},
+						},
+						{
+							Name:    "list",
+							Aliases: []string{"ls"},
+							Usage:   "List the links of a project",
+							Flags: []cli.Flag{
+								cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+							},
+							Action: func(c *cli.Context) error {
+								ProjectLinkList(c)
+								return nil
+							},
+						},
+					},
+				},
+				{
+					Name:  "locate",
+					Usage: "Show where a project is on this machine and in the workspace of its connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+						cli.StringFlag{Name: "file, f", Usage: "a file of the project, on this machine or in the workspace, to find in the other"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectLocate(c)
+						return nil
+					},
+				},
+				{
+					Name:      "exec",
+					Usage:     "run a command inside a project's container",
+					ArgsUsage: "-- <command> [args...]",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+						cli.BoolFlag{Name: "tty, t", Usage: "allocate a terminal for the command"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectExec(c)
+						return nil
+					},
+				},
+				{
+					Name:  "shell",
+					Usage: "open an interactive shell inside a project's container",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectShell(c)
+						return nil
+					},
+				},
+				{
+					Name:    "remove",
+					Aliases: []string{"rm"},
+					Usage:   "unbind a project and remove its containers, images and volumes",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project id", Required: true},
+						cli.BoolFlag{Name: "delete-files", Usage: "also delete the local project files"},
+					},
+					Action: func(c *cli.Context) error {
+						ProjectRemove(c)
+						return nil
+					},
+				},
 				{
 					Name:    "connection",
 					Aliases: []string{"con"},
@@ -152,63 +500,123 @@ func Commands() {
 			Name:    "install",
 			Aliases: []string{"in"},
 			Usage:   "Pull pfe and performance images from dockerhub",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "tag, t",
-					Value: "latest",
-					Usage: "dockerhub image tag",
+					Usage: "dockerhub image tag (default: latest)",
 				},
 				cli.BoolFlag{
 					Name:  "json, j",
 					Usage: "ouput as JSON",
 				},
-			},
+				cli.BoolFlag{
+					Name:  "verify",
+					Usage: "require both images to be pinned to digests, and fail unless the pulled images match them",
+				},
+			}, imageFlags...),
 			Action: func(c *cli.Context) error {
 				InstallCommand(c)
 				return nil
 			},
-			/*
-				Subcommands: []cli.Command{
-					{
-						Name:    "remote",
-						Aliases: []string{"r"},
-						Usage:   "Install a remote deployment of Codewind",
-						Flags: []cli.Flag{
-							cli.StringFlag{Name: "namespace,n", Usage: "Kubernetes namespace", Required: true},
-							cli.StringFlag{Name: "session,ses", Usage: "Codewind session secret", Required: false},
-							cli.StringFlag{Name: "ingress,i", Usage: "Ingress Domain eg: 10.22.33.44.nip.io", Required: false},
-							cli.StringFlag{Name: "addkeycloak,k", Usage: "Deploy an instance of Keycloak", Required: false},
-							cli.StringFlag{Name: "kadminuser,au", Usage: "Keycloak admin user", Required: false},
-							cli.StringFlag{Name: "kadminpass,ap", Usage: "Keycloak admin password", Required: false},
-							cli.StringFlag{Name: "kdevuser,du", Usage: "Keycloak developer username to add", Required: false},
-							cli.StringFlag{Name: "kdevpass,dp", Usage: "Keycloak developer username initial password", Required: false},
-							cli.StringFlag{Name: "krealm,r", Usage: "Keycloak realm to setup", Required: false},
-							cli.StringFlag{Name: "kclient,c", Usage: "Keycloak client to setup", Required: false},
-						},
-						Action: func(c *cli.Context) error {
-							DoRemoteInstall(c)
-							return nil
-						},
+			Subcommands: []cli.Command{
+				{
+					Name:    "remote",
+					Aliases: []string{"r"},
+					Usage:   "Install a remote deployment of Codewind",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{Name: "namespace,n", Usage: "Kubernetes namespace, required unless --docker is given"},
+						cli.StringFlag{Name: "session,ses", Usage: "Codewind session secret", Required: false},
+						cli.StringFlag{Name: "ingress,i", Usage: "Ingress Domain eg: 10.22.33.44.nip.io", Required: false},
+						cli.StringFlag{Name: "addkeycloak,k", Usage: "Deploy an instance of Keycloak", Required: false},
+						cli.StringFlag{Name: "kadminuser,au", Usage: "Keycloak admin user", Required: false},
+						cli.StringFlag{Name: "kadminpass,ap", Usage: "Keycloak admin password", Required: false},
+						cli.StringFlag{Name: "kdevuser,du", Usage: "Keycloak developer username to add", Required: false},
+						cli.StringFlag{Name: "kdevpass,dp", Usage: "Keycloak developer username initial password", Required: false},
+						cli.StringFlag{Name: "krealm,r", Usage: "Keycloak realm to setup", Required: false},
+						cli.StringFlag{Name: "kclient,c", Usage: "Keycloak client to setup", Required: false},
+						cli.BoolFlag{Name: "resume", Usage: "Continue a failed install from the step that failed"},
+						cli.BoolFlag{Name: "skip-preflight", Usage: "Deploy without first checking the cluster version, permissions, default storage class and ingress controller"},
+						cli.StringFlag{Name: "export-manifests", Usage: "Write the Kubernetes manifests of the install to this directory rather than applying them, requires --ingress"},
+						cli.BoolFlag{Name: "openshift", Usage: "With --export-manifests, expose Codewind with OpenShift routes rather than ingresses"},
+						cli.StringFlag{Name: "workspace-id", Usage: "With --export-manifests, the workspace ID to name the objects after, so that exporting again updates the same install (default: generated)"},
+						cli.BoolFlag{Name: "openshift-oauth", Usage: "On OpenShift, log users in with the OAuth server of the cluster rather than deploying Keycloak"},
+						cli.BoolFlag{Name: "use-helm", Usage: "Deploy as a Helm release, installed the first time and upgraded after"},
+						cli.StringFlag{Name: "release", Value: remote.DefaultHelmReleaseName, Usage: "With --use-helm, the name of the Helm release"},
+						cli.BoolFlag{Name: "docker", Usage: "Deploy Keycloak, the gatekeeper and PFE on the Docker host with docker-compose rather than on Kubernetes, and add a connection to it"},
+						cli.StringFlag{Name: "host", Usage: "With --docker, the hostname or IP address clients reach the Docker host by (default: the hostname of this machine)"},
+						cli.StringFlag{Name: "gatekeeper-port", Value: remote.DefaultDockerGatekeeperPort, Usage: "With --docker, the host port to publish the gatekeeper on"},
+						cli.StringFlag{Name: "keycloak-port", Value: remote.DefaultDockerKeycloakPort, Usage: "With --docker, the host port to publish Keycloak on"},
+						cli.StringFlag{Name: "label", Usage: "With --docker, the label of the connection added (default: the host)"},
+						cli.StringFlag{Name: "workspace", Usage: "With --docker, the absolute path of the directory on the Docker host the projects are kept in (default: ~/codewind-remote-workspace)"},
+					}, kubeFlags...),
+					Action: func(c *cli.Context) error {
+						DoRemoteInstall(c)
+						return nil
 					},
-				},*/
+				},
+			},
+		},
+
+		{
+			Name:  "operator",
+			Usage: "Run in a cluster, installing, upgrading and removing Codewind as CodewindInstall resources change",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "namespace,n", Usage: "Only watch CodewindInstall resources in this namespace (default: every namespace)"},
+				cli.IntFlag{Name: "resync", Value: 300, Usage: "Seconds between reconciling every CodewindInstall again, which retries failed installs"},
+			},
+			Action: func(c *cli.Context) error {
+				OperatorCommand(c)
+				return nil
+			},
 		},
 
 		{
 			Name:  "start",
 			Usage: "Start the Codewind containers",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "tag, t",
-					Value: "latest",
-					Usage: "dockerhub image tag",
+					Usage: "dockerhub image tag (default: latest)",
 				},
 				cli.BoolFlag{
 					Name:  "debug, d",
 					Usage: "add debug output",
 				},
-			},
+				cli.StringFlag{
+					Name:  "pfe-port",
+					Usage: "host port to publish PFE on, saved for later starts (default: the first free port from 10000)",
+				},
+				cli.StringFlag{
+					Name:  "performance-port",
+					Usage: "host port to publish the performance dashboard on, saved for later starts (default: " + utils.DefaultPerformancePort + ")",
+				},
+				cli.StringFlag{
+					Name:  "host-interface",
+					Usage: "IPv4 address to publish the ports on, 0.0.0.0 for every interface, saved for later starts (default: " + utils.DefaultHostInterface + ")",
+				},
+				cli.StringFlag{
+					Name:  "workspace",
+					Usage: "host directory to keep the workspace in, saved for the profile for later starts (default: ~/codewind-data)",
+				},
+				cli.BoolFlag{
+					Name:  "workspace-volume",
+					Usage: "keep the workspace in a docker named volume rather than a host directory, saved for the profile for later starts",
+				},
+				cli.BoolFlag{
+					Name:  "no-wait",
+					Usage: "return once the containers are created, without waiting for them to be ready",
+				},
+				cli.BoolFlag{
+					Name:  "verify",
+					Usage: "fail unless the local images match the digests they are pinned to",
+				},
+				cli.BoolFlag{
+					Name:  "print-compose",
+					Usage: "print the generated docker-compose file without starting Codewind",
+				},
+			}, append(startPhaseFlags, imageFlags...)...),
 			Action: func(c *cli.Context) error {
-				StartCommand(c, tempFilePath, healthEndpoint)
+				StartCommand(c, healthEndpoint)
 				return nil
 			},
 		},
@@ -223,7 +631,38 @@ func Commands() {
 				},
 				cli.StringFlag{
 					Name:  "conid",
-					Usage: "ConnectionID to check",
+					Usage: "ConnectionID to check, or all to check every connection",
+				},
+				cli.IntFlag{
+					Name:  "certdays",
+					Value: connections.DefaultCertExpiryDays,
+					Usage: "warn when a remote ingress certificate expires within this many days",
+				},
+				cli.BoolFlag{
+					Name:  "deep",
+					Usage: "probe each component of a remote connection, exiting 2 if one is unreachable or 3 if a certificate needs attention",
+				},
+				cli.BoolFlag{
+					Name:  "watch",
+					Usage: "keep probing the health of the connection until interrupted, printing an event whenever it changes",
+				},
+				cli.StringFlag{
+					Name:  "interval",
+					Value: "30s",
+					Usage: "how often --watch probes the connection",
+				},
+				cli.StringFlag{
+					Name:  "webhook",
+					Usage: "URL to also post the --watch events to, as Slack-compatible JSON",
+				},
+				cli.BoolFlag{
+					Name:  "cached",
+					Usage: "report the state of a remote connection when it was last checked, without contacting it",
+				},
+				cli.StringFlag{
+					Name:  "timeout",
+					Value: "5s",
+					Usage: "how long to wait for each request when checking a connection or posting to --webhook",
 				},
 			},
 			Action: func(c *cli.Context) error {
@@ -232,6 +671,21 @@ func Commands() {
 			},
 		},
 
+		{
+			Name:  "adopt",
+			Usage: "Manage the running Codewind containers that cwctl did not start with the profile selected with --profile",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "container",
+					Usage: "name or ID of the PFE container to adopt, when several Codewind deployments are running",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				AdoptCommand(c)
+				return nil
+			},
+		},
+
 		{
 			Name:  "stop",
 			Usage: "Stop the running Codewind containers",
@@ -239,13 +693,27 @@ func Commands() {
 				StopCommand()
 				return nil
 			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "remote",
+					Usage: "Scale down the deployments of the remote Codewind a connection points to",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "the connection ID, label or alias", Required: true},
+						cli.StringFlag{Name: "namespace, n", Usage: "Kubernetes namespace Codewind is installed in (default: the namespace of the current context)"},
+					}, kubeFlags...),
+					Action: func(c *cli.Context) error {
+						StopRemoteCommand(c)
+						return nil
+					},
+				},
+			},
 		},
 
 		{
 			Name:  "stop-all",
 			Usage: "Stop all of the Codewind and project containers",
 			Action: func(c *cli.Context) error {
-				StopAllCommand()
+				StopAllCommand(c)
 				return nil
 			},
 		},
@@ -253,36 +721,130 @@ func Commands() {
 		{
 			Name:    "remove",
 			Aliases: []string{"rm"},
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "tag, t",
 					Usage: "dockerhub image tag",
 				},
-			},
+				cli.BoolFlag{
+					Name:  "unused",
+					Usage: "only remove the images, exited project containers, networks and volumes Codewind no longer uses, see gc",
+				},
+				cli.BoolFlag{Name: "images", Usage: "remove the Codewind and project images"},
+				cli.BoolFlag{Name: "containers", Usage: "stop and remove the Codewind containers of the profile, and for the default profile the project containers"},
+				cli.BoolFlag{Name: "network", Usage: "remove the docker networks of the profile"},
+				cli.BoolFlag{Name: "volumes", Usage: "remove the docker volumes of the profile, including a named workspace volume"},
+				cli.BoolFlag{Name: "keep-workspace", Usage: "keep the docker volumes of the profile"},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "list what would be removed without removing it",
+				},
+			}, imageFlags...),
 			Usage: "Remove Codewind/Project docker images and the codewind network",
 			Action: func(c *cli.Context) error {
+				if c.Bool("unused") {
+					GCCommand(c)
+				}
 				RemoveCommand(c)
 				return nil
 			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "remote",
+					Usage: "Remove the remote Codewind a connection points to, and the connection",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "the connection ID, label or alias", Required: true},
+						cli.StringFlag{Name: "namespace, n", Usage: "Kubernetes namespace Codewind is installed in (default: the namespace of the current context)"},
+						cli.BoolFlag{Name: "delete-volumes", Usage: "also delete the persistent volume claims holding the projects"},
+						cli.BoolFlag{Name: "keep-connection", Usage: "keep the connection to the removed Codewind"},
+						cli.BoolFlag{Name: "use-helm", Usage: "uninstall the Helm release Codewind was installed as with install remote --use-helm"},
+						cli.StringFlag{Name: "release", Value: remote.DefaultHelmReleaseName, Usage: "with --use-helm, the name of the Helm release"},
+						cli.BoolFlag{Name: "docker", Usage: "remove the Codewind installed on the Docker host with install remote --docker"},
+					}, kubeFlags...),
+					Action: func(c *cli.Context) error {
+						RemoveRemoteCommand(c)
+						return nil
+					},
+				},
+			},
 		},
 
 		{
-			Name:  "templates",
-			Usage: "Manage project templates",
-			Subcommands: []cli.Command{
-				{
-					Name:    "list",
-					Aliases: []string{"ls"},
-					Usage:   "List available templates",
-					Flags: []cli.Flag{
-						cli.StringFlag{
-							Name:  "projectStyle",
+			Name:  "gc",
+			Usage: "Remove the images, exited project containers, networks and volumes Codewind no longer uses",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "tag, t",
+					Usage: "tag of the Codewind images to keep (default: the saved imageTag, or latest)",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "list what would be removed and how much space it takes, without removing it",
+				},
+			}, imageFlags...),
+			Action: func(c *cli.Context) error {
+				GCCommand(c)
+				return nil
+			},
+		},
+
+		{
+			Name:  "images",
+			Usage: "Manage the local Codewind images",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the Codewind and project images, and the containers using them",
+					Action: func(c *cli.Context) error {
+						ImagesListCommand(c)
+						return nil
+					},
+				},
+			},
+		},
+
+		{
+			Name:  "doctor",
+			Usage: "Check the prerequisites for installing and starting Codewind",
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "certdays",
+					Value: connections.DefaultCertExpiryDays,
+					Usage: "warn when a remote ingress certificate expires within this many days",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				DoctorCommand(c)
+				return nil
+			},
+		},
+
+		{
+			Name:  "templates",
+			Usage: "Manage project templates",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List available templates",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "projectStyle",
 							Usage: "Filter by project style",
 						},
 						cli.BoolFlag{
 							Name:  "showEnabledOnly",
 							Usage: "Filter by whether a template is enabled or not",
 						},
+						cli.StringFlag{
+							Name:  "conid",
+							Usage: "List the templates of this connection rather than the local Codewind, or all to list those of every connection",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Usage: "Print the templates as a wide table, json or yaml, overriding the global --output (default: json)",
+						},
 					},
 					Action: func(c *cli.Context) error {
 						ListTemplates(c)
@@ -326,8 +888,25 @@ func Commands() {
 								cli.StringFlag{
 									Name:  "name",
 									Value: "",
-									Usage: "Name of the template repo",
+									Usage: "Name of the template repo, which no other repo may have",
+								},
+								cli.BoolFlag{
+									Name:  "skip-validation",
+									Usage: "add the repo without fetching and checking its index, e.g. when offline",
 								},
+								cli.StringFlag{
+									Name:  "provider",
+									Value: utils.TemplateProviderURL,
+									Usage: "how the index is fetched: url, basic or apikey for Artifactory and Nexus, or s3",
+								},
+								cli.StringFlag{Name: "username", Usage: "username for the basic provider"},
+								cli.StringFlag{Name: "password", EnvVar: "CW_TEMPLATE_REPO_PASSWORD", Usage: "password for the basic provider"},
+								cli.StringFlag{Name: "api-key", EnvVar: "CW_TEMPLATE_REPO_API_KEY", Usage: "API key for the apikey provider"},
+								cli.StringFlag{Name: "api-key-header", Usage: "header the API key is sent in (default: " + utils.DefaultAPIKeyHeader + ")"},
+								cli.StringFlag{Name: "access-key-id", Usage: "AWS access key ID for the s3 provider (default: AWS_ACCESS_KEY_ID when the index is fetched)"},
+								cli.StringFlag{Name: "secret-access-key", Usage: "AWS secret access key for the s3 provider (default: AWS_SECRET_ACCESS_KEY when the index is fetched)"},
+								cli.StringFlag{Name: "session-token", Usage: "AWS session token for temporary s3 access keys"},
+								cli.StringFlag{Name: "region", Usage: "AWS region of the s3 bucket (default: AWS_REGION, or the region in the URL)"},
 							},
 							Action: func(c *cli.Context) error {
 								AddTemplateRepo(c)
@@ -335,14 +914,19 @@ func Commands() {
 							},
 						},
 						{
-							Name:    "remove",
-							Aliases: []string{"rm"},
-							Usage:   "Remove a template repo",
+							Name:      "remove",
+							Aliases:   []string{"rm"},
+							Usage:     "Remove a template repo, given by its name or URL",
+							ArgsUsage: "[name or URL]",
 							Flags: []cli.Flag{
 								cli.StringFlag{
 									Name:  "url",
 									Usage: "URL of the template repo",
 								},
+								cli.StringFlag{
+									Name:  "name",
+									Usage: "Name of the template repo",
+								},
 							},
 							Action: func(c *cli.Context) error {
 								DeleteTemplateRepo(c)
@@ -383,16 +967,29 @@ func Commands() {
 					Flags: []cli.Flag{
 						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: false},
 						cli.StringFlag{Name: "realm,r", Usage: "Application realm", Required: false},
-						cli.StringFlag{Name: "username,u", Usage: "Account Username", Required: true},
+						cli.StringFlag{Name: "username,u", Usage: "Account Username, required by the password flow", Required: false},
 						cli.StringFlag{Name: "password,p", Usage: "Account Password", Required: false},
 						cli.StringFlag{Name: "client,c", Usage: "Client", Required: false},
-						cli.StringFlag{Name: "conid", Usage: "Connection ID", Required: false},
+						cli.StringFlag{Name: "conid", Usage: "Connection ID, discovers the host, realm and client from its gatekeeper", Required: false},
+						cli.StringFlag{Name: "flow", Value: "password", Usage: "Login flow, password or authcode to log in through the browser", Required: false},
 					},
 					Action: func(c *cli.Context) error {
 						SecurityTokenGet(c)
 						return nil
 					},
 				},
+				{
+					Name:  "logout",
+					Usage: "Revoke the session of a connection and remove its cached tokens from the keyring",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "Connection ID to log out of", Required: true},
+						cli.StringFlag{Name: "username,u", Usage: "Also remove the saved password of this user", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityTokenLogout(c)
+						return nil
+					},
+				},
 			},
 		},
 		{
@@ -425,6 +1022,29 @@ func Commands() {
 						SecurityKeyValidate(c)
 						return nil
 					},
+				}, {
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the passwords and tokens kept in the keyring for connections, without the secrets",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "Only list those of this connection"},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityKeyringList(c)
+						return nil
+					},
+				}, {
+					Name:    "delete",
+					Aliases: []string{"rm"},
+					Usage:   "Delete the passwords and tokens kept in the keyring for a connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "Connection ID (see the connections cmd)", Required: true},
+						cli.StringFlag{Name: "username,u", Usage: "Only delete those of this user"},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityKeyringDelete(c)
+						return nil
+					},
 				},
 			},
 		},
@@ -447,6 +1067,39 @@ func Commands() {
 						return nil
 					},
 				},
+				{
+					Name:  "export",
+					Usage: "Export the clients, roles, groups and users of a realm to a file (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "file,f", Usage: "The file to write the realm to", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityRealmExport(c)
+						return nil
+					},
+				},
+				{
+					Name:  "import",
+					Usage: "Create or add to a realm from an exported file (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name, defaults to the realm in the file", Required: false},
+						cli.StringFlag{Name: "file,f", Usage: "The exported realm file", Required: true},
+						cli.BoolFlag{Name: "overwrite", Usage: "Replace clients, roles, groups and users which already exist"},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityRealmImport(c)
+						return nil
+					},
+				},
 			},
 		}, {
 			Name:    "secclient",
@@ -561,6 +1214,144 @@ func Commands() {
 						SecurityUserSetPassword(c)
 						return nil
 					},
+				}, {
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the users of a realm (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "search,s", Usage: "Only list users whose username, email or name contains this", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityUserList(c)
+						return nil
+					},
+				}, {
+					Name:    "delete",
+					Aliases: []string{"rm"},
+					Usage:   "Delete a user (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "name,n", Usage: "Username to delete", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityUserDelete(c)
+						return nil
+					},
+				}, {
+					Name:  "import",
+					Usage: "Create the users of a CSV file with their initial passwords and roles (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "file,f", Usage: "CSV file with a header row naming its columns: username, password and optionally email, firstName, lastName and roles", Required: true},
+						cli.BoolFlag{Name: "temporary", Usage: "Require the users to change their initial password when they first log in"},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityUserImport(c)
+						return nil
+					},
+				}, {
+					Name:  "sync",
+					Usage: "Import the users of a realm from its user federation, such as LDAP (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.BoolFlag{Name: "full", Usage: "Import every user rather than only those changed since the last sync"},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityUserSync(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:    "secrole",
+			Aliases: []string{"sro"},
+			Usage:   "Manage keycloak realm roles",
+			Subcommands: []cli.Command{
+				{
+					Name:    "create",
+					Aliases: []string{"c"},
+					Usage:   "Create a new realm role (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "role", Usage: "Role name to create", Required: true},
+						cli.StringFlag{Name: "description,d", Usage: "Role description", Required: false},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityRoleCreate(c)
+						return nil
+					},
+				}, {
+					Name:    "add",
+					Aliases: []string{"a"},
+					Usage:   "Grant a realm role to a user (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "name,n", Usage: "Username to grant the role to", Required: true},
+						cli.StringFlag{Name: "role", Usage: "Role name to grant", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityRoleAdd(c)
+						return nil
+					},
+				}, {
+					Name:    "remove",
+					Aliases: []string{"rm"},
+					Usage:   "Revoke a realm role from a user (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "name,n", Usage: "Username to revoke the role from", Required: true},
+						cli.StringFlag{Name: "role", Usage: "Role name to revoke", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityRoleRemove(c)
+						return nil
+					},
+				}, {
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the realm roles of a user (requires either admin_token or username/password)",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "host", Usage: "URL or ingress to Keycloak service", Required: true},
+						cli.StringFlag{Name: "realm,r", Usage: "Realm name", Required: true},
+						cli.StringFlag{Name: "accesstoken,t", Usage: "Admin access_token", Required: false},
+						cli.StringFlag{Name: "username,u", Usage: "Admin Username", Required: false},
+						cli.StringFlag{Name: "password,p", Usage: "Admin Password", Required: false},
+						cli.StringFlag{Name: "name,n", Usage: "Username to list the roles of", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						SecurityRoleList(c)
+						return nil
+					},
 				},
 			},
 		},
@@ -577,6 +1368,9 @@ func Commands() {
 					Flags: []cli.Flag{
 						cli.StringFlag{Name: "label", Usage: "A displayable name", Required: true},
 						cli.StringFlag{Name: "url", Usage: "The ingress URL of Codewind gatekeeper", Required: true},
+						cli.StringFlag{Name: "projectprefix", Usage: "A prefix added to the names of projects bound to this connection", Required: false},
+						cli.BoolFlag{Name: "insecure", Usage: "Disable certificate checking for this connection only"},
+						cli.StringFlag{Name: "cacert", Usage: "Path to the PEM encoded certificate of the private CA which signed the connection's certificates"},
 					},
 					Action: func(c *cli.Context) error {
 						ConnectionAddToList(c)
@@ -588,13 +1382,43 @@ func Commands() {
 					Aliases: []string{"g"},
 					Usage:   "Get a connection config by id",
 					Flags: []cli.Flag{
-						cli.StringFlag{Name: "conid", Usage: "Connection ID to retrieve", Required: true},
+						cli.StringFlag{Name: "conid", Usage: "Connection ID, label or alias to retrieve", Required: true},
 					},
 					Action: func(c *cli.Context) error {
 						ConnectionGetByID(c)
 						return nil
 					},
 				},
+				{
+					Name:    "update",
+					Aliases: []string{"u"},
+					Usage:   "Update the label, URL or settings of a connection, keeping its ID",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "The reference ID of the connection to be updated", Required: true},
+						cli.StringFlag{Name: "label", Usage: "A new displayable name", Required: false},
+						cli.StringFlag{Name: "url", Usage: "A new ingress URL of Codewind gatekeeper", Required: false},
+						cli.StringFlag{Name: "projectprefix", Usage: "A new project name prefix, or \"\" to remove it", Required: false},
+						cli.BoolFlag{Name: "insecure", Usage: "Disable certificate checking for this connection, or --insecure=false to enable it"},
+						cli.StringFlag{Name: "cacert", Usage: "Path to the PEM encoded certificate of the private CA which signed the connection's certificates, or --cacert \"\" to remove it"},
+					},
+					Action: func(c *cli.Context) error {
+						ConnectionUpdate(c)
+						return nil
+					},
+				},
+				{
+					Name:  "rename",
+					Usage: "Change the label of a connection, keeping its ID",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Usage: "The ID, label or alias of the connection to be renamed", Required: true},
+						cli.StringFlag{Name: "label", Usage: "The new displayable name, which must not be used by another connection", Required: true},
+						cli.BoolFlag{Name: "alias", Usage: "Keep the old label as an alias that still resolves to the connection"},
+					},
+					Action: func(c *cli.Context) error {
+						ConnectionRename(c)
+						return nil
+					},
+				},
 				{
 					Name:    "remove",
 					Aliases: []string{"rm"},
@@ -612,7 +1436,55 @@ func Commands() {
 					Aliases: []string{"ls"},
 					Usage:   "List known connections",
 					Action: func(c *cli.Context) error {
-						ConnectionListAll()
+						ConnectionListAll(c)
+						return nil
+					},
+				},
+				{
+					Name:  "env",
+					Usage: "Show the auth server, realm, client and version the gatekeeper at a URL reports, without adding a connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "url", Usage: "The ingress URL of Codewind gatekeeper", Required: true},
+						cli.BoolFlag{Name: "insecure", Usage: "Disable certificate checking for the gatekeeper"},
+						cli.StringFlag{Name: "cacert", Usage: "Path to the PEM encoded certificate of the private CA which signed the gatekeeper's certificates"},
+					},
+					Action: func(c *cli.Context) error {
+						ConnectionEnv(c)
+						return nil
+					},
+				},
+				{
+					Name:  "capabilities",
+					Usage: "Show the optional features supported by the Codewind of a connection",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID to query"},
+						cli.BoolFlag{Name: "refresh", Usage: "Ask Codewind again instead of using the cached result"},
+					},
+					Action: func(c *cli.Context) error {
+						ConnectionCapabilities(c)
+						return nil
+					},
+				},
+				{
+					Name:  "export",
+					Usage: "Export remote connections to a file that can be shared",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "file, f", Usage: "The file to write the connections to", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						ConnectionExport(c)
+						return nil
+					},
+				},
+				{
+					Name:  "import",
+					Usage: "Import connections from an exported file, merging them by URL",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "file, f", Usage: "The file to read the connections from", Required: true},
+						cli.BoolFlag{Name: "force", Usage: "Replace existing connections with the same URL without prompting"},
+					},
+					Action: func(c *cli.Context) error {
+						ConnectionImport(c)
 						return nil
 					},
 				},
@@ -620,7 +1492,298 @@ func Commands() {
 					Name:  "reset",
 					Usage: "Resets the connections list",
 					Action: func(c *cli.Context) error {
-						ConnectionResetList()
+						ConnectionResetList(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:      "loglevel",
+			Usage:     "Show or set the log level of cwctl and Codewind",
+			ArgsUsage: "[error|warn|info|debug|trace]",
+			BashComplete: func(c *cli.Context) {
+				printWords(utils.LogLevels)
+			},
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to update"},
+			},
+			Action: func(c *cli.Context) error {
+				LogLevelCommand(c)
+				return nil
+			},
+		},
+		{
+			Name:  "config",
+			Usage: "Manage the saved cwctl defaults",
+			Subcommands: []cli.Command{
+				{
+					Name:      "set",
+					Usage:     "Save a default, or restore the built in default when no value is given",
+					ArgsUsage: "<key> [value]",
+					Action: func(c *cli.Context) error {
+						ConfigSetCommand(c)
+						return nil
+					},
+				},
+				{
+					Name:      "get",
+					Usage:     "Print a saved default",
+					ArgsUsage: "<key>",
+					Action: func(c *cli.Context) error {
+						ConfigGetCommand(c)
+						return nil
+					},
+				},
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "Print every default, and whether it comes from the environment, the config file or is built in",
+					Action: func(c *cli.Context) error {
+						ConfigListCommand(c)
+						return nil
+					},
+				},
+				{
+					Name:  "migrate",
+					Usage: "Migrate the connections, config and project connection files to the current schema, backing each up first",
+					Flags: []cli.Flag{
+						cli.BoolFlag{Name: "check", Usage: "List the files waiting for migration without changing them, exiting 1 when there are any"},
+					},
+					Action: func(c *cli.Context) error {
+						ConfigMigrateCommand(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "apply",
+			Usage: "Set up connections, templates, secrets and projects from a file",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "file, f", Usage: "The YAML or JSON environment file", Required: true},
+				cli.BoolFlag{Name: "dry-run", Usage: "Show the changes without making them"},
+				cli.BoolFlag{Name: "prune", Usage: "Also delete connections and template repositories the file does not declare"},
+			},
+			Action: func(c *cli.Context) error {
+				ApplyCommand(c)
+				return nil
+			},
+		},
+		{
+			Name:  "loadtest",
+			Usage: "Run load tests against a project and fetch their metrics",
+			Subcommands: []cli.Command{
+				{
+					Name:  "start",
+					Usage: "Start a load run, configured in the project's load-test/config.json",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project ID", Required: true},
+						cli.StringFlag{Name: "description, d", Usage: "a description of the run, shown with its results"},
+					},
+					Action: func(c *cli.Context) error {
+						LoadTestStart(c)
+						return nil
+					},
+				},
+				{
+					Name:  "cancel",
+					Usage: "Stop the load run in progress",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project ID", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						LoadTestCancel(c)
+						return nil
+					},
+				},
+				{
+					Name:  "results",
+					Usage: "Print the response time, throughput, CPU and memory of each load run",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "id, i", Usage: "the project ID", Required: true},
+						cli.StringFlag{Name: "format", Value: "table", Usage: "table, json or csv"},
+						cli.StringFlag{Name: "output, o", Usage: "write the results to this file instead of the terminal"},
+					},
+					Action: func(c *cli.Context) error {
+						LoadTestResults(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "listen",
+			Usage: "Print the events Codewind sends, as one line of JSON per event",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to listen to"},
+				cli.StringSliceFlag{Name: "event", Usage: "only print events with this name or category (status, build, logs, project, other), may be repeated"},
+				cli.StringFlag{Name: "project", Usage: "only print events for this project ID"},
+				cli.BoolFlag{Name: "once", Usage: "exit when the connection is lost instead of reconnecting"},
+			},
+			Action: func(c *cli.Context) error {
+				ListenCommand(c)
+				return nil
+			},
+		},
+		{
+			Name:    "extensions",
+			Aliases: []string{"ext"},
+			Usage:   "Manage the project extensions installed in Codewind",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the installed extensions",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to query"},
+					},
+					Action: func(c *cli.Context) error {
+						ExtensionsList(c)
+						return nil
+					},
+				},
+				{
+					Name:  "install",
+					Usage: "Upload an extension zip",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to update"},
+						cli.StringFlag{Name: "file, f", Usage: "Path of the extension zip", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						ExtensionsInstall(c)
+						return nil
+					},
+				},
+				{
+					Name:    "remove",
+					Aliases: []string{"rm"},
+					Usage:   "Remove an extension",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to update"},
+						cli.StringFlag{Name: "name", Usage: "Name of the extension", Required: true},
+					},
+					Action: func(c *cli.Context) error {
+						ExtensionsRemove(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:    "registrysecrets",
+			Aliases: []string{"rs"},
+			Usage:   "Manage the image registry credentials Codewind uses to build projects",
+			Subcommands: []cli.Command{
+				{
+					Name:  "add",
+					Usage: "Add credentials for an image registry",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to update"},
+						cli.StringFlag{Name: "address", Usage: "Address of the image registry", Required: true},
+						cli.StringFlag{Name: "username", Usage: "Username for the image registry", Required: true},
+						cli.StringFlag{Name: "password", Usage: "Password for the image registry", Required: true},
+						cli.BoolFlag{Name: "kube", Usage: "Also create a docker-registry secret in the Kubernetes namespace of a remote connection"},
+						cli.StringFlag{Name: "namespace", Usage: "Kubernetes namespace for the docker-registry secret (default: current namespace)"},
+					},
+					Action: func(c *cli.Context) error {
+						RegistrySecretsAdd(c)
+						return nil
+					},
+				},
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the image registries Codewind has credentials for",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to query"},
+					},
+					Action: func(c *cli.Context) error {
+						RegistrySecretsList(c)
+						return nil
+					},
+				},
+				{
+					Name:    "remove",
+					Aliases: []string{"rm"},
+					Usage:   "Remove the credentials for an image registry",
+					Flags: []cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "Connection ID of the Codewind to update"},
+						cli.StringFlag{Name: "address", Usage: "Address of the image registry", Required: true},
+						cli.BoolFlag{Name: "kube", Usage: "Also delete the docker-registry secret from the Kubernetes namespace of a remote connection"},
+						cli.StringFlag{Name: "namespace", Usage: "Kubernetes namespace of the docker-registry secret (default: current namespace)"},
+					},
+					Action: func(c *cli.Context) error {
+						RegistrySecretsRemove(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:            "redo",
+			Usage:           "Run a command again with the flags it last succeeded with",
+			ArgsUsage:       "[command] [extra flags]",
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				RedoCommand(c)
+				return nil
+			},
+		},
+		{
+			Name:      "completion",
+			Usage:     "Print a shell completion script",
+			ArgsUsage: "bash|zsh|fish|powershell",
+			BashComplete: func(c *cli.Context) {
+				printWords(completionShells)
+			},
+			Action: func(c *cli.Context) error {
+				CompletionCommand(c)
+				return nil
+			},
+		},
+		{
+			Name:  "version",
+			Usage: "Print the version of cwctl, and of the Codewind components of a connection",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "conid", Usage: "the connection ID, label or alias whose component versions to print"},
+			},
+			Action: func(c *cli.Context) error {
+				VersionCommand(c)
+				return nil
+			},
+		},
+		{
+			Name:  "errors",
+			Usage: "Describe the errors cwctl reports",
+			Subcommands: []cli.Command{
+				{
+					Name:    "list",
+					Aliases: []string{"ls"},
+					Usage:   "List the error codes and the exit code of each",
+					Action: func(c *cli.Context) error {
+						ErrorsList(c)
+						return nil
+					},
+				},
+			},
+		},
+		{
+			Name:  "logs",
+			Usage: "Show the logs of Codewind itself, to debug failures of its server",
+			Subcommands: []cli.Command{
+				{
+					Name:  "pfe",
+					Usage: "Show the logs of the PFE, performance and gatekeeper containers, or pods of a remote connection",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{Name: "conid", Value: defaultConnection, Usage: "the connection whose Codewind to show the logs of"},
+						cli.BoolFlag{Name: "follow, f", Usage: "keep streaming new log lines until interrupted"},
+						cli.StringFlag{Name: "since", Usage: "only show the lines logged within this duration, e.g. 30m or 1h"},
+						cli.StringSliceFlag{Name: "component, c", Usage: "only show the logs of this component (pfe, performance or gatekeeper), may be repeated"},
+						cli.StringFlag{Name: "namespace, n", Usage: "Kubernetes namespace a remote Codewind is installed in (default: the namespace of the current context)"},
+					}, kubeFlags...),
+					Action: func(c *cli.Context) error {
+						LogsPFECommand(c)
 						return nil
 					},
 				},
@@ -631,21 +1794,91 @@ func Commands() {
 			Aliases: []string{"up"},
 			Usage:   "Upgrade projects",
 			Flags: []cli.Flag{
-				cli.StringFlag{Name: "workspace, ws", Usage: "the workspace directory to upgrade, location of projects", Required: true},
+				cli.StringFlag{Name: "workspace, ws", Usage: "the workspace directory to upgrade, location of projects (required)"},
 			},
 			Action: func(c *cli.Context) error {
 				UpgradeProjects(c)
 				return nil
 			},
+			Subcommands: []cli.Command{
+				{
+					Name:  "deployment",
+					Usage: "Upgrade the local Codewind to new images, rolling back to the previous images if they fail to start",
+					Flags: append([]cli.Flag{
+						cli.StringFlag{Name: "tag, t", Usage: "dockerhub image tag to upgrade to", Required: true},
+						cli.StringFlag{Name: "pfe-port", Usage: "host port to publish PFE on (default: the saved pfePort, or the first free port from 10000)"},
+						cli.StringFlag{Name: "performance-port", Usage: "host port to publish the performance dashboard on (default: the saved performancePort, or " + utils.DefaultPerformancePort + ")"},
+						cli.StringFlag{Name: "host-interface", Usage: "IPv4 address to publish the ports on (default: the saved hostInterface, or " + utils.DefaultHostInterface + ")"},
+						cli.BoolFlag{Name: "verify", Usage: "fail unless the new images are pinned to digests"},
+					}, append(startPhaseFlags, imageFlags...)...),
+					Action: func(c *cli.Context) error {
+						UpgradeDeploymentCommand(c, healthEndpoint)
+						return nil
+					},
+				},
+			},
 		},
 	}
 
+	// Save the arguments of each successful command for redo
+	invocationArgs = os.Args[1:]
+	recordInvocations(app.Commands)
+
+	// Complete commands, flags, connection IDs and project IDs
+	addCompletions(app.Commands)
+	app.BashComplete = completeCommand
+
 	app.Before = func(c *cli.Context) error {
-		// Handle Global flag to disable certificate checking
-		if c.GlobalBool("insecure") {
-			http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		// Report failures as JSON on stderr when --json is given
+		errors.SetJSONOutput(c.GlobalBool("json"))
+		// Settings given on the command line take precedence over the environment, which takes precedence over
+		// the saved config
+		cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+		if configErr != nil {
+			// Commands which need the config report the broken file, so that config set can still repair it
+			cliConfig = &cliconfig.CLIConfig{}
+			cliConfig.ApplyEnv()
+		}
+		flagProxies := utils.ProxySettings{
+			HTTPProxy:  c.GlobalString("proxy"),
+			HTTPSProxy: c.GlobalString("proxy"),
+			NoProxy:    c.GlobalString("no-proxy"),
+		}
+		if c.GlobalString("https-proxy") != "" {
+			flagProxies.HTTPSProxy = c.GlobalString("https-proxy")
+		}
+		configProxies := utils.ProxySettings{HTTPProxy: cliConfig.HTTPProxy, HTTPSProxy: cliConfig.HTTPSProxy, NoProxy: cliConfig.NoProxy}
+		utils.ApplyProxySettings(configProxies.Merge(utils.GetProxySettings()).Merge(flagProxies))
+		// Certificate checks are only skipped for insecure connections, or all connections with --insecure
+		connections.UseConnectionTransport(c.GlobalBool("insecure"))
+		if c.GlobalBool("trace-http") || c.GlobalBool("trace-body") || c.GlobalString("trace-file") != "" {
+			traceOut := os.Stderr
+			if traceFile := c.GlobalString("trace-file"); traceFile != "" {
+				file, err := os.OpenFile(traceFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+				if err != nil {
+					return err
+				}
+				traceOut = file
+			}
+			utils.TraceHTTP(traceOut, c.GlobalBool("trace-body"))
+		}
+		err := security.SetSecretBackend(c.GlobalString("secret-backend"))
+		if err != nil {
+			return err
+		}
+		utils.SetNonInteractive(c.GlobalBool("non-interactive"))
+		err = utils.SetProfile(c.GlobalString("profile"))
+		if err != nil {
+			return err
+		}
+		logLevel := c.GlobalString("loglevel")
+		if logLevel == "" {
+			logLevel = utils.DefaultLogLevel
+			if cliConfig.LogLevel != "" {
+				logLevel = cliConfig.LogLevel
+			}
 		}
-		return nil
+		return utils.SetLogLevel(logLevel)
 	}
 
 	// Start application
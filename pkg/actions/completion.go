@@ -0,0 +1,150 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/project"
+	"github.com/urfave/cli"
+)
+
+// completionShells are the shells `cwctl completion` can generate scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// Each script asks cwctl itself for suggestions, using the hidden --generate-bash-completion flag
+const bashCompletion = `_cwctl_completions() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local words=$("${COMP_WORDS[0]}" "${COMP_WORDS[@]:1:$COMP_CWORD-1}" --generate-bash-completion 2>/dev/null)
+    COMPREPLY=($(compgen -W "${words}" -- "${cur}"))
+}
+complete -o default -F _cwctl_completions cwctl
+`
+
+const zshCompletion = `autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+const fishCompletion = `function __cwctl_complete
+    set -l tokens (commandline -opc)
+    $tokens[1] $tokens[2..-1] --generate-bash-completion 2>/dev/null
+end
+complete -c cwctl -f -a '(__cwctl_complete)'
+`
+
+const powershellCompletion = `Register-ArgumentCompleter -Native -CommandName cwctl -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -ne '') { $words = @($words | Select-Object -SkipLast 1) }
+    & cwctl @words --generate-bash-completion 2>$null | Where-Object { $_ -like "$wordToComplete*" } | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+// CompletionCommand : Print the completion script for the given shell
+func CompletionCommand(c *cli.Context) {
+	shell := strings.ToLower(c.Args().First())
+	switch shell {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	case "powershell":
+		fmt.Print(powershellCompletion)
+	default:
+		exitWithUsageError("Unsupported shell '" + shell + "', must be one of: " + strings.Join(completionShells, ", "))
+	}
+	os.Exit(0)
+}
+
+// addCompletions : Use completeCommand for every command and subcommand
+func addCompletions(commands []cli.Command) {
+	for i := range commands {
+		if commands[i].BashComplete == nil {
+			commands[i].BashComplete = completeCommand
+		}
+		addCompletions(commands[i].Subcommands)
+	}
+}
+
+// completeCommand : Print the suggestions for the word following the command line typed so far.
+// Connection IDs, project IDs and log levels are read locally so completion works offline
+func completeCommand(c *cli.Context) {
+	// The completion flag is always last, so the word before it is the last one typed
+	if len(os.Args) > 2 {
+		switch os.Args[len(os.Args)-2] {
+		case "--conid":
+			printConnectionIDs()
+			return
+		case "--id", "-i":
+			printProjectIDs()
+			return
+		case "--loglevel":
+			printWords(utils.LogLevels)
+			return
+		}
+	}
+
+	// A command with subcommands is run as its own app
+	flags := c.App.Flags
+	if c.Command.Name != "" {
+		flags = c.Command.Flags
+	} else {
+		for _, command := range c.App.Commands {
+			if !command.Hidden {
+				printWords(command.Names())
+			}
+		}
+	}
+	for _, flag := range flags {
+		for _, name := range strings.Split(flag.GetName(), ",") {
+			name = strings.TrimSpace(name)
+			if len(name) == 1 {
+				fmt.Println("-" + name)
+			} else if name != "" {
+				fmt.Println("--" + name)
+			}
+		}
+	}
+}
+
+func printConnectionIDs() {
+	allConnections, err := connections.GetAllConnections()
+	if err != nil {
+		return
+	}
+	for _, connection := range allConnections {
+		fmt.Println(connection.ID)
+	}
+}
+
+func printProjectIDs() {
+	projectIDs, err := project.ListProjectIDs()
+	if err != nil {
+		return
+	}
+	printWords(projectIDs)
+}
+
+func printWords(words []string) {
+	for _, word := range words {
+		if word != "" {
+			fmt.Println(word)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+	"github.com/eclipse/codewind-installer/pkg/utils/project"
+	"github.com/eclipse/codewind-installer/pkg/utils/telemetry"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// ConfigSetCommand : Save a cwctl default, an empty value restores the built in default
+func ConfigSetCommand(c *cli.Context) {
+	key := c.Args().Get(0)
+	value := c.Args().Get(1)
+	if key == "" {
+		exitWithUsageError("A config key is required, must be one of: " + strings.Join(cliconfig.Keys(), ", "))
+	}
+	if key == "loglevel" && value != "" && !utils.IsValidLogLevel(value) {
+		exitWithUsageError("Invalid log level '" + value + "', must be one of: " + strings.Join(utils.LogLevels, ", "))
+	}
+	if key == "pfeHost" && value != "" && !utils.IsValidPFEHost(value) {
+		exitWithUsageError("Invalid PFE host '" + value + "', must be a hostname or IP address without a scheme or port")
+	}
+	if key == "pfeRoute" && value != "" && !utils.IsValidPFERoute(value) {
+		exitWithUsageError("Invalid PFE route '" + value + "', must be one of: " + strings.Join(utils.PFERoutes, ", "))
+	}
+	if key == "syncMaxFileSize" && value != "" {
+		if size, err := strconv.Atoi(value); err != nil || size < 1 {
+			exitWithUsageError("Invalid sync max file size '" + value + "', must be a whole number of MB greater than 0")
+		}
+	}
+	if (key == "pfePort" || key == "performancePort") && value != "" && !utils.IsValidPort(value) {
+		exitWithUsageError("Invalid port '" + value + "', must be a number between 1 and 65535")
+	}
+	if key == "hostInterface" && value != "" && !utils.IsValidHostInterface(value) {
+		exitWithUsageError("Invalid host interface '" + value + "', must be an IPv4 address such as 127.0.0.1, or 0.0.0.0 for every interface")
+	}
+	if (key == "pfeImageDigest" || key == "performanceImageDigest") && value != "" && !utils.IsValidDigest(value) {
+		exitWithUsageError("Invalid image digest '" + value + "', must be sha256: followed by 64 lower case hex characters")
+	}
+	if key == "syncConcurrency" && value != "" {
+		if concurrency, err := strconv.Atoi(value); err != nil || concurrency < 1 || concurrency > project.MaxSyncConcurrency {
+			exitWithUsageError("Invalid sync concurrency '" + value + "', must be a whole number from 1 to " + strconv.Itoa(project.MaxSyncConcurrency))
+		}
+	}
+	if (key == "httpProxy" || key == "httpsProxy") && value != "" {
+		if proxyURL, err := url.Parse(value); err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			exitWithUsageError("Invalid proxy '" + value + "', must be a URL such as http://proxy.example.com:3128")
+		}
+	}
+	if key == "telemetry" && value != "" && value != "on" && value != "off" {
+		exitWithUsageError("Invalid telemetry setting '" + value + "', must be on or off")
+	}
+	if key == "defaultConnection" && value != "" {
+		// Save the ID, so that the default still works if the label is changed
+		conID, conErr := connections.ResolveConnectionID(value)
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+		value = conID
+	}
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	configErr = cliConfig.Set(key, value)
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	configErr = cliconfig.SaveConfig(cliConfig)
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	if key == "telemetry" && value != "on" {
+		// Nothing more is sent once the user opts out, including what was spooled
+		err := telemetry.Discard()
+		if err != nil {
+			logr.Debugln("Unable to delete the spooled usage reports: " + err.Error())
+		}
+	}
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(map[string]string{key: value})
+		fmt.Println(string(response))
+	} else {
+		fmt.Println("Set " + key + " to '" + value + "'")
+	}
+	exitSuccess()
+}
+
+// ConfigGetCommand : Print a saved cwctl default
+func ConfigGetCommand(c *cli.Context) {
+	key := c.Args().Get(0)
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	value, configErr := cliConfig.Get(key)
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(map[string]string{key: value})
+		fmt.Println(string(response))
+	} else {
+		fmt.Println(value)
+	}
+	exitSuccess()
+}
+
+// ConfigListCommand : Print every cwctl default, and whether it is set in the environment, the config file or built in
+func ConfigListCommand(c *cli.Context) {
+	saved, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	effective, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	savedValues := saved.Values()
+	effectiveValues := effective.Values()
+	entries := []configEntry{}
+	for _, key := range cliconfig.Keys() {
+		entry := configEntry{Key: key, Value: effectiveValues[key], Source: "default", EnvVar: cliconfig.EnvVar(key)}
+		if effectiveValues[key] != savedValues[key] {
+			entry.Source = "env"
+		} else if savedValues[key] != "" {
+			entry.Source = "config"
+		}
+		entries = append(entries, entry)
+	}
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(entries)
+		fmt.Println(string(response))
+		exitSuccess()
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE\tENV VAR")
+	for _, entry := range entries {
+		fmt.Fprintln(w, entry.Key+"\t"+entry.Value+"\t"+entry.Source+"\t"+entry.EnvVar)
+	}
+	w.Flush()
+	exitSuccess()
+}
+
+// configEntry : A cwctl default as printed by config list. Source is env, config or default
+type configEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	EnvVar string `json:"envVar,omitempty"`
+}
+
+// ConfigMigrateCommand : Migrate the connections, cwctl config and project connection files to the current schema
+// versions, backing each file up first. With --check the files are not changed, and the command exits 1 when any
+// of them are waiting for migration
+func ConfigMigrateCommand(c *cli.Context) {
+	check := c.Bool("check")
+	migrateFile := migrate.Migrate
+	if check {
+		migrateFile = migrate.Check
+	}
+	statuses := []migrate.Status{}
+
+	// The connections file is changed under the connections lock
+	if check {
+		status, migErr := migrate.Check(connections.Schema, connections.GetConnectionConfigFilename())
+		if migErr != nil {
+			exitWithError(migErr)
+		}
+		statuses = append(statuses, *status)
+	} else {
+		status, conErr := connections.MigrateConnectionsFile()
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+		statuses = append(statuses, *status)
+	}
+
+	status, migErr := migrateFile(cliconfig.Schema, cliconfig.GetConfigFilename())
+	if migErr != nil {
+		exitWithError(migErr)
+	}
+	statuses = append(statuses, *status)
+
+	filenames, projErr := project.ConnectionFilenames()
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	for _, filename := range filenames {
+		status, migErr := migrateFile(project.ConnectionFileSchema, filename)
+		if migErr != nil {
+			exitWithError(migErr)
+		}
+		statuses = append(statuses, *status)
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.Required() {
+			pending++
+		}
+	}
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(statuses)
+		fmt.Println(string(response))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FILE\tVERSION\tSTATUS\tPATH")
+		for _, status := range statuses {
+			state := "up to date"
+			if status.Required() && check {
+				state = "pending: " + strings.Join(status.Pending, ", ")
+			} else if status.Required() {
+				state = "migrated, backed up to " + status.Backup
+			}
+			fmt.Fprintln(w, status.Name+"\t"+strconv.Itoa(status.Version)+"\t"+state+"\t"+status.Path)
+		}
+		w.Flush()
+	}
+	if check && pending > 0 {
+		os.Exit(1)
+	}
+	exitSuccess()
+}
+
+// getDefaultConnection : The connection used when --conid is not given, from the environment or the saved
+// config, else the local connection
+func getDefaultConnection() string {
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr == nil && cliConfig.DefaultConnection != "" {
+		return cliConfig.DefaultConnection
+	}
+	return "local"
+}
+
+// getImageConfig : The images to use, from the defaults overridden by the saved config, the environment and then
+// the command flags. The images are for the architecture Docker runs containers on unless --arch gives another
+func getImageConfig(c *cli.Context) utils.ImageConfig {
+	images := getConfiguredImageConfig().Merge(utils.ImageConfig{
+		Registry:          c.String("registry"),
+		Org:               c.String("org"),
+		PFEImage:          c.String("pfe-image"),
+		PerformanceImage:  c.String("performance-image"),
+		Tag:               c.String("tag"),
+		PFEDigest:         c.String("pfe-digest"),
+		PerformanceDigest: c.String("performance-digest"),
+		Architecture:      utils.NormalizeArchitecture(c.String("arch")),
+	})
+	if c.String("arch") != "" && !utils.IsKnownArchitecture(images.Architecture) {
+		exitWithUsageError("Invalid --arch '" + c.String("arch") + "', must be one of: " + strings.Join(utils.KnownArchitectures, ", "))
+	}
+	if images.Architecture == "" {
+		images.Architecture = utils.HostArchitecture()
+	}
+	// Digests given as flags are checked here, those saved in the config were checked when they were set
+	for _, digest := range []string{c.String("pfe-digest"), c.String("performance-digest")} {
+		if digest != "" && !utils.IsValidDigest(digest) {
+			exitWithUsageError("Invalid image digest '" + digest + "', must be sha256: followed by 64 lower case hex characters")
+		}
+	}
+	if c.Bool("verify") {
+		if err := images.RequireDigests(); err != nil {
+			exitWithUsageError(err.Error())
+		}
+	}
+	return images
+}
+
+// getConfiguredImageConfig : The images to use without command flags, from the defaults overridden by the saved
+// config and the environment
+func getConfiguredImageConfig() utils.ImageConfig {
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	return utils.DefaultImageConfig().Merge(utils.ImageConfig{
+		Registry:          cliConfig.ImageRegistry,
+		Org:               cliConfig.ImageOrg,
+		PFEImage:          cliConfig.PFEImage,
+		PerformanceImage:  cliConfig.PerformanceImage,
+		Tag:               cliConfig.ImageTag,
+		PFEDigest:         cliConfig.PFEImageDigest,
+		PerformanceDigest: cliConfig.PerformanceImageDigest,
+	})
+}
+
+// getPortConfig : Where to publish the Codewind containers, from the defaults overridden by the saved config and
+// then the command flags. Ports given as flags are saved, so later starts use them too. The saved ports belong
+// to the default profile, other profiles use free ports unless given flags, which are not saved. With save false
+// the flags apply to this command only
+func getPortConfig(c *cli.Context, save bool) utils.PortConfig {
+	flags := utils.PortConfig{
+		PFEPort:         c.String("pfe-port"),
+		PerformancePort: c.String("performance-port"),
+		HostInterface:   c.String("host-interface"),
+	}
+	if flags.PFEPort != "" && !utils.IsValidPort(flags.PFEPort) {
+		exitWithUsageError("Invalid --pfe-port '" + flags.PFEPort + "', must be a number between 1 and 65535")
+	}
+	if flags.PerformancePort != "" && !utils.IsValidPort(flags.PerformancePort) {
+		exitWithUsageError("Invalid --performance-port '" + flags.PerformancePort + "', must be a number between 1 and 65535")
+	}
+	if flags.HostInterface != "" && !utils.IsValidHostInterface(flags.HostInterface) {
+		exitWithUsageError("Invalid --host-interface '" + flags.HostInterface + "', must be an IPv4 address such as 127.0.0.1, or 0.0.0.0 for every interface")
+	}
+
+	if !utils.ActiveProfile().IsDefault() {
+		return utils.ProfilePortConfig().Merge(flags)
+	}
+
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	saved := utils.PortConfig{
+		PFEPort:         cliConfig.PFEPort,
+		PerformancePort: cliConfig.PerformancePort,
+		HostInterface:   cliConfig.HostInterface,
+	}.Merge(flags)
+	if save && (saved.PFEPort != cliConfig.PFEPort || saved.PerformancePort != cliConfig.PerformancePort || saved.HostInterface != cliConfig.HostInterface) {
+		cliConfig.PFEPort = saved.PFEPort
+		cliConfig.PerformancePort = saved.PerformancePort
+		cliConfig.HostInterface = saved.HostInterface
+		configErr = cliconfig.SaveConfig(cliConfig)
+		if configErr != nil {
+			exitWithError(configErr)
+		}
+	}
+	return utils.DefaultPortConfig().Merge(saved)
+}
+
+// getWorkspaceConfig : Where the profile keeps its workspace, the saved config overridden by the command flags.
+// --workspace gives a host directory and --workspace-volume a docker named volume, whichever is given is saved
+// for the profile so later starts use it too. With save false the flags apply to this command only
+func getWorkspaceConfig(c *cli.Context, save bool) utils.WorkspaceConfig {
+	profile := utils.ActiveProfile()
+	saved := profile.Workspace()
+	workspace := saved
+	if c.IsSet("workspace") && c.Bool("workspace-volume") {
+		exitWithUsageError("Only one of --workspace and --workspace-volume can be given")
+	}
+	if c.IsSet("workspace") {
+		directory := strings.TrimSpace(c.String("workspace"))
+		if directory == "" {
+			exitWithUsageError("Invalid --workspace, must be the path of a directory")
+		}
+		directory, err := filepath.Abs(directory)
+		if err != nil {
+			exitWithUsageError("Invalid --workspace '" + c.String("workspace") + "': " + err.Error())
+		}
+		if info, err := os.Stat(directory); err == nil && !info.IsDir() {
+			exitWithUsageError("Invalid --workspace '" + directory + "', it is a file rather than a directory")
+		}
+		workspace = utils.WorkspaceConfig{Directory: directory}
+	}
+	if c.Bool("workspace-volume") {
+		workspace = utils.WorkspaceConfig{NamedVolume: true}
+	}
+	if save && workspace != saved {
+		if err := profile.SaveWorkspace(workspace); err != nil {
+			errors.Exit(errors.Filesystem, "", "Unable to save the workspace of the profile: "+err.Error())
+		}
+	}
+	return workspace
+}
@@ -12,12 +12,17 @@
 package actions
 
 import (
+	"bufio"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/docker/docker/pkg/term"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/urfave/cli"
 )
@@ -26,8 +31,7 @@ import (
 func ConnectionAddToList(c *cli.Context) {
 	connection, err := connections.AddConnectionToList(http.DefaultClient, c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 
 	type Result struct {
@@ -38,54 +42,184 @@ func ConnectionAddToList(c *cli.Context) {
 
 	response, _ := json.Marshal(Result{Status: "OK", StatusMessage: "Connection added", ConID: strings.ToUpper(connection.ID)})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
 }
 
-// ConnectionGetByID : Get connection by its id
+// ConnectionGetByID : Get connection by its id, label or alias
 func ConnectionGetByID(c *cli.Context) {
-	connectionID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	connectionID, err := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if err != nil {
+		exitWithError(err)
+	}
 	connection, err := connections.GetConnectionByID(connectionID)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(connection)
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
+}
+
+// ConnectionUpdate : Change the label or URL of a connection, keeping its ID
+func ConnectionUpdate(c *cli.Context) {
+	connection, err := connections.UpdateConnection(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	type Result struct {
+		Status        string `json:"status"`
+		StatusMessage string `json:"status_message"`
+		ConID         string `json:"id"`
+	}
+
+	response, _ := json.Marshal(Result{Status: "OK", StatusMessage: "Connection updated", ConID: strings.ToUpper(connection.ID)})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// ConnectionRename : Change the label of a connection, optionally keeping the old label as an alias
+func ConnectionRename(c *cli.Context) {
+	connection, err := connections.RenameConnection(c)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	type Result struct {
+		Status        string `json:"status"`
+		StatusMessage string `json:"status_message"`
+		ConID         string `json:"id"`
+	}
+
+	response, _ := json.Marshal(Result{Status: "OK", StatusMessage: "Connection renamed", ConID: strings.ToUpper(connection.ID)})
+	fmt.Println(string(response))
+	exitSuccess()
 }
 
 // ConnectionRemoveFromList : Removes a connection from the connections config file
 func ConnectionRemoveFromList(c *cli.Context) {
 	err := connections.RemoveConnectionFromList(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(connections.Result{Status: "OK", StatusMessage: "Connection removed"})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
 }
 
-// ConnectionListAll : Fetch all connections
-func ConnectionListAll() {
+// ConnectionListAll : Fetch all connections, printed as JSON unless --output yaml is given
+func ConnectionListAll(c *cli.Context) {
+	format := outputFormat(c)
 	allConnections, err := connections.GetConnectionsConfig()
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
-	response, _ := json.Marshal(allConnections)
+	if format == outputText {
+		format = outputJSON
+	}
+	printOutput(format, allConnections)
+	exitSuccess()
+}
+
+// ConnectionCapabilities : Show the optional features the Codewind of a connection supports
+func ConnectionCapabilities(c *cli.Context) {
+	connectionID := strings.TrimSpace(c.String("conid"))
+	capabilities, err := connections.GetCapabilities(&sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: connectionID}, connectionID, c.Bool("refresh"))
+	if err != nil {
+		exitWithError(err)
+	}
+	response, _ := json.Marshal(capabilities)
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
 }
 
-// ConnectionResetList : Reset to a single default local connection
-func ConnectionResetList() {
+// ConnectionEnv : Print the environment of the gatekeeper at a URL without adding a connection
+func ConnectionEnv(c *cli.Context) {
+	details, err := connections.DiscoverGatekeeper(http.DefaultClient, c.String("url"), c.Bool("insecure"), c.String("cacert"))
+	if err != nil {
+		exitWithError(err)
+	}
+	response, _ := json.Marshal(details)
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// ConnectionResetList : Reset to a single default local connection, or with --dry-run list the connections
+// that would be removed
+func ConnectionResetList(c *cli.Context) {
+	if isDryRun(c) {
+		allConnections, conErr := connections.GetAllConnections()
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+		removed := []string{}
+		for _, connection := range allConnections {
+			if !strings.EqualFold(connection.ID, "local") {
+				removed = append(removed, connection.ID)
+			}
+		}
+		response, _ := json.Marshal(struct {
+			connections.Result
+			Connections []string `json:"connections"`
+		}{connections.Result{Status: "OK", StatusMessage: "Dry run, the connection list was not reset"}, removed})
+		fmt.Println(string(response))
+		exitSuccess()
+	}
 	err := connections.ResetConnectionsFile()
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(connections.Result{Status: "OK", StatusMessage: "Connection list reset"})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
+}
+
+// ConnectionExport : Write the remote connections to a file that can be shared
+func ConnectionExport(c *cli.Context) {
+	filename := strings.TrimSpace(c.String("file"))
+	count, err := connections.ExportConnections(filename)
+	if err != nil {
+		exitWithError(err)
+	}
+	response, _ := json.Marshal(connections.Result{Status: "OK", StatusMessage: strconv.Itoa(count) + " connections exported to " + filename})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// ConnectionImport : Merge connections from an exported file into the connections config file
+func ConnectionImport(c *cli.Context) {
+	filename := strings.TrimSpace(c.String("file"))
+	force := c.Bool("force")
+	_, isTerm := term.GetFdInfo(os.Stdin)
+	reader := bufio.NewReader(os.Stdin)
+	notAsked := []string{}
+
+	// Ask before replacing a connection, unless forced or there is nobody to ask
+	overwrite := func(existing connections.Connection, imported connections.Connection) bool {
+		if force {
+			return true
+		}
+		if !utils.IsInteractive() {
+			notAsked = append(notAsked, existing.ID)
+			return false
+		}
+		if !isTerm {
+			return false
+		}
+		fmt.Fprintf(os.Stderr, "Connection %s (%s) already exists for %s, replace it with %s? [y/N] ", existing.ID, existing.Label, existing.URL, imported.Label)
+		answer, _ := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	}
+
+	result, err := connections.ImportConnections(filename, overwrite)
+	if err != nil {
+		exitWithError(err)
+	}
+	response, _ := json.Marshal(result)
+	fmt.Println(string(response))
+	if len(notAsked) > 0 {
+		exitWithUsageError("Connections " + strings.Join(notAsked, ", ") + " already exist and were not replaced, as cwctl cannot prompt when non-interactive, use --force to replace them")
+	}
+	exitSuccess()
 }
@@ -0,0 +1,43 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/doctor"
+	"github.com/urfave/cli"
+)
+
+// DoctorCommand : Check the prerequisites for installing and starting Codewind, exiting with 1 if any check failed
+func DoctorCommand(c *cli.Context) {
+	report := doctor.RunChecks(c.Int("certdays"))
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(report)
+		fmt.Println(string(response))
+	} else {
+		for _, check := range report.Checks {
+			fmt.Printf("[%s] %s: %s\n", strings.ToUpper(check.Status), check.Name, check.Message)
+			if check.Remediation != "" {
+				fmt.Println("    " + check.Remediation)
+			}
+		}
+		fmt.Println("Result: " + report.Status)
+	}
+	if report.Status == doctor.StatusFailed {
+		os.Exit(1)
+	}
+	exitSuccess()
+}
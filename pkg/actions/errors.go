@@ -0,0 +1,84 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/apply"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+	"github.com/eclipse/codewind-installer/pkg/utils/project"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	"github.com/urfave/cli"
+)
+
+// exitWithError : Exit after a command has failed, with the exit code of the error's category
+func exitWithError(err error) {
+	op := errorOp(err)
+	errors.Exit(errors.CategoryForOp(op), op, err.Error())
+}
+
+// exitWithUsageError : Exit after a command was given invalid options
+func exitWithUsageError(msg string) {
+	errors.Exit(errors.Usage, "cli_options", msg)
+}
+
+// errorOp : the Op of the error types returned by the cwctl packages
+func errorOp(err error) string {
+	switch e := err.(type) {
+	case *connections.ConError:
+		return e.Op
+	case *security.SecError:
+		return e.Op
+	case *project.ProjectError:
+		return e.Op
+	case *cliconfig.ConfigError:
+		return e.Op
+	case *apply.ApplyError:
+		return e.Op
+	case *migrate.MigrateError:
+		return e.Op
+	case *remote.RemInstError:
+		return e.Op
+	case *sechttp.HTTPSecError:
+		return e.Op
+	case *apiroutes.APIError:
+		return "pfe_response"
+	}
+	return ""
+}
+
+// ErrorsList : List the error categories cwctl exits with
+func ErrorsList(c *cli.Context) {
+	categories := errors.Categories()
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(categories)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tEXIT CODE\tDESCRIPTION")
+		for _, category := range categories {
+			fmt.Fprintln(w, category.Code+"\t"+strconv.Itoa(category.ExitCode)+"\t"+category.Description)
+		}
+		w.Flush()
+	}
+	exitSuccess()
+}
@@ -0,0 +1,93 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/urfave/cli"
+)
+
+// ExtensionsList : Lists the extensions installed in the connection's PFE
+func ExtensionsList(c *cli.Context) {
+	extensions, err := getExtensionsClient(c).ListExtensions()
+	if err != nil {
+		errors.Exit(errors.PFEAPI, "", err.Error())
+	}
+	printExtensions(c, extensions)
+	exitSuccess()
+}
+
+// ExtensionsInstall : Uploads an extension zip to the connection's PFE
+func ExtensionsInstall(c *cli.Context) {
+	zipPath := strings.TrimSpace(c.String("file"))
+	// Check the zip can be read before uploading it, so a wrong path is reported clearly
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		errors.Exit(errors.Filesystem, "", fmt.Sprintf("Unable to read the extension zip %v: %v", zipPath, err))
+	}
+	archive.Close()
+
+	extensions, err := getExtensionsClient(c).InstallExtension(zipPath)
+	if err != nil {
+		errors.Exit(errors.PFEAPI, "", err.Error())
+	}
+	printExtensions(c, extensions)
+	exitSuccess()
+}
+
+// ExtensionsRemove : Removes an extension from the connection's PFE
+func ExtensionsRemove(c *cli.Context) {
+	extensions, err := getExtensionsClient(c).RemoveExtension(strings.TrimSpace(c.String("name")))
+	if err != nil {
+		errors.Exit(errors.PFEAPI, "", err.Error())
+	}
+	printExtensions(c, extensions)
+	exitSuccess()
+}
+
+// getExtensionsClient : a client of the PFE of the connection given with --conid
+func getExtensionsClient(c *cli.Context) *apiroutes.PFEClient {
+	conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	client, conErr := sechttp.NewPFEClient(conID)
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	return client
+}
+
+func printExtensions(c *cli.Context, extensions []utils.Extension) {
+	if c.GlobalBool("json") {
+		jsonResponse, _ := json.Marshal(extensions)
+		fmt.Println(string(jsonResponse))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tVERSION\tPROJECT TYPE\tSTYLES")
+	for _, extension := range extensions {
+		fmt.Fprintln(w, extension.Name+"\t"+extension.Version+"\t"+extension.ProjectType+"\t"+strings.Join(extension.GetStyles(), ","))
+	}
+	w.Flush()
+}
@@ -0,0 +1,108 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/urfave/cli"
+)
+
+// GCCommand : Removes the docker resources Codewind left behind, or with --dry-run lists what would be removed
+func GCCommand(c *cli.Context) {
+	options := utils.GarbageOptions{Images: getImageConfig(c), Profiles: existingProfiles()}
+	garbage, err := utils.FindGarbage(options)
+	if err != nil {
+		errors.Exit(errors.Docker, "", "Unable to list the docker resources: "+err.Error())
+	}
+
+	dryRun := isDryRun(c)
+	if !dryRun {
+		garbage, err = utils.RemoveGarbage(garbage)
+		if err != nil {
+			errors.Exit(errors.Docker, "", "Unable to remove the docker resources: "+err.Error())
+		}
+	}
+
+	var reclaimed int64
+	failed := 0
+	for _, item := range garbage {
+		if item.Error != "" {
+			failed++
+		} else {
+			reclaimed += item.Size
+		}
+	}
+
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(struct {
+			DryRun         bool                `json:"dryRun"`
+			Items          []utils.GarbageItem `json:"items"`
+			ReclaimedBytes int64               `json:"reclaimedBytes"`
+		}{dryRun, garbage, reclaimed})
+	} else {
+		printGarbage(garbage, dryRun, reclaimed)
+	}
+	if failed > 0 {
+		errors.Exit(errors.Docker, "", strconv.Itoa(failed)+" of the docker resources could not be removed")
+	}
+	exitSuccess()
+}
+
+// printGarbage : Prints a table of the garbage and how much space removing it frees
+func printGarbage(garbage []utils.GarbageItem, dryRun bool, reclaimed int64) {
+	if len(garbage) == 0 {
+		fmt.Println("Nothing to remove")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tNAME\tREASON\tSIZE (MB)\tERROR")
+	for _, item := range garbage {
+		size := "-"
+		if item.Size > 0 {
+			size = strconv.FormatFloat(float64(item.Size)/1000000, 'f', 1, 64)
+		}
+		itemErr := "-"
+		if item.Error != "" {
+			itemErr = item.Error
+		}
+		fmt.Fprintln(w, item.Kind+"\t"+item.Name+"\t"+item.Reason+"\t"+size+"\t"+itemErr)
+	}
+	w.Flush()
+	size := strconv.FormatFloat(float64(reclaimed)/1000000, 'f', 1, 64)
+	if dryRun {
+		fmt.Println("Would reclaim up to " + size + " MB, run without --dry-run to remove")
+	} else {
+		fmt.Println("Reclaimed up to " + size + " MB")
+	}
+}
+
+// existingProfiles : The named profiles which have a connection, and the one selected with --profile
+func existingProfiles() []utils.LocalProfile {
+	profiles := []utils.LocalProfile{utils.ActiveProfile()}
+	allConnections, conErr := connections.GetAllConnections()
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	for _, connection := range allConnections {
+		if connections.IsLocal(connection.ID) {
+			profiles = append(profiles, connections.LocalProfileOf(connection.ID))
+		}
+	}
+	return profiles
+}
@@ -0,0 +1,59 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// ImagesListCommand : List the local Codewind core and project images
+func ImagesListCommand(c *cli.Context) {
+	images := utils.GetCodewindImages()
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(images)
+		fmt.Println(string(response))
+		exitSuccess()
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tTAGS\tIMAGE ID\tDIGEST\tSIZE (MB)\tCREATED\tIN USE BY")
+	for _, image := range images {
+		tags := "<none>"
+		if len(image.Tags) > 0 {
+			tags = strings.Join(image.Tags, ",")
+		}
+		digest := "<none>"
+		if len(image.Digests) > 0 {
+			digest = image.Digests[0][strings.Index(image.Digests[0], "@")+1:]
+		}
+		inUse := "-"
+		if image.InUse {
+			inUse = strings.Join(image.Containers, ",")
+		}
+		size := strconv.FormatFloat(float64(image.Size)/1000000, 'f', 1, 64)
+		id := strings.TrimPrefix(image.ID, "sha256:")
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Fprintln(w, image.Kind+"\t"+tags+"\t"+id+"\t"+digest+"\t"+size+"\t"+image.Created+"\t"+inUse)
+	}
+	w.Flush()
+	exitSuccess()
+}
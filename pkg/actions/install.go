@@ -12,35 +12,51 @@
 package actions
 
 import (
-	"crypto/tls"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
-	"strconv"
+	"path/filepath"
+	"runtime"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/eclipse/codewind-installer/pkg/utils/project"
 	"github.com/eclipse/codewind-installer/pkg/utils/remote"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
 	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 //InstallCommand to pull images from dockerhub
 func InstallCommand(c *cli.Context) {
-	tag := c.String("tag")
+	images := getImageConfig(c)
 	jsonOutput := c.Bool("json") || c.GlobalBool("json")
+	if err := images.CheckArchitecture(); err != nil {
+		errors.Exit(errors.Docker, "", err.Error())
+	}
 
-	imageArr := [2]string{"docker.io/eclipse/codewind-pfe-amd64:",
-		"docker.io/eclipse/codewind-performance-amd64:"}
+	imageArr := [2]string{images.PFEPullReference(),
+		images.PerformancePullReference()}
 
-	targetArr := [2]string{"codewind-pfe-amd64:",
-		"codewind-performance-amd64:"}
+	targetArr := [2]string{images.LocalPFEImageName(),
+		images.LocalPerformanceImageName()}
 
 	for i := 0; i < len(imageArr); i++ {
-		utils.PullImage(imageArr[i]+tag, jsonOutput)
-		utils.TagImage(imageArr[i]+tag, targetArr[i]+tag)
+		utils.PullImage(imageArr[i], jsonOutput)
+		utils.TagImage(imageArr[i], targetArr[i])
+	}
+	if err := utils.VerifyImageArchitectures(images); err != nil {
+		errors.Exit(errors.Docker, "", err.Error())
+	}
+
+	if c.Bool("verify") {
+		if err := utils.VerifyImageDigests(images); err != nil {
+			errors.Exit(errors.Docker, "", err.Error())
+		}
 	}
 
 	fmt.Println("Image Tagging Successful")
@@ -49,14 +65,19 @@ func InstallCommand(c *cli.Context) {
 // DoRemoteInstall : Deploy a remote PFE and support containers
 func DoRemoteInstall(c *cli.Context) {
 
-	// Since remote will always use Self Signed Certificates initally, turn on insecure flag
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	// Since remote will always use Self Signed Certificates initally, skip certificate checks
+	connections.TrustAllHosts()
 
 	printAsJSON := c.GlobalBool("json")
+	kube.SelectContext(c.String("kubeconfig"), c.String("context"))
 
 	session := c.String("session")
 	if session == "" {
-		session = strings.ToUpper(strconv.FormatInt(utils.CreateTimestamp(), 36))
+		var err error
+		session, err = remote.NewSecret()
+		if err != nil {
+			errors.Exit(errors.Internal, "", "Unable to generate a session secret: "+err.Error())
+		}
 	}
 
 	deployOptions := remote.DeployOptions{
@@ -72,16 +93,50 @@ func DoRemoteInstall(c *cli.Context) {
 		GateKeeperTLSSecure:   true,
 		KeycloakTLSSecure:     true,
 		CodewindSessionSecret: session,
+		Resume:                c.Bool("resume"),
+		SkipPreflight:         c.Bool("skip-preflight"),
+		OpenShiftOAuth:        c.Bool("openshift-oauth"),
+	}
+	if deployOptions.OpenShiftOAuth {
+		for _, flag := range []string{"docker", "export-manifests", "use-helm"} {
+			if c.IsSet(flag) {
+				exitWithUsageError("--openshift-oauth can not be given with --" + flag)
+			}
+		}
+	}
+	if c.Bool("docker") {
+		doDockerRemoteInstall(c, &deployOptions)
+		return
+	}
+	if deployOptions.Namespace == "" {
+		exitWithUsageError("--namespace is required unless --docker is given")
+	}
+	if c.String("export-manifests") != "" {
+		exportRemoteManifests(c, &deployOptions)
+		return
+	}
+	for _, flag := range []string{"openshift", "workspace-id"} {
+		if c.IsSet(flag) {
+			exitWithUsageError("--" + flag + " can only be given with --export-manifests")
+		}
 	}
 
-	deploymentResult, remInstError := remote.DeployRemote(&deployOptions)
+	var deploymentResult *remote.DeploymentResult
+	var remInstError *remote.RemInstError
+	if c.Bool("use-helm") {
+		if c.Bool("resume") {
+			exitWithUsageError("--resume can not be given with --use-helm, rerun without it to upgrade the release")
+		}
+		deploymentResult, remInstError = remote.DeployRemoteWithHelm(c.String("release"), &deployOptions)
+	} else {
+		deploymentResult, remInstError = remote.DeployRemote(&deployOptions)
+	}
 	if remInstError != nil {
 		if printAsJSON {
-			fmt.Println(remInstError.Error())
-		} else {
-			logr.Errorf("Error: %v - %v\n", remInstError.Op, remInstError.Desc)
+			exitWithError(remInstError)
 		}
-		os.Exit(1)
+		logr.Errorf("Error: %v - %v\n", remInstError.Op, remInstError.Desc)
+		os.Exit(errors.CategoryForOp(remInstError.Op).ExitCode)
 	}
 
 	gatekeeperURL := deploymentResult.GatekeeperURL
@@ -99,5 +154,133 @@ func DoRemoteInstall(c *cli.Context) {
 	} else {
 		logr.Infoln("Codewind is available at: " + gatekeeperURL)
 	}
-	os.Exit(0)
+	exitSuccess()
+}
+
+// exportRemoteManifests : Writes the manifests of a remote install to the directory given by --export-manifests,
+// without applying them, so they can be reviewed or kept in a GitOps repo
+func exportRemoteManifests(c *cli.Context, deployOptions *remote.DeployOptions) {
+	if c.Bool("resume") {
+		exitWithUsageError("--resume can not be given with --export-manifests")
+	}
+	if deployOptions.IngressDomain == "" {
+		exitWithUsageError("--ingress is required with --export-manifests")
+	}
+	for _, flag := range []string{"kadminuser", "kadminpass", "kdevuser", "kdevpass"} {
+		if strings.TrimSpace(c.String(flag)) == "" {
+			exitWithUsageError("--" + flag + " is required with --export-manifests, as Keycloak imports the realm and users from the manifests")
+		}
+	}
+	workspaceID := c.String("workspace-id")
+	if workspaceID != "" && !remote.IsValidWorkspaceID(workspaceID) {
+		exitWithUsageError("Invalid --workspace-id '" + workspaceID + "', must be 1 to 16 lower case letters and digits")
+	}
+	// Exporting a workspace again keeps the secrets exported before unless they are given
+	if c.String("session") == "" {
+		deployOptions.CodewindSessionSecret = ""
+	}
+	previous, remInstError := remote.ReadExportedSecrets(c.String("export-manifests"))
+	if remInstError != nil {
+		exitWithError(remInstError)
+	}
+	manifests, remInstError := remote.RenderManifests(deployOptions, remote.ExportOptions{
+		WorkspaceID: workspaceID,
+		OnOpenShift: c.Bool("openshift"),
+		Previous:    previous,
+	})
+	if remInstError != nil {
+		exitWithError(remInstError)
+	}
+	files, remInstError := remote.ExportManifests(c.String("export-manifests"), manifests)
+	if remInstError != nil {
+		exitWithError(remInstError)
+	}
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(map[string]interface{}{"status": "OK", "files": files})
+		fmt.Println(string(response))
+	} else {
+		for _, file := range files {
+			fmt.Println(file)
+		}
+	}
+	exitSuccess()
+}
+
+// doDockerRemoteInstall : Deploys an authenticated Codewind with Keycloak and the gatekeeper on the Docker host
+// with docker-compose, rather than on Kubernetes, and adds a connection to it
+func doDockerRemoteInstall(c *cli.Context, deployOptions *remote.DeployOptions) {
+	printAsJSON := c.GlobalBool("json")
+	for _, flag := range []string{"kadminuser", "kadminpass", "kdevuser", "kdevpass"} {
+		if strings.TrimSpace(c.String(flag)) == "" {
+			exitWithUsageError("--" + flag + " is required with --docker")
+		}
+	}
+	if deployOptions.KeycloakRealm == "" {
+		deployOptions.KeycloakRealm = "codewind"
+	}
+	if deployOptions.KeycloakClient == "" {
+		deployOptions.KeycloakClient = "codewind-docker"
+	}
+	// The gatekeeper and Keycloak are both served over HTTPS with their own certificates
+	deployOptions.GateKeeperTLSSecure = true
+	deployOptions.KeycloakTLSSecure = true
+
+	docker := remote.DockerOptions{
+		Host:           strings.TrimSpace(c.String("host")),
+		GatekeeperPort: c.String("gatekeeper-port"),
+		KeycloakPort:   c.String("keycloak-port"),
+		WorkspaceDir:   strings.TrimSpace(c.String("workspace")),
+	}
+	if docker.Host == "" {
+		docker.Host, _ = os.Hostname()
+	}
+	if docker.WorkspaceDir == "" {
+		home := os.Getenv("HOME")
+		if runtime.GOOS == "windows" {
+			home = os.Getenv("USERPROFILE")
+		}
+		docker.WorkspaceDir = filepath.Join(home, "codewind-remote-workspace")
+	}
+	if !filepath.IsAbs(docker.WorkspaceDir) {
+		exitWithUsageError("Invalid --workspace '" + docker.WorkspaceDir + "', must be an absolute path on the Docker host")
+	}
+	for _, port := range []struct{ flag, value string }{{"gatekeeper-port", docker.GatekeeperPort}, {"keycloak-port", docker.KeycloakPort}} {
+		if !utils.IsValidPort(port.value) {
+			exitWithUsageError("Invalid --" + port.flag + " '" + port.value + "', must be a number between 1 and 65535")
+		}
+	}
+
+	deploymentResult, remInstError := remote.DeployDocker(deployOptions, docker)
+	if remInstError != nil {
+		if printAsJSON {
+			exitWithError(remInstError)
+		}
+		logr.Errorf("Error: %v - %v\n", remInstError.Op, remInstError.Desc)
+		os.Exit(errors.CategoryForOp(remInstError.Op).ExitCode)
+	}
+
+	logr.Infoln("Waiting for Codewind Gatekeeper to start on " + deploymentResult.GatekeeperURL)
+	utils.WaitForService(deploymentResult.GatekeeperURL+"/health", 200, 500)
+
+	label := strings.TrimSpace(c.String("label"))
+	if label == "" {
+		label = docker.Host
+	}
+	flagSet := flag.NewFlagSet("addConnection", 0)
+	flagSet.String("label", label, "doc")
+	flagSet.String("url", deploymentResult.GatekeeperURL, "doc")
+	flagSet.Bool("insecure", true, "doc")
+	connection, conErr := connections.AddConnectionToList(http.DefaultClient, cli.NewContext(nil, flagSet, nil))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+
+	result := project.Result{Status: "OK", StatusMessage: "Install Successful: " + deploymentResult.GatekeeperURL + ", added connection " + connection.ID}
+	if printAsJSON {
+		response, _ := json.Marshal(result)
+		fmt.Println(string(response))
+	} else {
+		logr.Infoln("Codewind is available at: " + deploymentResult.GatekeeperURL + ", added as connection " + connection.ID + " (" + connection.Label + ")")
+	}
+	exitSuccess()
 }
@@ -0,0 +1,85 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/events"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// listenRetryDelay is how long listen waits before reconnecting after losing PFE
+const listenRetryDelay = 5 * time.Second
+
+// ListenCommand : Print the events the connection's PFE sends as newline delimited JSON, reconnecting when
+// the connection is lost unless --once is given
+func ListenCommand(c *cli.Context) {
+	conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	client, conErr := sechttp.NewPFEClient(conID)
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	filter := eventFilter(c.StringSlice("event"), c.String("project"))
+
+	for {
+		// PFE names the socket.io namespace it sends events on in its environment
+		env, err := client.GetEnvironment()
+		if err == nil {
+			err = events.Listen(context.Background(), client.HTTPClient, client.Host, env.SocketNamespace, func(event events.Event) {
+				if filter(event) {
+					line, _ := json.Marshal(event)
+					fmt.Println(string(line))
+				}
+			})
+		}
+		if c.Bool("once") {
+			if err != nil {
+				errors.Exit(errors.Network, "", err.Error())
+			}
+			exitSuccess()
+		}
+		if err != nil {
+			logr.Warnf("Lost the event stream from %v, reconnecting in %v: %v", client.Host, listenRetryDelay, err)
+		}
+		time.Sleep(listenRetryDelay)
+	}
+}
+
+// eventFilter : accepts events whose name or category is one of names, when given, for projectID when given
+func eventFilter(names []string, projectID string) func(events.Event) bool {
+	wanted := map[string]bool{}
+	for _, name := range names {
+		for _, part := range strings.Split(name, ",") {
+			if strings.TrimSpace(part) != "" {
+				wanted[strings.TrimSpace(part)] = true
+			}
+		}
+	}
+	return func(event events.Event) bool {
+		if len(wanted) > 0 && !wanted[event.Name] && !wanted[event.Category] {
+			return false
+		}
+		return projectID == "" || event.ProjectID == projectID
+	}
+}
@@ -0,0 +1,116 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/project"
+	"github.com/urfave/cli"
+)
+
+// LoadTestStart : Start a load run against a project
+func LoadTestStart(c *cli.Context) {
+	projectID, client := getLoadTestClient(c)
+	projErr := project.StartLoadTest(client, projectID, c.String("description"))
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	printLoadTestStatus(c, projectID, "Load run started, fetch its metrics with loadtest results when it completes")
+	exitSuccess()
+}
+
+// LoadTestCancel : Stop the load run a project is running
+func LoadTestCancel(c *cli.Context) {
+	projectID, client := getLoadTestClient(c)
+	projErr := project.CancelLoadTest(client, projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	printLoadTestStatus(c, projectID, "Load run cancelled")
+	exitSuccess()
+}
+
+// LoadTestResults : Print or export the metrics of each load run of a project, as a table, JSON or CSV
+func LoadTestResults(c *cli.Context) {
+	format := strings.ToLower(strings.TrimSpace(c.String("format")))
+	if c.GlobalBool("json") {
+		format = "json"
+	}
+	if format != "table" && format != "json" && format != "csv" {
+		exitWithUsageError("Invalid format '" + format + "', must be one of: table, json, csv")
+	}
+	projectID, client := getLoadTestClient(c)
+	runs, projErr := project.GetLoadTestResults(client, projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+
+	var out io.Writer = os.Stdout
+	outputPath := strings.TrimSpace(c.String("output"))
+	if outputPath != "" {
+		file, err := os.Create(outputPath)
+		if err != nil {
+			errors.Exit(errors.Filesystem, "", fmt.Sprintf("Unable to write %v: %v", outputPath, err))
+		}
+		defer file.Close()
+		out = file
+	}
+
+	switch format {
+	case "json":
+		jsonResponse, _ := json.MarshalIndent(runs, "", "  ")
+		fmt.Fprintln(out, string(jsonResponse))
+	case "csv":
+		err := project.WriteLoadTestCSV(out, runs)
+		if err != nil {
+			errors.Exit(errors.Filesystem, "", err.Error())
+		}
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tDESCRIPTION\tHITS\tRESPONSE TIME (ms)\tTHROUGHPUT (req/s)\tCPU (%)\tMEMORY (MB)")
+		for _, run := range runs {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\n", run.Time.Local().Format("2006-01-02 15:04:05"), run.Description,
+				run.Hits, run.ResponseTime, run.Throughput, run.CPU*100, run.Memory/(1024*1024))
+		}
+		w.Flush()
+	}
+	exitSuccess()
+}
+
+// getLoadTestClient : the project given with --id, and a client authenticated for its connection
+func getLoadTestClient(c *cli.Context) (string, utils.HTTPClient) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID, projErr := project.GetConnectionID(projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	return projectID, &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+}
+
+func printLoadTestStatus(c *cli.Context, projectID string, message string) {
+	if c.GlobalBool("json") {
+		response, _ := json.Marshal(map[string]string{"status": "OK", "status_message": message, "projectID": projectID})
+		fmt.Println(string(response))
+		return
+	}
+	fmt.Println(message)
+}
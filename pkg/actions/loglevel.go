@@ -0,0 +1,89 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/urfave/cli"
+)
+
+// LogLevelCommand : Show the cwctl and PFE log levels, or set both when a level is given
+func LogLevelCommand(c *cli.Context) {
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	jsonOutput := c.Bool("json") || c.GlobalBool("json")
+
+	client, conErr := sechttp.NewPFEClient(conID)
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+
+	type Result struct {
+		CLILevel string                     `json:"cwctl"`
+		PFE      *apiroutes.LoggingResponse `json:"pfe,omitempty"`
+	}
+
+	newLevel := strings.ToLower(strings.TrimSpace(c.Args().First()))
+	if newLevel == "" {
+		cliLevel := utils.DefaultLogLevel
+		cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+		if configErr != nil {
+			exitWithError(configErr)
+		}
+		if cliConfig.LogLevel != "" {
+			cliLevel = cliConfig.LogLevel
+		}
+		pfeLevels, err := client.GetLogLevels()
+		if err != nil {
+			exitWithError(err)
+		}
+		if jsonOutput {
+			response, _ := json.Marshal(Result{CLILevel: cliLevel, PFE: pfeLevels})
+			fmt.Println(string(response))
+		} else {
+			fmt.Println("cwctl log level: " + cliLevel)
+			fmt.Println("PFE log level: " + pfeLevels.CurrentLevel + " (default: " + pfeLevels.DefaultLevel + ")")
+			fmt.Println("Available levels: " + strings.Join(pfeLevels.AllLevels, ", "))
+		}
+		exitSuccess()
+	}
+
+	if !utils.IsValidLogLevel(newLevel) {
+		exitWithUsageError("Invalid log level '" + newLevel + "', must be one of: " + strings.Join(utils.LogLevels, ", "))
+	}
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	cliConfig.LogLevel = newLevel
+	configErr = cliconfig.SaveConfig(cliConfig)
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	err := client.SetLogLevel(newLevel)
+	if err != nil {
+		exitWithError(err)
+	}
+	if jsonOutput {
+		response, _ := json.Marshal(Result{CLILevel: newLevel})
+		fmt.Println(string(response))
+	} else {
+		fmt.Println("Log level set to " + newLevel)
+	}
+	exitSuccess()
+}
@@ -0,0 +1,64 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/urfave/cli"
+)
+
+// LogsPFECommand : Writes the logs of the Codewind containers of a local connection, or the pods of a remote one
+func LogsPFECommand(c *cli.Context) {
+	options := utils.LogOptions{Follow: c.Bool("follow")}
+	for _, component := range c.StringSlice("component") {
+		if !utils.IsLogComponent(component) {
+			exitWithUsageError("Unknown component '" + component + "', must be one of: " + strings.Join(utils.LogComponents, ", "))
+		}
+		options.Components = append(options.Components, strings.ToLower(component))
+	}
+	if since := strings.TrimSpace(c.String("since")); since != "" {
+		duration, err := time.ParseDuration(since)
+		if err != nil || duration <= 0 {
+			exitWithUsageError("--since must be a duration such as 30m or 1h")
+		}
+		options.Since = duration
+	}
+
+	conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	if connections.IsLocal(conID) {
+		streams, err := utils.LocalLogStreams(connections.LocalProfileOf(conID), options)
+		if err != nil {
+			errors.Exit(errors.Docker, "", "Unable to list the Codewind containers: "+err.Error())
+		}
+		err = utils.CopyLogs(streams, options.Follow, os.Stdout)
+		if err != nil {
+			errors.Exit(errors.Docker, "", err.Error())
+		}
+		exitSuccess()
+	}
+
+	_, install := findRemoteInstall(c)
+	remErr := install.Logs(options, os.Stdout)
+	if remErr != nil {
+		exitWithError(remErr)
+	}
+	exitSuccess()
+}
@@ -0,0 +1,36 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote"
+	"github.com/urfave/cli"
+)
+
+// OperatorCommand : Run in-cluster, managing remote Codewind installs declared as CodewindInstall resources
+func OperatorCommand(c *cli.Context) {
+	resync := c.Int("resync")
+	if resync <= 0 {
+		exitWithUsageError("--resync must be a positive number of seconds")
+	}
+
+	// As with install remote, Keycloak is configured over its self signed certificate
+	connections.TrustAllHosts()
+
+	err := remote.RunOperator(c.String("namespace"), time.Duration(resync)*time.Second)
+	if err != nil {
+		exitWithError(err)
+	}
+}
@@ -0,0 +1,63 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats the read commands print their results in
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// outputFormat : The format a read command prints in, json with --json, else as given by --output, else text.
+// A command's own --output takes precedence over the global one
+func outputFormat(c *cli.Context) string {
+	if c.Bool("json") || c.GlobalBool("json") {
+		return outputJSON
+	}
+	format := c.String("output")
+	if format == "" {
+		format = c.GlobalString("output")
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "":
+		return outputText
+	case outputJSON, outputYAML:
+		return format
+	}
+	exitWithUsageError("Invalid --output '" + format + "', must be json or yaml")
+	return ""
+}
+
+// printOutput : Prints the result of a read command as one JSON or YAML document. YAML has the same keys as the
+// JSON, so that scripts can move between them
+func printOutput(format string, result interface{}) {
+	document, _ := json.Marshal(result)
+	if format != outputYAML {
+		fmt.Println(string(document))
+		return
+	}
+	var value interface{}
+	json.Unmarshal(document, &value)
+	body, _ := yaml.Marshal(value)
+	fmt.Print(string(body))
+}
@@ -14,9 +14,16 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"os"
+	"regexp"
 	"strings"
+	"text/tabwriter"
 
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/eclipse/codewind-installer/pkg/utils/project"
 	"github.com/urfave/cli"
 )
@@ -25,28 +32,62 @@ import (
 func ProjectValidate(c *cli.Context) {
 	err := project.ValidateProject(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
-	os.Exit(0)
+	exitSuccess()
+}
+
+// ProjectValidateCheck : Reports the language, build type and extension of the project at --path without
+// changing it, so that it can be checked before it is bound
+func ProjectValidateCheck(c *cli.Context) {
+	conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	report, err := project.ValidateProjectPath(client, conID, strings.TrimSpace(c.String("path")), strings.TrimSpace(c.String("type")))
+	if err != nil {
+		exitWithError(err)
+	}
+	if c.GlobalBool("json") {
+		jsonResponse, _ := json.Marshal(report)
+		fmt.Println(string(jsonResponse))
+		exitSuccess()
+	}
+	fmt.Println("Language: " + report.Language)
+	fmt.Println("Project type: " + report.BuildType)
+	if report.Warning != "" {
+		fmt.Println("Warning: " + report.Warning)
+	}
+	if report.Extension == nil {
+		exitSuccess()
+	}
+	fmt.Println("Extension: " + report.Extension.Name)
+	for _, command := range report.Extension.Commands {
+		fmt.Println("Runs " + command.Name + ": " + strings.Join(append([]string{command.Command}, command.Args...), " "))
+	}
+	exitSuccess()
 }
 
 // ProjectCreate : Downloads template and creates a new project
 func ProjectCreate(c *cli.Context) {
 	err := project.DownloadTemplate(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 }
 
 // ProjectSync : Does a project Sync
 func ProjectSync(c *cli.Context) {
 	PrintAsJSON := c.GlobalBool("json")
-	response, err := project.SyncProject(c)
+	if c.Int("bandwidth-limit") < 0 {
+		exitWithUsageError("--bandwidth-limit must not be negative")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	response, err := project.SyncProject(ctx, c)
 	if err != nil {
-		fmt.Println(err.Err)
-		os.Exit(1)
+		exitWithError(err)
 	} else {
 		if PrintAsJSON {
 			jsonResponse, _ := json.Marshal(response)
@@ -55,16 +96,32 @@ func ProjectSync(c *cli.Context) {
 			fmt.Println("Status: " + response.Status)
 		}
 	}
-	os.Exit(0)
+	exitSuccess()
 }
 
 // ProjectBind : Does a project bind
 func ProjectBind(c *cli.Context) {
 	PrintAsJSON := c.GlobalBool("json")
-	response, err := project.BindProject(c)
+	if c.Int("bandwidth-limit") < 0 {
+		exitWithUsageError("--bandwidth-limit must not be negative")
+	}
+	if !c.Bool("resume") {
+		for _, flag := range []string{"name", "language", "type"} {
+			if strings.TrimSpace(c.String(flag)) == "" {
+				exitWithUsageError("--" + flag + " is required unless --resume is given")
+			}
+		}
+	}
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	if conID == "" {
+		conID = "local"
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	ctx, stop := interruptContext()
+	defer stop()
+	response, err := project.BindProject(ctx, client, c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	} else {
 		if PrintAsJSON {
 			jsonResponse, _ := json.Marshal(response)
@@ -74,17 +131,334 @@ func ProjectBind(c *cli.Context) {
 			fmt.Println("Status: " + response.Status)
 		}
 	}
-	os.Exit(0)
+	exitSuccess()
+}
+
+// ProjectAdd : Validates, configures and binds the existing project at --path in one step
+func ProjectAdd(c *cli.Context) {
+	if c.Int("bandwidth-limit") < 0 {
+		exitWithUsageError("--bandwidth-limit must not be negative")
+	}
+	conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	transferMode := strings.TrimSpace(strings.ToLower(c.String("transfer-mode")))
+	if transferMode == "" {
+		transferMode = project.TransferHTTP
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	ctx, stop := interruptContext()
+	defer stop()
+	response, err := project.AddProject(ctx, client, strings.ToLower(conID), strings.TrimSpace(c.String("path")), strings.TrimSpace(c.String("name")),
+		strings.TrimSpace(c.String("type")), int64(c.Int("bandwidth-limit"))*1024, transferMode)
+	if err != nil {
+		exitWithError(err)
+	}
+	if c.GlobalBool("json") {
+		jsonResponse, _ := json.Marshal(response)
+		fmt.Println(string(jsonResponse))
+	} else {
+		fmt.Println("Project name: " + response.Name)
+		fmt.Println("Language: " + response.Validation.Language)
+		fmt.Println("Project type: " + response.Validation.BuildType)
+		if response.Validation.Warning != "" {
+			fmt.Println("Warning: " + response.Validation.Warning)
+		}
+		fmt.Println("Project ID: " + response.ProjectID)
+		fmt.Println("Status: " + response.Status)
+	}
+	exitSuccess()
+}
+
+// ProjectList : Lists the projects on a connection, filtered by the connection's project prefix unless --all is given
+func ProjectList(c *cli.Context) {
+	format := outputFormat(c)
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	if connections.IsAllConnections(conID) {
+		broadcastToConnections(format, func(connection connections.Connection) (interface{}, error) {
+			client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: connection.ID}
+			projects, projErr := project.ListProjects(client, connection.ID, c.Bool("all"))
+			if projErr != nil {
+				return nil, projErr
+			}
+			return projects, nil
+		}, func(result interface{}) {
+			printProjects(result.([]apiroutes.Project))
+		})
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	projects, err := project.ListProjects(client, conID, c.Bool("all"))
+	if err != nil {
+		exitWithError(err)
+	}
+	if format != outputText {
+		printOutput(format, projects)
+		exitSuccess()
+	}
+	printProjects(projects)
+	exitSuccess()
+}
+
+func printProjects(projects []apiroutes.Project) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROJECT ID\tNAME\tLANGUAGE\tTYPE\tAPP STATUS\tBUILD STATUS")
+	for _, p := range projects {
+		fmt.Fprintln(w, p.ProjectID+"\t"+p.Name+"\t"+p.Language+"\t"+p.ProjectType+"\t"+p.AppStatus+"\t"+p.BuildStatus)
+	}
+	w.Flush()
+}
+
+// ProjectGrep : Search the files of the projects bound from this machine
+func ProjectGrep(c *cli.Context) {
+	pattern, err := regexp.Compile(c.String("pattern"))
+	if err != nil {
+		exitWithUsageError("Invalid pattern: " + err.Error())
+	}
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	ctx, stop := interruptContext()
+	defer stop()
+	matches, projErr := project.SearchProjects(ctx, pattern, conID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	if c.GlobalBool("json") {
+		jsonResponse, _ := json.Marshal(matches)
+		fmt.Println(string(jsonResponse))
+	} else {
+		for _, match := range matches {
+			fmt.Printf("%s:%s:%d: %s\n", match.ProjectID, match.File, match.Line, match.Text)
+		}
+	}
+	if len(matches) == 0 {
+		os.Exit(1)
+	}
+	exitSuccess()
+}
+
+// ProjectSettingsGet : Print the .cw-settings of a project, or one field of it
+func ProjectSettingsGet(c *cli.Context) {
+	projectID := strings.TrimSpace(c.String("id"))
+	projectPath := strings.TrimSpace(c.String("path"))
+	key := strings.TrimSpace(c.Args().First())
+	if key == "" {
+		settings, projErr := project.GetProjectSettings(projectID, projectPath)
+		if projErr != nil {
+			exitWithError(projErr)
+		}
+		utils.PrettyPrintJSON(settings)
+		exitSuccess()
+	}
+	value, projErr := project.GetProjectSetting(projectID, projectPath, key)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(map[string]interface{}{key: value})
+	} else if text, ok := value.(string); ok {
+		fmt.Println(text)
+	} else if value != nil {
+		jsonValue, _ := json.Marshal(value)
+		fmt.Println(string(jsonValue))
+	}
+	exitSuccess()
+}
+
+// ProjectSettingsSet : Change a field of the .cw-settings of a project and apply it in Codewind
+func ProjectSettingsSet(c *cli.Context) {
+	projectID := strings.TrimSpace(c.String("id"))
+	key := strings.TrimSpace(c.Args().Get(0))
+	if key == "" || len(c.Args()) < 2 {
+		exitWithUsageError("A setting and a value are required, the setting must be one of: " + strings.Join(project.ProjectSettingKeys(), ", "))
+	}
+	conID, projErr := project.GetConnectionID(projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	settings, projErr := project.SetProjectSetting(client, projectID, strings.TrimSpace(c.String("path")), key, c.Args().Get(1))
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(settings)
+	} else {
+		fmt.Println("Updated " + key + ", Codewind will rebuild the project if needed")
+	}
+	exitSuccess()
+}
+
+// ProjectMetricsInject : Add the metrics collector for its language to a project's build
+func ProjectMetricsInject(c *cli.Context) {
+	setProjectMetricsInjection(c, true)
+}
+
+// ProjectMetricsRemove : Take the metrics collector back out of a project's build
+func ProjectMetricsRemove(c *cli.Context) {
+	setProjectMetricsInjection(c, false)
+}
+
+func setProjectMetricsInjection(c *cli.Context, enable bool) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID, projErr := project.GetConnectionID(projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	result, projErr := project.SetMetricsInjection(client, projectID, enable)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(result)
+	} else if !result.Supported {
+		fmt.Println("Metrics injection is not supported for " + result.Language + " projects, only for " + strings.Join(result.SupportedLanguages, ", ") + ", so project " + projectID + " is unchanged")
+	} else if enable {
+		fmt.Println("Injecting " + result.Collector + " into project " + projectID + ", Codewind will rebuild the project")
+	} else {
+		fmt.Println("Removing " + result.Collector + " from project " + projectID + ", Codewind will rebuild the project")
+	}
+	exitSuccess()
+}
+
+// ProjectLocate : Show where a project is on this machine and in the workspace of its connection, or with --file,
+// where a file of the project given by either path is in the other
+func ProjectLocate(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	location, projErr := project.LocateProject(newProjectConnectionClient(projectID), projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	file := strings.TrimSpace(c.String("file"))
+	if file != "" {
+		fileLocation, err := location.LocateFile(file)
+		if err != nil {
+			exitWithUsageError(err.Error())
+		}
+		if c.GlobalBool("json") {
+			utils.PrettyPrintJSON(fileLocation)
+		} else {
+			fmt.Println("Local path:      " + fileLocation.LocalPath)
+			fmt.Println("Workspace path:  " + fileLocation.WorkspacePath)
+		}
+		exitSuccess()
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(location)
+		exitSuccess()
+	}
+	localPath := location.LocalPath
+	if localPath == "" {
+		localPath = "(not bound or synced from this machine)"
+	}
+	fmt.Println("Project " + projectID + " on connection " + location.ConnectionID)
+	fmt.Println("Local path:      " + localPath)
+	fmt.Println("Workspace path:  " + location.WorkspacePath)
+	if location.Volume != "" {
+		fmt.Println("Volume path:     " + location.Volume + ":/" + location.WorkspaceRelativePath)
+	}
+	exitSuccess()
+}
+
+// ProjectLinkList : List the links of a project to the projects it depends on
+func ProjectLinkList(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	links, projErr := project.ListProjectLinks(newProjectConnectionClient(projectID), projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(links)
+	} else if len(links) == 0 {
+		fmt.Println("Project " + projectID + " has no links")
+	} else {
+		for _, link := range links {
+			fmt.Println(link.EnvName + " -> " + link.ProjectID + " " + link.ProjectName)
+		}
+	}
+	exitSuccess()
+}
+
+// ProjectLinkAdd : Link a project to another, injecting the URL of the other project as an environment variable
+func ProjectLinkAdd(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	targetID := strings.TrimSpace(strings.ToLower(c.String("targetID")))
+	envName := c.String("env")
+	projErr := project.AddProjectLink(newProjectConnectionClient(projectID), projectID, targetID, envName)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: "Project " + projectID + " linked to " + targetID + " as " + strings.TrimSpace(envName)})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// ProjectLinkRemove : Remove the link of a project injected as an environment variable
+func ProjectLinkRemove(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	envName := c.String("env")
+	projErr := project.RemoveProjectLink(newProjectConnectionClient(projectID), projectID, envName)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: "Link " + strings.TrimSpace(envName) + " removed from project " + projectID})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// newProjectConnectionClient : an HTTPClient which authenticates against the connection a project is bound to
+func newProjectConnectionClient(projectID string) *sechttp.ConnectionClient {
+	conID, projErr := project.GetConnectionID(projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	return &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+}
+
+// ProjectRemove : Unbind a project and clean up its containers, images and volumes
+func ProjectRemove(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	conID, projErr := project.GetConnectionID(projectID)
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	result, projErr := project.RemoveProject(client, projectID, c.Bool("delete-files"))
+	if projErr != nil {
+		exitWithError(projErr)
+	}
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(result)
+	} else {
+		fmt.Println("Removed project " + projectID)
+		for _, name := range result.Containers {
+			fmt.Println("Removed container " + name)
+		}
+		for _, name := range result.Images {
+			fmt.Println("Removed image " + name)
+		}
+		for _, name := range result.Volumes {
+			fmt.Println("Removed volume claim " + name)
+		}
+		if result.DeletedPath != "" {
+			fmt.Println("Deleted " + result.DeletedPath)
+		}
+	}
+	exitSuccess()
 }
 
 // UpgradeProjects : Upgrades projects
 func UpgradeProjects(c *cli.Context) {
-	err := project.UpgradeProjects(c)
+	if strings.TrimSpace(c.String("workspace")) == "" {
+		exitWithUsageError("Required flag \"workspace\" not set, or use 'upgrade deployment' to upgrade Codewind itself")
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	err := project.UpgradeProjects(ctx, c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
-	os.Exit(0)
+	exitSuccess()
 }
 
 // ProjectSetConnection : Set connection for a project
@@ -93,12 +467,11 @@ func ProjectSetConnection(c *cli.Context) {
 	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
 	err := project.SetConnection(projectID, conID)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: "Project target added successfully"})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
 }
 
 // ProjectGetConnection : List connection for a project
@@ -106,11 +479,10 @@ func ProjectGetConnection(c *cli.Context) {
 	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
 	connectionTargets, err := project.GetConnectionID(projectID)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	fmt.Println(connectionTargets)
-	os.Exit(0)
+	exitSuccess()
 }
 
 // ProjectRemoveConnection : Remove Connection from  a project
@@ -118,10 +490,35 @@ func ProjectRemoveConnection(c *cli.Context) {
 	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
 	err := project.ResetConnectionFile(projectID)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(project.Result{Status: "OK", StatusMessage: "Project target removed successfully"})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
+}
+
+// ProjectExec : Run a command inside a project's container
+func ProjectExec(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	if c.Bool("tty") && !utils.IsInteractive() {
+		exitWithUsageError("--tty needs an interactive terminal, run project exec without it when non-interactive")
+	}
+	err := project.ExecInProject(projectID, c.Args(), c.Bool("tty"))
+	if err != nil {
+		exitWithError(err)
+	}
+	exitSuccess()
+}
+
+// ProjectShell : Open an interactive shell inside a project's container
+func ProjectShell(c *cli.Context) {
+	projectID := strings.TrimSpace(strings.ToLower(c.String("id")))
+	if !utils.IsInteractive() {
+		exitWithUsageError("project shell needs an interactive terminal, use project exec to run a command when non-interactive")
+	}
+	err := project.ExecInProject(projectID, project.DefaultShell, true)
+	if err != nil {
+		exitWithError(err)
+	}
+	exitSuccess()
 }
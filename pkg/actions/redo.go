@@ -0,0 +1,154 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/eclipse/codewind-installer/pkg/utils/telemetry"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// Commands which are never saved for redo
+var unrecordedCommands = []string{"redo", "completion", "help", "h"}
+
+// invocationArgs are the arguments cwctl is running with, excluding the program name
+var invocationArgs []string
+
+// invocation : A command and the arguments it was run with
+type invocation struct {
+	command string
+	args    []string
+}
+
+// pendingInvocation is the command being run, saved by exitSuccess once it has succeeded
+var pendingInvocation *invocation
+
+// recordInvocations : Wrap the action of every command so that its arguments are saved when it succeeds, and
+// its usage is reported
+func recordInvocations(commands []cli.Command) {
+	for i := range commands {
+		recordInvocations(commands[i].Subcommands)
+		action, ok := commands[i].Action.(func(*cli.Context) error)
+		if !ok || isUnrecorded(commands[i].Name) {
+			continue
+		}
+		commands[i].Action = func(c *cli.Context) error {
+			command := strings.TrimPrefix(c.Command.HelpName, "cwctl ")
+			pendingInvocation = &invocation{command, withoutSecrets(invocationArgs, c.Command.Flags)}
+			startUsageReport(command)
+			err := action(c)
+			if err == nil {
+				saveInvocation()
+				reportUsage(telemetry.ResultSuccess)
+			}
+			return err
+		}
+	}
+}
+
+// exitSuccess : Exit after a command has succeeded, saving it for redo
+func exitSuccess() {
+	saveInvocation()
+	reportUsage(telemetry.ResultSuccess)
+	os.Exit(0)
+}
+
+func saveInvocation() {
+	if pendingInvocation == nil {
+		return
+	}
+	history, configErr := cliconfig.LoadHistory()
+	if configErr == nil {
+		history.Last = pendingInvocation.command
+		history.Commands[pendingInvocation.command] = pendingInvocation.args
+		configErr = cliconfig.SaveHistory(history)
+	}
+	if configErr != nil {
+		logr.Debugln("Unable to save command history: " + configErr.Desc)
+	}
+	pendingInvocation = nil
+}
+
+// withoutSecrets : Removes the flags in secretFlags, by any of their names, and their values, from the arguments
+func withoutSecrets(args []string, flags []cli.Flag) []string {
+	secrets := map[string]bool{}
+	for _, flag := range flags {
+		names := strings.Split(flag.GetName(), ",")
+		if secretFlags[strings.TrimSpace(names[0])] {
+			for _, name := range names {
+				secrets[strings.TrimSpace(name)] = true
+			}
+		}
+	}
+	filtered := []string{}
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if !strings.HasPrefix(args[i], "-") || !secrets[strings.SplitN(name, "=", 2)[0]] {
+			filtered = append(filtered, args[i])
+			continue
+		}
+		// Skip the value too, unless it was given as --flag=value
+		if !strings.Contains(name, "=") {
+			i++
+		}
+	}
+	return filtered
+}
+
+func isUnrecorded(command string) bool {
+	for _, unrecorded := range unrecordedCommands {
+		if command == unrecorded {
+			return true
+		}
+	}
+	return false
+}
+
+// RedoCommand : Run a command again with the arguments it last succeeded with. Without a command, the last successful
+// command is repeated. Any further arguments, such as secrets which are not saved, are added to the saved ones
+func RedoCommand(c *cli.Context) {
+	history, configErr := cliconfig.LoadHistory()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+
+	commandWords := []string{}
+	extraArgs := []string{}
+	for i, arg := range c.Args() {
+		if strings.HasPrefix(arg, "-") {
+			extraArgs = c.Args()[i:]
+			break
+		}
+		commandWords = append(commandWords, arg)
+	}
+	command := strings.Join(commandWords, " ")
+	if command == "" {
+		command = history.Last
+	}
+	args, ok := history.Commands[command]
+	if command == "" || !ok {
+		exitWithUsageError("No successful run of '" + command + "' has been recorded")
+	}
+
+	args = append(append([]string{}, args...), extraArgs...)
+	fmt.Fprintln(os.Stderr, "Running: cwctl "+strings.Join(args, " "))
+	invocationArgs = args
+	err := c.App.Run(append([]string{c.App.Name}, args...))
+	if err != nil {
+		exitWithError(err)
+	}
+}
@@ -0,0 +1,56 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func Test_WithoutSecrets(t *testing.T) {
+	setpwFlags := []cli.Flag{
+		cli.StringFlag{Name: "realm,r"},
+		cli.StringFlag{Name: "accesstoken,t"},
+		cli.StringFlag{Name: "password,p"},
+		cli.StringFlag{Name: "username,u"},
+		cli.StringFlag{Name: "newpw,w"},
+	}
+
+	t.Run("Removes secret flags by any of their names", func(t *testing.T) {
+		args := []string{"secuser", "setpw", "--realm", "codewind", "-t", "token", "--username", "developer", "--newpw", "new", "-w", "new"}
+		assert.Equal(t, []string{"secuser", "setpw", "--realm", "codewind", "--username", "developer"}, withoutSecrets(args, setpwFlags))
+	})
+
+	t.Run("Removes secret flags given with their value", func(t *testing.T) {
+		args := []string{"secuser", "setpw", "--newpw=new", "--password=admin", "--realm=codewind"}
+		assert.Equal(t, []string{"secuser", "setpw", "--realm=codewind"}, withoutSecrets(args, setpwFlags))
+	})
+
+	t.Run("Keeps flags which are not secret", func(t *testing.T) {
+		args := []string{"secuser", "setpw", "-r", "codewind", "-u", "developer"}
+		assert.Equal(t, args, withoutSecrets(args, setpwFlags))
+	})
+
+	t.Run("Removes the API key of a template repository but not its header", func(t *testing.T) {
+		addFlags := []cli.Flag{
+			cli.StringFlag{Name: "url"},
+			cli.StringFlag{Name: "api-key"},
+			cli.StringFlag{Name: "api-key-header"},
+		}
+		args := []string{"templates", "repos", "add", "--url", "https://example.com/index.json", "--api-key", "key", "--api-key-header", "X-API-Key"}
+		assert.Equal(t, []string{"templates", "repos", "add", "--url", "https://example.com/index.json", "--api-key-header", "X-API-Key"}, withoutSecrets(args, addFlags))
+		args = []string{"templates", "repos", "add", "--api-key=key", "--url=https://example.com/index.json"}
+		assert.Equal(t, []string{"templates", "repos", "add", "--url=https://example.com/index.json"}, withoutSecrets(args, addFlags))
+	})
+}
@@ -0,0 +1,122 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RegistrySecretsAdd : Adds credentials for an image registry to the connection's PFE
+func RegistrySecretsAdd(c *cli.Context) {
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	address := strings.TrimSpace(c.String("address"))
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	registrySecrets, secErr := security.SecRegistrySecretAdd(client, conID, address, c.String("username"), c.String("password"))
+	if secErr != nil {
+		exitWithError(secErr)
+	}
+	if c.Bool("kube") {
+		clientset, namespace := getRegistryKubeClient(c, conID)
+		err := kube.CreateRegistrySecret(clientset, namespace, address, c.String("username"), c.String("password"))
+		if err != nil {
+			errors.Exit(errors.PFEAPI, "", fmt.Sprintf("Unable to create the docker-registry secret in namespace %v: %v", namespace, err))
+		}
+		logr.Infof("Created docker-registry secret %v in namespace %v", kube.RegistrySecretName(address), namespace)
+	}
+	printRegistrySecrets(c, registrySecrets)
+	exitSuccess()
+}
+
+// RegistrySecretsList : Lists the image registries the connection's PFE has credentials for
+func RegistrySecretsList(c *cli.Context) {
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	registrySecrets, secErr := security.SecRegistrySecretList(client, conID)
+	if secErr != nil {
+		exitWithError(secErr)
+	}
+	printRegistrySecrets(c, registrySecrets)
+	exitSuccess()
+}
+
+// RegistrySecretsRemove : Removes the credentials for an image registry from the connection's PFE
+func RegistrySecretsRemove(c *cli.Context) {
+	conID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	address := strings.TrimSpace(c.String("address"))
+	client := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+	registrySecrets, secErr := security.SecRegistrySecretRemove(client, conID, address)
+	if secErr != nil {
+		exitWithError(secErr)
+	}
+	if c.Bool("kube") {
+		clientset, namespace := getRegistryKubeClient(c, conID)
+		err := kube.DeleteRegistrySecret(clientset, namespace, address)
+		if err != nil {
+			errors.Exit(errors.PFEAPI, "", fmt.Sprintf("Unable to delete the docker-registry secret from namespace %v: %v", namespace, err))
+		}
+		logr.Infof("Deleted docker-registry secret %v from namespace %v", kube.RegistrySecretName(address), namespace)
+	}
+	printRegistrySecrets(c, registrySecrets)
+	exitSuccess()
+}
+
+// getRegistryKubeClient : Returns a client for the Kubernetes context of the connection and the namespace to use,
+// docker-registry secrets are only meaningful for remote connections
+func getRegistryKubeClient(c *cli.Context, conID string) (*kubernetes.Clientset, string) {
+	if connections.IsLocal(conID) {
+		exitWithUsageError("--kube can only be used with a remote connection")
+	}
+	connections.SelectConnectionKubeContext(conID)
+	kubeConfig := kube.GetKubeClientConfig()
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		errors.Exit(errors.Filesystem, "", fmt.Sprintf("Unable to retrieve Kubernetes config: %v", err))
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		errors.Exit(errors.Internal, "", fmt.Sprintf("Unable to create Kubernetes client: %v", err))
+	}
+	namespace := strings.TrimSpace(c.String("namespace"))
+	if namespace == "" {
+		namespace = kube.GetCurrentNamespace()
+	}
+	return clientset, namespace
+}
+
+func printRegistrySecrets(c *cli.Context, registrySecrets []apiroutes.RegistrySecret) {
+	if c.GlobalBool("json") {
+		jsonResponse, _ := json.Marshal(registrySecrets)
+		fmt.Println(string(jsonResponse))
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tUSERNAME")
+	for _, registrySecret := range registrySecrets {
+		fmt.Fprintln(w, registrySecret.Address+"\t"+registrySecret.Username)
+	}
+	w.Flush()
+}
@@ -0,0 +1,188 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	logr "github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+// remoteResult : the outcome of stopping or removing a remote Codewind install
+type remoteResult struct {
+	Status        string   `json:"status"`
+	StatusMessage string   `json:"status_message"`
+	ConID         string   `json:"id"`
+	Namespace     string   `json:"namespace"`
+	WorkspaceID   string   `json:"workspace"`
+	Deployments   []string `json:"deployments,omitempty"`
+	Resources     []string `json:"resources,omitempty"`
+}
+
+// StopRemoteCommand : Scale down the remote Codewind install a connection points to
+func StopRemoteCommand(c *cli.Context) {
+	conID, install := findRemoteInstall(c)
+	stopped, remErr := install.Stop()
+	if remErr != nil {
+		exitWithError(remErr)
+	}
+	response, _ := json.Marshal(remoteResult{
+		Status:        "OK",
+		StatusMessage: "Codewind stopped, scale its deployments back up to restart it",
+		ConID:         conID,
+		Namespace:     install.Namespace,
+		WorkspaceID:   install.WorkspaceID,
+		Deployments:   stopped,
+	})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// RemoveRemoteCommand : Remove the remote Codewind install a connection points to, then the connection itself
+// unless --keep-connection is given
+func RemoveRemoteCommand(c *cli.Context) {
+	if c.Bool("docker") {
+		removeDockerRemote(c)
+		return
+	}
+	conID, install := findRemoteInstall(c)
+	if isDryRun(c) {
+		resources, remErr := install.Resources(c.Bool("delete-volumes"))
+		if remErr != nil {
+			exitWithError(remErr)
+		}
+		message := "Dry run, nothing was removed"
+		if !c.Bool("keep-connection") {
+			message += ", connection " + conID + " would be removed too"
+		}
+		response, _ := json.Marshal(remoteResult{
+			Status:        "OK",
+			StatusMessage: message,
+			ConID:         conID,
+			Namespace:     install.Namespace,
+			WorkspaceID:   install.WorkspaceID,
+			Resources:     resources,
+		})
+		fmt.Println(string(response))
+		exitSuccess()
+	}
+	var remErr *remote.RemInstError
+	if c.Bool("use-helm") {
+		remErr = install.UninstallHelmRelease(c.String("release"), c.Bool("delete-volumes"))
+	} else {
+		remErr = install.Remove(c.Bool("delete-volumes"))
+	}
+	if remErr != nil {
+		exitWithError(remErr)
+	}
+	removeRemoteConnection(c, conID)
+	response, _ := json.Marshal(remoteResult{
+		Status:        "OK",
+		StatusMessage: "Codewind removed",
+		ConID:         conID,
+		Namespace:     install.Namespace,
+		WorkspaceID:   install.WorkspaceID,
+	})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// removeDockerRemote : Remove the Codewind installed on the Docker host with install remote --docker, which the
+// connection given with --conid must point to, then the connection itself unless --keep-connection is given
+func removeDockerRemote(c *cli.Context) {
+	conID, connection := findRemoteConnection(c)
+	if isDryRun(c) {
+		resources, remErr := remote.DockerResources(connection.URL, c.Bool("delete-volumes"))
+		if remErr != nil {
+			exitWithError(remErr)
+		}
+		message := "Dry run, nothing was removed"
+		if !c.Bool("keep-connection") {
+			message += ", connection " + conID + " would be removed too"
+		}
+		response, _ := json.Marshal(remoteResult{Status: "OK", StatusMessage: message, ConID: conID, Resources: resources})
+		fmt.Println(string(response))
+		exitSuccess()
+	}
+	remErr := remote.RemoveDocker(connection.URL, c.Bool("delete-volumes"))
+	if remErr != nil {
+		exitWithError(remErr)
+	}
+	removeRemoteConnection(c, conID)
+	response, _ := json.Marshal(remoteResult{Status: "OK", StatusMessage: "Codewind removed", ConID: conID})
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// removeRemoteConnection : removes the connection to a removed remote install, unless --keep-connection is given
+func removeRemoteConnection(c *cli.Context, conID string) {
+	if c.Bool("keep-connection") {
+		return
+	}
+	// --conid may have been a label, which the connection is not removed by
+	c.Set("conid", conID)
+	conErr := connections.RemoveConnectionFromList(c)
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+}
+
+// findRemoteConnection : the ID and the connection given with --conid, which must be remote
+func findRemoteConnection(c *cli.Context) (string, *connections.Connection) {
+	conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	if connections.IsLocal(conID) {
+		exitWithUsageError("Connection " + conID + " is local, use stop or remove without remote to manage local Codewind")
+	}
+	connection, conErr := connections.GetConnectionByID(conID)
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+	return strings.ToUpper(conID), connection
+}
+
+// findRemoteInstall : the remote install the connection given with --conid points to, in the namespace given
+// with --namespace or else the one of the current Kubernetes context
+func findRemoteInstall(c *cli.Context) (string, *remote.RemoteInstall) {
+	conID, connection := findRemoteConnection(c)
+	// The cluster is the one given, or else the one the connection was last managed in
+	kube.SelectContext(c.String("kubeconfig"), c.String("context"))
+	connections.SelectConnectionKubeContext(conID)
+	install, remErr := remote.FindRemoteInstall(connection.URL, c.String("namespace"))
+	if remErr != nil {
+		exitWithError(remErr)
+	}
+	recordKubeContext(conID, connection)
+	return conID, install
+}
+
+// recordKubeContext : Records the kubeconfig file and context a remote install was found with in its connection,
+// so later commands manage it in the same cluster without --kubeconfig and --context
+func recordKubeContext(conID string, connection *connections.Connection) {
+	kubeconfig := kube.Kubeconfig()
+	kubeContext, err := kube.GetCurrentContext()
+	if err != nil || kubeContext == "" || (kubeconfig == connection.Kubeconfig && kubeContext == connection.KubeContext) {
+		return
+	}
+	conErr := connections.SetConnectionKubeContext(conID, kubeconfig, kubeContext)
+	if conErr != nil {
+		logr.Warnf("Unable to record Kubernetes context %v in connection %v: %v\n", kubeContext, strings.ToUpper(conID), conErr.Desc)
+	}
+}
@@ -12,48 +12,247 @@
 package actions
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/urfave/cli"
 )
 
-//RemoveCommand to remove all codewind and project images
+//RemoveCommand to remove all codewind and project images, or only the containers, network, volume and
+// connection of the profile selected with --profile, as the images are shared by every profile. The component
+// flags select what is removed instead, and a summary of what was removed is printed. With --dry-run they are
+// only listed
 func RemoveCommand(c *cli.Context) {
-	tag := c.String("tag")
+	dryRun := isDryRun(c)
+	profile := utils.ActiveProfile()
+	components := getRemoveComponents(c, profile)
+	summary := removeSummary{DryRun: dryRun, Images: []string{}, Containers: []string{}, Networks: []string{}, Volumes: []string{}}
+	// The JSON summary is the only output on stdout, so that it can be parsed
+	summary.progress = os.Stdout
+	if c.GlobalBool("json") {
+		summary.progress = os.Stderr
+	}
+	if dryRun {
+		fmt.Fprintln(summary.progress, "Dry run, nothing will be removed")
+	}
+
+	if components.Containers {
+		removeContainers(profile, &summary)
+	}
+	if components.Images {
+		removeImages(c.String("tag"), getImageConfig(c), &summary)
+	}
+	if components.Network {
+		removeNetworks(profile, &summary)
+	}
+	if components.Volumes {
+		removeVolumes(profile, components.Workspace, &summary)
+	}
+	if components.Connection {
+		summary.Connection = profile.ConnectionID()
+		if !dryRun {
+			conErr := connections.RemoveProfileConnection(profile)
+			if conErr != nil {
+				exitWithError(conErr)
+			}
+		}
+	}
+	printRemoveSummary(c, profile, components, summary)
+}
+
+// removeComponents : The parts of a local Codewind that remove acts on
+type removeComponents struct {
+	Images     bool
+	Containers bool
+	Network    bool
+	Volumes    bool
+	// Workspace is the named volume a profile can keep its workspace in, only removed when asked for
+	Workspace  bool
+	Connection bool
+}
+
+// removeSummary : What remove removed, or would remove in a dry run
+type removeSummary struct {
+	DryRun     bool     `json:"dryRun,omitempty"`
+	Images     []string `json:"images"`
+	Containers []string `json:"containers"`
+	Networks   []string `json:"networks"`
+	Volumes    []string `json:"volumes"`
+	Connection string   `json:"connection,omitempty"`
+	// progress is where each step is reported as it is taken
+	progress io.Writer
+}
+
+// getRemoveComponents : The components selected with --images, --containers, --network and --volumes. Without
+// them the default profile removes its images and network, and a named profile everything but its images, which
+// are shared, and the workspace it keeps in a named volume. --keep-workspace leaves out every volume
+func getRemoveComponents(c *cli.Context, profile utils.LocalProfile) removeComponents {
+	components := removeComponents{
+		Images:     c.Bool("images"),
+		Containers: c.Bool("containers"),
+		Network:    c.Bool("network"),
+		Volumes:    c.Bool("volumes"),
+		Workspace:  c.Bool("volumes"),
+	}
+	if components.Volumes && c.Bool("keep-workspace") {
+		exitWithUsageError("Only one of --volumes and --keep-workspace can be given")
+	}
+	if components == (removeComponents{}) {
+		if profile.IsDefault() {
+			components = removeComponents{Images: true, Network: true}
+		} else {
+			components = removeComponents{Containers: true, Network: true, Volumes: !c.Bool("keep-workspace"), Connection: true}
+		}
+	}
+	return components
+}
+
+// removeContainers : Stops and removes the Codewind containers of the profile and the project containers on its
+// network
+func removeContainers(profile utils.LocalProfile, summary *removeSummary) {
+	for _, container := range utils.GetContainerList() {
+		if !profile.Owns(container) || !(isCodewindContainer(container) || isProjectContainer(container)) {
+			continue
+		}
+		name := strings.TrimPrefix(container.Names[0], "/")
+		summary.Containers = append(summary.Containers, name)
+		if summary.DryRun {
+			fmt.Fprintln(summary.progress, "Would stop container", name)
+			continue
+		}
+		fmt.Fprintln(summary.progress, "Stopping container ", name, "... ")
+		utils.StopContainer(container)
+	}
+}
+
+// removeImages : Removes the Codewind and project images, of every Codewind version without a tag
+func removeImages(tag string, imageConfig utils.ImageConfig, summary *removeSummary) {
 	imageArr := []string{
 		"eclipse/codewind-pfe-amd64:" + tag,
 		"eclipse/codewind-performance-amd64:" + tag,
+		strings.TrimPrefix(imageConfig.PFERepository(), "docker.io/") + ":" + tag,
+		strings.TrimPrefix(imageConfig.PerformanceRepository(), "docker.io/") + ":" + tag,
+		utils.LocalPFEImage + ":" + tag,
+		utils.LocalPerformanceImage + ":" + tag,
 		"cw-",
 	}
-	networkName := "codewind"
-
-	images := utils.GetImageList()
-
-	fmt.Println("Removing Codewind docker images..")
-
-	for _, image := range images {
+	if !summary.DryRun {
+		fmt.Fprintln(summary.progress, "Removing Codewind docker images..")
+	}
+	for _, image := range utils.GetImageList() {
 		imageRepo := strings.Join(image.RepoDigests, " ")
 		imageTags := strings.Join(image.RepoTags, " ")
 		for _, key := range imageArr {
 			if strings.HasPrefix(imageRepo, key) || strings.HasPrefix(imageTags, key) {
+				name := image.ID
 				if len(image.RepoTags) > 0 {
-					fmt.Println("Deleting Image ", image.RepoTags[0], "... ")
-				} else {
-					fmt.Println("Deleting Image ", image.ID, "... ")
+					name = image.RepoTags[0]
 				}
+				summary.Images = append(summary.Images, name)
+				if summary.DryRun {
+					fmt.Fprintln(summary.progress, "Would delete image", name)
+					break
+				}
+				fmt.Fprintln(summary.progress, "Deleting Image ", name, "... ")
 				utils.RemoveImage(image.ID)
+				break
 			}
 		}
 	}
+}
+
+// removeNetworks : Removes the networks of the profile. Those of the default profile are any named codewind,
+// except the networks of other profiles, which are named codewind-<profile>_network
+func removeNetworks(profile utils.LocalProfile, summary *removeSummary) {
+	networkName := "codewind"
+	for _, network := range utils.GetNetworkList() {
+		isProfileNetwork := strings.HasPrefix(network.Name, profile.NetworkPrefix())
+		if profile.IsDefault() {
+			isProfileNetwork = strings.Contains(network.Name, networkName) && !strings.HasPrefix(network.Name, networkName+"-")
+		}
+		if !isProfileNetwork {
+			continue
+		}
+		summary.Networks = append(summary.Networks, network.Name)
+		if summary.DryRun {
+			fmt.Fprintln(summary.progress, "Would remove docker network", network.Name)
+			continue
+		}
+		fmt.Fprint(summary.progress, "Removing docker network: ", network.Name, "... ")
+		utils.RemoveNetwork(network)
+	}
+}
 
-	networks := utils.GetNetworkList()
+// removeVolumes : Removes the workspace volume of the profile, and with withWorkspace the named volume it keeps
+// its workspace in if it uses one
+func removeVolumes(profile utils.LocalProfile, withWorkspace bool, summary *removeSummary) {
+	volumes := []string{profile.WorkspaceVolume()}
+	if withWorkspace && profile.Workspace().NamedVolume {
+		volumes = append(volumes, profile.DataVolume())
+	}
+	for _, volume := range volumes {
+		summary.Volumes = append(summary.Volumes, volume)
+		if summary.DryRun {
+			fmt.Fprintln(summary.progress, "Would remove docker volume", volume)
+			continue
+		}
+		fmt.Fprintln(summary.progress, "Removing docker volume: ", volume, "... ")
+		err := utils.RemoveVolume(volume)
+		if err != nil {
+			errors.Exit(errors.Docker, "", "Unable to remove volume "+volume+": "+err.Error())
+		}
+	}
+}
+
+// printRemoveSummary : Prints what was removed, as JSON with the global --json flag. Removing a named profile
+// also says where its workspace was kept
+func printRemoveSummary(c *cli.Context, profile utils.LocalProfile, components removeComponents, summary removeSummary) {
+	if c.GlobalBool("json") {
+		output, _ := json.Marshal(summary)
+		fmt.Println(string(output))
+		return
+	}
+	verb := "Removed"
+	if summary.DryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("%v %v, %v, %v and %v\n", verb, countOf(len(summary.Images), "image"), countOf(len(summary.Containers), "container"),
+		countOf(len(summary.Networks), "network"), countOf(len(summary.Volumes), "volume"))
+	if summary.DryRun || !components.Connection {
+		return
+	}
+	if !components.Volumes {
+		fmt.Println("Removed profile " + profile.Name + ", its workspace volume " + profile.WorkspaceVolume() + " was kept")
+	} else if profile.Workspace().NamedVolume {
+		fmt.Println("Removed profile " + profile.Name + ", its workspace volume " + profile.DataVolume() + " was kept")
+	} else {
+		fmt.Println("Removed profile " + profile.Name + ", its workspace directory " + profile.WorkspaceDirectory() + " was kept")
+	}
+}
+
+// countOf : A count of things, such as 1 image or 2 images
+func countOf(count int, thing string) string {
+	if count == 1 {
+		return "1 " + thing
+	}
+	return strconv.Itoa(count) + " " + thing + "s"
+}
 
-	for _, network := range networks {
-		if strings.Contains(network.Name, networkName) {
-			fmt.Print("Removing docker network: ", network.Name, "... ")
-			utils.RemoveNetwork(network)
+// isDryRun : true when the command, a command it is a subcommand of, or cwctl itself was given --dry-run, so that
+// only what it would change is printed. Each context is checked, as GlobalBool stops at the first parent with a
+// dry-run flag of its own, such as remove for remove remote
+func isDryRun(c *cli.Context) bool {
+	for context := c; context != nil; context = context.Parent() {
+		if context.Bool("dry-run") {
+			return true
 		}
 	}
+	return false
 }
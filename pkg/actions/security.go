@@ -18,113 +18,235 @@ import (
 	"os"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 // SecurityTokenGet : Authenticate and retrieve an access_token
 func SecurityTokenGet(c *cli.Context) {
-	auth, err := security.SecAuthenticate(http.DefaultClient, c, "", "")
+	var auth *security.AuthToken
+	var err *security.SecError
+	conID := strings.TrimSpace(c.String("conid"))
+	flow := strings.TrimSpace(strings.ToLower(c.String("flow")))
+	if flow == security.FlowAuthCode {
+		auth, err = security.SecAuthenticateAuthCode(http.DefaultClient, c)
+	} else if flow != "" && flow != security.FlowPassword {
+		exitWithUsageError("Unknown login flow " + flow + ", use " + security.FlowPassword + " or " + security.FlowAuthCode)
+	} else if conID != "" {
+		// The connection is authoritative, its auth details are discovered from its gatekeeper
+		if c.String("host") != "" || c.String("realm") != "" || c.String("client") != "" {
+			logr.Warnln("Ignoring --host, --realm and --client as they are discovered from connection " + conID)
+		}
+		auth, err = security.SecAuthenticateConnection(http.DefaultClient, conID, c.String("username"), c.String("password"))
+	} else {
+		auth, err = security.SecAuthenticate(http.DefaultClient, c, "", "")
+	}
 	if err == nil && auth != nil {
 		utils.PrettyPrintJSON(auth)
 	} else {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
+	}
+	exitSuccess()
+}
+
+// SecurityTokenLogout : End the session of a connection and remove its cached tokens
+func SecurityTokenLogout(c *cli.Context) {
+	err := security.SecLogout(http.DefaultClient, c.String("conid"), c.String("username"))
+	if err != nil {
+		exitWithError(err)
 	}
-	os.Exit(0)
+	utils.PrettyPrintJSON(security.Result{Status: "OK"})
+	exitSuccess()
 }
 
 // SecurityCreateRealm : Create a realm in Keycloak
 func SecurityCreateRealm(c *cli.Context) {
 	err := security.SecRealmCreate(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	} else {
 		utils.PrettyPrintJSON(security.Result{Status: "OK"})
 	}
-	os.Exit(0)
+	exitSuccess()
+}
+
+// SecurityRealmExport : Export a realm from Keycloak to a file
+func SecurityRealmExport(c *cli.Context) {
+	err := security.SecRealmExport(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(security.Result{Status: "OK"})
+	exitSuccess()
+}
+
+// SecurityRealmImport : Create or add to a realm in Keycloak from an exported file
+func SecurityRealmImport(c *cli.Context) {
+	result, err := security.SecRealmImport(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(result)
+	exitSuccess()
 }
 
 // SecurityClientCreate : Create a new client in Keycloak
 func SecurityClientCreate(c *cli.Context) {
 	err := security.SecClientCreate(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	} else {
 		utils.PrettyPrintJSON(security.Result{Status: "OK"})
 	}
-	os.Exit(0)
+	exitSuccess()
 }
 
 // SecurityClientGet : Retrieve a client configuration from Keycloak
 func SecurityClientGet(c *cli.Context) {
 	registeredClient, err := security.SecClientGet(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	if registeredClient != nil {
 		utils.PrettyPrintJSON(registeredClient)
-		os.Exit(0)
+		exitSuccess()
 	}
 	utils.PrettyPrintJSON(security.Result{Status: "Not found"})
-	os.Exit(1)
+	os.Exit(errors.Auth.ExitCode)
 }
 
 // SecurityClientGetSecret : Retrieve a client secret from Keycloak
 func SecurityClientGetSecret(c *cli.Context) {
 	registeredClientSecret, err := security.SecClientGetSecret(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	if registeredClientSecret != nil {
 		utils.PrettyPrintJSON(registeredClientSecret)
-		os.Exit(0)
+		exitSuccess()
 	}
 	utils.PrettyPrintJSON(security.Result{Status: "Not found"})
-	os.Exit(1)
+	os.Exit(errors.Auth.ExitCode)
 }
 
 // SecurityUserCreate : Create a user in a Keycloak realm
 func SecurityUserCreate(c *cli.Context) {
 	err := security.SecUserCreate(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	} else {
 		utils.PrettyPrintJSON(security.Result{Status: "OK"})
 	}
-	os.Exit(0)
+	exitSuccess()
 }
 
 // SecurityUserGet : Retrieve the user detail from Keycloak
 func SecurityUserGet(c *cli.Context) {
 	registeredUser, err := security.SecUserGet(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	if registeredUser != nil {
 		utils.PrettyPrintJSON(registeredUser)
-		os.Exit(0)
+		exitSuccess()
 	}
 	utils.PrettyPrintJSON(security.Result{Status: "Not found"})
-	os.Exit(1)
+	os.Exit(errors.Auth.ExitCode)
 }
 
 // SecurityUserSetPassword : Set a users password in Keycloak
 func SecurityUserSetPassword(c *cli.Context) {
 	err := security.SecUserSetPW(c)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	utils.PrettyPrintJSON(security.Result{Status: "OK"})
-	os.Exit(0)
+	exitSuccess()
+}
+
+// SecurityUserList : List the users of a realm in Keycloak
+func SecurityUserList(c *cli.Context) {
+	users, err := security.SecUserList(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(users)
+	exitSuccess()
+}
+
+// SecurityUserDelete : Delete a user from Keycloak
+func SecurityUserDelete(c *cli.Context) {
+	err := security.SecUserDelete(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(security.Result{Status: "OK"})
+	exitSuccess()
+}
+
+// SecurityUserImport : Create the users of a CSV file in Keycloak, with their initial passwords and roles
+func SecurityUserImport(c *cli.Context) {
+	results, err := security.SecUserImport(http.DefaultClient, c)
+	if results != nil {
+		utils.PrettyPrintJSON(results)
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	exitSuccess()
+}
+
+// SecurityUserSync : Sync the users of a realm from its user federation, such as LDAP
+func SecurityUserSync(c *cli.Context) {
+	results, err := security.SecUserSync(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(results)
+	exitSuccess()
+}
+
+// SecurityRoleCreate : Create a realm role in Keycloak
+func SecurityRoleCreate(c *cli.Context) {
+	err := security.SecRoleCreate(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(security.Result{Status: "OK"})
+	exitSuccess()
+}
+
+// SecurityRoleAdd : Grant a realm role to a user in Keycloak
+func SecurityRoleAdd(c *cli.Context) {
+	err := security.SecRoleAddToUser(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(security.Result{Status: "OK"})
+	exitSuccess()
+}
+
+// SecurityRoleRemove : Revoke a realm role from a user in Keycloak
+func SecurityRoleRemove(c *cli.Context) {
+	err := security.SecRoleRemoveFromUser(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(security.Result{Status: "OK"})
+	exitSuccess()
+}
+
+// SecurityRoleList : List the realm roles of a user in Keycloak
+func SecurityRoleList(c *cli.Context) {
+	roles, err := security.SecRoleListForUser(http.DefaultClient, c)
+	if err != nil {
+		exitWithError(err)
+	}
+	utils.PrettyPrintJSON(roles)
+	exitSuccess()
 }
 
 // SecurityKeyUpdate : Creates or updates a key in the platforms keyring
@@ -134,12 +256,11 @@ func SecurityKeyUpdate(c *cli.Context) {
 	password := strings.TrimSpace(c.String("password"))
 	err := security.SecKeyUpdate(connectionID, username, password)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(security.Result{Status: "OK"})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
 }
 
 // SecurityKeyValidate : Checks the key is available in the platform keyring
@@ -148,10 +269,31 @@ func SecurityKeyValidate(c *cli.Context) {
 	username := strings.TrimSpace(strings.ToLower(c.String("username")))
 	_, err := security.SecKeyGetSecret(connectionID, username)
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		exitWithError(err)
 	}
 	response, _ := json.Marshal(security.Result{Status: "OK"})
 	fmt.Println(string(response))
-	os.Exit(0)
+	exitSuccess()
+}
+
+// SecurityKeyringList : Lists the secrets kept in the keyring for connections, without the secrets themselves
+func SecurityKeyringList(c *cli.Context) {
+	entries, err := security.SecKeyringList(strings.TrimSpace(c.String("conid")))
+	if err != nil {
+		exitWithError(err)
+	}
+	response, _ := json.Marshal(entries)
+	fmt.Println(string(response))
+	exitSuccess()
+}
+
+// SecurityKeyringDelete : Deletes the secrets kept in the keyring for a connection, or one of its users
+func SecurityKeyringDelete(c *cli.Context) {
+	deleted, err := security.SecKeyringDelete(c.String("conid"), c.String("username"))
+	if err != nil {
+		exitWithError(err)
+	}
+	response, _ := json.Marshal(deleted)
+	fmt.Println(string(response))
+	exitSuccess()
 }
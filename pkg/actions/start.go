@@ -12,30 +12,136 @@
 package actions
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 //StartCommand to start the codewind conainers
-func StartCommand(c *cli.Context, tempFilePath string, healthEndpoint string) {
+func StartCommand(c *cli.Context, healthEndpoint string) {
+	if c.Bool("print-compose") {
+		printComposeFile(c)
+	}
+
 	status := utils.CheckContainerStatus()
+	profile := utils.ActiveProfile()
 
 	if status {
 		fmt.Println("Codewind is already running!")
 	} else {
-		tag := c.String("tag")
+		images := getImageConfig(c)
+		ports := getPortConfig(c, true)
+		workspace := getWorkspaceConfig(c, true)
 		debug := c.Bool("debug")
-		fmt.Println("Debug:", debug)
+		logr.Debugln("Debug:", debug)
+
+		if err := utils.CheckPFEHost(); err != nil {
+			errors.Exit(errors.Network, "", err.Error())
+		}
+		if err := utils.CheckPFERoute(); err != nil {
+			errors.Exit(errors.Network, "", err.Error())
+		}
+		checkUnmanagedContainers(profile)
+
+		stopBeforeStart(profile)
+
+		if err := images.CheckArchitecture(); err != nil {
+			errors.Exit(errors.Docker, "", err.Error())
+		}
+		utils.EnsureLocalImages(images)
+		if err := utils.VerifyImageArchitectures(images); err != nil {
+			errors.Exit(errors.Docker, "", err.Error())
+		}
+		if c.Bool("verify") {
+			if err := utils.VerifyImageDigests(images); err != nil {
+				errors.Exit(errors.Docker, "", err.Error())
+			}
+		}
+		composeFile := utils.ComposeFilePath(profile)
+		if err := utils.WriteComposeFile(composeFile, images, ports, workspace, debug); err != nil {
+			errors.Exit(errors.Filesystem, "", "Unable to write the docker-compose file: "+err.Error())
+		}
+		report := utils.StartCodewind(composeFile, ports, healthEndpoint, getStartOptions(c))
+
+		// The phase report is always printed on failure, so a slow phase can be identified and its timeout raised
+		if report.Status != utils.PhaseStatusOK || c.GlobalBool("json") {
+			jsonResponse, _ := json.MarshalIndent(report, "", "\t")
+			fmt.Println(string(jsonResponse))
+		} else if c.Bool("no-wait") {
+			fmt.Println("Codewind containers created on " + report.URL + ", use status to check when they are ready")
+		} else {
+			fmt.Println("Codewind successfully started on " + report.URL)
+		}
+		if report.Status != utils.PhaseStatusOK {
+			errors.Exit(errors.Docker, "", "Codewind failed to start. The containers section of the report shows which container is failing with its last log lines, or increase the timeout of the phase that failed")
+		}
+	}
+
+	// Projects are bound to a profile through its own connection
+	if !profile.IsDefault() {
+		_, conErr := connections.AddProfileConnection(profile)
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+	}
+}
 
-		// Stop all running project containers and remove codewind networks
-		StopAllCommand()
+// printComposeFile : Prints the docker-compose file start would run, without starting Codewind
+func printComposeFile(c *cli.Context) {
+	content, err := utils.GenerateComposeFile(getImageConfig(c), getPortConfig(c, false), getWorkspaceConfig(c, false))
+	if err != nil {
+		errors.Exit(errors.Filesystem, "", "Unable to generate the docker-compose file: "+err.Error())
+	}
+	fmt.Print(string(content))
+	exitSuccess()
+}
 
-		utils.CreateTempFile(tempFilePath)
-		utils.WriteToComposeFile(tempFilePath, debug)
-		utils.DockerCompose(tempFilePath, tag)
-		utils.DeleteTempFile(tempFilePath) // Remove installer-docker-compose.yaml
-		utils.PingHealth(healthEndpoint)
+// stopBeforeStart : Stops the containers and removes the network a previous start of the profile left behind,
+// with the project containers on its network, leaving those of other profiles running. A deployment the profile
+// adopted is replaced by the one cwctl starts
+func stopBeforeStart(profile utils.LocalProfile) {
+	for _, container := range utils.GetContainerList() {
+		if profile.Owns(container) && (isCodewindContainer(container) || isProjectContainer(container)) {
+			fmt.Println("Stopping container ", container.Names[0], "... ")
+			utils.StopContainer(container)
+		}
+	}
+	if err := profile.ReleaseAdoptedDeployment(); err != nil {
+		logr.Warnln("Unable to forget the adopted deployment: " + err.Error())
+	}
+	for _, network := range utils.GetNetworkList() {
+		if strings.HasPrefix(network.Name, profile.NetworkPrefix()) {
+			fmt.Print("Removing docker network: ", network.Name, "... ")
+			utils.RemoveNetwork(network)
+		}
+	}
+}
+
+// getStartOptions : the phase timeouts, which default to utils.DefaultStartTimeouts, and how to wait for Codewind.
+// With --verbose each phase is reported as it runs, on stderr when the report is printed as JSON
+func getStartOptions(c *cli.Context) utils.StartOptions {
+	options := utils.StartOptions{
+		Timeouts: utils.StartTimeouts{
+			ComposeUp:         c.Duration("compose-timeout"),
+			Network:           c.Duration("network-timeout"),
+			PFEHealth:         c.Duration("pfe-timeout"),
+			PerformanceHealth: c.Duration("performance-timeout"),
+		},
+		Timeout: c.Duration("timeout"),
+		NoWait:  c.Bool("no-wait"),
+	}
+	if c.Bool("verbose") {
+		options.Progress = os.Stdout
+		if c.GlobalBool("json") {
+			options.Progress = os.Stderr
+		}
 	}
+	return options
 }
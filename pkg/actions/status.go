@@ -12,86 +12,192 @@
 package actions
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
 	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 // StatusCommand : to show the status
 func StatusCommand(c *cli.Context) {
 	conID := c.String("conid")
-	if conID != "" && conID != "local" {
+	if connections.IsAllConnections(conID) && (c.Bool("watch") || c.Bool("deep")) {
+		exitWithUsageError("--watch and --deep check a single connection, status of --conid all probes every connection")
+	} else if c.Bool("cached") && (conID == "" || connections.IsLocal(conID) || connections.IsAllConnections(conID) || c.Bool("watch") || c.Bool("deep")) {
+		exitWithUsageError("--cached reports the last known state of a single remote connection, and cannot be used with --watch or --deep")
+	} else if connections.IsAllConnections(conID) {
+		StatusCommandAllConnections(c)
+	} else if c.Bool("watch") {
+		StatusCommandWatch(c)
+	} else if c.String("webhook") != "" || c.IsSet("interval") {
+		exitWithUsageError("--interval and --webhook are only used with --watch")
+	} else if conID != "" && !connections.IsLocal(conID) && c.Bool("deep") {
+		StatusCommandDeepProbe(c)
+	} else if conID != "" && !connections.IsLocal(conID) {
 		StatusCommandRemoteConnection(c)
 	} else {
+		// The connection of a local profile selects that profile
+		if conID != "" {
+			utils.SetProfile(connections.LocalProfileOf(conID).Name)
+		}
 		StatusCommandLocalConnection(c)
 	}
 }
 
-// StatusCommandRemoteConnection : Output remote connection details
+// StatusCommandRemoteConnection : Output remote connection details. The gatekeeper is checked before
+// authenticating, and the state is cached so that --cached can report it without contacting the connection
 func StatusCommandRemoteConnection(c *cli.Context) {
-	jsonOutput := c.Bool("json") || c.GlobalBool("json")
+	format := outputFormat(c)
 	conID := c.String("conid")
 	connection, conErr := connections.GetConnectionByID(conID)
 	if conErr != nil {
-		fmt.Println(conErr)
+		exitWithError(conErr)
+	}
+
+	state := connections.GetCachedConnectionState(connection.ID)
+	certificates := []connections.CertificateStatus{}
+	if !c.Bool("cached") {
+		timeout := statusRequestTimeout(c)
+		// Expired self-signed ingress certificates are a common reason for remote connections to stop working
+		certificates = connections.CheckConnectionCertificates(*connection, c.Int("certdays"))
+		if format == outputText {
+			printCertificateWarnings(certificates)
+		}
+		httpClient := &http.Client{Timeout: timeout}
+		pfeClient := sechttp.NewRemotePFEClient(httpClient, *connection)
+		state, conErr = connections.SaveConnectionState(connections.CheckConnectionState(httpClient, pfeClient, *connection, sechttp.IsAuthError))
+		if conErr != nil {
+			logr.Warnln("Unable to cache the state of the connection: " + conErr.Desc)
+		}
+	}
+
+	status := "started"
+	if state.State == connections.StateUnreachable || state.State == connections.StateUnknown {
+		status = "stopped"
+	}
+	if format != outputText {
+		type remoteStatus struct {
+			Status       string                          `json:"status"`
+			Certificates []connections.CertificateStatus `json:"certificates"`
+			connections.ConnectionState
+		}
+		printOutput(format, &remoteStatus{Status: status, Certificates: certificates, ConnectionState: state})
+	} else {
+		printConnectionState(state)
+	}
+	if status == "stopped" {
 		os.Exit(1)
 	}
+	exitSuccess()
+}
 
-	PFEReady, err := apiroutes.IsPFEReady(http.DefaultClient, connection.URL)
-	if err != nil || PFEReady == false {
-		if jsonOutput {
-			type status struct {
-				Status string `json:"status"`
+// printConnectionState : Describe the state of a remote connection, and when it was last healthy if it is not now
+func printConnectionState(state connections.ConnectionState) {
+	when := ""
+	if state.Cached && state.Checked != nil {
+		when = " when last checked at " + state.Checked.Format(time.RFC3339)
+	}
+	switch state.State {
+	case connections.StateUnknown:
+		fmt.Println("Remote connection " + state.ConnectionID + " has not been checked yet")
+	case connections.StateHealthy:
+		if when == "" {
+			fmt.Println("Remote Codewind is installed and running")
+		} else {
+			fmt.Println("Remote Codewind was installed and running" + when)
+		}
+	case connections.StateUnauthenticated:
+		fmt.Println("Remote Codewind responded but could not be authenticated against" + when + ": " + state.Error)
+	default:
+		fmt.Println("Codewind did not respond on remote connection " + state.ConnectionID + when + ": " + state.Error)
+	}
+	if state.State != connections.StateHealthy && state.LastHealthy != nil {
+		fmt.Println("Last healthy at " + state.LastHealthy.Format(time.RFC3339))
+	}
+}
+
+// Exit codes of a deep status probe, so that scripts can tell an outage from a certificate that needs renewing
+const (
+	exitStatusUnreachable = 2
+	exitStatusDegraded    = 3
+)
+
+// StatusCommandDeepProbe : Output the reachability, version and certificate of each remote Codewind component
+func StatusCommandDeepProbe(c *cli.Context) {
+	format := outputFormat(c)
+	conID := c.String("conid")
+	connection, conErr := connections.GetConnectionByID(conID)
+	if conErr != nil {
+		exitWithError(conErr)
+	}
+
+	httpClient := &http.Client{Timeout: statusRequestTimeout(c)}
+	pfeClient := sechttp.NewRemotePFEClient(httpClient, *connection)
+	report := connections.ProbeConnection(httpClient, pfeClient, *connection, c.Int("certdays"))
+	if format != outputText {
+		printOutput(format, report)
+	} else {
+		for _, component := range report.Components {
+			line := fmt.Sprintf("%-12s %-12s %s", component.Name, reachability(component.Reachable), component.URL)
+			if component.Version != "" {
+				line += " (version " + component.Version + ")"
 			}
-			resp := &status{
-				Status: "stopped",
+			fmt.Println(line)
+			if component.Error != "" {
+				fmt.Println("             " + component.Error)
 			}
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
+			if component.Certificate != nil {
+				printCertificateWarnings([]connections.CertificateStatus{*component.Certificate})
 			}
-			output, _ := json.Marshal(resp)
-			fmt.Println(string(output))
-			os.Exit(1)
-		} else {
-			fmt.Println("Codewind did not respond on remote connection", conID)
-			log.Println(err)
 		}
+		fmt.Println("Remote Codewind is " + report.Status)
 	}
 
-	// Codewind responded
-	if jsonOutput {
-		type status struct {
-			Status   string   `json:"status"`
-			URL      string   `json:"url"`
-			Versions []string `json:"installed-versions"`
-			Started  []string `json:"started"`
-		}
-		resp := &status{
-			Status: "started",
+	switch report.Status {
+	case connections.HealthUnreachable:
+		os.Exit(exitStatusUnreachable)
+	case connections.HealthDegraded:
+		os.Exit(exitStatusDegraded)
+	}
+	exitSuccess()
+}
+
+func reachability(reachable bool) string {
+	if reachable {
+		return "reachable"
+	}
+	return "unreachable"
+}
+
+// printCertificateWarnings : Warn about ingress certificates which have expired, are about to, or could not be read
+func printCertificateWarnings(certificates []connections.CertificateStatus) {
+	for _, certificate := range certificates {
+		if certificate.Error != "" {
+			logr.Warnf("Unable to check the %s certificate at %s: %s\n", certificate.Name, certificate.URL, certificate.Error)
+		} else if certificate.Expired {
+			logr.Warnf("The %s certificate at %s expired on %s\n", certificate.Name, certificate.URL, certificate.Expires.Format("2006-01-02"))
+		} else if certificate.Expiring {
+			logr.Warnf("The %s certificate at %s expires in %d days on %s\n", certificate.Name, certificate.URL, certificate.DaysLeft, certificate.Expires.Format("2006-01-02"))
 		}
-		output, _ := json.Marshal(resp)
-		fmt.Println(string(output))
-	} else {
-		fmt.Println("Remote Codewind is installed and running")
 	}
-	os.Exit(0)
 }
 
 // StatusCommandLocalConnection : Output local connection details
 func StatusCommandLocalConnection(c *cli.Context) {
-	jsonOutput := c.Bool("json") || c.GlobalBool("json")
+	format := outputFormat(c)
 	if utils.CheckContainerStatus() {
 		// Started
 		hostname, port := utils.GetPFEHostAndPort()
-		if jsonOutput {
+		if format != outputText {
 
 			imageTagArr := utils.GetImageTags()
 			containerTagArr := utils.GetContainerTags()
@@ -105,22 +211,37 @@ func StatusCommandLocalConnection(c *cli.Context) {
 
 			resp := &status{
 				Status:   "started",
-				URL:      "http://" + hostname + ":" + port,
+				URL:      "http://" + net.JoinHostPort(hostname, port),
 				Versions: imageTagArr,
 				Started:  containerTagArr,
 			}
 
-			output, _ := json.Marshal(resp)
-			fmt.Println(string(output))
+			printOutput(format, resp)
 		} else {
-			fmt.Println("Codewind is installed and running on http://" + hostname + ":" + port)
+			fmt.Println("Codewind is installed and running on http://" + net.JoinHostPort(hostname, port))
 		}
-		os.Exit(0)
+		exitSuccess()
+	}
+
+	if unmanaged := utils.FindUnmanagedContainers(); len(unmanaged) > 0 {
+		// Running, but not started by cwctl
+		names := utils.ContainerNames(unmanaged)
+		if format != outputText {
+			type status struct {
+				Status     string   `json:"status"`
+				Containers []string `json:"containers"`
+			}
+			printOutput(format, &status{Status: "unmanaged", Containers: names})
+		} else {
+			fmt.Println("Codewind is running in containers that cwctl did not start: " + strings.Join(names, ", "))
+			fmt.Println("Run 'cwctl adopt' to manage them with cwctl")
+		}
+		exitSuccess()
 	}
 
 	if utils.CheckImageStatus() {
 		// Installed but not started
-		if jsonOutput {
+		if format != outputText {
 
 			imageTagArr := utils.GetImageTags()
 
@@ -134,21 +255,129 @@ func StatusCommandLocalConnection(c *cli.Context) {
 				Versions: imageTagArr,
 			}
 
-			output, _ := json.Marshal(resp)
-			fmt.Println(string(output))
+			printOutput(format, resp)
 		} else {
 			fmt.Println("Codewind is installed but not running")
 		}
-		os.Exit(0)
+		exitSuccess()
 	} else {
 		// Not installed
-		if jsonOutput {
-			output, _ := json.Marshal(map[string]string{"status": "uninstalled"})
-			fmt.Println(string(output))
+		if format != outputText {
+			printOutput(format, map[string]string{"status": "uninstalled"})
 		} else {
 			fmt.Println("Codewind is not installed")
 		}
-		os.Exit(0)
+		exitSuccess()
 	}
 	return
 }
+
+// StatusCommandAllConnections : Output the health of every connection, probing each component of the remote ones
+func StatusCommandAllConnections(c *cli.Context) {
+	format := outputFormat(c)
+	httpClient := &http.Client{Timeout: statusRequestTimeout(c)}
+	broadcastToConnections(format, func(connection connections.Connection) (interface{}, error) {
+		if connections.IsLocal(connection.ID) {
+			utils.SetProfile(connections.LocalProfileOf(connection.ID).Name)
+			return probeLocalConnection(httpClient, connection.ID), nil
+		}
+		pfeClient := sechttp.NewRemotePFEClient(httpClient, connection)
+		return connections.ProbeConnection(httpClient, pfeClient, connection, c.Int("certdays")), nil
+	}, func(result interface{}) {
+		report := result.(*connections.HealthReport)
+		fmt.Println("Codewind is " + report.Status)
+		for _, component := range report.Components {
+			if component.Error != "" {
+				fmt.Println(component.Name + ": " + component.Error)
+			}
+		}
+	})
+}
+
+// StatusCommandWatch : Probe the health of a connection every --interval until interrupted, printing an event
+// whenever it changes and posting it to --webhook when one is given
+func StatusCommandWatch(c *cli.Context) {
+	format := outputFormat(c)
+	interval, err := time.ParseDuration(strings.TrimSpace(c.String("interval")))
+	if err != nil || interval < time.Second {
+		exitWithUsageError("--interval must be a duration of at least 1s, such as 30s or 5m")
+	}
+	webhook := strings.TrimSpace(c.String("webhook"))
+	// A request which never completes would otherwise stop the watch, or each event waiting for the webhook
+	httpClient := &http.Client{Timeout: statusRequestTimeout(c)}
+
+	conID := c.String("conid")
+	if conID == "" {
+		conID = "local"
+	}
+	watcher := connections.HealthWatcher{}
+	if connections.IsLocal(conID) {
+		utils.SetProfile(connections.LocalProfileOf(conID).Name)
+		watcher.Probe = func() *connections.HealthReport {
+			return probeLocalConnection(httpClient, strings.ToUpper(conID))
+		}
+	} else {
+		connection, conErr := connections.GetConnectionByID(conID)
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+		pfeClient := sechttp.NewRemotePFEClient(httpClient, *connection)
+		watcher.Probe = func() *connections.HealthReport {
+			return connections.ProbeConnection(httpClient, pfeClient, *connection, c.Int("certdays"))
+		}
+	}
+
+	for {
+		if event := watcher.Check(time.Now()); event != nil {
+			if format == outputYAML {
+				// Each event is a document of a YAML stream
+				fmt.Println("---")
+				printOutput(format, event)
+			} else if format == outputJSON {
+				printOutput(format, event)
+			} else {
+				fmt.Println(event.Time.Format(time.RFC3339) + " " + event.Text())
+			}
+			if webhook != "" {
+				err = connections.SendHealthWebhook(httpClient, webhook, *event)
+				if err != nil {
+					logr.Warnln("Unable to send the event to the webhook: " + err.Error())
+				}
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+// statusRequestTimeout : How long to wait for each request made to check a connection, from --timeout
+func statusRequestTimeout(c *cli.Context) time.Duration {
+	timeout, err := time.ParseDuration(strings.TrimSpace(c.String("timeout")))
+	if err != nil || timeout <= 0 {
+		exitWithUsageError("--timeout must be a duration such as 5s")
+	}
+	return timeout
+}
+
+// probeLocalConnection : The health of the active local Codewind, healthy when its containers are running and
+// PFE reports that it is ready
+func probeLocalConnection(httpClient utils.HTTPClient, conID string) *connections.HealthReport {
+	pfe := connections.ComponentHealth{Name: "pfe"}
+	if !utils.CheckContainerStatus() {
+		pfe.Error = "The Codewind containers are not running"
+	} else {
+		hostname, port := utils.GetPFEHostAndPort()
+		pfe.URL = "http://" + net.JoinHostPort(hostname, port)
+		ready, err := apiroutes.IsPFEReady(httpClient, pfe.URL)
+		if err != nil {
+			pfe.Error = err.Error()
+		} else if !ready {
+			pfe.Error = "PFE is not ready"
+		}
+		pfe.Reachable = err == nil && ready
+	}
+	report := connections.HealthReport{ConnectionID: conID, Status: connections.HealthOK, Components: []connections.ComponentHealth{pfe}}
+	if !pfe.Reachable {
+		report.Status = connections.HealthUnreachable
+	}
+	return &report
+}
@@ -15,38 +15,56 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/docker/docker/api/types"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
 )
 
-//StopAllCommand to stop codewind and project containers
-func StopAllCommand() {
-	containerArr := []string{
-		"codewind-pfe",
-		"codewind-performance",
-		"cw-",
-		"appsody",
+// isCodewindContainer : true for the PFE and performance containers of any profile
+func isCodewindContainer(container types.Container) bool {
+	return utils.CodewindService(container) != ""
+}
+
+// isProjectContainer : true for the application containers Codewind runs projects in
+func isProjectContainer(container types.Container) bool {
+	if strings.HasPrefix(container.Image, "cw-") {
+		return true
 	}
+	return strings.HasPrefix(container.Image, "appsody") && len(container.Names) > 0 && strings.Contains(container.Names[0], "cw-")
+}
 
+//StopAllCommand to stop codewind and project containers, or with --dry-run to list them
+func StopAllCommand(c *cli.Context) {
+	dryRun := isDryRun(c)
 	containers := utils.GetContainerList()
 
-	fmt.Println("Stopping Codewind and Project containers")
+	if dryRun {
+		fmt.Println("Dry run, nothing will be stopped or removed")
+	} else {
+		fmt.Println("Stopping Codewind and Project containers")
+	}
 	for _, container := range containers {
-		for _, key := range containerArr {
-			if strings.HasPrefix(container.Image, key) {
-				if key != "appsody" || strings.Contains(container.Names[0], "cw-") {
-					fmt.Println("Stopping container ", container.Names[0], "... ")
-					utils.StopContainer(container)
-					break
-				}
+		if isCodewindContainer(container) || isProjectContainer(container) {
+			if dryRun {
+				fmt.Println("Would stop container", container.Names[0])
+				continue
 			}
+			fmt.Println("Stopping container ", container.Names[0], "... ")
+			utils.StopContainer(container)
 		}
 	}
 
 	networkName := "codewind"
 	networks := utils.GetNetworkList()
-	fmt.Println("Removing Codewind docker networks..")
+	if !dryRun {
+		fmt.Println("Removing Codewind docker networks..")
+	}
 	for _, network := range networks {
 		if strings.Contains(network.Name, networkName) {
+			if dryRun {
+				fmt.Println("Would remove docker network", network.Name)
+				continue
+			}
 			fmt.Print("Removing docker network: ", network.Name, "... ")
 			utils.RemoveNetwork(network)
 		}
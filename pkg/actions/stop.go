@@ -13,27 +13,21 @@ package actions
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/eclipse/codewind-installer/pkg/utils"
 )
 
-//StopCommand to stop only the codewind containers
+//StopCommand to stop only the codewind containers of the profile selected with --profile
 func StopCommand() {
-	containerArr := [2]string{}
-	containerArr[0] = "codewind-pfe"
-	containerArr[1] = "codewind-performance"
-
+	profile := utils.ActiveProfile()
 	containers := utils.GetContainerList()
 
 	fmt.Println("Only stopping Codewind containers. To stop project containers, please use 'stop-all'")
 
 	for _, container := range containers {
-		for _, key := range containerArr {
-			if strings.HasPrefix(container.Image, key) {
-				fmt.Println("Stopping container ", container.Names, "... ")
-				utils.StopContainer(container)
-			}
+		if profile.Owns(container) && isCodewindContainer(container) {
+			fmt.Println("Stopping container ", container.Names, "... ")
+			utils.StopContainer(container)
 		}
 	}
 }
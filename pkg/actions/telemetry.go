@@ -0,0 +1,43 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils/telemetry"
+)
+
+// runningCommand is the name of the command being run, without its arguments, reported once it exits
+var runningCommand string
+
+// commandStarted is when the running command started
+var commandStarted time.Time
+
+// startUsageReport : Notes the command being run, so that its usage can be reported when the user has opted in
+func startUsageReport(command string) {
+	runningCommand = command
+	commandStarted = time.Now()
+	errors.SetExitHandler(func(category errors.Category) {
+		reportUsage(category.Code)
+	})
+}
+
+// reportUsage : Records the running command with its result, once
+func reportUsage(result string) {
+	if runningCommand == "" {
+		return
+	}
+	telemetry.Record(telemetry.NewEvent(runningCommand, time.Since(commandStarted), result, versionNum))
+	runningCommand = ""
+}
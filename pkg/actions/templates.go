@@ -14,37 +14,90 @@ package actions
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
 // ListTemplates lists project templates of which Codewind is aware.
 // Filter them by providing flags
 func ListTemplates(c *cli.Context) {
-	templates, err := apiroutes.GetTemplates(
-		c.String("projectStyle"),
+	projectStyle := c.String("projectStyle")
+	conID := strings.TrimSpace(c.String("conid"))
+	// Templates are printed as JSON unless a wide table or YAML is asked for
+	wide := strings.EqualFold(strings.TrimSpace(c.String("output")), "wide")
+	format := outputJSON
+	if !wide && outputFormat(c) == outputYAML {
+		format = outputYAML
+	}
+	if connections.IsAllConnections(conID) {
+		broadcastToConnections(format, func(connection connections.Connection) (interface{}, error) {
+			client, conErr := sechttp.NewPFEClient(connection.ID)
+			if conErr != nil {
+				return nil, conErr
+			}
+			templates, err := client.GetTemplateListings(projectStyle, c.Bool("showEnabledOnly"))
+			if err != nil {
+				return nil, err
+			}
+			return templates, nil
+		}, nil)
+	}
+	client := apiroutes.NewLocalPFEClient()
+	if conID != "" {
+		connectionClient, conErr := sechttp.NewPFEClient(conID)
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+		client = connectionClient
+	}
+	if projectStyle != "" {
+		styles, err := client.GetAllTemplateStyles()
+		if err != nil {
+			logr.Errorf("Error getting template styles: %q", err)
+			return
+		}
+		if !utils.ContainsStyle(styles, projectStyle) {
+			logr.Errorf("Unknown project style %q, must be one of: %s", projectStyle, strings.Join(styles, ", "))
+			return
+		}
+	}
+	templates, err := client.GetTemplateListings(
+		projectStyle,
 		c.Bool("showEnabledOnly"),
 	)
 	if err != nil {
-		log.Printf("Error getting templates: %q", err)
+		logr.Errorf("Error getting templates: %q", err)
 		return
 	}
-	if len(templates) > 0 {
-		PrettyPrintJSON(templates)
+	if wide {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "LABEL\tSTYLE\tLANGUAGE\tPROJECT TYPE\tREPO\tEXTENSION\tID\tDESCRIPTION")
+		for _, template := range templates {
+			fmt.Fprintln(w, template.Label+"\t"+template.Style+"\t"+template.Language+"\t"+template.ProjectType+"\t"+template.Repo+"\t"+template.Extension+"\t"+template.ID+"\t"+template.Description)
+		}
+		w.Flush()
+	} else if format == outputYAML {
+		printOutput(format, templates)
 	} else {
-		fmt.Println(templates)
+		PrettyPrintJSON(templates)
 	}
-
 }
 
 // ListTemplateStyles lists all template styles of which Codewind is aware.
 func ListTemplateStyles() {
-	styles, err := apiroutes.GetTemplateStyles()
+	styles, err := apiroutes.NewLocalPFEClient().GetAllTemplateStyles()
 	if err != nil {
-		log.Printf("Error getting template styles: %q", err)
+		logr.Errorf("Error getting template styles: %q", err)
 		return
 	}
 	PrettyPrintJSON(styles)
@@ -52,67 +105,127 @@ func ListTemplateStyles() {
 
 // ListTemplateRepos lists all template repos of which Codewind is aware.
 func ListTemplateRepos() {
-	repos, err := apiroutes.GetTemplateRepos()
+	repos, err := apiroutes.NewLocalPFEClient().GetTemplateRepos()
 	if err != nil {
-		log.Printf("Error getting template repos: %q", err)
+		logr.Errorf("Error getting template repos: %q", err)
 		return
 	}
 	PrettyPrintJSON(repos)
 }
 
 // AddTemplateRepo adds the provided template repo to PFE.
+// Unless --skip-validation is given, the repo's index is checked first so a broken repo is not added.
+// A repo which is already added, under an equivalent URL or the URL it redirects to, is refused, as is a
+// name another repo has. An index hosted in an artifact repository is fetched with the --provider given, whose
+// credentials are kept in the keyring.
 func AddTemplateRepo(c *cli.Context) {
-	url := c.String("url")
-	repos, err := apiroutes.AddTemplateRepo(
+	url := strings.TrimSpace(c.String("url"))
+	urls := []string{url}
+	provider := utils.TemplateProvider{
+		Type:            strings.ToLower(strings.TrimSpace(c.String("provider"))),
+		Username:        strings.TrimSpace(c.String("username")),
+		Password:        c.String("password"),
+		APIKeyHeader:    strings.TrimSpace(c.String("api-key-header")),
+		APIKey:          c.String("api-key"),
+		Region:          strings.TrimSpace(c.String("region")),
+		AccessKeyID:     strings.TrimSpace(c.String("access-key-id")),
+		SecretAccessKey: c.String("secret-access-key"),
+		SessionToken:    c.String("session-token"),
+	}
+	err := provider.Validate(url)
+	if err != nil {
+		exitWithUsageError(err.Error())
+	}
+	if !c.Bool("skip-validation") {
+		count, err := utils.ValidateTemplateIndex(provider.Client(http.DefaultClient), url)
+		if err != nil {
+			exitWithUsageError("Error validating template repo: " + err.Error() + ", fix the repo or add it with --skip-validation")
+		}
+		logr.Infof("Found %d templates in %s", count, url)
+		urls = append(urls, utils.ResolveTemplateRepoURL(provider.Client(http.DefaultClient), url))
+	}
+	client := apiroutes.NewLocalPFEClient()
+	existing, err := client.GetTemplateRepos()
+	if err != nil {
+		exitWithError(err)
+	}
+	err = utils.CheckNewTemplateRepo(existing, urls, strings.TrimSpace(c.String("name")))
+	if err != nil {
+		exitWithUsageError(err.Error())
+	}
+	repos, err := client.AddTemplateRepoWithProvider(
 		url,
 		c.String("description"),
 		c.String("name"),
+		provider,
 	)
 	if err != nil {
-		log.Printf("Error adding template repo: %q", err)
-		return
+		exitWithError(err)
 	}
-	extensions, err := apiroutes.GetExtensions()
+	if provider.NeedsCredentials() {
+		secErr := security.SecTemplateProviderSet(url, provider)
+		if secErr != nil {
+			exitWithError(secErr)
+		}
+	}
+	extensions, err := client.ListExtensions()
 	if err == nil {
 		utils.OnAddTemplateRepo(extensions, url, repos)
 	}
 	PrettyPrintJSON(repos)
 }
 
-// DeleteTemplateRepo deletes the provided template repo from PFE.
+// DeleteTemplateRepo deletes the template repo given by name or URL from PFE.
 func DeleteTemplateRepo(c *cli.Context) {
-	url := c.String("url")
-	extensions, err := apiroutes.GetExtensions()
+	nameOrURL := strings.TrimSpace(c.String("url"))
+	if nameOrURL == "" {
+		nameOrURL = strings.TrimSpace(c.String("name"))
+	}
+	if nameOrURL == "" {
+		nameOrURL = strings.TrimSpace(c.Args().First())
+	}
+	if nameOrURL == "" {
+		exitWithUsageError("Give the template repo to remove by its name or URL")
+	}
+	client := apiroutes.NewLocalPFEClient()
+	existing, err := client.GetTemplateRepos()
+	if err != nil {
+		exitWithError(err)
+	}
+	repo := utils.FindTemplateRepo(existing, nameOrURL)
+	if repo == nil {
+		exitWithUsageError("No template repo is named " + nameOrURL + " or has that URL")
+	}
+	url := repo.URL
+	extensions, err := client.ListExtensions()
 	if err == nil {
-		repos, err2 := apiroutes.GetTemplateRepos()
-		if err2 == nil {
-			utils.OnDeleteTemplateRepo(extensions, url, repos)
-		}
+		utils.OnDeleteTemplateRepo(extensions, url, existing)
 	}
-	repos, err := apiroutes.DeleteTemplateRepo(url)
+	repos, err := client.DeleteTemplateRepo(url)
 	if err != nil {
-		log.Printf("Error deleting template repo: %q", err)
-		return
+		exitWithError(err)
+	}
+	secErr := security.SecTemplateProviderRemove(url)
+	if secErr != nil {
+		logr.Warnf("Unable to remove the credentials of the template repo: %v", secErr.Desc)
 	}
 	PrettyPrintJSON(repos)
 }
 
 // EnableTemplateRepos enables templates repo of which Codewind is aware.
 func EnableTemplateRepos(c *cli.Context) {
-	repos, err := apiroutes.EnableTemplateRepos(c.Args())
+	repos, err := apiroutes.NewLocalPFEClient().EnableTemplateRepos(c.Args())
 	if err != nil {
-		log.Printf("Error enabling template repos: %q", err)
-		return
+		exitWithError(err)
 	}
 	PrettyPrintJSON(repos)
 }
 
 // DisableTemplateRepos disables templates repo of which Codewind is aware.
 func DisableTemplateRepos(c *cli.Context) {
-	repos, err := apiroutes.DisableTemplateRepos(c.Args())
+	repos, err := apiroutes.NewLocalPFEClient().DisableTemplateRepos(c.Args())
 	if err != nil {
-		log.Printf("Error enabling template repos: %q", err)
-		return
+		exitWithError(err)
 	}
 	PrettyPrintJSON(repos)
 }
@@ -0,0 +1,86 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/urfave/cli"
+)
+
+// UpgradeDeploymentCommand : Upgrades the local Codewind of the profile selected with --profile to the images of
+// --tag, rolling back to the images it ran before if the new ones fail to start. The saved digests belong to the
+// saved tag, so the new images are only pinned to the digest flags. The tag and digests are saved once the upgrade
+// succeeds, so that later starts run them
+func UpgradeDeploymentCommand(c *cli.Context, healthEndpoint string) {
+	to := getImageConfig(c)
+	to.PFEDigest = c.String("pfe-digest")
+	to.PerformanceDigest = c.String("performance-digest")
+	if c.Bool("verify") {
+		if err := to.RequireDigests(); err != nil {
+			exitWithUsageError(err.Error())
+		}
+	}
+	if err := to.CheckArchitecture(); err != nil {
+		errors.Exit(errors.Docker, "", err.Error())
+	}
+	// The images run before are for the same architecture, so rolling back to them does not pull other images
+	configured := getConfiguredImageConfig()
+	configured.Architecture = to.Architecture
+	from, _ := utils.RunningImageConfig(utils.ActiveProfile(), configured)
+	if err := utils.CheckPFEHost(); err != nil {
+		errors.Exit(errors.Network, "", err.Error())
+	}
+
+	report := utils.UpgradeDeployment(utils.DeploymentUpgrade{
+		Profile:        utils.ActiveProfile(),
+		From:           from,
+		To:             to,
+		Ports:          getPortConfig(c, false),
+		HealthEndpoint: healthEndpoint,
+		StartOptions:   getStartOptions(c),
+	})
+
+	if c.GlobalBool("json") || (report.Status != utils.UpgradeStatusOK && report.Status != utils.UpgradeStatusUnchanged) {
+		jsonResponse, _ := json.MarshalIndent(report, "", "\t")
+		fmt.Println(string(jsonResponse))
+	} else if report.Status == utils.UpgradeStatusUnchanged {
+		fmt.Println("Codewind already runs " + report.To + ", nothing to upgrade")
+	} else {
+		fmt.Println("Codewind successfully upgraded to " + report.To + " on " + report.Start.URL)
+	}
+	switch report.Status {
+	case utils.UpgradeStatusRolledBack:
+		errors.Exit(errors.Docker, "", "Codewind failed to start with the new images, so it was rolled back to "+report.From)
+	case utils.UpgradeStatusFailed:
+		errors.Exit(errors.Docker, "", "Codewind could not be upgraded, the steps section of the report shows which step failed")
+	}
+
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		exitWithError(configErr)
+	}
+	if cliConfig.ImageTag != to.Tag || cliConfig.PFEImageDigest != to.PFEDigest || cliConfig.PerformanceImageDigest != to.PerformanceDigest {
+		cliConfig.ImageTag = to.Tag
+		cliConfig.PFEImageDigest = to.PFEDigest
+		cliConfig.PerformanceImageDigest = to.PerformanceDigest
+		configErr = cliconfig.SaveConfig(cliConfig)
+		if configErr != nil {
+			exitWithError(configErr)
+		}
+	}
+	exitSuccess()
+}
@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package actions
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/urfave/cli"
+)
+
+// Set when building a release, e.g. go build -ldflags "-X github.com/eclipse/codewind-installer/pkg/actions.gitCommit=<sha>"
+var (
+	gitCommit = "unknown"
+	buildDate = "unknown"
+)
+
+// clientVersion : The version of cwctl and how it was built
+type clientVersion struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// VersionCommand : Print the version of cwctl, and the versions of the Codewind components of --conid if given
+func VersionCommand(c *cli.Context) {
+	type versions struct {
+		Client clientVersion               `json:"client"`
+		Server *connections.ServerVersions `json:"server,omitempty"`
+	}
+	output := versions{Client: clientVersion{
+		Version:   versionNum,
+		GitCommit: gitCommit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}}
+
+	if c.String("conid") != "" {
+		conID, conErr := connections.ResolveConnectionID(strings.TrimSpace(c.String("conid")))
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+		pfeClient := &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+		output.Server, conErr = connections.GetServerVersions(pfeClient, conID)
+		if conErr != nil {
+			exitWithError(conErr)
+		}
+	}
+
+	if c.GlobalBool("json") {
+		utils.PrettyPrintJSON(output)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "COMPONENT\tVERSION\tDETAILS")
+		fmt.Fprintln(w, "cwctl\t"+output.Client.Version+"\tcommit "+output.Client.GitCommit+", built "+output.Client.BuildDate+" with "+output.Client.GoVersion+" for "+output.Client.Platform)
+		if output.Server != nil {
+			for _, component := range output.Server.Components {
+				fmt.Fprintln(w, component.Name+"\t"+component.Version+"\t"+output.Server.URL)
+			}
+		}
+		w.Flush()
+	}
+	exitSuccess()
+}
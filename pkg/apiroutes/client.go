@@ -0,0 +1,139 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/config"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// PFEClient : A client of the REST API of one PFE. Requests are sent through HTTPClient, so a client built on
+// an authenticating HTTPClient, such as the one sechttp.NewPFEClient builds for a connection, adds the auth
+// header to every request. Error responses are returned as an *APIError
+type PFEClient struct {
+	HTTPClient utils.HTTPClient
+	// Host is the origin of PFE, e.g. "http://127.0.0.1:9090"
+	Host string
+}
+
+// APIError : An error response from PFE, with the message PFE gave when it gave one
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error : The status code PFE responded with, and its message
+func (apiErr *APIError) Error() string {
+	if apiErr.Message == "" {
+		return fmt.Sprintf("Error: PFE responded with status code %d", apiErr.StatusCode)
+	}
+	return fmt.Sprintf("Error: PFE responded with status code %d: %s", apiErr.StatusCode, apiErr.Message)
+}
+
+// NewPFEClientForHost : A client of the PFE at host, sending requests through httpClient
+func NewPFEClientForHost(httpClient utils.HTTPClient, host string) *PFEClient {
+	return &PFEClient{HTTPClient: httpClient, Host: strings.TrimSuffix(host, "/")}
+}
+
+// NewLocalPFEClient : A client of the PFE of the active local Codewind, which needs no authentication
+func NewLocalPFEClient() *PFEClient {
+	return NewPFEClientForHost(utils.LocalPFEClient(utils.ActiveProfile()), config.PFEOrigin())
+}
+
+// newRequest : A request to path below /api/v1/ of PFE. A body which is not an io.Reader is sent as JSON
+func (client *PFEClient) newRequest(method string, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	isJSON := false
+	switch content := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = content
+	default:
+		jsonBody, err := json.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(jsonBody)
+		isJSON = true
+	}
+	req, err := http.NewRequest(method, client.Host+"/api/v1/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if isJSON {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// do : Sends the request, decoding the response into result when it is not nil. A response with a status other
+// than okStatuses, or 200 when none are given, is returned as an *APIError
+func (client *PFEClient) do(req *http.Request, result interface{}, okStatuses ...int) error {
+	if len(okStatuses) == 0 {
+		okStatuses = []int{http.StatusOK}
+	}
+	resp, err := client.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	byteArray, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	isOK := false
+	for _, status := range okStatuses {
+		isOK = isOK || resp.StatusCode == status
+	}
+	if !isOK {
+		return decodeAPIError(resp.StatusCode, byteArray)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(byteArray, result)
+}
+
+// send : Builds and sends a request, see newRequest and do
+func (client *PFEClient) send(method string, path string, body interface{}, result interface{}, okStatuses ...int) error {
+	req, err := client.newRequest(method, path, body)
+	if err != nil {
+		return err
+	}
+	return client.do(req, result, okStatuses...)
+}
+
+// decodeAPIError : The error PFE responded with, which is the message or error of a JSON body, or else the
+// body as text
+func decodeAPIError(statusCode int, body []byte) *APIError {
+	var errorBody struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &errorBody) == nil {
+		if errorBody.Message != "" {
+			message = errorBody.Message
+		} else if errorBody.Error != "" {
+			message = errorBody.Error
+		}
+	}
+	return &APIError{StatusCode: statusCode, Message: message}
+}
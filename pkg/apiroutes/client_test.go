@@ -0,0 +1,134 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// authClient : an HTTPClient which adds an auth header to each request, as a connection's client does
+type authClient struct {
+	token string
+}
+
+func (client *authClient) Do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "bearer "+client.token)
+	return http.DefaultClient.Do(req)
+}
+
+func TestPFEClient(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/api/v1/projects":
+			w.Write([]byte(`[{"projectID":"a1","name":"node"}]`))
+		case "/api/v1/projects/a1/unbind":
+			w.WriteHeader(http.StatusAccepted)
+		case "/api/v1/templates/repositories":
+			w.Write([]byte(`[]`))
+		case "/api/v1/logginglevels":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message":"Unknown log level"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewPFEClientForHost(&authClient{token: "t0k3n"}, server.URL+"/")
+
+	t.Run("Asserts a response is decoded and the auth header is sent", func(t *testing.T) {
+		projects, err := client.GetProjects()
+		assert.Nil(t, err)
+		assert.Equal(t, []Project{{ProjectID: "a1", Name: "node"}}, projects)
+		assert.Equal(t, "bearer t0k3n", received.Header.Get("Authorization"))
+	})
+
+	t.Run("Asserts the statuses a method accepts are not errors", func(t *testing.T) {
+		err := client.UnbindProject("a1")
+		assert.Nil(t, err)
+		assert.Equal(t, "POST", received.Method)
+	})
+
+	t.Run("Asserts a body is sent as JSON and an error response is decoded", func(t *testing.T) {
+		err := client.SetLogLevel("loud")
+		assert.Equal(t, &APIError{StatusCode: http.StatusBadRequest, Message: "Unknown log level"}, err)
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		var body map[string]string
+		json.Unmarshal(receivedBody, &body)
+		assert.Equal(t, "loud", body["level"])
+	})
+
+	t.Run("Asserts the credentials of a template repo are given to PFE", func(t *testing.T) {
+		provider := utils.TemplateProvider{Type: utils.TemplateProviderBasic, Username: "dev", Password: "pw"}
+		_, err := client.AddTemplateRepoWithProvider("https://artifactory.example.com/index.json", "team", "team", provider)
+		assert.Nil(t, err)
+		var body struct {
+			URL         string                  `json:"url"`
+			Credentials *utils.TemplateProvider `json:"credentials"`
+		}
+		json.Unmarshal(receivedBody, &body)
+		assert.Equal(t, "https://artifactory.example.com/index.json", body.URL)
+		assert.Equal(t, &provider, body.Credentials)
+
+		_, err = client.AddTemplateRepo("https://example.com/index.json", "public", "public")
+		assert.Nil(t, err)
+		body.Credentials = nil
+		json.Unmarshal(receivedBody, &body)
+		assert.Nil(t, body.Credentials)
+	})
+
+	t.Run("Asserts an error response without a message has none", func(t *testing.T) {
+		_, err := client.GetTemplateStyles()
+		assert.Equal(t, "Error: PFE responded with status code 404", err.Error())
+	})
+}
+
+func TestDecodeAPIError(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		expected string
+	}{
+		"a JSON message": {`{"message":"Project not found"}`, "Project not found"},
+		"a JSON error":   {`{"error":"Invalid credentials"}`, "Invalid credentials"},
+		"a text body":    {"Extension not found\n", "Extension not found"},
+		"other JSON":     {`{"status":"failed"}`, `{"status":"failed"}`},
+		"an empty body":  {"", ""},
+		"a JSON string":  {`"Busy"`, `"Busy"`},
+		"truncated JSON": {`{"message":`, `{"message":`},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			apiErr := decodeAPIError(http.StatusConflict, []byte(test.body))
+			assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+			assert.Equal(t, test.expected, apiErr.Message)
+		})
+	}
+}
+
+func TestStatusError(t *testing.T) {
+	apiErr := &APIError{StatusCode: http.StatusBadRequest, Message: "Repository already exists"}
+	assert.Equal(t, errors.New("Error: PFE responded with status code 400"), statusError(apiErr))
+	other := errors.New("connection refused")
+	assert.Equal(t, other, statusError(other))
+	assert.Nil(t, statusError(nil))
+}
@@ -12,7 +12,6 @@
 package apiroutes
 
 import (
-	"crypto/tls"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -27,14 +26,15 @@ type Environment struct {
 	Version           string `json:"codewind_version"`
 	WorkspaceLocation string `json:"workspace_location"`
 	Platform          string `json:"os_platform"`
+	// Capabilities lists the optional protocol features PFE supports, and is absent on older versions
+	Capabilities []string `json:"capabilities"`
+	// PerformanceVersion and GatekeeperVersion are the versions of the other components, absent on older versions
+	PerformanceVersion string `json:"performance_version,omitempty"`
+	GatekeeperVersion  string `json:"gatekeeper_version,omitempty"`
 }
 
 func GetAPIEnvironment(c *cli.Context, host string) (*Environment, error) {
 
-	if c.GlobalBool("insecure") {
-		http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-	}
-
 	resp, err := http.Get(host + "/api/v1/environment")
 	if err != nil {
 		return nil, err
@@ -51,3 +51,18 @@ func GetAPIEnvironment(c *cli.Context, host string) (*Environment, error) {
 	}
 	return &environment, nil
 }
+
+// GetEnvironment : Fetch the PFE environment, which includes its capabilities
+func (client *PFEClient) GetEnvironment() (*Environment, error) {
+	req, err := client.newRequest("GET", "environment", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Cache-Control", "no-cache")
+	var environment Environment
+	err = client.do(req, &environment)
+	if err != nil {
+		return nil, err
+	}
+	return &environment, nil
+}
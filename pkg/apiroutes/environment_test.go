@@ -0,0 +1,38 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetEnvironment(t *testing.T) {
+	t.Run("Returns the advertised capabilities", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"codewind_version":"0.6.0","capabilities":["batchedUpload"]}`)))
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		env, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetEnvironment()
+		assert.Nil(t, err)
+		assert.Equal(t, "0.6.0", env.Version)
+		assert.Equal(t, []string{"batchedUpload"}, env.Capabilities)
+	})
+	t.Run("Returns an error when PFE fails", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusInternalServerError, Body: body}
+		_, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetEnvironment()
+		assert.NotNil(t, err)
+	})
+}
@@ -12,30 +12,76 @@
 package apiroutes
 
 import (
-	"encoding/json"
-	"io/ioutil"
+	"bytes"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 
-	"github.com/eclipse/codewind-installer/config"
 	"github.com/eclipse/codewind-installer/pkg/utils"
 )
 
-// GetExtensions gets project extensions from PFE's REST API.
+// GetExtensions gets project extensions from the REST API of the local PFE.
 func GetExtensions() ([]utils.Extension, error) {
-	resp, err := http.Get(config.PFEApiRoute() + "extensions")
+	return NewLocalPFEClient().ListExtensions()
+}
+
+// ListExtensions : Get the extensions installed in PFE
+func (client *PFEClient) ListExtensions() ([]utils.Extension, error) {
+	req, err := client.newRequest("GET", "extensions", nil)
 	if err != nil {
 		return nil, err
 	}
+	return client.doExtensionsRequest(req)
+}
 
-	defer resp.Body.Close()
+// InstallExtension : Upload an extension zip to PFE, returning the extensions then installed
+func (client *PFEClient) InstallExtension(zipPath string) ([]utils.Extension, error) {
+	file, err := os.Open(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
 
-	byteArray, err := ioutil.ReadAll(resp.Body)
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(zipPath))
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(part, file)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Close()
 	if err != nil {
 		return nil, err
 	}
 
-	var extensions []utils.Extension
-	json.Unmarshal(byteArray, &extensions)
+	req, err := client.newRequest("POST", "extensions", body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return client.doExtensionsRequest(req)
+}
 
+// RemoveExtension : Remove an extension from PFE, returning the extensions still installed
+func (client *PFEClient) RemoveExtension(name string) ([]utils.Extension, error) {
+	req, err := client.newRequest("DELETE", "extensions/"+url.PathEscape(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.doExtensionsRequest(req)
+}
+
+func (client *PFEClient) doExtensionsRequest(req *http.Request) ([]utils.Extension, error) {
+	var extensions []utils.Extension
+	err := client.do(req, &extensions, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
 	return extensions, nil
 }
@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Extensions(t *testing.T) {
+	mockResponse := []utils.Extension{{Name: "codewind-appsody-extension", Version: "0.9.0", ProjectType: "appsodyExtension"}}
+	jsonResponse, _ := json.Marshal(mockResponse)
+
+	t.Run("Lists the installed extensions", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader(jsonResponse))
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		extensions, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").ListExtensions()
+		assert.Nil(t, err)
+		assert.Equal(t, mockResponse, extensions)
+	})
+	t.Run("Returns an error when PFE rejects the removal", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte("Extension not found")))
+		mockClient := &MockResponse{StatusCode: http.StatusNotFound, Body: body}
+		_, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").RemoveExtension("missing")
+		assert.Contains(t, err.Error(), "Extension not found")
+	})
+	t.Run("Uploads the zip as a multipart form", func(t *testing.T) {
+		zipPath := filepath.Join(os.TempDir(), "codewind-test-extension.zip")
+		ioutil.WriteFile(zipPath, []byte("zip content"), 0644)
+		defer os.Remove(zipPath)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			file, header, err := r.FormFile("file")
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := ioutil.ReadAll(file)
+			assert.Equal(t, "codewind-test-extension.zip", header.Filename)
+			assert.Equal(t, "zip content", string(content))
+			w.Write(jsonResponse)
+		}))
+		defer server.Close()
+
+		extensions, err := NewPFEClientForHost(http.DefaultClient, server.URL).InstallExtension(zipPath)
+		assert.Nil(t, err)
+		assert.Len(t, extensions, 1)
+	})
+}
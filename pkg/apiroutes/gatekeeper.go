@@ -24,6 +24,22 @@ type GatekeeperEnvironment struct {
 	AuthURL  string `json:"auth_url"`
 	Realm    string `json:"realm"`
 	ClientID string `json:"client_id"`
+	// Version is reported by gatekeepers which know their version, and is empty otherwise
+	Version string `json:"version,omitempty"`
+	// AuthProvider is openshift for gatekeepers which log users in with the OAuth server of their OpenShift
+	// cluster, which has no realm, and empty or keycloak otherwise
+	AuthProvider string `json:"auth_provider,omitempty"`
+}
+
+// Auth providers a gatekeeper reports
+const (
+	AuthProviderKeycloak  = "keycloak"
+	AuthProviderOpenShift = "openshift"
+)
+
+// UsesOpenShiftOAuth : whether the gatekeeper logs users in with the OAuth server of its OpenShift cluster
+func (environment *GatekeeperEnvironment) UsesOpenShiftOAuth() bool {
+	return environment.AuthProvider == AuthProviderOpenShift
 }
 
 // GetGatekeeperEnvironment : Fetch the Gatekeeper environment
@@ -50,6 +66,9 @@ func GetGatekeeperEnvironment(httpClient utils.HTTPClient, host string) (*Gateke
 	if err != nil {
 		return nil, err
 	}
+	if res.StatusCode != http.StatusOK {
+		return nil, decodeAPIError(res.StatusCode, byteArray)
+	}
 	var environment GatekeeperEnvironment
 	err = json.Unmarshal(byteArray, &environment)
 	if err != nil {
@@ -0,0 +1,48 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"net/http"
+)
+
+// ProjectLink : A link from a project to another, which PFE injects into the project as an environment variable
+// holding the URL of the other project
+type ProjectLink struct {
+	ProjectID   string `json:"projectID"`
+	ProjectName string `json:"projectName,omitempty"`
+	EnvName     string `json:"envName"`
+	ProjectURL  string `json:"projectURL,omitempty"`
+}
+
+// GetProjectLinks : The links of a project to other projects
+func (client *PFEClient) GetProjectLinks(projectID string) ([]ProjectLink, error) {
+	var links []ProjectLink
+	err := client.send("GET", "projects/"+projectID+"/links", nil, &links)
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// CreateProjectLink : Links a project to targetProjectID, injecting the URL of the target as envName. PFE
+// restarts or rebuilds the project to apply the link
+func (client *PFEClient) CreateProjectLink(projectID string, targetProjectID string, envName string) error {
+	body := map[string]string{"targetProjectID": targetProjectID, "envName": envName}
+	return client.send("POST", "projects/"+projectID+"/links", body, nil, http.StatusOK, http.StatusAccepted)
+}
+
+// DeleteProjectLink : Removes the link of a project whose URL is injected as envName
+func (client *PFEClient) DeleteProjectLink(projectID string, envName string) error {
+	body := map[string]string{"envName": envName}
+	return client.send("DELETE", "projects/"+projectID+"/links", body, nil, http.StatusOK, http.StatusAccepted)
+}
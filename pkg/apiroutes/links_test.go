@@ -0,0 +1,62 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectLinks(t *testing.T) {
+	var received *http.Request
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.Method {
+		case "GET":
+			w.Write([]byte(`[{"projectID":"b1","projectName":"backend","envName":"BACKEND_URL","projectURL":"backend:3000"}]`))
+		case "DELETE":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Link not found"}`))
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	client := NewPFEClientForHost(http.DefaultClient, server.URL)
+
+	t.Run("Asserts the links of a project are listed", func(t *testing.T) {
+		links, err := client.GetProjectLinks("a1")
+		assert.Nil(t, err)
+		assert.Equal(t, []ProjectLink{{ProjectID: "b1", ProjectName: "backend", EnvName: "BACKEND_URL", ProjectURL: "backend:3000"}}, links)
+		assert.Equal(t, "/api/v1/projects/a1/links", received.URL.Path)
+	})
+
+	t.Run("Asserts a link is created with its target and environment variable", func(t *testing.T) {
+		err := client.CreateProjectLink("a1", "b1", "BACKEND_URL")
+		assert.Nil(t, err)
+		assert.Equal(t, "POST", received.Method)
+		assert.Equal(t, map[string]string{"targetProjectID": "b1", "envName": "BACKEND_URL"}, body)
+	})
+
+	t.Run("Asserts a link which cannot be deleted is an error", func(t *testing.T) {
+		err := client.DeleteProjectLink("a1", "DB_URL")
+		assert.Equal(t, &APIError{StatusCode: http.StatusNotFound, Message: "Link not found"}, err)
+		assert.Equal(t, map[string]string{"envName": "DB_URL"}, body)
+	})
+}
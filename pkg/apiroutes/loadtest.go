@@ -0,0 +1,61 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Types of metrics the performance container records for each load run
+const (
+	MetricsCPU    = "cpu"
+	MetricsMemory = "memory"
+	MetricsHTTP   = "http"
+)
+
+// ProjectMetrics : The metrics of one type recorded for each load run of a project
+type ProjectMetrics struct {
+	Type    string          `json:"type"`
+	Metrics []LoadRunMetric `json:"metrics"`
+}
+
+// LoadRunMetric : The metrics recorded during one load run, the layout of Data depends on the metrics type
+type LoadRunMetric struct {
+	Container string `json:"container"`
+	Time      int64  `json:"time"`
+	EndTime   int64  `json:"endTime"`
+	Desc      string `json:"desc"`
+	Value     struct {
+		Data json.RawMessage `json:"data"`
+	} `json:"value"`
+}
+
+// StartLoadTest : Ask PFE to run the load test of a project
+func (client *PFEClient) StartLoadTest(projectID string, description string) error {
+	return client.send("POST", "projects/"+projectID+"/loadtest", map[string]string{"description": description}, nil, http.StatusOK, http.StatusAccepted)
+}
+
+// CancelLoadTest : Ask PFE to stop the load test a project is running
+func (client *PFEClient) CancelLoadTest(projectID string) error {
+	return client.send("POST", "projects/"+projectID+"/loadtest/cancel", nil, nil, http.StatusOK, http.StatusAccepted)
+}
+
+// GetProjectMetrics : Get the metrics of one type recorded for each load run of a project
+func (client *PFEClient) GetProjectMetrics(projectID string, metricsType string) (*ProjectMetrics, error) {
+	var metrics ProjectMetrics
+	err := client.send("GET", "projects/"+projectID+"/metrics/"+metricsType, nil, &metrics)
+	if err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
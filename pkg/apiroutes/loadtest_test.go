@@ -0,0 +1,45 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadTest(t *testing.T) {
+	t.Run("Starts a load run", func(t *testing.T) {
+		mockClient := &MockResponse{StatusCode: http.StatusAccepted, Body: ioutil.NopCloser(bytes.NewReader(nil))}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").StartLoadTest("a9384430-f177-11e9-b862-edc28aca827a", "baseline")
+		assert.Nil(t, err)
+	})
+	t.Run("Returns an error when PFE rejects the cancel", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte("No run in progress")))
+		mockClient := &MockResponse{StatusCode: http.StatusConflict, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").CancelLoadTest("a9384430-f177-11e9-b862-edc28aca827a")
+		assert.Contains(t, err.Error(), "No run in progress")
+	})
+	t.Run("Reads the metrics of each load run", func(t *testing.T) {
+		response := `{"type":"cpu","metrics":[{"container":"load-test","time":1571000000000,"endTime":1571000060000,"desc":"baseline","value":{"data":{"processMean":0.25}}}]}`
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewReader([]byte(response)))}
+		metrics, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetProjectMetrics("a9384430-f177-11e9-b862-edc28aca827a", MetricsCPU)
+		assert.Nil(t, err)
+		assert.Equal(t, "cpu", metrics.Type)
+		assert.Len(t, metrics.Metrics, 1)
+		assert.Equal(t, "baseline", metrics.Metrics[0].Desc)
+		assert.JSONEq(t, `{"processMean":0.25}`, string(metrics.Metrics[0].Value.Data))
+	})
+}
@@ -0,0 +1,34 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+// LoggingResponse : The logging levels reported by PFE
+type LoggingResponse struct {
+	CurrentLevel string   `json:"currentLevel"`
+	DefaultLevel string   `json:"defaultLevel"`
+	AllLevels    []string `json:"allLevels"`
+}
+
+// GetLogLevels : Get the current, default and available log levels from PFE
+func (client *PFEClient) GetLogLevels() (*LoggingResponse, error) {
+	var loggingResponse LoggingResponse
+	err := client.send("GET", "logginglevels", nil, &loggingResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingResponse, nil
+}
+
+// SetLogLevel : Set the log level PFE uses
+func (client *PFEClient) SetLogLevel(level string) error {
+	return client.send("PUT", "logginglevels", map[string]string{"level": level}, nil)
+}
@@ -0,0 +1,59 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetLogLevels(t *testing.T) {
+	mockResponse := LoggingResponse{CurrentLevel: "debug", DefaultLevel: "info", AllLevels: []string{"error", "warn", "info", "debug", "trace"}}
+	jsonResponse, _ := json.Marshal(mockResponse)
+	body := ioutil.NopCloser(bytes.NewReader([]byte(jsonResponse)))
+
+	mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+	loggingResponse, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetLogLevels()
+	if err != nil {
+		t.Fail()
+	}
+
+	t.Run("Assert current level is debug", func(t *testing.T) {
+		assert.Equal(t, "debug", loggingResponse.CurrentLevel)
+	})
+	t.Run("Assert default level is info", func(t *testing.T) {
+		assert.Equal(t, "info", loggingResponse.DefaultLevel)
+	})
+	t.Run("Assert all five levels are returned", func(t *testing.T) {
+		assert.Len(t, loggingResponse.AllLevels, 5)
+	})
+}
+
+func Test_SetLogLevel(t *testing.T) {
+	t.Run("Returns no error when PFE accepts the level", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").SetLogLevel("debug")
+		assert.Nil(t, err)
+	})
+	t.Run("Returns an error when PFE rejects the level", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusBadRequest, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").SetLogLevel("debug")
+		assert.NotNil(t, err)
+	})
+}
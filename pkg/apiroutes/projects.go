@@ -0,0 +1,70 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"net/http"
+)
+
+// Project : A project known to PFE
+type Project struct {
+	ProjectID   string `json:"projectID"`
+	Name        string `json:"name"`
+	Language    string `json:"language"`
+	ProjectType string `json:"projectType"`
+	AppStatus   string `json:"appStatus"`
+	BuildStatus string `json:"buildStatus"`
+	LocOnDisk   string `json:"locOnDisk"`
+	// InjectMetrics is set while PFE adds the metrics collector to the project's build
+	InjectMetrics bool `json:"injectMetrics"`
+}
+
+// GetProjects : Get all projects from PFE
+func (client *PFEClient) GetProjects() ([]Project, error) {
+	var projects []Project
+	err := client.send("GET", "projects", nil, &projects)
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProject : Get one project from PFE
+func (client *PFEClient) GetProject(projectID string) (*Project, error) {
+	var project Project
+	err := client.send("GET", "projects/"+projectID, nil, &project)
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// ProjectSettingsRequest : The .cw-settings values PFE is asked to apply to a project
+type ProjectSettingsRequest struct {
+	Settings map[string]interface{} `json:"settings"`
+}
+
+// UpdateProjectSettings : Asks PFE to apply changed .cw-settings values to a project, which rebuilds it if needed
+func (client *PFEClient) UpdateProjectSettings(projectID string, settings map[string]interface{}) error {
+	return client.send("POST", "projects/"+projectID+"/settings", ProjectSettingsRequest{Settings: settings}, nil, http.StatusOK, http.StatusAccepted)
+}
+
+// UnbindProject : Asks PFE to stop building and forget a project. A project PFE does not know is already unbound
+func (client *PFEClient) UnbindProject(projectID string) error {
+	return client.send("POST", "projects/"+projectID+"/unbind", nil, nil, http.StatusOK, http.StatusAccepted, http.StatusNotFound)
+}
+
+// InjectMetrics : Asks PFE to add the metrics collector to a project's build, or to take it out again, which
+// rebuilds the project
+func (client *PFEClient) InjectMetrics(projectID string, enable bool) error {
+	return client.send("POST", "projects/"+projectID+"/metrics/inject", map[string]bool{"enable": enable}, nil, http.StatusOK, http.StatusAccepted)
+}
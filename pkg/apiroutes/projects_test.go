@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetProjects(t *testing.T) {
+	t.Run("Returns the projects from PFE", func(t *testing.T) {
+		mockResponse := []Project{{ProjectID: "a1", Name: "team-a-node"}, {ProjectID: "b2", Name: "team-b-java"}}
+		jsonResponse, _ := json.Marshal(mockResponse)
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonResponse)))
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		projects, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetProjects()
+		assert.Nil(t, err)
+		assert.Len(t, projects, 2)
+		assert.Equal(t, "team-b-java", projects[1].Name)
+	})
+	t.Run("Returns an error when PFE fails", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusInternalServerError, Body: body}
+		_, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetProjects()
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_UpdateProjectSettings(t *testing.T) {
+	t.Run("Sends the changed settings to PFE", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusAccepted, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").UpdateProjectSettings("a1", map[string]interface{}{"internalPort": "3000"})
+		assert.Nil(t, err)
+	})
+	t.Run("Returns an error when PFE rejects the settings", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusBadRequest, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").UpdateProjectSettings("a1", map[string]interface{}{"internalPort": "3000"})
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_UnbindProject(t *testing.T) {
+	t.Run("Accepts a project PFE no longer knows", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusNotFound, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").UnbindProject("a1")
+		assert.Nil(t, err)
+	})
+	t.Run("Returns an error when PFE fails", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &MockResponse{StatusCode: http.StatusInternalServerError, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").UnbindProject("a1")
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_GetProject(t *testing.T) {
+	t.Run("Returns the project from PFE", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"projectID":"a1","language":"nodejs","injectMetrics":true}`)))
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		project, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetProject("a1")
+		assert.Nil(t, err)
+		assert.Equal(t, "nodejs", project.Language)
+		assert.True(t, project.InjectMetrics)
+	})
+}
+
+func Test_InjectMetrics(t *testing.T) {
+	t.Run("Returns the reason PFE rejects the request", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte("Project does not support metrics injection")))
+		mockClient := &MockResponse{StatusCode: http.StatusBadRequest, Body: body}
+		err := NewPFEClientForHost(mockClient, "http://noserver.test.com").InjectMetrics("a1", true)
+		assert.Contains(t, err.Error(), "does not support metrics injection")
+	})
+}
@@ -0,0 +1,52 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+)
+
+// RegistrySecret : A registry PFE has credentials for. PFE never returns the password
+type RegistrySecret struct {
+	Address  string `json:"address"`
+	Username string `json:"username"`
+}
+
+// GetRegistrySecrets : Get the registries PFE has credentials for
+func (client *PFEClient) GetRegistrySecrets() ([]RegistrySecret, error) {
+	return client.sendRegistrySecrets("GET", nil)
+}
+
+// AddRegistrySecret : Give PFE the credentials for a registry, returning all of the registries PFE has credentials for
+func (client *PFEClient) AddRegistrySecret(address string, username string, password string) ([]RegistrySecret, error) {
+	credentials, _ := json.Marshal(map[string]string{"username": username, "password": password})
+	return client.sendRegistrySecrets("POST", map[string]string{
+		"address":     address,
+		"credentials": base64.StdEncoding.EncodeToString(credentials),
+	})
+}
+
+// RemoveRegistrySecret : Remove PFE's credentials for a registry, returning the registries PFE still has credentials for
+func (client *PFEClient) RemoveRegistrySecret(address string) ([]RegistrySecret, error) {
+	return client.sendRegistrySecrets("DELETE", map[string]string{"address": address})
+}
+
+func (client *PFEClient) sendRegistrySecrets(method string, body interface{}) ([]RegistrySecret, error) {
+	var registrySecrets []RegistrySecret
+	err := client.send(method, "registrysecrets", body, &registrySecrets, http.StatusOK, http.StatusCreated)
+	if err != nil {
+		return nil, err
+	}
+	return registrySecrets, nil
+}
@@ -0,0 +1,48 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apiroutes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RegistrySecrets(t *testing.T) {
+	mockResponse := []RegistrySecret{{Address: "docker.io", Username: "dev"}}
+	jsonResponse, _ := json.Marshal(mockResponse)
+
+	t.Run("Lists the registries PFE has credentials for", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonResponse)))
+		mockClient := &MockResponse{StatusCode: http.StatusOK, Body: body}
+		registrySecrets, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").GetRegistrySecrets()
+		assert.Nil(t, err)
+		assert.Equal(t, mockResponse, registrySecrets)
+	})
+	t.Run("Returns the registries after adding one", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonResponse)))
+		mockClient := &MockResponse{StatusCode: http.StatusCreated, Body: body}
+		registrySecrets, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").AddRegistrySecret("docker.io", "dev", "secret")
+		assert.Nil(t, err)
+		assert.Len(t, registrySecrets, 1)
+	})
+	t.Run("Returns an error when PFE rejects the credentials", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte("Invalid credentials")))
+		mockClient := &MockResponse{StatusCode: http.StatusBadRequest, Body: body}
+		_, err := NewPFEClientForHost(mockClient, "http://noserver.test.com").AddRegistrySecret("docker.io", "dev", "wrong")
+		assert.Contains(t, err.Error(), "Invalid credentials")
+	})
+}
@@ -12,17 +12,17 @@
 package apiroutes
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 
-	"github.com/eclipse/codewind-installer/config"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
 )
 
+// DefaultTemplateStyle is the style of templates which do not name one
+const DefaultTemplateStyle = "Codewind"
+
 type (
 	// Template represents a project template.
 	Template struct {
@@ -36,6 +36,24 @@ type (
 		SourceID     string `json:"sourceId,omitempty"`
 	}
 
+	// TemplateListing represents a template as listed for IDE pickers, with the name of the repo it comes
+	// from and the extension it needs, if any. ID is the URL that project create takes
+	TemplateListing struct {
+		ID                string `json:"id" yaml:"id"`
+		Label             string `json:"label" yaml:"label"`
+		Description       string `json:"description" yaml:"description"`
+		Language          string `json:"language" yaml:"language"`
+		URL               string `json:"url" yaml:"url"`
+		ProjectType       string `json:"projectType" yaml:"projectType"`
+		ProjectStyle      string `json:"projectStyle,omitempty" yaml:"projectStyle,omitempty"`
+		Style             string `json:"style" yaml:"style"`
+		Source            string `json:"source,omitempty" yaml:"source,omitempty"`
+		SourceID          string `json:"sourceId,omitempty" yaml:"sourceId,omitempty"`
+		Repo              string `json:"repo,omitempty" yaml:"repo,omitempty"`
+		RequiresExtension bool   `json:"requiresExtension" yaml:"requiresExtension"`
+		Extension         string `json:"extension,omitempty" yaml:"extension,omitempty"`
+	}
+
 	// RepoOperation represents a requested operation on a template repository.
 	RepoOperation struct {
 		Operation string `json:"op"`
@@ -52,10 +70,80 @@ type (
 	}
 )
 
-// GetTemplates gets project templates from PFE's REST API.
+// GetTemplates gets project templates from the REST API of the local PFE.
 // Filter them using the function arguments
 func GetTemplates(projectStyle string, showEnabledOnly bool) ([]Template, error) {
-	req, err := http.NewRequest("GET", config.PFEApiRoute()+"templates", nil)
+	result, err := NewLocalPFEClient().GetTemplates(projectStyle, showEnabledOnly)
+	return result, statusError(err)
+}
+
+// GetTemplateStyles gets all template styles from the REST API of the local PFE
+func GetTemplateStyles() ([]string, error) {
+	result, err := NewLocalPFEClient().GetTemplateStyles()
+	return result, statusError(err)
+}
+
+// GetAllTemplateStyles gets the template styles from the REST API of the local PFE along with
+// any additional styles registered by project extensions
+func GetAllTemplateStyles() ([]string, error) {
+	result, err := NewLocalPFEClient().GetAllTemplateStyles()
+	return result, statusError(err)
+}
+
+// GetTemplateRepos gets all template repos from the REST API of the local PFE
+func GetTemplateRepos() ([]utils.TemplateRepo, error) {
+	result, err := NewLocalPFEClient().GetTemplateRepos()
+	return result, statusError(err)
+}
+
+// AddTemplateRepo adds a template repo to the local PFE and
+// returns the new list of existing repos
+func AddTemplateRepo(URL, description string, name string) ([]utils.TemplateRepo, error) {
+	result, err := NewLocalPFEClient().AddTemplateRepo(URL, description, name)
+	return result, statusError(err)
+}
+
+// DeleteTemplateRepo deletes a template repo from the local PFE and
+// returns the new list of existing repos
+func DeleteTemplateRepo(URL string) ([]utils.TemplateRepo, error) {
+	result, err := NewLocalPFEClient().DeleteTemplateRepo(URL)
+	return result, statusError(err)
+}
+
+// EnableTemplateRepos enables template repos in the local PFE and
+// returns the new list of template repos
+func EnableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
+	result, err := NewLocalPFEClient().EnableTemplateRepos(repoURLs)
+	return result, statusError(err)
+}
+
+// DisableTemplateRepos disables template repos in the local PFE and
+// returns the new list of template repos
+func DisableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
+	result, err := NewLocalPFEClient().DisableTemplateRepos(repoURLs)
+	return result, statusError(err)
+}
+
+// BatchPatchTemplateRepos requests that the local PFE perform batch operations on template repositories and
+// returns a list of sub-responses to the requested operations
+func BatchPatchTemplateRepos(operations []RepoOperation) ([]SubResponseFromBatchOperation, error) {
+	result, err := NewLocalPFEClient().BatchPatchTemplateRepos(operations)
+	return result, statusError(err)
+}
+
+// statusError : The error the functions of the local PFE return, which gives only the status code PFE responded
+// with, as they did before PFEClient returned its message
+func statusError(err error) error {
+	if apiErr, ok := err.(*APIError); ok {
+		return fmt.Errorf("Error: PFE responded with status code %d", apiErr.StatusCode)
+	}
+	return err
+}
+
+// GetTemplates gets project templates from PFE.
+// Filter them using the function arguments
+func (client *PFEClient) GetTemplates(projectStyle string, showEnabledOnly bool) ([]Template, error) {
+	req, err := client.newRequest("GET", "templates", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -67,177 +155,174 @@ func GetTemplates(projectStyle string, showEnabledOnly bool) ([]Template, error)
 		query.Add("showEnabledOnly", "true")
 	}
 	req.URL.RawQuery = query.Encode()
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-
-	defer resp.Body.Close()
-
-	byteArray, err := ioutil.ReadAll(resp.Body)
+	var templates []Template
+	err = client.do(req, &templates)
 	if err != nil {
 		return nil, err
 	}
-
-	var templates []Template
-	json.Unmarshal(byteArray, &templates)
 	return templates, nil
 }
 
-// GetTemplateStyles gets all template styles from PFE's REST API
-func GetTemplateStyles() ([]string, error) {
-	resp, err := http.Get(config.PFEApiRoute() + "templates/styles")
+// GetTemplateListings gets project templates from PFE, along with the name of the repo each comes from and
+// whether it needs an extension. Filter them using the function arguments. The templates are still listed when
+// the repos or extensions cannot be read, without the names of their repos or the extensions they need
+func (client *PFEClient) GetTemplateListings(projectStyle string, showEnabledOnly bool) ([]TemplateListing, error) {
+	templates, err := client.GetTemplates(projectStyle, showEnabledOnly)
 	if err != nil {
 		return nil, err
 	}
+	repos, err := client.GetTemplateRepos()
+	if err != nil {
+		logr.Warnln("Unable to get the template repos, so their names are not shown: " + err.Error())
+		repos = nil
+	}
+	extensions, err := client.ListExtensions()
+	if err != nil {
+		logr.Warnln("Unable to get the extensions, so the extensions templates need are not shown: " + err.Error())
+		extensions = nil
+	}
+	return describeTemplates(templates, repos, extensions), nil
+}
 
-	defer resp.Body.Close()
+// describeTemplates adds the repo name, style and needed extension to each template. Templates without a
+// style are Codewind templates, and a template needs an extension which registers its project type or style
+func describeTemplates(templates []Template, repos []utils.TemplateRepo, extensions []utils.Extension) []TemplateListing {
+	listings := []TemplateListing{}
+	for _, template := range templates {
+		listing := TemplateListing{
+			ID:           template.URL,
+			Label:        template.Label,
+			Description:  template.Description,
+			Language:     template.Language,
+			URL:          template.URL,
+			ProjectType:  template.ProjectType,
+			ProjectStyle: template.ProjectStyle,
+			Style:        template.ProjectStyle,
+			Source:       template.Source,
+			SourceID:     template.SourceID,
+			Repo:         template.Source,
+		}
+		if listing.Style == "" {
+			listing.Style = DefaultTemplateStyle
+		}
+		for _, repo := range repos {
+			if template.SourceID != "" && repo.ID == template.SourceID && repo.Name != "" {
+				listing.Repo = repo.Name
+			}
+		}
+		for _, extension := range extensions {
+			if extension.ProjectType == template.ProjectType || utils.ContainsStyle(extension.GetStyles(), listing.Style) {
+				listing.RequiresExtension = true
+				listing.Extension = extension.Name
+				if listing.Extension == "" {
+					listing.Extension = extension.ProjectType
+				}
+				break
+			}
+		}
+		listings = append(listings, listing)
+	}
+	return listings
+}
 
-	byteArray, err := ioutil.ReadAll(resp.Body)
+// GetTemplateStyles gets all template styles from PFE
+func (client *PFEClient) GetTemplateStyles() ([]string, error) {
+	var styles []string
+	err := client.send("GET", "templates/styles", nil, &styles)
 	if err != nil {
 		return nil, err
 	}
-
-	var styles []string
-	json.Unmarshal(byteArray, &styles)
-
 	return styles, nil
 }
 
-// GetTemplateRepos gets all template repos from PFE's REST API
-func GetTemplateRepos() ([]utils.TemplateRepo, error) {
-	resp, err := http.Get(config.PFEApiRoute() + "templates/repositories")
+// GetAllTemplateStyles gets the template styles from PFE along with
+// any additional styles registered by project extensions
+func (client *PFEClient) GetAllTemplateStyles() ([]string, error) {
+	styles, err := client.GetTemplateStyles()
 	if err != nil {
 		return nil, err
 	}
-
-	defer resp.Body.Close()
-
-	byteArray, err := ioutil.ReadAll(resp.Body)
+	extensions, err := client.ListExtensions()
 	if err != nil {
 		return nil, err
 	}
+	return mergeExtensionStyles(styles, extensions), nil
+}
 
-	var repos []utils.TemplateRepo
-	json.Unmarshal(byteArray, &repos)
+// mergeExtensionStyles appends the styles registered by extensions which PFE does not already know
+func mergeExtensionStyles(styles []string, extensions []utils.Extension) []string {
+	if styles == nil {
+		styles = []string{}
+	}
+	for _, extension := range extensions {
+		for _, style := range extension.GetStyles() {
+			if !utils.ContainsStyle(styles, style) {
+				styles = append(styles, style)
+			}
+		}
+	}
+	return styles
+}
 
-	return repos, nil
+// GetTemplateRepos gets all template repos from PFE
+func (client *PFEClient) GetTemplateRepos() ([]utils.TemplateRepo, error) {
+	return client.sendTemplateRepos("GET", nil)
 }
 
 // AddTemplateRepo adds a template repo to PFE and
 // returns the new list of existing repos
-func AddTemplateRepo(URL, description string, name string) ([]utils.TemplateRepo, error) {
+func (client *PFEClient) AddTemplateRepo(URL, description string, name string) ([]utils.TemplateRepo, error) {
+	return client.AddTemplateRepoWithProvider(URL, description, name, utils.TemplateProvider{})
+}
+
+// AddTemplateRepoWithProvider adds a template repo whose index is hosted in an artifact repository to PFE, with
+// the provider and credentials PFE fetches the index with, and returns the new list of existing repos
+func (client *PFEClient) AddTemplateRepoWithProvider(URL, description string, name string, provider utils.TemplateProvider) ([]utils.TemplateRepo, error) {
 	if _, err := url.ParseRequestURI(URL); err != nil {
 		return nil, fmt.Errorf("Error: '%s' is not a valid URL", URL)
 	}
-
-	values := map[string]string{
+	body := map[string]interface{}{
 		"url":         URL,
 		"description": description,
 		"name":        name,
 	}
-	jsonValue, _ := json.Marshal(values)
-
-	resp, err := http.Post(
-		config.PFEApiRoute()+"templates/repositories",
-		"application/json",
-		bytes.NewBuffer(jsonValue),
-	)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Error: PFE responded with status code %d", resp.StatusCode)
-	}
-
-	defer resp.Body.Close()
-
-	byteArray, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if provider.NeedsCredentials() {
+		body["credentials"] = provider.PFECredentials()
 	}
-
-	var repos []utils.TemplateRepo
-	json.Unmarshal(byteArray, &repos)
-
-	return repos, nil
+	return client.sendTemplateRepos("POST", body)
 }
 
 // DeleteTemplateRepo deletes a template repo from PFE and
 // returns the new list of existing repos
-func DeleteTemplateRepo(URL string) ([]utils.TemplateRepo, error) {
+func (client *PFEClient) DeleteTemplateRepo(URL string) ([]utils.TemplateRepo, error) {
 	if _, err := url.ParseRequestURI(URL); err != nil {
 		return nil, fmt.Errorf("Error: '%s' is not a valid URL", URL)
 	}
+	return client.sendTemplateRepos("DELETE", map[string]string{"url": URL})
+}
 
-	values := map[string]string{"url": URL}
-	jsonValue, _ := json.Marshal(values)
-
-	req, err := http.NewRequest(
-		"DELETE",
-		config.PFEApiRoute()+"templates/repositories",
-		bytes.NewBuffer(jsonValue),
-	)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Error: PFE responded with status code %d", resp.StatusCode)
-	}
-
-	defer resp.Body.Close()
-
-	byteArray, err := ioutil.ReadAll(resp.Body)
+func (client *PFEClient) sendTemplateRepos(method string, body interface{}) ([]utils.TemplateRepo, error) {
+	var repos []utils.TemplateRepo
+	err := client.send(method, "templates/repositories", body, &repos)
 	if err != nil {
 		return nil, err
 	}
-
-	var repos []utils.TemplateRepo
-	json.Unmarshal(byteArray, &repos)
-
 	return repos, nil
 }
 
-// EnableTemplateRepos enables a template repo in PFE and
+// EnableTemplateRepos enables template repos in PFE and
 // returns the new list of template repos
-func EnableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
-	if repoURLs == nil {
-		return nil, fmt.Errorf("Error: '%s' is not a valid URL", repoURLs)
-	}
-
-	var operations []RepoOperation
-	for _, URL := range repoURLs {
-		if _, err := url.ParseRequestURI(URL); err != nil {
-			return nil, fmt.Errorf("Error: '%s' is not a valid URL", URL)
-		}
-		operation := RepoOperation{
-			Operation: "enable",
-			URL:       URL,
-			Value:     "true",
-		}
-		operations = append(operations, operation)
-	}
-	_, err := BatchPatchTemplateRepos(operations)
-	if err != nil {
-		return nil, err
-	}
-
-	repos, err := GetTemplateRepos()
-	if err != nil {
-		return nil, err
-	}
-
-	return repos, nil
+func (client *PFEClient) EnableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
+	return client.setTemplateReposEnabled(repoURLs, "true")
 }
 
-// DisableTemplateRepos enables a template repo in PFE and
+// DisableTemplateRepos disables template repos in PFE and
 // returns the new list of template repos
-func DisableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
+func (client *PFEClient) DisableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
+	return client.setTemplateReposEnabled(repoURLs, "false")
+}
+
+func (client *PFEClient) setTemplateReposEnabled(repoURLs []string, enabled string) ([]utils.TemplateRepo, error) {
 	if repoURLs == nil {
 		return nil, fmt.Errorf("Error: '%s' is not a valid URL", repoURLs)
 	}
@@ -250,53 +335,24 @@ func DisableTemplateRepos(repoURLs []string) ([]utils.TemplateRepo, error) {
 		operation := RepoOperation{
 			Operation: "enable",
 			URL:       URL,
-			Value:     "false",
+			Value:     enabled,
 		}
 		operations = append(operations, operation)
 	}
-	_, err := BatchPatchTemplateRepos(operations)
+	_, err := client.BatchPatchTemplateRepos(operations)
 	if err != nil {
 		return nil, err
 	}
-
-	repos, err := GetTemplateRepos()
-	if err != nil {
-		return nil, err
-	}
-
-	return repos, nil
+	return client.GetTemplateRepos()
 }
 
 // BatchPatchTemplateRepos requests that PFE perform batch operations on template repositories and
 // returns a list of sub-responses to the requested operations
-func BatchPatchTemplateRepos(operations []RepoOperation) ([]SubResponseFromBatchOperation, error) {
-	jsonValue, _ := json.Marshal(operations)
-
-	req, err := http.NewRequest(
-		"PATCH",
-		config.PFEApiRoute()+"batch/templates/repositories",
-		bytes.NewBuffer(jsonValue),
-	)
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode != 207 {
-		return nil, fmt.Errorf("Error: PFE responded with status code %d", resp.StatusCode)
-	}
-
-	defer resp.Body.Close()
-
-	byteArray, err := ioutil.ReadAll(resp.Body)
+func (client *PFEClient) BatchPatchTemplateRepos(operations []RepoOperation) ([]SubResponseFromBatchOperation, error) {
+	var subResponsesFromBatchOperation []SubResponseFromBatchOperation
+	err := client.send("PATCH", "batch/templates/repositories", operations, &subResponsesFromBatchOperation, http.StatusMultiStatus)
 	if err != nil {
 		return nil, err
 	}
-
-	var subResponsesFromBatchOperation []SubResponseFromBatchOperation
-	json.Unmarshal(byteArray, &subResponsesFromBatchOperation)
-
 	return subResponsesFromBatchOperation, nil
 }
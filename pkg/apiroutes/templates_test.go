@@ -14,6 +14,8 @@ package apiroutes
 import (
 	"errors"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/eclipse/codewind-installer/pkg/utils"
@@ -448,3 +450,70 @@ func TestBatchPatchTemplateRepos(t *testing.T) {
 
 	// This test block cleans up after itself, assuming that the template repo tested was initially enabled. (This test block resets it to 'enabled')
 }
+
+func TestMergeExtensionStyles(t *testing.T) {
+	extensions := []utils.Extension{
+		utils.Extension{ProjectType: "appsodyExtension", Config: utils.ExtensionConfig{Style: "Appsody"}},
+		utils.Extension{ProjectType: "odoExtension", Config: utils.ExtensionConfig{Style: "OpenShift", Styles: []string{"odo", "openshift"}}},
+	}
+	styles := mergeExtensionStyles([]string{"Codewind", "appsody"}, extensions)
+	assert.Equal(t, []string{"Codewind", "appsody", "OpenShift", "odo"}, styles)
+}
+
+func TestDescribeTemplates(t *testing.T) {
+	templates := []Template{
+		Template{Label: "Node.js Express", Language: "nodejs", URL: "https://github.com/codewind-resources/nodeExpressTemplate", ProjectType: "nodejs", Source: "Default templates", SourceID: "repo1"},
+		Template{Label: "Appsody Java", Language: "java", URL: "https://github.com/appsody/stacks/java", ProjectType: "appsodyExtension", ProjectStyle: "Appsody", Source: "Appsody Stacks"},
+		Template{Label: "OpenShift Python", Language: "python", URL: "https://github.com/openshift/python", ProjectType: "docker", ProjectStyle: "odo"},
+	}
+	repos := []utils.TemplateRepo{
+		utils.TemplateRepo{ID: "repo1", Name: "codewind-templates"},
+	}
+	extensions := []utils.Extension{
+		utils.Extension{Name: "codewind-appsody-extension", ProjectType: "appsodyExtension"},
+		utils.Extension{ProjectType: "odoExtension", Config: utils.ExtensionConfig{Style: "OpenShift", Styles: []string{"odo"}}},
+	}
+	listings := describeTemplates(templates, repos, extensions)
+
+	t.Run("Asserts templates without a style are Codewind templates from their repo", func(t *testing.T) {
+		assert.Equal(t, "https://github.com/codewind-resources/nodeExpressTemplate", listings[0].ID)
+		assert.Equal(t, DefaultTemplateStyle, listings[0].Style)
+		assert.Equal(t, "codewind-templates", listings[0].Repo)
+		assert.False(t, listings[0].RequiresExtension)
+	})
+
+	t.Run("Asserts the extension of the project type is needed", func(t *testing.T) {
+		assert.Equal(t, "Appsody Stacks", listings[1].Repo)
+		assert.True(t, listings[1].RequiresExtension)
+		assert.Equal(t, "codewind-appsody-extension", listings[1].Extension)
+	})
+
+	t.Run("Asserts the extension of the style is needed", func(t *testing.T) {
+		assert.True(t, listings[2].RequiresExtension)
+		assert.Equal(t, "odoExtension", listings[2].Extension)
+	})
+
+	t.Run("Asserts there are no listings without templates", func(t *testing.T) {
+		assert.Equal(t, []TemplateListing{}, describeTemplates(nil, repos, extensions))
+	})
+}
+
+func TestGetTemplateListings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/templates" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[{"label": "Appsody Java", "url": "https://github.com/appsody/stacks/java", "projectType": "appsodyExtension", "projectStyle": "Appsody", "source": "Appsody Stacks", "sourceId": "repo1"}]`))
+	}))
+	defer server.Close()
+
+	t.Run("Asserts templates are listed when the repos and extensions cannot be read", func(t *testing.T) {
+		listings, err := NewPFEClientForHost(http.DefaultClient, server.URL).GetTemplateListings("", false)
+		assert.Nil(t, err)
+		assert.Len(t, listings, 1)
+		assert.Equal(t, "Appsody Stacks", listings[0].Repo)
+		assert.False(t, listings[0].RequiresExtension)
+		assert.Equal(t, "", listings[0].Extension)
+	})
+}
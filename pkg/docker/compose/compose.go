@@ -0,0 +1,176 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package compose
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Template is the docker-compose file built into cwctl, used when the release manifest has none. It gives the
+// layout of the Codewind services, and Render fills in the images, names, ports, volumes and environment
+const Template = `
+version: 2
+services:
+ codewind-pfe:
+  image: codewind-pfe
+  container_name: codewind-pfe
+  user: root
+  environment: []
+  depends_on: [codewind-performance]
+  ports: []
+  volumes: []
+  networks: [network]
+ codewind-performance:
+  image: codewind-performance
+  ports: []
+  container_name: codewind-performance
+  networks: [network]
+networks:
+  network:
+   driver_opts:
+    com.docker.network.bridge.host_binding_ipv4: "127.0.0.1"
+volumes:
+  cw-workspace:
+`
+
+// File : the parts of a docker-compose file that cwctl reads and renders
+type File struct {
+	Version  string `yaml:"version"`
+	Services struct {
+		PFE struct {
+			Image         string   `yaml:"image"`
+			ContainerName string   `yaml:"container_name"`
+			User          string   `yaml:"user"`
+			Environment   []string `yaml:"environment"`
+			DependsOn     []string `yaml:"depends_on"`
+			Ports         []string `yaml:"ports"`
+			Volumes       []string `yaml:"volumes"`
+			Networks      []string `yaml:"networks"`
+		} `yaml:"codewind-pfe"`
+		Performance struct {
+			Image         string   `yaml:"image"`
+			Ports         []string `yaml:"ports"`
+			ContainerName string   `yaml:"container_name"`
+			Volumes       []string `yaml:"volumes"`
+			Networks      []string `yaml:"networks"`
+		} `yaml:"codewind-performance"`
+	} `yaml:"services"`
+	// Volumes are the named volumes of the file, with any options the template gives them
+	Volumes  map[string]interface{} `yaml:"volumes"`
+	Networks struct {
+		Network struct {
+			DriverOpts struct {
+				HostIP string `yaml:"com.docker.network.bridge.host_binding_ipv4"`
+			} `yaml:"driver_opts"`
+		} `yaml:"network"`
+	} `yaml:"networks"`
+}
+
+// Service : what a Codewind container runs, and how it is published
+type Service struct {
+	Image         string
+	ContainerName string
+	// HostPort is the port the service is published on, or empty for a port Docker assigns
+	HostPort      string
+	ContainerPort int
+	Environment   map[string]string
+	Volumes       []string
+}
+
+// Config : the values a docker-compose file is rendered from
+type Config struct {
+	// HostInterface is the address the ports are published on
+	HostInterface string
+	PFE           Service
+	Performance   Service
+	// Volumes are the named volumes the services mount besides those the template declares
+	Volumes []string
+}
+
+// Render : the docker-compose file of the template with the values of config filled in. Variables of the
+// template which config also sets are replaced, and so are any ${...} placeholders older templates used for them
+func Render(template []byte, config Config) ([]byte, error) {
+	file := File{}
+	err := yaml.Unmarshal(template, &file)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to parse the docker-compose template: %v", err)
+	}
+
+	pfe := &file.Services.PFE
+	pfe.Image = config.PFE.Image
+	pfe.ContainerName = config.PFE.ContainerName
+	pfe.Environment = mergeEnvironment(pfe.Environment, config.PFE.Environment)
+	pfe.Ports = []string{config.PFE.portMapping(config.HostInterface)}
+	pfe.Volumes = config.PFE.Volumes
+
+	performance := &file.Services.Performance
+	performance.Image = config.Performance.Image
+	performance.ContainerName = config.Performance.ContainerName
+	performance.Ports = []string{config.Performance.portMapping(config.HostInterface)}
+	performance.Volumes = config.Performance.Volumes
+
+	if file.Volumes == nil {
+		file.Volumes = map[string]interface{}{}
+	}
+	for _, volume := range config.Volumes {
+		if _, isDeclared := file.Volumes[volume]; !isDeclared {
+			file.Volumes[volume] = nil
+		}
+	}
+
+	file.Networks.Network.DriverOpts.HostIP = config.HostInterface
+	return yaml.Marshal(&file)
+}
+
+// WriteFile : writes a rendered docker-compose file, creating its directory. It is only readable by the user, as
+// the environment of PFE can include proxy credentials
+func WriteFile(path string, content []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+func (service Service) portMapping(hostInterface string) string {
+	return hostInterface + ":" + service.HostPort + ":" + strconv.Itoa(service.ContainerPort)
+}
+
+// mergeEnvironment : the NAME=value entries of the template that values does not set, followed by values sorted
+// by name. Entries left with a ${...} placeholder are dropped, as nothing sets the variable any more
+func mergeEnvironment(template []string, values map[string]string) []string {
+	merged := []string{}
+	for _, entry := range template {
+		name := strings.SplitN(entry, "=", 2)[0]
+		if _, isSet := values[name]; isSet || strings.Contains(entry, "${") {
+			continue
+		}
+		merged = append(merged, entry)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		merged = append(merged, name+"="+values[name])
+	}
+	return merged
+}
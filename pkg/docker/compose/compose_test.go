@@ -0,0 +1,114 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package compose
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+var testConfig = Config{
+	HostInterface: "127.0.0.1",
+	PFE: Service{
+		Image:         "eclipse/codewind-pfe-amd64:0.7.0",
+		ContainerName: "codewind-pfe-demo",
+		HostPort:      "10000",
+		ContainerPort: 9090,
+		Environment:   map[string]string{"HOST_OS": "linux", "CODEWIND_VERSION": "0.7.0"},
+		Volumes:       []string{"cw-workspace:/codewind-workspace"},
+	},
+	Performance: Service{
+		Image:         "eclipse/codewind-performance-amd64:0.7.0",
+		ContainerName: "codewind-performance-demo",
+		HostPort:      "9095",
+		ContainerPort: 9095,
+	},
+}
+
+func TestRender(t *testing.T) {
+	t.Run("Fills the config into the built in template", func(t *testing.T) {
+		content, err := Render([]byte(Template), testConfig)
+		assert.Nil(t, err)
+		file := File{}
+		assert.Nil(t, yaml.Unmarshal(content, &file))
+		assert.Equal(t, "eclipse/codewind-pfe-amd64:0.7.0", file.Services.PFE.Image)
+		assert.Equal(t, "codewind-pfe-demo", file.Services.PFE.ContainerName)
+		assert.Equal(t, []string{"127.0.0.1:10000:9090"}, file.Services.PFE.Ports)
+		assert.Equal(t, []string{"CODEWIND_VERSION=0.7.0", "HOST_OS=linux"}, file.Services.PFE.Environment)
+		assert.Equal(t, []string{"cw-workspace:/codewind-workspace"}, file.Services.PFE.Volumes)
+		assert.Equal(t, []string{"codewind-performance"}, file.Services.PFE.DependsOn)
+		assert.Equal(t, []string{"127.0.0.1:9095:9095"}, file.Services.Performance.Ports)
+		assert.Equal(t, "127.0.0.1", file.Networks.Network.DriverOpts.HostIP)
+	})
+
+	t.Run("Declares the named volumes the services mount", func(t *testing.T) {
+		config := testConfig
+		config.PFE.Volumes = []string{"cw-workspace:/codewind-workspace", "cw-data:/mounted-workspace"}
+		config.Volumes = []string{"cw-workspace", "cw-data"}
+		content, err := Render([]byte(Template), config)
+		assert.Nil(t, err)
+		file := File{}
+		assert.Nil(t, yaml.Unmarshal(content, &file))
+		assert.Contains(t, file.Volumes, "cw-workspace")
+		assert.Contains(t, file.Volumes, "cw-data")
+		assert.Len(t, file.Volumes, 2)
+	})
+
+	t.Run("Leaves Docker to assign a port which is not configured", func(t *testing.T) {
+		config := testConfig
+		config.PFE.HostPort = ""
+		content, err := Render([]byte(Template), config)
+		assert.Nil(t, err)
+		assert.Contains(t, string(content), "127.0.0.1::9090")
+	})
+
+	t.Run("Keeps the variables of a template which the config does not set", func(t *testing.T) {
+		template := `
+version: 2
+services:
+ codewind-pfe:
+  image: ${PFE_IMAGE}
+  environment: ["HOST_OS=${HOST_OS}","HOST_HOME=${HOST_HOME}","LOG_FORMAT=json"]
+`
+		content, err := Render([]byte(template), testConfig)
+		assert.Nil(t, err)
+		file := File{}
+		assert.Nil(t, yaml.Unmarshal(content, &file))
+		assert.Equal(t, []string{"LOG_FORMAT=json", "CODEWIND_VERSION=0.7.0", "HOST_OS=linux"}, file.Services.PFE.Environment)
+		assert.NotContains(t, string(content), "${")
+	})
+
+	t.Run("Rejects a template which is not YAML", func(t *testing.T) {
+		_, err := Render([]byte("services: ["), testConfig)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestWriteFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "compose")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state", "codewind-docker-compose.yaml")
+
+	err := WriteFile(path, []byte("version: \"2\"\n"))
+	assert.Nil(t, err)
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+}
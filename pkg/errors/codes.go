@@ -0,0 +1,181 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Category : A class of failure. Code and ExitCode are stable so that scripts and IDEs can react to them
+type Category struct {
+	Code        string `json:"code"`
+	ExitCode    int    `json:"exit_code"`
+	Description string `json:"description"`
+}
+
+// Error categories, every failure of a cwctl command exits with the exit code of one of these
+var (
+	Internal   = Category{"internal", 1, "An unexpected failure inside cwctl"}
+	Usage      = Category{"usage", 10, "Invalid command line options, or an unknown connection, project or config key"}
+	Network    = Category{"network", 20, "A remote server, Keycloak or a download could not be reached"}
+	Auth       = Category{"auth", 30, "Authentication failed, or credentials are missing or cannot be stored"}
+	Docker     = Category{"docker", 40, "A docker, docker-compose or container operation failed"}
+	Filesystem = Category{"filesystem", 50, "A file or directory could not be read, written or parsed"}
+	PFEAPI     = Category{"pfe_api", 60, "PFE, the gatekeeper or a deployment rejected a request or responded unexpectedly"}
+)
+
+// Categories : Every error category, in exit code order
+func Categories() []Category {
+	return []Category{Internal, Usage, Network, Auth, Docker, Filesystem, PFEAPI}
+}
+
+// opCategories maps the Op of each package error type to its category
+var opCategories = map[string]Category{
+	"con_parse":             Filesystem,
+	"con_load":              Filesystem,
+	"con_write":             Filesystem,
+	"con_lock":              Filesystem,
+	"con_schema_update":     Filesystem,
+	"con_conflict":          Usage,
+	"con_not_found":         Usage,
+	"con_protected":         Usage,
+	"con_cli_options":       Usage,
+	"con_environment":       Network,
+	"sec_connection":        Network,
+	"sec_response":          Auth,
+	"sec_bodyparser":        Auth,
+	"sec_notfound":          Auth,
+	"sec_create":            Auth,
+	"sec_passwordcontent":   Auth,
+	"sec_badhostname":       Usage,
+	"sec_keyring":           Auth,
+	"sec_con_config":        Usage,
+	"sec_cli_options":       Usage,
+	"sec_discovery":         Network,
+	"sec_federated":         Auth,
+	"sec_authcode":          Auth,
+	"sec_openshift":         Auth,
+	"proj_path":             Usage,
+	"proj_type":             Usage,
+	"proj_id_invalid":       Usage,
+	"proj_name_invalid":     Usage,
+	"proj_notfound":         Usage,
+	"proj_conflict":         Usage,
+	"connection_notfound":   Usage,
+	"proj_response":         PFEAPI,
+	"proj_parse":            Filesystem,
+	"proj_load":             Filesystem,
+	"proj_write":            Filesystem,
+	"proj_delete":           Filesystem,
+	"proj_exec":             Docker,
+	"proj_setting":          Usage,
+	"proj_cleanup":          Docker,
+	"proj_git_clone":        Network,
+	"proj_verify":           Network,
+	"proj_bind_interrupted": Network,
+	"proj_bind_aborted":     PFEAPI,
+	"proj_transfer":         Usage,
+	"proj_link":             Usage,
+	"proj_extension":        Filesystem,
+	"proj_github":           Network,
+	"config_parse":          Filesystem,
+	"config_load":           Filesystem,
+	"config_write":          Filesystem,
+	"config_unknown_key":    Usage,
+	"migrate_parse":         Filesystem,
+	"migrate_load":          Filesystem,
+	"migrate_write":         Filesystem,
+	"migrate_backup":        Filesystem,
+	"migrate_step":          Filesystem,
+	"migrate_newer_version": Usage,
+	"apply_load":            Filesystem,
+	"apply_parse":           Filesystem,
+	"apply_invalid":         Usage,
+	"apply_connection":      Network,
+	"apply_template_repo":   PFEAPI,
+	"apply_registry_secret": PFEAPI,
+	"apply_project":         PFEAPI,
+	"rem_not_found":         Usage,
+	"rem_no_ingress":        PFEAPI,
+	"rem_progress":          PFEAPI,
+	"rem_step_failed":       PFEAPI,
+	"rem_remove_failed":     PFEAPI,
+	"rem_logs":              PFEAPI,
+	"rem_docker_failed":     Docker,
+	"rem_preflight":         PFEAPI,
+	"rem_export":            Filesystem,
+	"rem_helm":              PFEAPI,
+	"rem_not_openshift":     Usage,
+	"rem_oauth":             PFEAPI,
+	"tx_connection":         Network,
+	"tx_auth":               Auth,
+	"tx_failed":             Auth,
+	"tx_nopassword":         Auth,
+	"cli_options":           Usage,
+	"pfe_response":          PFEAPI,
+}
+
+// CategoryForOp : The category of an error Op, ops which are not known are internal errors
+func CategoryForOp(op string) Category {
+	if category, ok := opCategories[op]; ok {
+		return category
+	}
+	return Internal
+}
+
+// jsonOutput is set when the global --json flag is given
+var jsonOutput bool
+
+// SetJSONOutput : Sets whether failures are reported as JSON on stderr
+func SetJSONOutput(enabled bool) {
+	jsonOutput = enabled
+}
+
+// exitHandler is called with the category of a failure just before cwctl exits with it
+var exitHandler func(category Category)
+
+// SetExitHandler : Sets the function called with the category of a failure just before cwctl exits with it
+func SetExitHandler(handler func(category Category)) {
+	exitHandler = handler
+}
+
+func exitWith(category Category) {
+	if exitHandler != nil {
+		exitHandler(category)
+	}
+	os.Exit(category.ExitCode)
+}
+
+// jsonError : the body of a failure reported in JSON mode
+type jsonError struct {
+	Code string `json:"code"`
+	Op   string `json:"op,omitempty"`
+	Msg  string `json:"msg"`
+}
+
+// Exit : Reports a failure and exits with the exit code of its category. In JSON mode the failure is
+// written to stderr as {"error": {"code": ..., "op": ..., "msg": ...}}, otherwise msg is printed to stdout
+func Exit(category Category, op string, msg string) {
+	if jsonOutput {
+		writeJSONError(category, op, msg)
+	} else {
+		fmt.Println(msg)
+	}
+	exitWith(category)
+}
+
+func writeJSONError(category Category, op string, msg string) {
+	body, _ := json.Marshal(map[string]jsonError{"error": {category.Code, op, msg}})
+	fmt.Fprintln(os.Stderr, string(body))
+}
@@ -0,0 +1,39 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CategoryForOp(t *testing.T) {
+	assert.Equal(t, Network, CategoryForOp("sec_connection"))
+	assert.Equal(t, Usage, CategoryForOp("con_not_found"))
+	assert.Equal(t, PFEAPI, CategoryForOp("proj_response"))
+	assert.Equal(t, Internal, CategoryForOp("not_an_op"))
+}
+
+func Test_CategoriesAreUnique(t *testing.T) {
+	codes := map[string]bool{}
+	exitCodes := map[int]bool{}
+	for _, category := range Categories() {
+		assert.False(t, codes[category.Code], category.Code)
+		assert.False(t, exitCodes[category.ExitCode], category.Code)
+		codes[category.Code] = true
+		exitCodes[category.ExitCode] = true
+	}
+	for op, category := range opCategories {
+		assert.Contains(t, Categories(), category, op)
+	}
+}
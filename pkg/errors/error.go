@@ -13,65 +13,64 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"log"
 )
 
-// CheckErr function to respond with appropriate error messages
+// checkErrCode : the name and category of a numeric CheckErr code
+type checkErrCode struct {
+	name     string
+	category Category
+}
+
+var checkErrCodes = map[int]checkErrCode{
+	100: {"DOCKER_ERROR", Docker},
+	101: {"DOCKER_COMPOSE_ERROR", Docker},
+	102: {"IMAGE_TAGGING_ERROR", Docker},
+	103: {"CONTAINER_STATUS_ERROR", Docker},
+	104: {"IMAGE_STATUS_ERROR", Docker},
+	105: {"REMOVE_IMAGE_ERROR", Docker},
+	107: {"CONTAINER_LIST_ERROR", Docker},
+	108: {"CONTAINER_ERROR", Docker},
+	109: {"IMAGE_LIST_ERROR", Docker},
+	110: {"DOCKER_NETWORK_LIST_ERROR", Docker},
+	111: {"DOCKER_NETWORK_ERROR", Docker},
+	200: {"INTERNAL_ERROR", Internal},
+	201: {"CREATE_FILE_ERROR", Filesystem},
+	202: {"WRITE_FILE_ERROR", Filesystem},
+	203: {"WRITE_FILE_ERROR", Filesystem},
+	204: {"WRITE_FILE_ERROR", Filesystem},
+	205: {"DIRECTORY_ERROR", Filesystem},
+	206: {"DELETE_FILE_ERROR", Filesystem},
+	207: {"READ_FILE_ERROR", Filesystem},
+	208: {"PARSING_ERROR", Filesystem},
+	300: {"APPLICATION_ERROR", Internal},
+	400: {"REPOSITORY_DOWNLOAD_ERROR", Network},
+	401: {"CREATE_ZIP_FILE_ERROR", Filesystem},
+	402: {"READ_ZIP_FILE_ERROR", Filesystem},
+	403: {"OUTPUT_FILE_ERROR", Filesystem},
+	404: {"WRITE_FILE_ERROR", Filesystem},
+	405: {"RELEASE_MANIFEST_ERROR", Network},
+}
+
+// CheckErr function to respond with appropriate error messages, exiting with the exit code of the error's category
 func CheckErr(err error, code int, optMsg string) {
 	if err != nil {
-		switch code {
-		case 100:
-			log.Fatal("DOCKER_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 101:
-			log.Fatal("DOCKER_COMPOSE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 102:
-			log.Fatal("IMAGE_TAGGING_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 103:
-			log.Fatal("CONTAINER_STATUS_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 104:
-			log.Fatal("IMAGE_STATUS_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 105:
-			log.Fatal("REMOVE_IMAGE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 107:
-			log.Fatal("CONTAINER_LIST_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 108:
-			log.Fatal("CONTAINER_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 109:
-			log.Fatal("IMAGE_LIST_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 110:
-			log.Fatal("DOCKER_NETWORK_LIST_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 111:
-			log.Fatal("DOCKER_NETWORK_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 200:
-			log.Fatal("INTERNAL_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 201:
-			log.Fatal("CREATE_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 202, 203, 204:
-			log.Fatal("WRITE_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 205:
-			log.Fatal("DIRECTORY_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 206:
+		errCode, ok := checkErrCodes[code]
+		if !ok {
+			errCode = checkErrCode{"UNKNOWN_ERROR", Internal}
+		}
+		if code == 206 {
 			// Do not want to exit if this is thrown
-			log.Print("DELETE_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 207:
-			log.Fatal("READ_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 208:
-			log.Fatal("PARSING_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 300:
-			log.Fatal("APPLICATION_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 400:
-			log.Fatal("REPOSITORY_DOWNLOAD_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 401:
-			log.Fatal("CREATE_ZIP_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 402:
-			log.Fatal("READ_ZIP_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 403:
-			log.Fatal("OUTPUT_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		case 404:
-			log.Fatal("WRITE_FILE_ERROR", "[", code, "]: ", err, ". ", optMsg)
-		default:
-			log.Fatal("UNKNOWN_ERROR", "[", code, "]: ", err, ". ", optMsg)
+			log.Print(errCode.name, "[", code, "]: ", err, ". ", optMsg)
+			return
+		}
+		if jsonOutput {
+			writeJSONError(errCode.category, errCode.name, fmt.Sprint(err, ". ", optMsg))
+			exitWith(errCode.category)
 		}
+		log.Print(errCode.name, "[", code, "]: ", err, ". ", optMsg)
+		exitWith(errCode.category)
 	}
 }
 
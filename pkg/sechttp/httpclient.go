@@ -17,24 +17,22 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/eclipse/codewind-installer/pkg/utils/security"
 	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
-	"github.com/zalando/go-keyring"
 )
 
 // DispatchHTTPRequest : perform an HTTP request with token based authentication
 // Returns: HTTPResponse, HTTPSecError
 func DispatchHTTPRequest(httpClient utils.HTTPClient, originalRequest *http.Request, username string, connectionID string) (*http.Response, *HTTPSecError) {
 
-	logr.SetLevel(logr.TraceLevel)
+	logr.Debugf("Request URL: %v %v", originalRequest.Method, originalRequest.URL)
 
-	logr.Printf("Request URL: %v %v", originalRequest.Method, originalRequest.URL)
-
-	if strings.ToLower(connectionID) == "local" {
-		response, err := sendRequest(httpClient, originalRequest, "")
+	if connections.IsLocal(connectionID) {
+		response, err := sendRequest(localPFEClient(httpClient, connectionID), originalRequest, "")
 		if err == nil {
 			logr.Debugf("Received HTTP Status code: %v", response.StatusCode)
 			return response, nil
@@ -51,39 +49,49 @@ func DispatchHTTPRequest(httpClient utils.HTTPClient, originalRequest *http.Requ
 		return nil, &HTTPSecError{errOpNoConnection, conErr.Err, conErr.Desc}
 	}
 
-	// Get the current access token from the keychain
-	logr.Debugf("Retrieving an access token from the keychain")
+	// An access token from the environment or the credentials file is used first, as CI has no keyring to cache one in
 	conID := strings.TrimSpace(strings.ToLower(connectionID))
-	accessToken, _ := keyring.Get(security.KeyringServiceName+"."+conID, "access_token")
+	credentials := security.LookupCredentials(conID, username)
+	if credentials.AccessToken != "" {
+		logr.Debugf("Trying request with the access token from the environment or credentials file")
+		response, err := sendRequest(httpClient, originalRequest, credentials.AccessToken)
+		if err == nil && response.StatusCode != keycloakLoginErrorStatus {
+			logr.Debugf("Received HTTP Status code: %v", response.StatusCode)
+			return response, nil
+		}
+	}
+
+	// Get the current access token from the keychain, skipping it when it has expired or is about to
+	logr.Debugf("Retrieving an access token from the keychain")
+	tokens := security.SecGetCachedTokens(conID)
 
-	if accessToken == "" {
-		logr.Debugf("Access token not found in keychain")
+	if !tokens.HasValidAccessToken() {
+		logr.Debugf("Access token not found in keychain or expired")
 	} else {
 		logr.Debugf("Access token found in keychain, trying request")
-		response, err := sendRequest(httpClient, originalRequest, accessToken)
+		response, err := sendRequest(httpClient, originalRequest, tokens.AccessToken)
 		if err == nil && response.StatusCode != keycloakLoginErrorStatus {
 			logr.Debugf("Received HTTP Status code: %v", response.StatusCode)
 			return response, nil
 		}
-		logr.Debugf(" Request failed: %v", err.Desc)
+		if err != nil {
+			logr.Debugf(" Request failed: %v", err.Desc)
+		}
 	}
 
 	// Try refreshing the access token with our cached refresh token
-	logr.Debugf("Retrieving a refresh token from the keychain")
-	refreshToken, _ := keyring.Get(security.KeyringServiceName+"."+conID, "refresh_token")
-	if refreshToken == "" {
-		logr.Debugf("Refresh token not found in keychain")
+	if !tokens.HasValidRefreshToken() {
+		logr.Debugf("Refresh token not found in keychain or expired")
 	} else {
 		logr.Debugf("Try refreshing the access token with our cached refresh token")
-		tokens, secError := security.SecRefreshAccessToken(http.DefaultClient, con, refreshToken)
+		refreshed, secError := security.SecRefreshAccessToken(http.DefaultClient, con, tokens.RefreshToken)
 		if secError != nil {
 			logr.Debugf("Failed refreshing access token %v : %v\n", secError.Op, secError.Desc)
 		}
-		if tokens != nil {
+		if refreshed != nil {
 			logr.Debugf("New access token received")
-			accessToken = tokens.AccessToken
 			logr.Debugf("Trying the original request again with the new access_token")
-			response, err := sendRequest(httpClient, originalRequest, accessToken)
+			response, err := sendRequest(httpClient, originalRequest, refreshed.AccessToken)
 			if err == nil && response.StatusCode != keycloakLoginErrorStatus {
 				logr.Debugf("Received HTTP Status code: %v", response.StatusCode)
 				return response, nil
@@ -91,23 +99,40 @@ func DispatchHTTPRequest(httpClient utils.HTTPClient, originalRequest *http.Requ
 		}
 	}
 
-	logr.Debugf("Re-authenticate using cached credentials from the keychain")
-	password, keyErr := keyring.Get(security.KeyringServiceName+"."+conID, strings.ToLower(username))
-	if keyErr != nil {
-		logr.Debugf("ERROR:  %v\n", keyErr.Error())
-		err := errors.New(errMissingPassword)
-		return nil, &HTTPSecError{errOpNoPassword, err, err.Error()}
+	// Re-authenticate with the password from the environment or credentials file, or else the one in the keychain.
+	// OpenShift OAuth falls back to the token of `oc login` when there is no password
+	openShiftOAuth := con.AuthProvider == apiroutes.AuthProviderOpenShift
+	password := credentials.Password
+	if password == "" && (credentials.Username != "" || !openShiftOAuth) {
+		logr.Debugf("Re-authenticate using cached credentials from the keychain")
+		secret, keyErr := security.SecKeyGetSecret(conID, credentials.Username)
+		if keyErr != nil && !openShiftOAuth {
+			logr.Debugf("ERROR:  %v\n", keyErr.Desc)
+			err := errors.New(errMissingPassword)
+			return nil, &HTTPSecError{errOpNoPassword, err, err.Error()}
+		}
+		password = secret
 	}
 
-	set := flag.NewFlagSet("Authentication", 0)
-	set.String("host", con.AuthURL, "doc")
-	set.String("realm", con.Realm, "doc")
-	set.String("username", username, "doc")
-	set.String("password", password, "doc")
-	set.String("client", con.ClientID, "doc")
-	set.String("conid", con.ID, "doc")
-	c := cli.NewContext(nil, set, nil)
-	tokens, secError := security.SecAuthenticate(http.DefaultClient, c, "", "")
+	var authTokens *security.AuthToken
+	var secError *security.SecError
+	if openShiftOAuth {
+		username := credentials.Username
+		if password == "" {
+			username = ""
+		}
+		authTokens, secError = security.SecAuthenticateOpenShift(http.DefaultClient, con.AuthURL, con.ID, username, password)
+	} else {
+		set := flag.NewFlagSet("Authentication", 0)
+		set.String("host", con.AuthURL, "doc")
+		set.String("realm", con.Realm, "doc")
+		set.String("username", credentials.Username, "doc")
+		set.String("password", password, "doc")
+		set.String("client", con.ClientID, "doc")
+		set.String("conid", con.ID, "doc")
+		c := cli.NewContext(nil, set, nil)
+		authTokens, secError = security.SecAuthenticate(http.DefaultClient, c, "", "")
+	}
 	if secError != nil {
 		// Bailing out, user cant authenticate
 		logr.Debugf("Bailing out, user can not authenticate")
@@ -116,7 +141,7 @@ func DispatchHTTPRequest(httpClient utils.HTTPClient, originalRequest *http.Requ
 
 	// Try to access the resource again with the new access token
 	logr.Debugf("Try to access the resource again with the new access token")
-	response, err := sendRequest(httpClient, originalRequest, tokens.AccessToken)
+	response, err := sendRequest(httpClient, originalRequest, authTokens.AccessToken)
 
 	if err == nil {
 		logr.Debugf("Received HTTP Status code: %v", response.StatusCode)
@@ -129,6 +154,59 @@ func DispatchHTTPRequest(httpClient utils.HTTPClient, originalRequest *http.Requ
 	return nil, &HTTPSecError{errOpFailed, failedError, failedError.Error()}
 }
 
+// ConnectionClient : an HTTPClient which authenticates every request it sends against a connection
+type ConnectionClient struct {
+	HTTPClient   utils.HTTPClient
+	ConnectionID string
+	Username     string
+}
+
+// Do : dispatch the request using token based authentication for the client's connection
+func (client *ConnectionClient) Do(req *http.Request) (*http.Response, error) {
+	response, httpSecError := DispatchHTTPRequest(client.HTTPClient, req, client.Username, client.ConnectionID)
+	if httpSecError != nil {
+		return nil, httpSecError
+	}
+	return response, nil
+}
+
+// IsAuthError : true when a request sent by a ConnectionClient failed because it could not be authenticated,
+// rather than because the connection could not be reached. The gatekeeper redirects requests it does not
+// accept to the Keycloak login page
+func IsAuthError(err error) bool {
+	switch e := err.(type) {
+	case *HTTPSecError:
+		return e.Op == errOpAuthFailed || e.Op == errOpFailed || e.Op == errOpNoPassword
+	case *apiroutes.APIError:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden || e.StatusCode == http.StatusFound
+	}
+	return false
+}
+
+// NewPFEClient : a client of the PFE API of a connection, which authenticates every request it sends
+func NewPFEClient(connectionID string) (*apiroutes.PFEClient, *connections.ConError) {
+	host, conErr := connections.GetPFEOrigin(connectionID)
+	if conErr != nil {
+		return nil, conErr
+	}
+	return apiroutes.NewPFEClientForHost(&ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: connectionID}, host), nil
+}
+
+// NewRemotePFEClient : a client of the PFE API of a remote connection, which sends requests through httpClient and
+// authenticates every one
+func NewRemotePFEClient(httpClient utils.HTTPClient, connection connections.Connection) *apiroutes.PFEClient {
+	return apiroutes.NewPFEClientForHost(&ConnectionClient{HTTPClient: httpClient, ConnectionID: connection.ID}, connection.URL)
+}
+
+// localPFEClient : the client requests to a local connection are sent with. The default client is replaced by the
+// one which reaches the local PFE without a proxy or DNS, clients with settings of their own are kept
+func localPFEClient(httpClient utils.HTTPClient, connectionID string) utils.HTTPClient {
+	if client, ok := httpClient.(*http.Client); ok && client == http.DefaultClient {
+		return utils.LocalPFEClient(connections.LocalProfileOf(connectionID))
+	}
+	return httpClient
+}
+
 // Send the HTTP request along with supplied headers and access_token
 func sendRequest(httpClient utils.HTTPClient, originalRequest *http.Request, accessToken string) (*http.Response, *HTTPSecError) {
 
@@ -29,7 +29,7 @@ const (
 
 const (
 	errConnetionNotFound = "Cant find a valid connection"
-	errMissingPassword   = "Unable to find password in keychain"
+	errMissingPassword   = "Unable to find a password in the keychain, CW_PASSWORD or the credentials file"
 )
 
 // HTTPSecError : Error formatted in JSON containing an errorOp and a description from
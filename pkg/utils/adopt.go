@@ -0,0 +1,265 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+)
+
+// composeServiceLabel is set by docker-compose on the containers of a compose project to the name of their service
+const composeServiceLabel = "com.docker.compose.service"
+
+// The services of a Codewind deployment
+const (
+	ServicePFE         = "codewind-pfe"
+	ServicePerformance = "codewind-performance"
+)
+
+// adoptedFileSuffix ends the name of the file in the state directory that a profile's adopted deployment is kept in
+const adoptedFileSuffix = "-adopted.json"
+
+// cwctlProjectPattern matches the docker-compose project names of the profiles cwctl starts
+var cwctlProjectPattern = regexp.MustCompile(`^codewind(-[a-z0-9][a-z0-9-]*)?$`)
+
+// adoptedDeployments caches the deployment each profile has adopted, by compose project, once it has been read
+var adoptedDeployments = map[string]*AdoptedDeployment{}
+
+// AdoptedDeployment : Codewind containers that were not started by cwctl, such as by an older installer or by
+// docker-compose by hand, which a profile manages as its own since they were adopted
+type AdoptedDeployment struct {
+	// ComposeProject is the docker-compose project of the containers, empty when they were started by docker run
+	ComposeProject string             `json:"composeProject,omitempty"`
+	Containers     []AdoptedContainer `json:"containers"`
+	AdoptedAt      time.Time          `json:"adoptedAt"`
+}
+
+// AdoptedContainer : A container of an adopted deployment
+type AdoptedContainer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Image   string `json:"image"`
+	Service string `json:"service"`
+}
+
+// CodewindService : The Codewind service a container runs, ServicePFE or ServicePerformance, or empty for other
+// containers. The service is taken from the docker-compose label, or else from the image without its registry and
+// organization, so that containers of the eclipse images or started by docker run are recognized too
+func CodewindService(container types.Container) string {
+	services := []string{ServicePFE, ServicePerformance}
+	for _, service := range services {
+		if container.Labels[composeServiceLabel] == service {
+			return service
+		}
+	}
+	image := container.Image[strings.LastIndex(container.Image, "/")+1:]
+	for _, service := range services {
+		if strings.HasPrefix(image, service) {
+			return service
+		}
+	}
+	return ""
+}
+
+// Includes : true when the container belongs to the adopted deployment. Containers of a compose project belong
+// by their label, so they are still recognized when docker-compose recreates them, others by their ID
+func (deployment *AdoptedDeployment) Includes(container types.Container) bool {
+	if deployment.ComposeProject != "" {
+		return container.Labels[composeProjectLabel] == deployment.ComposeProject
+	}
+	for _, adopted := range deployment.Containers {
+		if adopted.ID == container.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// AdoptedDeployment : The deployment the profile has adopted, or nil when it has not adopted one
+func (profile LocalProfile) AdoptedDeployment() *AdoptedDeployment {
+	project := profile.ComposeProject()
+	if deployment, isCached := adoptedDeployments[project]; isCached {
+		return deployment
+	}
+	deployment, err := readAdoptedDeployment(profile.adoptedFile())
+	if err != nil && !os.IsNotExist(err) {
+		logr.Warnf("Ignoring the unreadable adopted deployment of %v: %v", project, err)
+	}
+	adoptedDeployments[project] = deployment
+	return deployment
+}
+
+// Adopt : Records the containers as the profile's deployment, so that status, stop and start manage them as if
+// cwctl had started them
+func (profile LocalProfile) Adopt(containers []types.Container) (*AdoptedDeployment, error) {
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("There are no containers to adopt")
+	}
+	deployment := &AdoptedDeployment{
+		ComposeProject: containers[0].Labels[composeProjectLabel],
+		AdoptedAt:      time.Now().UTC(),
+	}
+	for _, container := range containers {
+		deployment.Containers = append(deployment.Containers, AdoptedContainer{
+			ID:      container.ID,
+			Name:    containerName(container),
+			Image:   container.Image,
+			Service: CodewindService(container),
+		})
+	}
+	body, err := json.MarshalIndent(deployment, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	err = os.MkdirAll(cliconfig.GetStateDir(), 0700)
+	if err != nil {
+		return nil, err
+	}
+	err = ioutil.WriteFile(profile.adoptedFile(), body, 0600)
+	if err != nil {
+		return nil, err
+	}
+	adoptedDeployments[profile.ComposeProject()] = deployment
+	return deployment, nil
+}
+
+// ReleaseAdoptedDeployment : Forgets the deployment the profile adopted, for when cwctl replaces it with its own
+func (profile LocalProfile) ReleaseAdoptedDeployment() error {
+	adoptedDeployments[profile.ComposeProject()] = nil
+	err := os.Remove(profile.adoptedFile())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (profile LocalProfile) adoptedFile() string {
+	return filepath.Join(cliconfig.GetStateDir(), profile.ComposeProject()+adoptedFileSuffix)
+}
+
+// FindUnmanagedContainers : The running Codewind containers that no profile manages, because they were neither
+// started by cwctl nor adopted
+func FindUnmanagedContainers() []types.Container {
+	return unmanagedContainers(GetContainerList(), allAdoptedDeployments())
+}
+
+// unmanagedContainers : The Codewind containers of the list which are neither in a compose project cwctl started
+// nor in one of the adopted deployments
+func unmanagedContainers(containers []types.Container, adopted []*AdoptedDeployment) []types.Container {
+	unmanaged := []types.Container{}
+	for _, container := range containers {
+		if CodewindService(container) == "" || cwctlProjectPattern.MatchString(container.Labels[composeProjectLabel]) {
+			continue
+		}
+		isAdopted := false
+		for _, deployment := range adopted {
+			isAdopted = isAdopted || deployment.Includes(container)
+		}
+		if !isAdopted {
+			unmanaged = append(unmanaged, container)
+		}
+	}
+	return unmanaged
+}
+
+// SelectDeployment : The containers of the unmanaged deployment whose PFE container is named or has the ID given,
+// or of the only one when no container is given. A deployment is a PFE container and the performance containers
+// of its compose project, or the unmanaged performance containers outside any compose project
+func SelectDeployment(unmanaged []types.Container, pfeContainer string) ([]types.Container, error) {
+	pfes := []types.Container{}
+	for _, container := range unmanaged {
+		if CodewindService(container) != ServicePFE {
+			continue
+		}
+		if pfeContainer == "" || containerName(container) == pfeContainer || strings.HasPrefix(container.ID, pfeContainer) {
+			pfes = append(pfes, container)
+		}
+	}
+	if len(pfes) == 0 {
+		if pfeContainer != "" {
+			return nil, fmt.Errorf("No Codewind PFE container %v that cwctl does not manage was found", pfeContainer)
+		}
+		return nil, fmt.Errorf("No Codewind containers that cwctl does not manage were found")
+	}
+	if len(pfes) > 1 {
+		names := []string{}
+		for _, pfe := range pfes {
+			names = append(names, containerName(pfe))
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("Found several Codewind deployments not started by cwctl, select the PFE container of one of %v", strings.Join(names, ", "))
+	}
+	project := pfes[0].Labels[composeProjectLabel]
+	deployment := []types.Container{pfes[0]}
+	for _, container := range unmanaged {
+		if CodewindService(container) == ServicePerformance && container.Labels[composeProjectLabel] == project {
+			deployment = append(deployment, container)
+		}
+	}
+	return deployment, nil
+}
+
+// allAdoptedDeployments : The deployments adopted by every profile
+func allAdoptedDeployments() []*AdoptedDeployment {
+	files, _ := filepath.Glob(filepath.Join(cliconfig.GetStateDir(), "*"+adoptedFileSuffix))
+	deployments := []*AdoptedDeployment{}
+	for _, file := range files {
+		deployment, err := readAdoptedDeployment(file)
+		if err != nil {
+			logr.Warnf("Ignoring the unreadable adopted deployment %v: %v", file, err)
+			continue
+		}
+		deployments = append(deployments, deployment)
+	}
+	return deployments
+}
+
+func readAdoptedDeployment(file string) (*AdoptedDeployment, error) {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	deployment := AdoptedDeployment{}
+	err = json.Unmarshal(body, &deployment)
+	if err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// containerName : The name of a container, without the leading / docker gives it
+func containerName(container types.Container) string {
+	if len(container.Names) == 0 {
+		return container.ID
+	}
+	return strings.TrimPrefix(container.Names[0], "/")
+}
+
+// ContainerNames : The names of the containers
+func ContainerNames(containers []types.Container) []string {
+	names := []string{}
+	for _, container := range containers {
+		names = append(names, containerName(container))
+	}
+	return names
+}
@@ -0,0 +1,133 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func testContainer(id string, name string, image string, project string) types.Container {
+	labels := map[string]string{}
+	if project != "" {
+		labels[composeProjectLabel] = project
+	}
+	return types.Container{ID: id, Names: []string{"/" + name}, Image: image, Labels: labels}
+}
+
+var (
+	cwctlPFE          = testContainer("a1", "codewind-pfe", "codewind-pfe-amd64:latest", "codewind")
+	installerPFE      = testContainer("b1", "codewind-pfe", "eclipse/codewind-pfe-amd64:0.6.0", "installer")
+	installerPerf     = testContainer("b2", "codewind-performance", "eclipse/codewind-performance-amd64:0.6.0", "installer")
+	dockerRunPFE      = testContainer("c1", "my-pfe", "docker.io/eclipse/codewind-pfe-amd64:0.6.0", "")
+	dockerRunPerf     = testContainer("c2", "my-performance", "codewind-performance-amd64:0.6.0", "")
+	projectContainer  = testContainer("d1", "cw-node-1234", "cw-node-1234", "")
+	labelledContainer = types.Container{ID: "e1", Image: "sha256:0123", Labels: map[string]string{composeServiceLabel: ServicePFE}}
+)
+
+func TestCodewindService(t *testing.T) {
+	assert.Equal(t, ServicePFE, CodewindService(cwctlPFE))
+	assert.Equal(t, ServicePFE, CodewindService(dockerRunPFE))
+	assert.Equal(t, ServicePerformance, CodewindService(installerPerf))
+	assert.Equal(t, ServicePFE, CodewindService(labelledContainer))
+	assert.Equal(t, "", CodewindService(projectContainer))
+}
+
+func TestUnmanagedContainers(t *testing.T) {
+	containers := []types.Container{cwctlPFE, installerPFE, installerPerf, dockerRunPFE, dockerRunPerf, projectContainer}
+
+	t.Run("Finds the Codewind containers of other compose projects or none", func(t *testing.T) {
+		unmanaged := unmanagedContainers(containers, nil)
+		assert.Equal(t, []string{"codewind-pfe", "codewind-performance", "my-pfe", "my-performance"}, ContainerNames(unmanaged))
+	})
+
+	t.Run("Leaves out the containers of adopted deployments", func(t *testing.T) {
+		adopted := []*AdoptedDeployment{
+			{ComposeProject: "installer"},
+			{Containers: []AdoptedContainer{{ID: "c1"}}},
+		}
+		unmanaged := unmanagedContainers(containers, adopted)
+		assert.Equal(t, []string{"my-performance"}, ContainerNames(unmanaged))
+	})
+}
+
+func TestSelectDeployment(t *testing.T) {
+	unmanaged := []types.Container{installerPFE, installerPerf, dockerRunPFE, dockerRunPerf}
+
+	t.Run("Selects the PFE container by name with its compose project", func(t *testing.T) {
+		deployment, err := SelectDeployment(unmanaged, "codewind-pfe")
+		assert.Nil(t, err)
+		assert.Equal(t, []types.Container{installerPFE, installerPerf}, deployment)
+	})
+
+	t.Run("Selects the PFE container by ID with the containers outside a compose project", func(t *testing.T) {
+		deployment, err := SelectDeployment(unmanaged, "c1")
+		assert.Nil(t, err)
+		assert.Equal(t, []types.Container{dockerRunPFE, dockerRunPerf}, deployment)
+	})
+
+	t.Run("Asks for a container when several deployments are running", func(t *testing.T) {
+		_, err := SelectDeployment(unmanaged, "")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Selects the only deployment without a container", func(t *testing.T) {
+		deployment, err := SelectDeployment([]types.Container{installerPerf, installerPFE}, "")
+		assert.Nil(t, err)
+		assert.Equal(t, []types.Container{installerPFE, installerPerf}, deployment)
+	})
+
+	t.Run("Fails when there is nothing to adopt", func(t *testing.T) {
+		_, err := SelectDeployment(unmanaged, "other-pfe")
+		assert.NotNil(t, err)
+		_, err = SelectDeployment(nil, "")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestAdopt(t *testing.T) {
+	home, _ := ioutil.TempDir("", "adopt")
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	adoptedDeployments = map[string]*AdoptedDeployment{}
+	defer func() { adoptedDeployments = map[string]*AdoptedDeployment{} }()
+
+	profile := LocalProfile{Name: "demo"}
+	assert.Nil(t, profile.AdoptedDeployment())
+	assert.False(t, profile.Owns(installerPFE))
+
+	deployment, err := profile.Adopt([]types.Container{installerPFE, installerPerf})
+	assert.Nil(t, err)
+	assert.Equal(t, "installer", deployment.ComposeProject)
+	assert.Equal(t, AdoptedContainer{ID: "b1", Name: "codewind-pfe", Image: "eclipse/codewind-pfe-amd64:0.6.0", Service: ServicePFE}, deployment.Containers[0])
+	assert.True(t, profile.Owns(installerPerf))
+	assert.False(t, LocalProfile{}.Owns(installerPerf))
+
+	t.Run("Reads the adopted deployment back", func(t *testing.T) {
+		adoptedDeployments = map[string]*AdoptedDeployment{}
+		assert.Equal(t, "installer", profile.AdoptedDeployment().ComposeProject)
+		assert.Equal(t, []string{"my-pfe"}, ContainerNames(unmanagedContainers([]types.Container{installerPFE, dockerRunPFE}, allAdoptedDeployments())))
+	})
+
+	t.Run("Forgets the adopted deployment once released", func(t *testing.T) {
+		assert.Nil(t, profile.ReleaseAdoptedDeployment())
+		assert.False(t, profile.Owns(installerPFE))
+		adoptedDeployments = map[string]*AdoptedDeployment{}
+		assert.Nil(t, profile.AdoptedDeployment())
+		assert.Nil(t, profile.ReleaseAdoptedDeployment())
+	})
+}
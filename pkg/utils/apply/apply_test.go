@@ -0,0 +1,118 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apply
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+const testEnvironmentYAML = `
+connections:
+  - label: Team
+    url: https://codewind.team.remote/
+    projectPrefix: team-a-
+registrySecrets:
+  - address: docker.io
+    username: dev
+    passwordEnv: CW_APPLY_TEST_PASSWORD
+projects:
+  - connection: Team
+    name: myapp
+    path: myapp
+    language: nodejs
+    type: nodejs
+`
+
+const testEnvironmentJSON = `{"templateRepos": [{"url": "https://example.com/templates.json", "enabled": false}]}`
+
+func writeEnvironment(t *testing.T, dir string, name string, content string) string {
+	filename := filepath.Join(dir, name)
+	err := ioutil.WriteFile(filename, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func Test_LoadEnvironment(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-apply")
+	defer os.RemoveAll(dir)
+
+	t.Run("Loads a YAML file, resolving passwords and project paths", func(t *testing.T) {
+		os.Setenv("CW_APPLY_TEST_PASSWORD", "secret")
+		defer os.Unsetenv("CW_APPLY_TEST_PASSWORD")
+		env, applyErr := LoadEnvironment(writeEnvironment(t, dir, "env.yaml", testEnvironmentYAML))
+		if applyErr != nil {
+			t.Fatal(applyErr)
+		}
+		assert.Equal(t, "https://codewind.team.remote", env.Connections[0].URL)
+		assert.Equal(t, "local", env.RegistrySecrets[0].Connection)
+		assert.Equal(t, "secret", env.RegistrySecrets[0].Password)
+		assert.Equal(t, filepath.Join(dir, "myapp"), env.Projects[0].Path)
+		assert.Nil(t, env.TemplateRepos)
+	})
+
+	t.Run("Loads a JSON file", func(t *testing.T) {
+		env, applyErr := LoadEnvironment(writeEnvironment(t, dir, "env.json", testEnvironmentJSON))
+		if applyErr != nil {
+			t.Fatal(applyErr)
+		}
+		assert.Len(t, env.TemplateRepos, 1)
+		assert.False(t, *env.TemplateRepos[0].Enabled)
+	})
+
+	t.Run("Rejects a registry secret without a password", func(t *testing.T) {
+		_, applyErr := LoadEnvironment(writeEnvironment(t, dir, "env.yaml", testEnvironmentYAML))
+		assert.Equal(t, errOpInvalidOptions, applyErr.Op)
+	})
+
+	t.Run("Rejects a connection labelled local", func(t *testing.T) {
+		_, applyErr := LoadEnvironment(writeEnvironment(t, dir, "env.yaml", "connections:\n  - label: local\n    url: https://a.remote\n"))
+		assert.Equal(t, errOpInvalidOptions, applyErr.Op)
+	})
+}
+
+func Test_ReconcileDryRun(t *testing.T) {
+	connections.ResetConnectionsFile()
+	env := &Environment{
+		Connections: []ConnectionSpec{{Label: "Team", URL: "https://codewind.team.remote"}},
+		RegistrySecrets: []RegistrySecretSpec{
+			{Connection: "Team", Address: "docker.io", Username: "dev", Password: "secret"},
+		},
+	}
+
+	t.Run("Plans to create a connection and the resources that use it without changing anything", func(t *testing.T) {
+		changes, applyErr := Reconcile(context.Background(), env, Options{DryRun: true})
+		if applyErr != nil {
+			t.Fatal(applyErr)
+		}
+		assert.Equal(t, []Change{
+			{Kind: KindConnection, Name: "Team", Action: ActionCreate, Detail: "https://codewind.team.remote"},
+			{Kind: KindRegistrySecret, Name: "Team/docker.io", Action: ActionCreate, Detail: "dev"},
+		}, changes)
+		all, _ := connections.GetAllConnections()
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("Fails when a resource uses an unknown connection", func(t *testing.T) {
+		env.RegistrySecrets[0].Connection = "Other"
+		_, applyErr := Reconcile(context.Background(), env, Options{DryRun: true})
+		assert.Equal(t, errOpConnection, applyErr.Op)
+	})
+}
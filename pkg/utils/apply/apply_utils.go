@@ -0,0 +1,45 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apply
+
+import (
+	"encoding/json"
+)
+
+// ApplyError : Apply package errors
+type ApplyError struct {
+	Op   string
+	Err  error
+	Desc string
+}
+
+const (
+	errOpFileLoad       = "apply_load"
+	errOpFileParse      = "apply_parse"
+	errOpInvalidOptions = "apply_invalid"
+	errOpConnection     = "apply_connection"
+	errOpTemplateRepo   = "apply_template_repo"
+	errOpRegistrySecret = "apply_registry_secret"
+	errOpProject        = "apply_project"
+)
+
+// ApplyError : Error formatted in JSON containing an errorOp and a description from
+// either a fault condition in the CLI, or an error payload from a REST request
+func (ae *ApplyError) Error() string {
+	type Output struct {
+		Operation   string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	tempOutput := &Output{Operation: ae.Op, Description: ae.Err.Error()}
+	jsonError, _ := json.Marshal(tempOutput)
+	return string(jsonError)
+}
@@ -0,0 +1,145 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apply
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Environment : the connections, template repositories, registry secrets and projects a machine should have.
+	// A section missing from the file is left alone, even when pruning
+	Environment struct {
+		Connections     []ConnectionSpec     `yaml:"connections"`
+		TemplateRepos   []TemplateRepoSpec   `yaml:"templateRepos"`
+		RegistrySecrets []RegistrySecretSpec `yaml:"registrySecrets"`
+		Projects        []ProjectSpec        `yaml:"projects"`
+	}
+
+	// ConnectionSpec : a remote connection, identified by its label
+	ConnectionSpec struct {
+		Label         string `yaml:"label"`
+		URL           string `yaml:"url"`
+		ProjectPrefix string `yaml:"projectPrefix"`
+	}
+
+	// TemplateRepoSpec : a template repository of the local Codewind, identified by its URL
+	TemplateRepoSpec struct {
+		URL         string `yaml:"url"`
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+		Enabled     *bool  `yaml:"enabled"`
+	}
+
+	// RegistrySecretSpec : credentials for an image registry. The password is best read from
+	// an environment variable named by passwordEnv, so that the file can be shared
+	RegistrySecretSpec struct {
+		Connection  string `yaml:"connection"`
+		Address     string `yaml:"address"`
+		Username    string `yaml:"username"`
+		Password    string `yaml:"password"`
+		PasswordEnv string `yaml:"passwordEnv"`
+	}
+
+	// ProjectSpec : a project on disk to bind, identified by its name. A relative path is relative to the file
+	ProjectSpec struct {
+		Connection string `yaml:"connection"`
+		Name       string `yaml:"name"`
+		Path       string `yaml:"path"`
+		Language   string `yaml:"language"`
+		Type       string `yaml:"type"`
+	}
+)
+
+// LoadEnvironment : Reads and validates an environment file, which may be YAML or JSON
+func LoadEnvironment(filename string) (*Environment, *ApplyError) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, &ApplyError{errOpFileLoad, err, err.Error()}
+	}
+	env := Environment{}
+	err = yaml.Unmarshal(file, &env)
+	if err != nil {
+		return nil, &ApplyError{errOpFileParse, err, err.Error()}
+	}
+	baseDir, _ := filepath.Abs(filepath.Dir(filename))
+	applyErr := env.validate(baseDir)
+	if applyErr != nil {
+		return nil, applyErr
+	}
+	return &env, nil
+}
+
+// validate : checks required fields and fills in defaults
+func (env *Environment) validate(baseDir string) *ApplyError {
+	labels := map[string]bool{}
+	for i := range env.Connections {
+		spec := &env.Connections[i]
+		spec.Label = strings.TrimSpace(spec.Label)
+		spec.URL = strings.TrimSuffix(strings.TrimSpace(spec.URL), "/")
+		if spec.Label == "" || spec.URL == "" {
+			return invalidError("Each connection needs a label and a url")
+		}
+		if strings.EqualFold(spec.Label, "local") || labels[strings.ToLower(spec.Label)] {
+			return invalidError("Connection label " + spec.Label + " is reserved or declared more than once")
+		}
+		labels[strings.ToLower(spec.Label)] = true
+	}
+	for _, spec := range env.TemplateRepos {
+		if strings.TrimSpace(spec.URL) == "" {
+			return invalidError("Each template repository needs a url")
+		}
+	}
+	for i := range env.RegistrySecrets {
+		spec := &env.RegistrySecrets[i]
+		spec.Connection = connectionRef(spec.Connection)
+		if spec.PasswordEnv != "" {
+			spec.Password = os.Getenv(spec.PasswordEnv)
+		}
+		if strings.TrimSpace(spec.Address) == "" || strings.TrimSpace(spec.Username) == "" {
+			return invalidError("Each registry secret needs an address and a username")
+		}
+		if spec.Password == "" {
+			return invalidError("Registry secret " + spec.Address + " needs a password, or passwordEnv naming a variable that is set")
+		}
+	}
+	for i := range env.Projects {
+		spec := &env.Projects[i]
+		spec.Connection = connectionRef(spec.Connection)
+		if spec.Name == "" || spec.Path == "" || spec.Language == "" || spec.Type == "" {
+			return invalidError("Each project needs a name, path, language and type")
+		}
+		if !filepath.IsAbs(spec.Path) {
+			spec.Path = filepath.Join(baseDir, spec.Path)
+		}
+	}
+	return nil
+}
+
+func connectionRef(connection string) string {
+	connection = strings.TrimSpace(connection)
+	if connection == "" {
+		return "local"
+	}
+	return connection
+}
+
+func invalidError(message string) *ApplyError {
+	err := errors.New(message)
+	return &ApplyError{errOpInvalidOptions, err, err.Error()}
+}
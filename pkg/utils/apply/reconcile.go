@@ -0,0 +1,420 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package apply
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/project"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	"github.com/urfave/cli"
+)
+
+// Kinds of resource in an environment
+const (
+	KindConnection     = "connection"
+	KindTemplateRepo   = "templateRepo"
+	KindRegistrySecret = "registrySecret"
+	KindProject        = "project"
+)
+
+// What reconciling does to a resource
+const (
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionDelete    = "delete"
+	ActionUnchanged = "unchanged"
+)
+
+type (
+	// Options : how to reconcile
+	Options struct {
+		// DryRun reports the changes without making them
+		DryRun bool
+		// Prune deletes connections and template repositories that are not declared
+		Prune bool
+	}
+
+	// Change : a difference between the declared and the current state, and what was done about it
+	Change struct {
+		Kind   string `json:"kind"`
+		Name   string `json:"name"`
+		Action string `json:"action"`
+		Detail string `json:"detail,omitempty"`
+	}
+
+	reconciler struct {
+		ctx     context.Context
+		options Options
+		changes []Change
+		// newClient returns the client for requests to a connection's PFE
+		newClient func(conID string) utils.HTTPClient
+	}
+)
+
+// Reconcile : Brings the connections, template repositories, registry secrets and projects in line with the environment,
+// in that order so that later resources can use connections created earlier. It stops at the first failure, returning
+// the changes made until then. Cancelling ctx interrupts the bind of a project
+func Reconcile(ctx context.Context, env *Environment, options Options) ([]Change, *ApplyError) {
+	r := &reconciler{
+		ctx:     ctx,
+		options: options,
+		changes: []Change{},
+		newClient: func(conID string) utils.HTTPClient {
+			return &sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}
+		},
+	}
+	for _, step := range []func(*Environment) *ApplyError{
+		r.reconcileConnections,
+		r.reconcileTemplateRepos,
+		r.reconcileRegistrySecrets,
+		r.reconcileProjects,
+	} {
+		applyErr := step(env)
+		if applyErr != nil {
+			return r.changes, applyErr
+		}
+	}
+	return r.changes, nil
+}
+
+func (r *reconciler) record(kind string, name string, action string, detail string) {
+	r.changes = append(r.changes, Change{Kind: kind, Name: name, Action: action, Detail: detail})
+}
+
+func (r *reconciler) reconcileConnections(env *Environment) *ApplyError {
+	if env.Connections == nil {
+		return nil
+	}
+	existing, conErr := connections.GetAllConnections()
+	if conErr != nil {
+		return &ApplyError{errOpConnection, conErr.Err, conErr.Desc}
+	}
+	declared := map[string]bool{}
+	for _, spec := range env.Connections {
+		current := findConnection(existing, spec)
+		if current == nil {
+			r.record(KindConnection, spec.Label, ActionCreate, spec.URL)
+			if !r.options.DryRun {
+				_, conErr = connections.AddConnectionToList(http.DefaultClient, connectionContext("", spec, nil))
+			}
+		} else {
+			declared[strings.ToUpper(current.ID)] = true
+			changed := []string{}
+			if current.Label != spec.Label {
+				changed = append(changed, "label")
+			}
+			if !strings.EqualFold(current.URL, spec.URL) {
+				changed = append(changed, "url")
+			}
+			if current.ProjectPrefix != spec.ProjectPrefix {
+				changed = append(changed, "projectprefix")
+			}
+			if len(changed) == 0 {
+				r.record(KindConnection, spec.Label, ActionUnchanged, current.ID)
+				continue
+			}
+			r.record(KindConnection, spec.Label, ActionUpdate, strings.Join(changed, ", "))
+			if !r.options.DryRun {
+				_, conErr = connections.UpdateConnection(http.DefaultClient, connectionContext(current.ID, spec, changed))
+			}
+		}
+		if conErr != nil {
+			return &ApplyError{errOpConnection, conErr.Err, conErr.Desc}
+		}
+	}
+
+	if !r.options.Prune {
+		return nil
+	}
+	for _, connection := range existing {
+		if connections.IsLocal(connection.ID) || declared[strings.ToUpper(connection.ID)] {
+			continue
+		}
+		r.record(KindConnection, connection.Label, ActionDelete, connection.URL)
+		if !r.options.DryRun {
+			set := flag.NewFlagSet("apply", 0)
+			set.String("conid", connection.ID, "doc")
+			conErr = connections.RemoveConnectionFromList(cli.NewContext(nil, set, nil))
+			if conErr != nil {
+				return &ApplyError{errOpConnection, conErr.Err, conErr.Desc}
+			}
+		}
+	}
+	return nil
+}
+
+// findConnection : matches a declared connection by label, or failing that by URL
+func findConnection(existing []connections.Connection, spec ConnectionSpec) *connections.Connection {
+	for i := range existing {
+		if strings.EqualFold(existing[i].Label, spec.Label) {
+			return &existing[i]
+		}
+	}
+	for i := range existing {
+		if !connections.IsLocal(existing[i].ID) && strings.EqualFold(existing[i].URL, spec.URL) {
+			return &existing[i]
+		}
+	}
+	return nil
+}
+
+// connectionContext : the flags connections add and update would be given, only setting those that changed on update
+func connectionContext(conID string, spec ConnectionSpec, changed []string) *cli.Context {
+	set := flag.NewFlagSet("apply", 0)
+	set.String("conid", conID, "doc")
+	set.String("label", "", "doc")
+	set.String("url", "", "doc")
+	set.String("projectprefix", "", "doc")
+	values := map[string]string{"label": spec.Label, "url": spec.URL, "projectprefix": spec.ProjectPrefix}
+	args := []string{}
+	for name, value := range values {
+		if changed == nil || containsString(name, changed) {
+			args = append(args, "--"+name, value)
+		}
+	}
+	set.Parse(args)
+	return cli.NewContext(nil, set, nil)
+}
+
+func (r *reconciler) reconcileTemplateRepos(env *Environment) *ApplyError {
+	if env.TemplateRepos == nil {
+		return nil
+	}
+	client := apiroutes.NewLocalPFEClient()
+	repos, err := client.GetTemplateRepos()
+	if err != nil {
+		return &ApplyError{errOpTemplateRepo, err, err.Error()}
+	}
+	declared := map[string]bool{}
+	for _, spec := range env.TemplateRepos {
+		declared[utils.NormalizeTemplateRepoURL(spec.URL)] = true
+		var current *utils.TemplateRepo
+		for i := range repos {
+			if utils.SameTemplateRepoURL(repos[i].URL, spec.URL) {
+				current = &repos[i]
+			}
+		}
+		switch {
+		case current == nil:
+			r.record(KindTemplateRepo, spec.URL, ActionCreate, spec.Name)
+			if !r.options.DryRun {
+				err = addTemplateRepo(client, spec)
+			}
+		case spec.Enabled != nil && *spec.Enabled != current.Enabled:
+			if *spec.Enabled {
+				r.record(KindTemplateRepo, spec.URL, ActionUpdate, "enable")
+			} else {
+				r.record(KindTemplateRepo, spec.URL, ActionUpdate, "disable")
+			}
+			if !r.options.DryRun {
+				err = enableTemplateRepo(client, current.URL, *spec.Enabled)
+			}
+		default:
+			r.record(KindTemplateRepo, spec.URL, ActionUnchanged, current.Name)
+		}
+		if err != nil {
+			return &ApplyError{errOpTemplateRepo, err, err.Error()}
+		}
+	}
+
+	if !r.options.Prune {
+		return nil
+	}
+	for _, repo := range repos {
+		if repo.Protected || declared[utils.NormalizeTemplateRepoURL(repo.URL)] {
+			continue
+		}
+		r.record(KindTemplateRepo, repo.URL, ActionDelete, repo.Name)
+		if !r.options.DryRun {
+			extensions, err := client.ListExtensions()
+			if err == nil {
+				utils.OnDeleteTemplateRepo(extensions, repo.URL, repos)
+			}
+			_, err = client.DeleteTemplateRepo(repo.URL)
+			if err != nil {
+				return &ApplyError{errOpTemplateRepo, err, err.Error()}
+			}
+		}
+	}
+	return nil
+}
+
+func addTemplateRepo(client *apiroutes.PFEClient, spec TemplateRepoSpec) error {
+	repos, err := client.AddTemplateRepo(spec.URL, spec.Description, spec.Name)
+	if err != nil {
+		return err
+	}
+	extensions, err := client.ListExtensions()
+	if err == nil {
+		utils.OnAddTemplateRepo(extensions, spec.URL, repos)
+	}
+	if spec.Enabled != nil && !*spec.Enabled {
+		return enableTemplateRepo(client, spec.URL, false)
+	}
+	return nil
+}
+
+func enableTemplateRepo(client *apiroutes.PFEClient, url string, enabled bool) error {
+	var err error
+	if enabled {
+		_, err = client.EnableTemplateRepos([]string{url})
+	} else {
+		_, err = client.DisableTemplateRepos([]string{url})
+	}
+	return err
+}
+
+func (r *reconciler) reconcileRegistrySecrets(env *Environment) *ApplyError {
+	for _, conRef := range uniqueConnectionRefs(len(env.RegistrySecrets), func(i int) string { return env.RegistrySecrets[i].Connection }) {
+		conID, applyErr := r.resolveConnection(conRef)
+		if applyErr != nil {
+			return applyErr
+		}
+		current := []apiroutes.RegistrySecret{}
+		if conID != "" {
+			var secErr *security.SecError
+			current, secErr = security.SecRegistrySecretList(r.newClient(conID), conID)
+			if secErr != nil {
+				return &ApplyError{errOpRegistrySecret, secErr.Err, secErr.Desc}
+			}
+		}
+		for _, spec := range env.RegistrySecrets {
+			if spec.Connection != conRef {
+				continue
+			}
+			name := conRef + "/" + spec.Address
+			action := ActionCreate
+			for _, registrySecret := range current {
+				if registrySecret.Address != spec.Address {
+					continue
+				}
+				action = ActionUpdate
+				saved, secErr := security.SecRegistrySecretGet(conID, spec.Address)
+				if registrySecret.Username == spec.Username && secErr == nil && saved.Username == spec.Username && saved.Password == spec.Password {
+					action = ActionUnchanged
+				}
+			}
+			r.record(KindRegistrySecret, name, action, spec.Username)
+			if r.options.DryRun || action == ActionUnchanged {
+				continue
+			}
+			client := r.newClient(conID)
+			if action == ActionUpdate {
+				_, secErr := security.SecRegistrySecretRemove(client, conID, spec.Address)
+				if secErr != nil {
+					return &ApplyError{errOpRegistrySecret, secErr.Err, secErr.Desc}
+				}
+			}
+			_, secErr := security.SecRegistrySecretAdd(client, conID, spec.Address, spec.Username, spec.Password)
+			if secErr != nil {
+				return &ApplyError{errOpRegistrySecret, secErr.Err, secErr.Desc}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) reconcileProjects(env *Environment) *ApplyError {
+	for _, conRef := range uniqueConnectionRefs(len(env.Projects), func(i int) string { return env.Projects[i].Connection }) {
+		conID, applyErr := r.resolveConnection(conRef)
+		if applyErr != nil {
+			return applyErr
+		}
+		current := []apiroutes.Project{}
+		prefix := ""
+		if conID != "" {
+			connection, conErr := connections.GetConnectionByID(conID)
+			if conErr != nil {
+				return &ApplyError{errOpProject, conErr.Err, conErr.Desc}
+			}
+			prefix = connection.ProjectPrefix
+			var projErr *project.ProjectError
+			current, projErr = project.ListProjects(r.newClient(conID), conID, true)
+			if projErr != nil {
+				return &ApplyError{errOpProject, projErr.Err, projErr.Desc}
+			}
+		}
+		for _, spec := range env.Projects {
+			if spec.Connection != conRef {
+				continue
+			}
+			name := conRef + "/" + spec.Name
+			action := ActionCreate
+			for _, p := range current {
+				if p.Name == project.ApplyProjectPrefix(spec.Name, prefix) {
+					action = ActionUnchanged
+				}
+			}
+			r.record(KindProject, name, action, spec.Path)
+			if r.options.DryRun || action == ActionUnchanged {
+				continue
+			}
+			_, projErr := project.Bind(r.ctx, spec.Path, spec.Name, spec.Language, spec.Type, conID)
+			if projErr != nil {
+				return &ApplyError{errOpProject, projErr.Err, projErr.Desc}
+			}
+		}
+	}
+	return nil
+}
+
+// resolveConnection : finds the ID of the connection with the given ID or label. On a dry run, a connection that
+// is declared but not yet created resolves to an empty ID
+func (r *reconciler) resolveConnection(conRef string) (string, *ApplyError) {
+	existing, conErr := connections.GetAllConnections()
+	if conErr != nil {
+		return "", &ApplyError{errOpConnection, conErr.Err, conErr.Desc}
+	}
+	for _, connection := range existing {
+		if strings.EqualFold(connection.ID, conRef) || strings.EqualFold(connection.Label, conRef) {
+			return connection.ID, nil
+		}
+	}
+	if r.options.DryRun {
+		for _, change := range r.changes {
+			if change.Kind == KindConnection && change.Action == ActionCreate && strings.EqualFold(change.Name, conRef) {
+				return "", nil
+			}
+		}
+	}
+	err := errors.New("Connection " + conRef + " not found")
+	return "", &ApplyError{errOpConnection, err, err.Error()}
+}
+
+// uniqueConnectionRefs : the connections referenced by a section, in the order they first appear
+func uniqueConnectionRefs(count int, ref func(i int) string) []string {
+	refs := []string{}
+	for i := 0; i < count; i++ {
+		if !containsString(ref(i), refs) {
+			refs = append(refs, ref(i))
+		}
+	}
+	return refs
+}
+
+func containsString(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
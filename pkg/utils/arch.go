@@ -0,0 +1,144 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/docker/docker/client"
+)
+
+// KnownArchitectures are the architectures an image name can end with, as Go and docker name them
+var KnownArchitectures = []string{"amd64", "arm64", "ppc64le", "s390x"}
+
+// PublishedArchitectures are the architectures the Codewind project publishes its images for on Docker Hub
+var PublishedArchitectures = []string{"amd64", "ppc64le", "s390x"}
+
+// archSuffixPattern matches the architecture at the end of an architecture specific image name
+var archSuffixPattern = regexp.MustCompile(`-(amd64|arm64|ppc64le|s390x)$`)
+
+// NormalizeArchitecture : The name Go and docker give an architecture that uname or the Docker daemon can report
+// under another name, e.g. arm64 for aarch64
+func NormalizeArchitecture(arch string) string {
+	arch = strings.ToLower(strings.TrimSpace(arch))
+	switch arch {
+	case "x86_64", "x86-64":
+		return "amd64"
+	case "aarch64", "armv8", "arm64/v8":
+		return "arm64"
+	}
+	return arch
+}
+
+// IsKnownArchitecture : true when images can be selected for the architecture
+func IsKnownArchitecture(arch string) bool {
+	return containsArchitecture(KnownArchitectures, arch)
+}
+
+// HostArchitecture : The architecture the Docker daemon runs containers on, or that of cwctl when the daemon
+// cannot be reached. They differ when cwctl runs under emulation, such as an amd64 build on Apple Silicon
+func HostArchitecture() string {
+	cli, err := client.NewEnvClient()
+	if err == nil {
+		info, err := cli.Info(context.Background())
+		if err == nil && info.Architecture != "" {
+			return NormalizeArchitecture(info.Architecture)
+		}
+	}
+	return runtime.GOARCH
+}
+
+// CheckArchitecture : An error when the images are the architecture specific images of Docker Hub and the
+// Codewind project does not publish them for the architecture, listing the architectures it does
+func (images ImageConfig) CheckArchitecture() error {
+	if images.Architecture == "" || containsArchitecture(PublishedArchitectures, images.Architecture) {
+		return nil
+	}
+	defaults := DefaultImageConfig()
+	if images.Registry != defaults.Registry || images.Org != defaults.Org {
+		return nil
+	}
+	for _, name := range []string{images.PFEImage, images.PerformanceImage} {
+		if archSuffixPattern.MatchString(name) {
+			return unsupportedArchitectureError(images.Architecture)
+		}
+	}
+	return nil
+}
+
+// VerifyImageArchitectures : An error when a local image start would run is built for another architecture than
+// the images are for, which would otherwise fail to start with an exec format error. Missing images are left for
+// docker-compose to report
+func VerifyImageArchitectures(images ImageConfig) error {
+	if images.Architecture == "" {
+		return nil
+	}
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	for _, reference := range []string{images.PFERunReference(), images.PerformanceRunReference()} {
+		inspect, _, err := cli.ImageInspectWithRaw(context.Background(), reference)
+		if err != nil {
+			continue
+		}
+		if err := checkImageArchitecture(reference, inspect.Architecture, images.Architecture); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkImageArchitecture : An error when the architecture of an image is not the one wanted
+func checkImageArchitecture(reference string, imageArch string, arch string) error {
+	imageArch = NormalizeArchitecture(imageArch)
+	if imageArch == "" || imageArch == arch {
+		return nil
+	}
+	return fmt.Errorf("The image %v is built for %v, but Docker runs %v containers. Run install again to pull the %v images, or give --arch %v to run them under emulation", reference, imageArch, arch, arch, imageArch)
+}
+
+// unsupportedArchitectureError : The error for an architecture the Codewind images are not published for
+func unsupportedArchitectureError(arch string) error {
+	return fmt.Errorf("The Codewind images are not published for %v, the supported architectures are %v. Give --registry, --org, --pfe-image and --performance-image for images built for %v, or --arch amd64 to run the amd64 images under emulation", arch, strings.Join(PublishedArchitectures, ", "), arch)
+}
+
+func containsArchitecture(architectures []string, arch string) bool {
+	for _, known := range architectures {
+		if known == arch {
+			return true
+		}
+	}
+	return false
+}
+
+// archImageName : The name of the image for the architecture, replacing the architecture an architecture specific
+// name ends with. Other names are kept, as a multi-arch image lets docker select the platform itself
+func archImageName(name string, arch string) string {
+	if arch == "" || !archSuffixPattern.MatchString(name) {
+		return name
+	}
+	return archSuffixPattern.ReplaceAllString(name, "-"+arch)
+}
+
+// describePullError : The error of pulling an image, explaining a multi-arch image without an image for the
+// architecture Docker runs containers on
+func describePullError(reference string, err error) error {
+	if err != nil && strings.Contains(err.Error(), "no matching manifest") {
+		return fmt.Errorf("The image %v is not published for %v, the architecture Docker runs containers on: %v", reference, HostArchitecture(), err)
+	}
+	return err
+}
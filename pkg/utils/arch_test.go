@@ -0,0 +1,73 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeArchitecture(t *testing.T) {
+	assert.Equal(t, "amd64", NormalizeArchitecture("x86_64"))
+	assert.Equal(t, "arm64", NormalizeArchitecture("aarch64"))
+	assert.Equal(t, "arm64", NormalizeArchitecture(" ARM64 "))
+	assert.Equal(t, "ppc64le", NormalizeArchitecture("ppc64le"))
+	assert.True(t, IsKnownArchitecture("s390x"))
+	assert.False(t, IsKnownArchitecture("mips"))
+}
+
+func TestArchImageNames(t *testing.T) {
+	t.Run("Replaces the architecture of architecture specific names", func(t *testing.T) {
+		images := DefaultImageConfig()
+		images.Architecture = "ppc64le"
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe-ppc64le", images.PFERepository())
+		assert.Equal(t, "docker.io/eclipse/codewind-performance-ppc64le:latest", images.PerformancePullReference())
+		assert.Equal(t, LocalPFEImage+":latest", images.LocalPFEImageName())
+	})
+
+	t.Run("Keeps multi-arch names", func(t *testing.T) {
+		images := DefaultImageConfig().Merge(ImageConfig{PFEImage: "codewind-pfe", Architecture: "arm64"})
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe", images.PFERepository())
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe-amd64", DefaultImageConfig().PFERepository())
+	})
+}
+
+func TestCheckArchitecture(t *testing.T) {
+	images := DefaultImageConfig()
+	for _, arch := range []string{"", "amd64", "ppc64le", "s390x"} {
+		images.Architecture = arch
+		assert.Nil(t, images.CheckArchitecture(), arch)
+	}
+
+	images.Architecture = "arm64"
+	err := images.CheckArchitecture()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "not published for arm64")
+		assert.Contains(t, err.Error(), "amd64, ppc64le, s390x")
+	}
+
+	t.Run("Leaves other images to the registry", func(t *testing.T) {
+		assert.Nil(t, images.Merge(ImageConfig{Registry: "registry.example.com"}).CheckArchitecture())
+		assert.Nil(t, images.Merge(ImageConfig{PFEImage: "codewind-pfe", PerformanceImage: "codewind-performance"}).CheckArchitecture())
+	})
+}
+
+func TestCheckImageArchitecture(t *testing.T) {
+	assert.Nil(t, checkImageArchitecture("codewind-pfe-amd64:latest", "amd64", "amd64"))
+	assert.Nil(t, checkImageArchitecture("codewind-pfe-amd64:latest", "aarch64", "arm64"))
+	assert.Nil(t, checkImageArchitecture("codewind-pfe-amd64:latest", "", "arm64"))
+	err := checkImageArchitecture("codewind-pfe-amd64:latest", "amd64", "arm64")
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "built for amd64, but Docker runs arm64 containers")
+	}
+}
@@ -0,0 +1,285 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package cliconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+)
+
+// CLIConfig : Persisted cwctl defaults
+type CLIConfig struct {
+	SchemaVersion          int    `json:"schemaVersion,omitempty"`
+	LogLevel               string `json:"loglevel,omitempty"`
+	ImageRegistry          string `json:"imageRegistry,omitempty"`
+	ImageOrg               string `json:"imageOrg,omitempty"`
+	PFEImage               string `json:"pfeImage,omitempty"`
+	PerformanceImage       string `json:"performanceImage,omitempty"`
+	ImageTag               string `json:"imageTag,omitempty"`
+	PFEHost                string `json:"pfeHost,omitempty"`
+	PFERoute               string `json:"pfeRoute,omitempty"`
+	ReleaseManifest        string `json:"releaseManifest,omitempty"`
+	ManifestPublicKey      string `json:"manifestPublicKey,omitempty"`
+	SyncMaxFileSize        string `json:"syncMaxFileSize,omitempty"`
+	PFEPort                string `json:"pfePort,omitempty"`
+	PerformancePort        string `json:"performancePort,omitempty"`
+	HostInterface          string `json:"hostInterface,omitempty"`
+	HTTPProxy              string `json:"httpProxy,omitempty"`
+	HTTPSProxy             string `json:"httpsProxy,omitempty"`
+	NoProxy                string `json:"noProxy,omitempty"`
+	DefaultConnection      string `json:"defaultConnection,omitempty"`
+	SyncConcurrency        string `json:"syncConcurrency,omitempty"`
+	PFEImageDigest         string `json:"pfeImageDigest,omitempty"`
+	PerformanceImageDigest string `json:"performanceImageDigest,omitempty"`
+	Telemetry              string `json:"telemetry,omitempty"`
+}
+
+// Schema : The schema versions of the cwctl config file, Steps[i] migrates it from version i. A step must be
+// appended when a key is renamed or its values change
+var Schema = migrate.Schema{
+	Name:         "config",
+	VersionField: "schemaVersion",
+	Steps: []migrate.Step{
+		{Description: "Record the schema version", Migrate: func(document map[string]interface{}) error { return nil }},
+		{Description: "Record the imageTag the image digests belong to", Migrate: pinDigestTag},
+	},
+}
+
+// pinDigestTag : Saves the default imageTag in a config with image digests but no tag, as upgrade deployment
+// replaces the digests when it saves another tag, and the digests were saved for the default tag
+func pinDigestTag(document map[string]interface{}) error {
+	if _, ok := document["imageTag"]; ok {
+		return nil
+	}
+	for _, key := range []string{"pfeImageDigest", "performanceImageDigest"} {
+		if digest, _ := document[key].(string); digest != "" {
+			document["imageTag"] = "latest"
+			return nil
+		}
+	}
+	return nil
+}
+
+// configFields maps the keys accepted by `cwctl config` to the fields they set
+var configFields = map[string]func(*CLIConfig) *string{
+	"loglevel":               func(cliConfig *CLIConfig) *string { return &cliConfig.LogLevel },
+	"imageRegistry":          func(cliConfig *CLIConfig) *string { return &cliConfig.ImageRegistry },
+	"imageOrg":               func(cliConfig *CLIConfig) *string { return &cliConfig.ImageOrg },
+	"pfeImage":               func(cliConfig *CLIConfig) *string { return &cliConfig.PFEImage },
+	"performanceImage":       func(cliConfig *CLIConfig) *string { return &cliConfig.PerformanceImage },
+	"imageTag":               func(cliConfig *CLIConfig) *string { return &cliConfig.ImageTag },
+	"pfeHost":                func(cliConfig *CLIConfig) *string { return &cliConfig.PFEHost },
+	"pfeRoute":               func(cliConfig *CLIConfig) *string { return &cliConfig.PFERoute },
+	"releaseManifest":        func(cliConfig *CLIConfig) *string { return &cliConfig.ReleaseManifest },
+	"manifestPublicKey":      func(cliConfig *CLIConfig) *string { return &cliConfig.ManifestPublicKey },
+	"syncMaxFileSize":        func(cliConfig *CLIConfig) *string { return &cliConfig.SyncMaxFileSize },
+	"pfePort":                func(cliConfig *CLIConfig) *string { return &cliConfig.PFEPort },
+	"performancePort":        func(cliConfig *CLIConfig) *string { return &cliConfig.PerformancePort },
+	"hostInterface":          func(cliConfig *CLIConfig) *string { return &cliConfig.HostInterface },
+	"httpProxy":              func(cliConfig *CLIConfig) *string { return &cliConfig.HTTPProxy },
+	"httpsProxy":             func(cliConfig *CLIConfig) *string { return &cliConfig.HTTPSProxy },
+	"noProxy":                func(cliConfig *CLIConfig) *string { return &cliConfig.NoProxy },
+	"defaultConnection":      func(cliConfig *CLIConfig) *string { return &cliConfig.DefaultConnection },
+	"syncConcurrency":        func(cliConfig *CLIConfig) *string { return &cliConfig.SyncConcurrency },
+	"pfeImageDigest":         func(cliConfig *CLIConfig) *string { return &cliConfig.PFEImageDigest },
+	"performanceImageDigest": func(cliConfig *CLIConfig) *string { return &cliConfig.PerformanceImageDigest },
+	"telemetry":              func(cliConfig *CLIConfig) *string { return &cliConfig.Telemetry },
+}
+
+// configEnvVars maps config keys to the environment variables which override them. The proxy keys are
+// overridden by the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables, which are read where they are
+// applied. Ports are not overridden, as the ports a start publishes on are saved for the next start
+var configEnvVars = map[string]string{
+	"loglevel":               "CW_LOGLEVEL",
+	"imageRegistry":          "CW_IMAGE_REGISTRY",
+	"imageOrg":               "CW_IMAGE_ORG",
+	"pfeImage":               "CW_PFE_IMAGE",
+	"performanceImage":       "CW_PERFORMANCE_IMAGE",
+	"imageTag":               "CW_IMAGE_TAG",
+	"pfeHost":                "CW_PFE_HOST",
+	"pfeRoute":               "CW_PFE_ROUTE",
+	"syncMaxFileSize":        "CW_SYNC_MAX_FILE_SIZE",
+	"defaultConnection":      "CW_CONNECTION",
+	"syncConcurrency":        "CW_SYNC_CONCURRENCY",
+	"pfeImageDigest":         "CW_PFE_IMAGE_DIGEST",
+	"performanceImageDigest": "CW_PERFORMANCE_IMAGE_DIGEST",
+	"telemetry":              "CW_TELEMETRY",
+}
+
+// Keys : The config keys which can be read and set, in alphabetical order
+func Keys() []string {
+	keys := []string{}
+	for key := range configFields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get : Returns the value of a config key, or an empty string if it has not been set
+func (cliConfig *CLIConfig) Get(key string) (string, *ConfigError) {
+	field, ok := configFields[key]
+	if !ok {
+		return "", unknownKeyError(key)
+	}
+	return *field(cliConfig), nil
+}
+
+// Set : Sets the value of a config key, an empty value restores the default
+func (cliConfig *CLIConfig) Set(key string, value string) *ConfigError {
+	field, ok := configFields[key]
+	if !ok {
+		return unknownKeyError(key)
+	}
+	*field(cliConfig) = strings.TrimSpace(value)
+	return nil
+}
+
+// EnvVar : The environment variable which overrides a config key, empty when there is none
+func EnvVar(key string) string {
+	return configEnvVars[key]
+}
+
+// Values : The value of every config key, empty for keys which have not been set
+func (cliConfig *CLIConfig) Values() map[string]string {
+	values := map[string]string{}
+	for key, field := range configFields {
+		values[key] = *field(cliConfig)
+	}
+	return values
+}
+
+// ApplyEnv : Overrides config keys with the environment variables set for them, so that the environment takes
+// precedence over the config file and flags take precedence over both
+func (cliConfig *CLIConfig) ApplyEnv() {
+	for key, envVar := range configEnvVars {
+		if value := strings.TrimSpace(os.Getenv(envVar)); value != "" {
+			*configFields[key](cliConfig) = value
+		}
+	}
+}
+
+func unknownKeyError(key string) *ConfigError {
+	err := errors.New("Unknown config key '" + key + "', must be one of: " + strings.Join(Keys(), ", "))
+	return &ConfigError{errOpUnknownKey, err, err.Error()}
+}
+
+// LoadConfig : Load the cwctl config file from disk, returning an empty config if none has been saved
+func LoadConfig() (*CLIConfig, *ConfigError) {
+	data := CLIConfig{}
+	file, err := ioutil.ReadFile(GetConfigFilename())
+	if os.IsNotExist(err) {
+		return &data, nil
+	}
+	if err != nil {
+		return nil, &ConfigError{errOpFileLoad, err, err.Error()}
+	}
+	// Files written by older versions of cwctl are read at the current schema, and saved at it by the next change
+	file, migErr := migrate.Upgrade(Schema, file)
+	if migErr != nil {
+		return nil, &ConfigError{errOpFileParse, migErr.Err, migErr.Desc}
+	}
+	err = json.Unmarshal(file, &data)
+	if err != nil {
+		return nil, &ConfigError{errOpFileParse, err, err.Error()}
+	}
+	return &data, nil
+}
+
+// LoadEffectiveConfig : Load the cwctl config file, overridden by the environment. Use LoadConfig instead
+// when the config is to be saved again, so that environment variables are not written to the file
+func LoadEffectiveConfig() (*CLIConfig, *ConfigError) {
+	cliConfig, configErr := LoadConfig()
+	if configErr != nil {
+		return nil, configErr
+	}
+	cliConfig.ApplyEnv()
+	return cliConfig, nil
+}
+
+// SaveConfig : Write the cwctl config file to disk
+func SaveConfig(cliConfig *CLIConfig) *ConfigError {
+	cliConfig.SchemaVersion = Schema.Version()
+	body, err := json.MarshalIndent(cliConfig, "", "\t")
+	if err != nil {
+		return &ConfigError{errOpFileParse, err, err.Error()}
+	}
+	err = os.MkdirAll(getConfigDir(), 0777)
+	if err != nil {
+		return &ConfigError{errOpFileWrite, err, err.Error()}
+	}
+	err = ioutil.WriteFile(GetConfigFilename(), body, 0644)
+	if err != nil {
+		return &ConfigError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+// getConfigDir : get directory path to the cwctl config file
+func getConfigDir() string {
+	val, isSet := os.LookupEnv("CHE_API_EXTERNAL")
+	homeDir := ""
+	if isSet && (val != "") {
+		val, isSet := os.LookupEnv("CHE_PROJECTS_ROOT")
+		if isSet && (val != "") {
+			homeDir = val
+		} else {
+			// Cannot set projects root without env variable, suggests issue with Codewind Che installation
+			panic("CHE_PROJECTS_ROOT not set")
+		}
+	} else {
+		const GOOS string = runtime.GOOS
+		if GOOS == "windows" {
+			homeDir = os.Getenv("USERPROFILE")
+		} else {
+			homeDir = os.Getenv("HOME")
+		}
+	}
+	return path.Join(homeDir, ".codewind")
+}
+
+// GetConfigFilename : get full file path of the cwctl config file
+func GetConfigFilename() string {
+	return path.Join(getConfigDir(), "cwctl.json")
+}
+
+// GetStateDir : get the directory cwctl keeps the files it generates in, such as docker-compose files
+func GetStateDir() string {
+	return path.Join(getConfigDir(), "state")
+}
+
+// GetSecretsFilename : get full file path of the encrypted secrets file used when no keyring is available
+func GetSecretsFilename() string {
+	return path.Join(getConfigDir(), "cwctl-secrets.json")
+}
+
+// GetKeyringIndexFilename : get full file path of the index of the connection secrets kept in the keyring, which
+// holds no secrets itself
+func GetKeyringIndexFilename() string {
+	return path.Join(getConfigDir(), "cwctl-keyring.json")
+}
+
+// GetCredentialsFilename : get full file path of the credentials file, which CW_CREDENTIALS_FILE overrides
+func GetCredentialsFilename() string {
+	if filename := strings.TrimSpace(os.Getenv("CW_CREDENTIALS_FILE")); filename != "" {
+		return filename
+	}
+	return path.Join(getConfigDir(), "credentials.json")
+}
@@ -0,0 +1,84 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package cliconfig
+
+import (
+	"os"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SetAndGet(t *testing.T) {
+	cliConfig := CLIConfig{}
+
+	t.Run("Sets a known key", func(t *testing.T) {
+		configErr := cliConfig.Set("imageRegistry", " mirror.example.com ")
+		assert.Nil(t, configErr)
+		assert.Equal(t, "mirror.example.com", cliConfig.ImageRegistry)
+		value, configErr := cliConfig.Get("imageRegistry")
+		assert.Nil(t, configErr)
+		assert.Equal(t, "mirror.example.com", value)
+	})
+
+	t.Run("An empty value clears the key", func(t *testing.T) {
+		configErr := cliConfig.Set("imageRegistry", "")
+		assert.Nil(t, configErr)
+		assert.Equal(t, "", cliConfig.ImageRegistry)
+	})
+
+	t.Run("Rejects an unknown key", func(t *testing.T) {
+		configErr := cliConfig.Set("registry", "mirror.example.com")
+		assert.Equal(t, errOpUnknownKey, configErr.Op)
+		_, configErr = cliConfig.Get("registry")
+		assert.Equal(t, errOpUnknownKey, configErr.Op)
+	})
+}
+
+func Test_ApplyEnv(t *testing.T) {
+	t.Run("The environment overrides saved keys", func(t *testing.T) {
+		cliConfig := CLIConfig{ImageTag: "0.6.0", ImageOrg: "eclipse"}
+		os.Setenv("CW_IMAGE_TAG", "0.7.0")
+		defer os.Unsetenv("CW_IMAGE_TAG")
+		cliConfig.ApplyEnv()
+		assert.Equal(t, "0.7.0", cliConfig.ImageTag)
+		assert.Equal(t, "eclipse", cliConfig.ImageOrg)
+	})
+
+	t.Run("Lists every key", func(t *testing.T) {
+		cliConfig := CLIConfig{DefaultConnection: "K3NVHTN4"}
+		values := cliConfig.Values()
+		assert.Len(t, values, len(Keys()))
+		assert.Equal(t, "K3NVHTN4", values["defaultConnection"])
+		assert.Equal(t, "", values["syncConcurrency"])
+	})
+
+	t.Run("Ports have no environment variable", func(t *testing.T) {
+		assert.Equal(t, "", EnvVar("pfePort"))
+		assert.Equal(t, "CW_CONNECTION", EnvVar("defaultConnection"))
+	})
+}
+
+func Test_Schema(t *testing.T) {
+	t.Run("Digests saved without a tag are pinned to the default tag", func(t *testing.T) {
+		body, migErr := migrate.Upgrade(Schema, []byte(`{"schemaVersion": 1, "pfeImageDigest": "sha256:aaa"}`))
+		assert.Nil(t, migErr)
+		assert.JSONEq(t, `{"schemaVersion": 2, "pfeImageDigest": "sha256:aaa", "imageTag": "latest"}`, string(body))
+	})
+
+	t.Run("A saved tag is kept", func(t *testing.T) {
+		body, migErr := migrate.Upgrade(Schema, []byte(`{"pfeImageDigest": "sha256:aaa", "imageTag": "0.7.0"}`))
+		assert.Nil(t, migErr)
+		assert.JSONEq(t, `{"schemaVersion": 2, "pfeImageDigest": "sha256:aaa", "imageTag": "0.7.0"}`, string(body))
+	})
+}
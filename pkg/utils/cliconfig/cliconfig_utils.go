@@ -0,0 +1,42 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package cliconfig
+
+import (
+	"encoding/json"
+)
+
+// ConfigError : cwctl config package errors
+type ConfigError struct {
+	Op   string
+	Err  error
+	Desc string
+}
+
+const (
+	errOpFileParse  = "config_parse"
+	errOpFileLoad   = "config_load"
+	errOpFileWrite  = "config_write"
+	errOpUnknownKey = "config_unknown_key"
+)
+
+// ConfigError : Error formatted in JSON containing an errorOp and a description from
+// either a fault condition in the CLI, or an error payload from a REST request
+func (ce *ConfigError) Error() string {
+	type Output struct {
+		Operation   string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	tempOutput := &Output{Operation: ce.Op, Description: ce.Err.Error()}
+	jsonError, _ := json.Marshal(tempOutput)
+	return string(jsonError)
+}
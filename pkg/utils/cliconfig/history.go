@@ -0,0 +1,68 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package cliconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// CommandHistory : The arguments of the last successful run of each command, used by `cwctl redo`
+type CommandHistory struct {
+	Last     string              `json:"last"`
+	Commands map[string][]string `json:"commands"`
+}
+
+// LoadHistory : Load the command history from disk, returning an empty history if none has been saved
+func LoadHistory() (*CommandHistory, *ConfigError) {
+	history := CommandHistory{Commands: map[string][]string{}}
+	file, err := ioutil.ReadFile(GetHistoryFilename())
+	if os.IsNotExist(err) {
+		return &history, nil
+	}
+	if err != nil {
+		return nil, &ConfigError{errOpFileLoad, err, err.Error()}
+	}
+	err = json.Unmarshal(file, &history)
+	if err != nil {
+		return nil, &ConfigError{errOpFileParse, err, err.Error()}
+	}
+	if history.Commands == nil {
+		history.Commands = map[string][]string{}
+	}
+	return &history, nil
+}
+
+// SaveHistory : Write the command history to disk
+func SaveHistory(history *CommandHistory) *ConfigError {
+	body, err := json.MarshalIndent(history, "", "\t")
+	if err != nil {
+		return &ConfigError{errOpFileParse, err, err.Error()}
+	}
+	err = os.MkdirAll(getConfigDir(), 0777)
+	if err != nil {
+		return &ConfigError{errOpFileWrite, err, err.Error()}
+	}
+	// The history holds project paths and URLs, so keep it private to the user
+	err = ioutil.WriteFile(GetHistoryFilename(), body, 0600)
+	if err != nil {
+		return &ConfigError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+// GetHistoryFilename : get full file path of the command history file
+func GetHistoryFilename() string {
+	return path.Join(getConfigDir(), "cwctl-history.json")
+}
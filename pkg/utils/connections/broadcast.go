@@ -0,0 +1,63 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import "strings"
+
+// AllConnections : the --conid which runs a command on every connection
+const AllConnections = "all"
+
+// IsAllConnections : true when conID asks for a command to run on every connection
+func IsAllConnections(conID string) bool {
+	return strings.EqualFold(strings.TrimSpace(conID), AllConnections)
+}
+
+// BroadcastResult : the outcome of running a command on one connection, which either has a result or an error
+type BroadcastResult struct {
+	ConnectionID string      `json:"connectionID"`
+	Label        string      `json:"label"`
+	Result       interface{} `json:"result,omitempty"`
+	Error        string      `json:"error,omitempty"`
+}
+
+// BroadcastResponse : the results of running a command on every connection, in the order the connections were added
+type BroadcastResponse struct {
+	Connections []BroadcastResult `json:"connections"`
+}
+
+// Failed : how many of the connections the command failed on
+func (response BroadcastResponse) Failed() int {
+	failed := 0
+	for _, result := range response.Connections {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// Broadcast : Runs a command on each connection in turn, as the commands of local connections select the active
+// local profile. A connection the command fails on does not stop it running on the others
+func Broadcast(connections []Connection, command func(connection Connection) (interface{}, error)) BroadcastResponse {
+	response := BroadcastResponse{Connections: []BroadcastResult{}}
+	for _, connection := range connections {
+		result := BroadcastResult{ConnectionID: connection.ID, Label: connection.Label}
+		value, err := command(connection)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Result = value
+		}
+		response.Connections = append(response.Connections, result)
+	}
+	return response
+}
@@ -0,0 +1,66 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsAllConnections(t *testing.T) {
+	assert.True(t, IsAllConnections("all"))
+	assert.True(t, IsAllConnections(" ALL "))
+	assert.False(t, IsAllConnections("local"))
+	assert.False(t, IsAllConnections(""))
+}
+
+func TestBroadcast(t *testing.T) {
+	connections := []Connection{
+		{ID: "local", Label: "Codewind local connection"},
+		{ID: "remote1", Label: "staging"},
+		{ID: "remote2", Label: "production"},
+	}
+	response := Broadcast(connections, func(connection Connection) (interface{}, error) {
+		if connection.ID == "remote1" {
+			return nil, errors.New("unreachable")
+		}
+		return []string{connection.ID + "-project"}, nil
+	})
+
+	t.Run("Asserts every connection has a result or an error in order", func(t *testing.T) {
+		assert.Equal(t, []BroadcastResult{
+			{ConnectionID: "local", Label: "Codewind local connection", Result: []string{"local-project"}},
+			{ConnectionID: "remote1", Label: "staging", Error: "unreachable"},
+			{ConnectionID: "remote2", Label: "production", Result: []string{"remote2-project"}},
+		}, response.Connections)
+		assert.Equal(t, 1, response.Failed())
+	})
+
+	t.Run("Asserts the results form one JSON document", func(t *testing.T) {
+		document, err := json.Marshal(response)
+		assert.Nil(t, err)
+		assert.JSONEq(t, `{"connections": [
+			{"connectionID": "local", "label": "Codewind local connection", "result": ["local-project"]},
+			{"connectionID": "remote1", "label": "staging", "error": "unreachable"},
+			{"connectionID": "remote2", "label": "production", "result": ["remote2-project"]}
+		]}`, string(document))
+	})
+
+	t.Run("Asserts there are no results without connections", func(t *testing.T) {
+		empty := Broadcast(nil, nil)
+		assert.Empty(t, empty.Connections)
+		assert.Equal(t, 0, empty.Failed())
+	})
+}
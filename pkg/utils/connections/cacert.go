@@ -0,0 +1,45 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+)
+
+// LoadCACert : Reads the PEM encoded CA certificates at path, which are kept with a connection so that its
+// ingresses can be verified when they are signed by a private CA
+func LoadCACert(path string) (string, *ConError) {
+	pem, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", &ConError{errOpInvalidOptions, err, "Unable to read the CA certificate: " + err.Error()}
+	}
+	_, err = caCertPool(string(pem))
+	if err != nil {
+		return "", &ConError{errOpInvalidOptions, err, err.Error()}
+	}
+	return string(pem), nil
+}
+
+// caCertPool : the system's CAs together with the PEM encoded CA certificates given
+func caCertPool(pem string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		// The system pool cannot be read on Windows before Go 1.18
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(pem)) {
+		return nil, errors.New("No PEM encoded certificate was found in the CA certificate")
+	}
+	return pool, nil
+}
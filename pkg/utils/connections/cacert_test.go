@@ -0,0 +1,52 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LoadCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	dir, _ := ioutil.TempDir("", "cacert")
+	defer os.RemoveAll(dir)
+
+	t.Run("Reads a PEM encoded certificate", func(t *testing.T) {
+		path := filepath.Join(dir, "ca.pem")
+		ioutil.WriteFile(path, caCert, 0644)
+		loaded, conErr := LoadCACert(path)
+		assert.Nil(t, conErr)
+		assert.Equal(t, string(caCert), loaded)
+	})
+
+	t.Run("Rejects a file without a certificate", func(t *testing.T) {
+		path := filepath.Join(dir, "ca.txt")
+		ioutil.WriteFile(path, []byte("not a certificate"), 0644)
+		_, conErr := LoadCACert(path)
+		assert.Equal(t, errOpInvalidOptions, conErr.Op)
+	})
+
+	t.Run("Rejects a missing file", func(t *testing.T) {
+		_, conErr := LoadCACert(filepath.Join(dir, "missing.pem"))
+		assert.Equal(t, errOpInvalidOptions, conErr.Op)
+	})
+}
@@ -0,0 +1,136 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// Optional protocol features which PFE may advertise. New features should be added here
+// and only used once the connection's Capabilities report them as supported
+const (
+	CapabilityBatchedUpload   = "batchedUpload"
+	CapabilityDeletions       = "deletions"
+	CapabilityPagination      = "pagination"
+	CapabilitySettings        = "settings"
+	CapabilityRawUpload       = "rawUpload"
+	CapabilityRenames         = "renames"
+	CapabilityResumableUpload = "resumableUpload"
+)
+
+// capabilitiesCacheTTL is how long the capabilities of a connection are reused before PFE is asked again
+const capabilitiesCacheTTL = time.Hour
+
+// Capabilities : The optional features supported by the PFE behind a connection
+type Capabilities struct {
+	ConnectionID string    `json:"id"`
+	Version      string    `json:"version"`
+	Features     []string  `json:"features"`
+	Checked      time.Time `json:"checked"`
+}
+
+// Supports : true when PFE advertised the feature
+func (capabilities *Capabilities) Supports(feature string) bool {
+	for _, supported := range capabilities.Features {
+		if supported == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCapabilities : Returns the capabilities of a connection, asking its PFE only when the cached
+// result is missing, older than an hour, or refresh is set
+func GetCapabilities(httpClient utils.HTTPClient, conID string, refresh bool) (*Capabilities, *ConError) {
+	id := strings.ToUpper(conID)
+	cache := loadCapabilitiesCache()
+	if cached, ok := cache[id]; ok && !refresh && time.Since(cached.Checked) < capabilitiesCacheTTL {
+		return &cached, nil
+	}
+
+	origin, conErr := GetPFEOrigin(conID)
+	if conErr != nil {
+		return nil, conErr
+	}
+	env, err := apiroutes.NewPFEClientForHost(httpClient, origin).GetEnvironment()
+	if err != nil {
+		return nil, &ConError{errOpGetEnv, err, err.Error()}
+	}
+	capabilities := Capabilities{
+		ConnectionID: id,
+		Version:      env.Version,
+		Features:     env.Capabilities,
+		Checked:      time.Now(),
+	}
+	if capabilities.Features == nil {
+		capabilities.Features = []string{}
+	}
+	cache[id] = capabilities
+	conErr = saveCapabilitiesCache(cache)
+	if conErr != nil {
+		return nil, conErr
+	}
+	return &capabilities, nil
+}
+
+// ClearCapabilities : Forgets the cached capabilities of a connection, so they are fetched again on next use
+func ClearCapabilities(conID string) *ConError {
+	cache := loadCapabilitiesCache()
+	id := strings.ToUpper(conID)
+	if _, ok := cache[id]; !ok {
+		return nil
+	}
+	delete(cache, id)
+	return saveCapabilitiesCache(cache)
+}
+
+// loadCapabilitiesCache : the cached capabilities by connection ID. A missing or unreadable
+// cache is treated as empty, since it can always be rebuilt from PFE
+func loadCapabilitiesCache() map[string]Capabilities {
+	cache := map[string]Capabilities{}
+	file, err := ioutil.ReadFile(getCapabilitiesCacheFilename())
+	if err != nil {
+		return cache
+	}
+	if json.Unmarshal(file, &cache) != nil {
+		return map[string]Capabilities{}
+	}
+	return cache
+}
+
+func saveCapabilitiesCache(cache map[string]Capabilities) *ConError {
+	body, err := json.MarshalIndent(cache, "", "\t")
+	if err != nil {
+		return &ConError{errOpFileParse, err, err.Error()}
+	}
+	err = os.MkdirAll(getConnectionConfigDir(), 0777)
+	if err != nil {
+		return &ConError{errOpFileWrite, err, err.Error()}
+	}
+	err = ioutil.WriteFile(getCapabilitiesCacheFilename(), body, 0644)
+	if err != nil {
+		return &ConError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+func getCapabilitiesCacheFilename() string {
+	return path.Join(getConnectionConfigDir(), "capabilities.json")
+}
@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetCapabilities(t *testing.T) {
+	saveConnectionsConfigFile(&ConnectionConfig{
+		SchemaVersion: connectionsSchemaVersion,
+		Connections:   []Connection{{ID: "local"}, {ID: "CAPSTEST", Label: "Capabilities", URL: "http://noserver.test.com"}},
+	})
+	defer ResetConnectionsFile()
+	ClearCapabilities("capstest")
+
+	t.Run("Asserts advertised capabilities are returned and cached", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"codewind_version":"0.6.0","capabilities":["batchedUpload","pagination"]}`)))
+		mockClient := &ClientMockServerConfig{StatusCode: http.StatusOK, Body: body}
+		capabilities, conErr := GetCapabilities(mockClient, "capstest", false)
+		if conErr != nil {
+			t.Fatal(conErr)
+		}
+		assert.Equal(t, "0.6.0", capabilities.Version)
+		assert.True(t, capabilities.Supports(CapabilityBatchedUpload))
+		assert.True(t, capabilities.Supports(CapabilityPagination))
+		assert.False(t, capabilities.Supports(CapabilityDeletions))
+
+		// a failing client shows the cached result is used
+		failingClient := &ClientMockServerConfig{StatusCode: http.StatusInternalServerError, Body: ioutil.NopCloser(bytes.NewReader([]byte{}))}
+		cached, conErr := GetCapabilities(failingClient, "capstest", false)
+		assert.Nil(t, conErr)
+		assert.Equal(t, capabilities.Features, cached.Features)
+	})
+
+	t.Run("Asserts an older PFE supports no optional features", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"codewind_version":"0.5.0"}`)))
+		mockClient := &ClientMockServerConfig{StatusCode: http.StatusOK, Body: body}
+		capabilities, conErr := GetCapabilities(mockClient, "capstest", true)
+		if conErr != nil {
+			t.Fatal(conErr)
+		}
+		assert.Empty(t, capabilities.Features)
+		assert.False(t, capabilities.Supports(CapabilityBatchedUpload))
+	})
+
+	t.Run("Asserts cleared capabilities are fetched again", func(t *testing.T) {
+		ClearCapabilities("capstest")
+		failingClient := &ClientMockServerConfig{StatusCode: http.StatusInternalServerError, Body: ioutil.NopCloser(bytes.NewReader([]byte{}))}
+		_, conErr := GetCapabilities(failingClient, "capstest", false)
+		assert.Equal(t, errOpGetEnv, conErr.Op)
+	})
+}
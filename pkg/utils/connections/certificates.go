@@ -0,0 +1,94 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+)
+
+// DefaultCertExpiryDays is how close to expiry a certificate must be before it is reported
+const DefaultCertExpiryDays = 30
+
+// CertificateStatus : The expiry of the certificate served by one of a connection's ingresses
+type CertificateStatus struct {
+	Name     string    `json:"name"`
+	URL      string    `json:"url"`
+	Expires  time.Time `json:"expires,omitempty"`
+	DaysLeft int       `json:"days_left"`
+	Expiring bool      `json:"expiring"`
+	Expired  bool      `json:"expired"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// CheckConnectionCertificates : Inspects the gatekeeper and keycloak ingress certificates of a remote
+// connection, flagging any which expire within expiryDays. Plain HTTP URLs are skipped
+func CheckConnectionCertificates(connection Connection, expiryDays int) []CertificateStatus {
+	statuses := []CertificateStatus{}
+	ingresses := []struct{ name, url string }{
+		{"gatekeeper", connection.URL},
+		{"keycloak", connection.AuthURL},
+	}
+	for _, ingress := range ingresses {
+		status := checkCertificate(ingress.name, ingress.url, expiryDays)
+		if status != nil {
+			statuses = append(statuses, *status)
+		}
+	}
+	return statuses
+}
+
+// checkCertificate : The expiry of the certificate served at an ingress URL, or nil for an empty or plain HTTP URL
+func checkCertificate(name string, rawURL string, expiryDays int) *CertificateStatus {
+	parsedURL, err := url.Parse(rawURL)
+	if rawURL == "" || (err == nil && parsedURL.Scheme != "https") {
+		return nil
+	}
+	status := CertificateStatus{Name: name, URL: rawURL}
+	expires, err := GetCertificateExpiry(rawURL)
+	if err != nil {
+		status.Error = err.Error()
+		return &status
+	}
+	status.Expires = expires
+	status.DaysLeft = int(time.Until(expires).Hours() / 24)
+	status.Expired = time.Now().After(expires)
+	status.Expiring = status.Expired || status.DaysLeft < expiryDays
+	return &status
+}
+
+// GetCertificateExpiry : Returns when the certificate served at an https URL expires. Verification
+// is skipped since remote installs use self-signed certificates, and expired ones must still be read
+func GetCertificateExpiry(rawURL string) (time.Time, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return time.Time{}, err
+	}
+	address := parsedURL.Host
+	if parsedURL.Port() == "" {
+		address = net.JoinHostPort(parsedURL.Hostname(), "443")
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{InsecureSkipVerify: true, ServerName: parsedURL.Hostname()})
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+	certificates := conn.ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return time.Time{}, errors.New("No certificate was presented by " + address)
+	}
+	return certificates[0].NotAfter, nil
+}
@@ -0,0 +1,55 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckConnectionCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	t.Run("Reads the expiry of the gatekeeper certificate", func(t *testing.T) {
+		connection := Connection{URL: server.URL}
+		statuses := CheckConnectionCertificates(connection, DefaultCertExpiryDays)
+		assert.Len(t, statuses, 1)
+		assert.Equal(t, "gatekeeper", statuses[0].Name)
+		assert.Equal(t, server.Certificate().NotAfter, statuses[0].Expires)
+		assert.False(t, statuses[0].Expiring)
+		assert.False(t, statuses[0].Expired)
+	})
+
+	t.Run("Flags certificates expiring within the given days", func(t *testing.T) {
+		connection := Connection{URL: server.URL, AuthURL: server.URL}
+		statuses := CheckConnectionCertificates(connection, 1000000)
+		assert.Len(t, statuses, 2)
+		assert.True(t, statuses[1].Expiring)
+		assert.Equal(t, "keycloak", statuses[1].Name)
+	})
+
+	t.Run("Skips plain HTTP URLs", func(t *testing.T) {
+		connection := Connection{URL: "http://localhost:10000"}
+		assert.Empty(t, CheckConnectionCertificates(connection, DefaultCertExpiryDays))
+	})
+
+	t.Run("Reports an error when the ingress cannot be reached", func(t *testing.T) {
+		connection := Connection{URL: "https://127.0.0.1:1"}
+		statuses := CheckConnectionCertificates(connection, DefaultCertExpiryDays)
+		assert.Len(t, statuses, 1)
+		assert.NotEmpty(t, statuses[0].Error)
+	})
+}
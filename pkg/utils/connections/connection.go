@@ -17,18 +17,26 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/config"
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
 	"github.com/urfave/cli"
 )
 
-// connectionsSchemaVersion must be incremented when changing the Connections Config or Connection Entry
+// connectionsSchemaVersion must be incremented when changing the Connections Config or Connection Entry, along
+// with a migration step added to Schema
 const connectionsSchemaVersion = 1
 
+// projectPrefixPattern matches the characters PFE allows in project names
+var projectPrefixPattern = regexp.MustCompile(`^[a-z0-9._-]*$`)
+
 // ConnectionConfig state and possible connections
 type ConnectionConfig struct {
 	SchemaVersion int          `json:"schemaversion"`
@@ -43,20 +51,48 @@ type Connection struct {
 	AuthURL  string `json:"auth"`
 	Realm    string `json:"realm"`
 	ClientID string `json:"clientid"`
+	// AuthProvider is openshift when the gatekeeper logs users in with the OAuth server of its OpenShift cluster
+	AuthProvider string `json:"authprovider,omitempty"`
+	// ProjectPrefix is added to the names of projects bound to a shared Codewind, so that teams do not collide
+	ProjectPrefix string `json:"projectprefix,omitempty"`
+	// Aliases are previous labels kept on rename, they still resolve to this connection and cannot be reused
+	Aliases []string `json:"aliases,omitempty"`
+	// Insecure skips certificate checks for the URL and auth URL of this connection only
+	Insecure bool `json:"insecure,omitempty"`
+	// CACert holds the PEM encoded certificates of the private CA which signed the certificates of the URL and
+	// auth URL, which are verified against it as well as the system's CAs
+	CACert string `json:"cacert,omitempty"`
+	// Kubeconfig and KubeContext are the kubeconfig file, empty for the default files, and the context in it
+	// that the remote Codewind was last managed with, used by Kubernetes operations on it unless others are given
+	Kubeconfig  string `json:"kubeconfig,omitempty"`
+	KubeContext string `json:"kubecontext,omitempty"`
 }
 
 // InitConfigFileIfRequired : Check the config file exist, if it does not then create a new default configuration
 func InitConfigFileIfRequired() *ConError {
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return conErr
+	}
+	defer unlock()
 	_, err := os.Stat(GetConnectionConfigFilename())
 	if os.IsNotExist(err) {
-		os.MkdirAll(getConnectionConfigDir(), 0777)
-		return ResetConnectionsFile()
+		return resetConnectionsFile()
 	}
 	return applySchemaUpdates()
 }
 
 // ResetConnectionsFile : Creates a new / overwrites connection config file with a default single local Codewind connection
 func ResetConnectionsFile() *ConError {
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return conErr
+	}
+	defer unlock()
+	return resetConnectionsFile()
+}
+
+func resetConnectionsFile() *ConError {
 	// create the default local connection
 	initialConfig := ConnectionConfig{
 		SchemaVersion: connectionsSchemaVersion,
@@ -71,16 +107,7 @@ func ResetConnectionsFile() *ConError {
 			},
 		},
 	}
-	body, err := json.MarshalIndent(initialConfig, "", "\t")
-	if err != nil {
-		return &ConError{errOpFileParse, err, err.Error()}
-	}
-
-	err = ioutil.WriteFile(GetConnectionConfigFilename(), body, 0644)
-	if err != nil {
-		return &ConError{errOpFileWrite, err, err.Error()}
-	}
-	return nil
+	return saveConnectionsConfigFile(&initialConfig)
 }
 
 // GetConnectionByID : retrieve a single connection with matching ID
@@ -98,6 +125,179 @@ func GetConnectionByID(conID string) (*Connection, *ConError) {
 	return nil, &ConError{errOpNotFound, err, err.Error()}
 }
 
+// ResolveConnectionID : the ID of the connection whose ID, label or alias matches nameOrID
+func ResolveConnectionID(nameOrID string) (string, *ConError) {
+	data, conErr := loadConnectionsConfigFile()
+	if conErr != nil {
+		return "", conErr
+	}
+	index := findConnectionIndex(data, nameOrID)
+	if index == -1 {
+		err := errors.New("Connection " + nameOrID + " not found")
+		return "", &ConError{errOpNotFound, err, err.Error()}
+	}
+	return data.Connections[index].ID, nil
+}
+
+// findConnectionIndex : the index of the connection matching nameOrID, checking IDs before labels and aliases
+func findConnectionIndex(data *ConnectionConfig, nameOrID string) int {
+	nameOrID = strings.TrimSpace(nameOrID)
+	for i := 0; i < len(data.Connections); i++ {
+		if strings.EqualFold(nameOrID, data.Connections[i].ID) {
+			return i
+		}
+	}
+	for i := 0; i < len(data.Connections); i++ {
+		if strings.EqualFold(nameOrID, data.Connections[i].Label) || containsFold(data.Connections[i].Aliases, nameOrID) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkLabelAvailable : a label must be set, and must not match the ID, label or alias of any connection
+// other than the one at index except, so that connections list output is unambiguous
+func checkLabelAvailable(data *ConnectionConfig, label string, except int) *ConError {
+	if label == "" {
+		err := errors.New("Connection label must not be empty")
+		return &ConError{errOpInvalidOptions, err, err.Error()}
+	}
+	for i := 0; i < len(data.Connections); i++ {
+		if i == except {
+			continue
+		}
+		connection := data.Connections[i]
+		if strings.EqualFold(label, connection.ID) || strings.EqualFold(label, connection.Label) || containsFold(connection.Aliases, label) {
+			err := errors.New("Label '" + label + "' is already used by connection " + strings.ToUpper(connection.ID))
+			return &ConError{errOpConflict, err, err.Error()}
+		}
+	}
+	return nil
+}
+
+// checkURLAvailable : a URL must not be used by any connection other than the one at index except
+func checkURLAvailable(data *ConnectionConfig, url string, except int) *ConError {
+	for i := 0; i < len(data.Connections); i++ {
+		if i != except && strings.EqualFold(url, data.Connections[i].URL) {
+			err := errors.New("URL " + url + " is already used by connection " + strings.ToUpper(data.Connections[i].ID))
+			return &ConError{errOpConflict, err, err.Error()}
+		}
+	}
+	return nil
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPFEOrigin : the origin at which the PFE API can be reached for a connection,
+// e.g. "http://127.0.0.1:10000" when local, or the gatekeeper URL when remote
+func GetPFEOrigin(conID string) (string, *ConError) {
+	connection, conErr := GetConnectionByID(conID)
+	if conErr != nil {
+		return "", conErr
+	}
+	if IsLocal(connection.ID) {
+		return config.ProfilePFEOrigin(LocalProfileOf(connection.ID)), nil
+	}
+	return connection.URL, nil
+}
+
+// IsLocal : true for the connection to the default local Codewind, and the connections to local profiles
+func IsLocal(conID string) bool {
+	return strings.EqualFold(conID, "local") || strings.HasPrefix(strings.ToLower(conID), "local-")
+}
+
+// LocalProfileOf : the local Codewind instance a local connection belongs to
+func LocalProfileOf(conID string) utils.LocalProfile {
+	id := strings.ToLower(conID)
+	if !strings.HasPrefix(id, "local-") {
+		return utils.LocalProfile{}
+	}
+	return utils.LocalProfile{Name: strings.TrimPrefix(id, "local-")}
+}
+
+// AddProfileConnection : Adds the connection to a local profile, unless it already exists, so that projects
+// can be bound to the profile's Codewind
+func AddProfileConnection(profile utils.LocalProfile) (*Connection, *ConError) {
+	connection := Connection{ID: profile.ConnectionID(), Label: "Codewind local connection (" + profile.Name + ")"}
+	conErr := updateConnectionsConfig(func(data *ConnectionConfig) *ConError {
+		for _, existing := range data.Connections {
+			if strings.EqualFold(existing.ID, connection.ID) {
+				connection = existing
+				return nil
+			}
+		}
+		if checkLabelAvailable(data, connection.Label, -1) != nil {
+			connection.Label = connection.ID
+		}
+		data.Connections = append(data.Connections, connection)
+		return nil
+	})
+	if conErr != nil {
+		return nil, conErr
+	}
+	return &connection, nil
+}
+
+// RemoveProfileConnection : Removes the connection to a local profile, if there is one
+func RemoveProfileConnection(profile utils.LocalProfile) *ConError {
+	conErr := updateConnectionsConfig(func(data *ConnectionConfig) *ConError {
+		for i := 0; i < len(data.Connections); i++ {
+			if strings.EqualFold(profile.ConnectionID(), data.Connections[i].ID) {
+				data.Connections = append(data.Connections[:i], data.Connections[i+1:]...)
+				return nil
+			}
+		}
+		return nil
+	})
+	if conErr != nil {
+		return conErr
+	}
+	ClearCapabilities(profile.ConnectionID())
+	ClearConnectionState(profile.ConnectionID())
+	return nil
+}
+
+// SetConnectionKubeContext : Records the kubeconfig file and context the remote Codewind of a connection is managed
+// with
+func SetConnectionKubeContext(conID string, kubeconfig string, kubeContext string) *ConError {
+	return updateConnectionsConfig(func(data *ConnectionConfig) *ConError {
+		index := findConnectionIndex(data, conID)
+		if index < 0 {
+			err := errors.New("Connection " + strings.ToUpper(conID) + " not found")
+			return &ConError{errOpNotFound, err, err.Error()}
+		}
+		data.Connections[index].Kubeconfig = kubeconfig
+		data.Connections[index].KubeContext = kubeContext
+		return nil
+	})
+}
+
+// SelectConnectionKubeContext : Selects the kubeconfig file and context recorded for a connection for the
+// Kubernetes operations on its remote Codewind, unless --kubeconfig or --context selected others
+func SelectConnectionKubeContext(conID string) {
+	connection, conErr := GetConnectionByID(conID)
+	if conErr != nil {
+		return
+	}
+	kube.SelectDefaultContext(connection.Kubeconfig, connection.KubeContext)
+}
+
+// ValidateProjectPrefix : checks a project prefix only uses characters allowed in project names
+func ValidateProjectPrefix(projectPrefix string) *ConError {
+	if !projectPrefixPattern.MatchString(projectPrefix) {
+		err := errors.New("Project prefix '" + projectPrefix + "' must only contain lower case letters, numbers, '.', '_' and '-'")
+		return &ConError{errOpInvalidOptions, err, err.Error()}
+	}
+	return nil
+}
+
 // GetConnectionsConfig : Retrieves and returns the entire Connection configuration contents
 func GetConnectionsConfig() (*ConnectionConfig, *ConError) {
 	data, conErr := loadConnectionsConfigFile()
@@ -115,19 +315,40 @@ func AddConnectionToList(httpClient utils.HTTPClient, c *cli.Context) (*Connecti
 	if url != "" && len(strings.TrimSpace(url)) > 0 {
 		url = strings.TrimSuffix(url, "/")
 	}
+	projectPrefix := strings.TrimSpace(c.String("projectprefix"))
+	conErr := ValidateProjectPrefix(projectPrefix)
+	if conErr != nil {
+		return nil, conErr
+	}
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return nil, conErr
+	}
+	defer unlock()
 	data, conErr := loadConnectionsConfigFile()
 	if conErr != nil {
 		return nil, conErr
 	}
 
 	// check the url and label are not already in use
-	for i := 0; i < len(data.Connections); i++ {
-		if strings.EqualFold(label, data.Connections[i].Label) || strings.EqualFold(url, data.Connections[i].URL) {
-			conErr := errors.New("Connection ID: " + connectionID + " already exists. To update, first remove and then re-add")
-			return nil, &ConError{errOpConflict, conErr, conErr.Error()}
+	conErr = checkLabelAvailable(data, label, -1)
+	if conErr != nil {
+		return nil, conErr
+	}
+	conErr = checkURLAvailable(data, url, -1)
+	if conErr != nil {
+		return nil, conErr
+	}
+
+	caCert := ""
+	if caCertPath := strings.TrimSpace(c.String("cacert")); caCertPath != "" {
+		caCert, conErr = LoadCACert(caCertPath)
+		if conErr != nil {
+			return nil, conErr
 		}
 	}
 
+	trustConnection(Connection{URL: url, Insecure: c.Bool("insecure"), CACert: caCert})
 	gatekeeperEnv, err := apiroutes.GetGatekeeperEnvironment(httpClient, url)
 	if err != nil {
 		return nil, &ConError{errOpGetEnv, err, err.Error()}
@@ -141,28 +362,177 @@ func AddConnectionToList(httpClient utils.HTTPClient, c *cli.Context) (*Connecti
 		AuthURL:  gatekeeperEnv.AuthURL,
 		Realm:    gatekeeperEnv.Realm,
 		ClientID: gatekeeperEnv.ClientID,
+
+		AuthProvider:  gatekeeperEnv.AuthProvider,
+		ProjectPrefix: projectPrefix,
+		Insecure:      c.Bool("insecure"),
+		CACert:        caCert,
 	}
+	trustConnection(newConnection)
 
 	// append it to the list
 	data.Connections = append(data.Connections, newConnection)
-	body, err := json.MarshalIndent(data, "", "\t")
-	if err != nil {
-		return nil, &ConError{errOpFileParse, err, err.Error()}
+	conErr = saveConnectionsConfigFile(data)
+	if conErr != nil {
+		return nil, conErr
 	}
+	return &newConnection, nil
+}
 
-	err = ioutil.WriteFile(GetConnectionConfigFilename(), body, 0644)
-	if err != nil {
-		return nil, &ConError{errOpFileWrite, err, err.Error()}
+// UpdateConnection : changes the label, URL, project prefix, insecure setting and/or CA certificate of an existing connection, keeping
+// its ID so that projects using the connection are unaffected. The gatekeeper environment is revalidated when the URL changes
+func UpdateConnection(httpClient utils.HTTPClient, c *cli.Context) (*Connection, *ConError) {
+	id := strings.TrimSpace(c.String("conid"))
+	label := strings.TrimSpace(c.String("label"))
+	url := strings.TrimSuffix(strings.TrimSpace(c.String("url")), "/")
+	projectPrefix := strings.TrimSpace(c.String("projectprefix"))
+
+	if IsLocal(id) {
+		err := errors.New("Local is a required connection and must not be updated")
+		return nil, &ConError{errOpProtected, err, err.Error()}
 	}
-	return &newConnection, nil
+	if label == "" && url == "" && !c.IsSet("projectprefix") && !c.IsSet("insecure") && !c.IsSet("cacert") {
+		err := errors.New("Must supply a new label, URL, project prefix, insecure setting or CA certificate for connection " + strings.ToUpper(id))
+		return nil, &ConError{errOpInvalidOptions, err, err.Error()}
+	}
+	conErr := ValidateProjectPrefix(projectPrefix)
+	if conErr != nil {
+		return nil, conErr
+	}
+
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return nil, conErr
+	}
+	defer unlock()
+	data, conErr := loadConnectionsConfigFile()
+	if conErr != nil {
+		return nil, conErr
+	}
+
+	index := -1
+	for i := 0; i < len(data.Connections); i++ {
+		if strings.EqualFold(id, data.Connections[i].ID) {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		err := errors.New("Connection " + strings.ToUpper(id) + " not found")
+		return nil, &ConError{errOpNotFound, err, err.Error()}
+	}
+
+	connection := data.Connections[index]
+	if label != "" {
+		connection.Label = label
+	}
+	if url != "" {
+		connection.URL = url
+	}
+	if c.IsSet("projectprefix") {
+		connection.ProjectPrefix = projectPrefix
+	}
+	if c.IsSet("insecure") {
+		connection.Insecure = c.Bool("insecure")
+	}
+	// An empty --cacert removes the CA certificate
+	if c.IsSet("cacert") {
+		connection.CACert = ""
+		if caCertPath := strings.TrimSpace(c.String("cacert")); caCertPath != "" {
+			connection.CACert, conErr = LoadCACert(caCertPath)
+			if conErr != nil {
+				return nil, conErr
+			}
+		}
+	}
+
+	// check the new url and label are not used by another connection
+	conErr = checkLabelAvailable(data, connection.Label, index)
+	if conErr != nil {
+		return nil, conErr
+	}
+	conErr = checkURLAvailable(data, connection.URL, index)
+	if conErr != nil {
+		return nil, conErr
+	}
+
+	if url != "" {
+		trustConnection(connection)
+		gatekeeperEnv, err := apiroutes.GetGatekeeperEnvironment(httpClient, connection.URL)
+		if err != nil {
+			return nil, &ConError{errOpGetEnv, err, err.Error()}
+		}
+		connection.AuthURL = gatekeeperEnv.AuthURL
+		connection.Realm = gatekeeperEnv.Realm
+		connection.ClientID = gatekeeperEnv.ClientID
+		connection.AuthProvider = gatekeeperEnv.AuthProvider
+		ClearCapabilities(connection.ID)
+		ClearConnectionState(connection.ID)
+	}
+	trustConnection(connection)
+
+	data.Connections[index] = connection
+	conErr = saveConnectionsConfigFile(data)
+	if conErr != nil {
+		return nil, conErr
+	}
+	return &connection, nil
+}
+
+// RenameConnection : changes the label of the connection whose ID, label or alias is given by conid, keeping
+// its ID. When alias is set the previous label is kept as an alias, so it still resolves to the connection
+func RenameConnection(c *cli.Context) (*Connection, *ConError) {
+	nameOrID := strings.TrimSpace(c.String("conid"))
+	label := strings.TrimSpace(c.String("label"))
+
+	var renamed Connection
+	conErr := updateConnectionsConfig(func(data *ConnectionConfig) *ConError {
+		index := findConnectionIndex(data, nameOrID)
+		if index == -1 {
+			err := errors.New("Connection " + nameOrID + " not found")
+			return &ConError{errOpNotFound, err, err.Error()}
+		}
+		connection := data.Connections[index]
+		if IsLocal(connection.ID) {
+			err := errors.New("Local is a required connection and must not be renamed")
+			return &ConError{errOpProtected, err, err.Error()}
+		}
+		conErr := checkLabelAvailable(data, label, index)
+		if conErr != nil {
+			return conErr
+		}
+
+		// renaming back to an alias removes it, so a label is never also an alias
+		aliases := []string{}
+		for _, alias := range connection.Aliases {
+			if !strings.EqualFold(alias, label) {
+				aliases = append(aliases, alias)
+			}
+		}
+		if c.Bool("alias") && connection.Label != "" && !strings.EqualFold(connection.Label, label) && !containsFold(aliases, connection.Label) {
+			aliases = append(aliases, connection.Label)
+		}
+		if len(aliases) == 0 {
+			aliases = nil
+		}
+		connection.Label = label
+		connection.Aliases = aliases
+		data.Connections[index] = connection
+		renamed = connection
+		return nil
+	})
+	if conErr != nil {
+		return nil, conErr
+	}
+	return &renamed, nil
 }
 
 // RemoveConnectionFromList : Removes the stored entry
 func RemoveConnectionFromList(c *cli.Context) *ConError {
 	id := strings.ToUpper(c.String("conid"))
 
-	if strings.EqualFold(id, "LOCAL") {
-		err := errors.New("Local is a required connection and must not be removed")
+	if IsLocal(id) {
+		err := errors.New("Local is a required connection and must not be removed, use remove with --profile to remove a local profile")
 		return &ConError{errOpProtected, err, err.Error()}
 	}
 
@@ -172,26 +542,20 @@ func RemoveConnectionFromList(c *cli.Context) *ConError {
 		return conErr
 	}
 
-	data, conErr := loadConnectionsConfigFile()
+	conErr = updateConnectionsConfig(func(data *ConnectionConfig) *ConError {
+		for i := 0; i < len(data.Connections); i++ {
+			if strings.EqualFold(id, data.Connections[i].ID) {
+				copy(data.Connections[i:], data.Connections[i+1:])
+				data.Connections = data.Connections[:len(data.Connections)-1]
+			}
+		}
+		return nil
+	})
 	if conErr != nil {
 		return conErr
 	}
-
-	for i := 0; i < len(data.Connections); i++ {
-		if strings.EqualFold(id, data.Connections[i].ID) {
-			copy(data.Connections[i:], data.Connections[i+1:])
-			data.Connections = data.Connections[:len(data.Connections)-1]
-		}
-	}
-	body, err := json.MarshalIndent(data, "", "\t")
-	if err != nil {
-		return &ConError{errOpFileParse, err, err.Error()}
-	}
-
-	err = ioutil.WriteFile(GetConnectionConfigFilename(), body, 0644)
-	if err != nil {
-		return &ConError{errOpFileWrite, err, err.Error()}
-	}
+	ClearCapabilities(id)
+	ClearConnectionState(id)
 	return nil
 }
 
@@ -224,13 +588,13 @@ func loadConnectionsConfigFile() (*ConnectionConfig, *ConError) {
 }
 
 // saveConnectionsConfigFile : Save the connections configuration file to disk
-// returns an error, and error code
+// returns an error, and error code. Callers changing loaded connections must hold the connections lock
 func saveConnectionsConfigFile(ConnectionConfig *ConnectionConfig) *ConError {
 	body, err := json.MarshalIndent(ConnectionConfig, "", "\t")
 	if err != nil {
 		return &ConError{errOpFileParse, err, err.Error()}
 	}
-	conErr := ioutil.WriteFile(GetConnectionConfigFilename(), body, 0644)
+	conErr := writeConnectionsFile(body)
 	if conErr != nil {
 		return &ConError{errOpFileWrite, conErr, conErr.Error()}
 	}
@@ -265,69 +629,25 @@ func GetConnectionConfigFilename() string {
 	return path.Join(getConnectionConfigDir(), "connections.json")
 }
 
-func loadRawConnectionsFile() ([]byte, *ConError) {
-	file, err := ioutil.ReadFile(GetConnectionConfigFilename())
-	if err != nil {
-		return nil, &ConError{errOpFileLoad, err, err.Error()}
+// MigrateConnectionsFile : Migrates the connections file to the current schema while holding the connections lock
+func MigrateConnectionsFile() (*migrate.Status, *ConError) {
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return nil, conErr
 	}
-	return file, nil
+	defer unlock()
+	status, migErr := migrate.Migrate(Schema, GetConnectionConfigFilename())
+	if migErr != nil {
+		return nil, &ConError{errOpSchemaUpdate, migErr.Err, migErr.Desc}
+	}
+	return status, nil
 }
 
-// applySchemaUpdates : update any existing entries to use the new schema design
+// applySchemaUpdates : migrate the connections file to the current schema, the connections lock must be held
 func applySchemaUpdates() *ConError {
-
-	loadedFile, conErr := loadConnectionsConfigFile()
-	if conErr != nil {
-		return conErr
-	}
-	savedSchemaVersion := loadedFile.SchemaVersion
-
-	// upgrade the schema if needed
-	if savedSchemaVersion < connectionsSchemaVersion {
-		file, conErr := loadRawConnectionsFile()
-		if conErr != nil {
-			return conErr
-		}
-
-		// apply schama updates from version 0 to version 1
-		if savedSchemaVersion == 0 {
-
-			// current config file
-			ConnectionConfig := ConnectionConfigV0{}
-
-			// create new config structure
-			newConnectionConfig := ConnectionConfigV1{}
-
-			err := json.Unmarshal([]byte(file), &ConnectionConfig)
-			if err != nil {
-				return &ConError{errOpFileParse, err, err.Error()}
-			}
-
-			newConnectionConfig.SchemaVersion = 1
-
-			// copy connections from old to new config
-			originalConnectionsV0 := ConnectionConfig.Connections
-			for i := 0; i < len(originalConnectionsV0); i++ {
-				originalConnection := originalConnectionsV0[i]
-				connectionJSON, _ := json.Marshal(originalConnection)
-				var upgradedConnection ConnectionV1
-				json.Unmarshal(connectionJSON, &upgradedConnection)
-
-				// rename 'name' field to 'id'
-				upgradedConnection.ID = originalConnection.Name
-				newConnectionConfig.Connections = append(newConnectionConfig.Connections, upgradedConnection)
-			}
-
-			// schema has been updated
-			body, err := json.MarshalIndent(newConnectionConfig, "", "\t")
-			if err != nil {
-				return &ConError{errOpFileParse, err, err.Error()}
-			}
-			err = ioutil.WriteFile(GetConnectionConfigFilename(), body, 0644)
-			if err != nil {
-				return &ConError{errOpFileWrite, err, err.Error()}
-			}
-		}
+	_, migErr := migrate.Migrate(Schema, GetConnectionConfigFilename())
+	if migErr != nil {
+		return &ConError{errOpSchemaUpdate, migErr.Err, migErr.Desc}
 	}
 	return nil
 }
@@ -18,9 +18,12 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"testing"
 
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
 )
@@ -52,6 +55,16 @@ func Test_SchemaUpgrade0to1(t *testing.T) {
 		assert.Len(t, result.Connections, 1)
 		assert.Equal(t, "testlocal", result.Connections[0].ID)
 	})
+	t.Run("Asserts the v0 file was backed up before it was migrated", func(t *testing.T) {
+		backup := migrate.BackupFilename(GetConnectionConfigFilename(), 0)
+		defer os.Remove(backup)
+		body, err := ioutil.ReadFile(backup)
+		assert.Nil(t, err)
+		assert.Equal(t, v1File, string(body))
+	})
+	t.Run("Asserts the schema version matches the migration steps", func(t *testing.T) {
+		assert.Equal(t, connectionsSchemaVersion, Schema.Version())
+	})
 }
 
 func Test_GetConnectionsConfig(t *testing.T) {
@@ -91,6 +104,154 @@ func Test_CreateNewConnection(t *testing.T) {
 	})
 }
 
+// Test_UpdateConnection : Changes the URL of the remoteserver connection, keeping its ID
+func Test_UpdateConnection(t *testing.T) {
+	allConnections, err := GetAllConnections()
+	if err != nil {
+		t.Fail()
+	}
+	idToUpdate := allConnections[1].ID
+
+	mockResponse := apiroutes.GatekeeperEnvironment{AuthURL: "http://a.new.auth.server.remote:1234", Realm: "newRealm", ClientID: "newClient"}
+	jsonResponse, _ := json.Marshal(mockResponse)
+	body := ioutil.NopCloser(bytes.NewReader([]byte(jsonResponse)))
+	mockClient := &ClientMockServerConfig{StatusCode: http.StatusOK, Body: body}
+
+	t.Run("Local connection cannot be updated", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("conid", "local", "doc")
+		set.String("label", "New label", "doc")
+		_, conErr := UpdateConnection(mockClient, cli.NewContext(nil, set, nil))
+		assert.Equal(t, errOpProtected, conErr.Op)
+	})
+
+	t.Run("Updates the URL and rediscovers the gatekeeper environment", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("conid", idToUpdate, "doc")
+		set.String("url", "https://codewind.newserver.remote/", "doc")
+		connection, conErr := UpdateConnection(mockClient, cli.NewContext(nil, set, nil))
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.Equal(t, idToUpdate, connection.ID)
+		assert.Equal(t, "MyRemoteServer", connection.Label)
+		assert.Equal(t, "https://codewind.newserver.remote", connection.URL)
+		assert.Equal(t, "newRealm", connection.Realm)
+	})
+
+	t.Run("Sets the project prefix without contacting the gatekeeper", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("conid", idToUpdate, "doc")
+		set.String("projectprefix", "team-a-", "doc")
+		set.Parse([]string{"--projectprefix", "team-a-"})
+		connection, conErr := UpdateConnection(nil, cli.NewContext(nil, set, nil))
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.Equal(t, "team-a-", connection.ProjectPrefix)
+		assert.Equal(t, "newRealm", connection.Realm)
+	})
+
+	t.Run("Rejects a project prefix with invalid characters", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("conid", idToUpdate, "doc")
+		set.String("projectprefix", "Team A", "doc")
+		set.Parse([]string{"--projectprefix", "Team A"})
+		_, conErr := UpdateConnection(nil, cli.NewContext(nil, set, nil))
+		assert.Equal(t, errOpInvalidOptions, conErr.Op)
+	})
+
+	t.Run("Marks the connection insecure and back without contacting the gatekeeper", func(t *testing.T) {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("conid", idToUpdate, "doc")
+		set.Bool("insecure", false, "doc")
+		set.Parse([]string{"--insecure"})
+		connection, conErr := UpdateConnection(nil, cli.NewContext(nil, set, nil))
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.True(t, connection.Insecure)
+
+		set = flag.NewFlagSet("tests", 0)
+		set.String("conid", idToUpdate, "doc")
+		set.Bool("insecure", false, "doc")
+		set.Parse([]string{"--insecure=false"})
+		connection, conErr = UpdateConnection(nil, cli.NewContext(nil, set, nil))
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.False(t, connection.Insecure)
+	})
+}
+
+// Test_RenameConnection : Renames the remoteserver connection, keeping its old label as an alias
+func Test_RenameConnection(t *testing.T) {
+	allConnections, err := GetAllConnections()
+	if err != nil {
+		t.Fail()
+	}
+	idToRename := allConnections[1].ID
+
+	renameContext := func(conID string, label string, alias bool) *cli.Context {
+		set := flag.NewFlagSet("tests", 0)
+		set.String("conid", conID, "doc")
+		set.String("label", label, "doc")
+		set.Bool("alias", alias, "doc")
+		return cli.NewContext(nil, set, nil)
+	}
+
+	t.Run("Local connection cannot be renamed", func(t *testing.T) {
+		_, conErr := RenameConnection(renameContext("local", "New label", false))
+		assert.Equal(t, errOpProtected, conErr.Op)
+	})
+
+	t.Run("Rejects a label used by another connection", func(t *testing.T) {
+		_, conErr := RenameConnection(renameContext(idToRename, "codewind LOCAL connection", false))
+		assert.Equal(t, errOpConflict, conErr.Op)
+	})
+
+	t.Run("Rejects an empty label", func(t *testing.T) {
+		_, conErr := RenameConnection(renameContext(idToRename, " ", false))
+		assert.Equal(t, errOpInvalidOptions, conErr.Op)
+	})
+
+	t.Run("Renames by label and keeps the old label as an alias", func(t *testing.T) {
+		connection, conErr := RenameConnection(renameContext("MyRemoteServer", "TeamServer", true))
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.Equal(t, idToRename, connection.ID)
+		assert.Equal(t, "TeamServer", connection.Label)
+		assert.Equal(t, []string{"MyRemoteServer"}, connection.Aliases)
+	})
+
+	t.Run("The alias resolves to the connection", func(t *testing.T) {
+		id, conErr := ResolveConnectionID("myremoteserver")
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.Equal(t, idToRename, id)
+	})
+
+	t.Run("The alias cannot be used as another label", func(t *testing.T) {
+		data, conErr := GetConnectionsConfig()
+		if conErr != nil {
+			t.Fail()
+		}
+		conErr = checkLabelAvailable(data, "MyRemoteServer", 0)
+		assert.Equal(t, errOpConflict, conErr.Op)
+	})
+
+	t.Run("Renaming back to an alias removes it", func(t *testing.T) {
+		connection, conErr := RenameConnection(renameContext(idToRename, "MyRemoteServer", false))
+		if conErr != nil {
+			t.Fail()
+		}
+		assert.Equal(t, "MyRemoteServer", connection.Label)
+		assert.Empty(t, connection.Aliases)
+	})
+}
+
 // Test_RemoveConnectionFromList : Adds a new connection to the stored list
 func Test_RemoveConnectionFromList(t *testing.T) {
 	set := flag.NewFlagSet("tests", 0)
@@ -122,3 +283,38 @@ func Test_RemoveConnectionFromList(t *testing.T) {
 		assert.Len(t, result.Connections, 1)
 	})
 }
+
+func Test_ProfileConnections(t *testing.T) {
+	t.Run("Recognises the connections of local profiles", func(t *testing.T) {
+		assert.True(t, IsLocal("local"))
+		assert.True(t, IsLocal("local-demo"))
+		assert.False(t, IsLocal("localhost"))
+		assert.True(t, LocalProfileOf("local").IsDefault())
+		assert.Equal(t, "demo", LocalProfileOf("local-demo").Name)
+	})
+
+	t.Run("Adds the connection of a profile once", func(t *testing.T) {
+		profile := utils.LocalProfile{Name: "demo"}
+		connection, conErr := AddProfileConnection(profile)
+		if conErr != nil {
+			t.Fatal(conErr)
+		}
+		assert.Equal(t, "local-demo", connection.ID)
+		assert.Equal(t, "Codewind local connection (demo)", connection.Label)
+		_, conErr = AddProfileConnection(profile)
+		if conErr != nil {
+			t.Fatal(conErr)
+		}
+		result, _ := GetConnectionsConfig()
+		assert.Len(t, result.Connections, 2)
+	})
+
+	t.Run("Removes the connection of a profile", func(t *testing.T) {
+		conErr := RemoveProfileConnection(utils.LocalProfile{Name: "demo"})
+		if conErr != nil {
+			t.Fatal(conErr)
+		}
+		result, _ := GetConnectionsConfig()
+		assert.Len(t, result.Connections, 1)
+	})
+}
@@ -23,14 +23,16 @@ type ConError struct {
 }
 
 const (
-	errOpFileParse    = "con_parse"
-	errOpFileLoad     = "con_load"
-	errOpFileWrite    = "con_write"
-	errOpSchemaUpdate = "con_schema_update"
-	errOpConflict     = "con_conflict"
-	errOpNotFound     = "con_not_found"
-	errOpProtected    = "con_protected"
-	errOpGetEnv       = "con_environment"
+	errOpFileParse      = "con_parse"
+	errOpFileLoad       = "con_load"
+	errOpFileWrite      = "con_write"
+	errOpFileLock       = "con_lock"
+	errOpSchemaUpdate   = "con_schema_update"
+	errOpConflict       = "con_conflict"
+	errOpNotFound       = "con_not_found"
+	errOpProtected      = "con_protected"
+	errOpGetEnv         = "con_environment"
+	errOpInvalidOptions = "con_cli_options"
 )
 
 const (
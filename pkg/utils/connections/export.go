@@ -0,0 +1,177 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+)
+
+// ImportResult : the outcome of importing a connections file
+type ImportResult struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Skipped []string `json:"skipped"`
+}
+
+// ExportConnections : Writes all remote connections to a file which can be shared and imported elsewhere.
+// Credentials live in the keyring rather than the connections file, so are never exported
+func ExportConnections(filename string) (int, *ConError) {
+	data, conErr := loadConnectionsConfigFile()
+	if conErr != nil {
+		return 0, conErr
+	}
+	exported := ConnectionConfig{SchemaVersion: connectionsSchemaVersion, Connections: []Connection{}}
+	for _, connection := range data.Connections {
+		if !IsLocal(connection.ID) {
+			exported.Connections = append(exported.Connections, connection)
+		}
+	}
+	body, err := json.MarshalIndent(exported, "", "\t")
+	if err != nil {
+		return 0, &ConError{errOpFileParse, err, err.Error()}
+	}
+	err = ioutil.WriteFile(filename, body, 0644)
+	if err != nil {
+		return 0, &ConError{errOpFileWrite, err, err.Error()}
+	}
+	return len(exported.Connections), nil
+}
+
+// ImportConnections : Merges the connections in an exported file into the connections config, matching
+// entries by URL. When an entry for the same URL differs, overwrite is called to decide whether to replace it
+func ImportConnections(filename string, overwrite func(existing Connection, imported Connection) bool) (*ImportResult, *ConError) {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, &ConError{errOpFileLoad, err, err.Error()}
+	}
+	imported := ConnectionConfig{}
+	err = json.Unmarshal(file, &imported)
+	if err != nil {
+		return nil, &ConError{errOpFileParse, err, err.Error()}
+	}
+	if imported.SchemaVersion > connectionsSchemaVersion {
+		err := errors.New("Connections file " + filename + " was exported by a newer version of cwctl")
+		return nil, &ConError{errOpSchemaUpdate, err, err.Error()}
+	}
+	// Files exported by older versions of cwctl are read at the current schema
+	file, migErr := migrate.Upgrade(Schema, file)
+	if migErr != nil {
+		return nil, &ConError{errOpSchemaUpdate, migErr.Err, migErr.Desc}
+	}
+	imported = ConnectionConfig{}
+	err = json.Unmarshal(file, &imported)
+	if err != nil {
+		return nil, &ConError{errOpFileParse, err, err.Error()}
+	}
+
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return nil, conErr
+	}
+	defer unlock()
+	data, conErr := loadConnectionsConfigFile()
+	if conErr != nil {
+		return nil, conErr
+	}
+
+	result := ImportResult{Added: []string{}, Updated: []string{}, Skipped: []string{}}
+	for _, connection := range imported.Connections {
+		if IsLocal(connection.ID) {
+			continue
+		}
+		connection.URL = strings.TrimSuffix(strings.TrimSpace(connection.URL), "/")
+		if connection.URL == "" {
+			result.Skipped = append(result.Skipped, connection.Label)
+			continue
+		}
+
+		existingIndex := -1
+		for i := 0; i < len(data.Connections); i++ {
+			if strings.EqualFold(connection.URL, data.Connections[i].URL) {
+				existingIndex = i
+				break
+			}
+		}
+
+		if existingIndex == -1 {
+			connection.ID = uniqueConnectionID(data, connection.ID)
+			connection.Label = uniqueLabel(data, connection, -1)
+			data.Connections = append(data.Connections, connection)
+			result.Added = append(result.Added, connection.ID)
+			continue
+		}
+
+		existing := data.Connections[existingIndex]
+		connection.ID = existing.ID
+		connection.Label = uniqueLabel(data, connection, existingIndex)
+		if reflect.DeepEqual(existing, connection) || !overwrite(existing, connection) {
+			result.Skipped = append(result.Skipped, existing.ID)
+			continue
+		}
+		data.Connections[existingIndex] = connection
+		result.Updated = append(result.Updated, existing.ID)
+	}
+
+	conErr = saveConnectionsConfigFile(data)
+	if conErr != nil {
+		return nil, conErr
+	}
+	return &result, nil
+}
+
+// uniqueLabel : keeps the exported label unless another connection already uses it, in which case the
+// connection ID is appended so that labels stay unique
+func uniqueLabel(data *ConnectionConfig, connection Connection, except int) string {
+	label := strings.TrimSpace(connection.Label)
+	if checkLabelAvailable(data, label, except) == nil {
+		return label
+	}
+	if label == "" {
+		return connection.ID
+	}
+	return label + " (" + connection.ID + ")"
+}
+
+// uniqueConnectionID : keeps the exported ID unless it is already in use or reserved, in which case a new one is
+// generated
+func uniqueConnectionID(data *ConnectionConfig, connectionID string) string {
+	isInUse := func(id string) bool {
+		for _, connection := range data.Connections {
+			if strings.EqualFold(connection.ID, id) {
+				return true
+			}
+		}
+		return false
+	}
+	connectionID = strings.ToUpper(strings.TrimSpace(connectionID))
+	timestamp := utils.CreateTimestamp()
+	for connectionID == "" || isInUse(connectionID) || isReservedConnectionID(connectionID) {
+		connectionID = strings.ToUpper(strconv.FormatInt(timestamp, 36))
+		timestamp++
+	}
+	return connectionID
+}
+
+// isReservedConnectionID : whether the ID would name a keyring service cwctl keeps other secrets under, as older
+// versions kept the secrets of a connection under the keyring service org.eclipse.codewind.<connection ID>
+func isReservedConnectionID(connectionID string) bool {
+	id := strings.ToLower(connectionID)
+	return id == "templates" || id == "probe" || strings.HasPrefix(id, "registry.")
+}
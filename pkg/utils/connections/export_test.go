@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExportImportConnections(t *testing.T) {
+	exportFile := filepath.Join(os.TempDir(), "cwctl-test-connections-export.json")
+	defer os.Remove(exportFile)
+
+	remote := Connection{ID: "REMOTE1", Label: "Team server", URL: "https://codewind.team.remote", AuthURL: "https://auth.team.remote", Realm: "teamRealm", ClientID: "teamClient"}
+	ResetConnectionsFile()
+	data, _ := loadConnectionsConfigFile()
+	data.Connections = append(data.Connections, remote)
+	saveConnectionsConfigFile(data)
+
+	t.Run("Asserts only remote connections are exported", func(t *testing.T) {
+		count, err := ExportConnections(exportFile)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		file, _ := ioutil.ReadFile(exportFile)
+		assert.NotContains(t, string(file), "\"local\"")
+	})
+
+	t.Run("Asserts an exported connection is added to an empty list", func(t *testing.T) {
+		ResetConnectionsFile()
+		result, err := ImportConnections(exportFile, func(existing Connection, imported Connection) bool { return false })
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"REMOTE1"}, result.Added)
+		connection, conErr := GetConnectionByID("REMOTE1")
+		assert.Nil(t, conErr)
+		assert.Equal(t, "teamRealm", connection.Realm)
+	})
+
+	t.Run("Asserts identical connections are skipped", func(t *testing.T) {
+		result, err := ImportConnections(exportFile, func(existing Connection, imported Connection) bool { return true })
+		assert.Nil(t, err)
+		assert.Empty(t, result.Added)
+		assert.Equal(t, []string{"REMOTE1"}, result.Skipped)
+	})
+
+	t.Run("Asserts a conflicting connection is only replaced when allowed", func(t *testing.T) {
+		data, _ := loadConnectionsConfigFile()
+		data.Connections[1].Label = "Renamed locally"
+		saveConnectionsConfigFile(data)
+
+		result, err := ImportConnections(exportFile, func(existing Connection, imported Connection) bool { return false })
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"REMOTE1"}, result.Skipped)
+		connection, _ := GetConnectionByID("REMOTE1")
+		assert.Equal(t, "Renamed locally", connection.Label)
+
+		result, err = ImportConnections(exportFile, func(existing Connection, imported Connection) bool { return true })
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"REMOTE1"}, result.Updated)
+		connection, _ = GetConnectionByID("REMOTE1")
+		assert.Equal(t, "Team server", connection.Label)
+	})
+
+	t.Run("Asserts an ID reserved for the keyring is replaced", func(t *testing.T) {
+		data, _ := loadConnectionsConfigFile()
+		assert.Equal(t, "REMOTE2", uniqueConnectionID(data, "remote2"))
+		for _, id := range []string{"templates", "PROBE", "registry.remote1"} {
+			connectionID := uniqueConnectionID(data, id)
+			assert.NotEqual(t, strings.ToUpper(id), connectionID)
+			assert.False(t, isReservedConnectionID(connectionID))
+		}
+	})
+
+	ResetConnectionsFile()
+}
@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// GatekeeperDetails : What the gatekeeper at a URL reports about the auth server of its Codewind, which is what
+// connections add saves for a connection
+type GatekeeperDetails struct {
+	URL      string `json:"url"`
+	AuthURL  string `json:"auth_url"`
+	Realm    string `json:"realm"`
+	ClientID string `json:"client_id"`
+	Version  string `json:"version"`
+	// AuthProvider is openshift when the gatekeeper logs users in with the OAuth server of its OpenShift cluster
+	AuthProvider string `json:"auth_provider,omitempty"`
+}
+
+// DiscoverGatekeeper : Fetches the environment of the gatekeeper at gatekeeperURL without adding a connection,
+// trusting its certificate as a connection with the same insecure and CA certificate options would. An error
+// is returned for a response connections add could not use, such as one without an auth server
+func DiscoverGatekeeper(httpClient utils.HTTPClient, gatekeeperURL string, insecure bool, caCertPath string) (*GatekeeperDetails, *ConError) {
+	gatekeeperURL = strings.TrimSuffix(strings.TrimSpace(gatekeeperURL), "/")
+	parsedURL, err := url.Parse(gatekeeperURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		err := errors.New("The gatekeeper URL '" + gatekeeperURL + "' must be an http or https URL")
+		return nil, &ConError{errOpInvalidOptions, err, err.Error()}
+	}
+
+	caCert := ""
+	if caCertPath = strings.TrimSpace(caCertPath); caCertPath != "" {
+		var conErr *ConError
+		caCert, conErr = LoadCACert(caCertPath)
+		if conErr != nil {
+			return nil, conErr
+		}
+	}
+	trustConnection(Connection{URL: gatekeeperURL, Insecure: insecure, CACert: caCert})
+
+	gatekeeperEnv, err := apiroutes.GetGatekeeperEnvironment(httpClient, gatekeeperURL)
+	if err != nil {
+		return nil, &ConError{errOpGetEnv, err, "Unable to fetch the gatekeeper environment from " + gatekeeperURL + ": " + err.Error()}
+	}
+	var missing []string
+	for name, value := range map[string]string{"auth_url": gatekeeperEnv.AuthURL, "realm": gatekeeperEnv.Realm, "client_id": gatekeeperEnv.ClientID} {
+		// The OAuth server of OpenShift has no realms
+		if value == "" && !(name == "realm" && gatekeeperEnv.UsesOpenShiftOAuth()) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		err := errors.New("The gatekeeper environment of " + gatekeeperURL + " has no " + strings.Join(missing, ", ") + ", check the URL is of a Codewind gatekeeper")
+		return nil, &ConError{errOpGetEnv, err, err.Error()}
+	}
+
+	return &GatekeeperDetails{
+		URL:      gatekeeperURL,
+		AuthURL:  gatekeeperEnv.AuthURL,
+		Realm:    gatekeeperEnv.Realm,
+		ClientID: gatekeeperEnv.ClientID,
+		Version:  knownVersion(gatekeeperEnv.Version),
+
+		AuthProvider: gatekeeperEnv.AuthProvider,
+	}, nil
+}
@@ -0,0 +1,58 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mockGatekeeper(statusCode int, body string) *ClientMockServerConfig {
+	return &ClientMockServerConfig{StatusCode: statusCode, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}
+}
+
+func Test_DiscoverGatekeeper(t *testing.T) {
+	t.Run("Reports the environment of the gatekeeper", func(t *testing.T) {
+		mockClient := mockGatekeeper(http.StatusOK, `{"auth_url":"https://keycloak.remote","realm":"codewind","client_id":"codewind-backend","version":"0.7.0"}`)
+		details, conErr := DiscoverGatekeeper(mockClient, "https://gatekeeper.remote/", false, "")
+		assert.Nil(t, conErr)
+		assert.Equal(t, &GatekeeperDetails{URL: "https://gatekeeper.remote", AuthURL: "https://keycloak.remote", Realm: "codewind", ClientID: "codewind-backend", Version: "0.7.0"}, details)
+	})
+
+	t.Run("Reports an unknown version when the gatekeeper has none", func(t *testing.T) {
+		mockClient := mockGatekeeper(http.StatusOK, `{"auth_url":"https://keycloak.remote","realm":"codewind","client_id":"codewind-backend"}`)
+		details, conErr := DiscoverGatekeeper(mockClient, "https://gatekeeper.remote", false, "")
+		assert.Nil(t, conErr)
+		assert.Equal(t, VersionUnknown, details.Version)
+	})
+
+	t.Run("Rejects a URL which is not http or https", func(t *testing.T) {
+		_, conErr := DiscoverGatekeeper(mockGatekeeper(http.StatusOK, "{}"), "gatekeeper.remote", false, "")
+		assert.Equal(t, errOpInvalidOptions, conErr.Op)
+	})
+
+	t.Run("Reports an error response of the gatekeeper", func(t *testing.T) {
+		_, conErr := DiscoverGatekeeper(mockGatekeeper(http.StatusNotFound, "Not Found"), "https://gatekeeper.remote", false, "")
+		assert.Equal(t, errOpGetEnv, conErr.Op)
+		assert.Contains(t, conErr.Desc, "404")
+	})
+
+	t.Run("Names what an incomplete environment is missing", func(t *testing.T) {
+		_, conErr := DiscoverGatekeeper(mockGatekeeper(http.StatusOK, `{"realm":"codewind"}`), "https://gatekeeper.remote", false, "")
+		assert.Equal(t, errOpGetEnv, conErr.Op)
+		assert.Contains(t, conErr.Desc, "auth_url, client_id")
+	})
+}
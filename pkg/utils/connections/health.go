@@ -0,0 +1,109 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// Overall health of a remote connection
+const (
+	HealthOK          = "healthy"
+	HealthDegraded    = "degraded"
+	HealthUnreachable = "unreachable"
+)
+
+// ComponentHealth : Whether one component of a remote Codewind responded, and the certificate it serves
+type ComponentHealth struct {
+	Name        string             `json:"name"`
+	URL         string             `json:"url"`
+	Reachable   bool               `json:"reachable"`
+	StatusCode  int                `json:"status_code,omitempty"`
+	Version     string             `json:"version,omitempty"`
+	Certificate *CertificateStatus `json:"certificate,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// HealthReport : The health of each component of a remote Codewind. Status is unreachable when any
+// component did not respond, degraded when a certificate has expired, expires soon or cannot be read
+type HealthReport struct {
+	ConnectionID string            `json:"id"`
+	Status       string            `json:"status"`
+	Components   []ComponentHealth `json:"components"`
+}
+
+// ProbeConnection : Checks the gatekeeper, PFE, performance dashboard and Keycloak of a remote connection.
+// pfeClient must authenticate against the connection, as PFE and the dashboard are behind the gatekeeper
+func ProbeConnection(httpClient utils.HTTPClient, pfeClient *apiroutes.PFEClient, connection Connection, expiryDays int) *HealthReport {
+	report := HealthReport{ConnectionID: connection.ID, Status: HealthOK}
+
+	gatekeeper := probeComponent(httpClient, "gatekeeper", connection.URL+"/health", func(statusCode int) bool { return statusCode == http.StatusOK })
+	gatekeeper.Certificate = checkCertificate("gatekeeper", connection.URL, expiryDays)
+
+	pfe := ComponentHealth{Name: "pfe", URL: connection.URL + "/api/v1/environment"}
+	env, err := pfeClient.GetEnvironment()
+	if err != nil {
+		pfe.Error = err.Error()
+	} else {
+		pfe.Reachable = true
+		pfe.Version = env.Version
+	}
+
+	performance := probeComponent(pfeClient.HTTPClient, "performance", connection.URL+"/performance/", func(statusCode int) bool { return statusCode < 500 })
+
+	var keycloak ComponentHealth
+	if connection.AuthProvider == apiroutes.AuthProviderOpenShift {
+		// The OAuth server of the OpenShift cluster takes the place of Keycloak
+		keycloak = probeComponent(httpClient, "oauth", connection.AuthURL+"/healthz", func(statusCode int) bool { return statusCode == http.StatusOK })
+		keycloak.Certificate = checkCertificate("oauth", connection.AuthURL, expiryDays)
+	} else {
+		keycloak = probeComponent(httpClient, "keycloak", connection.AuthURL+"/auth/realms/"+connection.Realm, func(statusCode int) bool { return statusCode == http.StatusOK })
+		keycloak.Certificate = checkCertificate("keycloak", connection.AuthURL, expiryDays)
+	}
+
+	report.Components = []ComponentHealth{gatekeeper, pfe, performance, keycloak}
+	for _, component := range report.Components {
+		if !component.Reachable {
+			report.Status = HealthUnreachable
+		} else if component.Certificate != nil && (component.Certificate.Expiring || component.Certificate.Error != "") && report.Status == HealthOK {
+			report.Status = HealthDegraded
+		}
+	}
+	return &report
+}
+
+// probeComponent : sends a GET to a component, which is reachable when healthy accepts the status code
+func probeComponent(httpClient utils.HTTPClient, name string, url string, healthy func(statusCode int) bool) ComponentHealth {
+	component := ComponentHealth{Name: name, URL: url}
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		component.Error = err.Error()
+		return component
+	}
+	req.Header.Add("Cache-Control", "no-cache")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		component.Error = err.Error()
+		return component
+	}
+	defer res.Body.Close()
+	component.StatusCode = res.StatusCode
+	component.Reachable = healthy(res.StatusCode)
+	if !component.Reachable {
+		component.Error = fmt.Sprintf("Responded with status code %d", res.StatusCode)
+	}
+	return component
+}
@@ -0,0 +1,68 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/stretchr/testify/assert"
+)
+
+// clientMockByURL : responds with the status code and body registered for the request URL, or 404
+type clientMockByURL map[string]struct {
+	statusCode int
+	body       string
+}
+
+func (c clientMockByURL) Do(req *http.Request) (*http.Response, error) {
+	response, ok := c[req.URL.String()]
+	if !ok {
+		response.statusCode = http.StatusNotFound
+	}
+	return &http.Response{
+		StatusCode: response.statusCode,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(response.body))),
+	}, nil
+}
+
+func Test_ProbeConnection(t *testing.T) {
+	connection := Connection{ID: "REMOTE", URL: "http://gatekeeper.test", AuthURL: "http://keycloak.test", Realm: "codewind"}
+
+	t.Run("Asserts a connection is healthy when every component responds", func(t *testing.T) {
+		mockClient := clientMockByURL{
+			"http://gatekeeper.test/health":             {http.StatusOK, `{"status":"UP"}`},
+			"http://gatekeeper.test/api/v1/environment": {http.StatusOK, `{"codewind_version":"0.6.0"}`},
+			"http://gatekeeper.test/performance/":       {http.StatusOK, ""},
+			"http://keycloak.test/auth/realms/codewind": {http.StatusOK, `{"realm":"codewind"}`},
+		}
+		report := ProbeConnection(mockClient, apiroutes.NewPFEClientForHost(mockClient, connection.URL), connection, DefaultCertExpiryDays)
+		assert.Equal(t, HealthOK, report.Status)
+		assert.Len(t, report.Components, 4)
+		assert.Equal(t, "0.6.0", report.Components[1].Version)
+	})
+
+	t.Run("Asserts a connection is unreachable when a component does not respond", func(t *testing.T) {
+		mockClient := clientMockByURL{
+			"http://gatekeeper.test/health":             {http.StatusOK, `{"status":"UP"}`},
+			"http://gatekeeper.test/api/v1/environment": {http.StatusOK, `{"codewind_version":"0.6.0"}`},
+			"http://gatekeeper.test/performance/":       {http.StatusOK, ""},
+		}
+		report := ProbeConnection(mockClient, apiroutes.NewPFEClientForHost(mockClient, connection.URL), connection, DefaultCertExpiryDays)
+		assert.Equal(t, HealthUnreachable, report.Status)
+		assert.False(t, report.Components[3].Reachable)
+		assert.Equal(t, http.StatusNotFound, report.Components[3].StatusCode)
+	})
+}
@@ -0,0 +1,119 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// connectionsLockTimeout is how long to wait for another cwctl process to finish changing the connections file
+const connectionsLockTimeout = 10 * time.Second
+
+// connectionsLockRetry is how often the lock is tried while another process holds it
+const connectionsLockRetry = 50 * time.Millisecond
+
+// getConnectionLockFilename : the file locked while the connections file is changed
+func getConnectionLockFilename() string {
+	return filepath.Join(getConnectionConfigDir(), "connections.json.lock")
+}
+
+// lockConnectionsFile : Takes the connections lock, retrying while another cwctl process holds it. The returned
+// function releases the lock. Locks are per open file, so the lock must not be taken again before it is released
+func lockConnectionsFile() (func(), *ConError) {
+	os.MkdirAll(getConnectionConfigDir(), 0777)
+	unlock, err := LockFile(getConnectionLockFilename(), GetConnectionConfigFilename())
+	if err != nil {
+		return nil, &ConError{errOpFileLock, err, err.Error()}
+	}
+	return unlock, nil
+}
+
+// LockFile : Takes an exclusive lock on lockPath, creating it if needed and retrying while another cwctl process
+// holds it, for changes to the file named by guarded. The returned function releases the lock
+func LockFile(lockPath string, guarded string) (func(), error) {
+	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(connectionsLockTimeout)
+	for {
+		locked, err := tryLockFile(lockFile)
+		if err != nil {
+			lockFile.Close()
+			return nil, err
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			lockFile.Close()
+			return nil, errors.New("Timed out waiting for another cwctl process to finish changing " + guarded)
+		}
+		time.Sleep(connectionsLockRetry)
+	}
+	return func() {
+		unlockFile(lockFile)
+		lockFile.Close()
+	}, nil
+}
+
+// updateConnectionsConfig : Loads the connections file, applies update and saves the result while holding
+// the connections lock, so that changes made by concurrent cwctl processes are not lost
+func updateConnectionsConfig(update func(data *ConnectionConfig) *ConError) *ConError {
+	unlock, conErr := lockConnectionsFile()
+	if conErr != nil {
+		return conErr
+	}
+	defer unlock()
+	data, conErr := loadConnectionsConfigFile()
+	if conErr != nil {
+		return conErr
+	}
+	conErr = update(data)
+	if conErr != nil {
+		return conErr
+	}
+	return saveConnectionsConfigFile(data)
+}
+
+// writeConnectionsFile : Writes the connections file so that a process reading it never sees it partly written
+func writeConnectionsFile(body []byte) error {
+	return WriteFileAtomically(GetConnectionConfigFilename(), body, 0644)
+}
+
+// WriteFileAtomically : Writes a file with mode perm to a temporary file in the same directory which is then
+// renamed over it, so that a process reading the file never sees it partly written
+func WriteFileAtomically(filename string, body []byte, perm os.FileMode) error {
+	tempFile, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = tempFile.Write(body)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFile.Name(), perm)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), filename)
+}
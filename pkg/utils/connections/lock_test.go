@@ -0,0 +1,61 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConcurrentConnectionUpdates(t *testing.T) {
+	ResetConnectionsFile()
+	defer ResetConnectionsFile()
+
+	t.Run("Asserts concurrent updates are not lost", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				conErr := updateConnectionsConfig(func(data *ConnectionConfig) *ConError {
+					id := "LOCKTEST" + strconv.Itoa(i)
+					data.Connections = append(data.Connections, Connection{ID: id, Label: id, URL: "https://" + id})
+					return nil
+				})
+				assert.Nil(t, conErr)
+			}(i)
+		}
+		wg.Wait()
+		data, conErr := loadConnectionsConfigFile()
+		assert.Nil(t, conErr)
+		assert.Len(t, data.Connections, 11)
+	})
+
+	t.Run("Asserts the lock is waited for", func(t *testing.T) {
+		unlock, conErr := lockConnectionsFile()
+		assert.Nil(t, conErr)
+		released := make(chan time.Time, 1)
+		go func() {
+			time.Sleep(200 * time.Millisecond)
+			released <- time.Now()
+			unlock()
+		}()
+		unlockAgain, conErr := lockConnectionsFile()
+		assert.Nil(t, conErr)
+		assert.False(t, time.Now().Before(<-released))
+		unlockAgain()
+	})
+}
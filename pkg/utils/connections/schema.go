@@ -13,33 +13,34 @@
 
 package connections
 
-// ConnectionConfigV0 : ConnectionsConfig Schema Version 0
-type ConnectionConfigV0 struct {
-	Connections []ConnectionV0 `json:"connections"`
-}
+import (
+	"errors"
 
-// ConnectionConfigV1 : Connections Schema Version 1
-type ConnectionConfigV1 struct {
-	SchemaVersion int            `json:"schemaversion"`
-	Connections   []ConnectionV1 `json:"connections"`
-}
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
+)
 
-// ConnectionV0 : Connections Schema Version 0
-type ConnectionV0 struct {
-	Name     string `json:"name"`
-	Label    string `json:"label"`
-	URL      string `json:"url"`
-	AuthURL  string `json:"auth"`
-	Realm    string `json:"realm"`
-	ClientID string `json:"client_id"`
+// Schema : The schema versions of the connections file, Steps[i] migrates it from version i. A step must be
+// appended, and connectionsSchemaVersion incremented, when changing the Connections Config or Connection Entry
+var Schema = migrate.Schema{
+	Name:         "connections",
+	VersionField: "schemaversion",
+	Steps: []migrate.Step{
+		{Description: "Rename the name of each connection to id", Migrate: renameConnectionNames},
+	},
 }
 
-// ConnectionV1 : Connections Schema Version 1
-type ConnectionV1 struct {
-	ID       string `json:"id"`
-	Label    string `json:"label"`
-	URL      string `json:"url"`
-	AuthURL  string `json:"auth"`
-	Realm    string `json:"realm"`
-	ClientID string `json:"client_id"`
+// renameConnectionNames : Version 0 identified connections by name, which version 1 renamed to id
+func renameConnectionNames(document map[string]interface{}) error {
+	entries, _ := document["connections"].([]interface{})
+	for _, entry := range entries {
+		connection, ok := entry.(map[string]interface{})
+		if !ok {
+			return errors.New("a connection is not an object")
+		}
+		if name, ok := connection["name"]; ok {
+			connection["id"] = name
+			delete(connection, "name")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,165 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// States of a remote connection, as status reports them. A connection is unknown until it has been checked
+const (
+	StateUnknown         = "unknown"
+	StateUnreachable     = "unreachable"
+	StateUnauthenticated = "unauthenticated"
+	StateHealthy         = "healthy"
+)
+
+// ConnectionState : Whether a remote connection could be reached and authenticated against when it was last
+// checked, with when it was last healthy so that IDEs can show how long it has been offline
+type ConnectionState struct {
+	ConnectionID string     `json:"id"`
+	State        string     `json:"state"`
+	Checked      *time.Time `json:"checked,omitempty"`
+	LastHealthy  *time.Time `json:"lastHealthy,omitempty"`
+	Version      string     `json:"version,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	Cached       bool       `json:"cached"`
+}
+
+// CheckConnectionState : Checks whether the gatekeeper of a remote connection responds, and only then whether
+// PFE can be reached through it, so that an unreachable connection never waits on authentication. pfeClient
+// must authenticate against the connection, and isAuthError tells whether an error it returns is a failure to
+// authenticate rather than to reach PFE
+func CheckConnectionState(httpClient utils.HTTPClient, pfeClient *apiroutes.PFEClient, connection Connection, isAuthError func(err error) bool) ConnectionState {
+	checked := time.Now()
+	state := ConnectionState{ConnectionID: strings.ToUpper(connection.ID), State: StateUnreachable, Checked: &checked}
+	gatekeeper := probeComponent(httpClient, "gatekeeper", connection.URL+"/health", func(statusCode int) bool { return statusCode == http.StatusOK })
+	if !gatekeeper.Reachable {
+		state.Error = gatekeeper.Error
+		return state
+	}
+	env, err := pfeClient.GetEnvironment()
+	if err != nil {
+		if isAuthError(err) {
+			state.State = StateUnauthenticated
+		}
+		state.Error = err.Error()
+		return state
+	}
+	state.State = StateHealthy
+	state.Version = env.Version
+	state.LastHealthy = &checked
+	return state
+}
+
+// SaveConnectionState : Caches the state of a connection, keeping when it was last healthy and the version it
+// last reported if it is not healthy now. Returns the state as cached
+func SaveConnectionState(state ConnectionState) (ConnectionState, *ConError) {
+	conErr := updateStateCache(func(cache map[string]ConnectionState) bool {
+		if previous, ok := cache[state.ConnectionID]; ok && state.State != StateHealthy {
+			state.LastHealthy = previous.LastHealthy
+			if state.Version == "" {
+				state.Version = previous.Version
+			}
+		}
+		cache[state.ConnectionID] = state
+		return true
+	})
+	return state, conErr
+}
+
+// GetCachedConnectionState : The state of a connection when it was last checked, without contacting it.
+// A connection which has not been checked is unknown
+func GetCachedConnectionState(conID string) ConnectionState {
+	id := strings.ToUpper(conID)
+	state, ok := loadStateCache()[id]
+	if !ok {
+		return ConnectionState{ConnectionID: id, State: StateUnknown, Cached: true}
+	}
+	state.Cached = true
+	return state
+}
+
+// ClearConnectionState : Forgets the cached state of a connection
+func ClearConnectionState(conID string) *ConError {
+	id := strings.ToUpper(conID)
+	return updateStateCache(func(cache map[string]ConnectionState) bool {
+		if _, ok := cache[id]; !ok {
+			return false
+		}
+		delete(cache, id)
+		return true
+	})
+}
+
+// loadStateCache : the cached states by connection ID. A missing or unreadable cache is treated as empty, as the
+// states are checked again by the next status
+func loadStateCache() map[string]ConnectionState {
+	cache := map[string]ConnectionState{}
+	file, err := ioutil.ReadFile(getStateCacheFilename())
+	if err != nil {
+		return cache
+	}
+	if json.Unmarshal(file, &cache) != nil {
+		return map[string]ConnectionState{}
+	}
+	return cache
+}
+
+// updateStateCache : Loads the cached states, applies update and saves them when update changed them, while holding
+// the lock of the cache so that the states saved by concurrent cwctl processes are not lost
+func updateStateCache(update func(cache map[string]ConnectionState) bool) *ConError {
+	err := os.MkdirAll(getConnectionConfigDir(), 0777)
+	if err != nil {
+		return &ConError{errOpFileWrite, err, err.Error()}
+	}
+	unlock, err := LockFile(getStateCacheFilename()+".lock", getStateCacheFilename())
+	if err != nil {
+		return &ConError{errOpFileLock, err, err.Error()}
+	}
+	defer unlock()
+	cache := loadStateCache()
+	if !update(cache) {
+		return nil
+	}
+	return saveStateCache(cache)
+}
+
+// saveStateCache : Writes the cached states. Callers must hold the lock of the cache
+func saveStateCache(cache map[string]ConnectionState) *ConError {
+	for id, state := range cache {
+		state.Cached = false
+		cache[id] = state
+	}
+	body, err := json.MarshalIndent(cache, "", "\t")
+	if err != nil {
+		return &ConError{errOpFileParse, err, err.Error()}
+	}
+	err = WriteFileAtomically(getStateCacheFilename(), body, 0644)
+	if err != nil {
+		return &ConError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+func getStateCacheFilename() string {
+	return path.Join(getConnectionConfigDir(), "health.json")
+}
@@ -0,0 +1,116 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/stretchr/testify/assert"
+)
+
+// erroringClient fails every request with its error
+type erroringClient struct {
+	err error
+}
+
+func (client *erroringClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, client.err
+}
+
+func stateResponse(statusCode int, body string) *ClientMockServerConfig {
+	return &ClientMockServerConfig{StatusCode: statusCode, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}
+}
+
+func Test_CheckConnectionState(t *testing.T) {
+	connection := Connection{ID: "remote1", URL: "https://codewind.example.com"}
+	errAuth := errors.New("authentication failed")
+	isAuthError := func(err error) bool { return err == errAuth }
+
+	t.Run("Asserts a connection whose gatekeeper does not respond is unreachable without authenticating", func(t *testing.T) {
+		pfeClient := apiroutes.NewPFEClientForHost(&erroringClient{err: errAuth}, connection.URL)
+		state := CheckConnectionState(stateResponse(http.StatusBadGateway, ""), pfeClient, connection, isAuthError)
+		assert.Equal(t, StateUnreachable, state.State)
+		assert.Equal(t, "REMOTE1", state.ConnectionID)
+		assert.Nil(t, state.LastHealthy)
+	})
+
+	t.Run("Asserts a connection whose credentials are refused is unauthenticated", func(t *testing.T) {
+		state := CheckConnectionState(stateResponse(http.StatusOK, ""), apiroutes.NewPFEClientForHost(&erroringClient{err: errAuth}, connection.URL), connection, isAuthError)
+		assert.Equal(t, StateUnauthenticated, state.State)
+		assert.Equal(t, "authentication failed", state.Error)
+	})
+
+	t.Run("Asserts a connection whose PFE fails behind the gatekeeper is unreachable", func(t *testing.T) {
+		state := CheckConnectionState(stateResponse(http.StatusOK, ""), apiroutes.NewPFEClientForHost(&erroringClient{err: errors.New("timeout")}, connection.URL), connection, isAuthError)
+		assert.Equal(t, StateUnreachable, state.State)
+	})
+
+	t.Run("Asserts a connection whose PFE responds is healthy", func(t *testing.T) {
+		state := CheckConnectionState(stateResponse(http.StatusOK, ""), apiroutes.NewPFEClientForHost(stateResponse(http.StatusOK, `{"codewind_version":"0.6.0"}`), connection.URL), connection, isAuthError)
+		assert.Equal(t, StateHealthy, state.State)
+		assert.Equal(t, "0.6.0", state.Version)
+		assert.Equal(t, state.Checked, state.LastHealthy)
+	})
+}
+
+func Test_ConnectionStateCache(t *testing.T) {
+	ClearConnectionState("statetest")
+	defer ClearConnectionState("statetest")
+
+	t.Run("Asserts a connection which has not been checked is unknown", func(t *testing.T) {
+		state := GetCachedConnectionState("statetest")
+		assert.Equal(t, StateUnknown, state.State)
+		assert.True(t, state.Cached)
+	})
+
+	t.Run("Asserts the last healthy state is kept while a connection is offline", func(t *testing.T) {
+		connection := Connection{ID: "statetest", URL: "https://codewind.example.com"}
+		healthy := CheckConnectionState(stateResponse(http.StatusOK, ""), apiroutes.NewPFEClientForHost(stateResponse(http.StatusOK, `{"codewind_version":"0.6.0"}`), connection.URL), connection, nil)
+		_, conErr := SaveConnectionState(healthy)
+		assert.Nil(t, conErr)
+		offline, conErr := SaveConnectionState(CheckConnectionState(stateResponse(http.StatusBadGateway, ""), nil, connection, nil))
+		assert.Nil(t, conErr)
+		assert.Equal(t, StateUnreachable, offline.State)
+		assert.True(t, healthy.LastHealthy.Equal(*offline.LastHealthy))
+		assert.Equal(t, "0.6.0", offline.Version)
+
+		cached := GetCachedConnectionState("statetest")
+		assert.True(t, cached.Cached)
+		assert.Equal(t, StateUnreachable, cached.State)
+		assert.True(t, healthy.LastHealthy.Equal(*cached.LastHealthy))
+	})
+
+	t.Run("Asserts concurrent saves are not lost", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, conErr := SaveConnectionState(ConnectionState{ConnectionID: "STATETEST" + strconv.Itoa(i), State: StateUnreachable})
+				assert.Nil(t, conErr)
+			}(i)
+		}
+		wg.Wait()
+		for i := 0; i < 10; i++ {
+			id := "statetest" + strconv.Itoa(i)
+			assert.Equal(t, StateUnreachable, GetCachedConnectionState(id).State, id)
+			ClearConnectionState(id)
+		}
+	})
+}
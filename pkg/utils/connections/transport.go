@@ -0,0 +1,250 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// connectionTransport : the shared HTTP transport, which only skips certificate checks for the hosts of
+// connections marked insecure, so that every other request is still verified, and verifies the hosts of
+// connections with their own CA certificate against it
+type connectionTransport struct {
+	base     *http.Transport
+	insecure *http.Transport
+
+	mutex sync.Mutex
+	hosts map[string]bool
+	// caTransports verify the certificates of the hosts of connections with a CA certificate against it
+	caTransports map[string]*http.Transport
+	loaded       bool
+	override     bool
+	all          bool
+	warned       map[string]bool
+	warnings     io.Writer
+}
+
+var sharedTransport *connectionTransport
+
+// UseConnectionTransport : Replaces http.DefaultTransport so that certificate checks are only skipped for the
+// hosts of insecure connections. When override is set every connection is treated as insecure for this invocation
+func UseConnectionTransport(override bool) {
+	if sharedTransport == nil {
+		base, ok := http.DefaultTransport.(*http.Transport)
+		if !ok {
+			return
+		}
+		sharedTransport = newConnectionTransport(base, os.Stderr)
+		http.DefaultTransport = sharedTransport
+	}
+	sharedTransport.mutex.Lock()
+	sharedTransport.override = override
+	sharedTransport.mutex.Unlock()
+}
+
+// TrustAllHosts : Skips certificate checks for every host for the rest of this invocation. Only used while
+// installing remote Codewind, which serves self signed certificates until it is configured
+func TrustAllHosts() {
+	UseConnectionTransport(InsecureOverride())
+	if sharedTransport == nil {
+		return
+	}
+	sharedTransport.mutex.Lock()
+	sharedTransport.all = true
+	sharedTransport.mutex.Unlock()
+}
+
+// InsecureOverride : true when --insecure was given for this invocation
+func InsecureOverride() bool {
+	if sharedTransport == nil {
+		return false
+	}
+	sharedTransport.mutex.Lock()
+	defer sharedTransport.mutex.Unlock()
+	return sharedTransport.override
+}
+
+// trustConnection : skips certificate checks for the URL and auth URL of a connection which is marked
+// insecure, or of any connection when --insecure was given, and otherwise verifies them against the CA
+// certificate of a connection which has one, including connections not yet saved
+func trustConnection(connection Connection) {
+	if sharedTransport == nil {
+		return
+	}
+	if connection.Insecure || InsecureOverride() {
+		sharedTransport.trust(connection)
+	} else if connection.CACert != "" {
+		sharedTransport.trustCA(connection)
+	}
+}
+
+func newConnectionTransport(base *http.Transport, warnings io.Writer) *connectionTransport {
+	return &connectionTransport{
+		base:         base,
+		hosts:        map[string]bool{},
+		caTransports: map[string]*http.Transport{},
+		warned:       map[string]bool{},
+		warnings:     warnings,
+	}
+}
+
+// RoundTrip : sends the request over the insecure transport when its host belongs to an insecure connection,
+// or over the transport which trusts the CA certificate of the connection it belongs to
+func (t *connectionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme == "https" {
+		if t.isInsecure(req.URL.Host) {
+			return t.insecureTransport(req.URL.Host).RoundTrip(req)
+		}
+		if caTransport := t.caTransport(req.URL.Host); caTransport != nil {
+			return caTransport.RoundTrip(req)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// CloseIdleConnections : lets http.Client.CloseIdleConnections reach both transports
+func (t *connectionTransport) CloseIdleConnections() {
+	t.base.CloseIdleConnections()
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.insecure != nil {
+		t.insecure.CloseIdleConnections()
+	}
+	for _, caTransport := range t.caTransports {
+		caTransport.CloseIdleConnections()
+	}
+}
+
+func (t *connectionTransport) isInsecure(host string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.all {
+		return true
+	}
+	t.load()
+	return t.hosts[strings.ToLower(host)]
+}
+
+// caTransport : the transport which trusts the CA certificate of the connection host belongs to, or nil
+func (t *connectionTransport) caTransport(host string) *http.Transport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.load()
+	return t.caTransports[strings.ToLower(host)]
+}
+
+// load : reads the insecure connections and the connections with a CA certificate on first use, as most
+// commands never make a request. Must be called with the mutex held
+func (t *connectionTransport) load() {
+	if t.loaded {
+		return
+	}
+	t.loaded = true
+	connections, conErr := GetAllConnections()
+	if conErr != nil {
+		return
+	}
+	for _, connection := range connections {
+		if connection.Insecure || t.override {
+			t.addHosts(connection)
+		} else if connection.CACert != "" {
+			t.addCATransport(connection)
+		}
+	}
+}
+
+func (t *connectionTransport) trust(connection Connection) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.addHosts(connection)
+}
+
+func (t *connectionTransport) trustCA(connection Connection) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.addCATransport(connection)
+}
+
+func (t *connectionTransport) addHosts(connection Connection) {
+	for _, host := range connectionHosts(connection) {
+		t.hosts[host] = true
+	}
+}
+
+// addCATransport : verifies the hosts of a connection against its CA certificate, as well as the system's CAs
+// so that a connection whose ingresses move to a public certificate keeps working. A certificate which cannot
+// be read is ignored, leaving the hosts to be verified against the system's CAs alone
+func (t *connectionTransport) addCATransport(connection Connection) {
+	pool, err := caCertPool(connection.CACert)
+	if err != nil {
+		return
+	}
+	caTransport := t.newTransport(&tls.Config{RootCAs: pool})
+	for _, host := range connectionHosts(connection) {
+		t.caTransports[host] = caTransport
+	}
+}
+
+// connectionHosts : the hosts of the URL and auth URL of a connection, in lower case, with the default HTTPS
+// port added to those without a port
+func connectionHosts(connection Connection) []string {
+	hosts := []string{}
+	for _, rawURL := range []string{connection.URL, connection.AuthURL} {
+		parsed, err := url.Parse(rawURL)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		host := strings.ToLower(parsed.Host)
+		hosts = append(hosts, host)
+		if parsed.Port() == "" && parsed.Scheme == "https" {
+			hosts = append(hosts, host+":443")
+		}
+	}
+	return hosts
+}
+
+// newTransport : a transport with the settings of the shared transport, so it keeps the proxy settings, and
+// its own TLS config
+func (t *connectionTransport) newTransport(tlsConfig *tls.Config) *http.Transport {
+	return &http.Transport{
+		Proxy:                 t.base.Proxy,
+		DialContext:           t.base.DialContext,
+		MaxIdleConns:          t.base.MaxIdleConns,
+		IdleConnTimeout:       t.base.IdleConnTimeout,
+		TLSHandshakeTimeout:   t.base.TLSHandshakeTimeout,
+		ExpectContinueTimeout: t.base.ExpectContinueTimeout,
+		TLSClientConfig:       tlsConfig,
+	}
+}
+
+// insecureTransport : the transport which skips certificate checks, created from the shared transport on first use
+// so it keeps the proxy settings. A warning is printed the first time each host is reached over it
+func (t *connectionTransport) insecureTransport(host string) *http.Transport {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.insecure == nil {
+		t.insecure = t.newTransport(&tls.Config{InsecureSkipVerify: true})
+	}
+	if !t.warned[host] {
+		t.warned[host] = true
+		banner := strings.Repeat("*", 78)
+		fmt.Fprintf(t.warnings, "%s\n* WARNING: certificate checks are disabled for %s\n* The connection is not protected against interception, do not use this on untrusted networks\n%s\n", banner, host, banner)
+	}
+	return t.insecure
+}
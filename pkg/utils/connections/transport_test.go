@@ -0,0 +1,81 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConnectionTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("Checks the certificates of hosts which are not insecure", func(t *testing.T) {
+		warnings := &bytes.Buffer{}
+		transport := newConnectionTransport(&http.Transport{}, warnings)
+		transport.loaded = true
+		client := &http.Client{Transport: transport}
+
+		_, err := client.Get(server.URL)
+		assert.NotNil(t, err)
+		assert.Empty(t, warnings.String())
+	})
+
+	t.Run("Skips certificate checks for an insecure connection and warns once", func(t *testing.T) {
+		warnings := &bytes.Buffer{}
+		transport := newConnectionTransport(&http.Transport{}, warnings)
+		transport.loaded = true
+		transport.trust(Connection{URL: server.URL, Insecure: true})
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(server.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		resp, err = client.Get(server.URL + "/again")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 1, strings.Count(warnings.String(), "WARNING"))
+		assert.Contains(t, warnings.String(), strings.TrimPrefix(server.URL, "https://"))
+	})
+
+	t.Run("Verifies a connection with a CA certificate against it", func(t *testing.T) {
+		caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+		warnings := &bytes.Buffer{}
+		transport := newConnectionTransport(&http.Transport{}, warnings)
+		transport.loaded = true
+		transport.trustCA(Connection{URL: server.URL, CACert: caCert})
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(server.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, warnings.String())
+	})
+
+	t.Run("Matches hosts without a port to the default HTTPS port", func(t *testing.T) {
+		transport := newConnectionTransport(&http.Transport{}, &bytes.Buffer{})
+		transport.loaded = true
+		transport.trust(Connection{URL: "https://codewind.example.com", AuthURL: "https://keycloak.example.com:8443"})
+		assert.True(t, transport.isInsecure("codewind.example.com:443"))
+		assert.True(t, transport.isInsecure("KEYCLOAK.example.com:8443"))
+		assert.False(t, transport.isInsecure("github.com"))
+	})
+}
@@ -0,0 +1,65 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// VersionUnknown is reported for a component whose version PFE does not report
+const VersionUnknown = "unknown"
+
+// ComponentVersion : The version of one component of a Codewind
+type ComponentVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ServerVersions : The versions of the components of the Codewind a connection points to
+type ServerVersions struct {
+	ConnectionID string             `json:"id"`
+	URL          string             `json:"url"`
+	Components   []ComponentVersion `json:"components"`
+}
+
+// GetServerVersions : Reads the versions of PFE, the performance dashboard and, for remote connections, the
+// gatekeeper from the environment API. Older versions of PFE only report their own version
+func GetServerVersions(httpClient utils.HTTPClient, conID string) (*ServerVersions, *ConError) {
+	host, conErr := GetPFEOrigin(conID)
+	if conErr != nil {
+		return nil, conErr
+	}
+	env, err := apiroutes.NewPFEClientForHost(httpClient, host).GetEnvironment()
+	if err != nil {
+		return nil, &ConError{errOpGetEnv, err, err.Error()}
+	}
+	versions := ServerVersions{
+		ConnectionID: conID,
+		URL:          host,
+		Components: []ComponentVersion{
+			{"pfe", knownVersion(env.Version)},
+			{"performance", knownVersion(env.PerformanceVersion)},
+		},
+	}
+	if !IsLocal(conID) {
+		versions.Components = append(versions.Components, ComponentVersion{"gatekeeper", knownVersion(env.GatekeeperVersion)})
+	}
+	return &versions, nil
+}
+
+func knownVersion(version string) string {
+	if version == "" {
+		return VersionUnknown
+	}
+	return version
+}
@@ -0,0 +1,48 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetServerVersions(t *testing.T) {
+	saveConnectionsConfigFile(&ConnectionConfig{
+		SchemaVersion: connectionsSchemaVersion,
+		Connections:   []Connection{{ID: "local"}, {ID: "VERSIONTEST", Label: "Versions", URL: "http://noserver.test.com"}},
+	})
+	defer ResetConnectionsFile()
+
+	t.Run("Reports the components PFE knows the versions of", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"codewind_version":"0.9.0","performance_version":"0.9.0"}`)))
+		mockClient := &ClientMockServerConfig{StatusCode: http.StatusOK, Body: body}
+		versions, conErr := GetServerVersions(mockClient, "VERSIONTEST")
+		if conErr != nil {
+			t.Fatal(conErr)
+		}
+		assert.Equal(t, "http://noserver.test.com", versions.URL)
+		assert.Equal(t, []ComponentVersion{{"pfe", "0.9.0"}, {"performance", "0.9.0"}, {"gatekeeper", VersionUnknown}}, versions.Components)
+	})
+
+	t.Run("Reports an error when PFE cannot be reached", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte{}))
+		mockClient := &ClientMockServerConfig{StatusCode: http.StatusServiceUnavailable, Body: body}
+		_, conErr := GetServerVersions(mockClient, "VERSIONTEST")
+		assert.NotNil(t, conErr)
+		assert.Equal(t, errOpGetEnv, conErr.Op)
+	})
+}
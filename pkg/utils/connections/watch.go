@@ -0,0 +1,120 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// HealthEvent : A change in the health of a connection. Problems lists each component which is unreachable or
+// whose certificate needs attention
+type HealthEvent struct {
+	Time           time.Time `json:"time"`
+	ConnectionID   string    `json:"id"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	Problems       []string  `json:"problems,omitempty"`
+}
+
+// HealthWatcher : Probes a connection repeatedly, reporting only when its health changes
+type HealthWatcher struct {
+	Probe func() *HealthReport
+	last  string
+}
+
+// Check : Probes the connection, returning an event when its status differs from the last check. The first check
+// always returns an event, so that watching starts by reporting the current health
+func (watcher *HealthWatcher) Check(now time.Time) *HealthEvent {
+	report := watcher.Probe()
+	if report.Status == watcher.last {
+		return nil
+	}
+	event := HealthEvent{
+		Time:           now.UTC(),
+		ConnectionID:   report.ConnectionID,
+		Status:         report.Status,
+		PreviousStatus: watcher.last,
+		Problems:       healthProblems(report),
+	}
+	watcher.last = report.Status
+	return &event
+}
+
+// healthProblems : describes each component of the report which is unreachable, or whose certificate has expired,
+// expires soon or cannot be read
+func healthProblems(report *HealthReport) []string {
+	problems := []string{}
+	for _, component := range report.Components {
+		if !component.Reachable {
+			problem := component.Name + " is unreachable"
+			if component.Error != "" {
+				problem += ": " + component.Error
+			}
+			problems = append(problems, problem)
+		} else if certificate := component.Certificate; certificate != nil {
+			if certificate.Error != "" {
+				problems = append(problems, component.Name+" certificate cannot be checked: "+certificate.Error)
+			} else if certificate.Expired {
+				problems = append(problems, component.Name+" certificate expired on "+certificate.Expires.Format("2006-01-02"))
+			} else if certificate.Expiring {
+				problems = append(problems, fmt.Sprintf("%s certificate expires in %d days", component.Name, certificate.DaysLeft))
+			}
+		}
+	}
+	return problems
+}
+
+// Text : a one line description of the event
+func (event HealthEvent) Text() string {
+	text := "Codewind connection " + event.ConnectionID + " is " + event.Status
+	if event.PreviousStatus != "" {
+		text += " (was " + event.PreviousStatus + ")"
+	}
+	if len(event.Problems) > 0 {
+		text += ": " + strings.Join(event.Problems, "; ")
+	}
+	return text
+}
+
+// SendHealthWebhook : Posts the event to a webhook as {"text": ..., "event": {...}}. The text field makes the
+// payload that of a Slack incoming webhook, while other receivers can read the event itself
+func SendHealthWebhook(httpClient utils.HTTPClient, webhookURL string, event HealthEvent) error {
+	payload := struct {
+		Text  string      `json:"text"`
+		Event HealthEvent `json:"event"`
+	}{event.Text(), event}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("The webhook responded with status code %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package connections
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_HealthWatcher(t *testing.T) {
+	reports := []*HealthReport{
+		{ConnectionID: "REMOTE", Status: HealthOK, Components: []ComponentHealth{{Name: "pfe", Reachable: true}}},
+		{ConnectionID: "REMOTE", Status: HealthOK, Components: []ComponentHealth{{Name: "pfe", Reachable: true}}},
+		{ConnectionID: "REMOTE", Status: HealthUnreachable, Components: []ComponentHealth{{Name: "pfe", Error: "connection refused"}}},
+		{ConnectionID: "REMOTE", Status: HealthDegraded, Components: []ComponentHealth{{Name: "gatekeeper", Reachable: true, Certificate: &CertificateStatus{Expiring: true, DaysLeft: 5}}}},
+	}
+	watcher := HealthWatcher{Probe: func() *HealthReport {
+		report := reports[0]
+		reports = reports[1:]
+		return report
+	}}
+	now := time.Date(2019, 11, 20, 10, 15, 0, 0, time.UTC)
+
+	t.Run("Reports the health of the first check", func(t *testing.T) {
+		event := watcher.Check(now)
+		assert.NotNil(t, event)
+		assert.Equal(t, "Codewind connection REMOTE is healthy", event.Text())
+	})
+	t.Run("Does not report health which has not changed", func(t *testing.T) {
+		assert.Nil(t, watcher.Check(now))
+	})
+	t.Run("Reports changes with their problems", func(t *testing.T) {
+		event := watcher.Check(now)
+		assert.NotNil(t, event)
+		assert.Equal(t, HealthOK, event.PreviousStatus)
+		assert.Equal(t, "Codewind connection REMOTE is unreachable (was healthy): pfe is unreachable: connection refused", event.Text())
+
+		event = watcher.Check(now)
+		assert.NotNil(t, event)
+		assert.Equal(t, []string{"gatekeeper certificate expires in 5 days"}, event.Problems)
+	})
+}
+
+func Test_SendHealthWebhook(t *testing.T) {
+	event := HealthEvent{Time: time.Now().UTC(), ConnectionID: "REMOTE", Status: HealthUnreachable, PreviousStatus: HealthOK}
+
+	t.Run("Posts Slack-compatible JSON with the event", func(t *testing.T) {
+		var received map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&received)
+		}))
+		defer server.Close()
+
+		err := SendHealthWebhook(http.DefaultClient, server.URL, event)
+		assert.Nil(t, err)
+		assert.Equal(t, "Codewind connection REMOTE is unreachable (was healthy)", received["text"])
+		assert.Equal(t, HealthUnreachable, received["event"].(map[string]interface{})["status"])
+	})
+	t.Run("Reports webhooks which do not accept the event", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		assert.NotNil(t, SendHealthWebhook(http.DefaultClient, server.URL, event))
+	})
+}
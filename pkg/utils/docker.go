@@ -16,83 +16,33 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"path"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
+	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/jsonmessage"
 	"github.com/docker/docker/pkg/term"
+	"github.com/eclipse/codewind-installer/pkg/docker/compose"
 	"github.com/eclipse/codewind-installer/pkg/errors"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
 )
 
-// codewind-docker-compose.yaml data
-var data = `
-version: 2
-services:
- codewind-pfe:
-  image: ${REPOSITORY}codewind-pfe${PLATFORM}:${TAG}
-  container_name: codewind-pfe
-  user: root
-  environment: ["HOST_WORKSPACE_DIRECTORY=${WORKSPACE_DIRECTORY}","CONTAINER_WORKSPACE_DIRECTORY=/codewind-workspace","HOST_OS=${HOST_OS}","CODEWIND_VERSION=${TAG}","PERFORMANCE_CONTAINER=codewind-performance${PLATFORM}:${TAG}","HOST_HOME=${HOST_HOME}","HOST_MAVEN_OPTS=${HOST_MAVEN_OPTS}"]
-  depends_on: [codewind-performance]
-  ports: ["127.0.0.1:${PFE_EXTERNAL_PORT}:9090"]
-  volumes: ["/var/run/docker.sock:/var/run/docker.sock","cw-workspace:/codewind-workspace","${WORKSPACE_DIRECTORY}:/mounted-workspace"]
-  networks: [network]
- codewind-performance:
-  image: codewind-performance${PLATFORM}:${TAG}
-  ports: ["127.0.0.1:9095:9095"]
-  container_name: codewind-performance
-  networks: [network]
-networks:
-  network:
-   driver_opts:
-    com.docker.network.bridge.host_binding_ipv4: "127.0.0.1"
-volumes:
-  cw-workspace:
-`
-
-// Compose struct for the docker compose yaml file
-type Compose struct {
-	Version  string `yaml:"version"`
-	SERVICES struct {
-		PFE struct {
-			Image         string   `yaml:"image"`
-			ContainerName string   `yaml:"container_name"`
-			User          string   `yaml:"user"`
-			Environment   []string `yaml:"environment"`
-			DependsOn     []string `yaml:"depends_on"`
-			Ports         []string `yaml:"ports"`
-			Volumes       []string `yaml:"volumes"`
-			Networks      []string `yaml:"networks"`
-		} `yaml:"codewind-pfe"`
-		PERFORMANCE struct {
-			Image         string   `yaml:"image"`
-			Ports         []string `yaml:"ports"`
-			ContainerName string   `yaml:"container_name"`
-			Volumes       []string `yaml:"volumes"`
-			Networks      []string `yaml:"networks"`
-		} `yaml:"codewind-performance"`
-	} `yaml:"services"`
-	VOLUME struct {
-		CodewindWorkspace map[string]string `yaml:"cw-workspace"`
-	} `yaml:"volumes"`
-	NETWORKS struct {
-		NETWORK struct {
-			DRIVEROPTS struct {
-				HostIP string `yaml:"com.docker.network.bridge.host_binding_ipv4"`
-			} `yaml:"driver_opts"`
-		} `yaml:"network"`
-	} `yaml:"networks"`
-}
-
-// constant to identify the internal port of PFE in its container
-const internalPFEPort = 9090
+// constants to identify the internal ports of PFE and the performance dashboard in their containers
+const (
+	internalPFEPort         = 9090
+	internalPerformancePort = 9095
+)
 
 // constants to identify the range of external ports on which to expose PFE
 const (
@@ -100,66 +50,138 @@ const (
 	maxTCPPort = 11000
 )
 
-// DockerCompose to set up the Codewind environment
-func DockerCompose(tempFilePath string, tag string) {
+// NewComposeConfig : the values the docker-compose file of the profile selected with --profile is rendered from.
+// PFE is published on the first free port from 10000 when no port is configured, and mounts the workspace from
+// the host directory or the named volume the workspace config gives
+func NewComposeConfig(images ImageConfig, ports PortConfig, workspace WorkspaceConfig) compose.Config {
+	logr.Debugln("System architecture is: ", runtime.GOARCH)
+	logr.Debugln("Host operating system is: ", runtime.GOOS)
 
-	// Set env variables for the docker compose file
+	// In Windows, calling the env variable "HOME" does not return the user directory correctly
 	home := os.Getenv("HOME")
+	if runtime.GOOS == "windows" {
+		home = os.Getenv("USERPROFILE")
+	}
 
-	const GOARCH string = runtime.GOARCH
-	const GOOS string = runtime.GOOS
-	fmt.Println("System architecture is: ", GOARCH)
-	fmt.Println("Host operating system is: ", GOOS)
-
-	if GOARCH == "x86_64" || GOARCH == "amd64" {
-		os.Setenv("PLATFORM", "-amd64")
-	} else {
-		os.Setenv("PLATFORM", "-"+GOARCH)
+	pfePort := ports.PFEPort
+	if pfePort == "" {
+		logr.Debugln("Attempting to find available port")
+		portAvailable, freePort := IsTCPPortAvailable(minTCPPort, maxTCPPort)
+		if !portAvailable {
+			logr.Warnln("No available external ports in range, will default to Docker-assigned port")
+		}
+		pfePort = freePort
 	}
 
-	os.Setenv("REPOSITORY", "")
-	os.Setenv("TAG", tag)
-	if GOOS == "windows" {
-		os.Setenv("WORKSPACE_DIRECTORY", "C:\\codewind-data")
-		// In Windows, calling the env variable "HOME" does not return
-		// the user directory correctly
-		os.Setenv("HOST_HOME", os.Getenv("USERPROFILE"))
+	// Each profile is a separate compose project, so gets its own network and workspace volume
+	profile := ActiveProfile()
+	proxySettings := GetProxySettings()
+
+	// PFE mounts the directories of projects into their containers from the host workspace, so it must be a path
+	// the Docker daemon can bind mount, which for a named volume is where the daemon keeps the volume
+	hostWorkspace := profile.workspaceDirectory(workspace)
+	workspaceMount := hostWorkspace
+	volumes := []string{"cw-workspace"}
+	if workspace.NamedVolume {
+		hostWorkspace = dataVolumePath(profile)
+		workspaceMount = dataVolume
+		volumes = append(volumes, dataVolume)
+	}
+	return compose.Config{
+		HostInterface: ports.HostInterface,
+		PFE: compose.Service{
+			Image:         images.PFERunReference(),
+			ContainerName: profile.PFEContainerName(),
+			HostPort:      pfePort,
+			ContainerPort: internalPFEPort,
+			Environment: map[string]string{
+				"HOST_WORKSPACE_DIRECTORY":      hostWorkspace,
+				"CONTAINER_WORKSPACE_DIRECTORY": "/codewind-workspace",
+				"HOST_OS":                       runtime.GOOS,
+				"CODEWIND_VERSION":              images.Tag,
+				"PERFORMANCE_CONTAINER":         images.PerformanceRunReference(),
+				"HOST_HOME":                     home,
+				"HOST_MAVEN_OPTS":               os.Getenv("MAVEN_OPTS"),
+				// Pass the proxy settings through so PFE can download templates
+				"HTTP_PROXY":  proxySettings.HTTPProxy,
+				"HTTPS_PROXY": proxySettings.HTTPSProxy,
+				"NO_PROXY":    proxySettings.ContainerNoProxy(),
+			},
+			Volumes: []string{
+				"/var/run/docker.sock:/var/run/docker.sock",
+				"cw-workspace:/codewind-workspace",
+				workspaceMount + ":/mounted-workspace",
+			},
+		},
+		Performance: compose.Service{
+			Image:         images.PerformanceRunReference(),
+			ContainerName: profile.PerformanceContainerName(),
+			HostPort:      ports.PerformancePort,
+			ContainerPort: internalPerformancePort,
+		},
+		Volumes: volumes,
+	}
+}
 
-	} else {
-		os.Setenv("WORKSPACE_DIRECTORY", home+"/codewind-data")
-		os.Setenv("HOST_HOME", home)
+// dataVolumePath : the path on the Docker host of the data volume of a profile. The volume is created, with the
+// labels docker-compose gives the volumes it creates so that compose uses it, when it does not exist yet. When
+// Docker cannot be reached the path of the volume in the default data root of the daemon is assumed
+func dataVolumePath(profile LocalProfile) string {
+	cli, err := client.NewEnvClient()
+	if err == nil {
+		var volume types.Volume
+		volume, err = cli.VolumeCreate(context.Background(), volumetypes.VolumeCreateBody{
+			Name: profile.DataVolume(),
+			Labels: map[string]string{
+				"com.docker.compose.project": profile.ComposeProject(),
+				"com.docker.compose.volume":  dataVolume,
+			},
+		})
+		if err == nil && volume.Mountpoint != "" {
+			return volume.Mountpoint
+		}
 	}
-	os.Setenv("HOST_OS", GOOS)
-	os.Setenv("COMPOSE_PROJECT_NAME", "codewind")
-	os.Setenv("HOST_MAVEN_OPTS", os.Getenv("MAVEN_OPTS"))
-	fmt.Printf("Attempting to find available port\n")
-	portAvailable, port := IsTCPPortAvailable(minTCPPort, maxTCPPort)
-	if !portAvailable {
-		fmt.Printf("No available external ports in range, will default to Docker-assigned port")
+	defaultPath := path.Join("/var/lib/docker/volumes", profile.DataVolume(), "_data")
+	logr.Warnf("Unable to find where Docker keeps volume %v, assuming %v: %v", profile.DataVolume(), defaultPath, err)
+	return defaultPath
+}
+
+// GenerateComposeFile : renders the docker-compose file that starts Codewind, from the template of the release
+// manifest or the one built into cwctl
+func GenerateComposeFile(images ImageConfig, ports PortConfig, workspace WorkspaceConfig) ([]byte, error) {
+	template, err := GetArtifact(http.DefaultClient, ArtifactDockerCompose)
+	if err != nil {
+		return nil, err
 	}
-	os.Setenv("PFE_EXTERNAL_PORT", port)
+	return compose.Render(template, NewComposeConfig(images, ports, workspace))
+}
+
+// ComposeFilePath : where the docker-compose file of a profile is written, in the cwctl state directory
+func ComposeFilePath(profile LocalProfile) string {
+	return filepath.Join(cliconfig.GetStateDir(), profile.ComposeProject()+"-docker-compose.yaml")
+}
 
-	cmd := exec.Command("docker-compose", "-f", tempFilePath, "up", "-d")
+// DockerCompose to set up the Codewind environment from a generated docker-compose file
+func DockerCompose(ctx context.Context, composeFile string) error {
+	cmd := exec.CommandContext(ctx, "docker-compose", "-p", ActiveProfile().ComposeProject(), "-f", composeFile, "up", "-d")
 	output := new(bytes.Buffer)
 	cmd.Stdout = output
 	cmd.Stderr = output
-	if err := cmd.Start(); err != nil { // after 'Start' the program is continued and script is executing in background
-		DeleteTempFile(tempFilePath)
-		errors.CheckErr(err, 101, "Is docker-compose installed?")
+	fmt.Println("Please wait whilst containers initialize...")
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("Unable to run docker-compose, is it installed? %v", err)
 	}
-	fmt.Printf("Please wait whilst containers initialize... %s \n", output.String())
-	cmd.Wait()
-	fmt.Printf(output.String()) // Wait to finish execution, so we can read all output
+	err := cmd.Wait()
+	logr.Debugln(output.String())
 
-	if strings.Contains(output.String(), "ERROR") || strings.Contains(output.String(), "error") {
-		DeleteTempFile(tempFilePath)
-		os.Exit(1)
-	}
-
-	if strings.Contains(output.String(), "The image for the service you're trying to recreate has been removed") {
-		DeleteTempFile(tempFilePath)
-		os.Exit(1)
+	// docker-compose can report errors while still exiting successfully
+	if err != nil || strings.Contains(strings.ToLower(output.String()), "error") {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("docker-compose up failed: %v", strings.TrimSpace(output.String()))
 	}
+	return nil
 }
 
 // PullImage - pull pfe/performance images from dockerhub
@@ -171,15 +193,20 @@ func PullImage(image string, jsonOutput bool) {
 	var codewindOut io.ReadCloser
 
 	codewindOut, err = cli.ImagePull(ctx, image, types.ImagePullOptions{})
-
+	err = describePullError(image, err)
+	if err != nil && GetProxySettings().IsSet() {
+		// Images are pulled by the Docker daemon, which has its own proxy configuration
+		logr.Warnln("The Docker daemon does not use the cwctl proxy settings, make sure the daemon is configured to use your proxy")
+	}
 	errors.CheckErr(err, 100, "")
 	if jsonOutput == true {
 		defer codewindOut.Close()
 		io.Copy(os.Stdout, codewindOut)
 	} else {
 		defer codewindOut.Close()
+		// Progress bars are only drawn for a terminal, otherwise each layer's progress is a line of its own
 		termFd, isTerm := term.GetFdInfo(os.Stderr)
-		jsonmessage.DisplayJSONMessagesStream(codewindOut, os.Stderr, termFd, isTerm, nil)
+		jsonmessage.DisplayJSONMessagesStream(codewindOut, os.Stderr, termFd, isTerm && IsInteractive(), nil)
 	}
 }
 
@@ -192,21 +219,20 @@ func TagImage(source, tag string) {
 	fmt.Println(output)
 }
 
-// CheckContainerStatus of Codewind running/stopped
+// CheckContainerStatus of the Codewind selected with --profile running/stopped
 func CheckContainerStatus() bool {
-	var containerStatus = false
-	containerArr := [2]string{}
-	containerArr[0] = "codewind-pfe"
-	containerArr[1] = "codewind-performance"
+	return CheckProfileContainerStatus(ActiveProfile())
+}
 
+// CheckProfileContainerStatus of a local Codewind instance running/stopped
+func CheckProfileContainerStatus(profile LocalProfile) bool {
+	var containerStatus = false
 	containers := GetContainerList()
 
 	containerCount := 0
 	for _, container := range containers {
-		for _, key := range containerArr {
-			if strings.HasPrefix(container.Image, key) {
-				containerCount++
-			}
+		if profile.Owns(container) && CodewindService(container) != "" {
+			containerCount++
 		}
 	}
 	if containerCount >= 2 {
@@ -224,13 +250,19 @@ func CheckImageStatus() bool {
 	imageArr[0] = "eclipse/codewind-pfe"
 	imageArr[1] = "eclipse/codewind-performance"
 
+	// Images pulled from a mirror only share the local names install tags them with
+	localImageArr := [2]string{}
+	localImageArr[0] = LocalPFEImage + ":"
+	localImageArr[1] = LocalPerformanceImage + ":"
+
 	images := GetImageList()
 
 	imageCount := 0
 	for _, image := range images {
 		imageRepo := strings.Join(image.RepoDigests, " ")
-		for _, key := range imageArr {
-			if strings.HasPrefix(imageRepo, key) {
+		imageTags := " " + strings.Join(image.RepoTags, " ")
+		for i, key := range imageArr {
+			if strings.HasPrefix(imageRepo, key) || strings.Contains(imageTags, " "+localImageArr[i]) {
 				imageCount++
 			}
 		}
@@ -264,6 +296,15 @@ func GetContainerList() []types.Container {
 	return containers
 }
 
+// listContainers : the running containers, returning the error rather than exiting when docker can not be reached
+func listContainers() ([]types.Container, error) {
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	return cli.ContainerList(context.Background(), types.ContainerListOptions{})
+}
+
 // GetImageList from docker
 func GetImageList() []types.ImageSummary {
 	ctx := context.Background()
@@ -276,6 +317,68 @@ func GetImageList() []types.ImageSummary {
 	return images
 }
 
+// CodewindImage : A local Codewind core or project image
+type CodewindImage struct {
+	ID         string   `json:"id"`
+	Kind       string   `json:"kind"`
+	Tags       []string `json:"tags"`
+	Digests    []string `json:"digests"`
+	Size       int64    `json:"size"`
+	Created    string   `json:"created"`
+	InUse      bool     `json:"inUse"`
+	Containers []string `json:"containers"`
+}
+
+// codewindImagePrefixes are the repositories of the core Codewind images, before and after tagging by install
+var codewindImagePrefixes = []string{"eclipse/codewind-", "docker.io/eclipse/codewind-", "codewind-"}
+
+// codewindImageKind : core or project for the names of a Codewind image, or empty for other images
+func codewindImageKind(names []string) string {
+	kind := ""
+	for _, name := range names {
+		if strings.HasPrefix(name, "cw-") {
+			return "project"
+		}
+		for _, prefix := range codewindImagePrefixes {
+			if strings.HasPrefix(name, prefix) {
+				kind = "core"
+			}
+		}
+	}
+	return kind
+}
+
+// GetCodewindImages lists the local Codewind core images and project application images,
+// along with the running containers using each of them
+func GetCodewindImages() []CodewindImage {
+	containers := GetContainerList()
+	codewindImages := []CodewindImage{}
+	for _, image := range GetImageList() {
+		kind := codewindImageKind(append(append([]string{}, image.RepoTags...), image.RepoDigests...))
+		if kind == "" {
+			continue
+		}
+
+		codewindImage := CodewindImage{
+			ID:         image.ID,
+			Kind:       kind,
+			Tags:       image.RepoTags,
+			Digests:    image.RepoDigests,
+			Size:       image.Size,
+			Created:    time.Unix(image.Created, 0).UTC().Format(time.RFC3339),
+			Containers: []string{},
+		}
+		for _, container := range containers {
+			if container.ImageID == image.ID && len(container.Names) > 0 {
+				codewindImage.Containers = append(codewindImage.Containers, strings.TrimPrefix(container.Names[0], "/"))
+			}
+		}
+		codewindImage.InUse = len(codewindImage.Containers) > 0
+		codewindImages = append(codewindImages, codewindImage)
+	}
+	return codewindImages
+}
+
 // GetNetworkList from docker
 func GetNetworkList() []types.NetworkResource {
 	ctx := context.Background()
@@ -320,31 +423,125 @@ func RemoveNetwork(network types.NetworkResource) {
 	}
 }
 
-// GetPFEHostAndPort will return the current hostname and port that PFE is running on
+// isProjectResourceName is true for the names Codewind gives the containers and images of a project, cw-<name>-<projectID>
+func isProjectResourceName(name string, projectID string) bool {
+	name = strings.TrimPrefix(name, "/")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	return strings.HasPrefix(name, "cw-") && strings.HasSuffix(name, "-"+projectID)
+}
+
+// RemoveProjectContainers removes the application containers of a project, whether or not they are running,
+// and returns their names
+func RemoveProjectContainers(projectID string) ([]string, error) {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, container := range containers {
+		if len(container.Names) == 0 || !isProjectResourceName(container.Names[0], projectID) {
+			continue
+		}
+		err = cli.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{Force: true})
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, strings.TrimPrefix(container.Names[0], "/"))
+	}
+	return removed, nil
+}
+
+// RemoveProjectImages removes the application images of a project and returns their tags
+func RemoveProjectImages(projectID string) ([]string, error) {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	images, err := cli.ImageList(ctx, types.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, image := range images {
+		tags := []string{}
+		for _, tag := range image.RepoTags {
+			if isProjectResourceName(tag, projectID) {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			continue
+		}
+		_, err = cli.ImageRemove(ctx, image.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, tags...)
+	}
+	return removed, nil
+}
+
+// RemoveVolume removes a docker volume, ignoring one which does not exist
+func RemoveVolume(name string) error {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	err = cli.VolumeRemove(ctx, name, false)
+	if err != nil && !client.IsErrNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// GetPFEHostAndPort will return the current hostname and port that PFE is running on. The saved pfeHost
+// replaces the hostname docker published the port on, for setups where docker is not reachable on localhost
 func GetPFEHostAndPort() (string, string) {
+	return GetProfilePFEHostAndPort(ActiveProfile())
+}
+
+// GetProfilePFEHostAndPort will return the hostname and port that the PFE of a local Codewind instance is running on
+func GetProfilePFEHostAndPort(profile LocalProfile) (string, string) {
 	// on Che, can assume PFE is always on localhost:9090
 	if os.Getenv("CHE_API_EXTERNAL") != "" {
 		return "localhost", "9090"
-	} else if CheckContainerStatus() {
+	} else if CheckProfileContainerStatus(profile) {
 		containerList := GetContainerList()
 		for _, container := range containerList {
-			if strings.HasPrefix(container.Image, "codewind-pfe") {
-				for _, port := range container.Ports {
-					if port.PrivatePort == internalPFEPort {
-						return port.IP, strconv.Itoa(int(port.PublicPort))
-					}
-				}
+			if profile.Owns(container) && CodewindService(container) == ServicePFE {
+				return publishedPFEHostAndPort(container)
 			}
 		}
 	}
 	return "", ""
 }
 
+// publishedPFEHostAndPort : the hostname and host port docker published the port of a PFE container on
+func publishedPFEHostAndPort(container types.Container) (string, string) {
+	for _, port := range container.Ports {
+		if port.PrivatePort == internalPFEPort {
+			return localPFEHost(port.IP), strconv.Itoa(int(port.PublicPort))
+		}
+	}
+	return "", ""
+}
+
 // GetImageTags of Codewind images
 func GetImageTags() []string {
-	imageArr := [2]string{}
+	imageArr := [4]string{}
 	imageArr[0] = "eclipse/codewind-pfe"
 	imageArr[1] = "eclipse/codewind-performance"
+	imageArr[2] = LocalPFEImage + ":"
+	imageArr[3] = LocalPerformanceImage + ":"
 	tagArr := []string{}
 
 	images := GetImageList()
@@ -355,11 +552,10 @@ func GetImageTags() []string {
 		for _, key := range imageArr {
 			if strings.HasPrefix(imageRepo, key) || strings.HasPrefix(imageTags, key) {
 				if len(image.RepoTags) > 0 {
-					tag := image.RepoTags[0]
-					tag = strings.Split(tag, ":")[1]
+					tag := imageTag(image.RepoTags[0])
 					tagArr = append(tagArr, tag)
 				} else {
-					log.Println("No tag available. Defaulting to ''")
+					logr.Debugln("No tag available. Defaulting to ''")
 					tagArr = append(tagArr, "")
 				}
 			}
@@ -376,10 +572,10 @@ func IsTCPPortAvailable(minTCPPort int, maxTCPPort int) (bool, string) {
 	for port := minTCPPort; port < maxTCPPort; port++ {
 		conn, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
 		if err != nil {
-			log.Println("Unable to connect to port", port, ":", err)
+			logr.Debugln("Unable to connect to port", port, ":", err)
 		} else {
 			status = "Port " + strconv.Itoa(port) + " Available"
-			fmt.Println(status)
+			logr.Debugln(status)
 			conn.Close()
 			return true, strconv.Itoa(port)
 		}
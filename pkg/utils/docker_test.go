@@ -0,0 +1,26 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsProjectResourceName(t *testing.T) {
+	projectID := "a9384430-f177-11e9-b862-edc28aca827a"
+	assert.True(t, isProjectResourceName("/cw-nodeapp-"+projectID, projectID))
+	assert.True(t, isProjectResourceName("cw-nodeapp-"+projectID+":latest", projectID))
+	assert.False(t, isProjectResourceName("/cw-nodeapp-0000", projectID))
+	assert.False(t, isProjectResourceName("/codewind-pfe", projectID))
+}
@@ -0,0 +1,309 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package doctor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	// MinDockerVersion is the oldest Docker engine Codewind supports
+	MinDockerVersion = "17.06"
+
+	// MinFreeDiskMB is the disk space needed to pull the Codewind images and build projects
+	MinFreeDiskMB = 5000
+
+	dockerHubURL = "https://registry-1.docker.io/v2/"
+)
+
+// Check statuses, in increasing order of severity
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+	StatusFailed  = "failed"
+)
+
+// Check : The result of a single prerequisite check
+type Check struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Report : The results of all prerequisite checks, with the most severe status overall
+type Report struct {
+	Status string  `json:"status"`
+	Checks []Check `json:"checks"`
+}
+
+// RunChecks : Verify the prerequisites for installing and starting Codewind locally, and that the
+// ingress certificates of remote connections do not expire within certExpiryDays
+func RunChecks(certExpiryDays int) *Report {
+	checks := []Check{
+		checkDocker(),
+		checkDockerCompose(),
+		checkDiskSpace(),
+	}
+	checks = append(checks, checkPorts()...)
+	checks = append(checks, checkDockerHub(), checkKeyring())
+	checks = append(checks, checkCertificates(certExpiryDays)...)
+	report := Report{Status: StatusOK, Checks: checks}
+	for _, check := range checks {
+		if check.Status == StatusFailed || (check.Status == StatusWarning && report.Status == StatusOK) {
+			report.Status = check.Status
+		}
+	}
+	return &report
+}
+
+func checkDocker() Check {
+	check := Check{Name: "docker"}
+	dockerClient, err := client.NewEnvClient()
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		var version string
+		serverVersion, versionErr := dockerClient.ServerVersion(ctx)
+		err = versionErr
+		if err == nil {
+			version = serverVersion.Version
+			if !IsVersionAtLeast(version, MinDockerVersion) {
+				check.Status = StatusFailed
+				check.Message = "Docker " + version + " is older than the minimum supported version " + MinDockerVersion
+				check.Remediation = "Upgrade Docker to version " + MinDockerVersion + " or later"
+				return check
+			}
+			check.Status = StatusOK
+			check.Message = "Docker " + version + " is running"
+			return check
+		}
+	}
+	check.Status = StatusFailed
+	check.Message = "Unable to reach the Docker daemon: " + err.Error()
+	check.Remediation = "Install Docker and make sure the daemon is running and accessible by the current user"
+	return check
+}
+
+func checkDockerCompose() Check {
+	check := Check{Name: "docker-compose"}
+	out, err := exec.Command("docker-compose", "version", "--short").Output()
+	if err != nil {
+		check.Status = StatusFailed
+		check.Message = "docker-compose is not available: " + err.Error()
+		check.Remediation = "Install docker-compose and make sure it is on the PATH"
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "docker-compose " + strings.TrimSpace(string(out)) + " is available"
+	return check
+}
+
+func checkDiskSpace() Check {
+	check := Check{Name: "disk-space"}
+	homeDir := os.Getenv("HOME")
+	if runtime.GOOS == "windows" {
+		homeDir = os.Getenv("USERPROFILE")
+	}
+	freeBytes, err := freeDiskSpace(homeDir)
+	if err != nil {
+		check.Status = StatusWarning
+		check.Message = "Unable to determine free disk space: " + err.Error()
+		return check
+	}
+	freeMB := freeBytes / 1000000
+	check.Message = strconv.FormatUint(freeMB, 10) + " MB free in " + homeDir
+	if freeMB < MinFreeDiskMB {
+		check.Status = StatusWarning
+		check.Remediation = "Free up at least " + strconv.Itoa(MinFreeDiskMB) + " MB for the Codewind images and project builds"
+		return check
+	}
+	check.Status = StatusOK
+	return check
+}
+
+func checkPort(port int, usedBy string) Check {
+	check := Check{Name: "port-" + strconv.Itoa(port)}
+	if isPortFree(port) {
+		check.Status = StatusOK
+		check.Message = "Port " + strconv.Itoa(port) + " is free for the " + usedBy
+		return check
+	}
+	check.Status = StatusWarning
+	check.Message = "Port " + strconv.Itoa(port) + " is in use"
+	check.Remediation = "Stop the process using port " + strconv.Itoa(port) + ", unless it is a running Codewind"
+	return check
+}
+
+// checkPorts : checks the ports start publishes Codewind on are free, using any ports saved in the cwctl config
+func checkPorts() []Check {
+	performancePort := 9095
+	pfePort := 0
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr == nil {
+		if port, err := strconv.Atoi(cliConfig.PerformancePort); err == nil {
+			performancePort = port
+		}
+		if port, err := strconv.Atoi(cliConfig.PFEPort); err == nil {
+			pfePort = port
+		}
+	}
+	checks := []Check{checkPort(performancePort, "Codewind performance dashboard")}
+	if pfePort != 0 {
+		return append(checks, checkPort(pfePort, "Codewind"))
+	}
+	return append(checks, checkPortRange(10000, 11000, "Codewind"))
+}
+
+func checkPortRange(minPort int, maxPort int, usedBy string) Check {
+	check := Check{Name: "ports-" + strconv.Itoa(minPort) + "-" + strconv.Itoa(maxPort)}
+	for port := minPort; port < maxPort; port++ {
+		if isPortFree(port) {
+			check.Status = StatusOK
+			check.Message = "Port " + strconv.Itoa(port) + " is free for " + usedBy
+			return check
+		}
+	}
+	check.Status = StatusWarning
+	check.Message = "No ports are free between " + strconv.Itoa(minPort) + " and " + strconv.Itoa(maxPort) + ", Docker will assign a port instead"
+	check.Remediation = "Free a port in the range " + strconv.Itoa(minPort) + "-" + strconv.Itoa(maxPort)
+	return check
+}
+
+func isPortFree(port int) bool {
+	listener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+func checkDockerHub() Check {
+	check := Check{Name: "docker-hub"}
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	resp, err := httpClient.Get(dockerHubURL)
+	if err != nil {
+		check.Status = StatusFailed
+		check.Message = "Unable to reach Docker Hub: " + err.Error()
+		check.Remediation = "Check your internet connection and proxy settings"
+		return check
+	}
+	defer resp.Body.Close()
+	// Docker Hub asks anonymous clients to authenticate, which still shows it is reachable
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		check.Status = StatusWarning
+		check.Message = "Docker Hub responded with status code " + strconv.Itoa(resp.StatusCode)
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "Docker Hub is reachable"
+	return check
+}
+
+func checkKeyring() Check {
+	check := Check{Name: "keyring"}
+	service := "org.eclipse.codewind.doctor"
+	err := keyring.Set(service, "doctor", "check")
+	if err == nil {
+		_, err = keyring.Get(service, "doctor")
+		keyring.Delete(service, "doctor")
+	}
+	if err != nil {
+		check.Status = StatusWarning
+		check.Message = "The desktop keyring is not available, credentials are stored in the encrypted file " + cliconfig.GetSecretsFilename() + ": " + err.Error()
+		check.Remediation = "Install and unlock a keyring service to store credentials for remote connections, or set " + security.SecretsPassphraseEnv + " to protect the file with a passphrase"
+		return check
+	}
+	check.Status = StatusOK
+	check.Message = "The desktop keyring is available"
+	return check
+}
+
+// checkCertificates returns a check for each remote connection with an https ingress
+func checkCertificates(certExpiryDays int) []Check {
+	checks := []Check{}
+	allConnections, conErr := connections.GetAllConnections()
+	if conErr != nil {
+		return checks
+	}
+	for _, connection := range allConnections {
+		if connections.IsLocal(connection.ID) {
+			continue
+		}
+		certificates := connections.CheckConnectionCertificates(connection, certExpiryDays)
+		if len(certificates) > 0 {
+			checks = append(checks, certificateCheck(connection.ID, certificates))
+		}
+	}
+	return checks
+}
+
+func certificateCheck(connectionID string, certificates []connections.CertificateStatus) Check {
+	check := Check{Name: "certificates-" + connectionID, Status: StatusOK}
+	messages := []string{}
+	for _, certificate := range certificates {
+		switch {
+		case certificate.Error != "":
+			messages = append(messages, "unable to check the "+certificate.Name+" certificate: "+certificate.Error)
+			if check.Status == StatusOK {
+				check.Status = StatusWarning
+			}
+		case certificate.Expired:
+			messages = append(messages, "the "+certificate.Name+" certificate expired on "+certificate.Expires.Format("2006-01-02"))
+			check.Status = StatusFailed
+		case certificate.Expiring:
+			messages = append(messages, "the "+certificate.Name+" certificate expires in "+strconv.Itoa(certificate.DaysLeft)+" days")
+			if check.Status == StatusOK {
+				check.Status = StatusWarning
+			}
+		default:
+			messages = append(messages, "the "+certificate.Name+" certificate is valid until "+certificate.Expires.Format("2006-01-02"))
+		}
+	}
+	check.Message = "Connection " + connectionID + ": " + strings.Join(messages, ", ")
+	if check.Status != StatusOK {
+		check.Remediation = "Renew the ingress certificates of the remote Codewind for connection " + connectionID
+	}
+	return check
+}
+
+// IsVersionAtLeast : Compares dotted version numbers such as 19.03.5, ignoring any suffix like -ce
+func IsVersionAtLeast(version string, minimum string) bool {
+	versionParts := strings.Split(version, ".")
+	minimumParts := strings.Split(minimum, ".")
+	for i := 0; i < len(minimumParts); i++ {
+		if i >= len(versionParts) {
+			return false
+		}
+		current, _ := strconv.Atoi(strings.SplitN(versionParts[i], "-", 2)[0])
+		required, _ := strconv.Atoi(minimumParts[i])
+		if current != required {
+			return current > required
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package doctor
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsVersionAtLeast(t *testing.T) {
+	tests := map[string]struct {
+		version string
+		want    bool
+	}{
+		"newer major version":   {"19.03.5", true},
+		"same version":          {"17.06", true},
+		"newer patch":           {"17.06.2-ce", true},
+		"older minor version":   {"17.05.0-ce", false},
+		"older major version":   {"1.13.1", false},
+		"missing minor version": {"17", false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.want, IsVersionAtLeast(test.version, MinDockerVersion))
+		})
+	}
+}
+
+func Test_checkPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	t.Run("Warns when the port is in use", func(t *testing.T) {
+		check := checkPort(port, "test")
+		assert.Equal(t, StatusWarning, check.Status)
+		assert.NotEmpty(t, check.Remediation)
+	})
+
+	listener.Close()
+	t.Run("Passes when the port is free", func(t *testing.T) {
+		check := checkPort(port, "test")
+		assert.Equal(t, StatusOK, check.Status)
+		assert.Equal(t, "port-"+strconv.Itoa(port), check.Name)
+	})
+}
+
+func Test_certificateCheck(t *testing.T) {
+	t.Run("Fails when a certificate has expired", func(t *testing.T) {
+		check := certificateCheck("K1", []connections.CertificateStatus{
+			{Name: "gatekeeper", Expires: time.Now().Add(-time.Hour), Expired: true, Expiring: true},
+			{Name: "keycloak", Expires: time.Now().Add(time.Hour), Expiring: true},
+		})
+		assert.Equal(t, StatusFailed, check.Status)
+		assert.NotEmpty(t, check.Remediation)
+	})
+
+	t.Run("Warns when a certificate is expiring", func(t *testing.T) {
+		check := certificateCheck("K1", []connections.CertificateStatus{
+			{Name: "gatekeeper", Expires: time.Now().Add(48 * time.Hour), DaysLeft: 2, Expiring: true},
+		})
+		assert.Equal(t, StatusWarning, check.Status)
+		assert.Equal(t, "Connection K1: the gatekeeper certificate expires in 2 days", check.Message)
+	})
+
+	t.Run("Passes when certificates are valid", func(t *testing.T) {
+		check := certificateCheck("K1", []connections.CertificateStatus{
+			{Name: "gatekeeper", Expires: time.Now().AddDate(1, 0, 0), DaysLeft: 365},
+		})
+		assert.Equal(t, StatusOK, check.Status)
+		assert.Empty(t, check.Remediation)
+	})
+}
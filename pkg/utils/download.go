@@ -12,6 +12,11 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path"
@@ -32,6 +37,60 @@ func DownloadFromURLThenExtract(URL string, destination string) error {
 	return DownloadFromRepoURL(URL, destination)
 }
 
+// DownloadVerifiedArchive downloads a .tar.gz or .zip archive from a URL and extracts it to a destination, only
+// if its hex encoded SHA-256 checksum matches. Archives of a repository's branch are generated on each download,
+// so only release archives can be verified
+func DownloadVerifiedArchive(URL string, destination string, checksum string) error {
+	if _, err := url.ParseRequestURI(URL); err != nil {
+		return err
+	}
+	isZip := strings.HasSuffix(URL, ".zip")
+	if !IsTarGzURL(URL) && !isZip {
+		return fmt.Errorf("Only .tar.gz and .zip archives can be verified against a checksum, not %v", URL)
+	}
+	tempFile, err := ioutil.TempFile("", "cwctl-template-")
+	if err != nil {
+		return err
+	}
+	tempFile.Close()
+	defer os.Remove(tempFile.Name())
+
+	err = DownloadFile(URL, tempFile.Name())
+	if err != nil {
+		return err
+	}
+	actual, err := fileChecksum(tempFile.Name())
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, strings.TrimSpace(checksum)) {
+		return fmt.Errorf("The checksum of %v is %v, not %v", URL, actual, checksum)
+	}
+
+	err = os.MkdirAll(destination, 0755)
+	if err != nil {
+		return err
+	}
+	if isZip {
+		return UnZip(tempFile.Name(), destination)
+	}
+	return UnTar(tempFile.Name(), destination)
+}
+
+// fileChecksum returns the hex encoded SHA-256 checksum of a file
+func fileChecksum(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // DownloadFromTarGzURL downloads a tar.gz file from a URL
 // and extracts it to a destination
 func DownloadFromTarGzURL(URL string, destination string) error {
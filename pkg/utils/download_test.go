@@ -12,9 +12,16 @@
 package utils
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -239,3 +246,39 @@ func TestIsTarGzURL(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloadVerifiedArchive(t *testing.T) {
+	archive := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(archive)
+	tarWriter := tar.NewWriter(gzipWriter)
+	content := []byte("console.log('hello')")
+	tarWriter.WriteHeader(&tar.Header{Name: "index.js", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tarWriter.Write(content)
+	tarWriter.Close()
+	gzipWriter.Close()
+	checksum := sha256.Sum256(archive.Bytes())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+	defer os.RemoveAll(testDir)
+
+	t.Run("Extracts an archive matching its checksum", func(t *testing.T) {
+		destination := filepath.Join(testDir, "verified")
+		err := DownloadVerifiedArchive(server.URL+"/template.tar.gz", destination, hex.EncodeToString(checksum[:]))
+		assert.Nil(t, err)
+		extracted, _ := ioutil.ReadFile(filepath.Join(destination, "index.js"))
+		assert.Equal(t, content, extracted)
+	})
+	t.Run("Rejects an archive not matching its checksum", func(t *testing.T) {
+		destination := filepath.Join(testDir, "tampered")
+		err := DownloadVerifiedArchive(server.URL+"/template.tar.gz", destination, "0000")
+		assert.Contains(t, err.Error(), "checksum")
+		assert.False(t, PathExists(destination))
+	})
+	t.Run("Rejects a URL which is not an archive", func(t *testing.T) {
+		err := DownloadVerifiedArchive(exampleGitURL, filepath.Join(testDir, "repo"), "0000")
+		assert.Contains(t, err.Error(), "Only .tar.gz and .zip archives")
+	})
+}
@@ -0,0 +1,185 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	logr "github.com/sirupsen/logrus"
+)
+
+// Categories of the events PFE sends, so that listeners need not know every event name
+const (
+	CategoryStatus  = "status"
+	CategoryBuild   = "build"
+	CategoryLogs    = "logs"
+	CategoryProject = "project"
+	CategoryOther   = "other"
+)
+
+// eventCategories maps the names of the events PFE sends to their category
+var eventCategories = map[string]string{
+	"projectStatusChanged":   CategoryStatus,
+	"projectRestartResult":   CategoryStatus,
+	"projectClosed":          CategoryStatus,
+	"projectBuildStatus":     CategoryBuild,
+	"projectChanged":         CategoryBuild,
+	"projectValidated":       CategoryBuild,
+	"projectLogsListChanged": CategoryLogs,
+	"log-update":             CategoryLogs,
+	"projectCreation":        CategoryProject,
+	"newProjectAdded":        CategoryProject,
+	"projectDeletion":        CategoryProject,
+	"projectSettingsChanged": CategoryProject,
+}
+
+// Event : an event PFE sent, written as one line of JSON
+type Event struct {
+	Time      string          `json:"time"`
+	Name      string          `json:"event"`
+	Category  string          `json:"category"`
+	ProjectID string          `json:"projectID,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// Listen : Joins the socket.io namespace of the PFE at host and calls handle with each event, until the context
+// ends, PFE closes the session or a request fails
+func Listen(ctx context.Context, httpClient utils.HTTPClient, host string, namespace string, handle func(Event)) error {
+	s, packets, err := openSession(ctx, httpClient, host, namespace)
+	if err != nil {
+		return err
+	}
+	logr.Debugf("Opened socket.io session %v", s.sid)
+
+	// Engine.IO 3 clients keep the session open by pinging the server
+	pingCtx, stopPinging := context.WithCancel(ctx)
+	defer stopPinging()
+	go func() {
+		for {
+			select {
+			case <-pingCtx.Done():
+				return
+			case <-time.After(s.pingInterval):
+				err := s.send(pingCtx, string(enginePing))
+				if err != nil && pingCtx.Err() == nil {
+					logr.Debugf("Unable to ping PFE: %v", err)
+				}
+			}
+		}
+	}()
+
+	for {
+		for _, packet := range packets {
+			done, err := handlePacket(packet, namespace, handle)
+			if done || err != nil {
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		packets, err = s.poll(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// handlePacket : passes on the event an Engine.IO packet carries, reporting whether the session ended
+func handlePacket(packet string, namespace string, handle func(Event)) (bool, error) {
+	if packet == "" {
+		return false, nil
+	}
+	switch packet[0] {
+	case engineClose:
+		return true, nil
+	case engineMessage:
+		event, err := parseMessage(packet[1:], namespace)
+		if err != nil {
+			return true, err
+		}
+		if event != nil {
+			handle(*event)
+		}
+	case enginePing, enginePong, engineNoop, engineOpen:
+	}
+	return false, nil
+}
+
+// parseMessage : the event in a socket.io packet, nil for packets which are not events of the namespace
+func parseMessage(message string, namespace string) (*Event, error) {
+	if message == "" {
+		return nil, nil
+	}
+	packetType := message[0]
+	body := message[1:]
+	packetNamespace := "/"
+	if strings.HasPrefix(body, "/") {
+		end := strings.IndexAny(body, ",")
+		if end < 0 {
+			packetNamespace, body = body, ""
+		} else {
+			packetNamespace, body = body[:end], body[end+1:]
+		}
+	}
+	if namespace == "" {
+		namespace = "/"
+	}
+	if packetNamespace != namespace {
+		return nil, nil
+	}
+
+	switch packetType {
+	case socketError:
+		return nil, errors.New("PFE refused the socket.io connection: " + body)
+	case socketDisconnect:
+		return nil, errors.New("PFE disconnected the socket.io namespace " + namespace)
+	case socketEvent:
+	default:
+		return nil, nil
+	}
+
+	// Events may be followed by an acknowledgement ID before their arguments
+	body = strings.TrimLeft(body, "0123456789")
+	var args []json.RawMessage
+	err := json.Unmarshal([]byte(body), &args)
+	if err != nil || len(args) == 0 {
+		return nil, errors.New("Invalid socket.io event: " + message)
+	}
+	event := Event{Time: time.Now().UTC().Format(time.RFC3339)}
+	err = json.Unmarshal(args[0], &event.Name)
+	if err != nil {
+		return nil, errors.New("Invalid socket.io event name: " + string(args[0]))
+	}
+	event.Category = CategoryOther
+	if category, ok := eventCategories[event.Name]; ok {
+		event.Category = category
+	}
+	if len(args) > 1 {
+		event.Data = args[1]
+		var project struct {
+			ProjectID string `json:"projectID"`
+		}
+		if json.Unmarshal(args[1], &project) == nil {
+			event.ProjectID = project.ProjectID
+		}
+	}
+	return &event, nil
+}
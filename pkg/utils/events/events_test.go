@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package events
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Payloads(t *testing.T) {
+	t.Run("Splits a payload into packets", func(t *testing.T) {
+		packets, err := decodePayload(encodePayload("0{}", "40", `42["é"]`))
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"0{}", "40", `42["é"]`}, packets)
+	})
+	t.Run("Counts lengths in UTF-16 code units", func(t *testing.T) {
+		assert.Equal(t, "3:4😀", encodePayload("4😀"))
+		packets, err := decodePayload("3:4😀1:3")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"4😀", "3"}, packets)
+	})
+	t.Run("Rejects truncated payloads", func(t *testing.T) {
+		_, err := decodePayload("10:42")
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_ParseMessage(t *testing.T) {
+	t.Run("Reads the name, category and project of an event", func(t *testing.T) {
+		event, err := parseMessage(`2/default,["projectStatusChanged",{"projectID":"p1","appStatus":"started"}]`, "/default")
+		assert.Nil(t, err)
+		assert.Equal(t, "projectStatusChanged", event.Name)
+		assert.Equal(t, CategoryStatus, event.Category)
+		assert.Equal(t, "p1", event.ProjectID)
+		assert.JSONEq(t, `{"projectID":"p1","appStatus":"started"}`, string(event.Data))
+	})
+	t.Run("Ignores events of other namespaces and other packets", func(t *testing.T) {
+		event, err := parseMessage(`2["projectStatusChanged",{}]`, "/default")
+		assert.Nil(t, err)
+		assert.Nil(t, event)
+		event, err = parseMessage("0/default", "/default")
+		assert.Nil(t, err)
+		assert.Nil(t, event)
+	})
+	t.Run("Reports a refused connection", func(t *testing.T) {
+		_, err := parseMessage(`4/default,"Not authorized"`, "/default")
+		assert.Contains(t, err.Error(), "Not authorized")
+	})
+}
+
+func Test_Listen(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			w.Write([]byte("ok"))
+			return
+		}
+		if r.URL.Query().Get("sid") == "" {
+			w.Write([]byte(encodePayload(`0{"sid":"abc","pingInterval":25000}`, "40")))
+			return
+		}
+		polls++
+		if polls == 1 {
+			w.Write([]byte(encodePayload("40/default", `42/default,["projectLogsListChanged",{"projectID":"p1"}]`)))
+			return
+		}
+		w.Write([]byte(encodePayload("1")))
+	}))
+	defer server.Close()
+
+	var received []Event
+	err := Listen(context.Background(), http.DefaultClient, server.URL, "/default", func(event Event) {
+		received = append(received, event)
+	})
+	assert.Nil(t, err)
+	assert.Len(t, received, 1)
+	assert.Equal(t, CategoryLogs, received[0].Category)
+}
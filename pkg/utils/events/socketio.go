@@ -0,0 +1,177 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// Engine.IO packet types, which carry socket.io packets in messages
+const (
+	engineOpen    = '0'
+	engineClose   = '1'
+	enginePing    = '2'
+	enginePong    = '3'
+	engineMessage = '4'
+	engineNoop    = '6'
+)
+
+// Socket.io packet types
+const (
+	socketConnect    = '0'
+	socketDisconnect = '1'
+	socketEvent      = '2'
+	socketError      = '4'
+)
+
+// session : a socket.io session over the Engine.IO long polling transport, which only needs plain HTTP requests
+// so it works through the gatekeeper and proxies with the same client as the rest of cwctl
+type session struct {
+	httpClient   utils.HTTPClient
+	url          string
+	namespace    string
+	sid          string
+	pingInterval time.Duration
+}
+
+type handshake struct {
+	SID          string `json:"sid"`
+	PingInterval int    `json:"pingInterval"`
+}
+
+// openSession : Performs the Engine.IO handshake, then joins the socket.io namespace PFE sends events on
+func openSession(ctx context.Context, httpClient utils.HTTPClient, host string, namespace string) (*session, []string, error) {
+	s := session{httpClient: httpClient, url: host + "/socket.io/?EIO=3&transport=polling&b64=1", namespace: namespace}
+	packets, err := s.poll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(packets) == 0 || packets[0] == "" || packets[0][0] != engineOpen {
+		return nil, nil, errors.New("PFE did not open a socket.io session")
+	}
+	var open handshake
+	err = json.Unmarshal([]byte(packets[0][1:]), &open)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to parse the socket.io handshake: %v", err)
+	}
+	s.sid = open.SID
+	s.pingInterval = time.Duration(open.PingInterval) * time.Millisecond
+	if s.pingInterval <= 0 {
+		s.pingInterval = 25 * time.Second
+	}
+	if namespace != "" && namespace != "/" {
+		err = s.send(ctx, string(engineMessage)+string(socketConnect)+namespace)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return &s, packets[1:], nil
+}
+
+// poll : waits for the packets the server has for the session
+func (s *session) poll(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequest("GET", s.sessionURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error: PFE responded with status code %d: %s", resp.StatusCode, string(body))
+	}
+	return decodePayload(string(body))
+}
+
+// send : posts packets to the session
+func (s *session) send(ctx context.Context, packets ...string) error {
+	req, err := http.NewRequest("POST", s.sessionURL(), bytes.NewBufferString(encodePayload(packets...)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error: PFE responded with status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *session) sessionURL() string {
+	url := s.url + "&t=" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	if s.sid != "" {
+		url += "&sid=" + s.sid
+	}
+	return url
+}
+
+// encodePayload : joins packets into a polling payload, each prefixed with its length and a colon
+func encodePayload(packets ...string) string {
+	var payload strings.Builder
+	for _, packet := range packets {
+		payload.WriteString(strconv.Itoa(jsLength(packet)) + ":" + packet)
+	}
+	return payload.String()
+}
+
+// decodePayload : splits a polling payload into its packets. Lengths count UTF-16 code units, as in JavaScript
+func decodePayload(payload string) ([]string, error) {
+	packets := []string{}
+	remaining := []rune(payload)
+	for len(remaining) > 0 {
+		colon := 0
+		for colon < len(remaining) && remaining[colon] != ':' {
+			colon++
+		}
+		length, err := strconv.Atoi(string(remaining[:colon]))
+		if err != nil || colon == len(remaining) {
+			return nil, fmt.Errorf("Invalid socket.io payload: %q", payload)
+		}
+		remaining = remaining[colon+1:]
+		end := 0
+		for units := 0; units < length; end++ {
+			if end == len(remaining) {
+				return nil, fmt.Errorf("Truncated socket.io payload: %q", payload)
+			}
+			units += len(utf16.Encode([]rune{remaining[end]}))
+		}
+		packets = append(packets, string(remaining[:end]))
+		remaining = remaining[end:]
+	}
+	return packets, nil
+}
+
+func jsLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
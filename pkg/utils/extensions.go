@@ -13,16 +13,19 @@ package utils
 
 import (
 	"bytes"
-	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	logr "github.com/sirupsen/logrus"
 )
 
 type (
 	// Extension represents a project extension defined by codewind.yaml
 	Extension struct {
+		Name        string             `json:"name,omitempty"`
+		Version     string             `json:"version,omitempty"`
 		ProjectType string             `json:"projectType"`
 		Detection   string             `json:"detection"`
 		Commands    []ExtensionCommand `json:"commands"`
@@ -38,10 +41,35 @@ type (
 
 	// ExtensionConfig represents a project extension's config element
 	ExtensionConfig struct {
-		Style string `json:"style"`
+		Style  string   `json:"style"`
+		Styles []string `json:"styles,omitempty"`
 	}
 )
 
+// GetStyles returns the project styles an extension registers
+func (extension Extension) GetStyles() []string {
+	styles := []string{}
+	if extension.Config.Style != "" {
+		styles = append(styles, extension.Config.Style)
+	}
+	for _, style := range extension.Config.Styles {
+		if style != "" && !ContainsStyle(styles, style) {
+			styles = append(styles, style)
+		}
+	}
+	return styles
+}
+
+// ContainsStyle returns whether a list of project styles includes the given style, ignoring case
+func ContainsStyle(styles []string, style string) bool {
+	for _, known := range styles {
+		if strings.EqualFold(known, style) {
+			return true
+		}
+	}
+	return false
+}
+
 // Run a directive on the value
 func processDirective(value string, directive string) string {
 
@@ -80,22 +108,37 @@ func processArg(arg string, params map[string]string) string {
 	return arg
 }
 
-// RunCommand runs a command defined by an extension
-func RunCommand(projectPath string, command ExtensionCommand, params map[string]string) error {
+// CommandPath returns the path of the binary a command runs, which must be in the directory of cwctl
+func (command ExtensionCommand) CommandPath() (string, error) {
 	cwd, err := os.Executable()
 	if err != nil {
-		log.Println("There was a problem with locating the command directory")
-		return err
+		return "", err
 	}
 	cwctlPath := filepath.Dir(cwd)
 	commandName := filepath.Base(command.Command) // prevent path traversal
-	commandBin := filepath.Join(cwctlPath, commandName)
+	return filepath.Join(cwctlPath, commandName), nil
+}
 
-	// check for variable substitution into args
-	for i := 0; i < len(command.Args); i++ {
-		arg := command.Args[i]
-		command.Args[i] = processArg(arg, params)
+// SubstituteArgs returns the arguments of a command with the values of params substituted
+func (command ExtensionCommand) SubstituteArgs(params map[string]string) []string {
+	args := make([]string, len(command.Args))
+	for i, arg := range command.Args {
+		args[i] = processArg(arg, params)
+	}
+	return args
+}
+
+// RunCommand runs a command defined by an extension
+func RunCommand(projectPath string, command ExtensionCommand, params map[string]string) error {
+	commandBin, err := command.CommandPath()
+	if err != nil {
+		logr.Errorln("There was a problem with locating the command directory")
+		return err
 	}
+	commandName := filepath.Base(commandBin)
+
+	// check for variable substitution into args
+	command.Args = command.SubstituteArgs(params)
 
 	cmd := exec.Command(commandBin, command.Args...)
 	cmd.Dir = projectPath
@@ -103,11 +146,11 @@ func RunCommand(projectPath string, command ExtensionCommand, params map[string]
 	cmd.Stdout = output
 	cmd.Stderr = output
 	if err := cmd.Start(); err != nil { // after 'Start' the program is continued and script is executing in background
-		log.Println("There was a problem running the command:", commandName)
+		logr.Errorln("There was a problem running the command:", commandName)
 		return err
 	}
-	log.Printf("Please wait while the command runs... %s", output.String())
+	logr.Infof("Please wait while the command runs... %s", output.String())
 	cmd.Wait()
-	log.Println(output.String()) // Wait to finish execution, so we can read all output
+	logr.Debugln(output.String()) // Wait to finish execution, so we can read all output
 	return nil
 }
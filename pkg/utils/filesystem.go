@@ -20,17 +20,16 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
-	"time"
 
+	"github.com/eclipse/codewind-installer/pkg/docker/compose"
 	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/google/go-github/github"
-	"gopkg.in/yaml.v3"
+	logr "github.com/sirupsen/logrus"
 )
 
 // CreateTempFile in the same directory as the binary for docker compose
@@ -49,29 +48,22 @@ func CreateTempFile(filePath string) bool {
 	return false
 }
 
-// WriteToComposeFile the contents of the docker compose yaml
-func WriteToComposeFile(tempFilePath string, debug bool) bool {
-	if tempFilePath == "" {
-		return false
+// WriteComposeFile renders the docker-compose file that starts Codewind and writes it to composeFile
+func WriteComposeFile(composeFile string, images ImageConfig, ports PortConfig, workspace WorkspaceConfig, debug bool) error {
+	if composeFile == "" {
+		return fmt.Errorf("No docker-compose file to write to")
+	}
+	content, err := GenerateComposeFile(images, ports, workspace)
+	if err != nil {
+		return err
 	}
-
-	dataStruct := Compose{}
-
-	unmarshDataErr := yaml.Unmarshal([]byte(data), &dataStruct)
-	errors.CheckErr(unmarshDataErr, 202, "")
-
-	marshalledData, err := yaml.Marshal(&dataStruct)
-	errors.CheckErr(err, 203, "")
 
 	if debug == true {
-		fmt.Printf("==> %s structure is: \n%s\n\n", tempFilePath, string(marshalledData))
+		fmt.Printf("==> %s structure is: \n%s\n\n", composeFile, string(content))
 	} else {
-		fmt.Println("==> environment structure written to " + tempFilePath)
+		fmt.Println("==> environment structure written to " + composeFile)
 	}
-
-	err = ioutil.WriteFile(tempFilePath, marshalledData, 0644)
-	errors.CheckErr(err, 204, "")
-	return true
+	return compose.WriteFile(composeFile, content)
 }
 
 // DeleteTempFile once the the Codewind environment has been created
@@ -88,32 +80,6 @@ func DeleteTempFile(filePath string) (bool, error) {
 	return true, nil
 }
 
-// PingHealth - pings environment api every 15 seconds to check if containers started
-func PingHealth(healthEndpoint string) bool {
-	var started = false
-	fmt.Println("Waiting for Codewind to start")
-	hostname, port := GetPFEHostAndPort()
-	for i := 0; i < 120; i++ {
-		resp, err := http.Get("http://" + hostname + ":" + port + healthEndpoint)
-		if err != nil {
-			fmt.Printf(".")
-		} else {
-			if resp.StatusCode == 200 {
-				fmt.Println("\nHTTP Response Status:", resp.StatusCode, http.StatusText(resp.StatusCode))
-				fmt.Println("Codewind successfully started on http://" + hostname + ":" + port)
-				started = true
-				break
-			}
-		}
-		time.Sleep(1 * time.Second)
-	}
-
-	if started != true {
-		log.Fatal("Codewind containers are taking a while to start. Please check the container logs and/or restart Codewind")
-	}
-	return started
-}
-
 // GetZipURL from github api /repos/:owner/:repo/:archive_format/:ref
 func GetZipURL(owner, repo, branch string) (string, error) {
 	client := github.NewClient(nil)
@@ -140,14 +106,14 @@ func DownloadFile(URL, destination string) error {
 	// Create the file
 	file, err := os.Create(destination)
 	if err != nil {
-		log.Println(err)
+		logr.Errorln(err)
 		return err
 	}
 	defer file.Close()
 
 	// Write body to file
 	_, err = io.Copy(file, resp.Body)
-	log.Printf("Downloaded file from '%s' to '%s'\n", URL, destination)
+	logr.Debugf("Downloaded file from '%s' to '%s'", URL, destination)
 
 	return err
 }
@@ -175,12 +141,10 @@ func UnZip(filePath, destination string) error {
 		}
 
 		if file.FileInfo().IsDir() {
-			// For debug:
-			// fmt.Println("Directory Created:", extractedFilePath)
+			logr.Traceln("Directory Created:", extractedFilePath)
 			os.MkdirAll(extractedFilePath, file.Mode())
 		} else {
-			// For debug:
-			// fmt.Println("File extracted:", file.Name)
+			logr.Traceln("File extracted:", file.Name)
 
 			outputFile, err := os.OpenFile(
 				extractedFilePath,
@@ -194,7 +158,7 @@ func UnZip(filePath, destination string) error {
 			errors.CheckErr(err, 404, "")
 		}
 	}
-	log.Printf("Extracted file from '%s' to '%s'\n", filePath, destination)
+	logr.Debugf("Extracted file from '%s' to '%s'", filePath, destination)
 	return nil
 }
 
@@ -218,25 +182,25 @@ func UnTar(pathToTarFile, destination string) error {
 			break
 		}
 		if err != nil {
-			log.Fatal(err)
+			logr.Fatal(err)
 		}
 		target := filepath.Join(destination, header.Name)
 		switch header.Typeflag {
 		case tar.TypeDir:
 			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				log.Fatal(err)
+				logr.Fatal(err)
 			}
 		case tar.TypeReg:
 			fileToOverwrite, err := overwriteFile(target)
 			defer fileToOverwrite.Close()
 			if err != nil {
-				log.Fatal(err)
+				logr.Fatal(err)
 			}
 			if _, err := io.Copy(fileToOverwrite, tarReader); err != nil {
-				log.Fatal(err)
+				logr.Fatal(err)
 			}
 		default:
-			log.Printf("Can't extract to %s: unknown typeflag %c\n", target, header.Typeflag)
+			logr.Warnf("Can't extract to %s: unknown typeflag %c", target, header.Typeflag)
 		}
 	}
 	return nil
@@ -0,0 +1,20 @@
+//go:build !windows
+// +build !windows
+
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package filewatcher
+
+// longPath : paths of any length can be opened on this platform
+func longPath(path string) string {
+	return path
+}
@@ -0,0 +1,36 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package filewatcher
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// longPathPrefix lets Windows open paths longer than MAX_PATH, which deep node_modules trees often exceed
+const longPathPrefix = `\\?\`
+
+// longPath : the absolute form of path with the long path prefix, so that files below it can be opened however
+// deep they are. UNC paths use the \\?\UNC\ form
+func longPath(path string) string {
+	if strings.HasPrefix(path, longPathPrefix) {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(absPath, `\\`) {
+		return longPathPrefix + `UNC\` + absPath[2:]
+	}
+	return longPathPrefix + absPath
+}
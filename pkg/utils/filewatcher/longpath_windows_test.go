@@ -0,0 +1,78 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package filewatcher
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongPath(t *testing.T) {
+	t.Run("Asserts a drive path gets the long path prefix", func(t *testing.T) {
+		assert.Equal(t, `\\?\C:\projects\node`, longPath(`C:\projects\node`))
+	})
+
+	t.Run("Asserts forward slashes are converted", func(t *testing.T) {
+		assert.Equal(t, `\\?\C:\projects\node`, longPath(`C:/projects/node/`))
+	})
+
+	t.Run("Asserts a UNC path gets the UNC long path prefix", func(t *testing.T) {
+		assert.Equal(t, `\\?\UNC\server\share\node`, longPath(`\\server\share\node`))
+	})
+
+	t.Run("Asserts a prefixed path is unchanged", func(t *testing.T) {
+		assert.Equal(t, `\\?\C:\projects\node`, longPath(`\\?\C:\projects\node`))
+	})
+}
+
+func TestRelativePathWindows(t *testing.T) {
+	relativePath, err := RelativePath(`\\?\C:\projects\node`, `\\?\C:\projects\node\src\routes\index.js`)
+	assert.Nil(t, err)
+	assert.Equal(t, "src/routes/index.js", relativePath)
+
+	_, err = RelativePath(`C:\projects\node`, `D:\projects\node\index.js`)
+	assert.NotNil(t, err)
+}
+
+func TestWalkLongPaths(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "synclong")
+	defer os.RemoveAll(longPath(projectPath))
+
+	// Nest directories until the file's path is well over MAX_PATH
+	deepDir := projectPath
+	var segments []string
+	for len(deepDir) < 300 {
+		segment := strings.Repeat("d", 40)
+		segments = append(segments, segment)
+		deepDir = filepath.Join(deepDir, segment)
+	}
+	err := os.MkdirAll(longPath(deepDir), 0777)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(longPath(filepath.Join(deepDir, "index.js")), []byte("deep"), 0644)
+	assert.Nil(t, err)
+
+	walked := map[string]string{}
+	err = Walk(context.Background(), projectPath, Options{}, func(file File) error {
+		content, _ := ioutil.ReadFile(file.Path)
+		walked[file.RelativePath] = string(content)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{strings.Join(segments, "/") + "/index.js": "deep"}, walked)
+}
@@ -0,0 +1,177 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package filewatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SymlinkPolicy : what a walk does with the symbolic links it finds
+type SymlinkPolicy int
+
+const (
+	// SymlinksWithinRoot finds a link to a file within the root as that file, and skips every other link
+	SymlinksWithinRoot SymlinkPolicy = iota
+	// SymlinksSkip skips every link
+	SymlinksSkip
+	// SymlinksFollow finds a link to a file anywhere as that file, and skips links to directories, which could loop
+	SymlinksFollow
+)
+
+// ErrTooManyFiles is returned by Walk when the root holds more files than Options.MaxFiles
+var ErrTooManyFiles = errors.New("the directory holds too many files")
+
+// Options : which files a walk finds
+type Options struct {
+	// IgnoredFiles and IgnoredDirectories are filepath.Match patterns of the names of files and directories to skip
+	IgnoredFiles       []string
+	IgnoredDirectories []string
+	Symlinks           SymlinkPolicy
+	// MaxDepth is how many levels of directories below the root are entered, 0 for no limit
+	MaxDepth int
+	// MaxFiles is the most files found before the walk fails with ErrTooManyFiles, 0 for no limit
+	MaxFiles int
+	// OnSkip is told the relative path of each entry which is skipped because it cannot be read, is a link the
+	// policy does not follow, or is too deep, when it is set
+	OnSkip func(path string, reason error)
+}
+
+// File : a file found by a walk
+type File struct {
+	// Path is where to read the file, which is the target of a followed link
+	Path string
+	// RelativePath is the path of the file, or of the link to it, relative to the root with forward slashes
+	RelativePath string
+	Info         os.FileInfo
+}
+
+// Ignores : true when a file or directory of this name is skipped
+func (options Options) Ignores(name string, isDir bool) bool {
+	patterns := options.IgnoredFiles
+	if isDir {
+		patterns = options.IgnoredDirectories
+	}
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Walk : calls found with each file below root which options do not skip, in lexical order. The walk stops with
+// the context's error once it is cancelled, with ErrTooManyFiles once MaxFiles is exceeded, or with the first error
+// found returns. Entries which cannot be read are skipped, only an unreadable root fails the walk
+func Walk(ctx context.Context, root string, options Options, found func(File) error) error {
+	walkRoot := longPath(root)
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	count := 0
+	return filepath.Walk(walkRoot, func(path string, info os.FileInfo, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			if path == walkRoot {
+				return err
+			}
+			if relativePath, relErr := RelativePath(walkRoot, path); relErr == nil {
+				path = relativePath
+			}
+			options.skip(path, err)
+			return nil
+		}
+		relativePath, err := RelativePath(walkRoot, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == walkRoot {
+				return nil
+			}
+			if options.Ignores(info.Name(), true) {
+				return filepath.SkipDir
+			}
+			if options.MaxDepth > 0 && strings.Count(relativePath, "/")+1 > options.MaxDepth {
+				options.skip(relativePath, fmt.Errorf("it is more than %d directories deep", options.MaxDepth))
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if options.Ignores(info.Name(), false) {
+			return nil
+		}
+		file := File{Path: path, RelativePath: relativePath, Info: info}
+		if info.Mode()&os.ModeSymlink != 0 {
+			file.Path, file.Info, err = options.resolveSymlink(path, resolvedRoot)
+			if err != nil {
+				options.skip(relativePath, err)
+				return nil
+			}
+		}
+		count++
+		if options.MaxFiles > 0 && count > options.MaxFiles {
+			return ErrTooManyFiles
+		}
+		return found(file)
+	})
+}
+
+// RelativePath : the path of a file relative to root, with forward slashes whatever the platform
+func RelativePath(root string, path string) (string, error) {
+	relativePath, err := filepath.Rel(root, path)
+	if err != nil {
+		return "", err
+	}
+	if relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%v is not within %v", path, root)
+	}
+	return filepath.ToSlash(relativePath), nil
+}
+
+func (options Options) skip(path string, reason error) {
+	if options.OnSkip != nil {
+		options.OnSkip(path, reason)
+	}
+}
+
+// resolveSymlink : the file a symbolic link points to, which must be a regular file, and within resolvedRoot
+// unless the policy follows links anywhere, so that links cannot pull files from elsewhere on the machine
+func (options Options) resolveSymlink(link string, resolvedRoot string) (string, os.FileInfo, error) {
+	if options.Symlinks == SymlinksSkip {
+		return "", nil, errors.New("it is a symbolic link")
+	}
+	target, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return "", nil, err
+	}
+	if options.Symlinks == SymlinksWithinRoot {
+		if _, err := RelativePath(resolvedRoot, target); err != nil {
+			return "", nil, errors.New("it is a symbolic link to outside the root")
+		}
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return "", nil, errors.New("it is a symbolic link to something other than a file")
+	}
+	return target, info, nil
+}
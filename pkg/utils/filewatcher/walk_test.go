@@ -0,0 +1,194 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package filewatcher
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTree : creates the files, given by their paths relative to root with forward slashes
+func writeTree(t *testing.T, root string, files ...string) {
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(file), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// walkPaths : the relative paths of the files a walk finds, and of those it skips
+func walkPaths(t *testing.T, root string, options Options) ([]string, []string, error) {
+	found := []string{}
+	skipped := []string{}
+	options.OnSkip = func(path string, reason error) {
+		skipped = append(skipped, path)
+	}
+	err := Walk(context.Background(), root, options, func(file File) error {
+		found = append(found, file.RelativePath)
+		return nil
+	})
+	return found, skipped, err
+}
+
+func TestWalk(t *testing.T) {
+	root, _ := ioutil.TempDir("", "walk")
+	defer os.RemoveAll(root)
+	writeTree(t, root, "package.json", "src/app.js", "src/routes/index.js", "node_modules/express/index.js", "app.swp")
+
+	t.Run("Asserts every file is found with a forward slash path", func(t *testing.T) {
+		found, _, err := walkPaths(t, root, Options{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app.swp", "node_modules/express/index.js", "package.json", "src/app.js", "src/routes/index.js"}, found)
+	})
+
+	t.Run("Asserts ignored files and directories are skipped", func(t *testing.T) {
+		found, _, err := walkPaths(t, root, Options{IgnoredFiles: []string{"*.swp"}, IgnoredDirectories: []string{"node_modules*"}})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"package.json", "src/app.js", "src/routes/index.js"}, found)
+	})
+
+	t.Run("Asserts directories deeper than the maximum depth are skipped", func(t *testing.T) {
+		found, skipped, err := walkPaths(t, root, Options{MaxDepth: 1})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"app.swp", "package.json", "src/app.js"}, found)
+		assert.Equal(t, []string{"node_modules/express", "src/routes"}, skipped)
+	})
+
+	t.Run("Asserts the walk fails when there are more files than the maximum", func(t *testing.T) {
+		_, _, err := walkPaths(t, root, Options{MaxFiles: 4})
+		assert.Equal(t, ErrTooManyFiles, err)
+		_, _, err = walkPaths(t, root, Options{MaxFiles: 5})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Asserts a cancelled walk stops", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		found := []string{}
+		err := Walk(ctx, root, Options{}, func(file File) error {
+			found = append(found, file.RelativePath)
+			cancel()
+			return nil
+		})
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, []string{"app.swp"}, found)
+	})
+
+	t.Run("Asserts a missing root fails the walk", func(t *testing.T) {
+		_, _, err := walkPaths(t, filepath.Join(root, "missing"), Options{})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestWalkSymlinks(t *testing.T) {
+	parent, _ := ioutil.TempDir("", "walksymlinks")
+	defer os.RemoveAll(parent)
+	root := filepath.Join(parent, "project")
+	writeTree(t, root, "src/app.js")
+	writeTree(t, parent, "secret.txt")
+
+	// Creating symbolic links needs extra privileges on Windows
+	if err := os.Symlink(filepath.Join(root, "src", "app.js"), filepath.Join(root, "link.js")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	os.Symlink(filepath.Join(parent, "secret.txt"), filepath.Join(root, "secret.txt"))
+	os.Symlink(filepath.Join(root, "src"), filepath.Join(root, "srclink"))
+	os.Symlink(filepath.Join(root, "missing.js"), filepath.Join(root, "broken.js"))
+
+	tests := map[string]struct {
+		policy  SymlinkPolicy
+		found   []string
+		skipped []string
+	}{
+		"links within the root": {
+			policy:  SymlinksWithinRoot,
+			found:   []string{"link.js", "src/app.js"},
+			skipped: []string{"broken.js", "secret.txt", "srclink"},
+		},
+		"no links": {
+			policy:  SymlinksSkip,
+			found:   []string{"src/app.js"},
+			skipped: []string{"broken.js", "link.js", "secret.txt", "srclink"},
+		},
+		"links anywhere": {
+			policy:  SymlinksFollow,
+			found:   []string{"link.js", "secret.txt", "src/app.js"},
+			skipped: []string{"broken.js", "srclink"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			found, skipped, err := walkPaths(t, root, Options{Symlinks: test.policy})
+			assert.Nil(t, err)
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.skipped, skipped)
+		})
+	}
+
+	t.Run("Asserts a followed link is read from its target", func(t *testing.T) {
+		var linked File
+		Walk(context.Background(), root, Options{}, func(file File) error {
+			if file.RelativePath == "link.js" {
+				linked = file
+			}
+			return nil
+		})
+		content, _ := ioutil.ReadFile(linked.Path)
+		assert.Equal(t, "src/app.js", string(content))
+		assert.True(t, linked.Info.Mode().IsRegular())
+	})
+}
+
+func TestRelativePath(t *testing.T) {
+	root := filepath.Join("projects", "node")
+	tests := map[string]struct {
+		path     string
+		expected string
+		fails    bool
+	}{
+		"a file in the root": {
+			path:     filepath.Join(root, "package.json"),
+			expected: "package.json",
+		},
+		"a nested file uses forward slashes": {
+			path:     filepath.Join(root, "src", "routes", "index.js"),
+			expected: "src/routes/index.js",
+		},
+		"a file whose name starts with dots": {
+			path:     filepath.Join(root, "..hidden"),
+			expected: "..hidden",
+		},
+		"a file outside the root": {
+			path:  filepath.Join("projects", "other", "package.json"),
+			fails: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			relativePath, err := RelativePath(root+string(filepath.Separator), test.path)
+			if test.fails {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, relativePath)
+		})
+	}
+}
@@ -0,0 +1,237 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+// The kinds of docker resource garbage collection removes, in the order they are removed
+const (
+	GarbageContainer = "container"
+	GarbageImage     = "image"
+	GarbageNetwork   = "network"
+	GarbageVolume    = "volume"
+)
+
+// codewindLabelPrefix starts the labels Codewind builds its images with, which identify them once untagged
+const codewindLabelPrefix = "org.eclipse.codewind"
+
+var (
+	// profileNetworkPattern matches the networks docker-compose creates for the profiles cwctl starts
+	profileNetworkPattern = regexp.MustCompile(`^codewind(-[a-z0-9][a-z0-9-]*)?_network$`)
+	// profileVolumePattern matches the workspace volumes of named profiles, the default profile's is never removed
+	profileVolumePattern = regexp.MustCompile(`^codewind-([a-z0-9][a-z0-9-]*)_cw-workspace$`)
+)
+
+// GarbageItem : A docker resource that Codewind left behind and no longer uses
+type GarbageItem struct {
+	Kind   string `json:"kind"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+	// Size is the size of an image, which may share layers with the images that are kept
+	Size  int64  `json:"size"`
+	Error string `json:"error,omitempty"`
+}
+
+// GarbageOptions : What garbage collection keeps besides the resources containers use
+type GarbageOptions struct {
+	// Images are the configured Codewind images, which are kept for the next start
+	Images ImageConfig
+	// Profiles are the named profiles which still exist, whose workspace volumes are kept
+	Profiles []LocalProfile
+}
+
+// dockerResources : The docker resources garbage is looked for in
+type dockerResources struct {
+	containers []types.Container
+	images     []types.ImageSummary
+	networks   []types.NetworkResource
+	volumes    []*types.Volume
+}
+
+// FindGarbage : The exited project containers, the dangling or old Codewind images no container uses, and the
+// networks and named profile workspace volumes that are no longer used
+func FindGarbage(options GarbageOptions) ([]GarbageItem, error) {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	resources := dockerResources{}
+	resources.containers, err = cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	resources.images, err = cli.ImageList(ctx, types.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	resources.networks, err = cli.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	volumes, err := cli.VolumeList(ctx, filters.NewArgs())
+	if err != nil {
+		return nil, err
+	}
+	resources.volumes = volumes.Volumes
+	return findGarbage(resources, options), nil
+}
+
+// findGarbage : The garbage among the resources. Containers which are garbage do not keep the images, networks
+// or volumes they use, as they are removed first
+func findGarbage(resources dockerResources, options GarbageOptions) []GarbageItem {
+	garbage := []GarbageItem{}
+	usedImages := map[string]bool{}
+	usedNetworks := map[string]bool{}
+	usedVolumes := map[string]bool{}
+	for _, container := range resources.containers {
+		if isExitedProjectContainer(container) {
+			garbage = append(garbage, GarbageItem{Kind: GarbageContainer, ID: container.ID, Name: containerName(container), Reason: "exited project container"})
+			continue
+		}
+		usedImages[container.ImageID] = true
+		if container.NetworkSettings != nil {
+			for name := range container.NetworkSettings.Networks {
+				usedNetworks[name] = true
+			}
+		}
+		for _, mount := range container.Mounts {
+			usedVolumes[mount.Name] = true
+		}
+	}
+
+	for _, image := range resources.images {
+		if usedImages[image.ID] {
+			continue
+		}
+		if reason := imageGarbageReason(image, options.Images); reason != "" {
+			garbage = append(garbage, GarbageItem{Kind: GarbageImage, ID: image.ID, Name: imageName(image), Reason: reason, Size: image.Size})
+		}
+	}
+
+	for _, network := range resources.networks {
+		if profileNetworkPattern.MatchString(network.Name) && !usedNetworks[network.Name] {
+			garbage = append(garbage, GarbageItem{Kind: GarbageNetwork, ID: network.ID, Name: network.Name, Reason: "unused Codewind network"})
+		}
+	}
+
+	profiles := map[string]bool{}
+	for _, profile := range options.Profiles {
+		profiles[profile.Name] = true
+	}
+	for _, volume := range resources.volumes {
+		match := profileVolumePattern.FindStringSubmatch(volume.Name)
+		if match != nil && !profiles[match[1]] && !usedVolumes[volume.Name] {
+			garbage = append(garbage, GarbageItem{Kind: GarbageVolume, ID: volume.Name, Name: volume.Name, Reason: "workspace volume of removed profile " + match[1]})
+		}
+	}
+	return garbage
+}
+
+// isExitedProjectContainer : true for an application container of a project which is no longer running
+func isExitedProjectContainer(container types.Container) bool {
+	switch container.State {
+	case "exited", "dead", "created":
+	default:
+		return false
+	}
+	return strings.HasPrefix(containerName(container), "cw-") || strings.HasPrefix(container.Image, "cw-")
+}
+
+// imageGarbageReason : Why an image that no container uses is garbage, or empty when it is kept. Untagged
+// Codewind images are left behind by rebuilds and upgrades, and the core images of other tags by upgrades
+func imageGarbageReason(image types.ImageSummary, images ImageConfig) string {
+	tags := []string{}
+	for _, tag := range image.RepoTags {
+		if tag != "<none>:<none>" {
+			tags = append(tags, tag)
+		}
+	}
+	kind := codewindImageKind(append(append([]string{}, tags...), image.RepoDigests...))
+	isLabelled := false
+	for label := range image.Labels {
+		isLabelled = isLabelled || strings.HasPrefix(label, codewindLabelPrefix)
+	}
+	if kind == "" && !isLabelled {
+		return ""
+	}
+	if len(tags) == 0 {
+		for _, digest := range image.RepoDigests {
+			if (images.PFEDigest != "" && strings.HasSuffix(digest, "@"+images.PFEDigest)) ||
+				(images.PerformanceDigest != "" && strings.HasSuffix(digest, "@"+images.PerformanceDigest)) {
+				return ""
+			}
+		}
+		return "dangling Codewind image"
+	}
+	if kind != "core" {
+		return ""
+	}
+	for _, tag := range tags {
+		if strings.HasSuffix(tag, ":"+images.Tag) {
+			return ""
+		}
+	}
+	return "Codewind image of another version"
+}
+
+// imageName : The first tag of an image, or its ID when it has none
+func imageName(image types.ImageSummary) string {
+	for _, tag := range image.RepoTags {
+		if tag != "<none>:<none>" {
+			return tag
+		}
+	}
+	return image.ID
+}
+
+// RemoveGarbage : Removes the garbage, containers first so that the images, networks and volumes they used can
+// be removed. Images are removed with all of their tags. The items are returned with the error of each one that could not be removed
+func RemoveGarbage(garbage []GarbageItem) ([]GarbageItem, error) {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	results := []GarbageItem{}
+	for _, kind := range []string{GarbageContainer, GarbageImage, GarbageNetwork, GarbageVolume} {
+		for _, item := range garbage {
+			if item.Kind != kind {
+				continue
+			}
+			switch kind {
+			case GarbageContainer:
+				err = cli.ContainerRemove(ctx, item.ID, types.ContainerRemoveOptions{RemoveVolumes: true})
+			case GarbageImage:
+				_, err = cli.ImageRemove(ctx, item.ID, types.ImageRemoveOptions{Force: true, PruneChildren: true})
+			case GarbageNetwork:
+				err = cli.NetworkRemove(ctx, item.ID)
+			case GarbageVolume:
+				err = cli.VolumeRemove(ctx, item.ID, false)
+			}
+			if err != nil && !client.IsErrNotFound(err) {
+				item.Error = err.Error()
+			}
+			results = append(results, item)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindGarbage(t *testing.T) {
+	pfe := types.Container{
+		ID: "c1", Names: []string{"/codewind-pfe"}, Image: "codewind-pfe-amd64:latest", ImageID: "sha256:pfe", State: "running",
+		NetworkSettings: &types.SummaryNetworkSettings{Networks: map[string]*network.EndpointSettings{"codewind_network": {}}},
+		Mounts:          []types.MountPoint{{Name: "codewind_cw-workspace"}},
+	}
+	resources := dockerResources{
+		containers: []types.Container{
+			pfe,
+			{ID: "c2", Names: []string{"/cw-node-a1"}, Image: "cw-node-a1", ImageID: "sha256:node", State: "exited"},
+			{ID: "c3", Names: []string{"/cw-java-b2"}, Image: "cw-java-b2", ImageID: "sha256:java", State: "running"},
+			{ID: "c4", Names: []string{"/nginx"}, Image: "nginx", ImageID: "sha256:nginx", State: "exited"},
+		},
+		images: []types.ImageSummary{
+			{ID: "sha256:pfe", RepoTags: []string{"codewind-pfe-amd64:latest"}, Size: 900},
+			{ID: "sha256:old", RepoTags: []string{"eclipse/codewind-pfe-amd64:0.5.0", "codewind-pfe-amd64:0.5.0"}, Size: 800},
+			{ID: "sha256:perf", RepoTags: []string{"codewind-performance-amd64:latest"}, Size: 300},
+			{ID: "sha256:dangling", RepoTags: []string{"<none>:<none>"}, RepoDigests: []string{"eclipse/codewind-pfe-amd64@sha256:0a"}, Size: 700},
+			{ID: "sha256:pinned", RepoDigests: []string{"eclipse/codewind-performance-amd64@sha256:0b"}, Size: 200},
+			{ID: "sha256:labelled", Labels: map[string]string{"org.eclipse.codewind.project": "a1"}, Size: 100},
+			{ID: "sha256:node", RepoTags: []string{"cw-node-a1:latest"}, Size: 50},
+			{ID: "sha256:java", RepoTags: []string{"cw-java-b2:latest"}, Size: 60},
+			{ID: "sha256:untagged", Size: 10},
+		},
+		networks: []types.NetworkResource{
+			{ID: "n1", Name: "codewind_network"},
+			{ID: "n2", Name: "codewind-demo_network"},
+			{ID: "n3", Name: "bridge"},
+		},
+		volumes: []*types.Volume{
+			{Name: "codewind_cw-workspace"},
+			{Name: "codewind-demo_cw-workspace"},
+			{Name: "codewind-old_cw-workspace"},
+			{Name: "postgres-data"},
+		},
+	}
+	options := GarbageOptions{
+		Images:   ImageConfig{Tag: "latest", PerformanceDigest: "sha256:0b"},
+		Profiles: []LocalProfile{{Name: "demo"}},
+	}
+
+	garbage := findGarbage(resources, options)
+	found := map[string]string{}
+	for _, item := range garbage {
+		found[item.Kind+" "+item.Name] = item.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"container cw-node-a1":                   "exited project container",
+		"image eclipse/codewind-pfe-amd64:0.5.0": "Codewind image of another version",
+		"image sha256:dangling":                  "dangling Codewind image",
+		"image sha256:labelled":                  "dangling Codewind image",
+		"network codewind-demo_network":          "unused Codewind network",
+		"volume codewind-old_cw-workspace":       "workspace volume of removed profile old",
+	}, found)
+
+	t.Run("Keeps the images of the tag, and the projects' tagged images", func(t *testing.T) {
+		options.Images.Tag = "0.5.0"
+		garbage := findGarbage(resources, options)
+		names := []string{}
+		for _, item := range garbage {
+			if item.Kind == GarbageImage {
+				names = append(names, item.Name)
+			}
+		}
+		assert.Equal(t, []string{"codewind-performance-amd64:latest", "sha256:dangling", "sha256:labelled"}, names)
+	})
+}
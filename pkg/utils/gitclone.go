@@ -0,0 +1,124 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// commitPattern matches an abbreviated or full commit hash
+var commitPattern = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// IsGitURL returns whether a template URL is a git repository to clone rather than an archive to download,
+// which is the case for SSH URLs and for URLs ending in .git
+func IsGitURL(URL string) bool {
+	return strings.HasPrefix(URL, "git@") ||
+		strings.HasPrefix(URL, "ssh://") ||
+		strings.HasPrefix(URL, "git://") ||
+		strings.HasSuffix(strings.TrimSuffix(URL, "/"), ".git")
+}
+
+// CloneGitRepo clones a git repository to a destination without its history or .git directory. The ref may be
+// a branch, a tag or a commit hash, and the default branch is cloned when it is empty. Only the commit needed
+// is fetched, unless the server does not allow fetching a commit by its hash
+func CloneGitRepo(URL string, ref string, destination string) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git must be installed to create a project from a git repository")
+	}
+	parent := filepath.Dir(destination)
+	err := os.MkdirAll(parent, 0755)
+	if err != nil {
+		return err
+	}
+	// Clone beside the destination, so that it can be moved into place without copying
+	cloneDir, err := ioutil.TempDir(parent, ".cwctl-clone-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(cloneDir)
+
+	if commitPattern.MatchString(ref) {
+		err = fetchCommit(URL, ref, cloneDir)
+	} else {
+		args := []string{"clone", "--depth", "1", "--quiet"}
+		if ref != "" {
+			args = append(args, "--branch", ref)
+		}
+		err = runGit("", append(args, "--", URL, cloneDir)...)
+	}
+	if err != nil {
+		return err
+	}
+	err = os.RemoveAll(filepath.Join(cloneDir, ".git"))
+	if err != nil {
+		return err
+	}
+	return moveContents(cloneDir, destination)
+}
+
+func fetchCommit(URL string, commit string, cloneDir string) error {
+	err := runGit(cloneDir, "init", "--quiet")
+	if err != nil {
+		return err
+	}
+	err = runGit(cloneDir, "remote", "add", "origin", URL)
+	if err != nil {
+		return err
+	}
+	if runGit(cloneDir, "fetch", "--depth", "1", "--quiet", "origin", commit) == nil {
+		return runGit(cloneDir, "checkout", "--quiet", "FETCH_HEAD")
+	}
+	// Abbreviated hashes, and servers which only serve advertised refs, need the full history
+	err = runGit(cloneDir, "fetch", "--quiet", "origin")
+	if err != nil {
+		return err
+	}
+	return runGit(cloneDir, "checkout", "--quiet", commit)
+}
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Fail rather than wait for credentials, which cwctl cannot be asked for
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("git %v failed: %v", args[0], strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// moveContents : moves the entries of a directory into the destination, which is created if needed
+func moveContents(source string, destination string) error {
+	err := os.MkdirAll(destination, 0755)
+	if err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(source)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		err = os.Rename(filepath.Join(source, entry.Name()), filepath.Join(destination, entry.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsGitURL(t *testing.T) {
+	assert.True(t, IsGitURL("git@github.com:eclipse/codewind-installer.git"))
+	assert.True(t, IsGitURL("https://github.com/eclipse/codewind-installer.git"))
+	assert.True(t, IsGitURL("ssh://git@example.com/templates"))
+	assert.False(t, IsGitURL(exampleGitURL))
+	assert.False(t, IsGitURL(exampleTarGzURL))
+}
+
+func TestCloneGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	workDir, _ := ioutil.TempDir("", "cwctl-gitclone")
+	defer os.RemoveAll(workDir)
+
+	// A repository whose master has moved on from the tag, with a branch of its own
+	repoDir := filepath.Join(workDir, "template")
+	os.MkdirAll(repoDir, 0755)
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=cwctl", "-c", "user.email=cwctl@example.com"}, args...)...)
+		cmd.Dir = repoDir
+		output, err := cmd.CombinedOutput()
+		assert.Nil(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+	commit := func(content string) string {
+		ioutil.WriteFile(filepath.Join(repoDir, "version.txt"), []byte(content), 0644)
+		git("add", "version.txt")
+		git("commit", "--quiet", "-m", content)
+		return git("rev-parse", "HEAD")
+	}
+	git("init", "--quiet")
+	git("checkout", "--quiet", "-b", "master")
+	firstCommit := commit("1")
+	git("tag", "v1")
+	commit("2")
+	git("checkout", "--quiet", "-b", "feature")
+	commit("feature")
+	git("checkout", "--quiet", "master")
+	repoURL := "file://" + filepath.ToSlash(repoDir)
+
+	tests := map[string]struct {
+		ref     string
+		content string
+	}{
+		"clones the default branch": {"", "2"},
+		"clones a branch":           {"feature", "feature"},
+		"clones a tag":              {"v1", "1"},
+		"checks out a commit":       {firstCommit, "1"},
+		"checks out a short commit": {firstCommit[:7], "1"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			destination := filepath.Join(workDir, "projects", strings.Replace(name, " ", "-", -1))
+			err := CloneGitRepo(repoURL, test.ref, destination)
+			assert.Nil(t, err)
+			content, _ := ioutil.ReadFile(filepath.Join(destination, "version.txt"))
+			assert.Equal(t, test.content, string(content))
+			assert.False(t, PathExists(filepath.Join(destination, ".git")))
+		})
+	}
+
+	t.Run("reports an unknown ref", func(t *testing.T) {
+		err := CloneGitRepo(repoURL, "no-such-branch", filepath.Join(workDir, "projects", "missing"))
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,162 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// The GitHub API and archive hosts, which tests replace
+var (
+	githubAPIURL      = "https://api.github.com"
+	githubCodeloadURL = "https://codeload.github.com"
+)
+
+// GitHubRepo : A GitHub repository a project URL points to, with the branch, tag or commit the URL names
+type GitHubRepo struct {
+	Owner string
+	Name  string
+	Ref   string
+}
+
+// ParseGitHubRepoURL : The repository of a GitHub repository page URL, https://github.com/<owner>/<repo>,
+// optionally of a branch, tag or commit as https://github.com/<owner>/<repo>/tree/<ref>. nil for any other URL,
+// including clone URLs ending in .git, which are cloned instead
+func ParseGitHubRepoURL(repoURL string) *GitHubRepo {
+	parsed, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		return nil
+	}
+	host := strings.ToLower(parsed.Host)
+	if host != "github.com" && host != "www.github.com" {
+		return nil
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" || strings.HasSuffix(parts[1], ".git") {
+		return nil
+	}
+	repo := GitHubRepo{Owner: parts[0], Name: parts[1]}
+	switch {
+	case len(parts) == 2:
+	case len(parts) > 3 && parts[2] == "tree":
+		repo.Ref = strings.Join(parts[3:], "/")
+	default:
+		return nil
+	}
+	return &repo
+}
+
+// DownloadGitHubRepo : Downloads the archive of a GitHub repository and extracts it to destination. The ref given
+// takes precedence over the one in the repository URL, and the default branch is downloaded when there is neither.
+// With a personal access token, private repositories are downloaded through the GitHub API
+func DownloadGitHubRepo(httpClient HTTPClient, repo GitHubRepo, ref string, token string, destination string) error {
+	if ref == "" {
+		ref = repo.Ref
+	}
+	if ref == "" {
+		defaultBranch, err := getGitHubDefaultBranch(httpClient, repo, token)
+		if err != nil {
+			return err
+		}
+		ref = defaultBranch
+	}
+
+	archiveURL := githubCodeloadURL + "/" + repo.Owner + "/" + repo.Name + "/zip/" + ref
+	if token != "" {
+		archiveURL = githubAPIURL + "/repos/" + repo.Owner + "/" + repo.Name + "/zipball/" + ref
+	}
+	tempFile, err := ioutil.TempFile("", "cwctl-github-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	err = downloadGitHubArchive(httpClient, archiveURL, token, tempFile)
+	tempFile.Close()
+	if err != nil {
+		return fmt.Errorf("Unable to download %v/%v at %v: %v", repo.Owner, repo.Name, ref, err)
+	}
+
+	err = os.MkdirAll(destination, 0755)
+	if err != nil {
+		return err
+	}
+	return UnZip(tempFile.Name(), destination)
+}
+
+// getGitHubDefaultBranch : the default branch of a repository, from the GitHub API
+func getGitHubDefaultBranch(httpClient HTTPClient, repo GitHubRepo, token string) (string, error) {
+	req, err := newGitHubRequest(githubAPIURL+"/repos/"+repo.Owner+"/"+repo.Name, token)
+	if err != nil {
+		return "", err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Unable to find the default branch of %v/%v: %v", repo.Owner, repo.Name, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound && token == "" {
+		return "", fmt.Errorf("GitHub repository %v/%v was not found, if it is private give a personal access token with --github-token or GITHUB_TOKEN", repo.Owner, repo.Name)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Unable to find the default branch of %v/%v, GitHub responded with status code %d, give the branch with --ref", repo.Owner, repo.Name, res.StatusCode)
+	}
+	var body struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil || body.DefaultBranch == "" {
+		return "", fmt.Errorf("Unable to find the default branch of %v/%v, give the branch with --ref", repo.Owner, repo.Name)
+	}
+	return body.DefaultBranch, nil
+}
+
+func downloadGitHubArchive(httpClient HTTPClient, archiveURL string, token string, file io.Writer) error {
+	req, err := newGitHubRequest(archiveURL, token)
+	if err != nil {
+		return err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		if token == "" {
+			return fmt.Errorf("it was not found, if the repository is private give a personal access token with --github-token or GITHUB_TOKEN")
+		}
+		return fmt.Errorf("it was not found, or the token cannot read the repository")
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GitHub responded with status code %d", res.StatusCode)
+	}
+	_, err = io.Copy(file, res.Body)
+	return err
+}
+
+// newGitHubRequest : a GET request, authenticated with the personal access token when there is one
+func newGitHubRequest(requestURL string, token string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "token "+token)
+	}
+	return req, nil
+}
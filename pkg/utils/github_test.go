@@ -0,0 +1,91 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseGitHubRepoURL(t *testing.T) {
+	assert.Equal(t, &GitHubRepo{Owner: "eclipse", Name: "codewind"}, ParseGitHubRepoURL("https://github.com/eclipse/codewind"))
+	assert.Equal(t, &GitHubRepo{Owner: "eclipse", Name: "codewind"}, ParseGitHubRepoURL("https://www.github.com/eclipse/codewind/"))
+	assert.Equal(t, &GitHubRepo{Owner: "eclipse", Name: "codewind", Ref: "feature/logs"}, ParseGitHubRepoURL("https://github.com/eclipse/codewind/tree/feature/logs"))
+	assert.Nil(t, ParseGitHubRepoURL("https://github.com/eclipse/codewind.git"))
+	assert.Nil(t, ParseGitHubRepoURL("https://github.com/eclipse/codewind/releases/download/0.7.0/template.zip"))
+	assert.Nil(t, ParseGitHubRepoURL("https://github.com/eclipse"))
+	assert.Nil(t, ParseGitHubRepoURL("https://gitlab.com/eclipse/codewind"))
+	assert.Nil(t, ParseGitHubRepoURL("git@github.com:eclipse/codewind.git"))
+}
+
+func Test_DownloadGitHubRepo(t *testing.T) {
+	var archive bytes.Buffer
+	zipWriter := zip.NewWriter(&archive)
+	file, _ := zipWriter.Create("codewind-main/README.md")
+	file.Write([]byte("# Codewind"))
+	zipWriter.Close()
+
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path+" "+r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/repos/eclipse/codewind":
+			w.Write([]byte(`{"default_branch":"main"}`))
+		case "/eclipse/codewind/zip/main", "/eclipse/codewind/zip/0.7.0", "/repos/eclipse/codewind/zipball/main":
+			w.Write(archive.Bytes())
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(api string, codeload string) { githubAPIURL, githubCodeloadURL = api, codeload }(githubAPIURL, githubCodeloadURL)
+	githubAPIURL, githubCodeloadURL = server.URL, server.URL
+	repo := GitHubRepo{Owner: "eclipse", Name: "codewind"}
+
+	t.Run("Downloads the default branch", func(t *testing.T) {
+		requests = []string{}
+		destination, _ := ioutil.TempDir("", "cwctl-github")
+		defer os.RemoveAll(destination)
+		err := DownloadGitHubRepo(http.DefaultClient, repo, "", "", destination)
+		assert.Nil(t, err)
+		content, _ := ioutil.ReadFile(filepath.Join(destination, "README.md"))
+		assert.Equal(t, "# Codewind", string(content))
+		assert.Equal(t, []string{"/repos/eclipse/codewind ", "/eclipse/codewind/zip/main "}, requests)
+	})
+	t.Run("Downloads the ref given", func(t *testing.T) {
+		requests = []string{}
+		destination, _ := ioutil.TempDir("", "cwctl-github")
+		defer os.RemoveAll(destination)
+		assert.Nil(t, DownloadGitHubRepo(http.DefaultClient, GitHubRepo{Owner: "eclipse", Name: "codewind", Ref: "0.7.0"}, "", "", destination))
+		assert.Equal(t, []string{"/eclipse/codewind/zip/0.7.0 "}, requests)
+	})
+	t.Run("Downloads private repositories through the API with a token", func(t *testing.T) {
+		requests = []string{}
+		destination, _ := ioutil.TempDir("", "cwctl-github")
+		defer os.RemoveAll(destination)
+		assert.Nil(t, DownloadGitHubRepo(http.DefaultClient, repo, "", "secret", destination))
+		assert.Equal(t, []string{"/repos/eclipse/codewind token secret", "/repos/eclipse/codewind/zipball/main token secret"}, requests)
+	})
+	t.Run("Suggests a token for repositories which are not found", func(t *testing.T) {
+		err := DownloadGitHubRepo(http.DefaultClient, GitHubRepo{Owner: "eclipse", Name: "private"}, "", "", os.TempDir())
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "GITHUB_TOKEN")
+	})
+}
@@ -0,0 +1,253 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTracedBody : the number of bytes of each request and response body which are traced
+const maxTracedBody = 4096
+
+// redactedValue replaces the values of secrets in traced URLs and bodies
+const redactedValue = "REDACTED"
+
+// sensitiveKeys : query parameters, form fields and JSON keys containing any of these are redacted
+var sensitiveKeys = []string{"password", "passwd", "secret", "token", "authorization", "apikey", "api_key", "credential", "cookie"}
+
+// textKeyValue : a key and its value in a text body, such as key=value, key: value or "key": "value"
+var textKeyValue = regexp.MustCompile(`([A-Za-z0-9_.-]+)(["']?[ \t]*[:=][ \t]*["']?)([^\s"'&,;<>]+)`)
+
+// textCredentials : bearer and basic credentials, and JSON web tokens, which are secret wherever they appear in a text body
+var textCredentials = regexp.MustCompile(`(?i)\bbearer[ \t]+[A-Za-z0-9._~+/=-]+|\bbasic[ \t]+[A-Za-z0-9+/]{12,}=*|\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// tracingTransport : logs every request sent through the transport it wraps, with its status and duration,
+// and optionally the start of the request and response bodies with their secrets redacted
+type tracingTransport struct {
+	base   http.RoundTripper
+	out    io.Writer
+	bodies bool
+	mutex  sync.Mutex
+	now    func() time.Time
+}
+
+// TraceHTTP : Wraps http.DefaultTransport so that every outbound request is logged to out with its method,
+// URL, status and duration, and with its request and response bodies when bodies is set. Called once the
+// shared transport is in place, so that the requests are traced as they are sent
+func TraceHTTP(out io.Writer, bodies bool) {
+	httpTrace = newTracingTransport(http.DefaultTransport, out, bodies)
+	http.DefaultTransport = httpTrace
+}
+
+// httpTrace : the transport set by TraceHTTP, nil when requests are not traced
+var httpTrace *tracingTransport
+
+// tracedTransport : wraps a transport of its own client in the tracing set by TraceHTTP, if any, so that requests
+// which do not go through http.DefaultTransport are traced too
+func tracedTransport(base http.RoundTripper) http.RoundTripper {
+	if httpTrace == nil {
+		return base
+	}
+	return newTracingTransport(base, httpTrace.out, httpTrace.bodies)
+}
+
+func newTracingTransport(base http.RoundTripper, out io.Writer, bodies bool) *tracingTransport {
+	return &tracingTransport{base: base, out: out, bodies: bodies, now: time.Now}
+}
+
+// RoundTrip : Sends the request through the wrapped transport and logs it. The response body is logged
+// once it is closed, so that streamed responses are not held back
+func (transport *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var sent *tracedBody
+	if transport.bodies && req.Body != nil {
+		// A RoundTripper must not change the request it is given, so send a copy reading through the trace
+		sent = &tracedBody{ReadCloser: req.Body}
+		traced := new(http.Request)
+		*traced = *req
+		traced.Body = sent
+		req = traced
+	}
+	start := transport.now()
+	resp, err := transport.base.RoundTrip(req)
+	duration := transport.now().Sub(start).Round(time.Millisecond)
+	target := req.Method + " " + RedactURL(req.URL)
+	if err != nil {
+		transport.printf("HTTP %s -> error after %s: %s\n", target, duration, err)
+	} else {
+		transport.printf("HTTP %s -> %d (%s)\n", target, resp.StatusCode, duration)
+	}
+	if sent != nil {
+		transport.printBody(">", req.Header.Get("Content-Type"), sent)
+	}
+	if err == nil && transport.bodies && resp.Body != nil {
+		received := &tracedBody{ReadCloser: resp.Body}
+		contentType := resp.Header.Get("Content-Type")
+		received.onClose = func() { transport.printBody("<", contentType, received) }
+		resp.Body = received
+	}
+	return resp, err
+}
+
+func (transport *tracingTransport) printBody(prefix string, contentType string, body *tracedBody) {
+	if body.read == 0 {
+		return
+	}
+	text := RedactBody(contentType, body.captured.Bytes())
+	if body.read > maxTracedBody {
+		text += fmt.Sprintf(" ... (%d more bytes)", body.read-maxTracedBody)
+	}
+	transport.printf("%s %s\n", prefix, text)
+}
+
+func (transport *tracingTransport) printf(format string, args ...interface{}) {
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+	fmt.Fprintf(transport.out, format, args...)
+}
+
+// tracedBody : a body which keeps the start of what is read from it
+type tracedBody struct {
+	io.ReadCloser
+	captured bytes.Buffer
+	read     int
+	onClose  func()
+	closed   bool
+}
+
+func (body *tracedBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	if remaining := maxTracedBody - body.captured.Len(); remaining > 0 {
+		if remaining > n {
+			remaining = n
+		}
+		body.captured.Write(p[:remaining])
+	}
+	body.read += n
+	return n, err
+}
+
+func (body *tracedBody) Close() error {
+	err := body.ReadCloser.Close()
+	if !body.closed && body.onClose != nil {
+		body.onClose()
+	}
+	body.closed = true
+	return err
+}
+
+// RedactURL : The URL with the values of sensitive query parameters and any user password replaced
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	if redacted.User != nil {
+		if _, ok := redacted.User.Password(); ok {
+			redacted.User = url.UserPassword(redacted.User.Username(), redactedValue)
+		}
+	}
+	if redacted.RawQuery != "" {
+		query := redacted.Query()
+		redactValues(query)
+		redacted.RawQuery = query.Encode()
+	}
+	return redacted.String()
+}
+
+// RedactBody : A traced body as text, with the values of sensitive JSON keys and form fields replaced, and in
+// other text the values given to sensitive keys and any bearer tokens. Bodies which are not text are summarised
+// by their size
+func RedactBody(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			redactValues(form)
+			return form.Encode()
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		var document interface{}
+		if json.Unmarshal(body, &document) == nil {
+			redacted, _ := json.Marshal(redactJSON(document))
+			return string(redacted)
+		}
+		// A truncated or invalid document can not be redacted key by key
+		return fmt.Sprintf("<%d bytes of unparsed JSON>", len(body))
+	case strings.HasPrefix(mediaType, "text/"):
+		return redactText(string(body))
+	}
+	if mediaType == "" {
+		mediaType = "unknown content"
+	}
+	return fmt.Sprintf("<%d bytes of %s>", len(body), mediaType)
+}
+
+// redactText : text with the values of sensitive keys, and credentials, replaced. The text is not parsed, so the
+// values are found in any key=value, key: value or "key": "value" form
+func redactText(text string) string {
+	text = textCredentials.ReplaceAllString(text, redactedValue)
+	return textKeyValue.ReplaceAllStringFunc(text, func(match string) string {
+		parts := textKeyValue.FindStringSubmatch(match)
+		if !isSensitiveKey(parts[1]) {
+			return match
+		}
+		return parts[1] + parts[2] + redactedValue
+	})
+}
+
+func redactValues(values url.Values) {
+	for key := range values {
+		if isSensitiveKey(key) {
+			values[key] = []string{redactedValue}
+		}
+	}
+}
+
+func redactJSON(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key, child := range typed {
+			if isSensitiveKey(key) {
+				typed[key] = redactedValue
+			} else {
+				typed[key] = redactJSON(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range typed {
+			typed[i] = redactJSON(child)
+		}
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	lower := strings.ToLower(key)
+	if lower == "code" {
+		// OAuth authorization codes can be exchanged for tokens
+		return true
+	}
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	logr "github.com/sirupsen/logrus"
+)
+
+// Names install tags the Codewind images with, whichever registry they were pulled from. The
+// generated docker-compose file and PFE itself refer to the images by these names
+const (
+	LocalPFEImage         = "codewind-pfe-amd64"
+	LocalPerformanceImage = "codewind-performance-amd64"
+)
+
+// digestPattern matches the content digest of an image, as docker reports it
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ImageConfig : Where the Codewind images are pulled from. When a digest is set the image is pulled and run
+// by its digest, so docker rejects any content that does not match it
+type ImageConfig struct {
+	Registry          string
+	Org               string
+	PFEImage          string
+	PerformanceImage  string
+	Tag               string
+	PFEDigest         string
+	PerformanceDigest string
+	// Architecture replaces the architecture the image names end with, empty to keep the names as they are
+	Architecture string
+}
+
+// DefaultImageConfig returns the Docker Hub images published by the Codewind project
+func DefaultImageConfig() ImageConfig {
+	return ImageConfig{
+		Registry:         "docker.io",
+		Org:              "eclipse",
+		PFEImage:         LocalPFEImage,
+		PerformanceImage: LocalPerformanceImage,
+		Tag:              "latest",
+	}
+}
+
+// Merge returns a copy of the config with any non-empty values of overrides applied
+func (images ImageConfig) Merge(overrides ImageConfig) ImageConfig {
+	merged := images
+	for _, field := range []struct {
+		target *string
+		value  string
+	}{
+		{&merged.Registry, overrides.Registry},
+		{&merged.Org, overrides.Org},
+		{&merged.PFEImage, overrides.PFEImage},
+		{&merged.PerformanceImage, overrides.PerformanceImage},
+		{&merged.Tag, overrides.Tag},
+		{&merged.PFEDigest, overrides.PFEDigest},
+		{&merged.PerformanceDigest, overrides.PerformanceDigest},
+		{&merged.Architecture, overrides.Architecture},
+	} {
+		if strings.TrimSpace(field.value) != "" {
+			*field.target = strings.TrimSpace(field.value)
+		}
+	}
+	return merged
+}
+
+// PFERepository returns the PFE image name for the architecture without a tag, e.g.
+// docker.io/eclipse/codewind-pfe-amd64
+func (images ImageConfig) PFERepository() string {
+	return images.repository(images.PFEImage)
+}
+
+// PerformanceRepository returns the performance image name without a tag
+func (images ImageConfig) PerformanceRepository() string {
+	return images.repository(images.PerformanceImage)
+}
+
+func (images ImageConfig) repository(name string) string {
+	parts := []string{}
+	for _, part := range []string{images.Registry, images.Org, archImageName(name, images.Architecture)} {
+		part = strings.Trim(part, "/")
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// LocalPFEImageName returns the name start runs the PFE image as, e.g. codewind-pfe-amd64:latest
+func (images ImageConfig) LocalPFEImageName() string {
+	return LocalPFEImage + ":" + images.Tag
+}
+
+// LocalPerformanceImageName returns the name start runs the performance image as
+func (images ImageConfig) LocalPerformanceImageName() string {
+	return LocalPerformanceImage + ":" + images.Tag
+}
+
+// PFEPullReference returns the reference install pulls the PFE image by, its digest when one is configured
+// and otherwise its tag
+func (images ImageConfig) PFEPullReference() string {
+	return pullReference(images.PFERepository(), images.Tag, images.PFEDigest)
+}
+
+// PerformancePullReference returns the reference install pulls the performance image by
+func (images ImageConfig) PerformancePullReference() string {
+	return pullReference(images.PerformanceRepository(), images.Tag, images.PerformanceDigest)
+}
+
+// PFERunReference returns the image the generated docker-compose file runs PFE from, pinned to its digest
+// when one is configured
+func (images ImageConfig) PFERunReference() string {
+	if images.PFEDigest != "" {
+		return images.PFEPullReference()
+	}
+	return images.LocalPFEImageName()
+}
+
+// PerformanceRunReference returns the image the generated docker-compose file runs the performance container from
+func (images ImageConfig) PerformanceRunReference() string {
+	if images.PerformanceDigest != "" {
+		return images.PerformancePullReference()
+	}
+	return images.LocalPerformanceImageName()
+}
+
+func pullReference(repository string, tag string, digest string) string {
+	if digest != "" {
+		return repository + "@" + digest
+	}
+	return repository + ":" + tag
+}
+
+// IsValidDigest : checks a digest is the sha256 content digest of an image, e.g. sha256:<64 hex characters>
+func IsValidDigest(digest string) bool {
+	return digestPattern.MatchString(digest)
+}
+
+// RequireDigests returns an error unless both images are pinned to valid digests, for --verify
+func (images ImageConfig) RequireDigests() error {
+	for _, digest := range []struct{ name, value string }{
+		{"PFE", images.PFEDigest},
+		{"performance", images.PerformanceDigest},
+	} {
+		if digest.value == "" {
+			return fmt.Errorf("--verify needs the digest of the %v image, set it with the image digest flags or config keys", digest.name)
+		}
+		if !IsValidDigest(digest.value) {
+			return fmt.Errorf("The digest of the %v image '%v' must be sha256: followed by 64 lower case hex characters", digest.name, digest.value)
+		}
+	}
+	return nil
+}
+
+// VerifyImageDigests checks the local images were pulled by the configured digests, so that start does not run
+// images which were replaced or tampered with since install
+func VerifyImageDigests(images ImageConfig) error {
+	err := images.RequireDigests()
+	if err != nil {
+		return err
+	}
+	imageList := GetImageList()
+	for _, reference := range []string{images.PFEPullReference(), images.PerformancePullReference()} {
+		if !hasImageDigest(imageList, reference) {
+			return fmt.Errorf("No local image matches %v, run install --verify to pull it", reference)
+		}
+	}
+	return nil
+}
+
+func hasImageDigest(imageList []types.ImageSummary, reference string) bool {
+	for _, image := range imageList {
+		for _, repoDigest := range image.RepoDigests {
+			if repoDigest == trimDockerHub(reference) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EnsureLocalImages tags the configured images with the local names start uses when they are
+// missing, for images pulled from a mirror without using install
+func EnsureLocalImages(images ImageConfig) {
+	sources := []string{images.PFEPullReference(), images.PerformancePullReference()}
+	targets := []string{images.LocalPFEImageName(), images.LocalPerformanceImageName()}
+	imageList := GetImageList()
+	for i := range sources {
+		if !hasImageTag(imageList, targets[i]) && (hasImageTag(imageList, sources[i]) || hasImageDigest(imageList, sources[i])) {
+			logr.Debugln("Tagging " + sources[i] + " as " + targets[i])
+			TagImage(sources[i], targets[i])
+		}
+	}
+}
+
+func hasImageTag(imageList []types.ImageSummary, name string) bool {
+	for _, image := range imageList {
+		for _, repoTag := range image.RepoTags {
+			if repoTag == trimDockerHub(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// imageTag returns the tag of an image reference, ignoring any registry port
+func imageTag(reference string) string {
+	index := strings.LastIndex(reference, ":")
+	if index == -1 || strings.Contains(reference[index:], "/") {
+		return ""
+	}
+	return reference[index+1:]
+}
+
+// trimDockerHub removes the Docker Hub registry, which docker omits when listing image names
+func trimDockerHub(repository string) string {
+	return strings.TrimPrefix(repository, "docker.io/")
+}
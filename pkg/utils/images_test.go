@@ -0,0 +1,77 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ImageConfig(t *testing.T) {
+	t.Run("Defaults to the Docker Hub images", func(t *testing.T) {
+		images := DefaultImageConfig()
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe-amd64", images.PFERepository())
+		assert.Equal(t, "docker.io/eclipse/codewind-performance-amd64", images.PerformanceRepository())
+		assert.Equal(t, "codewind-pfe-amd64:latest", images.LocalPFEImageName())
+	})
+
+	t.Run("Overrides only the values given", func(t *testing.T) {
+		images := DefaultImageConfig().Merge(ImageConfig{Registry: "mirror.example.com:5000", Tag: "0.7.0"})
+		assert.Equal(t, "mirror.example.com:5000/eclipse/codewind-pfe-amd64", images.PFERepository())
+		assert.Equal(t, "codewind-performance-amd64:0.7.0", images.LocalPerformanceImageName())
+	})
+
+	t.Run("Omits an empty registry and org", func(t *testing.T) {
+		images := ImageConfig{PFEImage: "pfe", Tag: "dev"}
+		assert.Equal(t, "pfe", images.PFERepository())
+	})
+}
+
+func Test_ImageDigests(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	t.Run("Pulls and runs by tag without a digest", func(t *testing.T) {
+		images := DefaultImageConfig()
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe-amd64:latest", images.PFEPullReference())
+		assert.Equal(t, "codewind-pfe-amd64:latest", images.PFERunReference())
+		assert.NotNil(t, images.RequireDigests())
+	})
+
+	t.Run("Pins an image to its digest", func(t *testing.T) {
+		images := DefaultImageConfig().Merge(ImageConfig{PFEDigest: digest})
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe-amd64@"+digest, images.PFEPullReference())
+		assert.Equal(t, "docker.io/eclipse/codewind-pfe-amd64@"+digest, images.PFERunReference())
+		assert.Equal(t, "codewind-performance-amd64:latest", images.PerformanceRunReference())
+	})
+
+	t.Run("Requires both digests to be valid", func(t *testing.T) {
+		images := DefaultImageConfig().Merge(ImageConfig{PFEDigest: digest, PerformanceDigest: "sha256:abc"})
+		assert.NotNil(t, images.RequireDigests())
+		images.PerformanceDigest = digest
+		assert.Nil(t, images.RequireDigests())
+	})
+
+	t.Run("Matches the digests docker lists", func(t *testing.T) {
+		imageList := []types.ImageSummary{{RepoDigests: []string{"eclipse/codewind-pfe-amd64@" + digest}}}
+		assert.True(t, hasImageDigest(imageList, "docker.io/eclipse/codewind-pfe-amd64@"+digest))
+		assert.False(t, hasImageDigest(imageList, "mirror.example.com/eclipse/codewind-pfe-amd64@"+digest))
+	})
+}
+
+func Test_imageTag(t *testing.T) {
+	assert.Equal(t, "latest", imageTag("codewind-pfe-amd64:latest"))
+	assert.Equal(t, "0.7.0", imageTag("mirror.example.com:5000/eclipse/codewind-pfe-amd64:0.7.0"))
+	assert.Equal(t, "", imageTag("mirror.example.com:5000/eclipse/codewind-pfe-amd64"))
+}
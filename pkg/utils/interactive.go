@@ -0,0 +1,44 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"os"
+
+	"github.com/docker/docker/pkg/term"
+	logr "github.com/sirupsen/logrus"
+)
+
+// nonInteractive is set when cwctl must not prompt or draw progress bars, see SetNonInteractive
+var nonInteractive bool
+
+// SetNonInteractive : Selects non-interactive mode when it is requested, or when stdout is not a terminal, as in
+// CI pipelines and IDE process wrappers. In that mode cwctl never prompts, failing instead, shows docker pulls as
+// plain lines rather than progress bars, and logs without colors or timestamps so that its output can be parsed
+func SetNonInteractive(requested bool) {
+	_, stdoutIsTerminal := term.GetFdInfo(os.Stdout)
+	setNonInteractive(requested || !stdoutIsTerminal)
+}
+
+func setNonInteractive(enabled bool) {
+	nonInteractive = enabled
+	if enabled {
+		logr.SetFormatter(&logr.TextFormatter{DisableColors: true, DisableTimestamp: true})
+	} else {
+		logr.SetFormatter(&logr.TextFormatter{})
+	}
+}
+
+// IsInteractive : false when cwctl must not prompt or draw progress bars
+func IsInteractive() bool {
+	return !nonInteractive
+}
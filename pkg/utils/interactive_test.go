@@ -0,0 +1,41 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"bytes"
+	"testing"
+
+	logr "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SetNonInteractive(t *testing.T) {
+	defer setNonInteractive(false)
+	defer logr.SetOutput(logr.StandardLogger().Out)
+
+	t.Run("Is selected when requested", func(t *testing.T) {
+		SetNonInteractive(true)
+		assert.False(t, IsInteractive())
+	})
+	t.Run("Logs plain lines without timestamps", func(t *testing.T) {
+		setNonInteractive(true)
+		var out bytes.Buffer
+		logr.SetOutput(&out)
+		logr.Warnln("Docker is not running")
+		assert.Equal(t, "level=warning msg=\"Docker is not running\"\n", out.String())
+	})
+	t.Run("Can be turned off again", func(t *testing.T) {
+		setNonInteractive(false)
+		assert.True(t, IsInteractive())
+	})
+}
@@ -0,0 +1,188 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+)
+
+// Routes the API requests to a local PFE take, set by the pfeRoute config key
+const (
+	// PFERoutePublished reaches PFE on the host port docker published it on at start
+	PFERoutePublished = "published"
+	// PFERouteNetwork reaches PFE on its address in the docker network of its containers, which is only routable
+	// from a Linux host running the docker daemon itself
+	PFERouteNetwork = "network"
+)
+
+// PFERoutes : the values of the pfeRoute config key
+var PFERoutes = []string{PFERoutePublished, PFERouteNetwork}
+
+// localPFEClients : the client of the PFE of each profile, kept so its connections are reused between requests
+var localPFEClients = struct {
+	sync.Mutex
+	byProfile map[string]*http.Client
+}{byProfile: map[string]*http.Client{}}
+
+// IsValidPFERoute : checks a pfeRoute is one of PFERoutes
+func IsValidPFERoute(route string) bool {
+	for _, valid := range PFERoutes {
+		if route == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPFERoute : checks the saved pfeRoute can be used on this machine, so that a start does not wait on health
+// checks PFE will never answer
+func CheckPFERoute() error {
+	route := localPFERoute()
+	if !IsValidPFERoute(route) {
+		return fmt.Errorf("pfeRoute '%v' must be one of: %v", route, strings.Join(PFERoutes, ", "))
+	}
+	if route == PFERouteNetwork && !dockerNetworkRoutable() {
+		return errors.New("pfeRoute 'network' needs a Linux host running the docker daemon, use 'published' instead")
+	}
+	return nil
+}
+
+// LocalPFEClient : The client the API requests to the PFE of a local profile are sent with. It never goes through a
+// proxy, and dials the address of PFE chosen by the pfeRoute whatever host the request URL names, so neither a
+// proxy nor DNS can intercept the requests. Requests are traced like those of the default client. On Che, where PFE
+// is reached as localhost, the default client is used
+func LocalPFEClient(profile LocalProfile) *http.Client {
+	if os.Getenv("CHE_API_EXTERNAL") != "" {
+		return http.DefaultClient
+	}
+	localPFEClients.Lock()
+	defer localPFEClients.Unlock()
+	client, ok := localPFEClients.byProfile[profile.Name]
+	if !ok {
+		client = &http.Client{Transport: tracedTransport(newLocalPFETransport(func() (string, error) {
+			return localPFEDialAddress(profile)
+		}))}
+		localPFEClients.byProfile[profile.Name] = client
+	}
+	return client
+}
+
+// newLocalPFETransport : a transport which dials the address returned by dialAddress, and uses no proxy. The
+// address is looked up for the first connection and kept, and looked up again only when dialing it fails, so a
+// restarted PFE is found again without asking docker on every connection
+func newLocalPFETransport(dialAddress func() (string, error)) *http.Transport {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	var resolved struct {
+		sync.Mutex
+		address string
+	}
+	return &http.Transport{
+		Proxy: nil,
+		DialContext: func(ctx context.Context, network string, _ string) (net.Conn, error) {
+			resolved.Lock()
+			address := resolved.address
+			resolved.Unlock()
+			if address != "" {
+				conn, err := dialer.DialContext(ctx, network, address)
+				if err == nil {
+					return conn, nil
+				}
+				logr.Debugf("Unable to dial the local PFE at %v, looking it up again: %v", address, err)
+			}
+			address, err := dialAddress()
+			if err != nil {
+				return nil, err
+			}
+			resolved.Lock()
+			resolved.address = address
+			resolved.Unlock()
+			logr.Debugf("Dialing the local PFE at %v", address)
+			return dialer.DialContext(ctx, network, address)
+		},
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// localPFEDialAddress : the IP address and port of the PFE of a profile on the pfeRoute. A pfeHost which is a
+// hostname is resolved here rather than by a proxy. Docker being unreachable is returned as an error, so that
+// the request fails rather than the process exiting mid-dial
+func localPFEDialAddress(profile LocalProfile) (string, error) {
+	containers, err := listContainers()
+	if err != nil {
+		return "", err
+	}
+	for _, container := range containers {
+		if !profile.Owns(container) || CodewindService(container) != ServicePFE {
+			continue
+		}
+		if localPFERoute() == PFERouteNetwork {
+			return containerNetworkAddress(container, internalPFEPort)
+		}
+		host, port := publishedPFEHostAndPort(container)
+		if port == "" {
+			return "", errors.New("the PFE container has no published port")
+		}
+		if net.ParseIP(host) == nil {
+			addresses, err := net.LookupHost(host)
+			if err != nil {
+				return "", err
+			}
+			host = addresses[0]
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+	return "", errors.New("the PFE container is not running")
+}
+
+// containerNetworkAddress : the address of a port of a container in its docker network
+func containerNetworkAddress(container types.Container, port int) (string, error) {
+	if container.NetworkSettings != nil {
+		for _, network := range container.NetworkSettings.Networks {
+			if network != nil && network.IPAddress != "" {
+				return net.JoinHostPort(network.IPAddress, strconv.Itoa(port)), nil
+			}
+		}
+	}
+	return "", errors.New("the PFE container has no address in a docker network")
+}
+
+// localPFERoute : the saved pfeRoute, published when none is set
+func localPFERoute() string {
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr != nil || cliConfig.PFERoute == "" {
+		return PFERoutePublished
+	}
+	return cliConfig.PFERoute
+}
+
+// dockerNetworkRoutable : whether the docker networks are reachable from this machine, which is so when the docker
+// daemon runs on this Linux host rather than in a VM or on another machine
+func dockerNetworkRoutable() bool {
+	dockerHost := os.Getenv("DOCKER_HOST")
+	return runtime.GOOS == "linux" && (dockerHost == "" || strings.HasPrefix(dockerHost, "unix://"))
+}
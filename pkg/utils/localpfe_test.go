@@ -0,0 +1,104 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidPFERoute(t *testing.T) {
+	assert.True(t, IsValidPFERoute(PFERoutePublished))
+	assert.True(t, IsValidPFERoute(PFERouteNetwork))
+	assert.False(t, IsValidPFERoute("socket"))
+}
+
+func TestLocalPFETransport(t *testing.T) {
+	pfe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ready"))
+	}))
+	defer pfe.Close()
+	pfeAddress := strings.TrimPrefix(pfe.URL, "http://")
+
+	originalProxy := os.Getenv("HTTP_PROXY")
+	defer os.Setenv("HTTP_PROXY", originalProxy)
+	os.Setenv("HTTP_PROXY", "http://127.0.0.1:1")
+
+	t.Run("requests reach PFE without a proxy whatever host they name", func(t *testing.T) {
+		client := &http.Client{Transport: newLocalPFETransport(func() (string, error) { return pfeAddress, nil })}
+		response, err := client.Get("http://codewind.invalid:10000/api/v1/ready")
+		if assert.Nil(t, err) {
+			defer response.Body.Close()
+			assert.Equal(t, http.StatusOK, response.StatusCode)
+		}
+	})
+
+	t.Run("the address is looked up once, and again when it stops answering", func(t *testing.T) {
+		lookups := 0
+		address := pfeAddress
+		transport := newLocalPFETransport(func() (string, error) {
+			lookups++
+			return address, nil
+		})
+		transport.DisableKeepAlives = true
+		client := &http.Client{Transport: transport}
+		for i := 0; i < 2; i++ {
+			response, err := client.Get("http://codewind.invalid:10000/api/v1/ready")
+			if assert.Nil(t, err) {
+				response.Body.Close()
+			}
+		}
+		assert.Equal(t, 1, lookups)
+
+		restarted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ready"))
+		}))
+		defer restarted.Close()
+		address = strings.TrimPrefix(restarted.URL, "http://")
+		pfe.Close()
+		response, err := client.Get("http://codewind.invalid:10000/api/v1/ready")
+		if assert.Nil(t, err) {
+			response.Body.Close()
+		}
+		assert.Equal(t, 2, lookups)
+	})
+
+	t.Run("a PFE which can not be found is an error", func(t *testing.T) {
+		client := &http.Client{Transport: newLocalPFETransport(func() (string, error) { return "", errors.New("the PFE container is not running") })}
+		_, err := client.Get("http://127.0.0.1:10000/api/v1/ready")
+		assert.Contains(t, err.Error(), "not running")
+	})
+}
+
+func TestContainerNetworkAddress(t *testing.T) {
+	t.Run("the address in the docker network is used", func(t *testing.T) {
+		container := types.Container{
+			NetworkSettings: &types.SummaryNetworkSettings{Networks: map[string]*network.EndpointSettings{"codewind_network": {IPAddress: "172.18.0.2"}}},
+		}
+		address, err := containerNetworkAddress(container, internalPFEPort)
+		assert.Nil(t, err)
+		assert.Equal(t, "172.18.0.2:9090", address)
+	})
+
+	t.Run("a container without a network address is an error", func(t *testing.T) {
+		_, err := containerNetworkAddress(types.Container{}, internalPFEPort)
+		assert.NotNil(t, err)
+	})
+}
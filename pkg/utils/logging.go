@@ -0,0 +1,48 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	logr "github.com/sirupsen/logrus"
+)
+
+// DefaultLogLevel is used when no level has been given on the command line or saved as a default
+const DefaultLogLevel = "info"
+
+// LogLevels are the levels accepted by cwctl, ordered from least to most verbose
+var LogLevels = []string{"error", "warn", "info", "debug", "trace"}
+
+// IsValidLogLevel returns whether the given level is one cwctl accepts
+func IsValidLogLevel(level string) bool {
+	for _, logLevel := range LogLevels {
+		if strings.EqualFold(logLevel, level) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLogLevel sets the level for all cwctl log output
+func SetLogLevel(level string) error {
+	if !IsValidLogLevel(level) {
+		return fmt.Errorf("Invalid log level '%s', must be one of: %s", level, strings.Join(LogLevels, ", "))
+	}
+	logrusLevel, err := logr.ParseLevel(strings.ToLower(level))
+	if err != nil {
+		return err
+	}
+	logr.SetLevel(logrusLevel)
+	return nil
+}
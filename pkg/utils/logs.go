@@ -0,0 +1,219 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// The components of Codewind whose logs can be shown, the gatekeeper only runs in remote installs
+const (
+	LogComponentPFE         = "pfe"
+	LogComponentPerformance = "performance"
+	LogComponentGatekeeper  = "gatekeeper"
+)
+
+// LogComponents : every component whose logs can be shown
+var LogComponents = []string{LogComponentPFE, LogComponentPerformance, LogComponentGatekeeper}
+
+// IsLogComponent : true when component is one of LogComponents
+func IsLogComponent(component string) bool {
+	for _, known := range LogComponents {
+		if strings.EqualFold(known, component) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogOptions : which logs to show and how
+type LogOptions struct {
+	// Components are the components to show the logs of, all of them when empty
+	Components []string
+	// Follow keeps streaming new log lines until cwctl is interrupted
+	Follow bool
+	// Since only shows the lines logged within this long, all of them when 0
+	Since time.Duration
+}
+
+// Includes : true when the logs of component are to be shown
+func (options LogOptions) Includes(component string) bool {
+	if len(options.Components) == 0 {
+		return true
+	}
+	for _, included := range options.Components {
+		if strings.EqualFold(included, component) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogStream : the log of one container, named by Name, which Open starts reading. Framed logs are those docker
+// sends of containers without a terminal, with stdout and stderr multiplexed in frames
+type LogStream struct {
+	Name   string
+	Open   func() (io.ReadCloser, error)
+	Framed bool
+}
+
+// CopyLogs : Writes the lines of each log to out, prefixed with the name of its container. The logs are written one
+// after the other, or when following, all at once as their lines arrive
+func CopyLogs(streams []LogStream, follow bool, out io.Writer) error {
+	if len(streams) == 0 {
+		return errors.New("No Codewind containers were found to show the logs of")
+	}
+	var lock sync.Mutex
+	copyLog := func(stream LogStream) error {
+		logs, err := stream.Open()
+		if err != nil {
+			return errors.New("Unable to read the logs of " + stream.Name + ": " + err.Error())
+		}
+		defer logs.Close()
+		w := &prefixWriter{out: out, prefix: "[" + stream.Name + "] ", lock: &lock}
+		if stream.Framed {
+			_, err = stdcopy.StdCopy(w, w, logs)
+		} else {
+			_, err = io.Copy(w, logs)
+		}
+		w.Flush()
+		return err
+	}
+
+	if !follow {
+		for _, stream := range streams {
+			err := copyLog(stream)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	errs := make(chan error, len(streams))
+	for _, stream := range streams {
+		go func(stream LogStream) {
+			errs <- copyLog(stream)
+		}(stream)
+	}
+	var firstErr error
+	for range streams {
+		err := <-errs
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// prefixWriter : Writes whole lines to out with a prefix, so that the lines of logs written at the same time
+// are not interleaved
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	lock   *sync.Mutex
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		end := bytes.IndexByte(w.buf, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+		err := w.writeLine(w.buf[:end+1])
+		w.buf = w.buf[end+1:]
+		if err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush : Writes the last line when the log did not end with a newline
+func (w *prefixWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+	return err
+}
+
+func (w *prefixWriter) writeLine(line []byte) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := w.out.Write(append([]byte(w.prefix), line...))
+	return err
+}
+
+// LocalLogStreams : The logs of the PFE and performance containers of a local Codewind instance, including stopped
+// containers as the log of a container which exited is usually why it did
+func LocalLogStreams(profile LocalProfile, options LogOptions) ([]LogStream, error) {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	logsOptions := types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: options.Follow}
+	if options.Since > 0 {
+		logsOptions.Since = options.Since.String()
+	}
+	streams := []LogStream{}
+	for _, container := range containers {
+		component := containerComponent(container)
+		if component == "" || !options.Includes(component) || !profile.Owns(container) {
+			continue
+		}
+		name := container.ID
+		if len(container.Names) > 0 {
+			name = strings.TrimPrefix(container.Names[0], "/")
+		}
+		id := container.ID
+		inspect, err := cli.ContainerInspect(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, LogStream{
+			Name:   name,
+			Framed: inspect.Config == nil || !inspect.Config.Tty,
+			Open: func() (io.ReadCloser, error) {
+				return cli.ContainerLogs(ctx, id, logsOptions)
+			},
+		})
+	}
+	return streams, nil
+}
+
+// containerComponent : the component a container runs, from its image, or "" when it is not a Codewind container
+func containerComponent(container types.Container) string {
+	switch {
+	case strings.Contains(container.Image, "codewind-pfe"):
+		return LogComponentPFE
+	case strings.Contains(container.Image, "codewind-performance"):
+		return LogComponentPerformance
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LogOptions(t *testing.T) {
+	t.Run("Includes every component by default", func(t *testing.T) {
+		for _, component := range LogComponents {
+			assert.True(t, LogOptions{}.Includes(component))
+		}
+	})
+	t.Run("Includes only the components given", func(t *testing.T) {
+		options := LogOptions{Components: []string{LogComponentPFE}}
+		assert.True(t, options.Includes("PFE"))
+		assert.False(t, options.Includes(LogComponentPerformance))
+	})
+	t.Run("Knows the components", func(t *testing.T) {
+		assert.True(t, IsLogComponent("gatekeeper"))
+		assert.False(t, IsLogComponent("keycloak"))
+	})
+}
+
+func Test_ContainerComponent(t *testing.T) {
+	assert.Equal(t, LogComponentPFE, containerComponent(types.Container{Image: "eclipse/codewind-pfe-amd64:0.7.0"}))
+	assert.Equal(t, LogComponentPerformance, containerComponent(types.Container{Image: "codewind-performance-amd64:latest"}))
+	assert.Equal(t, "", containerComponent(types.Container{Image: "cw-nodeproject"}))
+}
+
+func Test_CopyLogs(t *testing.T) {
+	plain := func(text string) func() (io.ReadCloser, error) {
+		return func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(text)), nil
+		}
+	}
+
+	t.Run("Prefixes each line with its container", func(t *testing.T) {
+		var out bytes.Buffer
+		err := CopyLogs([]LogStream{
+			{Name: "codewind-pfe", Open: plain("Starting\nListening on 9090\n")},
+			{Name: "codewind-performance", Open: plain("Ready")},
+		}, false, &out)
+		assert.Nil(t, err)
+		assert.Equal(t, "[codewind-pfe] Starting\n[codewind-pfe] Listening on 9090\n[codewind-performance] Ready\n", out.String())
+	})
+	t.Run("Reads the frames of logs without a terminal", func(t *testing.T) {
+		var framed bytes.Buffer
+		stdcopy.NewStdWriter(&framed, stdcopy.Stdout).Write([]byte("out\n"))
+		stdcopy.NewStdWriter(&framed, stdcopy.Stderr).Write([]byte("err\n"))
+		var out bytes.Buffer
+		err := CopyLogs([]LogStream{{Name: "codewind-pfe", Open: plain(framed.String()), Framed: true}}, false, &out)
+		assert.Nil(t, err)
+		assert.Equal(t, "[codewind-pfe] out\n[codewind-pfe] err\n", out.String())
+	})
+	t.Run("Keeps the lines of followed logs whole", func(t *testing.T) {
+		var out bytes.Buffer
+		err := CopyLogs([]LogStream{
+			{Name: "a", Open: plain(strings.Repeat("first line\n", 100))},
+			{Name: "b", Open: plain(strings.Repeat("second line\n", 100))},
+		}, true, &out)
+		assert.Nil(t, err)
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		assert.Len(t, lines, 200)
+		for _, line := range lines {
+			assert.Contains(t, []string{"[a] first line", "[b] second line"}, line)
+		}
+	})
+	t.Run("Reports logs which cannot be read", func(t *testing.T) {
+		err := CopyLogs([]LogStream{{Name: "codewind-pfe", Open: func() (io.ReadCloser, error) {
+			return nil, errors.New("container is gone")
+		}}}, false, ioutil.Discard)
+		assert.EqualError(t, err, "Unable to read the logs of codewind-pfe: container is gone")
+	})
+	t.Run("Reports when there are no containers", func(t *testing.T) {
+		assert.NotNil(t, CopyLogs([]LogStream{}, false, ioutil.Discard))
+	})
+}
@@ -0,0 +1,156 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/docker/compose"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+)
+
+// ArtifactDockerCompose is the name of the docker-compose template in a release manifest
+const ArtifactDockerCompose = "docker-compose.yaml"
+
+// embeddedArtifacts are the copies built into cwctl, used when no release manifest is configured or it cannot be reached
+var embeddedArtifacts = map[string]string{
+	ArtifactDockerCompose: compose.Template,
+}
+
+// ReleaseManifest : The artifacts of a Codewind release, with the checksums they must match
+type ReleaseManifest struct {
+	Version   string                      `json:"version"`
+	Artifacts map[string]ManifestArtifact `json:"artifacts"`
+}
+
+// ManifestArtifact : Where to download an artifact, and its hex encoded SHA-256 checksum
+type ManifestArtifact struct {
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// SignedManifest : A release manifest as published, the base64 encoded manifest JSON and its base64 encoded ed25519 signature
+type SignedManifest struct {
+	Manifest  string `json:"manifest"`
+	Signature string `json:"signature"`
+}
+
+// ManifestVerificationError : A release manifest or artifact did not match its signature or checksum. Unlike
+// a network failure this is never answered with the embedded copy, as it may mean a download was tampered with
+type ManifestVerificationError struct {
+	Desc string
+}
+
+func (mve *ManifestVerificationError) Error() string {
+	return mve.Desc
+}
+
+// GetArtifact : Returns a release artifact from the release manifest saved as releaseManifest, verifying the
+// manifest against manifestPublicKey and the artifact against its checksum. The copy embedded in cwctl is
+// returned when no manifest is configured, or when the manifest or artifact cannot be downloaded
+func GetArtifact(httpClient HTTPClient, name string) ([]byte, error) {
+	embedded, ok := embeddedArtifacts[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown release artifact '%v'", name)
+	}
+	cliConfig, configErr := cliconfig.LoadConfig()
+	if configErr != nil {
+		return nil, configErr
+	}
+	if cliConfig.ReleaseManifest == "" {
+		return []byte(embedded), nil
+	}
+
+	manifest, err := FetchReleaseManifest(httpClient, cliConfig.ReleaseManifest, cliConfig.ManifestPublicKey)
+	if _, untrusted := err.(*ManifestVerificationError); untrusted {
+		return nil, err
+	}
+	if err != nil {
+		logr.Warnf("Unable to download the release manifest, using the %v built into cwctl: %v\n", name, err)
+		return []byte(embedded), nil
+	}
+	artifact, ok := manifest.Artifacts[name]
+	if !ok {
+		logr.Debugf("Release manifest %v does not list %v, using the copy built into cwctl", manifest.Version, name)
+		return []byte(embedded), nil
+	}
+	body, err := downloadArtifact(httpClient, artifact.URL)
+	if err != nil {
+		logr.Warnf("Unable to download %v, using the copy built into cwctl: %v\n", name, err)
+		return []byte(embedded), nil
+	}
+	checksum := sha256.Sum256(body)
+	if !strings.EqualFold(hex.EncodeToString(checksum[:]), artifact.SHA256) {
+		return nil, &ManifestVerificationError{"The checksum of " + artifact.URL + " does not match release manifest " + manifest.Version}
+	}
+	logr.Debugf("Using %v from release manifest %v", name, manifest.Version)
+	return body, nil
+}
+
+// FetchReleaseManifest : Downloads a signed release manifest and checks its signature with a base64 encoded ed25519 public key
+func FetchReleaseManifest(httpClient HTTPClient, manifestURL string, publicKey string) (*ReleaseManifest, error) {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if publicKey == "" || err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, &ManifestVerificationError{"manifestPublicKey must be set to the base64 encoded ed25519 key that signs the release manifest"}
+	}
+	body, err := downloadArtifact(httpClient, manifestURL)
+	if err != nil {
+		return nil, err
+	}
+	return VerifyReleaseManifest(body, ed25519.PublicKey(key))
+}
+
+// VerifyReleaseManifest : Parses a signed release manifest, returning the manifest only if its signature is valid
+func VerifyReleaseManifest(body []byte, publicKey ed25519.PublicKey) (*ReleaseManifest, error) {
+	signed := SignedManifest{}
+	if err := json.Unmarshal(body, &signed); err != nil {
+		return nil, &ManifestVerificationError{"Unable to parse the release manifest: " + err.Error()}
+	}
+	manifestJSON, err := base64.StdEncoding.DecodeString(signed.Manifest)
+	if err != nil {
+		return nil, &ManifestVerificationError{"Unable to decode the release manifest: " + err.Error()}
+	}
+	signature, err := base64.StdEncoding.DecodeString(signed.Signature)
+	if err != nil || !ed25519.Verify(publicKey, manifestJSON, signature) {
+		return nil, &ManifestVerificationError{"The release manifest signature is not valid"}
+	}
+	manifest := ReleaseManifest{}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, &ManifestVerificationError{"Unable to parse the release manifest: " + err.Error()}
+	}
+	return &manifest, nil
+}
+
+func downloadArtifact(httpClient HTTPClient, url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%v responded with status code %d", url, res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
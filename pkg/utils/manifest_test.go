@@ -0,0 +1,66 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+func signManifest(t *testing.T, privateKey ed25519.PrivateKey, manifest ReleaseManifest) []byte {
+	manifestJSON, _ := json.Marshal(manifest)
+	signed, err := json.Marshal(SignedManifest{
+		Manifest:  base64.StdEncoding.EncodeToString(manifestJSON),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, manifestJSON)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyReleaseManifest(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherPublicKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	manifest := ReleaseManifest{
+		Version:   "0.6.0",
+		Artifacts: map[string]ManifestArtifact{ArtifactDockerCompose: {URL: "https://example.com/compose.yaml", SHA256: "abc"}},
+	}
+	signed := signManifest(t, privateKey, manifest)
+
+	t.Run("Accepts a manifest signed by the key", func(t *testing.T) {
+		verified, err := VerifyReleaseManifest(signed, publicKey)
+		assert.Nil(t, err)
+		assert.Equal(t, manifest, *verified)
+	})
+
+	t.Run("Rejects a manifest signed by another key", func(t *testing.T) {
+		_, err := VerifyReleaseManifest(signed, otherPublicKey)
+		assert.IsType(t, &ManifestVerificationError{}, err)
+	})
+
+	t.Run("Rejects a manifest changed after signing", func(t *testing.T) {
+		tampered := SignedManifest{}
+		json.Unmarshal(signed, &tampered)
+		manifest.Artifacts[ArtifactDockerCompose] = ManifestArtifact{URL: "https://attacker.example.com/compose.yaml", SHA256: "abc"}
+		manifestJSON, _ := json.Marshal(manifest)
+		tampered.Manifest = base64.StdEncoding.EncodeToString(manifestJSON)
+		body, _ := json.Marshal(tampered)
+		_, err := VerifyReleaseManifest(body, publicKey)
+		assert.IsType(t, &ManifestVerificationError{}, err)
+	})
+}
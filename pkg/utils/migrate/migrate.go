@@ -0,0 +1,193 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Step : A migration of a persisted file to the next schema version. Migrate changes the decoded JSON document in place
+type Step struct {
+	Description string
+	Migrate     func(document map[string]interface{}) error
+}
+
+// Schema : The schema versions of a persisted file. Steps[i] migrates a document from version i to version i+1,
+// so steps must only ever be appended, and the current version is the number of steps
+type Schema struct {
+	Name         string
+	VersionField string
+	Steps        []Step
+}
+
+// Version : The current schema version, which files are written at
+func (schema Schema) Version() int {
+	return len(schema.Steps)
+}
+
+// Status : The schema version of a persisted file, and the migration steps it is waiting for. Once migrated,
+// Version and Pending are as they were before the migration, and Backup is where the file was copied to
+type Status struct {
+	Name           string   `json:"name"`
+	Path           string   `json:"path"`
+	Version        int      `json:"version"`
+	CurrentVersion int      `json:"currentVersion"`
+	Pending        []string `json:"pending"`
+	Backup         string   `json:"backup,omitempty"`
+}
+
+// Required : true when the file is at an older schema version than the current one
+func (status Status) Required() bool {
+	return len(status.Pending) > 0
+}
+
+// BackupFilename : The copy of a file taken before it is migrated from a schema version
+func BackupFilename(path string, version int) string {
+	return path + ".v" + strconv.Itoa(version) + ".bak"
+}
+
+// Check : The schema version of a file and the steps which would migrate it, without changing it. A file which
+// does not exist needs no migration
+func Check(schema Schema, path string) (*Status, *MigrateError) {
+	status := Status{Name: schema.Name, Path: path, Version: schema.Version(), CurrentVersion: schema.Version(), Pending: []string{}}
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &status, nil
+	}
+	if err != nil {
+		return nil, &MigrateError{errOpFileLoad, err, err.Error()}
+	}
+	document, migErr := decode(body)
+	if migErr != nil {
+		return nil, migErr
+	}
+	status.Version, migErr = schema.documentVersion(document, path)
+	if migErr != nil {
+		return nil, migErr
+	}
+	for _, step := range schema.Steps[status.Version:] {
+		status.Pending = append(status.Pending, step.Description)
+	}
+	return &status, nil
+}
+
+// Migrate : Migrates a file to the current schema version, first copying it to its backup file. The file is
+// replaced by renaming a temporary file over it, so that a process reading it never sees it partly written.
+// Callers hold any lock the file is changed under
+func Migrate(schema Schema, path string) (*Status, *MigrateError) {
+	status, migErr := Check(schema, path)
+	if migErr != nil || !status.Required() {
+		return status, migErr
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &MigrateError{errOpFileLoad, err, err.Error()}
+	}
+	migrated, migErr := Upgrade(schema, body)
+	if migErr != nil {
+		return nil, migErr
+	}
+	status.Backup = BackupFilename(path, status.Version)
+	err = ioutil.WriteFile(status.Backup, body, 0600)
+	if err != nil {
+		return nil, &MigrateError{errOpBackup, err, err.Error()}
+	}
+	err = writeFile(path, migrated)
+	if err != nil {
+		return nil, &MigrateError{errOpFileWrite, err, err.Error()}
+	}
+	return status, nil
+}
+
+// Upgrade : Applies the migration steps a JSON document is waiting for in order, returning it at the current
+// schema version. Used when a file is loaded, so that files are read correctly before they are migrated on disk
+func Upgrade(schema Schema, body []byte) ([]byte, *MigrateError) {
+	document, migErr := decode(body)
+	if migErr != nil {
+		return nil, migErr
+	}
+	version, migErr := schema.documentVersion(document, schema.Name)
+	if migErr != nil {
+		return nil, migErr
+	}
+	if version == schema.Version() {
+		return body, nil
+	}
+	for i, step := range schema.Steps[version:] {
+		err := step.Migrate(document)
+		if err != nil {
+			err = errors.New("Unable to migrate " + schema.Name + " to schema version " + strconv.Itoa(version+i+1) + ": " + err.Error())
+			return nil, &MigrateError{errOpStep, err, err.Error()}
+		}
+	}
+	document[schema.VersionField] = schema.Version()
+	migrated, err := json.MarshalIndent(document, "", "\t")
+	if err != nil {
+		return nil, &MigrateError{errOpFileParse, err, err.Error()}
+	}
+	return migrated, nil
+}
+
+// documentVersion : The schema version a document was written at, 0 for files written before it was recorded
+func (schema Schema) documentVersion(document map[string]interface{}, name string) (int, *MigrateError) {
+	value, ok := document[schema.VersionField]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	number, ok := value.(float64)
+	if !ok || number < 0 || number != float64(int(number)) {
+		err := errors.New("Invalid " + schema.VersionField + " in " + name)
+		return 0, &MigrateError{errOpFileParse, err, err.Error()}
+	}
+	if int(number) > schema.Version() {
+		err := errors.New(name + " is at schema version " + strconv.Itoa(int(number)) + ", which was written by a newer version of cwctl")
+		return 0, &MigrateError{errOpNewerVersion, err, err.Error()}
+	}
+	return int(number), nil
+}
+
+func decode(body []byte) (map[string]interface{}, *MigrateError) {
+	document := map[string]interface{}{}
+	err := json.Unmarshal(body, &document)
+	if err != nil {
+		return nil, &MigrateError{errOpFileParse, err, err.Error()}
+	}
+	return document, nil
+}
+
+// writeFile : Writes a file to a temporary file which is then renamed over it
+func writeFile(path string, body []byte) error {
+	tempFile, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = tempFile.Write(body)
+	if err == nil {
+		err = tempFile.Sync()
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempFile.Name(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tempFile.Name(), path)
+}
@@ -0,0 +1,125 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package migrate
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSchema = Schema{
+	Name:         "test",
+	VersionField: "schemaVersion",
+	Steps: []Step{
+		{Description: "Rename name to id", Migrate: func(document map[string]interface{}) error {
+			document["id"] = document["name"]
+			delete(document, "name")
+			return nil
+		}},
+		{Description: "Suffix the id", Migrate: func(document map[string]interface{}) error {
+			id, ok := document["id"].(string)
+			if !ok {
+				return errors.New("id is not a string")
+			}
+			document["id"] = id + "-V2"
+			return nil
+		}},
+	},
+}
+
+func Test_Check(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-migrate")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "test.json")
+
+	t.Run("A file which does not exist needs no migration", func(t *testing.T) {
+		status, migErr := Check(testSchema, file)
+		assert.Nil(t, migErr)
+		assert.False(t, status.Required())
+		assert.Equal(t, 2, status.Version)
+	})
+
+	t.Run("Lists the steps a file is waiting for in order", func(t *testing.T) {
+		ioutil.WriteFile(file, []byte(`{"name": "local"}`), 0644)
+		status, migErr := Check(testSchema, file)
+		assert.Nil(t, migErr)
+		assert.Equal(t, 0, status.Version)
+		assert.Equal(t, 2, status.CurrentVersion)
+		assert.Equal(t, []string{"Rename name to id", "Suffix the id"}, status.Pending)
+		ioutil.WriteFile(file, []byte(`{"schemaVersion": 1, "id": "local"}`), 0644)
+		status, migErr = Check(testSchema, file)
+		assert.Nil(t, migErr)
+		assert.Equal(t, []string{"Suffix the id"}, status.Pending)
+	})
+
+	t.Run("Rejects a file written by a newer version", func(t *testing.T) {
+		ioutil.WriteFile(file, []byte(`{"schemaVersion": 3}`), 0644)
+		_, migErr := Check(testSchema, file)
+		assert.Equal(t, errOpNewerVersion, migErr.Op)
+	})
+}
+
+func Test_Migrate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-migrate")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "test.json")
+	original := `{"name": "local", "label": "Local"}`
+	ioutil.WriteFile(file, []byte(original), 0644)
+
+	t.Run("Applies every pending step and records the version", func(t *testing.T) {
+		status, migErr := Migrate(testSchema, file)
+		assert.Nil(t, migErr)
+		assert.True(t, status.Required())
+		assert.Equal(t, BackupFilename(file, 0), status.Backup)
+		body, _ := ioutil.ReadFile(file)
+		assert.JSONEq(t, `{"schemaVersion": 2, "id": "local-V2", "label": "Local"}`, string(body))
+	})
+
+	t.Run("Backs the file up before migrating it", func(t *testing.T) {
+		body, err := ioutil.ReadFile(BackupFilename(file, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, original, string(body))
+	})
+
+	t.Run("Leaves a file at the current version unchanged", func(t *testing.T) {
+		status, migErr := Migrate(testSchema, file)
+		assert.Nil(t, migErr)
+		assert.False(t, status.Required())
+		assert.Equal(t, "", status.Backup)
+	})
+
+	t.Run("Leaves the file unchanged when a step fails", func(t *testing.T) {
+		ioutil.WriteFile(file, []byte(`{"schemaVersion": 1, "id": 5}`), 0644)
+		_, migErr := Migrate(testSchema, file)
+		assert.Equal(t, errOpStep, migErr.Op)
+		body, _ := ioutil.ReadFile(file)
+		assert.Equal(t, `{"schemaVersion": 1, "id": 5}`, string(body))
+	})
+}
+
+func Test_Upgrade(t *testing.T) {
+	t.Run("Returns a document at the current version as it is", func(t *testing.T) {
+		body, migErr := Upgrade(testSchema, []byte(`{"schemaVersion": 2, "id": "local"}`))
+		assert.Nil(t, migErr)
+		assert.Equal(t, `{"schemaVersion": 2, "id": "local"}`, string(body))
+	})
+
+	t.Run("Rejects a document which is not an object", func(t *testing.T) {
+		_, migErr := Upgrade(testSchema, []byte(`[]`))
+		assert.Equal(t, errOpFileParse, migErr.Op)
+	})
+}
@@ -0,0 +1,44 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package migrate
+
+import (
+	"encoding/json"
+)
+
+// MigrateError : schema migration package errors
+type MigrateError struct {
+	Op   string
+	Err  error
+	Desc string
+}
+
+const (
+	errOpFileParse    = "migrate_parse"
+	errOpFileLoad     = "migrate_load"
+	errOpFileWrite    = "migrate_write"
+	errOpBackup       = "migrate_backup"
+	errOpStep         = "migrate_step"
+	errOpNewerVersion = "migrate_newer_version"
+)
+
+// MigrateError : Error formatted in JSON containing an errorOp and a description from
+// either a fault condition in the CLI, or an error payload from a REST request
+func (me *MigrateError) Error() string {
+	type Output struct {
+		Operation   string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	tempOutput := &Output{Operation: me.Op, Description: me.Err.Error()}
+	jsonError, _ := json.Marshal(tempOutput)
+	return string(jsonError)
+}
@@ -0,0 +1,66 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+)
+
+// hostnamePattern matches a DNS name made of dot separated labels, e.g. "codewind.local"
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`)
+
+// IsValidPFEHost : checks a pfeHost is a bare hostname or IP address, without a scheme, port or path
+func IsValidPFEHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	return len(host) <= 253 && hostnamePattern.MatchString(host)
+}
+
+// CheckPFEHost : checks the saved pfeHost, if any, can be resolved so that a start does not
+// wait on health checks against a host that will never answer
+func CheckPFEHost() error {
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr != nil {
+		return configErr
+	}
+	host := cliConfig.PFEHost
+	if host == "" {
+		return nil
+	}
+	if !IsValidPFEHost(host) {
+		return fmt.Errorf("pfeHost '%v' must be a hostname or IP address without a scheme or port", host)
+	}
+	if _, err := net.LookupHost(host); err != nil {
+		return fmt.Errorf("pfeHost '%v' cannot be resolved: %v", host, err)
+	}
+	return nil
+}
+
+// localPFEHost : the saved pfeHost, or the address docker published the PFE port on when none is set. A port
+// published on every interface is reached on the loopback address
+func localPFEHost(publishedIP string) string {
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr != nil {
+		logr.Debugln("Unable to load the cwctl config, using the published PFE address:", configErr)
+		return localAddress(publishedIP)
+	}
+	if cliConfig.PFEHost != "" {
+		return cliConfig.PFEHost
+	}
+	return localAddress(publishedIP)
+}
@@ -0,0 +1,38 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidPFEHost(t *testing.T) {
+	tests := map[string]bool{
+		"localhost":             true,
+		"codewind.local":        true,
+		"host.docker.internal":  true,
+		"172.17.0.1":            true,
+		"::1":                   true,
+		"":                      false,
+		"http://codewind.local": false,
+		"codewind.local:9090":   false,
+		"codewind.local/api":    false,
+		"-codewind.local":       false,
+	}
+	for host, valid := range tests {
+		t.Run(host, func(t *testing.T) {
+			assert.Equal(t, valid, IsValidPFEHost(host))
+		})
+	}
+}
@@ -0,0 +1,96 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Where start publishes the Codewind containers unless configured otherwise
+const (
+	DefaultHostInterface   = "127.0.0.1"
+	DefaultPerformancePort = "9095"
+)
+
+// PortConfig : Where the local Codewind containers are published on the host. When PFEPort is
+// empty, the first free port between 10000 and 11000 is used
+type PortConfig struct {
+	PFEPort         string
+	PerformancePort string
+	HostInterface   string
+}
+
+// DefaultPortConfig returns the ports used when none are configured
+func DefaultPortConfig() PortConfig {
+	return PortConfig{
+		PerformancePort: DefaultPerformancePort,
+		HostInterface:   DefaultHostInterface,
+	}
+}
+
+// Merge returns a copy of the config with any non-empty values of overrides applied
+func (ports PortConfig) Merge(overrides PortConfig) PortConfig {
+	merged := ports
+	for _, field := range []struct {
+		target *string
+		value  string
+	}{
+		{&merged.PFEPort, overrides.PFEPort},
+		{&merged.PerformancePort, overrides.PerformancePort},
+		{&merged.HostInterface, overrides.HostInterface},
+	} {
+		if strings.TrimSpace(field.value) != "" {
+			*field.target = strings.TrimSpace(field.value)
+		}
+	}
+	return merged
+}
+
+// ProfilePortConfig returns the ports used by a profile other than the default, which publishes its performance
+// dashboard on the first free port after the default one so that it does not clash with other instances
+func ProfilePortConfig() PortConfig {
+	ports := DefaultPortConfig()
+	defaultPort, _ := strconv.Atoi(DefaultPerformancePort)
+	if available, port := IsTCPPortAvailable(defaultPort+1, defaultPort+100); available {
+		ports.PerformancePort = port
+	}
+	return ports
+}
+
+// IsValidPort : checks a port is a number between 1 and 65535
+func IsValidPort(port string) bool {
+	number, err := strconv.Atoi(port)
+	return err == nil && number >= 1 && number <= 65535
+}
+
+// IsValidHostInterface : checks a host interface is an IPv4 address, as docker only binds published ports
+// of a bridge network to IPv4 addresses. Use 0.0.0.0 to publish on every interface
+func IsValidHostInterface(hostInterface string) bool {
+	ip := net.ParseIP(hostInterface)
+	return ip != nil && ip.To4() != nil
+}
+
+// PerformanceURL returns the URL the performance container is reached on from this machine
+func (ports PortConfig) PerformanceURL() string {
+	return "http://" + net.JoinHostPort(localAddress(ports.HostInterface), ports.PerformancePort) + "/"
+}
+
+// localAddress : the address to reach a port published on hostInterface from this machine, which is the
+// loopback address when the port is published on every interface
+func localAddress(hostInterface string) string {
+	if hostInterface == "" || net.ParseIP(hostInterface).IsUnspecified() {
+		return DefaultHostInterface
+	}
+	return hostInterface
+}
@@ -0,0 +1,40 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PortConfig(t *testing.T) {
+	t.Run("Overrides only the ports which are set", func(t *testing.T) {
+		ports := DefaultPortConfig().Merge(PortConfig{PFEPort: " 10100 "})
+		assert.Equal(t, PortConfig{PFEPort: "10100", PerformancePort: "9095", HostInterface: "127.0.0.1"}, ports)
+	})
+	t.Run("Reaches ports published on every interface over loopback", func(t *testing.T) {
+		ports := PortConfig{PerformancePort: "9195", HostInterface: "0.0.0.0"}
+		assert.Equal(t, "http://127.0.0.1:9195/", ports.PerformanceURL())
+		ports.HostInterface = "192.168.1.20"
+		assert.Equal(t, "http://192.168.1.20:9195/", ports.PerformanceURL())
+	})
+	t.Run("Validates ports and interfaces", func(t *testing.T) {
+		assert.True(t, IsValidPort("9095"))
+		assert.False(t, IsValidPort("0"))
+		assert.False(t, IsValidPort("65536"))
+		assert.False(t, IsValidPort("http"))
+		assert.True(t, IsValidHostInterface("0.0.0.0"))
+		assert.False(t, IsValidHostInterface("::1"))
+		assert.False(t, IsValidHostInterface("localhost"))
+	})
+}
@@ -0,0 +1,108 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// composeProjectLabel is set by docker-compose on the containers of a compose project
+const composeProjectLabel = "com.docker.compose.project"
+
+// profileNamePattern matches the names docker-compose accepts in a project name
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// activeProfile is the local Codewind instance selected with --profile, the default instance when empty
+var activeProfile string
+
+// SetProfile : Selects the local Codewind instance that start, stop, remove and status operate on
+func SetProfile(name string) error {
+	if name != "" && !profileNamePattern.MatchString(name) {
+		return errors.New("Invalid profile '" + name + "', must be up to 32 lower case letters, digits and dashes, starting with a letter or digit")
+	}
+	activeProfile = name
+	return nil
+}
+
+// ActiveProfile : The local Codewind instance selected with --profile
+func ActiveProfile() LocalProfile {
+	return LocalProfile{Name: activeProfile}
+}
+
+// LocalProfile : A local Codewind instance, isolated from the others by its own docker-compose project, network,
+// workspace volume and directory, and connection. The default instance has no name
+type LocalProfile struct {
+	Name string
+}
+
+// IsDefault : true for the instance started without --profile
+func (profile LocalProfile) IsDefault() bool {
+	return profile.Name == ""
+}
+
+// ComposeProject : The docker-compose project name, which prefixes the network and volume names
+func (profile LocalProfile) ComposeProject() string {
+	return profile.withName("codewind")
+}
+
+// NetworkPrefix : The start of the name of the instance's docker network
+func (profile LocalProfile) NetworkPrefix() string {
+	return profile.ComposeProject() + "_network"
+}
+
+// WorkspaceVolume : The docker volume the instance keeps its projects in
+func (profile LocalProfile) WorkspaceVolume() string {
+	return profile.ComposeProject() + "_cw-workspace"
+}
+
+// PFEContainerName : The name of the instance's PFE container
+func (profile LocalProfile) PFEContainerName() string {
+	return profile.withName("codewind-pfe")
+}
+
+// PerformanceContainerName : The name of the instance's performance dashboard container
+func (profile LocalProfile) PerformanceContainerName() string {
+	return profile.withName("codewind-performance")
+}
+
+// ConnectionID : The ID of the connection to the instance
+func (profile LocalProfile) ConnectionID() string {
+	return profile.withName("local")
+}
+
+// Owns : true when the container was created by the instance's docker-compose project, is attached to the
+// instance's network, as the project containers PFE starts are, or belongs to the deployment the instance adopted
+func (profile LocalProfile) Owns(container types.Container) bool {
+	if container.Labels[composeProjectLabel] == profile.ComposeProject() {
+		return true
+	}
+	if container.NetworkSettings != nil {
+		for network := range container.NetworkSettings.Networks {
+			if strings.HasPrefix(network, profile.NetworkPrefix()) {
+				return true
+			}
+		}
+	}
+	adopted := profile.AdoptedDeployment()
+	return adopted != nil && adopted.Includes(container)
+}
+
+func (profile LocalProfile) withName(base string) string {
+	if profile.IsDefault() {
+		return base
+	}
+	return base + "-" + profile.Name
+}
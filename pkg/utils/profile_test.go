@@ -0,0 +1,59 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LocalProfile(t *testing.T) {
+	t.Run("Keeps the existing names for the default profile", func(t *testing.T) {
+		profile := LocalProfile{}
+		assert.Equal(t, "codewind", profile.ComposeProject())
+		assert.Equal(t, "codewind_network", profile.NetworkPrefix())
+		assert.Equal(t, "codewind-pfe", profile.PFEContainerName())
+		assert.Equal(t, "local", profile.ConnectionID())
+	})
+	t.Run("Suffixes the names of a named profile", func(t *testing.T) {
+		profile := LocalProfile{Name: "demo"}
+		assert.Equal(t, "codewind-demo", profile.ComposeProject())
+		assert.Equal(t, "codewind-demo_network", profile.NetworkPrefix())
+		assert.Equal(t, "codewind-demo_cw-workspace", profile.WorkspaceVolume())
+		assert.Equal(t, "codewind-pfe-demo", profile.PFEContainerName())
+		assert.Equal(t, "codewind-performance-demo", profile.PerformanceContainerName())
+		assert.Equal(t, "local-demo", profile.ConnectionID())
+	})
+	t.Run("Only owns the containers of its compose project", func(t *testing.T) {
+		container := types.Container{Labels: map[string]string{composeProjectLabel: "codewind-demo"}}
+		assert.True(t, LocalProfile{Name: "demo"}.Owns(container))
+		assert.False(t, LocalProfile{}.Owns(container))
+	})
+	t.Run("Owns the project containers on its network", func(t *testing.T) {
+		container := types.Container{NetworkSettings: &types.SummaryNetworkSettings{
+			Networks: map[string]*network.EndpointSettings{"codewind-demo_network": {}},
+		}}
+		assert.True(t, LocalProfile{Name: "demo"}.Owns(container))
+		assert.False(t, LocalProfile{}.Owns(container))
+		assert.False(t, LocalProfile{Name: "demo"}.Owns(types.Container{}))
+	})
+	t.Run("Rejects names docker-compose does not accept", func(t *testing.T) {
+		defer SetProfile("")
+		assert.Nil(t, SetProfile("demo-2"))
+		assert.Equal(t, "demo-2", ActiveProfile().Name)
+		assert.NotNil(t, SetProfile("Demo"))
+		assert.NotNil(t, SetProfile("-demo"))
+	})
+}
@@ -0,0 +1,78 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"regexp"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// invalidNameCharacters are removed from a directory name to make it a project name
+var invalidNameCharacters = regexp.MustCompile("[^a-zA-Z0-9._-]")
+
+// AddResponse : The outcome of adding an existing project, with what validating it found and the result of its bind
+type AddResponse struct {
+	Name       string            `json:"name"`
+	Validation *ValidationReport `json:"validation"`
+	*BindResponse
+}
+
+// ProjectNameFromPath : The name of a project in the directory projectPath, from the directory name without the
+// characters a project name cannot have. It is empty when none of the characters are allowed
+func ProjectNameFromPath(projectPath string) string {
+	return invalidNameCharacters.ReplaceAllString(filepath.Base(filepath.Clean(projectPath)), "")
+}
+
+// AddProject : Adds the existing source at projectPath to Codewind in one step, as the IDEs add an existing project.
+// The project is validated, its extension's validation command is run or a default .cw-settings is written if it
+// has none, and it is then bound. name defaults to the directory name, and typeHint is an optional type:subtype to
+// match an extension by
+func AddProject(ctx context.Context, httpClient utils.HTTPClient, conID string, projectPath string, name string, typeHint string, bandwidthLimit int64, transferMode string) (*AddResponse, *ProjectError) {
+	projectPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+	if name == "" {
+		name = ProjectNameFromPath(projectPath)
+		if name == "" {
+			err = errors.New("A project name cannot be made from the directory name of " + projectPath + ", give one with --name")
+			return nil, &ProjectError{errBadPath, err, err.Error()}
+		}
+	}
+
+	report, projErr := ValidateProjectPath(httpClient, conID, projectPath, typeHint)
+	if projErr != nil {
+		return nil, projErr
+	}
+	if report.Extension == nil {
+		writeCwSettingsIfNotInProject(projectPath, report.BuildType)
+	} else {
+		for _, command := range report.Extension.Commands {
+			// The arguments of the command have already been substituted
+			err = utils.RunCommand(projectPath, command, nil)
+			if err != nil {
+				err = errors.New("The " + command.Name + " command of extension " + report.Extension.Name + " failed: " + err.Error())
+				return nil, &ProjectError{errOpExtension, err, err.Error()}
+			}
+		}
+	}
+
+	bindResponse, projErr := bind(ctx, httpClient, projectPath, name, report.Language, report.BuildType, conID, bandwidthLimit, transferMode, false)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return &AddResponse{Name: name, Validation: report, BindResponse: bindResponse}, nil
+}
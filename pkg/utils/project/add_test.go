@@ -0,0 +1,41 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ProjectNameFromPath(t *testing.T) {
+	assert.Equal(t, "my-service", ProjectNameFromPath("/home/dev/repos/my-service"))
+	assert.Equal(t, "my-service", ProjectNameFromPath("/home/dev/repos/my-service/"))
+	assert.Equal(t, "MyApp_v2.1", ProjectNameFromPath(filepath.Join("repos", "My App_v2.1")))
+	assert.Equal(t, "", ProjectNameFromPath("/"))
+}
+
+func Test_AddProject(t *testing.T) {
+	t.Run("Reports paths which do not exist", func(t *testing.T) {
+		_, projErr := AddProject(context.Background(), http.DefaultClient, "local", filepath.Join("does", "not", "exist"), "", "", 0, TransferHTTP)
+		assert.NotNil(t, projErr)
+		assert.Equal(t, errBadPath, projErr.Op)
+	})
+	t.Run("Asks for a name when the directory name has none of the allowed characters", func(t *testing.T) {
+		_, projErr := AddProject(context.Background(), http.DefaultClient, "local", "/", "", "", 0, TransferHTTP)
+		assert.NotNil(t, projErr)
+		assert.Contains(t, projErr.Desc, "give one with --name")
+	})
+}
@@ -13,15 +13,22 @@ package project
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/eclipse/codewind-installer/config"
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
@@ -48,14 +55,26 @@ type (
 
 	BindResponse struct {
 		ProjectID     string         `json:"projectID"`
+		Name          string         `json:"name,omitempty"`
 		Status        string         `json:"status"`
 		StatusCode    int            `json:"statusCode"`
 		UploadedFiles []UploadedFile `json:"uploadedFiles"`
 	}
 )
 
-func BindProject(c *cli.Context) (*BindResponse, *ProjectError) {
+// maxRenameAttempts : how many suffixed names --rename-on-conflict tries before giving up
+const maxRenameAttempts = 20
+
+// BindProject : Binds the project given with --path, or resumes its interrupted bind when --resume is given.
+// httpClient checks the name is free on the connection before binding. Cancelling ctx interrupts the bind, which
+// can then be resumed
+func BindProject(ctx context.Context, httpClient utils.HTTPClient, c *cli.Context) (*BindResponse, *ProjectError) {
 	projectPath := strings.TrimSpace(c.String("path"))
+	bandwidthLimit := int64(c.Int("bandwidth-limit")) * 1024
+	// A resumed bind keeps the transfer mode it was started with
+	if c.Bool("resume") {
+		return ResumeBind(ctx, projectPath, bandwidthLimit)
+	}
 	Name := strings.TrimSpace(c.String("name"))
 	Language := strings.TrimSpace(c.String("language"))
 	BuildType := strings.TrimSpace(c.String("type"))
@@ -65,94 +84,225 @@ func BindProject(c *cli.Context) (*BindResponse, *ProjectError) {
 	} else {
 		conID = "local"
 	}
-	return Bind(projectPath, Name, Language, BuildType, conID)
+	transferMode := strings.TrimSpace(strings.ToLower(c.String("transfer-mode")))
+	if transferMode == "" {
+		transferMode = TransferHTTP
+	}
+	return bind(ctx, httpClient, projectPath, Name, Language, BuildType, conID, bandwidthLimit, transferMode, c.Bool("rename-on-conflict"))
 }
 
 // Bind is used to bind a project for building and running
-func Bind(projectPath string, name string, language string, projectType string, conID string) (*BindResponse, *ProjectError) {
+func Bind(ctx context.Context, projectPath string, name string, language string, projectType string, conID string) (*BindResponse, *ProjectError) {
+	return bind(ctx, http.DefaultClient, projectPath, name, language, projectType, conID, 0, TransferHTTP, false)
+}
+
+// bind : binds a project, transferring its files with transferMode at no more than bandwidthLimit bytes per second
+// when it is positive. When the name is taken on the connection, renameOnConflict binds it under the name with the
+// first free -2, -3, ... suffix instead of failing
+func bind(ctx context.Context, httpClient utils.HTTPClient, projectPath string, name string, language string, projectType string, conID string, bandwidthLimit int64, transferMode string, renameOnConflict bool) (*BindResponse, *ProjectError) {
 	_, err := os.Stat(projectPath)
 	if err != nil {
 		return nil, &ProjectError{errBadPath, err, err.Error()}
 	}
+	err = checkTransferMode(transferMode, conID)
+	if err != nil {
+		return nil, &ProjectError{errOpTransfer, err, err.Error()}
+	}
+
+	// Binding again would leave the interrupted bind half registered in Codewind
+	if progress := findBindProgress(projectPath); progress != nil {
+		err := fmt.Errorf("The bind of %v as project %v was interrupted, run project bind --resume to complete it or project remove to abandon it", projectPath, progress.ProjectID)
+		return nil, &ProjectError{errOpConflict, err, err.Error()}
+	}
 
 	conInfo, conErr := connections.GetConnectionByID(conID)
 	if conErr != nil {
 		return nil, &ProjectError{errOpConNotFound, conErr.Err, conErr.Error()}
 	}
 
+	// On a shared Codewind, the connection's prefix keeps project names from colliding with other teams
 	bindRequest := BindRequest{
 		Language:    language,
-		Name:        name,
+		Name:        ApplyProjectPrefix(name, conInfo.ProjectPrefix),
 		ProjectType: projectType,
 		Path:        projectPath,
 	}
-	buf := new(bytes.Buffer)
-	json.NewEncoder(buf).Encode(bindRequest)
+	if invalidNameCharacters.MatchString(bindRequest.Name) {
+		err := errors.New("The project name " + bindRequest.Name + " may only contain letters, numbers, '.', '_' and '-'")
+		return nil, &ProjectError{errOpInvalidName, err, err.Error()}
+	}
+	takenNames, projErr := projectNamesOnConnection(httpClient, conID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	baseName := bindRequest.Name
+	bindRequest.Name, projErr = freeProjectName(baseName, takenNames, conID, renameOnConflict)
+	if projErr != nil {
+		return nil, projErr
+	}
 
 	// use the given connectionID to call api/v1/bind/start
-	conURL := config.PFEApiRoute()
-	if conInfo.ID != "local" {
+	conURL := config.ProfilePFEApiRoute(connections.LocalProfileOf(conInfo.ID))
+	if !connections.IsLocal(conInfo.ID) {
 		conURL = conInfo.URL
 	}
-	bindURL := conURL + "projects/bind/start"
-
-	client := &http.Client{}
-
-	request, err := http.NewRequest("POST", bindURL, bytes.NewReader(buf.Bytes()))
-	request.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(request)
-	if err != nil {
-		bindError := errors.New(textNoCodewind)
-		return nil, &ProjectError{errOpResponse, bindError, bindError.Error()}
+	projectID, projErr := startBind(conURL, bindRequest)
+	// Another bind may take the name between the check and bind/start
+	for attempt := 1; projErr != nil && projErr.Op == errOpConflict && renameOnConflict && attempt < maxRenameAttempts; attempt++ {
+		takenNames[bindRequest.Name] = true
+		bindRequest.Name, projErr = freeProjectName(baseName, takenNames, conID, renameOnConflict)
+		if projErr == nil {
+			projectID, projErr = startBind(conURL, bindRequest)
+		}
 	}
-
-	switch httpCode := resp.StatusCode; {
-	case httpCode == 400:
-		err = errors.New(textInvalidType)
-		return nil, &ProjectError{errOpResponse, err, textInvalidType}
-	case httpCode == 404:
-		err = errors.New(textAPINotFound)
-		return nil, &ProjectError{errOpResponse, err, textAPINotFound}
-	case httpCode == 409:
-		err = errors.New(textDupName)
-		return nil, &ProjectError{errOpResponse, err, textDupName}
+	if projErr != nil {
+		return nil, projErr
+	}
+	if bindRequest.Name != baseName {
+		logr.Infof("A project named %v already exists on connection %v, binding as %v", baseName, conID, bindRequest.Name)
 	}
 
-	defer resp.Body.Close()
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	// Generate the .codewind/connections/{projectID}.json file based on the given conID
+	SetConnection(projectID, conID)
+	SetProjectPath(projectID, projectPath)
 
-	var projectInfo map[string]interface{}
-	if err := json.Unmarshal(bodyBytes, &projectInfo); err != nil {
-		panic(err)
+	// Until bind/end succeeds the project is only half registered in Codewind, so the bind is recorded to be resumed
+	progress := &bindProgress{ProjectID: projectID, ConnectionID: conID, Path: projectPath, Name: bindRequest.Name, TransferMode: transferMode}
+	if absPath, err := filepath.Abs(projectPath); err == nil {
+		progress.Path = absPath
+	}
+	projErr = saveBindProgress(progress)
+	if projErr != nil {
+		return nil, projErr
 	}
+	return finishBind(ctx, progress, bandwidthLimit)
+}
 
-	projectID := projectInfo["projectID"].(string)
+// ResumeBind : Completes the interrupted bind of the project at projectPath, uploading only the files which
+// were not uploaded before it was interrupted
+func ResumeBind(ctx context.Context, projectPath string, bandwidthLimit int64) (*BindResponse, *ProjectError) {
+	progress := findBindProgress(projectPath)
+	if progress == nil {
+		err := errors.New("There is no interrupted bind of " + projectPath + " to resume")
+		return nil, &ProjectError{errOpNotFound, err, err.Error()}
+	}
+	_, err := os.Stat(progress.Path)
+	if err != nil {
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+	return finishBind(ctx, progress, bandwidthLimit)
+}
 
-	// Generate the .codewind/connections/{projectID}.json file based on the given conID
-	SetConnection(projectID, conID)
+// finishBind : Uploads the project files and calls bind/end. When either is interrupted the progress is kept so
+// that the bind can be resumed, while a bind which Codewind rejects is aborted
+func finishBind(ctx context.Context, progress *bindProgress, bandwidthLimit int64) (*BindResponse, *ProjectError) {
+	projectID := progress.ProjectID
 
 	// Read connections.json to find the URL of the connection
 	conURL, projErr := GetConnectionURL(projectID)
-
 	if projErr != nil {
 		return nil, projErr
 	}
 
-	// Sync all the project files
-	_, _, uploadedFilesList := syncFiles(projectPath, projectID, conURL, 0)
+	// Sync the project files, skipping those already uploaded by an earlier attempt
+	options := getUploadOptions(progress.ConnectionID)
+	options.Bandwidth = newBandwidthLimiter(bandwidthLimit)
+	if progress.TransferMode == TransferKube {
+		transfer, err := newPodTransfer(progress.ConnectionID, conURL, progress.Name)
+		if err != nil {
+			return nil, interruptBind(progress, "Unable to reach the Codewind pod: "+err.Error())
+		}
+		options.Pod = transfer
+		// A tar archive keeps binary files intact, so they need not be skipped as they are for text uploads
+		options.Raw = true
+	}
+	syncStart := time.Now().UnixNano() / 1000000
+	result := syncFiles(ctx, progress.Path, projectID, conURL, 0, options, progress.State)
+	if result.Err != nil {
+		return nil, interruptBind(progress, "Unable to read the project files: "+result.Err.Error())
+	}
+	if result.State != nil {
+		progress.State = result.State
+	}
+	failed, rejected := failedUploads(result)
+	if rejected {
+		abortBind(projectID, conURL)
+		err := errors.New("Codewind no longer knows project " + projectID + ", so its bind was abandoned, bind the project again")
+		return nil, &ProjectError{errOpBindAborted, err, err.Error()}
+	}
+	if failed > 0 {
+		return nil, interruptBind(progress, fmt.Sprintf("%d of %d files failed to upload", failed, len(result.ModifiedList)))
+	}
 
 	// Call bind/end to complete
-	completeStatus, completeStatusCode := completeBind(projectID, conURL)
+	completeStatus, completeStatusCode, err := completeBind(projectID, conURL)
+	if err != nil {
+		return nil, interruptBind(progress, "Unable to complete the bind: "+err.Error())
+	}
+	if completeStatusCode != http.StatusOK {
+		if isRetryableStatus(completeStatusCode) {
+			return nil, interruptBind(progress, "Codewind could not complete the bind: "+completeStatus)
+		}
+		abortBind(projectID, conURL)
+		err := errors.New("Codewind rejected the bind with " + completeStatus + ", so it was aborted")
+		return nil, &ProjectError{errOpBindAborted, err, err.Error()}
+	}
+	result.State.LastSync = syncStart
+	saveSyncState(projectID, progress.ConnectionID, result.State)
+	removeBindProgress(projectID)
+
 	response := BindResponse{
 		ProjectID:     projectID,
-		UploadedFiles: uploadedFilesList,
+		Name:          progress.Name,
+		UploadedFiles: result.UploadedFiles,
 		Status:        completeStatus,
 		StatusCode:    completeStatusCode,
 	}
 	return &response, nil
 }
 
-func completeBind(projectID string, conURL string) (string, int) {
+// interruptBind : Keeps the progress of a bind which failed in a way that may succeed when tried again
+func interruptBind(progress *bindProgress, reason string) *ProjectError {
+	projErr := saveBindProgress(progress)
+	if projErr != nil {
+		return projErr
+	}
+	err := fmt.Errorf("%v, run project bind --resume --path %v to complete the bind of project %v", reason, progress.Path, progress.ProjectID)
+	return &ProjectError{errOpBindInterrupted, err, err.Error()}
+}
+
+// failedUploads : How many of the modified files were not uploaded, and whether any was rejected because
+// Codewind no longer knows the project
+func failedUploads(result syncResult) (int, bool) {
+	uploaded := map[string]bool{}
+	rejected := false
+	for _, file := range result.UploadedFiles {
+		if file.StatusCode < 300 {
+			uploaded[file.FilePath] = true
+		} else if file.StatusCode == http.StatusNotFound {
+			rejected = true
+		}
+	}
+	failed := 0
+	for _, filePath := range result.ModifiedList {
+		if !uploaded[filePath] {
+			failed++
+		}
+	}
+	return failed, rejected
+}
+
+// isRetryableStatus : Whether a response means Codewind could not be reached or was busy, rather than that it
+// rejected the request
+func isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func completeBind(projectID string, conURL string) (string, int, error) {
 	uploadEndURL := conURL + "projects/" + projectID + "/bind/end"
 
 	payload := &BindEndRequest{ProjectID: projectID}
@@ -161,7 +311,118 @@ func completeBind(projectID string, conURL string) (string, int) {
 	// Make the request to end the sync process.
 	resp, err := http.Post(uploadEndURL, "application/json", bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		panic(err)
+		return "", 0, err
+	}
+	resp.Body.Close()
+	return resp.Status, resp.StatusCode, nil
+}
+
+// abortBind : Asks Codewind to forget a bind which cannot be completed, unbinding the project when Codewind has
+// no abort endpoint, then forgets the project locally. Failures are ignored, as the bind has already failed
+func abortBind(projectID string, conURL string) {
+	resp, err := http.Post(conURL+"projects/"+projectID+"/bind/abort", "application/json", nil)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			resp, err = http.Post(conURL+"projects/"+projectID+"/unbind", "application/json", nil)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}
+	}
+	removeBindProgress(projectID)
+	RemoveConnectionFile(projectID)
+}
+
+// projectNamesOnConnection : the names of the projects already on a connection. When they cannot be listed, such as
+// by an older Codewind, none are returned and a name which is taken is refused by bind/start instead
+func projectNamesOnConnection(httpClient utils.HTTPClient, conID string) (map[string]bool, *ProjectError) {
+	names := map[string]bool{}
+	host, conErr := connections.GetPFEOrigin(conID)
+	if conErr != nil {
+		return nil, &ProjectError{errOpConNotFound, conErr.Err, conErr.Desc}
+	}
+	projects, err := apiroutes.NewPFEClientForHost(httpClient, host).GetProjects()
+	if err != nil {
+		logr.Debugf("Unable to list the projects of connection %v to check the name is free: %v", conID, err)
+		return names, nil
+	}
+	for _, project := range projects {
+		names[project.Name] = true
+	}
+	return names, nil
+}
+
+// freeProjectName : name when no project on the connection has it, else with renameOnConflict the name with the
+// first free -2, -3, ... suffix
+func freeProjectName(name string, takenNames map[string]bool, conID string, renameOnConflict bool) (string, *ProjectError) {
+	if !takenNames[name] {
+		return name, nil
+	}
+	if renameOnConflict {
+		for i := 2; i <= maxRenameAttempts+1; i++ {
+			candidate := fmt.Sprintf("%v-%d", name, i)
+			if !takenNames[candidate] {
+				return candidate, nil
+			}
+		}
+	}
+	return "", nameConflictError(name, conID, renameOnConflict)
+}
+
+func nameConflictError(name string, conID string, renameOnConflict bool) *ProjectError {
+	err := fmt.Errorf("A project named %v already exists on connection %v, choose another name with --name, or give --rename-on-conflict to add a suffix", name, conID)
+	if renameOnConflict {
+		err = fmt.Errorf("A project named %v and each of its first %d suffixed names already exist on connection %v, choose another name with --name", name, maxRenameAttempts, conID)
+	}
+	return &ProjectError{errOpConflict, err, err.Error()}
+}
+
+// startBind : Calls bind/start, returning the ID Codewind gives the project
+func startBind(conURL string, bindRequest BindRequest) (string, *ProjectError) {
+	buf := new(bytes.Buffer)
+	json.NewEncoder(buf).Encode(bindRequest)
+	request, err := http.NewRequest("POST", conURL+"projects/bind/start", bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return "", &ProjectError{errOpResponse, err, err.Error()}
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		bindError := errors.New(textNoCodewind)
+		return "", &ProjectError{errOpResponse, bindError, bindError.Error()}
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", &ProjectError{errOpResponse, err, err.Error()}
+	}
+
+	switch httpCode := resp.StatusCode; {
+	case httpCode == 400:
+		err = errors.New(textInvalidType)
+		return "", &ProjectError{errOpResponse, err, textInvalidType}
+	case httpCode == 404:
+		err = errors.New(textAPINotFound)
+		return "", &ProjectError{errOpResponse, err, textAPINotFound}
+	case httpCode == 409:
+		err = errors.New(textDupName + ": " + bindRequest.Name)
+		return "", &ProjectError{errOpConflict, err, err.Error()}
+	case httpCode < 200 || httpCode > 299:
+		err = fmt.Errorf("Codewind refused to bind the project with status code %d: %v", httpCode, strings.TrimSpace(string(bodyBytes)))
+		return "", &ProjectError{errOpResponse, err, err.Error()}
+	}
+
+	var projectInfo struct {
+		ProjectID string `json:"projectID"`
+	}
+	err = json.Unmarshal(bodyBytes, &projectInfo)
+	if err == nil && projectInfo.ProjectID == "" {
+		err = errors.New("no projectID")
+	}
+	if err != nil {
+		err = fmt.Errorf("Codewind started the bind but its response could not be read: %v", err)
+		return "", &ProjectError{errOpResponse, err, err.Error()}
 	}
-	return resp.Status, resp.StatusCode
+	return projectInfo.ProjectID, nil
 }
@@ -0,0 +1,74 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeProjectName(t *testing.T) {
+	taken := map[string]bool{"node": true, "node-2": true}
+
+	t.Run("a name no project has is used as it is", func(t *testing.T) {
+		name, projErr := freeProjectName("java", taken, "local", false)
+		assert.Nil(t, projErr)
+		assert.Equal(t, "java", name)
+	})
+
+	t.Run("a name which is taken is refused unless it may be renamed", func(t *testing.T) {
+		_, projErr := freeProjectName("node", taken, "local", false)
+		assert.Equal(t, errOpConflict, projErr.Op)
+		assert.Contains(t, projErr.Desc, "--rename-on-conflict")
+	})
+
+	t.Run("a name which is taken is given the first free suffix", func(t *testing.T) {
+		name, projErr := freeProjectName("node", taken, "local", true)
+		assert.Nil(t, projErr)
+		assert.Equal(t, "node-3", name)
+	})
+}
+
+func TestStartBind(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		body   string
+		id     string
+		op     string
+	}{
+		"returns the ID of the project":                {http.StatusAccepted, `{"projectID":"a1b2"}`, "a1b2", ""},
+		"reports a name which is taken as a conflict":  {http.StatusConflict, `{"message":"exists"}`, "", errOpConflict},
+		"reports a response which is not JSON":         {http.StatusAccepted, `<html></html>`, "", errOpResponse},
+		"reports a response without a project ID":      {http.StatusAccepted, `{"projectID":5}`, "", errOpResponse},
+		"reports a server error with what it returned": {http.StatusInternalServerError, `failed`, "", errOpResponse},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/api/v1/projects/bind/start", r.URL.Path)
+				w.WriteHeader(test.status)
+				w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+			id, projErr := startBind(server.URL+"/api/v1/", BindRequest{Name: "node"})
+			assert.Equal(t, test.id, id)
+			if test.op == "" {
+				assert.Nil(t, projErr)
+			} else {
+				assert.Equal(t, test.op, projErr.Op)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// bindProgress : a bind which has started on Codewind but not yet completed, kept so that it can be resumed.
+// State holds the files uploaded so far, which are not uploaded again
+type bindProgress struct {
+	ProjectID    string `json:"projectID"`
+	ConnectionID string `json:"connectionID"`
+	Path         string `json:"path"`
+	// Name is the name the project was bound with, which the kube transfer mode copies its files under
+	Name         string     `json:"name,omitempty"`
+	TransferMode string     `json:"transferMode,omitempty"`
+	State        *syncState `json:"state,omitempty"`
+}
+
+// saveBindProgress : records how far a bind has got
+func saveBindProgress(progress *bindProgress) *ProjectError {
+	body, err := json.MarshalIndent(progress, "", "\t")
+	if err != nil {
+		return &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	err = os.MkdirAll(getBindProgressDir(), 0777)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	err = ioutil.WriteFile(getBindProgressFilename(progress.ProjectID), body, 0644)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	return nil
+}
+
+// findBindProgress : the unfinished bind of the project at projectPath, or nil when there is none
+func findBindProgress(projectPath string) *bindProgress {
+	files, err := ioutil.ReadDir(getBindProgressDir())
+	if err != nil {
+		return nil
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		body, err := ioutil.ReadFile(path.Join(getBindProgressDir(), file.Name()))
+		if err != nil {
+			continue
+		}
+		progress := bindProgress{}
+		if json.Unmarshal(body, &progress) != nil || progress.ProjectID == "" {
+			continue
+		}
+		if sameProjectPath(progress.Path, projectPath) {
+			return &progress
+		}
+	}
+	return nil
+}
+
+// removeBindProgress : forgets the unfinished bind of a project, once it has completed or been aborted
+func removeBindProgress(projectID string) {
+	os.Remove(getBindProgressFilename(projectID))
+}
+
+// sameProjectPath : whether two paths refer to the same project directory, ignoring trailing separators
+// and relative paths
+func sameProjectPath(first string, second string) bool {
+	firstAbs, err := filepath.Abs(first)
+	if err != nil {
+		return false
+	}
+	secondAbs, err := filepath.Abs(second)
+	if err != nil {
+		return false
+	}
+	return firstAbs == secondAbs
+}
+
+// getBindProgressDir : the progress of unfinished binds is kept beside the project connection files
+func getBindProgressDir() string {
+	return path.Join(path.Dir(getProjectConnectionConfigDir()), "bind")
+}
+
+func getBindProgressFilename(projectID string) string {
+	return path.Join(getBindProgressDir(), projectID+".json")
+}
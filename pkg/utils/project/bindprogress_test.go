@@ -0,0 +1,99 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindProgress(t *testing.T) {
+	projectPath, err := ioutil.TempDir("", "bindprogress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(projectPath)
+	progressID := "b1d2e3f4-0000-11ea-8d71-362b9e155667"
+	defer removeBindProgress(progressID)
+
+	t.Run("no progress is found for a path which has none", func(t *testing.T) {
+		assert.Nil(t, findBindProgress(projectPath))
+	})
+
+	t.Run("saved progress is found by its path with a trailing separator", func(t *testing.T) {
+		state := newSyncState()
+		state.Files["package.json"] = syncedFile{SHA256: "abc", Size: 3, Modified: 1}
+		projErr := saveBindProgress(&bindProgress{ProjectID: progressID, ConnectionID: "local", Path: projectPath, State: state})
+		assert.Nil(t, projErr)
+
+		progress := findBindProgress(projectPath + string(filepath.Separator))
+		if assert.NotNil(t, progress) {
+			assert.Equal(t, progressID, progress.ProjectID)
+			assert.Equal(t, "local", progress.ConnectionID)
+			assert.Equal(t, "abc", progress.State.Files["package.json"].SHA256)
+		}
+	})
+
+	t.Run("removed progress is not found", func(t *testing.T) {
+		removeBindProgress(progressID)
+		assert.Nil(t, findBindProgress(projectPath))
+	})
+}
+
+func TestFailedUploads(t *testing.T) {
+	tests := map[string]struct {
+		result   syncResult
+		failed   int
+		rejected bool
+	}{
+		"all files uploaded": {
+			result: syncResult{
+				ModifiedList:  []string{"a", "b"},
+				UploadedFiles: []UploadedFile{{FilePath: "a", StatusCode: 200}, {FilePath: "b", StatusCode: 200}},
+			},
+		},
+		"files which failed or were never sent": {
+			result: syncResult{
+				ModifiedList:  []string{"a", "b", "c"},
+				UploadedFiles: []UploadedFile{{FilePath: "a", StatusCode: 200}, {FilePath: "b", StatusCode: 503}},
+			},
+			failed: 2,
+		},
+		"a project Codewind no longer knows": {
+			result: syncResult{
+				ModifiedList:  []string{"a"},
+				UploadedFiles: []UploadedFile{{FilePath: "a", StatusCode: 404}},
+			},
+			failed:   1,
+			rejected: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			failed, rejected := failedUploads(test.result)
+			assert.Equal(t, test.failed, failed)
+			assert.Equal(t, test.rejected, rejected)
+		})
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	assert.True(t, isRetryableStatus(http.StatusServiceUnavailable))
+	assert.True(t, isRetryableStatus(http.StatusGatewayTimeout))
+	assert.False(t, isRetryableStatus(http.StatusBadRequest))
+	assert.False(t, isRetryableStatus(http.StatusInternalServerError))
+}
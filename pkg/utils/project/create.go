@@ -15,7 +15,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -25,6 +25,8 @@ import (
 	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	"github.com/eclipse/codewind-installer/pkg/errors"
 	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/security"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
@@ -46,6 +48,8 @@ type (
 		IgnoredPaths      []string `json:"ignoredPaths"`
 		MavenProfiles     []string `json:"mavenProfiles,omitempty"`
 		MavenProperties   []string `json:"mavenProperties,omitempty"`
+		// ContextPaths are directories outside the project, such as the shared libraries of a monorepo, synced into it
+		ContextPaths []string `json:"contextPaths,omitempty"`
 	}
 )
 
@@ -54,7 +58,7 @@ func DownloadTemplate(c *cli.Context) *ProjectError {
 	destination := c.Args().Get(0)
 
 	if destination == "" {
-		log.Fatal("destination not set")
+		logr.Fatal("destination not set")
 	}
 
 	projectDir := path.Base(destination)
@@ -68,70 +72,99 @@ func DownloadTemplate(c *cli.Context) *ProjectError {
 	}
 
 	url := c.String("u")
+	ref := strings.TrimSpace(c.String("ref"))
+	checksum := strings.TrimSpace(c.String("sha256"))
+	if checksum == "" && c.Bool("verify") {
+		checksum = findTemplateChecksum(url)
+		if checksum == "" {
+			err := fmt.Errorf("--verify needs a checksum for %v, give it with --sha256 or list it as the sha256 of the template in its repository index", url)
+			return &ProjectError{errOpVerify, err, err.Error()}
+		}
+	}
 
-	err := utils.DownloadFromURLThenExtract(url, destination)
-	if err != nil {
-		log.Fatal(err)
+	if checksum != "" {
+		err := utils.DownloadVerifiedArchive(url, destination, checksum)
+		if err != nil {
+			return &ProjectError{errOpVerify, err, err.Error()}
+		}
+	} else if repo := utils.ParseGitHubRepoURL(url); repo != nil {
+		// GitHub repository pages are downloaded as an archive of the ref, rather than cloned
+		err := utils.DownloadGitHubRepo(http.DefaultClient, *repo, ref, strings.TrimSpace(c.String("github-token")), destination)
+		if err != nil {
+			return &ProjectError{errOpGitHub, err, err.Error()}
+		}
+	} else if utils.IsGitURL(url) || ref != "" {
+		// Repositories without release archives are cloned, as is any repository when a ref is given
+		err := utils.CloneGitRepo(url, ref, destination)
+		if err != nil {
+			return &ProjectError{errOpGitClone, err, err.Error()}
+		}
+	} else {
+		err := utils.DownloadFromURLThenExtract(url, destination)
+		if err != nil {
+			logr.Fatal(err)
+		}
 	}
-	err = utils.ReplaceInFiles(destination, "[PROJ_NAME_PLACEHOLDER]", projectName)
+	err := utils.ReplaceInFiles(destination, "[PROJ_NAME_PLACEHOLDER]", projectName)
 	if err != nil {
-		log.Fatal(err)
+		logr.Fatal(err)
 	}
 	return nil
 }
 
+// findTemplateChecksum returns the checksum the index of an enabled template repository gives for the template
+// at url, or an empty string when none does. Each index is fetched with the provider saved for its repo
+func findTemplateChecksum(url string) string {
+	repos, err := apiroutes.NewLocalPFEClient().GetTemplateRepos()
+	if err != nil {
+		logr.Debugln("Unable to get the template repositories:", err)
+		return ""
+	}
+	for _, repo := range repos {
+		if !repo.Enabled {
+			continue
+		}
+		provider, secErr := security.SecTemplateProviderGet(repo.URL)
+		if secErr != nil {
+			logr.Debugln("Skipping template repository", repo.URL, secErr.Desc)
+			continue
+		}
+		checksum, err := utils.FindTemplateChecksum(provider.Client(http.DefaultClient), repo.URL, url)
+		if err != nil {
+			logr.Debugln("Skipping template repository", repo.URL, err)
+			continue
+		}
+		if checksum != "" {
+			return checksum
+		}
+	}
+	return ""
+}
+
 // checkIsExtension checks if a project is an extension project and run associated commands as necessary
 func checkIsExtension(projectPath string, c *cli.Context) (string, error) {
 
-	extensions, err := apiroutes.GetExtensions()
+	extensions, err := apiroutes.NewLocalPFEClient().ListExtensions()
 	if err != nil {
-		log.Println("There was a problem retrieving extensions data")
+		logr.Warnln("There was a problem retrieving extensions data")
 		return "unknown", err
 	}
 
-	params := make(map[string]string)
-	commandName := "postProjectValidate"
-
-	// determine if type:subtype hint was given
-	// but only if url was not given
-	if c.String("u") == "" && c.String("t") != "" {
-		parts := strings.Split(c.String("t"), ":")
-		params["$type"] = parts[0]
-		if len(parts) > 1 {
-			params["$subtype"] = parts[1]
-		}
-		commandName = "postProjectValidateWithType"
+	// the type:subtype hint is only used when url was not given
+	typeHint := ""
+	if c.String("u") == "" {
+		typeHint = c.String("t")
 	}
-
-	for _, extension := range extensions {
-
-		var isMatch bool
-
-		if len(params) > 0 {
-			// check if extension project type matched the hinted type
-			isMatch = extension.ProjectType == params["$type"]
-		} else {
-			// check if project contains the detection file an extension defines
-			isMatch = extension.Detection != "" && utils.PathExists(path.Join(projectPath, extension.Detection))
-		}
-
-		if isMatch {
-
-			var cmdErr error
-
-			// check if there are any commands to run
-			for _, command := range extension.Commands {
-				if command.Name == commandName {
-					cmdErr = utils.RunCommand(projectPath, command, params)
-					break
-				}
-			}
-
-			return extension.ProjectType, cmdErr
-		}
+	extension, command, params := matchExtension(extensions, projectPath, typeHint)
+	if extension == nil {
+		return "", nil
 	}
 
-	return "", nil
+	var cmdErr error
+	if command != nil {
+		cmdErr = utils.RunCommand(projectPath, *command, params)
+	}
+	return extension.ProjectType, cmdErr
 }
 
 // ValidateProject returns the language and buildType for a project at given filesystem path,
@@ -190,10 +223,10 @@ func writeCwSettingsIfNotInProject(projectPath string, BuildType string) {
 // checkProjectPath will stop the process and return an error if path does not exist or is invalid
 func checkProjectPath(projectPath string) {
 	if projectPath == "" {
-		log.Fatal("Project path not given")
+		logr.Fatal("Project path not given")
 	}
 	if !utils.PathExists(projectPath) {
-		log.Fatal("Project not found at given path")
+		logr.Fatal("Project not found at given path")
 	}
 }
 
@@ -239,7 +272,7 @@ func determineJavaBuildType(projectPath string) string {
 func determineProjectLanguage(projectPath string) string {
 	projectFiles, err := ioutil.ReadDir(projectPath)
 	if err != nil {
-		log.Fatal(err)
+		logr.Fatal(err)
 	}
 	for _, file := range projectFiles {
 		if !file.IsDir() {
@@ -0,0 +1,113 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultShell is the command used by `project shell`
+var DefaultShell = []string{"/bin/sh", "-c", "if [ -x /bin/bash ]; then exec /bin/bash; else exec /bin/sh; fi"}
+
+// ExecInProject : Runs a command inside a project's container, locally through docker or
+// remotely through the Kubernetes exec API depending on the project's connection
+func ExecInProject(projectID string, command []string, tty bool) *ProjectError {
+	if len(command) == 0 {
+		execErr := errors.New(textNoCommand)
+		return &ProjectError{errOpExec, execErr, execErr.Error()}
+	}
+	conID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return projErr
+	}
+	if connections.IsLocal(conID) {
+		return execInLocalContainer(projectID, command, tty)
+	}
+	return execInRemotePod(conID, projectID, command, tty)
+}
+
+// execInLocalContainer : Runs the command in the project's docker container
+func execInLocalContainer(projectID string, command []string, tty bool) *ProjectError {
+	containerID := ""
+	for _, container := range utils.GetContainerList() {
+		if len(container.Names) > 0 && strings.HasPrefix(container.Names[0], "/cw-") && strings.HasSuffix(container.Names[0], projectID) {
+			containerID = container.ID
+			break
+		}
+	}
+	if containerID == "" {
+		execErr := errors.New(textNoContainer)
+		return &ProjectError{errOpNotFound, execErr, execErr.Error()}
+	}
+
+	args := []string{"exec", "-i"}
+	if tty {
+		args = append(args, "-t")
+	}
+	args = append(args, containerID)
+	args = append(args, command...)
+	cmd := exec.Command("docker", args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if err != nil {
+		return &ProjectError{errOpExec, err, err.Error()}
+	}
+	return nil
+}
+
+// execInRemotePod : Runs the command in the project's pod in the namespace of the Kubernetes context of the
+// connection
+func execInRemotePod(conID string, projectID string, command []string, tty bool) *ProjectError {
+	connections.SelectConnectionKubeContext(conID)
+	kubeConfig := kube.GetKubeClientConfig()
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return &ProjectError{errOpExec, err, err.Error()}
+	}
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return &ProjectError{errOpExec, err, err.Error()}
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return &ProjectError{errOpExec, err, err.Error()}
+	}
+
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: "projectID=" + projectID})
+	if err != nil {
+		return &ProjectError{errOpExec, err, err.Error()}
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		err = kube.ExecInPod(config, clientset, pod, command, tty)
+		if err != nil {
+			return &ProjectError{errOpExec, err, err.Error()}
+		}
+		return nil
+	}
+	execErr := errors.New(textNoContainer)
+	return &ProjectError{errOpNotFound, execErr, execErr.Error()}
+}
@@ -0,0 +1,29 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExecInProject(t *testing.T) {
+	t.Run("Fails when no command is given", func(t *testing.T) {
+		err := ExecInProject(testProjectID, []string{}, false)
+		assert.Equal(t, errOpExec, err.Op)
+	})
+	t.Run("Fails when the project has no connection", func(t *testing.T) {
+		err := ExecInProject("bad-project-id", []string{"ls"}, false)
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,124 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/filewatcher"
+	logr "github.com/sirupsen/logrus"
+)
+
+// grepMaxLineSize is the longest line searched, files with longer lines are skipped as they are unlikely to be source
+const grepMaxLineSize = 1024 * 1024
+
+// SearchMatch : A line of a bound project which matched a search
+type SearchMatch struct {
+	ProjectID    string `json:"projectID"`
+	ConnectionID string `json:"connectionID"`
+	ProjectPath  string `json:"projectPath"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	Text         string `json:"text"`
+}
+
+// SearchProjects : Searches the files of every project bound from this machine for lines matching pattern,
+// skipping the files which sync does not upload. When conID is given only the projects on that connection are searched.
+// The search stops with an error once ctx is cancelled
+func SearchProjects(ctx context.Context, pattern *regexp.Regexp, conID string) ([]SearchMatch, *ProjectError) {
+	projectIDs, projErr := ListProjectIDs()
+	if projErr != nil {
+		return nil, projErr
+	}
+	matches := []SearchMatch{}
+	for _, projectID := range projectIDs {
+		connectionFile, projErr := loadConnectionFile(projectID)
+		if projErr != nil {
+			logr.Debugln("Skipping project", projectID, projErr.Desc)
+			continue
+		}
+		if conID != "" && !strings.EqualFold(conID, connectionFile.ID) {
+			continue
+		}
+		if connectionFile.Path == "" {
+			logr.Debugln("Skipping project", projectID, "as its path is not known, sync it to record the path")
+			continue
+		}
+		if _, err := os.Stat(connectionFile.Path); err != nil {
+			logr.Debugln("Skipping project", projectID, err)
+			continue
+		}
+		projectMatches, err := searchProject(ctx, pattern, connectionFile.Path)
+		if err != nil {
+			return nil, &ProjectError{errOpFileLoad, err, "The search was interrupted: " + err.Error()}
+		}
+		for i := range projectMatches {
+			projectMatches[i].ProjectID = projectID
+			projectMatches[i].ConnectionID = connectionFile.ID
+		}
+		matches = append(matches, projectMatches...)
+	}
+	return matches, nil
+}
+
+// searchProject : the matching lines of the files under projectPath, with paths relative to it, or the error of
+// ctx when it is cancelled
+func searchProject(ctx context.Context, pattern *regexp.Regexp, projectPath string) ([]SearchMatch, error) {
+	matches := []SearchMatch{}
+	cwSettingsIgnoredPathsList := retrieveIgnoredPathsList(projectPath)
+	filewatcher.Walk(ctx, projectPath, syncWalkOptions(cwSettingsIgnoredPathsList), func(file filewatcher.File) error {
+		for _, match := range searchFile(pattern, file.Path) {
+			match.ProjectPath = projectPath
+			match.File = file.RelativePath
+			matches = append(matches, match)
+		}
+		return nil
+	})
+	// Other errors of the walk leave the matches found until then, as a project too large to sync can still be searched
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+// searchFile : the matching lines of a text file, binary and unreadable files have none
+func searchFile(pattern *regexp.Regexp, path string) []SearchMatch {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	matches := []SearchMatch{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), grepMaxLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if bytes.IndexByte(line, 0) != -1 {
+			return nil
+		}
+		if pattern.Match(line) {
+			matches = append(matches, SearchMatch{Line: lineNumber, Text: string(line)})
+		}
+	}
+	if scanner.Err() != nil {
+		return nil
+	}
+	return matches
+}
@@ -0,0 +1,56 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SearchProject(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "cwctl-grep")
+	defer os.RemoveAll(projectPath)
+	os.MkdirAll(filepath.Join(projectPath, "src"), 0777)
+	os.MkdirAll(filepath.Join(projectPath, "node_modules", "dep"), 0777)
+	ioutil.WriteFile(filepath.Join(projectPath, "src", "server.js"), []byte("const port = 3000\napp.listen(port)\n"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, "node_modules", "dep", "index.js"), []byte("app.listen(8080)\n"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, "image.png"), []byte("app.listen\x00binary"), 0644)
+
+	t.Run("Finds matching lines, skipping ignored directories and binary files", func(t *testing.T) {
+		matches, err := searchProject(context.Background(), regexp.MustCompile(`listen\(`), projectPath)
+		assert.Nil(t, err)
+		assert.Len(t, matches, 1)
+		assert.Equal(t, "src/server.js", matches[0].File)
+		assert.Equal(t, 2, matches[0].Line)
+		assert.Equal(t, "app.listen(port)", matches[0].Text)
+		assert.Equal(t, projectPath, matches[0].ProjectPath)
+	})
+
+	t.Run("Finds nothing when no line matches", func(t *testing.T) {
+		matches, err := searchProject(context.Background(), regexp.MustCompile(`not in any file`), projectPath)
+		assert.Nil(t, err)
+		assert.Empty(t, matches)
+	})
+
+	t.Run("Stops when it is interrupted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := searchProject(ctx, regexp.MustCompile(`listen\(`), projectPath)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
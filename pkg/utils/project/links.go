@@ -0,0 +1,102 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// linkEnvNamePattern : the names an environment variable can have in a container
+var linkEnvNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ListProjectLinks : The links of a project to the other projects it depends on
+func ListProjectLinks(httpClient utils.HTTPClient, projectID string) ([]apiroutes.ProjectLink, *ProjectError) {
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	links, err := client.GetProjectLinks(projectID)
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	if links == nil {
+		links = []apiroutes.ProjectLink{}
+	}
+	return links, nil
+}
+
+// AddProjectLink : Links a project to the target project on the same Codewind, so that the URL of the target is
+// injected into the project as the environment variable envName
+func AddProjectLink(httpClient utils.HTTPClient, projectID string, targetID string, envName string) *ProjectError {
+	envName = strings.TrimSpace(envName)
+	projErr := checkLinkEnvName(envName)
+	if projErr != nil {
+		return projErr
+	}
+	if !IsProjectIDValid(targetID) {
+		err := errors.New("Target " + textInvalidProjectID)
+		return &ProjectError{errOpInvalidID, err, err.Error()}
+	}
+	if targetID == projectID {
+		err := errors.New("A project cannot be linked to itself")
+		return &ProjectError{errOpLink, err, err.Error()}
+	}
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return projErr
+	}
+	err := client.CreateProjectLink(projectID, targetID, envName)
+	if err != nil {
+		return &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return nil
+}
+
+// RemoveProjectLink : Removes the link of a project whose URL is injected as the environment variable envName
+func RemoveProjectLink(httpClient utils.HTTPClient, projectID string, envName string) *ProjectError {
+	envName = strings.TrimSpace(envName)
+	projErr := checkLinkEnvName(envName)
+	if projErr != nil {
+		return projErr
+	}
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return projErr
+	}
+	err := client.DeleteProjectLink(projectID, envName)
+	if err != nil {
+		return &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return nil
+}
+
+func checkLinkEnvName(envName string) *ProjectError {
+	if !linkEnvNamePattern.MatchString(envName) {
+		err := errors.New("The environment variable '" + envName + "' must start with a letter or '_' and only contain letters, numbers and '_'")
+		return &ProjectError{errOpLink, err, err.Error()}
+	}
+	return nil
+}
+
+// newProjectClient : a client of the PFE API of the Codewind the project is bound to
+func newProjectClient(httpClient utils.HTTPClient, projectID string) (*apiroutes.PFEClient, *ProjectError) {
+	host, projErr := getProjectHost(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return apiroutes.NewPFEClientForHost(httpClient, host), nil
+}
@@ -0,0 +1,51 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddProjectLink(t *testing.T) {
+	projectID := "a9384430-f177-11e9-b862-edc28aca827a"
+
+	t.Run("Rejects an environment variable name a container cannot have", func(t *testing.T) {
+		for _, envName := range []string{"", "1_URL", "BACKEND-URL", "BACKEND URL"} {
+			projErr := AddProjectLink(nil, projectID, "b9384430-f177-11e9-b862-edc28aca827a", envName)
+			assert.Equal(t, errOpLink, projErr.Op, envName)
+		}
+	})
+
+	t.Run("Rejects an invalid target project ID", func(t *testing.T) {
+		projErr := AddProjectLink(nil, projectID, "not a project", "BACKEND_URL")
+		assert.Equal(t, errOpInvalidID, projErr.Op)
+	})
+
+	t.Run("Rejects a link of a project to itself", func(t *testing.T) {
+		projErr := AddProjectLink(nil, projectID, projectID, "BACKEND_URL")
+		assert.Equal(t, errOpLink, projErr.Op)
+	})
+}
+
+func TestRemoveProjectLink(t *testing.T) {
+	t.Run("Rejects an invalid project ID", func(t *testing.T) {
+		projErr := RemoveProjectLink(nil, "not a project", "BACKEND_URL")
+		assert.Equal(t, errOpInvalidID, projErr.Op)
+	})
+
+	t.Run("Rejects an environment variable name a container cannot have", func(t *testing.T) {
+		projErr := RemoveProjectLink(nil, "a9384430-f177-11e9-b862-edc28aca827a", "$URL")
+		assert.Equal(t, errOpLink, projErr.Op)
+	})
+}
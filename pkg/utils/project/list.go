@@ -0,0 +1,60 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+)
+
+// ListProjects : Lists the projects on a connection. Unless all is set, only the projects named with the
+// connection's project prefix are returned
+func ListProjects(httpClient utils.HTTPClient, conID string, all bool) ([]apiroutes.Project, *ProjectError) {
+	connection, conErr := connections.GetConnectionByID(conID)
+	if conErr != nil {
+		return nil, &ProjectError{errOpConNotFound, conErr.Err, conErr.Error()}
+	}
+	host, conErr := connections.GetPFEOrigin(conID)
+	if conErr != nil {
+		return nil, &ProjectError{errOpConNotFound, conErr.Err, conErr.Error()}
+	}
+	projects, err := apiroutes.NewPFEClientForHost(httpClient, host).GetProjects()
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	if all {
+		return projects, nil
+	}
+	return FilterProjectsByPrefix(projects, connection.ProjectPrefix), nil
+}
+
+// FilterProjectsByPrefix : Returns the projects whose names start with the prefix
+func FilterProjectsByPrefix(projects []apiroutes.Project, prefix string) []apiroutes.Project {
+	filtered := []apiroutes.Project{}
+	for _, project := range projects {
+		if strings.HasPrefix(project.Name, prefix) {
+			filtered = append(filtered, project)
+		}
+	}
+	return filtered
+}
+
+// ApplyProjectPrefix : Adds the prefix to a project name, unless it is already there
+func ApplyProjectPrefix(name string, prefix string) string {
+	if strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
@@ -0,0 +1,39 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FilterProjectsByPrefix(t *testing.T) {
+	projects := []apiroutes.Project{{Name: "team-a-node"}, {Name: "team-b-java"}, {Name: "team-a-go"}}
+
+	t.Run("Keeps only projects with the prefix", func(t *testing.T) {
+		filtered := FilterProjectsByPrefix(projects, "team-a-")
+		assert.Len(t, filtered, 2)
+		assert.Equal(t, "team-a-go", filtered[1].Name)
+	})
+
+	t.Run("Keeps every project without a prefix", func(t *testing.T) {
+		assert.Len(t, FilterProjectsByPrefix(projects, ""), 3)
+	})
+}
+
+func Test_ApplyProjectPrefix(t *testing.T) {
+	assert.Equal(t, "team-a-node", ApplyProjectPrefix("node", "team-a-"))
+	assert.Equal(t, "team-a-node", ApplyProjectPrefix("team-a-node", "team-a-"))
+	assert.Equal(t, "node", ApplyProjectPrefix("node", ""))
+}
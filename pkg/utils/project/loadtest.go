@@ -0,0 +1,191 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+)
+
+// LoadTestRun : The metrics of one load run of a project. Response time is in milliseconds, throughput in
+// requests per second, CPU as a fraction of one core and memory in bytes
+type LoadTestRun struct {
+	Time         time.Time `json:"time"`
+	Description  string    `json:"description"`
+	Duration     float64   `json:"durationSeconds"`
+	Hits         int64     `json:"hits"`
+	ResponseTime float64   `json:"responseTimeMs"`
+	Throughput   float64   `json:"throughput"`
+	CPU          float64   `json:"cpu"`
+	Memory       float64   `json:"memory"`
+}
+
+// loadTestCSVHeader : the columns WriteLoadTestCSV writes, in the order of the fields of LoadTestRun
+var loadTestCSVHeader = []string{"time", "description", "durationSeconds", "hits", "responseTimeMs", "throughput", "cpu", "memory"}
+
+// StartLoadTest : Asks Codewind to run the load test of a project, configured in its load-test/config.json
+func StartLoadTest(httpClient utils.HTTPClient, projectID string, description string) *ProjectError {
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return projErr
+	}
+	err := client.StartLoadTest(projectID, description)
+	if err != nil {
+		return &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return nil
+}
+
+// CancelLoadTest : Asks Codewind to stop the load run a project is running
+func CancelLoadTest(httpClient utils.HTTPClient, projectID string) *ProjectError {
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return projErr
+	}
+	err := client.CancelLoadTest(projectID)
+	if err != nil {
+		return &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return nil
+}
+
+// GetLoadTestResults : The metrics of each load run of a project, oldest first
+func GetLoadTestResults(httpClient utils.HTTPClient, projectID string) ([]LoadTestRun, *ProjectError) {
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	metrics := map[string]*apiroutes.ProjectMetrics{}
+	for _, metricsType := range []string{apiroutes.MetricsHTTP, apiroutes.MetricsCPU, apiroutes.MetricsMemory} {
+		typeMetrics, err := client.GetProjectMetrics(projectID, metricsType)
+		if err != nil {
+			return nil, &ProjectError{errOpResponse, err, err.Error()}
+		}
+		metrics[metricsType] = typeMetrics
+	}
+	return buildLoadTestRuns(metrics[apiroutes.MetricsHTTP], metrics[apiroutes.MetricsCPU], metrics[apiroutes.MetricsMemory]), nil
+}
+
+// WriteLoadTestCSV : Writes load runs as CSV with a header row
+func WriteLoadTestCSV(w io.Writer, runs []LoadTestRun) error {
+	writer := csv.NewWriter(w)
+	writer.Write(loadTestCSVHeader)
+	for _, run := range runs {
+		writer.Write([]string{
+			run.Time.UTC().Format(time.RFC3339),
+			run.Description,
+			formatMetric(run.Duration),
+			strconv.FormatInt(run.Hits, 10),
+			formatMetric(run.ResponseTime),
+			formatMetric(run.Throughput),
+			formatMetric(run.CPU),
+			formatMetric(run.Memory),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func getProjectHost(projectID string) (string, *ProjectError) {
+	if !IsProjectIDValid(projectID) {
+		err := errors.New(textInvalidProjectID)
+		return "", &ProjectError{errOpInvalidID, err, err.Error()}
+	}
+	conID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return "", projErr
+	}
+	host, conErr := connections.GetPFEOrigin(conID)
+	if conErr != nil {
+		return "", &ProjectError{errOpConNotFound, conErr.Err, conErr.Error()}
+	}
+	return host, nil
+}
+
+// buildLoadTestRuns : Joins the metrics of each type recorded for the same load run, which share its start time
+func buildLoadTestRuns(httpMetrics, cpuMetrics, memoryMetrics *apiroutes.ProjectMetrics) []LoadTestRun {
+	runs := map[int64]*LoadTestRun{}
+	runFor := func(metric apiroutes.LoadRunMetric) *LoadTestRun {
+		run, ok := runs[metric.Time]
+		if !ok {
+			run = &LoadTestRun{Time: time.Unix(0, metric.Time*int64(time.Millisecond)).UTC(), Description: metric.Desc}
+			if metric.EndTime > metric.Time {
+				run.Duration = float64(metric.EndTime-metric.Time) / 1000
+			}
+			runs[metric.Time] = run
+		}
+		return run
+	}
+
+	if httpMetrics != nil {
+		for _, metric := range httpMetrics.Metrics {
+			run := runFor(metric)
+			var endpoints []struct {
+				Hits                int64   `json:"hits"`
+				AverageResponseTime float64 `json:"averageResponseTime"`
+			}
+			json.Unmarshal(metric.Value.Data, &endpoints)
+			totalTime := 0.0
+			for _, endpoint := range endpoints {
+				run.Hits += endpoint.Hits
+				totalTime += endpoint.AverageResponseTime * float64(endpoint.Hits)
+			}
+			// Weight the response time of each endpoint by how often it was hit
+			if run.Hits > 0 {
+				run.ResponseTime = totalTime / float64(run.Hits)
+			}
+			if run.Duration > 0 {
+				run.Throughput = float64(run.Hits) / run.Duration
+			}
+		}
+	}
+	if cpuMetrics != nil {
+		for _, metric := range cpuMetrics.Metrics {
+			run := runFor(metric)
+			run.CPU = processMean(metric.Value.Data)
+		}
+	}
+	if memoryMetrics != nil {
+		for _, metric := range memoryMetrics.Metrics {
+			run := runFor(metric)
+			run.Memory = processMean(metric.Value.Data)
+		}
+	}
+
+	sorted := []LoadTestRun{}
+	for _, run := range runs {
+		sorted = append(sorted, *run)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	return sorted
+}
+
+func processMean(data json.RawMessage) float64 {
+	var summary struct {
+		ProcessMean float64 `json:"processMean"`
+	}
+	json.Unmarshal(data, &summary)
+	return summary.ProcessMean
+}
+
+func formatMetric(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,63 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTestResults(t *testing.T) {
+	parse := func(response string) *apiroutes.ProjectMetrics {
+		var metrics apiroutes.ProjectMetrics
+		json.Unmarshal([]byte(response), &metrics)
+		return &metrics
+	}
+	httpMetrics := parse(`{"type":"http","metrics":[
+		{"time":1571000000000,"endTime":1571000010000,"desc":"baseline","value":{"data":[
+			{"url":"/","hits":30,"averageResponseTime":10},{"url":"/health","hits":10,"averageResponseTime":50}]}},
+		{"time":1570000000000,"endTime":1570000020000,"desc":"first","value":{"data":[{"url":"/","hits":20,"averageResponseTime":5}]}}]}`)
+	cpuMetrics := parse(`{"type":"cpu","metrics":[{"time":1571000000000,"desc":"baseline","value":{"data":{"processMean":0.5}}}]}`)
+	memoryMetrics := parse(`{"type":"memory","metrics":[{"time":1571000000000,"desc":"baseline","value":{"data":{"processMean":1048576}}}]}`)
+
+	t.Run("Joins the metrics of each run, oldest first", func(t *testing.T) {
+		runs := buildLoadTestRuns(httpMetrics, cpuMetrics, memoryMetrics)
+		assert.Len(t, runs, 2)
+		assert.Equal(t, "first", runs[0].Description)
+		assert.Equal(t, 1.0, runs[0].Throughput)
+		assert.Equal(t, "baseline", runs[1].Description)
+		assert.Equal(t, int64(40), runs[1].Hits)
+		assert.Equal(t, 20.0, runs[1].ResponseTime)
+		assert.Equal(t, 4.0, runs[1].Throughput)
+		assert.Equal(t, 0.5, runs[1].CPU)
+		assert.Equal(t, 1048576.0, runs[1].Memory)
+	})
+
+	t.Run("Writes runs as CSV", func(t *testing.T) {
+		var out bytes.Buffer
+		err := WriteLoadTestCSV(&out, buildLoadTestRuns(httpMetrics, nil, nil))
+		assert.Nil(t, err)
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		assert.Equal(t, "time,description,durationSeconds,hits,responseTimeMs,throughput,cpu,memory", lines[0])
+		assert.Equal(t, "2019-10-13T20:53:20Z,baseline,10,40,20,4,0,0", lines[2])
+	})
+
+	t.Run("Rejects an invalid project ID", func(t *testing.T) {
+		projErr := StartLoadTest(nil, "not a project", "")
+		assert.Equal(t, errOpInvalidID, projErr.Op)
+	})
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+)
+
+// metricsCollectors : the languages PFE can add a metrics collector to, and the collector it adds
+var metricsCollectors = map[string]string{
+	"nodejs": "appmetrics",
+	"java":   "javametrics",
+}
+
+// MetricsInjectionResult : Whether a project's language supports metrics injection, and whether it is now injected.
+// SupportedLanguages names the languages which do when the project's does not
+type MetricsInjectionResult struct {
+	ProjectID          string   `json:"projectID"`
+	Language           string   `json:"language"`
+	Supported          bool     `json:"supported"`
+	SupportedLanguages []string `json:"supportedLanguages,omitempty"`
+	Collector          string   `json:"collector,omitempty"`
+	Injected           bool     `json:"injected"`
+}
+
+// SetMetricsInjection : Asks Codewind to add the metrics collector for its language to a project's build, or to
+// take it out again when enable is false. Codewind rebuilds the project to apply the change. A project whose
+// language has no collector is left as it is and reported as not supported
+func SetMetricsInjection(httpClient utils.HTTPClient, projectID string, enable bool) (*MetricsInjectionResult, *ProjectError) {
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return setMetricsInjection(client, projectID, enable)
+}
+
+func setMetricsInjection(client *apiroutes.PFEClient, projectID string, enable bool) (*MetricsInjectionResult, *ProjectError) {
+	project, err := client.GetProject(projectID)
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	result := MetricsInjectionResult{ProjectID: projectID, Language: project.Language, Injected: project.InjectMetrics}
+	result.Collector, result.Supported = metricsCollectors[strings.ToLower(project.Language)]
+	if !result.Supported {
+		result.SupportedLanguages = metricsLanguages()
+		return &result, nil
+	}
+	err = client.InjectMetrics(projectID, enable)
+	if err != nil {
+		return &result, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	result.Injected = enable
+	return &result, nil
+}
+
+func metricsLanguages() []string {
+	languages := []string{}
+	for language := range metricsCollectors {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
@@ -0,0 +1,65 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMetricsInjection(t *testing.T) {
+	t.Run("Rejects an invalid project ID", func(t *testing.T) {
+		_, projErr := SetMetricsInjection(nil, "not a project", true)
+		assert.Equal(t, errOpInvalidID, projErr.Op)
+	})
+	t.Run("Lists the supported languages", func(t *testing.T) {
+		assert.Equal(t, []string{"java", "nodejs"}, metricsLanguages())
+	})
+	t.Run("Leaves a project in an unsupported language unchanged", func(t *testing.T) {
+		injected := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				injected = true
+			}
+			json.NewEncoder(w).Encode(apiroutes.Project{ProjectID: "a1b2", Language: "python"})
+		}))
+		defer server.Close()
+		result, projErr := setMetricsInjection(apiroutes.NewPFEClientForHost(http.DefaultClient, server.URL), "a1b2", true)
+		assert.Nil(t, projErr)
+		assert.False(t, result.Supported)
+		assert.Equal(t, []string{"java", "nodejs"}, result.SupportedLanguages)
+		assert.False(t, result.Injected)
+		assert.False(t, injected)
+	})
+	t.Run("Injects the collector of a supported language", func(t *testing.T) {
+		var enable map[string]bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == "POST" {
+				json.NewDecoder(r.Body).Decode(&enable)
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			json.NewEncoder(w).Encode(apiroutes.Project{ProjectID: "a1b2", Language: "nodejs"})
+		}))
+		defer server.Close()
+		result, projErr := setMetricsInjection(apiroutes.NewPFEClientForHost(http.DefaultClient, server.URL), "a1b2", true)
+		assert.Nil(t, projErr)
+		assert.Equal(t, "appmetrics", result.Collector)
+		assert.True(t, result.Injected)
+		assert.Equal(t, map[string]bool{"enable": true}, enable)
+	})
+}
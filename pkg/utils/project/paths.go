@@ -0,0 +1,119 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+)
+
+// WorkspaceDirectory : where the Codewind container keeps its projects, on the workspace volume of local and
+// remote deployments alike
+const WorkspaceDirectory = "/codewind-workspace"
+
+// ProjectLocation : where a project is on this machine and in the workspace of the Codewind it is bound to
+type ProjectLocation struct {
+	ProjectID    string `json:"projectID"`
+	ConnectionID string `json:"connectionID"`
+	// LocalPath is where the project was last bound or synced from, empty when that was not on this machine
+	LocalPath string `json:"localPath,omitempty"`
+	// WorkspacePath is the directory of the project in the Codewind container
+	WorkspacePath string `json:"workspacePath"`
+	// WorkspaceRelativePath is the directory of the project relative to the root of the workspace volume
+	WorkspaceRelativePath string `json:"workspaceRelativePath"`
+	// Volume is the docker volume holding the workspace of a local connection, a remote workspace is in its pod
+	Volume string `json:"volume,omitempty"`
+}
+
+// FileLocation : a file of a project, on this machine and in the Codewind container
+type FileLocation struct {
+	LocalPath     string `json:"localPath"`
+	WorkspacePath string `json:"workspacePath"`
+}
+
+// LocateProject : Finds where a project is on this machine and in the workspace of its connection, from the
+// directory Codewind keeps it in
+func LocateProject(httpClient utils.HTTPClient, projectID string) (*ProjectLocation, *ProjectError) {
+	client, projErr := newProjectClient(httpClient, projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	connection, projErr := GetConnection(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	project, err := client.GetProject(projectID)
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	directory := workspaceProjectDirectory(project)
+	if directory == "" {
+		err = errors.New("Codewind did not report the directory of project " + projectID)
+		return nil, &ProjectError{errOpNotFound, err, err.Error()}
+	}
+	location := ProjectLocation{
+		ProjectID:             projectID,
+		ConnectionID:          connection.ID,
+		LocalPath:             connection.Path,
+		WorkspacePath:         path.Join(WorkspaceDirectory, directory),
+		WorkspaceRelativePath: directory,
+	}
+	if connections.IsLocal(connection.ID) {
+		location.Volume = connections.LocalProfileOf(connection.ID).WorkspaceVolume()
+	}
+	return &location, nil
+}
+
+// workspaceProjectDirectory : the directory of the workspace a project is in, the last part of the location
+// Codewind reports for it, which may be a Windows path, or else the project name which Codewind names it after
+func workspaceProjectDirectory(project *apiroutes.Project) string {
+	location := strings.TrimRight(strings.Replace(project.LocOnDisk, "\\", "/", -1), "/")
+	if location != "" {
+		return path.Base(location)
+	}
+	return project.Name
+}
+
+// LocateFile : The location of a file of the project on this machine and in the Codewind container, given by
+// either. A path within WorkspacePath is taken to be in the container, any other to be on this machine
+func (location ProjectLocation) LocateFile(file string) (*FileLocation, error) {
+	if location.LocalPath == "" {
+		return nil, errors.New("Project " + location.ProjectID + " was not bound or synced from this machine")
+	}
+	workspaceFile := path.Clean(filepath.ToSlash(file))
+	if workspaceFile == location.WorkspacePath || strings.HasPrefix(workspaceFile, location.WorkspacePath+"/") {
+		relativePath := strings.TrimPrefix(strings.TrimPrefix(workspaceFile, location.WorkspacePath), "/")
+		return &FileLocation{
+			LocalPath:     filepath.Join(location.LocalPath, filepath.FromSlash(relativePath)),
+			WorkspacePath: workspaceFile,
+		}, nil
+	}
+
+	localFile, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	relativePath, err := filepath.Rel(location.LocalPath, localFile)
+	if err != nil || relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return nil, errors.New(file + " is not in project " + location.ProjectID + " at " + location.LocalPath + " or " + location.WorkspacePath)
+	}
+	return &FileLocation{
+		LocalPath:     localFile,
+		WorkspacePath: path.Join(location.WorkspacePath, filepath.ToSlash(relativePath)),
+	}, nil
+}
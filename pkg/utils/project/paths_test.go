@@ -0,0 +1,74 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspaceProjectDirectory(t *testing.T) {
+	tests := map[string]struct {
+		project   apiroutes.Project
+		directory string
+	}{
+		"location in the workspace":  {apiroutes.Project{Name: "myapp", LocOnDisk: "/codewind-workspace/myapp"}, "myapp"},
+		"location on a Windows host": {apiroutes.Project{Name: "myapp", LocOnDisk: "C:\\codewind-data\\myapp\\"}, "myapp"},
+		"no location":                {apiroutes.Project{Name: "myapp"}, "myapp"},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.directory, workspaceProjectDirectory(&test.project))
+		})
+	}
+}
+
+func TestLocateFile(t *testing.T) {
+	localPath, _ := filepath.Abs(filepath.Join("projects", "myapp"))
+	location := ProjectLocation{
+		ProjectID:             "project",
+		LocalPath:             localPath,
+		WorkspacePath:         "/codewind-workspace/myapp",
+		WorkspaceRelativePath: "myapp",
+	}
+
+	t.Run("Asserts a local file is found in the workspace", func(t *testing.T) {
+		file, err := location.LocateFile(filepath.Join(localPath, "src", "app.js"))
+		assert.Nil(t, err)
+		assert.Equal(t, "/codewind-workspace/myapp/src/app.js", file.WorkspacePath)
+	})
+
+	t.Run("Asserts a workspace file is found on this machine", func(t *testing.T) {
+		file, err := location.LocateFile("/codewind-workspace/myapp/src/app.js")
+		assert.Nil(t, err)
+		assert.Equal(t, filepath.Join(localPath, "src", "app.js"), file.LocalPath)
+	})
+
+	t.Run("Asserts a file outside the project is refused", func(t *testing.T) {
+		_, err := location.LocateFile(filepath.Join(localPath, "..", "other", "app.js"))
+		assert.NotNil(t, err)
+		_, err = location.LocateFile("/codewind-workspace/myapp2/app.js")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Asserts files cannot be found for a project not bound from this machine", func(t *testing.T) {
+		unbound := location
+		unbound.LocalPath = ""
+		_, err := location.LocateFile("/codewind-workspace/myapp/app.js")
+		assert.Nil(t, err)
+		_, err = unbound.LocateFile("/codewind-workspace/myapp/app.js")
+		assert.NotNil(t, err)
+	})
+}
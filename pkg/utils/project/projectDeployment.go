@@ -17,22 +17,36 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	"github.com/eclipse/codewind-installer/config"
 
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/migrate"
 )
 
 // ConnectionFile : Structure of the project-connections file
 type ConnectionFile struct {
 	SchemaVersion int    `json:"schemaVersion"`
 	ID            string `json:"connectionID"`
+	// Path is where the project was bound from on this machine, so that it can be searched
+	Path string `json:"path,omitempty"`
 }
 
 const connectionTargetSchemaVersion = 1
 
+// ConnectionFileSchema : The schema versions of the project-connections files, Steps[i] migrates a file from
+// version i. A step must be appended, and connectionTargetSchemaVersion incremented, when changing ConnectionFile
+var ConnectionFileSchema = migrate.Schema{
+	Name:         "project connection",
+	VersionField: "schemaVersion",
+	Steps: []migrate.Step{
+		{Description: "Record the schema version", Migrate: func(document map[string]interface{}) error { return nil }},
+	},
+}
+
 // SetConnection : Add a connection target
 func SetConnection(projectID string, conID string) *ProjectError {
 
@@ -67,6 +81,23 @@ func SetConnection(projectID string, conID string) *ProjectError {
 	return nil
 }
 
+// SetProjectPath : Record the local path of a project in its connection file
+func SetProjectPath(projectID string, projectPath string) *ProjectError {
+	absPath, err := filepath.Abs(projectPath)
+	if err != nil {
+		return &ProjectError{errBadPath, err, err.Error()}
+	}
+	connectionTargets, projError := loadConnectionFile(projectID)
+	if projError != nil {
+		return projError
+	}
+	if connectionTargets.Path == absPath {
+		return nil
+	}
+	connectionTargets.Path = absPath
+	return saveConnectionTargets(projectID, connectionTargets)
+}
+
 // ResetConnectionFile : Reset target file
 func ResetConnectionFile(projectID string) *ProjectError {
 	connectionTargets := ConnectionFile{
@@ -102,8 +133,8 @@ func GetConnectionURL(projectID string) (string, *ProjectError) {
 		return "", &ProjectError{errOpNotFound, conErr, conErr.Error()}
 	}
 
-	if conID == "local" {
-		return config.PFEApiRoute(), nil
+	if connections.IsLocal(conID) {
+		return config.ProfilePFEApiRoute(connections.LocalProfileOf(conID)), nil
 	}
 	return projectConInfo.URL, nil
 }
@@ -153,9 +184,42 @@ func RemoveConnectionFile(projectID string) *ProjectError {
 	if err != nil {
 		return &ProjectError{errOpFileDelete, err, err.Error()}
 	}
+	removeSyncState(projectID)
+	removeBindProgress(projectID)
 	return nil
 }
 
+// ListProjectIDs : Returns the IDs of all projects which have a connection file
+func ListProjectIDs() ([]string, *ProjectError) {
+	files, err := ioutil.ReadDir(getProjectConnectionConfigDir())
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+	projectIDs := []string{}
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") {
+			projectIDs = append(projectIDs, strings.TrimSuffix(file.Name(), ".json"))
+		}
+	}
+	return projectIDs, nil
+}
+
+// ConnectionFilenames : Returns the paths of the connection files of all projects
+func ConnectionFilenames() ([]string, *ProjectError) {
+	projectIDs, projErr := ListProjectIDs()
+	if projErr != nil {
+		return nil, projErr
+	}
+	filenames := []string{}
+	for _, projectID := range projectIDs {
+		filenames = append(filenames, getConnectionFilename(projectID))
+	}
+	return filenames, nil
+}
+
 // getProjectConnectionConfigDir : Get directory path to the connection file
 func getProjectConnectionConfigDir() string {
 	val, isSet := os.LookupEnv("CHE_API_EXTERNAL")
@@ -207,6 +271,12 @@ func loadConnectionFile(projectID string) (*ConnectionFile, *ProjectError) {
 		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
 	}
 
+	// files written by older versions of cwctl are read at the current schema
+	file, migErr := migrate.Upgrade(ConnectionFileSchema, file)
+	if migErr != nil {
+		return nil, &ProjectError{errOpFileParse, migErr.Err, migErr.Desc}
+	}
+
 	// parse the file
 	projectConnectionTargets := ConnectionFile{}
 	err = json.Unmarshal([]byte(file), &projectConnectionTargets)
@@ -24,17 +24,29 @@ type ProjectError struct {
 }
 
 const (
-	errBadPath       = "proj_path"     // Invalid path provided
-	errBadType       = "proj_type"     // Invalid type provided
-	errOpResponse    = "proj_response" // Bad response to http
-	errOpFileParse   = "proj_parse"
-	errOpFileLoad    = "proj_load"
-	errOpFileWrite   = "proj_write"
-	errOpFileDelete  = "proj_delete"
-	errOpConflict    = "proj_conflict"
-	errOpNotFound    = "proj_notfound"
-	errOpConNotFound = "connection_notfound"
-	errOpInvalidID   = "proj_id_invalid"
+	errBadPath           = "proj_path"     // Invalid path provided
+	errBadType           = "proj_type"     // Invalid type provided
+	errOpResponse        = "proj_response" // Bad response to http
+	errOpFileParse       = "proj_parse"
+	errOpFileLoad        = "proj_load"
+	errOpFileWrite       = "proj_write"
+	errOpFileDelete      = "proj_delete"
+	errOpConflict        = "proj_conflict"
+	errOpNotFound        = "proj_notfound"
+	errOpConNotFound     = "connection_notfound"
+	errOpInvalidID       = "proj_id_invalid"
+	errOpInvalidName     = "proj_name_invalid"
+	errOpExec            = "proj_exec"
+	errOpSetting         = "proj_setting"
+	errOpCleanup         = "proj_cleanup"
+	errOpGitClone        = "proj_git_clone"
+	errOpVerify          = "proj_verify"
+	errOpBindInterrupted = "proj_bind_interrupted"
+	errOpBindAborted     = "proj_bind_aborted"
+	errOpTransfer        = "proj_transfer"
+	errOpLink            = "proj_link"
+	errOpExtension       = "proj_extension"
+	errOpGitHub          = "proj_github"
 )
 
 const (
@@ -47,6 +59,8 @@ const (
 	textAPINotFound      = "unable to find requested resource on Codewind server"
 	textNoProjects       = "unable to find any codewind projects"
 	textUpgradeError     = "error occurred upgrading projects"
+	textNoContainer      = "unable to find a running container for the project"
+	textNoCommand        = "no command given to run in the project container"
 )
 
 // ProjectError : Error formatted in JSON containing an errorOp and a description from
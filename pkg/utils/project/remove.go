@@ -0,0 +1,130 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"os"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RemoveResult : What was cleaned up when removing a project
+type RemoveResult struct {
+	ProjectID   string   `json:"projectID"`
+	Containers  []string `json:"containers"`
+	Images      []string `json:"images"`
+	Volumes     []string `json:"volumes"`
+	DeletedPath string   `json:"deletedPath,omitempty"`
+}
+
+// RemoveProject : Unbinds a project from PFE, then removes its application containers and images on the local
+// connection, or its persistent volume claims on a Kubernetes connection, and forgets its connection. The local
+// project files are only deleted when deleteFiles is set
+func RemoveProject(httpClient utils.HTTPClient, projectID string, deleteFiles bool) (*RemoveResult, *ProjectError) {
+	if !IsProjectIDValid(projectID) {
+		err := errors.New(textInvalidProjectID)
+		return nil, &ProjectError{errOpInvalidID, err, err.Error()}
+	}
+	connectionFile, projErr := GetConnection(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	conID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	if deleteFiles && connectionFile.Path == "" {
+		err := errors.New("The path of project " + projectID + " is not known, so its files cannot be deleted")
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+
+	host, conErr := connections.GetPFEOrigin(conID)
+	if conErr != nil {
+		return nil, &ProjectError{errOpConNotFound, conErr.Err, conErr.Error()}
+	}
+	err := apiroutes.NewPFEClientForHost(httpClient, host).UnbindProject(projectID)
+	if err != nil {
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+
+	result := RemoveResult{ProjectID: projectID, Containers: []string{}, Images: []string{}, Volumes: []string{}}
+	if connections.IsLocal(conID) {
+		result.Containers, err = utils.RemoveProjectContainers(projectID)
+		if err != nil {
+			return nil, &ProjectError{errOpCleanup, err, err.Error()}
+		}
+		result.Images, err = utils.RemoveProjectImages(projectID)
+		if err != nil {
+			return nil, &ProjectError{errOpCleanup, err, err.Error()}
+		}
+	} else {
+		result.Volumes, err = removeProjectVolumeClaims(conID, projectID)
+		if err != nil {
+			return nil, &ProjectError{errOpCleanup, err, err.Error()}
+		}
+	}
+
+	projErr = RemoveConnectionFile(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	if deleteFiles {
+		err = os.RemoveAll(connectionFile.Path)
+		if err != nil {
+			return nil, &ProjectError{errOpFileDelete, err, err.Error()}
+		}
+		result.DeletedPath = connectionFile.Path
+	}
+	return &result, nil
+}
+
+// removeProjectVolumeClaims : Deletes the persistent volume claims PFE keeps a project's data in, which
+// outlive the project's deployment, from the namespace of the Kubernetes context of the connection
+func removeProjectVolumeClaims(conID string, projectID string) ([]string, error) {
+	connections.SelectConnectionKubeContext(conID)
+	kubeConfig := kube.GetKubeClientConfig()
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return deleteVolumeClaims(clientset, namespace, projectID)
+}
+
+func deleteVolumeClaims(clientset kubernetes.Interface, namespace string, projectID string) ([]string, error) {
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(metav1.ListOptions{LabelSelector: "projectID=" + projectID})
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, claim := range claims.Items {
+		err = clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(claim.Name, &metav1.DeleteOptions{})
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, claim.Name)
+	}
+	return removed, nil
+}
@@ -0,0 +1,46 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_RemoveProject(t *testing.T) {
+	t.Run("Fails when the project ID is invalid", func(t *testing.T) {
+		_, err := RemoveProject(nil, "bad-project-id", false)
+		assert.Equal(t, errOpInvalidID, err.Op)
+	})
+	t.Run("Fails when the project has no connection", func(t *testing.T) {
+		_, err := RemoveProject(nil, "00000000-0000-0000-0000-000000000000", false)
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_DeleteVolumeClaims(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "cw-node-data", Namespace: "codewind", Labels: map[string]string{"projectID": testProjectID}}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "cw-other-data", Namespace: "codewind", Labels: map[string]string{"projectID": "other"}}},
+	)
+	removed, err := deleteVolumeClaims(clientset, "codewind", testProjectID)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cw-node-data"}, removed)
+
+	claims, _ := clientset.CoreV1().PersistentVolumeClaims("codewind").List(metav1.ListOptions{})
+	assert.Len(t, claims.Items, 1)
+	assert.Equal(t, "cw-other-data", claims.Items[0].Name)
+}
@@ -0,0 +1,234 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+)
+
+// settingKind : how the value of a .cw-settings field is given and checked
+type settingKind int
+
+const (
+	settingPort settingKind = iota
+	settingURLPath
+	settingBool
+	settingList
+)
+
+// projectSettings are the .cw-settings fields that can be changed, keys of nested fields are joined with '.'
+var projectSettings = map[string]settingKind{
+	"contextRoot":               settingURLPath,
+	"healthCheck":               settingURLPath,
+	"internalPort":              settingPort,
+	"internalDebugPort":         settingPort,
+	"isHttps":                   settingBool,
+	"ignoredPaths":              settingList,
+	"contextPaths":              settingList,
+	"mavenProfiles":             settingList,
+	"mavenProperties":           settingList,
+	"watchedFiles.includeFiles": settingList,
+	"watchedFiles.excludeFiles": settingList,
+}
+
+// ProjectSettingKeys : The .cw-settings fields that can be changed, in alphabetical order
+func ProjectSettingKeys() []string {
+	keys := []string{}
+	for key := range projectSettings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetProjectSettings : Reads the .cw-settings of a project. projectPath may be empty, in which
+// case the path the project was last bound or synced from is used
+func GetProjectSettings(projectID string, projectPath string) (map[string]interface{}, *ProjectError) {
+	settingsPath, projErr := getSettingsPath(projectID, projectPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+	return readSettingsFile(settingsPath)
+}
+
+// GetProjectSetting : Reads one field of the .cw-settings of a project, nil when it is not set
+func GetProjectSetting(projectID string, projectPath string, key string) (interface{}, *ProjectError) {
+	if _, ok := projectSettings[key]; !ok {
+		return nil, unknownSettingError(key)
+	}
+	settings, projErr := GetProjectSettings(projectID, projectPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+	topKey, nestedKey := splitSettingKey(key)
+	if nestedKey == "" {
+		return settings[topKey], nil
+	}
+	nested, _ := settings[topKey].(map[string]interface{})
+	return nested[nestedKey], nil
+}
+
+// SetProjectSetting : Checks and saves a field of the .cw-settings of a project, then asks PFE to apply it.
+// Lists are given as a JSON array or comma separated. Other fields of the file are kept as they are
+func SetProjectSetting(httpClient utils.HTTPClient, projectID string, projectPath string, key string, value string) (map[string]interface{}, *ProjectError) {
+	kind, ok := projectSettings[key]
+	if !ok {
+		return nil, unknownSettingError(key)
+	}
+	parsedValue, err := parseSettingValue(kind, value)
+	if err != nil {
+		err = errors.New("Invalid value for " + key + ": " + err.Error())
+		return nil, &ProjectError{errOpSetting, err, err.Error()}
+	}
+	settingsPath, projErr := getSettingsPath(projectID, projectPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+	settings, projErr := readSettingsFile(settingsPath)
+	if projErr != nil {
+		return nil, projErr
+	}
+
+	topKey, nestedKey := splitSettingKey(key)
+	if nestedKey == "" {
+		settings[topKey] = parsedValue
+	} else {
+		nested, _ := settings[topKey].(map[string]interface{})
+		if nested == nil {
+			nested = map[string]interface{}{}
+		}
+		nested[nestedKey] = parsedValue
+		settings[topKey] = nested
+	}
+
+	body, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	err = ioutil.WriteFile(settingsPath, body, 0644)
+	if err != nil {
+		return nil, &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+
+	conID, projErr := GetConnectionID(projectID)
+	if projErr != nil {
+		return nil, projErr
+	}
+	host, conErr := connections.GetPFEOrigin(conID)
+	if conErr != nil {
+		return nil, &ProjectError{errOpConNotFound, conErr.Err, conErr.Error()}
+	}
+	err = apiroutes.NewPFEClientForHost(httpClient, host).UpdateProjectSettings(projectID, map[string]interface{}{topKey: settings[topKey]})
+	if err != nil {
+		err = errors.New(".cw-settings was saved but Codewind did not apply it: " + err.Error())
+		return nil, &ProjectError{errOpResponse, err, err.Error()}
+	}
+	return settings, nil
+}
+
+// getSettingsPath : the .cw-settings file of a project
+func getSettingsPath(projectID string, projectPath string) (string, *ProjectError) {
+	if projectPath == "" {
+		connectionFile, projErr := loadConnectionFile(projectID)
+		if projErr != nil {
+			return "", projErr
+		}
+		projectPath = connectionFile.Path
+	}
+	if projectPath == "" {
+		err := errors.New("The path of project " + projectID + " is not known, give it with --path or sync the project")
+		return "", &ProjectError{errBadPath, err, err.Error()}
+	}
+	return filepath.Join(projectPath, ".cw-settings"), nil
+}
+
+func readSettingsFile(settingsPath string) (map[string]interface{}, *ProjectError) {
+	file, err := ioutil.ReadFile(settingsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = errors.New("No .cw-settings found at " + settingsPath)
+		}
+		return nil, &ProjectError{errOpFileLoad, err, err.Error()}
+	}
+	settings := map[string]interface{}{}
+	err = json.Unmarshal(file, &settings)
+	if err != nil {
+		return nil, &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	return settings, nil
+}
+
+// parseSettingValue : the value to save for a setting, or an error explaining why it is not valid
+func parseSettingValue(kind settingKind, value string) (interface{}, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case settingPort:
+		if value == "" {
+			return value, nil
+		}
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, errors.New("must be a port number between 1 and 65535")
+		}
+		return value, nil
+	case settingURLPath:
+		if value != "" && !strings.HasPrefix(value, "/") {
+			return nil, errors.New("must start with '/'")
+		}
+		return value, nil
+	case settingBool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.New("must be true or false")
+		}
+		return parsed, nil
+	default:
+		list := []string{}
+		if strings.HasPrefix(value, "[") {
+			err := json.Unmarshal([]byte(value), &list)
+			if err != nil {
+				return nil, errors.New("must be a JSON array of strings")
+			}
+			return list, nil
+		}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+}
+
+func splitSettingKey(key string) (string, string) {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func unknownSettingError(key string) *ProjectError {
+	err := errors.New("Unknown setting '" + key + "', must be one of: " + strings.Join(ProjectSettingKeys(), ", "))
+	return &ProjectError{errOpSetting, err, err.Error()}
+}
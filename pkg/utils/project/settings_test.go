@@ -0,0 +1,61 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectSettings(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "cwctl-settings")
+	defer os.RemoveAll(projectPath)
+	ioutil.WriteFile(filepath.Join(projectPath, ".cw-settings"), []byte(`{"internalPort":"3000","watchedFiles":{"includeFiles":["src"]}}`), 0644)
+
+	t.Run("Reads top level and nested settings", func(t *testing.T) {
+		value, projErr := GetProjectSetting("settingsproject", projectPath, "internalPort")
+		assert.Nil(t, projErr)
+		assert.Equal(t, "3000", value)
+		value, projErr = GetProjectSetting("settingsproject", projectPath, "watchedFiles.includeFiles")
+		assert.Nil(t, projErr)
+		assert.Equal(t, []interface{}{"src"}, value)
+	})
+
+	t.Run("Rejects unknown settings and invalid values before changing the file", func(t *testing.T) {
+		_, projErr := SetProjectSetting(nil, "settingsproject", projectPath, "notASetting", "1")
+		assert.Equal(t, errOpSetting, projErr.Op)
+		_, projErr = SetProjectSetting(nil, "settingsproject", projectPath, "internalPort", "70000")
+		assert.Equal(t, errOpSetting, projErr.Op)
+		_, projErr = SetProjectSetting(nil, "settingsproject", projectPath, "contextRoot", "api")
+		assert.Equal(t, errOpSetting, projErr.Op)
+		value, _ := GetProjectSetting("settingsproject", projectPath, "internalPort")
+		assert.Equal(t, "3000", value)
+	})
+}
+
+func TestParseSettingValue(t *testing.T) {
+	list, err := parseSettingValue(settingList, "src, test ,")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"src", "test"}, list)
+	list, err = parseSettingValue(settingList, `["a,b"]`)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a,b"}, list)
+	enabled, err := parseSettingValue(settingBool, "true")
+	assert.Nil(t, err)
+	assert.Equal(t, true, enabled)
+	_, err = parseSettingValue(settingBool, "yes")
+	assert.NotNil(t, err)
+}
@@ -14,27 +14,69 @@ package project
 import (
 	"bytes"
 	"compress/zlib"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/eclipse/codewind-installer/config"
+	"github.com/eclipse/codewind-installer/pkg/sechttp"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/filewatcher"
+	logr "github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
+// uploadBatchSize is how many files are sent in each request when PFE supports batched upload
+const uploadBatchSize = 50
+
+// uploadChunkSize is the largest part of a file sent in one request when PFE supports raw upload,
+// larger files are sent in chunks of this size
+const uploadChunkSize = 4 * 1024 * 1024
+
+// defaultSyncMaxFileSizeMB is the size above which files are not synced, unless syncMaxFileSize is configured
+const defaultSyncMaxFileSizeMB = 100
+
+// defaultSyncConcurrency is how many uploads run at once, unless syncConcurrency is configured
+const defaultSyncConcurrency = 4
+
+// MaxSyncConcurrency is the most uploads syncConcurrency may allow at once
+const MaxSyncConcurrency = 32
+
+// binarySniffSize is how much of a file is read to decide whether it is binary
+const binarySniffSize = 8000
+
 type (
 	// CompleteRequest is the request body format for calling the upload complete API
 	CompleteRequest struct {
 		FileList     []string `json:"fileList"`
 		ModifiedList []string `json:"modifiedList"`
-		TimeStamp    int64    `json:"timeStamp"`
+		// DeletedList is only sent to a PFE which advertises the deletions capability
+		DeletedList []string `json:"deletedList,omitempty"`
+		// RenamedList is only sent to a PFE which advertises the renames capability, the files
+		// are moved by PFE rather than uploaded again
+		RenamedList []RenamedPath `json:"renamedList,omitempty"`
+		TimeStamp   int64         `json:"timeStamp"`
+	}
+
+	// RenamedPath is a file, or a directory when IsDirectory is set, which was moved since the last sync
+	RenamedPath struct {
+		From        string `json:"from"`
+		To          string `json:"to"`
+		IsDirectory bool   `json:"isDirectory,omitempty"`
 	}
 
 	// FileUploadMsg is the message sent on uploading a file
@@ -42,6 +84,18 @@ type (
 		IsDirectory  bool   `json:"isDirectory"`
 		RelativePath string `json:"path"`
 		Message      string `json:"msg"`
+		// Encoding is "raw" when Message holds the bytes of the file rather than a JSON string of its text
+		Encoding string `json:"encoding,omitempty"`
+		// Offset and Size are set when Message holds the chunk starting at Offset of a file of Size bytes
+		Offset int64 `json:"offset,omitempty"`
+		Size   int64 `json:"size,omitempty"`
+		// Mode holds the permission bits of the file, which are not sent from Windows
+		Mode uint32 `json:"mode,omitempty"`
+	}
+
+	// BatchUploadMsg is the message sent on uploading several files at once
+	BatchUploadMsg struct {
+		Files []FileUploadMsg `json:"files"`
 	}
 	UploadedFile struct {
 		FilePath   string `json:"filePath"`
@@ -55,19 +109,16 @@ type (
 	}
 )
 
-// SyncProject syncs a project with its remote connection
-func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
+// SyncProject syncs a project with its remote connection. The path defaults to the one the project was last bound
+// or synced from, and the time of the last sync to the one recorded by it. Cancelling ctx stops finding and
+// uploading the files
+func SyncProject(ctx context.Context, c *cli.Context) (*SyncResponse, *ProjectError) {
 	projectPath := strings.TrimSpace(c.String("path"))
 	projectID := strings.TrimSpace(c.String("id"))
-	synctime := int64(c.Int("time"))
-
-	_, err := os.Stat(projectPath)
-	if err != nil {
-		return nil, &ProjectError{errBadPath, err, err.Error()}
-	}
+	bandwidthLimit := int64(c.Int("bandwidth-limit")) * 1024
 
 	if !ConnectionFileExists(projectID) {
-		fmt.Println("Project connection file does not exist, creating default local connection")
+		logr.Infoln("Project connection file does not exist, creating default local connection")
 		CreateConnectionFile(projectID)
 	}
 
@@ -76,6 +127,23 @@ func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
 	if projErr != nil {
 		return nil, projErr
 	}
+	if projectPath == "" {
+		connection, projErr := GetConnection(projectID)
+		if projErr != nil {
+			return nil, projErr
+		}
+		if connection.Path == "" {
+			err := errors.New("The path of project " + projectID + " is not known as it was not bound or synced from this machine, give it with --path")
+			return nil, &ProjectError{errBadPath, err, err.Error()}
+		}
+		projectPath = connection.Path
+	}
+
+	_, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+	SetProjectPath(projectID, projectPath)
 
 	conInfo, conInfoErr := connections.GetConnectionByID(conID)
 	if conInfoErr != nil {
@@ -83,18 +151,42 @@ func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
 	}
 
 	var conURL string
-	if conInfo.ID != "local" {
+	if !connections.IsLocal(conInfo.ID) {
 		conURL = conInfo.URL
 	} else {
-		conURL = config.PFEApiRoute()
+		conURL = config.ProfilePFEApiRoute(connections.LocalProfileOf(conInfo.ID))
+	}
+
+	// The time given overrides the recorded one, which callers have tracked themselves
+	previous := loadSyncState(projectID, conID)
+	synctime := int64(c.Int("time"))
+	if !c.IsSet("time") && previous != nil {
+		synctime = previous.LastSync
 	}
 
-	// Sync all the necessary project files
-	fileList, modifiedList, uploadedFilesList := syncFiles(projectPath, projectID, conURL, synctime)
+	// Sync the project files whose content changed since the last sync
+	syncStart := time.Now().UnixNano() / 1000000
+	options := getUploadOptions(conID)
+	options.Bandwidth = newBandwidthLimiter(bandwidthLimit)
+	result := syncFiles(ctx, projectPath, projectID, conURL, synctime, options, previous)
+	if result.Err == context.Canceled {
+		return nil, &ProjectError{errOpFileLoad, result.Err, "The sync was interrupted before any file was uploaded"}
+	}
+	if result.Err != nil {
+		return nil, &ProjectError{errBadPath, result.Err, result.Err.Error()}
+	}
+	deletedList := []string{}
+	if options.Deletions {
+		deletedList = result.DeletedList
+	}
 	// Complete the upload
-	completeStatus, completeStatusCode := completeUpload(projectID, fileList, modifiedList, conURL, synctime)
+	completeStatus, completeStatusCode := completeUpload(projectID, result.FileList, result.ModifiedList, deletedList, result.RenamedList, conURL, synctime)
+	if completeStatusCode == http.StatusOK {
+		result.State.LastSync = syncStart
+		saveSyncState(projectID, conID, result.State)
+	}
 	response := SyncResponse{
-		UploadedFiles: uploadedFilesList,
+		UploadedFiles: result.UploadedFiles,
 		Status:        completeStatus,
 		StatusCode:    completeStatusCode,
 	}
@@ -102,99 +194,485 @@ func SyncProject(c *cli.Context) (*SyncResponse, *ProjectError) {
 	return &response, nil
 }
 
-func syncFiles(projectPath string, projectID string, conURL string, synctime int64) ([]string, []string, []UploadedFile) {
+// uploadOptions : how files are sent to the PFE behind a connection
+type uploadOptions struct {
+	// Batched sends several small files in each request
+	Batched bool
+	// Raw sends the bytes of files, so binary files are not corrupted and large files can be chunked
+	Raw bool
+	// MaxFileSize is the size in bytes above which files are not synced
+	MaxFileSize int64
+	// Deletions reports the files deleted since the last sync, so that PFE removes them
+	Deletions bool
+	// Renames has PFE move files and directories which were renamed, rather than them being uploaded again
+	Renames bool
+	// Concurrency is how many upload requests are sent at once
+	Concurrency int
+	// Resumable has PFE keep the chunks of a large file whose upload was interrupted, so that only the rest is sent
+	Resumable bool
+	// Bandwidth limits the bytes sent by all uploads together, nil for no limit
+	Bandwidth *bandwidthLimiter
+	// Pod copies the files straight into the Codewind pod instead of uploading them, when it is set
+	Pod *podTransfer
+}
+
+// syncResult : the files found and uploaded by syncFiles
+type syncResult struct {
+	FileList      []string
+	ModifiedList  []string
+	DeletedList   []string
+	RenamedList   []RenamedPath
+	UploadedFiles []UploadedFile
+	// State is recorded once the sync completes, so that the next sync only uploads files whose content changed
+	State *syncState
+	// Err is set when the project could not be walked, in which case nothing was uploaded
+	Err error
+}
+
+// pendingUpload : a modified file, which is read when it is uploaded so that the content of every file is not held at once
+type pendingUpload struct {
+	Path         string
+	RelativePath string
+	Size         int64
+	SHA256       string
+	Mode         uint32
+	// Offset is where the upload starts, when resuming an interrupted chunked upload
+	Offset int64
+}
+
+// modeChanged : true when the mode of a file differs from the one it was synced with, so it is uploaded again to
+// send its mode. A mode which was not recorded, by an older cwctl, is taken to be the one Codewind gives files by
+// default, so only executable files are uploaded again. No mode is read on Windows, so it never changes there
+func modeChanged(previous uint32, current uint32) bool {
+	if current == 0 || current == previous {
+		return false
+	}
+	return previous != 0 || current&0111 != 0
+}
+
+// syncFiles : uploads the files of a project which changed since the previous sync state. Files are compared by
+// their SHA-256 hash, so touching a file does not upload it again. Without a previous state, such as for the first
+// sync after bind, files modified after synctime are uploaded. Once ctx is cancelled no more files are found or
+// uploaded, and the files not uploaded are left for the next sync
+func syncFiles(ctx context.Context, projectPath string, projectID string, conURL string, synctime int64, options uploadOptions, previous *syncState) syncResult {
 	var fileList []string
 	var modifiedList []string
-	var uploadedFiles []UploadedFile
-
-	projectUploadURL := conURL + "projects/" + projectID + "/upload"
-	client := &http.Client{}
+	var pendingUploads []pendingUpload
+	state := newSyncState()
+	found := map[string]bool{}
 
 	cwSettingsIgnoredPathsList := retrieveIgnoredPathsList(projectPath)
 
-	err := filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
+	addFile := func(path string, relativePath string, info os.FileInfo) {
+		found[relativePath] = true
+		// Files which are skipped keep their previous state, so they are reported as deleted once they are removed
+		keepPrevious := func() {
+			if previous != nil {
+				if previousFile, ok := previous.Files[relativePath]; ok {
+					state.Files[relativePath] = previousFile
+				}
+			}
+		}
+		if info.Size() > options.MaxFileSize {
+			logr.Warnf("Not syncing %v as it is larger than %v MB, set syncMaxFileSize to change the limit\n", relativePath, options.MaxFileSize/(1024*1024))
+			keepPrevious()
+			return
+		}
 
-		if err != nil {
-			panic(err)
-			// TODO - How to handle *some* files being unreadable
+		// get time file was modified in milliseconds since epoch
+		modifiedmillis := info.ModTime().UnixNano() / 1000000
+		synced := syncedFile{Size: info.Size(), Modified: modifiedmillis, Mode: syncFileMode(info)}
+
+		// Has the content of this file changed since last sync
+		var modified bool
+		previousFile, known := syncedFile{}, false
+		if previous != nil {
+			previousFile, known = previous.Files[relativePath]
+		}
+		if known && previousFile.Size == synced.Size && previousFile.Modified == synced.Modified {
+			synced.SHA256 = previousFile.SHA256
+		} else if hash, err := hashFile(path); err == nil {
+			synced.SHA256 = hash
+		}
+		if previous != nil {
+			modified = !known || synced.SHA256 == "" || synced.SHA256 != previousFile.SHA256
+			// A file made executable is uploaded again to send its mode
+			modified = modified || modeChanged(previousFile.Mode, synced.Mode)
+		} else {
+			modified = modifiedmillis > synctime
 		}
 
-		if !info.IsDir() {
-			shouldIgnore := ignoreFileOrDirectory(info.Name(), false, cwSettingsIgnoredPathsList)
-			if shouldIgnore {
-				return nil
+		if modified {
+			// Without raw upload the content is sent as text, which would corrupt a binary file
+			if !options.Raw && isBinaryFile(path) {
+				logr.Warnf("Not syncing binary file %v as Codewind does not support raw upload\n", relativePath)
+				keepPrevious()
+				return
 			}
-			// use ToSlash to try and get both Windows and *NIX paths to be *NIX for pfe
-			relativePath := filepath.ToSlash(path[(len(projectPath) + 1):])
-			// Create list of all files for a project
-			fileList = append(fileList, relativePath)
-
-			// get time file was modified in milliseconds since epoch
-			modifiedmillis := info.ModTime().UnixNano() / 1000000
-
-			fileUploadBody := FileUploadMsg{
-				IsDirectory:  info.IsDir(),
-				RelativePath: relativePath,
-				Message:      "",
+			// Create list of all modfied files
+			modifiedList = append(modifiedList, relativePath)
+			upload := pendingUpload{Path: path, RelativePath: relativePath, Size: info.Size(), SHA256: synced.SHA256, Mode: synced.Mode}
+			// Continue an interrupted upload from where it stopped, unless the file has changed since
+			if options.Resumable && previous != nil {
+				if partial, ok := previous.Partial[relativePath]; ok && partial.SHA256 != "" && partial.SHA256 == synced.SHA256 && partial.Size == synced.Size {
+					upload.Offset = partial.Received
+				}
 			}
+			pendingUploads = append(pendingUploads, upload)
+		}
+		if synced.SHA256 != "" {
+			state.Files[relativePath] = synced
+		}
+		// Create list of all files for a project
+		fileList = append(fileList, relativePath)
+	}
 
-			// Has this file been modified since last sync
-			if modifiedmillis > synctime {
-				fileContent, err := ioutil.ReadFile(path)
-				jsonContent, err := json.Marshal(string(fileContent))
-				// Skip this file if there is an error reading it.
-				if err != nil {
-					return nil
-				}
-				// Create list of all modfied files
-				modifiedList = append(modifiedList, relativePath)
-
-				var buffer bytes.Buffer
-				zWriter := zlib.NewWriter(&buffer)
-				zWriter.Write([]byte(jsonContent))
-
-				zWriter.Close()
-				encoded := base64.StdEncoding.EncodeToString(buffer.Bytes())
-				fileUploadBody.Message = encoded
-
-				buf := new(bytes.Buffer)
-				json.NewEncoder(buf).Encode(fileUploadBody)
-
-				// TODO - How do we handle partial success?
-				request, err := http.NewRequest("PUT", projectUploadURL, bytes.NewReader(buf.Bytes()))
-				request.Header.Set("Content-Type", "application/json")
-				resp, err := client.Do(request)
-				uploadedFiles = append(uploadedFiles, UploadedFile{
-					FilePath:   relativePath,
-					Status:     resp.Status,
-					StatusCode: resp.StatusCode,
-				})
-				if err != nil {
-					return nil
-				}
-				defer resp.Body.Close()
+	err := walkSyncFiles(ctx, projectPath, cwSettingsIgnoredPathsList, addFile)
+	if err == filewatcher.ErrTooManyFiles {
+		err = fmt.Errorf("%v holds more than %d files, add the directories which should not be synced to the ignoredPaths of its .cw-settings", projectPath, maxSyncFiles)
+	}
+	if err != nil {
+		logr.Errorf("error walking the path %q: %v", projectPath, err)
+		return syncResult{State: previous, Err: err}
+	}
+
+	// Shared directories outside the project, such as the libraries of a monorepo, are synced into it under their own name
+	for _, contextPath := range retrieveContextPaths(projectPath) {
+		name := contextPath.Name
+		err := walkSyncFiles(ctx, contextPath.Path, cwSettingsIgnoredPathsList, func(path string, relativePath string, info os.FileInfo) {
+			addFile(path, name+"/"+relativePath, info)
+		})
+		if err != nil {
+			logr.Errorf("error walking the context path %q: %v", contextPath.Path, err)
+		}
+	}
+
+	// Files synced last time which are no longer in the project have been deleted, or renamed
+	var deletedList []string
+	if previous != nil {
+		for relativePath := range previous.Files {
+			if !found[relativePath] {
+				deletedList = append(deletedList, relativePath)
 			}
-		} else {
-			shouldIgnore := ignoreFileOrDirectory(info.Name(), true, cwSettingsIgnoredPathsList)
-			if shouldIgnore {
-				return filepath.SkipDir
+		}
+		sort.Strings(deletedList)
+	}
+	var renamedList []RenamedPath
+	if options.Renames && previous != nil {
+		renamedList, pendingUploads, deletedList = detectRenames(previous, state, fileList, pendingUploads, deletedList)
+		modifiedList = nil
+		for _, file := range pendingUploads {
+			modifiedList = append(modifiedList, file.RelativePath)
+		}
+	}
+
+	var uploadedFiles []UploadedFile
+	var received map[string]int64
+	if options.Pod != nil {
+		uploadedFiles = options.Pod.copyFiles(pendingUploads, options.Bandwidth)
+	} else if options.Batched {
+		uploadedFiles, received = uploadFileBatches(ctx, projectID, conURL, pendingUploads, options)
+	} else {
+		uploadedFiles, received = uploadFiles(ctx, projectID, conURL, pendingUploads, options)
+	}
+
+	// Files which failed to upload are recorded as they were, so that they are uploaded again next time
+	uploaded := map[string]bool{}
+	for _, uploadedFile := range uploadedFiles {
+		if uploadedFile.StatusCode < 300 {
+			uploaded[uploadedFile.FilePath] = true
+		}
+	}
+	for _, file := range pendingUploads {
+		if uploaded[file.RelativePath] {
+			continue
+		}
+		delete(state.Files, file.RelativePath)
+		if previous != nil {
+			if previousFile, ok := previous.Files[file.RelativePath]; ok {
+				state.Files[file.RelativePath] = previousFile
+			}
+		}
+		if options.Resumable && received[file.RelativePath] > 0 && file.SHA256 != "" {
+			if state.Partial == nil {
+				state.Partial = map[string]partialUpload{}
 			}
+			state.Partial[file.RelativePath] = partialUpload{SHA256: file.SHA256, Size: file.Size, Received: received[file.RelativePath]}
+		}
+	}
 
+	return syncResult{
+		FileList:      fileList,
+		ModifiedList:  modifiedList,
+		DeletedList:   deletedList,
+		RenamedList:   renamedList,
+		UploadedFiles: uploadedFiles,
+		State:         state,
+	}
+}
+
+// uploadFiles : uploads each modified file in its own request, or in chunks when it is large and raw upload is
+// supported, options.Concurrency files at a time. The files are reported in the order they were given, along with
+// how many bytes PFE received of each chunked file whose upload was interrupted. Files not started when ctx is
+// cancelled are not uploaded
+func uploadFiles(ctx context.Context, projectID string, conURL string, files []pendingUpload, options uploadOptions) ([]UploadedFile, map[string]int64) {
+	results := make([]*UploadedFile, len(files))
+	receivedBytes := make([]int64, len(files))
+	projectUploadURL := conURL + "projects/" + projectID + "/upload"
+	client := &http.Client{}
+	runConcurrently(len(files), options.Concurrency, func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		file := files[i]
+		if options.Raw && file.Size > uploadChunkSize {
+			results[i], receivedBytes[i] = uploadFileChunks(client, projectUploadURL, file, options.Bandwidth)
+			return
+		}
+		fileUploadBody, err := newFileUploadMsg(file, options.Raw)
+		// Skip this file if there is an error reading it.
+		if err != nil {
+			return
 		}
+		buf := new(bytes.Buffer)
+		json.NewEncoder(buf).Encode(fileUploadBody)
 
-		return nil
+		status, statusCode, err := sendUpload(client, projectUploadURL, buf.Bytes(), options.Bandwidth)
+		if err != nil {
+			return
+		}
+		results[i] = &UploadedFile{
+			FilePath:   file.RelativePath,
+			Status:     status,
+			StatusCode: statusCode,
+		}
 	})
+	var uploadedFiles []UploadedFile
+	received := map[string]int64{}
+	for i, uploadedFile := range results {
+		if uploadedFile != nil {
+			uploadedFiles = append(uploadedFiles, *uploadedFile)
+		}
+		if receivedBytes[i] > 0 {
+			received[files[i].RelativePath] = receivedBytes[i]
+		}
+	}
+	return uploadedFiles, received
+}
+
+// uploadFileBatches : uploads the modified files uploadBatchSize at a time, for a PFE which
+// advertises the batchedUpload capability, options.Concurrency batches at a time. Each file reports the
+// status of its batch, except large files which are uploaded in chunks on their own. Batches not started when ctx
+// is cancelled are not uploaded
+func uploadFileBatches(ctx context.Context, projectID string, conURL string, files []pendingUpload, options uploadOptions) ([]UploadedFile, map[string]int64) {
+	var smallFiles []pendingUpload
+	var largeFiles []pendingUpload
+	for _, file := range files {
+		if options.Raw && file.Size > uploadChunkSize {
+			largeFiles = append(largeFiles, file)
+		} else {
+			smallFiles = append(smallFiles, file)
+		}
+	}
+
+	projectUploadURL := conURL + "projects/" + projectID + "/upload/batch"
+	client := &http.Client{}
+	batchCount := (len(smallFiles) + uploadBatchSize - 1) / uploadBatchSize
+	results := make([][]UploadedFile, batchCount)
+	runConcurrently(batchCount, options.Concurrency, func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		start := i * uploadBatchSize
+		end := start + uploadBatchSize
+		if end > len(smallFiles) {
+			end = len(smallFiles)
+		}
+		batch := []FileUploadMsg{}
+		for _, file := range smallFiles[start:end] {
+			fileUploadBody, err := newFileUploadMsg(file, options.Raw)
+			if err != nil {
+				continue
+			}
+			batch = append(batch, fileUploadBody)
+		}
+		buf := new(bytes.Buffer)
+		json.NewEncoder(buf).Encode(BatchUploadMsg{Files: batch})
+
+		status, statusCode, err := sendUpload(client, projectUploadURL, buf.Bytes(), options.Bandwidth)
+		if err != nil {
+			return
+		}
+		for _, file := range batch {
+			results[i] = append(results[i], UploadedFile{
+				FilePath:   file.RelativePath,
+				Status:     status,
+				StatusCode: statusCode,
+			})
+		}
+	})
+	var uploadedFiles []UploadedFile
+	for _, batchFiles := range results {
+		uploadedFiles = append(uploadedFiles, batchFiles...)
+	}
+	largeUploadedFiles, received := uploadFiles(ctx, projectID, conURL, largeFiles, options)
+	return append(uploadedFiles, largeUploadedFiles...), received
+}
+
+// runConcurrently : calls upload with each index below count, running at most concurrency calls at once
+func runConcurrently(count int, concurrency int, upload func(int)) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			upload(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// uploadFileChunks : uploads a large file uploadChunkSize bytes at a time from file.Offset, reading one chunk at
+// a time. The file reports the status of the first chunk that failed, or of the last chunk. When the upload is
+// interrupted by the network or an unavailable PFE, the offset of the first chunk not received is returned too
+func uploadFileChunks(client *http.Client, projectUploadURL string, file pendingUpload, limiter *bandwidthLimiter) (*UploadedFile, int64) {
+	f, err := os.Open(file.Path)
 	if err != nil {
-		fmt.Printf("error walking the path %q: %v\n", projectPath, err)
-		return nil, nil, nil
+		return nil, 0
 	}
-	return fileList, modifiedList, uploadedFiles
+	defer f.Close()
+	if _, err := f.Seek(file.Offset, io.SeekStart); err != nil {
+		return nil, 0
+	}
+
+	var uploadedFile *UploadedFile
+	chunk := make([]byte, uploadChunkSize)
+	for offset := file.Offset; offset < file.Size; offset += uploadChunkSize {
+		n, err := io.ReadFull(f, chunk)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, offset
+		}
+		fileUploadBody := FileUploadMsg{
+			RelativePath: file.RelativePath,
+			Message:      compressAndEncode(chunk[:n]),
+			Encoding:     "raw",
+			Offset:       offset,
+			Size:         file.Size,
+			Mode:         file.Mode,
+		}
+		buf := new(bytes.Buffer)
+		json.NewEncoder(buf).Encode(fileUploadBody)
+		status, statusCode, err := sendUpload(client, projectUploadURL, buf.Bytes(), limiter)
+		if err != nil {
+			return nil, offset
+		}
+		uploadedFile = &UploadedFile{FilePath: file.RelativePath, Status: status, StatusCode: statusCode}
+		if isRetryableStatus(statusCode) {
+			return uploadedFile, offset
+		}
+		if statusCode >= 300 {
+			return uploadedFile, 0
+		}
+	}
+	return uploadedFile, 0
+}
+
+// newFileUploadMsg : reads a file into an upload message, as its bytes when raw upload is supported, otherwise as text
+func newFileUploadMsg(file pendingUpload, raw bool) (FileUploadMsg, error) {
+	fileUploadBody := FileUploadMsg{
+		IsDirectory:  false,
+		RelativePath: file.RelativePath,
+		Message:      "",
+		Mode:         file.Mode,
+	}
+	fileContent, err := ioutil.ReadFile(file.Path)
+	if err != nil {
+		return fileUploadBody, err
+	}
+	if raw {
+		fileUploadBody.Encoding = "raw"
+		fileUploadBody.Message = compressAndEncode(fileContent)
+		return fileUploadBody, nil
+	}
+	jsonContent, err := json.Marshal(string(fileContent))
+	if err != nil {
+		return fileUploadBody, err
+	}
+	fileUploadBody.Message = compressAndEncode(jsonContent)
+	return fileUploadBody, nil
+}
+
+// compressAndEncode : zlib compresses then base64 encodes the content of an upload message
+func compressAndEncode(content []byte) string {
+	var buffer bytes.Buffer
+	zWriter := zlib.NewWriter(&buffer)
+	zWriter.Write(content)
+	zWriter.Close()
+	return base64.StdEncoding.EncodeToString(buffer.Bytes())
+}
+
+// isBinaryFile : whether the start of a file contains a NUL byte, as text files do not
+func isBinaryFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	start := make([]byte, binarySniffSize)
+	n, _ := io.ReadFull(f, start)
+	return bytes.IndexByte(start[:n], 0) != -1
+}
+
+// getUploadOptions : how to upload files to the PFE behind a connection. When its capabilities cannot
+// be fetched the files are uploaded individually as text, which every PFE accepts
+func getUploadOptions(conID string) uploadOptions {
+	options := uploadOptions{MaxFileSize: getSyncMaxFileSize(), Concurrency: getSyncConcurrency()}
+	capabilities, conErr := connections.GetCapabilities(&sechttp.ConnectionClient{HTTPClient: http.DefaultClient, ConnectionID: conID}, conID, false)
+	if conErr != nil {
+		logr.Debugln("Unable to get the PFE capabilities, uploading files individually:", conErr.Desc)
+		return options
+	}
+	options.Batched = capabilities.Supports(connections.CapabilityBatchedUpload)
+	options.Raw = capabilities.Supports(connections.CapabilityRawUpload)
+	options.Deletions = capabilities.Supports(connections.CapabilityDeletions)
+	options.Renames = capabilities.Supports(connections.CapabilityRenames)
+	options.Resumable = options.Raw && capabilities.Supports(connections.CapabilityResumableUpload)
+	return options
 }
 
-func completeUpload(projectID string, files []string, modfiles []string, conURL string, timestamp int64) (string, int) {
+// getSyncMaxFileSize : the configured syncMaxFileSize in bytes, or the default
+func getSyncMaxFileSize() int64 {
+	maxFileSizeMB := defaultSyncMaxFileSizeMB
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr == nil && cliConfig.SyncMaxFileSize != "" {
+		if configured, err := strconv.Atoi(cliConfig.SyncMaxFileSize); err == nil && configured > 0 {
+			maxFileSizeMB = configured
+		}
+	}
+	return int64(maxFileSizeMB) * 1024 * 1024
+}
+
+// getSyncConcurrency : the configured syncConcurrency, or the default
+func getSyncConcurrency() int {
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	if configErr == nil && cliConfig.SyncConcurrency != "" {
+		if configured, err := strconv.Atoi(cliConfig.SyncConcurrency); err == nil && configured > 0 && configured <= MaxSyncConcurrency {
+			return configured
+		}
+	}
+	return defaultSyncConcurrency
+}
+
+func completeUpload(projectID string, files []string, modfiles []string, deletedFiles []string, renamedFiles []RenamedPath, conURL string, timestamp int64) (string, int) {
 	uploadEndURL := conURL + "projects/" + projectID + "/upload/end"
 
-	payload := &CompleteRequest{FileList: files, ModifiedList: modfiles, TimeStamp: timestamp}
+	payload := &CompleteRequest{FileList: files, ModifiedList: modfiles, DeletedList: deletedFiles, RenamedList: renamedFiles, TimeStamp: timestamp}
 	jsonPayload, _ := json.Marshal(payload)
 
 	// Make the request to end the sync process.
@@ -220,63 +698,54 @@ func retrieveIgnoredPathsList(projectPath string) []string {
 	return cwSettingsIgnoredPathsList
 }
 
-func ignoreFileOrDirectory(name string, isDir bool, cwSettingsIgnoredPathsList []string) bool {
-	// List of files that will not be sent to PFE
-	ignoredFiles := []string{
-		".DS_Store",
-		"*.swp",
-		"*.swx",
-		"Jenkinsfile",
-		".cfignore",
-		"localm2cache.zip",
-		"libertyrepocache.zip",
-		"run-dev",
-		"run-debug",
-		"manifest.yml",
-		"idt.js",
-		".bluemix",
-		".build-ubuntu",
-		".yo-rc.json",
-	}
-
-	// List of directories that will not be sent to PFE
-	ignoredDirectories := []string{
-		".project",
-		"node_modules*",
-		".git*",
-		"load-test*",
-		".settings",
-		"Dockerfile-tools",
-		"target",
-		"mc-target",
-		".m2",
-		"debian",
-		".bluemix",
-		"terraform",
-		".build-ubuntu",
-	}
-
-	ignoredList := ignoredFiles
-	if isDir {
-		ignoredList = ignoredDirectories
-	}
-
-	if len(cwSettingsIgnoredPathsList) > 0 {
-		ignoredList = append(ignoredList, cwSettingsIgnoredPathsList...)
-	}
-
-	isFileInIgnoredList := false
-	for _, fileName := range ignoredList {
-		matched, err := filepath.Match(fileName, name)
-		if err != nil {
-			return false
+// contextPath : a directory outside a project which is synced into it as the directory Name
+type contextPath struct {
+	Path string
+	Name string
+}
+
+// Retrieve the contextPaths list from a .cw-settings file, resolving paths relative to the project. Paths which
+// are not directories, contain the project, or would replace a directory of the project are skipped
+func retrieveContextPaths(projectPath string) []contextPath {
+	cwSettingsPath := path.Join(projectPath, ".cw-settings")
+	plan, err := ioutil.ReadFile(cwSettingsPath)
+	if err != nil {
+		return nil
+	}
+	var cwSettingsJSON CWSettings
+	json.Unmarshal(plan, &cwSettingsJSON)
+
+	absProjectPath, _ := filepath.Abs(projectPath)
+	var contextPaths []contextPath
+	names := map[string]bool{}
+	for _, setting := range cwSettingsJSON.ContextPaths {
+		resolvedPath := filepath.FromSlash(setting)
+		if !filepath.IsAbs(resolvedPath) {
+			resolvedPath = filepath.Join(absProjectPath, resolvedPath)
 		}
-		if matched {
-			isFileInIgnoredList = true
-			break
+		resolvedPath = filepath.Clean(resolvedPath)
+		name := filepath.Base(resolvedPath)
+		if info, err := os.Stat(resolvedPath); err != nil || !info.IsDir() {
+			logr.Warnf("Skipping context path %v as it is not a directory\n", setting)
+			continue
 		}
+		if relative, err := filepath.Rel(resolvedPath, absProjectPath); err == nil && !strings.HasPrefix(relative, "..") {
+			logr.Warnf("Skipping context path %v as it contains the project\n", setting)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(absProjectPath, name)); err == nil || names[name] {
+			logr.Warnf("Skipping context path %v as the project already has %v\n", setting, name)
+			continue
+		}
+		names[name] = true
+		contextPaths = append(contextPaths, contextPath{Path: resolvedPath, Name: name})
 	}
-	return isFileInIgnoredList
+	return contextPaths
+}
+
+// ignoreFileOrDirectory : true when a file or directory of this name is not synced
+func ignoreFileOrDirectory(name string, isDir bool, cwSettingsIgnoredPathsList []string) bool {
+	return syncWalkOptions(cwSettingsIgnoredPathsList).Ignores(name, isDir)
 }
 
 // PrettyPrintJSON : Format JSON output for display
@@ -12,11 +12,20 @@
 package project
 
 import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -192,3 +201,197 @@ func TestRetrieveIgnoredPathsList(t *testing.T) {
 		})
 	}
 }
+
+func TestRetrieveContextPaths(t *testing.T) {
+	monorepo := path.Join(testFolder, "monorepo")
+	servicePath := path.Join(monorepo, "services", "api")
+	os.MkdirAll(servicePath, 0777)
+	os.MkdirAll(path.Join(monorepo, "libs"), 0777)
+	os.MkdirAll(path.Join(servicePath, "src"), 0777)
+	os.MkdirAll(path.Join(monorepo, "shared", "src"), 0777)
+	settings, _ := json.Marshal(CWSettings{ContextPaths: []string{"../../libs", "../../shared/src", "../../missing", ".."}})
+	ioutil.WriteFile(path.Join(servicePath, ".cw-settings"), settings, 0644)
+
+	contextPaths := retrieveContextPaths(servicePath)
+
+	absMonorepo, _ := filepath.Abs(monorepo)
+	assert.Equal(t, []contextPath{{Path: filepath.Join(absMonorepo, "libs"), Name: "libs"}}, contextPaths)
+}
+
+func TestUploadBinaryAndLargeFiles(t *testing.T) {
+	binaryPath := path.Join(testFolder, "image.png")
+	binaryContent := []byte{0x89, 'P', 'N', 'G', 0x00, 0xff, 0xfe}
+	ioutil.WriteFile(binaryPath, binaryContent, 0644)
+	largePath := path.Join(testFolder, "large.bin")
+	largeContent := bytes.Repeat([]byte{0xff}, uploadChunkSize*2+10)
+	ioutil.WriteFile(largePath, largeContent, 0644)
+
+	var received []FileUploadMsg
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := FileUploadMsg{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		received = append(received, msg)
+	}))
+	defer server.Close()
+
+	t.Run("Asserts binary files are detected", func(t *testing.T) {
+		assert.True(t, isBinaryFile(binaryPath))
+		assert.False(t, isBinaryFile(path.Join(cwSettingsPopulatedPath, ".cw-settings")))
+	})
+
+	t.Run("Asserts raw uploads keep the bytes of binary files", func(t *testing.T) {
+		msg, err := newFileUploadMsg(pendingUpload{Path: binaryPath, RelativePath: "image.png", Size: int64(len(binaryContent))}, true)
+		assert.Nil(t, err)
+		assert.Equal(t, "raw", msg.Encoding)
+		assert.Equal(t, binaryContent, decodeUploadMessage(msg.Message))
+	})
+
+	t.Run("Asserts large files are uploaded in chunks", func(t *testing.T) {
+		received = nil
+		files := []pendingUpload{{Path: largePath, RelativePath: "large.bin", Size: int64(len(largeContent))}}
+		uploaded, _ := uploadFiles(context.Background(), "project", server.URL+"/", files, uploadOptions{Raw: true, Concurrency: 2})
+		assert.Len(t, uploaded, 1)
+		assert.Len(t, received, 3)
+		var reassembled []byte
+		for _, msg := range received {
+			assert.Equal(t, int64(len(reassembled)), msg.Offset)
+			assert.Equal(t, int64(len(largeContent)), msg.Size)
+			reassembled = append(reassembled, decodeUploadMessage(msg.Message)...)
+		}
+		assert.Equal(t, largeContent, reassembled)
+	})
+}
+
+func TestRunConcurrently(t *testing.T) {
+	t.Run("Asserts every index is run, no more than the concurrency at once", func(t *testing.T) {
+		var running, most int32
+		done := make([]bool, 10)
+		runConcurrently(len(done), 3, func(i int) {
+			now := atomic.AddInt32(&running, 1)
+			for {
+				previous := atomic.LoadInt32(&most)
+				if now <= previous || atomic.CompareAndSwapInt32(&most, previous, now) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			done[i] = true
+			atomic.AddInt32(&running, -1)
+		})
+		assert.Equal(t, []bool{true, true, true, true, true, true, true, true, true, true}, done)
+		assert.True(t, most <= 3)
+	})
+}
+
+func decodeUploadMessage(message string) []byte {
+	compressed, _ := base64.StdEncoding.DecodeString(message)
+	zReader, _ := zlib.NewReader(bytes.NewReader(compressed))
+	content, _ := ioutil.ReadAll(zReader)
+	return content
+}
+
+func TestSyncFilesComparesContentHashes(t *testing.T) {
+	projectPath := path.Join(testFolder, "hashedProject")
+	os.MkdirAll(projectPath, 0777)
+	ioutil.WriteFile(path.Join(projectPath, "a.txt"), []byte("a"), 0644)
+	ioutil.WriteFile(path.Join(projectPath, "b.txt"), []byte("b"), 0644)
+
+	statusCode := http.StatusOK
+	var uploadedPaths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := FileUploadMsg{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		uploadedPaths = append(uploadedPaths, msg.RelativePath)
+		w.WriteHeader(statusCode)
+	}))
+	defer server.Close()
+	options := uploadOptions{MaxFileSize: 1024 * 1024}
+
+	result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, nil)
+	state := result.State
+
+	t.Run("Asserts the first sync uploads every file and records its hash", func(t *testing.T) {
+		assert.ElementsMatch(t, []string{"a.txt", "b.txt"}, uploadedPaths)
+		assert.Len(t, state.Files, 2)
+		hash, _ := hashFile(path.Join(projectPath, "a.txt"))
+		assert.Equal(t, hash, state.Files["a.txt"].SHA256)
+	})
+
+	t.Run("Asserts touched files are not uploaded again", func(t *testing.T) {
+		uploadedPaths = nil
+		later := time.Now().Add(time.Hour)
+		os.Chtimes(path.Join(projectPath, "a.txt"), later, later)
+		result = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Empty(t, uploadedPaths)
+		assert.Empty(t, result.ModifiedList)
+		assert.ElementsMatch(t, []string{"a.txt", "b.txt"}, result.FileList)
+		state = result.State
+	})
+
+	t.Run("Asserts files whose upload failed are uploaded again", func(t *testing.T) {
+		statusCode = http.StatusInternalServerError
+		ioutil.WriteFile(path.Join(projectPath, "b.txt"), []byte("changed"), 0644)
+		result = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Equal(t, []string{"b.txt"}, result.ModifiedList)
+		assert.Equal(t, state.Files["b.txt"], result.State.Files["b.txt"])
+
+		statusCode = http.StatusOK
+		uploadedPaths = nil
+		result = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, result.State)
+		assert.Equal(t, []string{"b.txt"}, uploadedPaths)
+		state = result.State
+	})
+
+	t.Run("Asserts deleted files are reported", func(t *testing.T) {
+		os.Remove(path.Join(projectPath, "a.txt"))
+		result = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Equal(t, []string{"a.txt"}, result.DeletedList)
+		assert.Len(t, result.State.Files, 1)
+	})
+}
+
+func TestSyncFilesDetectsRenames(t *testing.T) {
+	projectPath := path.Join(testFolder, "renamedProject")
+	os.MkdirAll(path.Join(projectPath, "lib", "util"), 0777)
+	ioutil.WriteFile(path.Join(projectPath, "lib", "a.js"), []byte("a"), 0644)
+	ioutil.WriteFile(path.Join(projectPath, "lib", "util", "b.js"), []byte("b"), 0644)
+	ioutil.WriteFile(path.Join(projectPath, "main.js"), []byte("main"), 0644)
+
+	var uploadedPaths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := FileUploadMsg{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		uploadedPaths = append(uploadedPaths, msg.RelativePath)
+	}))
+	defer server.Close()
+	options := uploadOptions{MaxFileSize: 1024 * 1024, Renames: true}
+
+	state := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, nil).State
+
+	t.Run("Asserts renamed directories and files are moved rather than uploaded", func(t *testing.T) {
+		uploadedPaths = nil
+		os.Rename(path.Join(projectPath, "lib"), path.Join(projectPath, "src"))
+		os.Rename(path.Join(projectPath, "main.js"), path.Join(projectPath, "index.js"))
+
+		result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Empty(t, uploadedPaths)
+		assert.Empty(t, result.ModifiedList)
+		assert.Empty(t, result.DeletedList)
+		assert.Equal(t, []RenamedPath{
+			{From: "lib", To: "src", IsDirectory: true},
+			{From: "main.js", To: "index.js"},
+		}, result.RenamedList)
+		assert.ElementsMatch(t, []string{"index.js", "src/a.js", "src/util/b.js"}, result.FileList)
+	})
+
+	t.Run("Asserts the directories a file moved between are found", func(t *testing.T) {
+		fromDir, toDir, ok := renamedDirectories("old/util/b.js", "new/util/b.js")
+		assert.True(t, ok)
+		assert.Equal(t, "old", fromDir)
+		assert.Equal(t, "new", toDir)
+		_, _, ok = renamedDirectories("lib/a.js", "lib/b.js")
+		assert.False(t, ok)
+		_, _, ok = renamedDirectories("a.js", "lib/a.js")
+		assert.False(t, ok)
+	})
+}
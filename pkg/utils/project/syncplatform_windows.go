@@ -0,0 +1,19 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import "os"
+
+// syncFileMode : Windows has no permission bits to keep, so none are sent
+func syncFileMode(info os.FileInfo) uint32 {
+	return 0
+}
@@ -0,0 +1,152 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// detectRenames : matches the new files of a sync to deleted files with the same content hash, so that PFE moves
+// them instead of them being uploaded again. When every file of a directory moved to a directory which has no
+// other files, the directory is reported as one rename. Returns the renames, and the uploads and deletions which remain
+func detectRenames(previous *syncState, state *syncState, fileList []string, pending []pendingUpload, deleted []string) ([]RenamedPath, []pendingUpload, []string) {
+	deletedByHash := map[string][]string{}
+	for _, relativePath := range deleted {
+		hash := previous.Files[relativePath].SHA256
+		deletedByHash[hash] = append(deletedByHash[hash], relativePath)
+	}
+
+	var fileRenames []RenamedPath
+	for _, file := range pending {
+		if _, known := previous.Files[file.RelativePath]; known {
+			continue
+		}
+		hash := state.Files[file.RelativePath].SHA256
+		candidates := deletedByHash[hash]
+		if hash == "" || len(candidates) == 0 {
+			continue
+		}
+		// Prefer the deleted file with the same name, as copies of a file are usually moved together. A file whose
+		// mode changed is uploaded, as moving it would keep the mode of the deleted file
+		chosen := -1
+		for i, candidate := range candidates {
+			if modeChanged(previous.Files[candidate].Mode, state.Files[file.RelativePath].Mode) {
+				continue
+			}
+			if chosen < 0 {
+				chosen = i
+			}
+			if path.Base(candidate) == path.Base(file.RelativePath) {
+				chosen = i
+				break
+			}
+		}
+		if chosen < 0 {
+			continue
+		}
+		fileRenames = append(fileRenames, RenamedPath{From: candidates[chosen], To: file.RelativePath})
+		deletedByHash[hash] = append(candidates[:chosen:chosen], candidates[chosen+1:]...)
+	}
+	if len(fileRenames) == 0 {
+		return nil, pending, deleted
+	}
+
+	// Group the file renames by the directories they moved between
+	type directoryMove struct{ from, to string }
+	groups := map[directoryMove][]RenamedPath{}
+	var renames []RenamedPath
+	for _, rename := range fileRenames {
+		fromDir, toDir, ok := renamedDirectories(rename.From, rename.To)
+		if !ok {
+			renames = append(renames, rename)
+			continue
+		}
+		move := directoryMove{fromDir, toDir}
+		groups[move] = append(groups[move], rename)
+	}
+	for move, group := range groups {
+		if isDirectoryRename(move.from, move.to, group, previous, fileList) {
+			renames = append(renames, RenamedPath{From: move.from, To: move.to, IsDirectory: true})
+		} else {
+			renames = append(renames, group...)
+		}
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].From < renames[j].From })
+
+	renamedFrom := map[string]bool{}
+	renamedTo := map[string]bool{}
+	for _, rename := range fileRenames {
+		renamedFrom[rename.From] = true
+		renamedTo[rename.To] = true
+	}
+	var remainingUploads []pendingUpload
+	for _, file := range pending {
+		if !renamedTo[file.RelativePath] {
+			remainingUploads = append(remainingUploads, file)
+		}
+	}
+	var remainingDeletions []string
+	for _, relativePath := range deleted {
+		if !renamedFrom[relativePath] {
+			remainingDeletions = append(remainingDeletions, relativePath)
+		}
+	}
+	return renames, remainingUploads, remainingDeletions
+}
+
+// renamedDirectories : the directories a file moved between, found by removing the path segments at the end
+// of from and to which are the same. There are none when the file itself was renamed, or moved to or from the root
+func renamedDirectories(from string, to string) (string, string, bool) {
+	fromSegments := strings.Split(from, "/")
+	toSegments := strings.Split(to, "/")
+	common := 0
+	for common < len(fromSegments) && common < len(toSegments) &&
+		fromSegments[len(fromSegments)-1-common] == toSegments[len(toSegments)-1-common] {
+		common++
+	}
+	if common == 0 || common == len(fromSegments) || common == len(toSegments) {
+		return "", "", false
+	}
+	fromDir := strings.Join(fromSegments[:len(fromSegments)-common], "/")
+	toDir := strings.Join(toSegments[:len(toSegments)-common], "/")
+	if strings.HasPrefix(fromDir+"/", toDir+"/") || strings.HasPrefix(toDir+"/", fromDir+"/") {
+		return "", "", false
+	}
+	return fromDir, toDir, true
+}
+
+// isDirectoryRename : whether a group of file renames moved the whole of from to to. Every file synced under from
+// must have moved, and to must be new and only hold the moved files, so PFE can move the directory in one step
+func isDirectoryRename(from string, to string, group []RenamedPath, previous *syncState, fileList []string) bool {
+	movedFrom := map[string]bool{}
+	movedTo := map[string]bool{}
+	for _, rename := range group {
+		movedFrom[rename.From] = true
+		movedTo[rename.To] = true
+	}
+	for relativePath := range previous.Files {
+		if strings.HasPrefix(relativePath, from+"/") && !movedFrom[relativePath] {
+			return false
+		}
+		if strings.HasPrefix(relativePath, to+"/") {
+			return false
+		}
+	}
+	for _, relativePath := range fileList {
+		if strings.HasPrefix(relativePath, to+"/") && !movedTo[relativePath] {
+			return false
+		}
+	}
+	return true
+}
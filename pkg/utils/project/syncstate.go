@@ -0,0 +1,152 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+// syncStateSchemaVersion must be incremented when changing the syncState or syncedFile structure
+const syncStateSchemaVersion = 2
+
+// legacySyncStateSchemaVersion is the version of the states kept per project before they were kept per connection,
+// which lack only the connection and the time of the last sync
+const legacySyncStateSchemaVersion = 1
+
+// syncState : the files of a project as they were when last synced to a connection, by path relative to the project
+type syncState struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	ConnectionID  string `json:"connectionID,omitempty"`
+	// LastSync is when the last completed sync started, in milliseconds since the epoch
+	LastSync int64                 `json:"lastSync,omitempty"`
+	Files    map[string]syncedFile `json:"files"`
+	// Partial holds the large files whose upload was interrupted, when PFE keeps the chunks it received
+	Partial map[string]partialUpload `json:"partial,omitempty"`
+}
+
+// syncedFile : the content hash of a synced file. Size and Modified are kept so that files which have
+// not been touched since the last sync are not read again. Mode is 0 when it was not recorded
+type syncedFile struct {
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Modified int64  `json:"modified"`
+	Mode     uint32 `json:"mode,omitempty"`
+}
+
+// partialUpload : how much of a file PFE received before its upload was interrupted, with the hash of the
+// content being sent, so that the upload is only resumed when the file has not changed
+type partialUpload struct {
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Received int64  `json:"received"`
+}
+
+func newSyncState() *syncState {
+	return &syncState{SchemaVersion: syncStateSchemaVersion, Files: map[string]syncedFile{}}
+}
+
+// loadSyncState : the state recorded by the last sync of a project to a connection, or nil when there is none or
+// it cannot be read, in which case files are compared by modification time instead. A state recorded by an older
+// cwctl for the project as a whole is taken to be that of its connection
+func loadSyncState(projectID string, conID string) *syncState {
+	state := readSyncState(getSyncStateFilename(projectID, conID))
+	if state == nil {
+		state = readSyncState(getLegacySyncStateFilename(projectID))
+	}
+	if state == nil || (state.ConnectionID != "" && state.ConnectionID != conID) {
+		return nil
+	}
+	return state
+}
+
+func readSyncState(filename string) *syncState {
+	file, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	state := syncState{}
+	if json.Unmarshal(file, &state) != nil || state.Files == nil {
+		return nil
+	}
+	if state.SchemaVersion != syncStateSchemaVersion && state.SchemaVersion != legacySyncStateSchemaVersion {
+		return nil
+	}
+	return &state
+}
+
+// saveSyncState : records the state of a project once a sync to a connection has completed, replacing any state
+// recorded for the project as a whole by an older cwctl
+func saveSyncState(projectID string, conID string, state *syncState) *ProjectError {
+	state.SchemaVersion = syncStateSchemaVersion
+	state.ConnectionID = conID
+	body, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return &ProjectError{errOpFileParse, err, err.Error()}
+	}
+	err = os.MkdirAll(getProjectSyncStateDir(projectID), 0777)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	err = ioutil.WriteFile(getSyncStateFilename(projectID, conID), body, 0644)
+	if err != nil {
+		return &ProjectError{errOpFileWrite, err, err.Error()}
+	}
+	os.Remove(getLegacySyncStateFilename(projectID))
+	return nil
+}
+
+// removeSyncState : forgets the state of a project on every connection, so that its next sync compares files by
+// modification time
+func removeSyncState(projectID string) {
+	os.RemoveAll(getProjectSyncStateDir(projectID))
+	os.Remove(getLegacySyncStateFilename(projectID))
+}
+
+// hashFile : the hex encoded SHA-256 hash of the content of a file
+func hashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getSyncStateDir : the sync states are kept in ~/.codewind/projects, beside the config directory holding the
+// project connection files
+func getSyncStateDir() string {
+	return path.Join(path.Dir(path.Dir(getProjectConnectionConfigDir())), "projects")
+}
+
+// getProjectSyncStateDir : the directory holding the state of a project on each connection it was synced to
+func getProjectSyncStateDir(projectID string) string {
+	return path.Join(getSyncStateDir(), projectID)
+}
+
+func getSyncStateFilename(projectID string, conID string) string {
+	return path.Join(getProjectSyncStateDir(projectID), conID+".json")
+}
+
+// getLegacySyncStateFilename : where older versions of cwctl kept the state of a project
+func getLegacySyncStateFilename(projectID string) string {
+	return path.Join(path.Dir(getProjectConnectionConfigDir()), "sync", projectID+".json")
+}
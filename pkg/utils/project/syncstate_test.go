@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncStatePerConnection(t *testing.T) {
+	projectID := "c1d2e3f4-0000-11ea-8d71-362b9e155667"
+	defer removeSyncState(projectID)
+
+	t.Run("Asserts there is no state for a project which was never synced", func(t *testing.T) {
+		assert.Nil(t, loadSyncState(projectID, "local"))
+	})
+
+	t.Run("Asserts the state of each connection is kept separately", func(t *testing.T) {
+		local := newSyncState()
+		local.LastSync = 1000
+		local.Files["a.txt"] = syncedFile{SHA256: "aaa", Size: 1, Modified: 1}
+		assert.Nil(t, saveSyncState(projectID, "local", local))
+		remote := newSyncState()
+		remote.LastSync = 2000
+		assert.Nil(t, saveSyncState(projectID, "remote1", remote))
+
+		loaded := loadSyncState(projectID, "local")
+		if assert.NotNil(t, loaded) {
+			assert.Equal(t, "local", loaded.ConnectionID)
+			assert.Equal(t, int64(1000), loaded.LastSync)
+			assert.Equal(t, "aaa", loaded.Files["a.txt"].SHA256)
+		}
+		loaded = loadSyncState(projectID, "remote1")
+		if assert.NotNil(t, loaded) {
+			assert.Equal(t, "remote1", loaded.ConnectionID)
+			assert.Equal(t, int64(2000), loaded.LastSync)
+		}
+		assert.Nil(t, loadSyncState(projectID, "remote2"))
+	})
+
+	t.Run("Asserts removing the state forgets every connection", func(t *testing.T) {
+		removeSyncState(projectID)
+		assert.Nil(t, loadSyncState(projectID, "local"))
+		assert.Nil(t, loadSyncState(projectID, "remote1"))
+	})
+
+	t.Run("Asserts the state recorded by an older cwctl is used and then moved", func(t *testing.T) {
+		legacyFilename := getLegacySyncStateFilename(projectID)
+		os.MkdirAll(path.Dir(legacyFilename), 0777)
+		legacy := `{"schemaVersion": 1, "files": {"a.txt": {"sha256": "aaa", "size": 1, "modified": 1}}}`
+		ioutil.WriteFile(legacyFilename, []byte(legacy), 0644)
+
+		loaded := loadSyncState(projectID, "local")
+		if assert.NotNil(t, loaded) {
+			assert.Equal(t, "aaa", loaded.Files["a.txt"].SHA256)
+			assert.Equal(t, int64(0), loaded.LastSync)
+		}
+		assert.Nil(t, saveSyncState(projectID, "local", loaded))
+		_, err := os.Stat(legacyFilename)
+		assert.True(t, os.IsNotExist(err))
+		assert.NotNil(t, loadSyncState(projectID, "local"))
+	})
+}
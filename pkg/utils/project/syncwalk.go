@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"context"
+	"os"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/filewatcher"
+	logr "github.com/sirupsen/logrus"
+)
+
+// maxSyncFiles guards against binding a directory such as a home directory by mistake
+const maxSyncFiles = 200000
+
+// maxSyncDepth guards against directories nested deeper than any project needs
+const maxSyncDepth = 100
+
+// syncIgnoredFiles are the names of files that are never sent to PFE
+var syncIgnoredFiles = []string{
+	".DS_Store",
+	"*.swp",
+	"*.swx",
+	"Jenkinsfile",
+	".cfignore",
+	"localm2cache.zip",
+	"libertyrepocache.zip",
+	"run-dev",
+	"run-debug",
+	"manifest.yml",
+	"idt.js",
+	".bluemix",
+	".build-ubuntu",
+	".yo-rc.json",
+}
+
+// syncIgnoredDirectories are the names of directories that are never sent to PFE
+var syncIgnoredDirectories = []string{
+	".project",
+	"node_modules*",
+	".git*",
+	"load-test*",
+	".settings",
+	"Dockerfile-tools",
+	"target",
+	"mc-target",
+	".m2",
+	"debian",
+	".bluemix",
+	"terraform",
+	".build-ubuntu",
+}
+
+// syncWalkOptions : how the files of a project are found, skipping those never sent to PFE and the ignoredPaths
+// of its .cw-settings. A symbolic link to a file within the project is found as that file
+func syncWalkOptions(ignoredPaths []string) filewatcher.Options {
+	return filewatcher.Options{
+		IgnoredFiles:       append(append([]string{}, syncIgnoredFiles...), ignoredPaths...),
+		IgnoredDirectories: append(append([]string{}, syncIgnoredDirectories...), ignoredPaths...),
+		Symlinks:           filewatcher.SymlinksWithinRoot,
+		MaxDepth:           maxSyncDepth,
+		MaxFiles:           maxSyncFiles,
+	}
+}
+
+// walkSyncFiles : calls add with each file under root which is synced, along with its path relative to root
+// using forward slashes, as sent to PFE. Files which are skipped are warned about. The walk stops with the error of
+// ctx once it is cancelled
+func walkSyncFiles(ctx context.Context, root string, ignoredPaths []string, add func(path string, relativePath string, info os.FileInfo)) error {
+	options := syncWalkOptions(ignoredPaths)
+	options.OnSkip = func(relativePath string, reason error) {
+		logr.Warnf("Not syncing %v as %v\n", relativePath, reason)
+	}
+	return filewatcher.Walk(ctx, root, options, func(file filewatcher.File) error {
+		add(file.Path, file.RelativePath, file.Info)
+		return nil
+	})
+}
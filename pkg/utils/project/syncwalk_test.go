@@ -0,0 +1,154 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkSyncFilesSymlinks(t *testing.T) {
+	parent, _ := ioutil.TempDir("", "syncwalk")
+	defer os.RemoveAll(parent)
+	projectPath := filepath.Join(parent, "project")
+	os.MkdirAll(filepath.Join(projectPath, "src"), 0777)
+	ioutil.WriteFile(filepath.Join(projectPath, "src", "app.js"), []byte("app"), 0644)
+	ioutil.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0644)
+
+	// Creating symbolic links needs extra privileges on Windows
+	if err := os.Symlink(filepath.Join(projectPath, "src", "app.js"), filepath.Join(projectPath, "link.js")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+	os.Symlink(filepath.Join(parent, "secret.txt"), filepath.Join(projectPath, "secret.txt"))
+	os.Symlink(filepath.Join(projectPath, "src"), filepath.Join(projectPath, "srclink"))
+	os.Symlink(filepath.Join(projectPath, "missing.js"), filepath.Join(projectPath, "broken.js"))
+
+	walked := map[string]string{}
+	err := walkSyncFiles(context.Background(), projectPath, []string{}, func(path string, relativePath string, info os.FileInfo) {
+		content, _ := ioutil.ReadFile(path)
+		walked[relativePath] = string(content)
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"src/app.js": "app", "link.js": "app"}, walked)
+}
+
+func TestSyncFilesInterrupted(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "syncinterrupt")
+	defer os.RemoveAll(projectPath)
+	ioutil.WriteFile(filepath.Join(projectPath, "app.js"), []byte("app"), 0644)
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+	}))
+	defer server.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := syncFiles(ctx, projectPath, "project", server.URL+"/", 0, uploadOptions{MaxFileSize: 1024 * 1024}, nil)
+	assert.Equal(t, context.Canceled, result.Err)
+	assert.Equal(t, 0, uploads)
+
+	t.Run("Asserts files found before the interrupt are not uploaded", func(t *testing.T) {
+		files := []pendingUpload{{Path: filepath.Join(projectPath, "app.js"), RelativePath: "app.js", Size: 3}}
+		uploaded, _ := uploadFiles(ctx, "project", server.URL+"/", files, uploadOptions{Concurrency: 1})
+		assert.Empty(t, uploaded)
+		assert.Equal(t, 0, uploads)
+	})
+}
+
+func TestSyncFilesSendsFileModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows files have no permission bits")
+	}
+	projectPath, _ := ioutil.TempDir("", "syncmode")
+	defer os.RemoveAll(projectPath)
+	scriptPath := filepath.Join(projectPath, "mvnw")
+	ioutil.WriteFile(scriptPath, []byte("#!/bin/sh"), 0644)
+	os.Chmod(scriptPath, 0644)
+
+	var modes []uint32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := FileUploadMsg{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		modes = append(modes, msg.Mode)
+	}))
+	defer server.Close()
+	options := uploadOptions{MaxFileSize: 1024 * 1024}
+
+	state := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, nil).State
+	assert.Equal(t, []uint32{0644}, modes)
+
+	t.Run("Asserts a file whose mode changed is uploaded again", func(t *testing.T) {
+		modes = nil
+		os.Chmod(scriptPath, 0755)
+		result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Equal(t, []string{"mvnw"}, result.ModifiedList)
+		assert.Equal(t, []uint32{0755}, modes)
+	})
+
+	t.Run("Asserts an executable file whose mode was not recorded is uploaded again", func(t *testing.T) {
+		modes = nil
+		unrecorded := state.Files["mvnw"]
+		unrecorded.Mode = 0
+		state.Files["mvnw"] = unrecorded
+		result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Equal(t, []string{"mvnw"}, result.ModifiedList)
+		assert.Equal(t, []uint32{0755}, modes)
+		assert.Equal(t, uint32(0755), result.State.Files["mvnw"].Mode)
+	})
+
+	t.Run("Asserts a file whose mode was not recorded is not uploaded again when it is not executable", func(t *testing.T) {
+		os.Chmod(scriptPath, 0644)
+		state = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state).State
+		modes = nil
+		unrecorded := state.Files["mvnw"]
+		unrecorded.Mode = 0
+		state.Files["mvnw"] = unrecorded
+		result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state)
+		assert.Empty(t, result.ModifiedList)
+		assert.Empty(t, modes)
+	})
+
+	t.Run("Asserts a renamed file whose mode changed is uploaded rather than moved", func(t *testing.T) {
+		state = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, state).State
+		modes = nil
+		renamedPath := filepath.Join(projectPath, "gradlew")
+		os.Rename(scriptPath, renamedPath)
+		os.Chmod(renamedPath, 0755)
+		renameOptions := options
+		renameOptions.Renames = true
+		result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, renameOptions, state)
+		assert.Empty(t, result.RenamedList)
+		assert.Equal(t, []string{"gradlew"}, result.ModifiedList)
+		assert.Equal(t, []string{"mvnw"}, result.DeletedList)
+		assert.Equal(t, []uint32{0755}, modes)
+	})
+}
+
+func TestModeChanged(t *testing.T) {
+	assert.False(t, modeChanged(0644, 0644))
+	assert.True(t, modeChanged(0644, 0755))
+	assert.True(t, modeChanged(0755, 0644))
+	assert.True(t, modeChanged(0, 0755))
+	assert.False(t, modeChanged(0, 0644))
+	assert.False(t, modeChanged(0755, 0))
+}
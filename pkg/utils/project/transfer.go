@@ -0,0 +1,197 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	logr "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// TransferHTTP uploads the project files to Codewind through its API
+	TransferHTTP = "http"
+	// TransferKube streams the project files as a tar archive straight into the workspace of the Codewind pod,
+	// which needs credentials for the cluster Codewind runs in
+	TransferKube = "kube"
+)
+
+// podUploadDirectory is where PFE keeps the files of a project being bound until bind/end, on its workspace volume
+const podUploadDirectory = "/codewind-workspace/cw-temp"
+
+// podTransfer : the Codewind pod a bind copies project files into, and how to reach it
+type podTransfer struct {
+	config    *rest.Config
+	clientset kubernetes.Interface
+	pod       corev1.Pod
+	// directory is where in the pod the files are extracted
+	directory string
+}
+
+// checkTransferMode : errors when mode is not a transfer mode, or is one which conID cannot use
+func checkTransferMode(mode string, conID string) error {
+	switch mode {
+	case TransferHTTP:
+		return nil
+	case TransferKube:
+		if connections.IsLocal(conID) {
+			return errors.New("The kube transfer mode is only for remote connections, the local Codewind is reached through docker")
+		}
+		return nil
+	}
+	return fmt.Errorf("Unknown transfer mode %v, use %v or %v", mode, TransferHTTP, TransferKube)
+}
+
+// newPodTransfer : connects to the Codewind pod behind conURL with the credentials of the Kubernetes context of
+// the connection conID, to copy the files of the project named projectName into it
+func newPodTransfer(conID string, conURL string, projectName string) (*podTransfer, error) {
+	if projectName == "" {
+		return nil, errors.New("The name of the project is not known, bind it again with the kube transfer mode")
+	}
+	connections.SelectConnectionKubeContext(conID)
+	kubeConfig := kube.GetKubeClientConfig()
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("No Kubernetes credentials were found for the kube transfer mode: %v", err)
+	}
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := findPFEPod(clientset, namespace, conURL)
+	if err != nil {
+		return nil, err
+	}
+	return &podTransfer{
+		config:    config,
+		clientset: clientset,
+		pod:       *pod,
+		directory: path.Join(podUploadDirectory, projectName),
+	}, nil
+}
+
+// findPFEPod : the running Codewind pod of the workspace whose ID is in the host of conURL, or the only running
+// Codewind pod in the namespace when the host does not name a workspace
+func findPFEPod(clientset kubernetes.Interface, namespace string, conURL string) (*corev1.Pod, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: "app=codewind-pfe"})
+	if err != nil {
+		return nil, err
+	}
+	host := conURL
+	if parsedURL, err := url.Parse(conURL); err == nil && parsedURL.Hostname() != "" {
+		host = parsedURL.Hostname()
+	}
+	var running []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if workspace := pod.Labels["codewindWorkspace"]; workspace != "" && strings.Contains(host, workspace) {
+			return &pod, nil
+		}
+		running = append(running, pod)
+	}
+	if len(running) == 1 {
+		return &running[0], nil
+	}
+	if len(running) == 0 {
+		return nil, fmt.Errorf("No running Codewind pod was found in namespace %v, switch to the context and namespace of the connection", namespace)
+	}
+	return nil, fmt.Errorf("%d Codewind pods are running in namespace %v and none is the workspace of %v", len(running), namespace, conURL)
+}
+
+// copyFiles : streams the files into the pod as one tar archive, at no more than the limiter allows. As the
+// archive is extracted in one go, either every file is reported as uploaded or none is
+func (transfer *podTransfer) copyFiles(files []pendingUpload, limiter *bandwidthLimiter) []UploadedFile {
+	if len(files) == 0 {
+		return nil
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeProjectTar(writer, files))
+	}()
+	var input io.Reader = reader
+	if limiter != nil {
+		input = &limitedReader{reader: reader, limiter: limiter}
+	}
+	// The directory is passed as an argument rather than in the script, so that no project name can change the script
+	command := []string{"sh", "-c", `mkdir -p "$1" && tar -xmf - -C "$1"`, "sh", transfer.directory}
+	err := kube.StreamToPod(transfer.config, transfer.clientset, transfer.pod, command, input)
+	reader.CloseWithError(err)
+	if err != nil {
+		logr.Errorf("Unable to copy the project files into pod %v: %v\n", transfer.pod.Name, err)
+		return nil
+	}
+	uploadedFiles := make([]UploadedFile, len(files))
+	for i, file := range files {
+		uploadedFiles[i] = UploadedFile{FilePath: file.RelativePath, Status: "200 OK", StatusCode: http.StatusOK}
+	}
+	return uploadedFiles
+}
+
+// writeProjectTar : writes the files to w as a tar archive of their relative paths, keeping their permission bits
+func writeProjectTar(w io.Writer, files []pendingUpload) error {
+	archive := tar.NewWriter(w)
+	for _, file := range files {
+		err := writeTarFile(archive, file)
+		if err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeTarFile(archive *tar.Writer, file pendingUpload) error {
+	content, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	info, err := content.Stat()
+	if err != nil {
+		return err
+	}
+	mode := int64(file.Mode)
+	if mode == 0 {
+		mode = 0644
+	}
+	err = archive.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     file.RelativePath,
+		Mode:     mode,
+		Size:     info.Size(),
+		ModTime:  info.ModTime(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(archive, content, info.Size())
+	return err
+}
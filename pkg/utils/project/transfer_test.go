@@ -0,0 +1,107 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckTransferMode(t *testing.T) {
+	assert.Nil(t, checkTransferMode(TransferHTTP, "local"))
+	assert.Nil(t, checkTransferMode(TransferHTTP, "remote1"))
+	assert.Nil(t, checkTransferMode(TransferKube, "remote1"))
+	assert.NotNil(t, checkTransferMode(TransferKube, "local"))
+	assert.NotNil(t, checkTransferMode("ftp", "remote1"))
+}
+
+func TestWriteProjectTar(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "transfer")
+	defer os.RemoveAll(projectPath)
+	os.MkdirAll(filepath.Join(projectPath, "bin"), 0777)
+	ioutil.WriteFile(filepath.Join(projectPath, "package.json"), []byte("{}"), 0644)
+	ioutil.WriteFile(filepath.Join(projectPath, "bin", "start.sh"), []byte("#!/bin/sh"), 0755)
+
+	files := []pendingUpload{
+		{Path: filepath.Join(projectPath, "package.json"), RelativePath: "package.json"},
+		{Path: filepath.Join(projectPath, "bin", "start.sh"), RelativePath: "bin/start.sh", Mode: 0755},
+	}
+	archive := new(bytes.Buffer)
+	err := writeProjectTar(archive, files)
+	assert.Nil(t, err)
+
+	reader := tar.NewReader(archive)
+	expected := []struct {
+		name    string
+		mode    int64
+		content string
+	}{
+		{"package.json", 0644, "{}"},
+		{"bin/start.sh", 0755, "#!/bin/sh"},
+	}
+	for _, file := range expected {
+		header, err := reader.Next()
+		assert.Nil(t, err)
+		assert.Equal(t, file.name, header.Name)
+		assert.Equal(t, file.mode, header.Mode)
+		content, _ := ioutil.ReadAll(reader)
+		assert.Equal(t, file.content, string(content))
+	}
+
+	t.Run("Asserts a file which cannot be read fails the archive", func(t *testing.T) {
+		err := writeProjectTar(new(bytes.Buffer), []pendingUpload{{Path: filepath.Join(projectPath, "missing.js"), RelativePath: "missing.js"}})
+		assert.NotNil(t, err)
+	})
+}
+
+func pfePod(name string, workspace string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "codewind", Labels: map[string]string{"app": "codewind-pfe", "codewindWorkspace": workspace}},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestFindPFEPod(t *testing.T) {
+	t.Run("Asserts the pod of the workspace in the connection URL is found", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(pfePod("codewind-pfe-k1a2b3", "k1a2b3", corev1.PodRunning), pfePod("codewind-pfe-z9y8x7", "z9y8x7", corev1.PodRunning))
+		pod, err := findPFEPod(clientset, "codewind", "https://codewind-gatekeeper-z9y8x7.10.0.0.1.nip.io/api/v1/")
+		assert.Nil(t, err)
+		assert.Equal(t, "codewind-pfe-z9y8x7", pod.Name)
+	})
+
+	t.Run("Asserts the only running pod is found when the URL names no workspace", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(pfePod("codewind-pfe-k1a2b3", "k1a2b3", corev1.PodRunning), pfePod("codewind-pfe-z9y8x7", "z9y8x7", corev1.PodPending))
+		pod, err := findPFEPod(clientset, "codewind", "https://codewind.example.com/api/v1/")
+		assert.Nil(t, err)
+		assert.Equal(t, "codewind-pfe-k1a2b3", pod.Name)
+	})
+
+	t.Run("Asserts no pod is chosen between several which the URL does not name", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(pfePod("codewind-pfe-k1a2b3", "k1a2b3", corev1.PodRunning), pfePod("codewind-pfe-z9y8x7", "z9y8x7", corev1.PodRunning))
+		_, err := findPFEPod(clientset, "codewind", "https://codewind.example.com/api/v1/")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Asserts no pod is found when none is running", func(t *testing.T) {
+		_, err := findPFEPod(fake.NewSimpleClientset(), "codewind", "https://codewind.example.com/api/v1/")
+		assert.NotNil(t, err)
+	})
+}
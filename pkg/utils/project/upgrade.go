@@ -12,18 +12,19 @@
 package project
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/utils/filewatcher"
 	"github.com/urfave/cli"
 )
 
-func UpgradeProjects(c *cli.Context) *ProjectError {
+func UpgradeProjects(ctx context.Context, c *cli.Context) *ProjectError {
 
 	oldDir := strings.TrimSpace(c.String("workspace"))
 	// Check to see if the workspace exists
@@ -41,46 +42,43 @@ func UpgradeProjects(c *cli.Context) *ProjectError {
 	}
 
 	fmt.Println("Looking for projects in " + projectDir)
-	filepath.Walk(projectDir, func(path string, info os.FileInfo, err error) error {
+	err = filewatcher.Walk(ctx, projectDir, filewatcher.Options{}, func(projectFile filewatcher.File) error {
+		file, err := ioutil.ReadFile(projectFile.Path)
 		if err != nil {
-			err = errors.New(textUpgradeError)
-			return &ProjectError{errOpFileParse, err, textUpgradeError}
+			return nil
 		}
+		var result map[string]string
+		json.Unmarshal([]byte(file), &result)
 
-		if !info.IsDir() {
-			file, err := ioutil.ReadFile(path)
-			if err != nil {
-				return nil
-			}
-			var result map[string]string
-			json.Unmarshal([]byte(file), &result)
-
-			language := result["language"]
-			projectType := result["projectType"]
-			name := result["name"]
-			location := oldDir + "/" + name
-			fmt.Println("Calling bind for project " + name + "," + projectType + "," + language + " in " + location)
+		language := result["language"]
+		projectType := result["projectType"]
+		name := result["name"]
+		location := oldDir + "/" + name
+		fmt.Println("Calling bind for project " + name + "," + projectType + "," + language + " in " + location)
 
-			if language != "" && projectType != "" && name != "" && location != "" {
-				response, binderr := Bind(location, name, language, projectType, "local")
-				PrintAsJSON := c.GlobalBool("json")
-				if binderr != nil {
-					fmt.Println(binderr)
+		if language != "" && projectType != "" && name != "" && location != "" {
+			response, binderr := Bind(ctx, location, name, language, projectType, "local")
+			PrintAsJSON := c.GlobalBool("json")
+			if binderr != nil {
+				fmt.Println(binderr)
+			} else {
+				if PrintAsJSON {
+					jsonResponse, _ := json.Marshal(response)
+					fmt.Println(string(jsonResponse))
 				} else {
-					if PrintAsJSON {
-						jsonResponse, _ := json.Marshal(response)
-						fmt.Println(string(jsonResponse))
-					} else {
-						fmt.Println("Project ID: " + response.ProjectID)
-						fmt.Println("Status: " + response.Status)
-					}
+					fmt.Println("Project ID: " + response.ProjectID)
+					fmt.Println("Status: " + response.Status)
 				}
-			} else {
-				fmt.Println("Unable to upgrade project, failed to determine project details")
 			}
+		} else {
+			fmt.Println("Unable to upgrade project, failed to determine project details")
 		}
 		return nil
 	})
+	if err != nil {
+		err = errors.New(textUpgradeError + ": " + err.Error())
+		return &ProjectError{errOpFileParse, err, err.Error()}
+	}
 	return nil
 
 }
@@ -0,0 +1,119 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	logr "github.com/sirupsen/logrus"
+)
+
+// uploadAttempts is how many times an upload request is sent before the upload is reported as failed
+const uploadAttempts = 4
+
+// bandwidthReadSize is the most bytes read from a request body at once when the bandwidth is limited, so that
+// concurrent uploads share the bandwidth evenly
+const bandwidthReadSize = 16 * 1024
+
+// uploadRetryDelay is how long to wait before retrying a failed upload request, doubling with each retry
+var uploadRetryDelay = time.Second
+
+// sendUpload : PUTs an upload message to PFE, retrying with a growing delay when the request fails or PFE is
+// unavailable, so that a brief network problem does not fail the upload. Returns the status of the last attempt,
+// or an error when the last attempt got no response
+func sendUpload(client *http.Client, uploadURL string, body []byte, limiter *bandwidthLimiter) (string, int, error) {
+	delay := uploadRetryDelay
+	var status string
+	var statusCode int
+	var err error
+	for attempt := 1; attempt <= uploadAttempts; attempt++ {
+		if attempt > 1 {
+			logr.Debugf("Retrying upload to %v in %v\n", uploadURL, delay)
+			time.Sleep(delay)
+			delay *= 2
+		}
+		status, statusCode, err = putUpload(client, uploadURL, body, limiter)
+		if err == nil && !isRetryableStatus(statusCode) {
+			return status, statusCode, nil
+		}
+	}
+	return status, statusCode, err
+}
+
+func putUpload(client *http.Client, uploadURL string, body []byte, limiter *bandwidthLimiter) (string, int, error) {
+	var reader io.Reader = bytes.NewReader(body)
+	if limiter != nil {
+		reader = &limitedReader{reader: reader, limiter: limiter}
+	}
+	request, err := http.NewRequest("PUT", uploadURL, reader)
+	if err != nil {
+		return "", 0, err
+	}
+	request.ContentLength = int64(len(body))
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(request)
+	if err != nil {
+		return "", 0, err
+	}
+	resp.Body.Close()
+	return resp.Status, resp.StatusCode, nil
+}
+
+// bandwidthLimiter : spaces out the bytes sent by concurrent uploads so that together they send no more than
+// bytesPerSecond
+type bandwidthLimiter struct {
+	bytesPerSecond int64
+	mutex          sync.Mutex
+	next           time.Time
+}
+
+// newBandwidthLimiter : a limiter of bytesPerSecond, or nil for no limit when it is not positive
+func newBandwidthLimiter(bytesPerSecond int64) *bandwidthLimiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return &bandwidthLimiter{bytesPerSecond: bytesPerSecond}
+}
+
+// wait : blocks until sending n more bytes keeps within the limit
+func (limiter *bandwidthLimiter) wait(n int) {
+	limiter.mutex.Lock()
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+	limiter.next = limiter.next.Add(time.Duration(int64(n) * int64(time.Second) / limiter.bytesPerSecond))
+	until := limiter.next
+	limiter.mutex.Unlock()
+	time.Sleep(time.Until(until))
+}
+
+// limitedReader : a request body read no faster than its limiter allows
+type limitedReader struct {
+	reader  io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthReadSize {
+		p = p[:bandwidthReadSize]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
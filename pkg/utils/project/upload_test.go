@@ -0,0 +1,126 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendUpload(t *testing.T) {
+	defer func(delay time.Duration) { uploadRetryDelay = delay }(uploadRetryDelay)
+	uploadRetryDelay = time.Millisecond
+
+	failures := 0
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("Asserts an unavailable PFE is retried", func(t *testing.T) {
+		failures, attempts = 2, 0
+		_, statusCode, err := sendUpload(&http.Client{}, server.URL, []byte("{}"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("Asserts the upload fails once every attempt has failed", func(t *testing.T) {
+		failures, attempts = uploadAttempts, 0
+		_, statusCode, err := sendUpload(&http.Client{}, server.URL, []byte("{}"), nil)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Equal(t, uploadAttempts, attempts)
+	})
+
+	t.Run("Asserts a request which cannot be sent is retried then reported", func(t *testing.T) {
+		_, _, err := sendUpload(&http.Client{}, "http://127.0.0.1:0", []byte("{}"), nil)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestBandwidthLimiter(t *testing.T) {
+	assert.Nil(t, newBandwidthLimiter(0))
+
+	var received []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	body := bytes.Repeat([]byte("a"), 20*1024)
+	started := time.Now()
+	_, statusCode, err := sendUpload(&http.Client{}, server.URL, body, newBandwidthLimiter(100*1024))
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, statusCode)
+	assert.Equal(t, body, received)
+	assert.True(t, time.Since(started) >= 150*time.Millisecond, "20 KB at 100 KB per second took %v", time.Since(started))
+}
+
+func TestResumableChunkedUpload(t *testing.T) {
+	defer func(delay time.Duration) { uploadRetryDelay = delay }(uploadRetryDelay)
+	uploadRetryDelay = time.Millisecond
+
+	projectPath := path.Join(testFolder, "resumableProject")
+	os.MkdirAll(projectPath, 0777)
+	defer os.RemoveAll(projectPath)
+	content := bytes.Repeat([]byte{0xff}, uploadChunkSize*2+10)
+	ioutil.WriteFile(path.Join(projectPath, "large.bin"), content, 0644)
+
+	available := false
+	var offsets []int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := FileUploadMsg{}
+		json.NewDecoder(r.Body).Decode(&msg)
+		// The second chunk cannot be delivered until PFE becomes available
+		if msg.Offset > 0 && !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		offsets = append(offsets, msg.Offset)
+	}))
+	defer server.Close()
+	options := uploadOptions{MaxFileSize: int64(len(content)) * 2, Raw: true, Resumable: true}
+
+	result := syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, nil)
+
+	t.Run("Asserts an interrupted upload records how much was received", func(t *testing.T) {
+		assert.Equal(t, []int64{0}, offsets)
+		assert.Empty(t, result.State.Files)
+		assert.Equal(t, int64(uploadChunkSize), result.State.Partial["large.bin"].Received)
+	})
+
+	t.Run("Asserts the next sync sends only the rest of the file", func(t *testing.T) {
+		available = true
+		offsets = nil
+		result = syncFiles(context.Background(), projectPath, "project", server.URL+"/", 0, options, result.State)
+		assert.Equal(t, []int64{uploadChunkSize, uploadChunkSize * 2}, offsets)
+		assert.Contains(t, result.State.Files, "large.bin")
+		assert.Empty(t, result.State.Partial)
+	})
+}
@@ -0,0 +1,134 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+)
+
+type (
+	// ValidationReport : What validating a project found, without running anything or writing to the project
+	ValidationReport struct {
+		Path      string              `json:"projectPath"`
+		Language  string              `json:"language"`
+		BuildType string              `json:"projectType"`
+		Extension *ValidatedExtension `json:"extension"`
+		// Warning explains why extensions were not checked, when Codewind could not be asked for them
+		Warning string `json:"warning,omitempty"`
+	}
+
+	// ValidatedExtension : The extension a project belongs to, with the commands it runs when the project is
+	// validated, their binaries resolved and arguments substituted
+	ValidatedExtension struct {
+		Name        string                   `json:"name"`
+		Version     string                   `json:"version,omitempty"`
+		ProjectType string                   `json:"projectType"`
+		Commands    []utils.ExtensionCommand `json:"commands"`
+	}
+)
+
+// ValidateProjectPath : Describes the project at projectPath as bind would see it: its language and build type,
+// and the extension of the connection's Codewind it belongs to. typeHint is an optional type:subtype which the
+// extension is matched by instead of its detection file. Unlike create, nothing is run or written
+func ValidateProjectPath(httpClient utils.HTTPClient, conID string, projectPath string, typeHint string) (*ValidationReport, *ProjectError) {
+	info, err := os.Stat(projectPath)
+	if err != nil {
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+	if !info.IsDir() {
+		err := errors.New(projectPath + " is not a directory")
+		return nil, &ProjectError{errBadPath, err, err.Error()}
+	}
+
+	language, buildType := determineProjectInfo(projectPath)
+	report := ValidationReport{Path: projectPath, Language: language, BuildType: buildType}
+
+	host, conErr := connections.GetPFEOrigin(conID)
+	if conErr != nil {
+		report.Warning = "Extensions were not checked, as Codewind could not be found: " + conErr.Desc
+		return &report, nil
+	}
+	extensions, err := apiroutes.NewPFEClientForHost(httpClient, host).ListExtensions()
+	if err != nil {
+		report.Warning = "Extensions were not checked, as they could not be listed: " + err.Error()
+		return &report, nil
+	}
+
+	extension, command, params := matchExtension(extensions, projectPath, typeHint)
+	if extension == nil {
+		return &report, nil
+	}
+	report.BuildType = extension.ProjectType
+	report.Extension = &ValidatedExtension{
+		Name:        extension.Name,
+		Version:     extension.Version,
+		ProjectType: extension.ProjectType,
+		Commands:    []utils.ExtensionCommand{},
+	}
+	if command != nil {
+		commandPath, err := command.CommandPath()
+		if err != nil {
+			commandPath = command.Command
+		}
+		report.Extension.Commands = append(report.Extension.Commands, utils.ExtensionCommand{
+			Name:    command.Name,
+			Command: commandPath,
+			Args:    command.SubstituteArgs(params),
+		})
+	}
+	return &report, nil
+}
+
+// matchExtension : The extension a project belongs to, matched by a type:subtype hint when one is given and
+// otherwise by the detection file an extension defines, with the command it runs on validation and the parameters
+// of that command. The extension is nil when none matches, the command when the extension has none to run
+func matchExtension(extensions []utils.Extension, projectPath string, typeHint string) (*utils.Extension, *utils.ExtensionCommand, map[string]string) {
+	params := make(map[string]string)
+	commandName := "postProjectValidate"
+	if typeHint != "" {
+		parts := strings.Split(typeHint, ":")
+		params["$type"] = parts[0]
+		if len(parts) > 1 {
+			params["$subtype"] = parts[1]
+		}
+		commandName = "postProjectValidateWithType"
+	}
+
+	for i := range extensions {
+		extension := &extensions[i]
+		var isMatch bool
+		if typeHint != "" {
+			// check if extension project type or one of its registered styles matched the hinted type
+			isMatch = extension.ProjectType == params["$type"] || utils.ContainsStyle(extension.GetStyles(), params["$type"])
+		} else {
+			// check if project contains the detection file an extension defines
+			isMatch = extension.Detection != "" && utils.PathExists(path.Join(projectPath, extension.Detection))
+		}
+		if !isMatch {
+			continue
+		}
+		for j := range extension.Commands {
+			if extension.Commands[j].Name == commandName {
+				return extension, &extension.Commands[j], params
+			}
+		}
+		return extension, nil, params
+	}
+	return nil, nil, params
+}
@@ -0,0 +1,88 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package project
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testExtensions = []utils.Extension{
+	{
+		Name:        "codewind-appsody-extension",
+		ProjectType: "appsody",
+		Detection:   ".appsody-config.yaml",
+		Commands: []utils.ExtensionCommand{
+			{Name: "postProjectValidate", Command: "appsody.sh", Args: []string{"validate"}},
+			{Name: "postProjectValidateWithType", Command: "appsody.sh", Args: []string{"init", "$type", "$subtype"}},
+		},
+		Config: utils.ExtensionConfig{Styles: []string{"Appsody"}},
+	},
+	{
+		Name:        "codewind-odo-extension",
+		ProjectType: "odo",
+		Detection:   ".odo",
+	},
+}
+
+func TestMatchExtension(t *testing.T) {
+	projectPath, _ := ioutil.TempDir("", "validate")
+	defer os.RemoveAll(projectPath)
+
+	t.Run("Asserts no extension matches a project without a detection file", func(t *testing.T) {
+		extension, command, _ := matchExtension(testExtensions, projectPath, "")
+		assert.Nil(t, extension)
+		assert.Nil(t, command)
+	})
+
+	t.Run("Asserts an extension matches by its detection file", func(t *testing.T) {
+		ioutil.WriteFile(filepath.Join(projectPath, ".appsody-config.yaml"), []byte{}, 0644)
+		defer os.Remove(filepath.Join(projectPath, ".appsody-config.yaml"))
+		extension, command, _ := matchExtension(testExtensions, projectPath, "")
+		assert.Equal(t, "appsody", extension.ProjectType)
+		assert.Equal(t, []string{"validate"}, command.Args)
+	})
+
+	t.Run("Asserts an extension matches a type hint by one of its styles", func(t *testing.T) {
+		extension, command, params := matchExtension(testExtensions, projectPath, "appsody:nodejs-express")
+		assert.Equal(t, "appsody", extension.ProjectType)
+		assert.Equal(t, "postProjectValidateWithType", command.Name)
+		assert.Equal(t, []string{"init", "appsody", "nodejs-express"}, command.SubstituteArgs(params))
+	})
+
+	t.Run("Asserts an extension without a validation command matches without one", func(t *testing.T) {
+		extension, command, _ := matchExtension(testExtensions, projectPath, "odo")
+		assert.Equal(t, "odo", extension.ProjectType)
+		assert.Nil(t, command)
+	})
+}
+
+func TestValidateProjectPath(t *testing.T) {
+	t.Run("Asserts a missing path is reported", func(t *testing.T) {
+		_, projErr := ValidateProjectPath(http.DefaultClient, "local", filepath.Join(os.TempDir(), "no-such-project"), "")
+		assert.Equal(t, errBadPath, projErr.Op)
+	})
+
+	t.Run("Asserts a file is not a project", func(t *testing.T) {
+		file, _ := ioutil.TempFile("", "validate")
+		file.Close()
+		defer os.Remove(file.Name())
+		_, projErr := ValidateProjectPath(http.DefaultClient, "local", file.Name(), "")
+		assert.Equal(t, errBadPath, projErr.Op)
+	})
+}
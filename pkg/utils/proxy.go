@@ -0,0 +1,110 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// containerNoProxyHosts are always reached directly from inside the Codewind containers
+var containerNoProxyHosts = []string{"localhost", "127.0.0.1", "codewind-pfe", "codewind-performance"}
+
+// ProxySettings : The proxies used for outbound HTTP and HTTPS requests
+type ProxySettings struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// GetProxySettings returns the proxy settings from the environment, accepting upper or lower case variables
+func GetProxySettings() ProxySettings {
+	return ProxySettings{
+		HTTPProxy:  getEnvAnyCase("HTTP_PROXY"),
+		HTTPSProxy: getEnvAnyCase("HTTPS_PROXY"),
+		NoProxy:    getEnvAnyCase("NO_PROXY"),
+	}
+}
+
+// Merge returns a copy of the settings with any non-empty values of overrides applied
+func (settings ProxySettings) Merge(overrides ProxySettings) ProxySettings {
+	merged := settings
+	if overrides.HTTPProxy != "" {
+		merged.HTTPProxy = overrides.HTTPProxy
+	}
+	if overrides.HTTPSProxy != "" {
+		merged.HTTPSProxy = overrides.HTTPSProxy
+	}
+	if overrides.NoProxy != "" {
+		merged.NoProxy = overrides.NoProxy
+	}
+	return merged
+}
+
+// ApplyProxySettings overrides the proxy environment with any non-empty settings, so that the shared
+// HTTP transport, the Kubernetes client and child processes such as docker-compose all use the same proxies
+func ApplyProxySettings(settings ProxySettings) {
+	setEnvBothCases("HTTP_PROXY", settings.HTTPProxy)
+	setEnvBothCases("HTTPS_PROXY", settings.HTTPSProxy)
+	setEnvBothCases("NO_PROXY", settings.NoProxy)
+	http.DefaultTransport.(*http.Transport).Proxy = http.ProxyFromEnvironment
+}
+
+// IsSet returns whether an HTTP or HTTPS proxy is configured
+func (settings ProxySettings) IsSet() bool {
+	return settings.HTTPProxy != "" || settings.HTTPSProxy != ""
+}
+
+// ContainerNoProxy returns the NO_PROXY value for the Codewind containers, which must also
+// reach each other directly
+func (settings ProxySettings) ContainerNoProxy() string {
+	if !settings.IsSet() {
+		return settings.NoProxy
+	}
+	hosts := []string{}
+	if settings.NoProxy != "" {
+		hosts = append(hosts, settings.NoProxy)
+	}
+	for _, host := range containerNoProxyHosts {
+		if !ContainsHost(settings.NoProxy, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return strings.Join(hosts, ",")
+}
+
+// ContainsHost returns whether a comma separated NO_PROXY list names the given host
+func ContainsHost(noProxy string, host string) bool {
+	for _, entry := range strings.Split(noProxy, ",") {
+		if strings.EqualFold(strings.TrimSpace(entry), host) {
+			return true
+		}
+	}
+	return false
+}
+
+func getEnvAnyCase(name string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		value = os.Getenv(strings.ToLower(name))
+	}
+	return value
+}
+
+func setEnvBothCases(name string, value string) {
+	if value == "" {
+		return
+	}
+	os.Setenv(name, value)
+	os.Setenv(strings.ToLower(name), value)
+}
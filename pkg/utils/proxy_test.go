@@ -0,0 +1,43 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ContainerNoProxy(t *testing.T) {
+	t.Run("Unchanged when no proxy is set", func(t *testing.T) {
+		settings := ProxySettings{NoProxy: "example.com"}
+		assert.Equal(t, "example.com", settings.ContainerNoProxy())
+	})
+
+	t.Run("Adds the Codewind containers when a proxy is set", func(t *testing.T) {
+		settings := ProxySettings{HTTPProxy: "http://proxy:3128", NoProxy: "example.com,localhost"}
+		assert.Equal(t, "example.com,localhost,127.0.0.1,codewind-pfe,codewind-performance", settings.ContainerNoProxy())
+	})
+
+	t.Run("Works with an empty NO_PROXY", func(t *testing.T) {
+		settings := ProxySettings{HTTPSProxy: "http://proxy:3128"}
+		assert.Equal(t, "localhost,127.0.0.1,codewind-pfe,codewind-performance", settings.ContainerNoProxy())
+	})
+}
+
+func Test_MergeProxySettings(t *testing.T) {
+	t.Run("Non-empty overrides take precedence", func(t *testing.T) {
+		configured := ProxySettings{HTTPProxy: "http://config:3128", HTTPSProxy: "http://config:3128", NoProxy: "example.com"}
+		merged := configured.Merge(ProxySettings{HTTPSProxy: "http://env:3128"})
+		assert.Equal(t, ProxySettings{HTTPProxy: "http://config:3128", HTTPSProxy: "http://env:3128", NoProxy: "example.com"}, merged)
+	})
+}
@@ -35,7 +35,12 @@ func SetupKeycloak(codewindInstance Codewind, deployOptions *DeployOptions) erro
 	if deployOptions.GateKeeperTLSSecure {
 		gateKeeperProtocol = "https://"
 	}
+	return configureKeycloak(authURL, gateKeeperProtocol+GatekeeperPrefix+codewindInstance.Ingress, deployOptions)
+}
 
+// configureKeycloak : waits for the Keycloak at authURL, then sets it up with a realm, a client which redirects to
+// the gatekeeper at gatekeeperURL, and a user, keeping the secret of the client in deployOptions
+func configureKeycloak(authURL string, gatekeeperURL string, deployOptions *DeployOptions) error {
 	logr.Infoln("Waiting for Keycloak to start")
 	startErr := utils.WaitForService(authURL, 200, 500)
 	if startErr != nil {
@@ -74,7 +79,7 @@ func SetupKeycloak(codewindInstance Codewind, deployOptions *DeployOptions) erro
 
 	// Create a new client
 	logr.Infoln("Creating Keycloak client")
-	gatekeeperPublicURL := gateKeeperProtocol + GatekeeperPrefix + codewindInstance.Ingress + "/*"
+	gatekeeperPublicURL := gatekeeperURL + "/*"
 	clientFlagset := flag.NewFlagSet("setupClient", 0)
 	clientFlagset.String("host", authURL, "doc")
 	clientFlagset.String("redirect", gatekeeperPublicURL, "doc")
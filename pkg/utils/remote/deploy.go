@@ -13,9 +13,6 @@ package remote
 
 import (
 	"errors"
-	"log"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -26,7 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
+	restclient "k8s.io/client-go/rest"
 )
 
 // DeployOptions : Keycloak initial config
@@ -45,24 +42,48 @@ type DeployOptions struct {
 	GateKeeperTLSSecure   bool
 	CodewindSessionSecret string
 	ClientSecret          string
+	Resume                bool
+	// SkipPreflight deploys without first checking the cluster can take the install
+	SkipPreflight bool
+	// OpenShiftOAuth has the gatekeeper log users in with the OAuth server of the OpenShift cluster, rather than
+	// deploying Keycloak
+	OpenShiftOAuth bool
+	// OAuthServerURL is the OAuth server of the OpenShift cluster, discovered when OpenShiftOAuth is set
+	OAuthServerURL string
+	// Images are the container images to deploy, any which are not set use the defaults from GetImages
+	Images Images
 }
 
 // DeploymentResult : Ingress root URLs
 type DeploymentResult struct {
 	GatekeeperURL string
 	KeycloakURL   string
+	WorkspaceID   string
+	Images        Images
 }
 
 // DeployRemote : InstallRemote
 func DeployRemote(remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemInstError) {
 
-	kubeconfig := filepath.Join(os.Getenv("HOME"), ".kube", "config")
-	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	config, err := kube.GetKubeClientConfig().ClientConfig()
 	if err != nil {
 		logr.Infof("Unable to retrieve Kubernetes Config %v\n", err)
 		return nil, &RemInstError{errOpNotFound, err, err.Error()}
 	}
+	logKubeContext()
+	return DeployRemoteWithConfig(config, remoteDeployOptions)
+}
+
+// logKubeContext : logs the Kubernetes context an install is deployed with, so the cluster it went to is known
+func logKubeContext() {
+	context, err := kube.GetCurrentContext()
+	if err == nil {
+		logr.Infof("Using Kubernetes context : %v\n", context)
+	}
+}
 
+// DeployRemoteWithConfig : InstallRemote using the given Kubernetes config, such as the in-cluster config of the operator
+func DeployRemoteWithConfig(config *restclient.Config, remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemInstError) {
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		logr.Infof("Unable to retrieve Kubernetes clientset %v\n", err)
@@ -77,7 +98,24 @@ func DeployRemote(remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemIn
 
 	logr.Infof("Using namespace : %v\n", namespace)
 
-	pfeImage, performanceImage, keycloakImage, gatekeeperImage := GetImages()
+	// Pick up where a failed install left off, if asked to
+	var progress *InstallProgress
+	if remoteDeployOptions.Resume {
+		progress, err = LoadInstallProgress(clientset, namespace)
+		if err != nil {
+			return nil, &RemInstError{errOpProgress, err, err.Error()}
+		}
+		if progress == nil {
+			remoteInstError := errors.New(errNoInstallProgress)
+			return nil, &RemInstError{errOpProgress, remoteInstError, remoteInstError.Error()}
+		}
+		logr.Infof("Resuming install of workspace %v, completed steps: %v\n", progress.WorkspaceID, progress.CompletedSteps)
+		remoteDeployOptions.ClientSecret = progress.ClientSecret
+		remoteDeployOptions.CodewindSessionSecret = progress.SessionSecret
+	}
+
+	images := remoteDeployOptions.Images.withDefaults()
+	pfeImage, performanceImage, keycloakImage, gatekeeperImage := images.PFE, images.Performance, images.Keycloak, images.Gatekeeper
 
 	logr.Infoln("Container images : ")
 	logr.Infoln(pfeImage)
@@ -89,19 +127,38 @@ func DeployRemote(remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemIn
 	onOpenShift := kube.DetectOpenShift(config)
 	logr.Infof("Running on openshift: %t\n", onOpenShift)
 
+	if remoteDeployOptions.OpenShiftOAuth {
+		if !onOpenShift {
+			remoteInstError := errors.New(errNotOpenShift)
+			return nil, &RemInstError{errOpNotOpenShift, remoteInstError, remoteInstError.Error()}
+		}
+		remoteDeployOptions.OAuthServerURL, err = kube.DiscoverOAuthServer(clientset)
+		if err != nil {
+			return nil, &RemInstError{errOpOAuth, err, err.Error()}
+		}
+		logr.Infof("Using the OpenShift OAuth server: %v\n", remoteDeployOptions.OAuthServerURL)
+	}
+
+	// Check the cluster can take the install before anything is deployed into it
+	if !remoteDeployOptions.SkipPreflight {
+		remInstErr := runPreflightCheck(clientset, namespace, remoteDeployOptions.IngressDomain, onOpenShift, remoteDeployOptions.OpenShiftOAuth)
+		if remInstErr != nil {
+			return nil, remInstErr
+		}
+	}
+
 	workspaceID := strings.ToLower(strconv.FormatInt(utils.CreateTimestamp(), 36))
 
 	// Get the ingress host
 	ingressDomain := remoteDeployOptions.IngressDomain
+	if progress != nil {
+		workspaceID = progress.WorkspaceID
+		ingressDomain = progress.IngressDomain
+	}
 
 	// Use a supplied ingress if one was not installed
-	if remoteDeployOptions.IngressDomain == "" && !onOpenShift {
-		logr.Infof("Attempting to discover Ingress Domain")
-		svcList := clientset.CoreV1().Services("ingress-nginx")
-		svc, err := svcList.List(v1.ListOptions{})
-		if err == nil && svc != nil && svc.Items != nil {
-			ingressDomain = svc.Items[0].Spec.ClusterIP + ".nip.io"
-		}
+	if ingressDomain == "" && !onOpenShift {
+		ingressDomain = discoverIngressDomain(clientset)
 	}
 
 	// Check ingress service installed
@@ -112,70 +169,83 @@ func DeployRemote(remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemIn
 
 	logr.Infof("Using ingress domain: %v\n", ingressDomain)
 
-	var ownerReferenceName string
 	var ownerReferenceUID types.UID
-	ownerReferenceName = "codewind" + workspaceID
 
 	logr.Errorln("TODO : Build PVC, Acct and Secret")
 	ownerReferenceUID = uuid.NewUUID()
-	workspacePVC := "codewind"
-	serviceAccountName := "codewind"
-	secretName := "codewind"
+	if progress != nil {
+		ownerReferenceUID = types.UID(progress.OwnerReferenceUID)
+	} else {
+		progress = &InstallProgress{
+			WorkspaceID:       workspaceID,
+			IngressDomain:     ingressDomain,
+			OwnerReferenceUID: string(ownerReferenceUID),
+			SessionSecret:     remoteDeployOptions.CodewindSessionSecret,
+		}
+	}
 
 	// Create the Codewind deployment object
-	codewindInstance := Codewind{
-		PFEName:            PFEPrefix + workspaceID,
-		PFEImage:           pfeImage,
-		PerformanceName:    PerformancePrefix + workspaceID,
-		PerformanceImage:   performanceImage,
-		KeycloakName:       KeycloakPrefix + workspaceID,
-		KeycloakImage:      keycloakImage,
-		GatekeeperName:     GatekeeperPrefix + workspaceID,
-		GatekeeperImage:    gatekeeperImage,
-		Namespace:          namespace,
-		WorkspaceID:        workspaceID,
-		PVCName:            workspacePVC,
-		ServiceAccountName: serviceAccountName,
-		PullSecret:         secretName,
-		OwnerReferenceName: ownerReferenceName,
-		OwnerReferenceUID:  ownerReferenceUID,
-		Privileged:         true,
-		Ingress:            "-" + workspaceID + "-" + ingressDomain,
-		OnOpenShift:        onOpenShift,
-	}
+	codewindInstance := newCodewindInstance(namespace, workspaceID, ingressDomain, images, ownerReferenceUID, onOpenShift)
 
-	err = DeployKeycloak(config, clientset, codewindInstance, remoteDeployOptions, onOpenShift)
-	if err != nil {
-		log.Printf("Codewind Keycloak failed, exiting...")
-		os.Exit(1)
+	type installStep struct {
+		name   string
+		deploy func() error
 	}
-
-	err = SetupKeycloak(codewindInstance, remoteDeployOptions)
-	if err != nil {
-		log.Printf("Codewind Keycloak configuration failed, exiting...")
-		os.Exit(1)
+	steps := []installStep{
+		{StepDeployKeycloak, func() error {
+			return DeployKeycloak(config, clientset, codewindInstance, remoteDeployOptions, onOpenShift)
+		}},
+		{StepConfigureKeycloak, func() error { return SetupKeycloak(codewindInstance, remoteDeployOptions) }},
 	}
-
-	err = DeployPFE(config, clientset, codewindInstance, remoteDeployOptions)
-	if err != nil {
-		log.Printf("Codewind deployment failed, exiting...")
-		os.Exit(1)
+	// OpenShift OAuth takes the place of Keycloak
+	if remoteDeployOptions.OpenShiftOAuth {
+		steps = []installStep{
+			{StepCreateOAuthClient, func() error { return DeployOAuthClient(config, codewindInstance, remoteDeployOptions) }},
+		}
 	}
+	steps = append(steps,
+		installStep{StepDeployPFE, func() error { return DeployPFE(config, clientset, codewindInstance, remoteDeployOptions) }},
+		installStep{StepDeployPerformance, func() error { return DeployPerformance(clientset, codewindInstance, remoteDeployOptions) }},
+		installStep{StepDeployGatekeeper, func() error {
+			return DeployGatekeeper(config, clientset, codewindInstance, remoteDeployOptions)
+		}},
+	)
 
-	err = DeployPerformance(clientset, codewindInstance, remoteDeployOptions)
-	if err != nil {
-		log.Printf("Codewind deployment failed, exiting...")
-		os.Exit(1)
+	for _, step := range steps {
+		if progress.IsComplete(step.name) {
+			logr.Infof("Skipping completed step: %v\n", step.name)
+			continue
+		}
+		err = step.deploy()
+		if err != nil {
+			logr.Errorf("Remote install failed at step %v, fix the problem and rerun with --resume to continue\n", step.name)
+			progress.FailedStep = step.name
+			saveErr := SaveInstallProgress(clientset, namespace, progress)
+			if saveErr != nil {
+				logr.Errorf("Unable to save install progress: %v\n", saveErr)
+			}
+			return nil, &RemInstError{errOpStepFailed, err, err.Error()}
+		}
+		progress.MarkComplete(step.name)
+		progress.ClientSecret = remoteDeployOptions.ClientSecret
+		err = SaveInstallProgress(clientset, namespace, progress)
+		if err != nil {
+			logr.Warnf("Unable to save install progress: %v\n", err)
+		}
 	}
 
-	err = DeployGatekeeper(config, clientset, codewindInstance, remoteDeployOptions)
+	err = DeleteInstallProgress(clientset, namespace)
 	if err != nil {
-		log.Printf("Codewind Gatekeeper deployment failed, exiting...")
-		os.Exit(1)
+		logr.Warnf("Unable to remove install progress: %v\n", err)
 	}
 
-	gatekeeperURL := GatekeeperPrefix + codewindInstance.Ingress
-	keycloakURL := KeycloakPrefix + codewindInstance.Ingress
+	return newDeploymentResult(codewindInstance, remoteDeployOptions, images), nil
+}
+
+// newDeploymentResult : the URLs of the gatekeeper and Keycloak, or the OpenShift OAuth server, of an install of codewind
+func newDeploymentResult(codewind Codewind, remoteDeployOptions *DeployOptions, images Images) *DeploymentResult {
+	gatekeeperURL := GatekeeperPrefix + codewind.Ingress
+	keycloakURL := KeycloakPrefix + codewind.Ingress
 
 	if remoteDeployOptions.GateKeeperTLSSecure {
 		gatekeeperURL = "https://" + gatekeeperURL
@@ -189,10 +259,53 @@ func DeployRemote(remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemIn
 		keycloakURL = "http://" + keycloakURL
 	}
 
+	// Users log in with the OAuth server of the cluster rather than Keycloak
+	if remoteDeployOptions.OpenShiftOAuth {
+		keycloakURL = remoteDeployOptions.OAuthServerURL
+	}
+
 	deploymentResult := DeploymentResult{
 		GatekeeperURL: gatekeeperURL,
 		KeycloakURL:   keycloakURL,
+		WorkspaceID:   codewind.WorkspaceID,
+		Images:        images,
+	}
+
+	return &deploymentResult
+}
+
+// newCodewindInstance : the Codewind instance of a remote install of workspaceID into namespace, exposed under
+// ingressDomain
+func newCodewindInstance(namespace string, workspaceID string, ingressDomain string, images Images, ownerReferenceUID types.UID, onOpenShift bool) Codewind {
+	return Codewind{
+		PFEName:            PFEPrefix + workspaceID,
+		PFEImage:           images.PFE,
+		PerformanceName:    PerformancePrefix + workspaceID,
+		PerformanceImage:   images.Performance,
+		KeycloakName:       KeycloakPrefix + workspaceID,
+		KeycloakImage:      images.Keycloak,
+		GatekeeperName:     GatekeeperPrefix + workspaceID,
+		GatekeeperImage:    images.Gatekeeper,
+		Namespace:          namespace,
+		WorkspaceID:        workspaceID,
+		PVCName:            "codewind",
+		ServiceAccountName: "codewind",
+		PullSecret:         "codewind",
+		OwnerReferenceName: "codewind" + workspaceID,
+		OwnerReferenceUID:  ownerReferenceUID,
+		Privileged:         true,
+		Ingress:            "-" + workspaceID + "-" + ingressDomain,
+		OnOpenShift:        onOpenShift,
 	}
+}
 
-	return &deploymentResult, nil
+// discoverIngressDomain : a nip.io domain for the cluster IP of the ingress-nginx service, if it is installed
+func discoverIngressDomain(clientset kubernetes.Interface) string {
+	logr.Infof("Attempting to discover Ingress Domain")
+	svcList := clientset.CoreV1().Services("ingress-nginx")
+	svc, err := svcList.List(v1.ListOptions{})
+	if err == nil && svc != nil && len(svc.Items) > 0 {
+		return svc.Items[0].Spec.ClusterIP + ".nip.io"
+	}
+	return ""
 }
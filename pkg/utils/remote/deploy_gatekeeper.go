@@ -12,8 +12,7 @@
 package remote
 
 import (
-	"os"
-
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	v1 "github.com/openshift/api/route/v1"
 	routev1 "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 	log "github.com/sirupsen/logrus"
@@ -43,35 +42,35 @@ func DeployGatekeeper(config *restclient.Config, clientset *kubernetes.Clientset
 	log.Infoln("Deploying Codewind Gatekeeper Secrets")
 
 	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(&gatekeeperSecrets)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Gatekeeper secrets: %v\n", err)
 		return err
 	}
 
 	log.Infoln("Deploying Codewind Gatekeeper Session Secrets")
 	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(&gatekeeperSessionSecret)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind secrets: %v\n", err)
 		return err
 	}
 
 	log.Infoln("Deploying Codewind Gatekeeper TLS Secrets")
 	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(&gatekeeperTLSSecret)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Gatekeeper TLS secrets: %v\n", err)
 		return err
 	}
 
 	log.Infoln("Deploying Codewind Gatekeeper Deployment")
 	_, err = clientset.AppsV1().Deployments(deployOptions.Namespace).Create(&gatekeeperDeploy)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Gatekeeper deployment: %v\n", err)
 		return err
 	}
 
 	log.Infoln("Deploying Codewind Gatekeeper Service")
 	_, err = clientset.CoreV1().Services(deployOptions.Namespace).Create(&gatekeeperService)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Gatekeeper service: %v\n", err)
 		return err
 	}
@@ -83,21 +82,21 @@ func DeployGatekeeper(config *restclient.Config, clientset *kubernetes.Clientset
 		route := createRouteGatekeeper(codewindInstance)
 		routev1client, err := routev1.NewForConfig(config)
 		if err != nil {
-			log.Printf("Error retrieving route client for OpenShift: %v\n", err)
-			os.Exit(1)
+			log.Errorf("Error retrieving route client for OpenShift: %v\n", err)
+			return err
 		}
 		_, err = routev1client.Routes(codewindInstance.Namespace).Create(&route)
-		if err != nil {
-			log.Printf("Error: Unable to create route for Codewind: %v\n", err)
-			os.Exit(1)
+		if ignoreAlreadyExists(err) != nil {
+			log.Errorf("Error: Unable to create route for Codewind: %v\n", err)
+			return err
 		}
 	} else {
 		logr.Infof("Deploying Codewind Gatekeeper Ingress")
 		ingress := createIngressGatekeeper(codewindInstance)
 		_, err = clientset.ExtensionsV1beta1().Ingresses(codewindInstance.Namespace).Create(&ingress)
-		if err != nil {
-			log.Printf("Error: Unable to create ingress for Codewind Gatekeeper: %v\n", err)
-			os.Exit(1)
+		if ignoreAlreadyExists(err) != nil {
+			log.Errorf("Error: Unable to create ingress for Codewind Gatekeeper: %v\n", err)
+			return err
 		}
 	}
 	return nil
@@ -261,14 +260,24 @@ func setGatekeeperEnvVars(codewind Codewind, deployOptions *DeployOptions) []cor
 		keycloakURL = "http://" + keycloakURL
 	}
 
+	// With OpenShift OAuth the gatekeeper logs users in with the OAuth server of the cluster and its own OAuth client
+	authProvider, authURL, clientID := apiroutes.AuthProviderKeycloak, keycloakURL, deployOptions.KeycloakClient
+	if deployOptions.OpenShiftOAuth {
+		authProvider, authURL, clientID = apiroutes.AuthProviderOpenShift, deployOptions.OAuthServerURL, oauthClientName(codewind.WorkspaceID)
+	}
+
 	return []corev1.EnvVar{
+		{
+			Name:  "AUTH_PROVIDER",
+			Value: authProvider,
+		},
 		{
 			Name:  "AUTH_URL",
-			Value: keycloakURL,
+			Value: authURL,
 		},
 		{
 			Name:  "CLIENT_ID",
-			Value: deployOptions.KeycloakClient,
+			Value: clientID,
 		},
 		{
 			Name:  "ENABLE_AUTH",
@@ -12,7 +12,7 @@
 package remote
 
 import (
-	"os"
+	"encoding/json"
 
 	v1 "github.com/openshift/api/route/v1"
 	routev1 "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
@@ -38,24 +38,24 @@ func DeployKeycloak(config *restclient.Config, clientset *kubernetes.Clientset,
 
 	log.Infoln("Deploying Codewind Keycloak Secrets")
 	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(&keycloakSecrets)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Keycloak secrets: %v\n", err)
 		return err
 	}
 	_, err = clientset.CoreV1().Services(deployOptions.Namespace).Create(&keycloakService)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Keycloak service: %v\n", err)
 		return err
 	}
 	_, err = clientset.AppsV1().Deployments(deployOptions.Namespace).Create(&keycloakDeploy)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Keycloak deployment: %v\n", err)
 		return err
 	}
 
 	log.Infoln("Deploying Codewind Keycloak TLS Secrets")
 	_, err = clientset.CoreV1().Secrets(deployOptions.Namespace).Create(&keycloakTLSSecret)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Keycloak TLS secrets: %v\n", err)
 		return err
 	}
@@ -66,22 +66,22 @@ func DeployKeycloak(config *restclient.Config, clientset *kubernetes.Clientset,
 		route := createKeycloakRoute(codewindInstance)
 		routev1client, err := routev1.NewForConfig(config)
 		if err != nil {
-			log.Printf("Error retrieving route client for OpenShift: %v\n", err)
-			os.Exit(1)
+			log.Errorf("Error retrieving route client for OpenShift: %v\n", err)
+			return err
 		}
 		_, err = routev1client.Routes(deployOptions.Namespace).Create(&route)
-		if err != nil {
-			log.Printf("Error: Unable to create route for Codewind: %v\n", err)
-			os.Exit(1)
+		if ignoreAlreadyExists(err) != nil {
+			log.Errorf("Error: Unable to create route for Codewind: %v\n", err)
+			return err
 		}
 
 	} else {
 		logr.Infof("Deploying Codewind Keycloak Ingress")
 		ingress := createIngressKeycloak(codewindInstance)
 		_, err = clientset.ExtensionsV1beta1().Ingresses(deployOptions.Namespace).Create(&ingress)
-		if err != nil {
-			log.Printf("Error: Unable to create ingress for Codewind Keycloak: %v\n", err)
-			os.Exit(1)
+		if ignoreAlreadyExists(err) != nil {
+			log.Errorf("Error: Unable to create ingress for Codewind Keycloak: %v\n", err)
+			return err
 		}
 	}
 	return nil
@@ -105,6 +105,83 @@ func createKeycloakSecrets(codewind Codewind, deployOptions *DeployOptions) core
 	return generateSecrets(codewind, name, secrets)
 }
 
+// keycloakRealmImportDir : where the realm import secret is mounted in the Keycloak container
+const keycloakRealmImportDir = "/etc/codewind/keycloak"
+
+// keycloakRealm : a Keycloak realm with its client and users, in the form Keycloak imports it. The realm, client
+// and user are set up as SetupKeycloak does through the admin API
+type keycloakRealm struct {
+	Realm               string           `json:"realm"`
+	DisplayName         string           `json:"displayName"`
+	Enabled             bool             `json:"enabled"`
+	LoginTheme          string           `json:"loginTheme"`
+	AccessTokenLifespan int              `json:"accessTokenLifespan"`
+	Clients             []keycloakClient `json:"clients"`
+	Users               []keycloakUser   `json:"users"`
+}
+
+type keycloakClient struct {
+	ClientID                  string   `json:"clientId"`
+	Name                      string   `json:"name"`
+	Secret                    string   `json:"secret"`
+	DirectAccessGrantsEnabled bool     `json:"directAccessGrantsEnabled"`
+	PublicClient              bool     `json:"publicClient"`
+	RedirectUris              []string `json:"redirectUris"`
+}
+
+type keycloakUser struct {
+	Username    string                   `json:"username"`
+	Enabled     bool                     `json:"enabled"`
+	Credentials []keycloakUserCredential `json:"credentials"`
+}
+
+type keycloakUserCredential struct {
+	Type      string `json:"type"`
+	Value     string `json:"value"`
+	Temporary bool   `json:"temporary"`
+}
+
+// createKeycloakRealmSecret : the realm, client and developer user of an install, which Keycloak imports when it
+// first starts from the KEYCLOAK_IMPORT file, so that exported manifests need no further setup
+func createKeycloakRealmSecret(codewind Codewind, deployOptions *DeployOptions) (corev1.Secret, error) {
+	realm, err := json.Marshal(keycloakRealm{
+		Realm:               deployOptions.KeycloakRealm,
+		DisplayName:         deployOptions.KeycloakRealm,
+		Enabled:             true,
+		LoginTheme:          "codewind",
+		AccessTokenLifespan: 86400,
+		Clients: []keycloakClient{{
+			ClientID:                  deployOptions.KeycloakClient,
+			Name:                      deployOptions.KeycloakClient,
+			Secret:                    deployOptions.ClientSecret,
+			DirectAccessGrantsEnabled: true,
+			PublicClient:              true,
+			RedirectUris:              []string{"https://" + GatekeeperPrefix + codewind.Ingress + "/*"},
+		}},
+		Users: []keycloakUser{{
+			Username:    deployOptions.KeycloakDevUser,
+			Enabled:     true,
+			Credentials: []keycloakUserCredential{{Type: "password", Value: deployOptions.KeycloakDevPassword}},
+		}},
+	})
+	if err != nil {
+		return corev1.Secret{}, err
+	}
+	return generateSecrets(codewind, "secret-keycloak-realm", map[string]string{"realm.json": string(realm)}), nil
+}
+
+// importKeycloakRealm : mounts the realm secret into the Keycloak deployment and has Keycloak import it
+func importKeycloakRealm(deploy *appsv1.Deployment, realmSecret corev1.Secret) {
+	pod := &deploy.Spec.Template.Spec
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name:         "keycloak-realm",
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: realmSecret.Name}},
+	})
+	container := &pod.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "keycloak-realm", MountPath: keycloakRealmImportDir, ReadOnly: true})
+	container.Env = append(container.Env, corev1.EnvVar{Name: "KEYCLOAK_IMPORT", Value: keycloakRealmImportDir + "/realm.json"})
+}
+
 func createKeycloakDeploy(codewind Codewind) appsv1.Deployment {
 	labels := map[string]string{
 		"app":               KeycloakPrefix,
@@ -27,12 +27,12 @@ func DeployPerformance(clientset *kubernetes.Clientset, codewind Codewind, deplo
 
 	log.Infoln("Deploying Codewind Performance Dashboard...")
 	_, err := clientset.CoreV1().Services(deployOptions.Namespace).Create(&performanceService)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Performance service: %v\n", err)
 		return err
 	}
 	_, err = clientset.AppsV1().Deployments(deployOptions.Namespace).Create(&performanceDeploy)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Error: Unable to create Codewind Performance deployment: %v\n", err)
 		return err
 	}
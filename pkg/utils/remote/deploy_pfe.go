@@ -27,12 +27,12 @@ func DeployPFE(config *restclient.Config, clientset *kubernetes.Clientset, codew
 	deploy := createPFEDeploy(codewindInstance)
 	log.Infoln("Deploying Codewind Service")
 	_, err := clientset.CoreV1().Services(deployOptions.Namespace).Create(&service)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Unable to create Codewind service: %v\n", err)
 		return err
 	}
 	_, err = clientset.AppsV1().Deployments(deployOptions.Namespace).Create(&deploy)
-	if err != nil {
+	if ignoreAlreadyExists(err) != nil {
 		log.Errorf("Unable to create Codewind deployment: %v\n", err)
 		return err
 	}
@@ -0,0 +1,340 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/docker/compose"
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// DockerComposeProject is the docker-compose project a remote install on a Docker host runs in
+const DockerComposeProject = "codewind-remote"
+
+// The services of a remote install on a Docker host, which reach each other by these names
+const (
+	dockerServicePFE         = "codewind-pfe"
+	dockerServicePerformance = "codewind-performance"
+	dockerServiceKeycloak    = "codewind-keycloak"
+	dockerServiceGatekeeper  = "codewind-gatekeeper"
+)
+
+// dockerVolumeKeycloak : the volume Keycloak keeps its realms and users in
+const dockerVolumeKeycloak = "cw-keycloak"
+
+// Default host ports a remote install on a Docker host publishes Keycloak and the gatekeeper on
+const (
+	DefaultDockerGatekeeperPort = "9096"
+	DefaultDockerKeycloakPort   = "8443"
+)
+
+// keycloakContainerTLSPort : the port Keycloak serves HTTPS on in its container, with a self-signed certificate
+// it generates itself, as the gatekeeper does
+const keycloakContainerTLSPort = 8443
+
+// DockerOptions : Where a remote install on a Docker host, rather than Kubernetes, is published
+type DockerOptions struct {
+	// Host is the hostname or IP address clients reach the Docker host by
+	Host           string
+	GatekeeperPort string
+	KeycloakPort   string
+	// WorkspaceDir is the absolute path of the directory on the Docker host the projects are kept in, which PFE
+	// mounts into the project containers it starts
+	WorkspaceDir string
+}
+
+// GatekeeperURL : The URL clients connect to Codewind by, which the gatekeeper serves over HTTPS
+func (docker DockerOptions) GatekeeperURL() string {
+	return "https://" + net.JoinHostPort(docker.Host, docker.GatekeeperPort)
+}
+
+// KeycloakURL : The URL of Keycloak, which is served over HTTPS with a self-signed certificate like the gatekeeper
+func (docker DockerOptions) KeycloakURL() string {
+	return "https://" + net.JoinHostPort(docker.Host, docker.KeycloakPort)
+}
+
+// dockerComposeService : A service of the docker-compose file of a remote install on a Docker host
+type dockerComposeService struct {
+	Image       string   `yaml:"image"`
+	User        string   `yaml:"user,omitempty"`
+	Environment []string `yaml:"environment,omitempty"`
+	Ports       []string `yaml:"ports,omitempty"`
+	Volumes     []string `yaml:"volumes,omitempty"`
+	DependsOn   []string `yaml:"depends_on,omitempty"`
+	Restart     string   `yaml:"restart"`
+}
+
+// dockerComposeFile : The docker-compose file of a remote install on a Docker host
+type dockerComposeFile struct {
+	Version  string                          `yaml:"version"`
+	Services map[string]dockerComposeService `yaml:"services"`
+	Volumes  map[string]interface{}          `yaml:"volumes"`
+}
+
+// DeployDocker : Deploys Keycloak, the gatekeeper, PFE and the performance dashboard on the Docker host with
+// docker-compose. Keycloak is started first and set up with the realm, client and user of deployOptions, so that
+// the gatekeeper is started with the secret of the client
+func DeployDocker(deployOptions *DeployOptions, docker DockerOptions) (*DeploymentResult, *RemInstError) {
+	if docker.Host == "" {
+		err := errors.New(errNoDockerHost)
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	if !filepath.IsAbs(docker.WorkspaceDir) {
+		err := errors.New(errNoDockerWorkspace)
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	if docker.GatekeeperPort == "" {
+		docker.GatekeeperPort = DefaultDockerGatekeeperPort
+	}
+	if docker.KeycloakPort == "" {
+		docker.KeycloakPort = DefaultDockerKeycloakPort
+	}
+	images := deployOptions.Images.withDefaults()
+	composeFile := DockerComposeFilePath()
+
+	logr.Infoln("Starting Codewind Keycloak")
+	err := writeDockerComposeFile(composeFile, images, deployOptions, docker)
+	if err == nil {
+		err = dockerComposeUp(composeFile, dockerServiceKeycloak)
+	}
+	if err != nil {
+		return nil, &RemInstError{errOpDocker, err, err.Error()}
+	}
+
+	err = configureKeycloak(docker.KeycloakURL(), docker.GatekeeperURL(), deployOptions)
+	if err != nil {
+		return nil, &RemInstError{errOpStepFailed, err, err.Error()}
+	}
+
+	logr.Infoln("Starting Codewind PFE, performance dashboard and gatekeeper")
+	err = writeDockerComposeFile(composeFile, images, deployOptions, docker)
+	if err == nil {
+		err = dockerComposeUp(composeFile)
+	}
+	if err != nil {
+		return nil, &RemInstError{errOpDocker, err, err.Error()}
+	}
+	return &DeploymentResult{
+		GatekeeperURL: docker.GatekeeperURL(),
+		KeycloakURL:   docker.KeycloakURL(),
+		Images:        images,
+	}, nil
+}
+
+// DockerComposeFilePath : where the docker-compose file of a remote install on a Docker host is written, in the
+// cwctl state directory
+func DockerComposeFilePath() string {
+	return filepath.Join(cliconfig.GetStateDir(), DockerComposeProject+"-docker-compose.yaml")
+}
+
+// writeDockerComposeFile : writes the docker-compose file, which is only readable by the user as it holds the
+// Keycloak admin password and the secrets of the gatekeeper
+func writeDockerComposeFile(composeFile string, images Images, deployOptions *DeployOptions, docker DockerOptions) error {
+	content, err := renderDockerComposeFile(images, deployOptions, docker)
+	if err != nil {
+		return err
+	}
+	return compose.WriteFile(composeFile, content)
+}
+
+// renderDockerComposeFile : The docker-compose file of a remote install on a Docker host. Only Keycloak and the
+// gatekeeper are published, the gatekeeper reaches PFE through the service variables it would be given on
+// Kubernetes. The workspace is a directory of the Docker host rather than a volume, as PFE mounts the projects
+// into the containers it starts by their path on the host
+func renderDockerComposeFile(images Images, deployOptions *DeployOptions, docker DockerOptions) ([]byte, error) {
+	gatekeeperHost := docker.Host
+	if docker.GatekeeperPort != "443" {
+		gatekeeperHost += ":" + docker.GatekeeperPort
+	}
+	file := dockerComposeFile{
+		Version: "2",
+		Services: map[string]dockerComposeService{
+			dockerServiceKeycloak: {
+				Image: images.Keycloak,
+				Environment: environment(map[string]string{
+					"KEYCLOAK_USER":            deployOptions.KeycloakUser,
+					"KEYCLOAK_PASSWORD":        deployOptions.KeycloakPassword,
+					"PROXY_ADDRESS_FORWARDING": "true",
+				}),
+				Ports:   []string{docker.KeycloakPort + ":" + strconv.Itoa(keycloakContainerTLSPort)},
+				Volumes: []string{dockerVolumeKeycloak + ":/opt/jboss/keycloak/standalone/data"},
+				Restart: "unless-stopped",
+			},
+			dockerServicePFE: {
+				Image: images.PFE,
+				User:  "root",
+				Environment: environment(map[string]string{
+					"HOST_OS":                       "linux",
+					"HOST_WORKSPACE_DIRECTORY":      docker.WorkspaceDir,
+					"CONTAINER_WORKSPACE_DIRECTORY": "/codewind-workspace",
+					"PORTAL_HTTPS":                  "true",
+					"CODEWIND_PERFORMANCE_SERVICE":  dockerServicePerformance,
+				}),
+				Volumes:   []string{"/var/run/docker.sock:/var/run/docker.sock", docker.WorkspaceDir + ":/codewind-workspace"},
+				DependsOn: []string{dockerServicePerformance},
+				Restart:   "unless-stopped",
+			},
+			dockerServicePerformance: {
+				Image:       images.Performance,
+				Environment: environment(map[string]string{"PORTAL_HTTPS": "false"}),
+				Restart:     "unless-stopped",
+			},
+			dockerServiceGatekeeper: {
+				Image: images.Gatekeeper,
+				Environment: environment(map[string]string{
+					"AUTH_URL":                  docker.KeycloakURL(),
+					"CLIENT_ID":                 deployOptions.KeycloakClient,
+					"CLIENT_SECRET":             deployOptions.ClientSecret,
+					"ENABLE_AUTH":               "1",
+					"GATEKEEPER_HOST":           gatekeeperHost,
+					"REALM":                     deployOptions.KeycloakRealm,
+					"SESSION_SECRET":            deployOptions.CodewindSessionSecret,
+					"PORTAL_HTTPS":              "true",
+					"WORKSPACE_SERVICE":         "CODEWIND_PFE",
+					"CODEWIND_PFE_SERVICE_HOST": dockerServicePFE,
+					"CODEWIND_PFE_SERVICE_PORT": strconv.Itoa(PFEContainerPort),
+				}),
+				Ports:     []string{docker.GatekeeperPort + ":" + strconv.Itoa(GatekeeperContainerPort)},
+				DependsOn: []string{dockerServicePFE, dockerServiceKeycloak},
+				Restart:   "unless-stopped",
+			},
+		},
+		Volumes: map[string]interface{}{dockerVolumeKeycloak: nil},
+	}
+	return yaml.Marshal(&file)
+}
+
+// environment : the NAME=value entries of the variables, sorted by name
+func environment(variables map[string]string) []string {
+	entries := []string{}
+	for name, value := range variables {
+		entries = append(entries, name+"="+value)
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+// dockerComposeUp : creates the services of the docker-compose file, or all of them when none are given
+func dockerComposeUp(composeFile string, services ...string) error {
+	args := append([]string{"-p", DockerComposeProject, "-f", composeFile, "up", "-d"}, services...)
+	output, err := exec.Command("docker-compose", args...).CombinedOutput()
+	logr.Debugln(string(output))
+	if err != nil {
+		return fmt.Errorf("Unable to run docker-compose, is it installed? %v: %v", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// DockerResources : the services and volumes of the remote install on the Docker host the connection URL points
+// to, which RemoveDocker removes, with its Keycloak volume only when deleteVolumes is set
+func DockerResources(connectionURL string, deleteVolumes bool) ([]string, *RemInstError) {
+	file, remErr := findDockerInstall(connectionURL)
+	if remErr != nil {
+		return nil, remErr
+	}
+	resources := []string{}
+	for name := range file.Services {
+		resources = append(resources, "service/"+name)
+	}
+	if deleteVolumes {
+		for name := range file.Volumes {
+			resources = append(resources, "volume/"+DockerComposeProject+"_"+name)
+		}
+	}
+	sort.Strings(resources)
+	return resources, nil
+}
+
+// RemoveDocker : Removes the containers and network of the remote install on the Docker host the connection URL
+// points to, and its docker-compose file, with the Keycloak volume only when deleteVolumes is set. The workspace
+// directory on the Docker host is kept
+func RemoveDocker(connectionURL string, deleteVolumes bool) *RemInstError {
+	_, remErr := findDockerInstall(connectionURL)
+	if remErr != nil {
+		return remErr
+	}
+	composeFile := DockerComposeFilePath()
+	args := []string{"-p", DockerComposeProject, "-f", composeFile, "down"}
+	if deleteVolumes {
+		args = append(args, "--volumes")
+	}
+	output, err := exec.Command("docker-compose", args...).CombinedOutput()
+	logr.Debugln(string(output))
+	if err != nil {
+		err = fmt.Errorf("Unable to run docker-compose, is it installed? %v: %v", err, strings.TrimSpace(string(output)))
+		return &RemInstError{errOpDocker, err, err.Error()}
+	}
+	err = os.Remove(composeFile)
+	if err != nil && !os.IsNotExist(err) {
+		return &RemInstError{errOpRemove, err, err.Error()}
+	}
+	return nil
+}
+
+// findDockerInstall : the docker-compose file of the remote install on the Docker host, once it is checked that
+// its gatekeeper is the one the connection URL points to
+func findDockerInstall(connectionURL string) (*dockerComposeFile, *RemInstError) {
+	content, err := ioutil.ReadFile(DockerComposeFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = errors.New(errNoDockerInstall)
+			return nil, &RemInstError{errOpNotFound, err, err.Error()}
+		}
+		return nil, &RemInstError{errOpDocker, err, err.Error()}
+	}
+	file := dockerComposeFile{}
+	err = yaml.Unmarshal(content, &file)
+	if err != nil {
+		return nil, &RemInstError{errOpDocker, err, err.Error()}
+	}
+	gatekeeperHost := ""
+	for _, variable := range file.Services[dockerServiceGatekeeper].Environment {
+		if strings.HasPrefix(variable, "GATEKEEPER_HOST=") {
+			gatekeeperHost = strings.TrimPrefix(variable, "GATEKEEPER_HOST=")
+		}
+	}
+	if gatekeeperHost == "" || !sameHTTPSHost(gatekeeperHost, connectionURL) {
+		err = fmt.Errorf("%v: %v", errTargetNotFound, connectionURL)
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	return &file, nil
+}
+
+// sameHTTPSHost : whether the host of an HTTPS URL is host, which omits the port when it is 443
+func sameHTTPSHost(host string, rawURL string) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	port := parsedURL.Port()
+	if port == "" {
+		port = "443"
+	}
+	hostname, hostPort, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, hostPort = host, "443"
+	}
+	return strings.EqualFold(hostname, parsedURL.Hostname()) && hostPort == port
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderDockerComposeFile(t *testing.T) {
+	docker := DockerOptions{Host: "codewind.example.com", GatekeeperPort: "9096", KeycloakPort: "8443", WorkspaceDir: "/srv/codewind-workspace"}
+	images := Images{PFE: "pfe:1", Performance: "performance:1", Keycloak: "keycloak:1", Gatekeeper: "gatekeeper:1"}
+	deployOptions := &DeployOptions{
+		KeycloakUser:          "admin",
+		KeycloakPassword:      "adminpass",
+		KeycloakRealm:         "codewind",
+		KeycloakClient:        "codewind-docker",
+		CodewindSessionSecret: "session",
+		ClientSecret:          "client",
+	}
+
+	assert.Equal(t, "https://codewind.example.com:9096", docker.GatekeeperURL())
+	assert.Equal(t, "https://codewind.example.com:8443", docker.KeycloakURL())
+	ipv6 := DockerOptions{Host: "fd00::1", GatekeeperPort: "9096", KeycloakPort: "8443"}
+	assert.Equal(t, "https://[fd00::1]:9096", ipv6.GatekeeperURL())
+	assert.Equal(t, "https://[fd00::1]:8443", ipv6.KeycloakURL())
+
+	content, err := renderDockerComposeFile(images, deployOptions, docker)
+	assert.Nil(t, err)
+	file := dockerComposeFile{}
+	assert.Nil(t, yaml.Unmarshal(content, &file))
+
+	t.Run("Publishes only Keycloak and the gatekeeper", func(t *testing.T) {
+		assert.Equal(t, []string{"8443:8443"}, file.Services[dockerServiceKeycloak].Ports)
+		assert.Equal(t, []string{"9096:9096"}, file.Services[dockerServiceGatekeeper].Ports)
+		assert.Empty(t, file.Services[dockerServicePFE].Ports)
+		assert.Empty(t, file.Services[dockerServicePerformance].Ports)
+	})
+
+	t.Run("Points the gatekeeper at Keycloak and PFE", func(t *testing.T) {
+		gatekeeper := file.Services[dockerServiceGatekeeper]
+		assert.Equal(t, "gatekeeper:1", gatekeeper.Image)
+		assert.Contains(t, gatekeeper.Environment, "AUTH_URL=https://codewind.example.com:8443")
+		assert.Contains(t, gatekeeper.Environment, "GATEKEEPER_HOST=codewind.example.com:9096")
+		assert.Contains(t, gatekeeper.Environment, "CLIENT_SECRET=client")
+		assert.Contains(t, gatekeeper.Environment, "CODEWIND_PFE_SERVICE_HOST=codewind-pfe")
+		assert.Contains(t, gatekeeper.Environment, "CODEWIND_PFE_SERVICE_PORT=9191")
+		assert.Contains(t, file.Services[dockerServiceKeycloak].Environment, "KEYCLOAK_USER=admin")
+	})
+
+	t.Run("Keeps the workspace in a host directory and Keycloak data in a volume", func(t *testing.T) {
+		pfe := file.Services[dockerServicePFE]
+		assert.Contains(t, pfe.Volumes, "/srv/codewind-workspace:/codewind-workspace")
+		assert.Contains(t, pfe.Environment, "HOST_WORKSPACE_DIRECTORY=/srv/codewind-workspace")
+		assert.NotContains(t, file.Volumes, "cw-workspace")
+		assert.Contains(t, file.Volumes, "cw-keycloak")
+	})
+}
+
+func TestSameHTTPSHost(t *testing.T) {
+	assert.True(t, sameHTTPSHost("codewind.example.com:9096", "https://Codewind.example.com:9096"))
+	assert.True(t, sameHTTPSHost("codewind.example.com", "https://codewind.example.com/"))
+	assert.False(t, sameHTTPSHost("codewind.example.com:9096", "https://codewind.example.com"))
+	assert.False(t, sameHTTPSHost("codewind.example.com:9096", "https://other.example.com:9096"))
+}
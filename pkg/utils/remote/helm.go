@@ -0,0 +1,292 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	logr "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultHelmReleaseName : the name of the Helm release of a remote install unless another is given
+const DefaultHelmReleaseName = "codewind"
+
+// helmChartVersion : the version of the chart a remote install is packaged as, which is built by cwctl rather than
+// published
+const helmChartVersion = "0.1.0"
+
+// The values a Helm release of Codewind keeps, so that upgrading it keeps its workspace and secrets
+const (
+	helmValueWorkspaceID   = "workspaceID"
+	helmValueIngressDomain = "ingressDomain"
+	helmValueSessionSecret = "sessionSecret"
+	helmValueClientSecret  = "clientSecret"
+)
+
+// DeployRemoteWithHelm : InstallRemote as the Helm release releaseName, which is installed the first time and
+// upgraded after, so it can be listed, rolled back and uninstalled with helm. The workspace and secrets of the
+// release are kept across upgrades. A new release is installed without the gatekeeper, which is added by upgrading
+// it once Keycloak is set up and the secret of its client is known
+func DeployRemoteWithHelm(releaseName string, remoteDeployOptions *DeployOptions) (*DeploymentResult, *RemInstError) {
+	config, err := kube.GetKubeClientConfig().ClientConfig()
+	if err != nil {
+		logr.Infof("Unable to retrieve Kubernetes Config %v\n", err)
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	logKubeContext()
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		logr.Infof("Unable to retrieve Kubernetes clientset %v\n", err)
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+
+	namespace := remoteDeployOptions.Namespace
+	if namespace == "" {
+		namespace = kube.GetCurrentNamespace()
+		remoteDeployOptions.Namespace = namespace
+	}
+	logr.Infof("Using namespace : %v\n", namespace)
+
+	onOpenShift := kube.DetectOpenShift(config)
+	logr.Infof("Running on openshift: %t\n", onOpenShift)
+
+	if !remoteDeployOptions.SkipPreflight {
+		remInstErr := runPreflightCheck(clientset, namespace, remoteDeployOptions.IngressDomain, onOpenShift, false)
+		if remInstErr != nil {
+			return nil, remInstErr
+		}
+	}
+
+	previous, err := getHelmValues(namespace, releaseName)
+	if err != nil {
+		return nil, &RemInstError{errOpHelm, err, err.Error()}
+	}
+	values := newHelmValues(previous, remoteDeployOptions)
+	if previous != nil {
+		logr.Infof("Upgrading Helm release %v of workspace %v\n", releaseName, values[helmValueWorkspaceID])
+	}
+
+	ingressDomain := values[helmValueIngressDomain].(string)
+	if ingressDomain == "" && !onOpenShift {
+		ingressDomain = discoverIngressDomain(clientset)
+		values[helmValueIngressDomain] = ingressDomain
+	}
+	if ingressDomain == "" {
+		remoteInstError := errors.New(errNoIngressService)
+		return nil, &RemInstError{errOpNoIngress, remoteInstError, remoteInstError.Error()}
+	}
+	logr.Infof("Using ingress domain: %v\n", ingressDomain)
+
+	images := remoteDeployOptions.Images.withDefaults()
+	codewindInstance := newCodewindInstance(namespace, values[helmValueWorkspaceID].(string), ingressDomain, images, uuid.NewUUID(), onOpenShift)
+	remoteDeployOptions.CodewindSessionSecret = values[helmValueSessionSecret].(string)
+	remoteDeployOptions.ClientSecret = values[helmValueClientSecret].(string)
+
+	if remoteDeployOptions.ClientSecret == "" {
+		err = releaseHelmChart(releaseName, codewindInstance, remoteDeployOptions, values, false)
+		if err != nil {
+			return nil, &RemInstError{errOpHelm, err, err.Error()}
+		}
+		err = SetupKeycloak(codewindInstance, remoteDeployOptions)
+		if err != nil {
+			logr.Errorf("Keycloak could not be set up, rerun with --use-helm to try again\n")
+			return nil, &RemInstError{errOpStepFailed, err, err.Error()}
+		}
+		values[helmValueClientSecret] = remoteDeployOptions.ClientSecret
+	}
+	err = releaseHelmChart(releaseName, codewindInstance, remoteDeployOptions, values, true)
+	if err != nil {
+		return nil, &RemInstError{errOpHelm, err, err.Error()}
+	}
+	return newDeploymentResult(codewindInstance, remoteDeployOptions, images), nil
+}
+
+// UninstallHelmRelease : Uninstalls the Helm release releaseName the install was deployed as with
+// DeployRemoteWithHelm, refusing a release of another workspace. The persistent volume claims holding its
+// projects are only deleted when deleteVolumes is set
+func (install *RemoteInstall) UninstallHelmRelease(releaseName string, deleteVolumes bool) *RemInstError {
+	previous, err := getHelmValues(install.Namespace, releaseName)
+	if err != nil {
+		return &RemInstError{errOpHelm, err, err.Error()}
+	}
+	if previous == nil {
+		err = errors.New("No Helm release " + releaseName + " was found in namespace " + install.Namespace + ", use --release to give its name")
+		return &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	if workspaceID, _ := previous[helmValueWorkspaceID].(string); workspaceID != install.WorkspaceID {
+		err = errors.New("Helm release " + releaseName + " is not of the Codewind workspace " + install.WorkspaceID)
+		return &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	claims := []string{}
+	if deleteVolumes {
+		claims, err = RemoteVolumeClaims(install.clientset, install.Namespace, install.WorkspaceID)
+		if err != nil {
+			return &RemInstError{errOpRemove, err, err.Error()}
+		}
+	}
+	logr.Infof("Uninstalling Helm release %v from namespace %v\n", releaseName, install.Namespace)
+	_, err = runHelm(install.Namespace, "uninstall", releaseName)
+	if err != nil {
+		return &RemInstError{errOpHelm, err, err.Error()}
+	}
+	if deleteVolumes {
+		_, err = RemoveRemoteVolumes(install.clientset, install.Namespace, claims)
+		if err != nil {
+			return &RemInstError{errOpRemove, err, err.Error()}
+		}
+	}
+	return nil
+}
+
+// errHelmReleaseNotFound : what helm answers with when a release does not exist
+const errHelmReleaseNotFound = "release: not found"
+
+// runHelm : Runs the helm binary with args for releases in namespace, with the kubeconfig and context of the rest
+// of the install. Helm reads its storage driver from HELM_DRIVER itself. The output is returned, and helm's error
+// output is returned as the error when it fails
+func runHelm(namespace string, args ...string) ([]byte, error) {
+	helmArgs := append(append([]string{}, args...), "--namespace", namespace)
+	if kubeconfig := kube.Kubeconfig(); kubeconfig != "" {
+		helmArgs = append(helmArgs, "--kubeconfig", kubeconfig)
+	}
+	if context, err := kube.GetCurrentContext(); err == nil && context != "" {
+		helmArgs = append(helmArgs, "--kube-context", context)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("helm", helmArgs...)
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	logr.Debugf("helm %v: %v\n", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	if _, ok := err.(*exec.Error); ok {
+		return nil, fmt.Errorf("Unable to run helm, is Helm 3 installed? %v", err)
+	}
+	if err != nil {
+		return nil, errors.New(strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// getHelmValues : the values of the release releaseName in namespace, nil when there is no such release
+func getHelmValues(namespace string, releaseName string) (map[string]interface{}, error) {
+	output, err := runHelm(namespace, "get", "values", releaseName, "--output", "json")
+	if err != nil && strings.Contains(err.Error(), errHelmReleaseNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	err = json.Unmarshal(output, &values)
+	if err != nil {
+		return nil, errors.New("Unable to read the values of Helm release " + releaseName + ": " + err.Error())
+	}
+	return values, nil
+}
+
+// newHelmValues : the values of a release, those of the previous release of it when there is one so that the
+// workspace and secrets are kept, and new ones otherwise. The ingress domain given replaces the previous one
+func newHelmValues(previous map[string]interface{}, remoteDeployOptions *DeployOptions) map[string]interface{} {
+	values := map[string]interface{}{
+		helmValueWorkspaceID:   strings.ToLower(strconv.FormatInt(utils.CreateTimestamp(), 36)),
+		helmValueIngressDomain: remoteDeployOptions.IngressDomain,
+		helmValueSessionSecret: remoteDeployOptions.CodewindSessionSecret,
+		helmValueClientSecret:  "",
+	}
+	if previous == nil {
+		return values
+	}
+	for _, key := range []string{helmValueWorkspaceID, helmValueIngressDomain, helmValueSessionSecret, helmValueClientSecret} {
+		if value, ok := previous[key].(string); ok && value != "" {
+			if key == helmValueIngressDomain && remoteDeployOptions.IngressDomain != "" {
+				continue
+			}
+			values[key] = value
+		}
+	}
+	return values
+}
+
+// releaseHelmChart : Installs the chart of codewind as the release releaseName, or upgrades the release to it when
+// it is installed already. The chart and values are written to a temporary directory for helm, which is only
+// readable by the user as the values and secrets hold the session and client secrets
+func releaseHelmChart(releaseName string, codewind Codewind, deployOptions *DeployOptions, values map[string]interface{}, withGatekeeper bool) error {
+	chartFiles, err := newHelmChart(codewind, deployOptions, withGatekeeper)
+	if err != nil {
+		return err
+	}
+	valuesFile, err := yaml.Marshal(values)
+	if err != nil {
+		return err
+	}
+	chartFiles["values.yaml"] = valuesFile
+	dir, err := ioutil.TempDir("", "codewind-chart")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for name, body := range chartFiles {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		err = os.MkdirAll(filepath.Dir(file), 0700)
+		if err != nil {
+			return err
+		}
+		err = ioutil.WriteFile(file, body, 0600)
+		if err != nil {
+			return err
+		}
+	}
+	logr.Infof("Installing or upgrading Helm release %v\n", releaseName)
+	_, err = runHelm(codewind.Namespace, "upgrade", releaseName, dir, "--install")
+	return err
+}
+
+// newHelmChart : The files of a chart, by their path in it, whose templates are the manifests of the install of
+// codewind without its namespace, which Helm would otherwise delete with the release
+func newHelmChart(codewind Codewind, deployOptions *DeployOptions, withGatekeeper bool) (map[string][]byte, error) {
+	manifests, err := renderManifests(codewind, deployOptions, withGatekeeper)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := yaml.Marshal(map[string]string{
+		"apiVersion":  "v2",
+		"name":        "codewind",
+		"version":     helmChartVersion,
+		"description": "Eclipse Codewind with Keycloak and the gatekeeper, built by cwctl install remote --use-helm",
+		"type":        "application",
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{"Chart.yaml": metadata}
+	for i, manifest := range manifests {
+		body, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			return nil, err
+		}
+		files["templates/"+manifestFileName(i, manifest)] = body
+	}
+	return files, nil
+}
@@ -0,0 +1,75 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewHelmValues(t *testing.T) {
+	deployOptions := &DeployOptions{CodewindSessionSecret: "new-session"}
+
+	t.Run("A new release gets a new workspace", func(t *testing.T) {
+		values := newHelmValues(nil, deployOptions)
+		assert.NotEqual(t, "", values[helmValueWorkspaceID])
+		assert.Equal(t, "new-session", values[helmValueSessionSecret])
+		assert.Equal(t, "", values[helmValueClientSecret])
+	})
+
+	t.Run("An upgrade keeps the workspace and secrets of the release", func(t *testing.T) {
+		previous := map[string]interface{}{
+			helmValueWorkspaceID:   "k1a2b3",
+			helmValueIngressDomain: "10.0.0.1.nip.io",
+			helmValueSessionSecret: "session",
+			helmValueClientSecret:  "client",
+		}
+		values := newHelmValues(previous, deployOptions)
+		assert.Equal(t, "k1a2b3", values[helmValueWorkspaceID])
+		assert.Equal(t, "10.0.0.1.nip.io", values[helmValueIngressDomain])
+		assert.Equal(t, "session", values[helmValueSessionSecret])
+		assert.Equal(t, "client", values[helmValueClientSecret])
+
+		values = newHelmValues(previous, &DeployOptions{IngressDomain: "10.0.0.2.nip.io"})
+		assert.Equal(t, "10.0.0.2.nip.io", values[helmValueIngressDomain])
+	})
+}
+
+func Test_NewHelmChart(t *testing.T) {
+	codewind := newCodewindInstance("codewind", "k1a2b3", "10.0.0.1.nip.io", Images{}.withDefaults(), "uid", false)
+
+	t.Run("Leaves out the namespace and, until Keycloak is set up, the gatekeeper", func(t *testing.T) {
+		chartFiles, err := newHelmChart(codewind, &DeployOptions{}, false)
+		assert.Nil(t, err)
+		assert.Contains(t, string(chartFiles["Chart.yaml"]), "name: codewind")
+		for name := range chartFiles {
+			if name == "Chart.yaml" {
+				continue
+			}
+			assert.True(t, strings.HasPrefix(name, "templates/"))
+			assert.NotContains(t, name, "namespace")
+			assert.NotContains(t, name, GatekeeperPrefix)
+		}
+	})
+
+	t.Run("Adds the gatekeeper", func(t *testing.T) {
+		chartFiles, err := newHelmChart(codewind, &DeployOptions{ClientSecret: "client"}, true)
+		assert.Nil(t, err)
+		names := []string{}
+		for name := range chartFiles {
+			names = append(names, name)
+		}
+		assert.Contains(t, strings.Join(names, ","), "deployment-"+GatekeeperPrefix+"-k1a2b3")
+	})
+}
@@ -23,13 +23,32 @@ type RemInstError struct {
 }
 
 const (
-	errOpNotFound  = "rem_not_found"
-	errOpNoIngress = "rem_no_ingress"
+	errOpNotFound     = "rem_not_found"
+	errOpNoIngress    = "rem_no_ingress"
+	errOpProgress     = "rem_progress"
+	errOpStepFailed   = "rem_step_failed"
+	errOpRemove       = "rem_remove_failed"
+	errOpLogs         = "rem_logs"
+	errOpDocker       = "rem_docker_failed"
+	errOpPreflight    = "rem_preflight"
+	errOpExport       = "rem_export"
+	errOpHelm         = "rem_helm"
+	errOpNotOpenShift = "rem_not_openshift"
+	errOpOAuth        = "rem_oauth"
 )
 
 const (
-	errTargetNotFound   = "Target deployment not found"
-	errNoIngressService = "Please check you have installed ingress-nginx into your Kubernetes environment or use the --ingress flag to set the domain"
+	errTargetNotFound    = "Target deployment not found"
+	errNoIngressService  = "Please check you have installed ingress-nginx into your Kubernetes environment or use the --ingress flag to set the domain"
+	errNoInstallProgress = "No failed install was found to resume in this namespace"
+	errNoDockerHost      = "The host clients reach the Docker host by is required"
+	errNoDockerWorkspace = "The workspace directory on the Docker host must be an absolute path"
+	errNoDockerInstall   = "No remote install on a Docker host was found, it is installed with install remote --docker"
+	errPreflightFailed   = "The cluster failed the checks run before installing, fix these problems or rerun with --skip-preflight"
+	errNoExportIngress   = "The ingress domain Codewind is to be exposed under is required to export its manifests, set it with --ingress"
+	errNoExportUser      = "The Keycloak admin and developer users and passwords are required to export the manifests, set them with --kadminuser, --kadminpass, --kdevuser and --kdevpass"
+	errBadWorkspaceID    = "The workspace ID must be 1 to 16 lower case letters and digits"
+	errNotOpenShift      = "OpenShift OAuth can only be used when installing into an OpenShift cluster"
 )
 
 // RemInstError : Error formatted in JSON containing an errorOp and a description from
@@ -13,6 +13,7 @@ package kube
 
 import (
 	"os"
+	"path/filepath"
 
 	log "github.com/sirupsen/logrus"
 
@@ -21,16 +22,60 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-// GetKubeClientConfig retrieves the Kubernetes client config from the cluster
+// kubeconfigPath and kubeContext are the kubeconfig file and the context in it that Kubernetes operations use.
+// When empty, the files in KUBECONFIG, or else ~/.kube/config, and their current context are used as kubectl does
+var kubeconfigPath, kubeContext string
+
+// SelectContext sets the kubeconfig file and context Kubernetes operations use, either of which may be empty to
+// keep the one already selected. The kubeconfig file is kept as an absolute path, so it can be recorded
+func SelectContext(kubeconfig string, context string) {
+	if kubeconfig != "" {
+		if absPath, err := filepath.Abs(kubeconfig); err == nil {
+			kubeconfig = absPath
+		}
+		kubeconfigPath = kubeconfig
+	}
+	if context != "" {
+		kubeContext = context
+	}
+}
+
+// SelectDefaultContext sets the kubeconfig file and context Kubernetes operations use when neither was selected
+// with SelectContext, such as those recorded for a connection, which give way to --kubeconfig and --context
+func SelectDefaultContext(kubeconfig string, context string) {
+	if kubeconfigPath == "" && kubeContext == "" {
+		SelectContext(kubeconfig, context)
+	}
+}
+
+// Kubeconfig is the kubeconfig file selected with SelectContext, empty when the default files are used
+func Kubeconfig() string {
+	return kubeconfigPath
+}
+
+// GetKubeClientConfig retrieves the Kubernetes client config of the selected kubeconfig and context
 func GetKubeClientConfig() clientcmd.ClientConfig {
-	// Retrieve the Kube client config
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigPath
 	clientconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		clientcmd.NewDefaultClientConfigLoadingRules(),
-		&clientcmd.ConfigOverrides{},
+		loadingRules,
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
 	)
 	return clientconfig
 }
 
+// GetCurrentContext gets the name of the context Kubernetes operations use
+func GetCurrentContext() (string, error) {
+	if kubeContext != "" {
+		return kubeContext, nil
+	}
+	rawConfig, err := GetKubeClientConfig().RawConfig()
+	if err != nil {
+		return "", err
+	}
+	return rawConfig.CurrentContext, nil
+}
+
 // GetCurrentNamespace gets the current namespace in the Kubernetes context
 func GetCurrentNamespace() string {
 	// Instantiate loader for kubeconfig file.
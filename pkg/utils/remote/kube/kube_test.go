@@ -0,0 +1,99 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package kube
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com:6443
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    namespace: codewind-dev
+- name: prod
+  context:
+    cluster: prod-cluster
+    namespace: codewind-prod
+`
+
+func Test_SelectContext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kubeconfig")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	kubeconfig := filepath.Join(dir, "config")
+	assert.Nil(t, ioutil.WriteFile(kubeconfig, []byte(testKubeconfig), 0600))
+
+	originalPath, originalContext := kubeconfigPath, kubeContext
+	defer func() { kubeconfigPath, kubeContext = originalPath, originalContext }()
+	kubeconfigPath, kubeContext = "", ""
+
+	t.Run("The current context of the selected kubeconfig is used", func(t *testing.T) {
+		SelectContext(kubeconfig, "")
+		assert.Equal(t, kubeconfig, Kubeconfig())
+		context, err := GetCurrentContext()
+		assert.Nil(t, err)
+		assert.Equal(t, "dev", context)
+		config, err := GetKubeClientConfig().ClientConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, "https://dev.example.com:6443", config.Host)
+	})
+
+	t.Run("A selected context is used in place of the current context", func(t *testing.T) {
+		SelectContext("", "prod")
+		assert.Equal(t, kubeconfig, Kubeconfig())
+		context, err := GetCurrentContext()
+		assert.Nil(t, err)
+		assert.Equal(t, "prod", context)
+		config, err := GetKubeClientConfig().ClientConfig()
+		assert.Nil(t, err)
+		assert.Equal(t, "https://prod.example.com:6443", config.Host)
+		namespace, _, err := GetKubeClientConfig().Namespace()
+		assert.Nil(t, err)
+		assert.Equal(t, "codewind-prod", namespace)
+	})
+
+	t.Run("A default context gives way to the one selected", func(t *testing.T) {
+		SelectDefaultContext("", "dev")
+		context, err := GetCurrentContext()
+		assert.Nil(t, err)
+		assert.Equal(t, "prod", context)
+
+		kubeconfigPath, kubeContext = "", ""
+		SelectDefaultContext(kubeconfig, "prod")
+		assert.Equal(t, kubeconfig, Kubeconfig())
+		context, err = GetCurrentContext()
+		assert.Nil(t, err)
+		assert.Equal(t, "prod", context)
+	})
+
+	t.Run("A context missing from the kubeconfig is an error", func(t *testing.T) {
+		SelectContext("", "staging")
+		_, err := GetKubeClientConfig().ClientConfig()
+		assert.NotNil(t, err)
+	})
+}
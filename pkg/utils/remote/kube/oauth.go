@@ -0,0 +1,47 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package kube
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// oauthServerMetadataPath is where the API server of an OpenShift cluster serves the metadata of its OAuth server
+const oauthServerMetadataPath = "/.well-known/oauth-authorization-server"
+
+// DiscoverOAuthServer gets the URL of the OAuth server of an OpenShift cluster, which gatekeepers log users in with
+func DiscoverOAuthServer(clientset kubernetes.Interface) (string, error) {
+	body, err := clientset.Discovery().RESTClient().Get().AbsPath(oauthServerMetadataPath).DoRaw()
+	if err != nil {
+		return "", errors.New("Unable to discover the OAuth server of the cluster: " + err.Error())
+	}
+	return parseOAuthServerMetadata(body)
+}
+
+// parseOAuthServerMetadata gets the issuer of the OAuth server metadata of a cluster, which is the URL of the server
+func parseOAuthServerMetadata(body []byte) (string, error) {
+	metadata := struct {
+		Issuer string `json:"issuer"`
+	}{}
+	err := json.Unmarshal(body, &metadata)
+	if err == nil && metadata.Issuer == "" {
+		err = errors.New("it has no issuer")
+	}
+	if err != nil {
+		return "", errors.New("Unable to read the OAuth server metadata of the cluster: " + err.Error())
+	}
+	return strings.TrimRight(metadata.Issuer, "/"), nil
+}
@@ -0,0 +1,31 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseOAuthServerMetadata(t *testing.T) {
+	t.Run("The issuer is the URL of the OAuth server", func(t *testing.T) {
+		issuer, err := parseOAuthServerMetadata([]byte(`{"issuer":"https://oauth-openshift.apps.example.com/","authorization_endpoint":"https://oauth-openshift.apps.example.com/oauth/authorize"}`))
+		assert.Nil(t, err)
+		assert.Equal(t, "https://oauth-openshift.apps.example.com", issuer)
+	})
+
+	t.Run("Metadata without an issuer is an error", func(t *testing.T) {
+		_, err := parseOAuthServerMetadata([]byte(`{}`))
+		assert.Contains(t, err.Error(), "no issuer")
+	})
+}
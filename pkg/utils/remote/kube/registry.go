@@ -0,0 +1,101 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package kube
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// RegistrySecretLabel marks the docker-registry secrets created by cwctl
+const RegistrySecretLabel = "codewind-registry-secret"
+
+var invalidSecretNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// RegistrySecretName returns the name of the docker-registry secret for a registry address
+func RegistrySecretName(address string) string {
+	name := invalidSecretNameChars.ReplaceAllString(strings.ToLower(address), "-")
+	return "codewind-registry-" + strings.Trim(name, "-")
+}
+
+// CreateRegistrySecret creates or replaces a docker-registry secret for the registry, and adds it to the
+// image pull secrets of the namespace's default service account so project pods can pull from the registry
+func CreateRegistrySecret(clientset kubernetes.Interface, namespace string, address string, username string, password string) error {
+	auth := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	dockerConfig, _ := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			address: map[string]string{"username": username, "password": password, "auth": auth},
+		},
+	})
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   RegistrySecretName(address),
+			Labels: map[string]string{RegistrySecretLabel: "true"},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+	}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&secret)
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(namespace).Update(&secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	serviceAccount, err := clientset.CoreV1().ServiceAccounts(namespace).Get("default", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for _, pullSecret := range serviceAccount.ImagePullSecrets {
+		if pullSecret.Name == secret.Name {
+			return nil
+		}
+	}
+	serviceAccount.ImagePullSecrets = append(serviceAccount.ImagePullSecrets, corev1.LocalObjectReference{Name: secret.Name})
+	_, err = clientset.CoreV1().ServiceAccounts(namespace).Update(serviceAccount)
+	return err
+}
+
+// DeleteRegistrySecret removes the docker-registry secret for the registry from the namespace and its default service account
+func DeleteRegistrySecret(clientset kubernetes.Interface, namespace string, address string) error {
+	name := RegistrySecretName(address)
+	serviceAccount, err := clientset.CoreV1().ServiceAccounts(namespace).Get("default", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	pullSecrets := []corev1.LocalObjectReference{}
+	for _, pullSecret := range serviceAccount.ImagePullSecrets {
+		if pullSecret.Name != name {
+			pullSecrets = append(pullSecrets, pullSecret)
+		}
+	}
+	if len(pullSecrets) != len(serviceAccount.ImagePullSecrets) {
+		serviceAccount.ImagePullSecrets = pullSecrets
+		_, err = clientset.CoreV1().ServiceAccounts(namespace).Update(serviceAccount)
+		if err != nil {
+			return err
+		}
+	}
+	err = clientset.CoreV1().Secrets(namespace).Delete(name, &metav1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package kube
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_RegistrySecrets(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "codewind"}})
+	name := RegistrySecretName("mirror.example.com:5000")
+
+	t.Run("Secret names only use valid characters", func(t *testing.T) {
+		assert.Equal(t, "codewind-registry-mirror-example-com-5000", name)
+	})
+
+	t.Run("Creates the secret and adds it to the default service account once", func(t *testing.T) {
+		err := CreateRegistrySecret(clientset, "codewind", "mirror.example.com:5000", "dev", "secret")
+		assert.Nil(t, err)
+		err = CreateRegistrySecret(clientset, "codewind", "mirror.example.com:5000", "dev", "newsecret")
+		assert.Nil(t, err)
+
+		secret, err := clientset.CoreV1().Secrets("codewind").Get(name, metav1.GetOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, corev1.SecretTypeDockerConfigJson, secret.Type)
+		assert.Contains(t, string(secret.Data[corev1.DockerConfigJsonKey]), "newsecret")
+		serviceAccount, _ := clientset.CoreV1().ServiceAccounts("codewind").Get("default", metav1.GetOptions{})
+		assert.Equal(t, []corev1.LocalObjectReference{{Name: name}}, serviceAccount.ImagePullSecrets)
+	})
+
+	t.Run("Deletes the secret and removes it from the default service account", func(t *testing.T) {
+		err := DeleteRegistrySecret(clientset, "codewind", "mirror.example.com:5000")
+		assert.Nil(t, err)
+		_, err = clientset.CoreV1().Secrets("codewind").Get(name, metav1.GetOptions{})
+		assert.NotNil(t, err)
+		serviceAccount, _ := clientset.CoreV1().ServiceAccounts("codewind").Get("default", metav1.GetOptions{})
+		assert.Empty(t, serviceAccount.ImagePullSecrets)
+	})
+}
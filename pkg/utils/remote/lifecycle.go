@@ -0,0 +1,468 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	routev1 "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
+	logr "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+// workspaceLabel is set on the resources of a Codewind install to the ID of its workspace
+const workspaceLabel = "codewindWorkspace"
+
+// Images : the container images of a Codewind install
+type Images struct {
+	PFE         string `json:"pfe,omitempty"`
+	Performance string `json:"performance,omitempty"`
+	Keycloak    string `json:"keycloak,omitempty"`
+	Gatekeeper  string `json:"gatekeeper,omitempty"`
+}
+
+// withDefaults : the images, using those from GetImages for any which are not set
+func (images Images) withDefaults() Images {
+	pfeImage, performanceImage, keycloakImage, gatekeeperImage := GetImages()
+	if images.PFE == "" {
+		images.PFE = pfeImage
+	}
+	if images.Performance == "" {
+		images.Performance = performanceImage
+	}
+	if images.Keycloak == "" {
+		images.Keycloak = keycloakImage
+	}
+	if images.Gatekeeper == "" {
+		images.Gatekeeper = gatekeeperImage
+	}
+	return images
+}
+
+// byContainer : the images by the name of the container they run in
+func (images Images) byContainer() map[string]string {
+	return map[string]string{
+		PFEPrefix:         images.PFE,
+		PerformancePrefix: images.Performance,
+		KeycloakPrefix:    images.Keycloak,
+		GatekeeperPrefix:  images.Gatekeeper,
+	}
+}
+
+// UpgradeRemote : Changes the deployments of a Codewind install to run the given images, any images which
+// are not set use the defaults from GetImages. Kubernetes then rolls out each changed deployment
+func UpgradeRemote(clientset kubernetes.Interface, namespace string, workspaceID string, images Images) (Images, error) {
+	images = images.withDefaults()
+	byContainer := images.byContainer()
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(metav1.ListOptions{LabelSelector: workspaceLabel + "=" + workspaceID})
+	if err != nil {
+		return images, err
+	}
+	for _, deployment := range deployments.Items {
+		changed := false
+		for i, container := range deployment.Spec.Template.Spec.Containers {
+			image, ok := byContainer[container.Name]
+			if ok && container.Image != image {
+				logr.Infof("Upgrading %v from %v to %v\n", deployment.Name, container.Image, image)
+				deployment.Spec.Template.Spec.Containers[i].Image = image
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		_, err = clientset.AppsV1().Deployments(namespace).Update(&deployment)
+		if err != nil {
+			return images, err
+		}
+	}
+	return images, nil
+}
+
+// RemoveRemote : Deletes the deployments, services, secrets and ingresses or routes of a Codewind install, its
+// OpenShift OAuth client if it has one, and any progress saved by a failed install. Resources which are already
+// gone are ignored
+func RemoveRemote(config *restclient.Config, clientset kubernetes.Interface, namespace string, workspaceID string, onOpenShift bool) error {
+	listOptions := metav1.ListOptions{LabelSelector: workspaceLabel + "=" + workspaceID}
+	deleteOptions := &metav1.DeleteOptions{}
+
+	logr.Infof("Removing Codewind workspace %v from namespace %v\n", workspaceID, namespace)
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, deployment := range deployments.Items {
+		err = ignoreNotFound(clientset.AppsV1().Deployments(namespace).Delete(deployment.Name, deleteOptions))
+		if err != nil {
+			return err
+		}
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, service := range services.Items {
+		err = ignoreNotFound(clientset.CoreV1().Services(namespace).Delete(service.Name, deleteOptions))
+		if err != nil {
+			return err
+		}
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(listOptions)
+	if err != nil {
+		return err
+	}
+	for _, secret := range secrets.Items {
+		err = ignoreNotFound(clientset.CoreV1().Secrets(namespace).Delete(secret.Name, deleteOptions))
+		if err != nil {
+			return err
+		}
+	}
+
+	if onOpenShift {
+		routev1client, err := routev1.NewForConfig(config)
+		if err != nil {
+			return err
+		}
+		routes, err := routev1client.Routes(namespace).List(listOptions)
+		if err != nil {
+			return err
+		}
+		for _, route := range routes.Items {
+			err = ignoreNotFound(routev1client.Routes(namespace).Delete(route.Name, deleteOptions))
+			if err != nil {
+				return err
+			}
+		}
+		err = removeOAuthClients(config, workspaceID)
+		if err != nil {
+			return err
+		}
+	} else {
+		ingresses, err := clientset.ExtensionsV1beta1().Ingresses(namespace).List(listOptions)
+		if err != nil {
+			return err
+		}
+		for _, ingress := range ingresses.Items {
+			err = ignoreNotFound(clientset.ExtensionsV1beta1().Ingresses(namespace).Delete(ingress.Name, deleteOptions))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return DeleteInstallProgress(clientset, namespace)
+}
+
+// ignoreNotFound : lets removal carry on when a resource has already been deleted
+func ignoreNotFound(err error) error {
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// RemoteInstall : A Codewind install found in the cluster of the current Kubernetes context
+type RemoteInstall struct {
+	Namespace   string
+	WorkspaceID string
+
+	config      *restclient.Config
+	clientset   kubernetes.Interface
+	onOpenShift bool
+}
+
+// FindRemoteInstall : Finds the Codewind install a connection URL points to, from the host of the gatekeeper
+// ingress or route in namespace. The namespace of the current Kubernetes context is used when namespace is empty
+func FindRemoteInstall(connectionURL string, namespace string) (*RemoteInstall, *RemInstError) {
+	kubeConfig := kube.GetKubeClientConfig()
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	if namespace == "" {
+		namespace, _, err = kubeConfig.Namespace()
+		if err != nil {
+			return nil, &RemInstError{errOpNotFound, err, err.Error()}
+		}
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	install := RemoteInstall{Namespace: namespace, config: config, clientset: clientset, onOpenShift: kube.DetectOpenShift(config)}
+
+	parsedURL, err := url.Parse(connectionURL)
+	if err != nil || parsedURL.Hostname() == "" {
+		err = errors.New("The connection URL '" + connectionURL + "' has no host")
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	install.WorkspaceID, err = findWorkspace(config, clientset, namespace, parsedURL.Hostname(), install.onOpenShift)
+	if err != nil {
+		return nil, &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	return &install, nil
+}
+
+// findWorkspace : the workspace ID of the Codewind install in namespace whose ingress or route serves host
+func findWorkspace(config *restclient.Config, clientset kubernetes.Interface, namespace string, host string, onOpenShift bool) (string, error) {
+	listOptions := metav1.ListOptions{LabelSelector: workspaceLabel}
+	if onOpenShift {
+		routev1client, err := routev1.NewForConfig(config)
+		if err != nil {
+			return "", err
+		}
+		routes, err := routev1client.Routes(namespace).List(listOptions)
+		if err != nil {
+			return "", err
+		}
+		for _, route := range routes.Items {
+			if strings.EqualFold(route.Spec.Host, host) {
+				return route.Labels[workspaceLabel], nil
+			}
+		}
+	} else {
+		ingresses, err := clientset.ExtensionsV1beta1().Ingresses(namespace).List(listOptions)
+		if err != nil {
+			return "", err
+		}
+		for _, ingress := range ingresses.Items {
+			for _, rule := range ingress.Spec.Rules {
+				if strings.EqualFold(rule.Host, host) {
+					return ingress.Labels[workspaceLabel], nil
+				}
+			}
+		}
+	}
+	return "", errors.New("No Codewind install serving " + host + " was found in namespace " + namespace + ", use --namespace to search another namespace")
+}
+
+// Stop : Scales the deployments of the install down to no replicas, keeping its other resources
+func (install *RemoteInstall) Stop() ([]string, *RemInstError) {
+	stopped, err := StopRemote(install.clientset, install.Namespace, install.WorkspaceID)
+	if err != nil {
+		return stopped, &RemInstError{errOpRemove, err, err.Error()}
+	}
+	return stopped, nil
+}
+
+// Remove : Deletes the resources of the install, including Keycloak when it was installed with it. The persistent
+// volume claims holding its projects are only deleted when deleteVolumes is set
+func (install *RemoteInstall) Remove(deleteVolumes bool) *RemInstError {
+	claims := []string{}
+	if deleteVolumes {
+		var err error
+		claims, err = RemoteVolumeClaims(install.clientset, install.Namespace, install.WorkspaceID)
+		if err != nil {
+			return &RemInstError{errOpRemove, err, err.Error()}
+		}
+	}
+	err := RemoveRemote(install.config, install.clientset, install.Namespace, install.WorkspaceID, install.onOpenShift)
+	if err != nil {
+		return &RemInstError{errOpRemove, err, err.Error()}
+	}
+	if deleteVolumes {
+		_, err = RemoveRemoteVolumes(install.clientset, install.Namespace, claims)
+		if err != nil {
+			return &RemInstError{errOpRemove, err, err.Error()}
+		}
+	}
+	return nil
+}
+
+// Resources : The resources Remove deletes, as kind/name, including the persistent volume claims when
+// deleteVolumes is set
+func (install *RemoteInstall) Resources(deleteVolumes bool) ([]string, *RemInstError) {
+	var routes routev1.RouteInterface
+	if install.onOpenShift {
+		routev1client, err := routev1.NewForConfig(install.config)
+		if err != nil {
+			return nil, &RemInstError{errOpRemove, err, err.Error()}
+		}
+		routes = routev1client.Routes(install.Namespace)
+	}
+	resources, err := ListRemoteResources(install.clientset, routes, install.Namespace, install.WorkspaceID, deleteVolumes)
+	if err != nil {
+		return resources, &RemInstError{errOpRemove, err, err.Error()}
+	}
+	return resources, nil
+}
+
+// ListRemoteResources : Lists the resources of a Codewind install that RemoveRemote deletes, as kind/name, for
+// a dry run. Routes are listed when routes is set, on OpenShift, and ingresses otherwise. The persistent volume
+// claims are included when volumes is set
+func ListRemoteResources(clientset kubernetes.Interface, routes routev1.RouteInterface, namespace string, workspaceID string, volumes bool) ([]string, error) {
+	listOptions := metav1.ListOptions{LabelSelector: workspaceLabel + "=" + workspaceID}
+	resources := []string{}
+
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(listOptions)
+	if err != nil {
+		return resources, err
+	}
+	for _, deployment := range deployments.Items {
+		resources = append(resources, "deployment/"+deployment.Name)
+	}
+
+	services, err := clientset.CoreV1().Services(namespace).List(listOptions)
+	if err != nil {
+		return resources, err
+	}
+	for _, service := range services.Items {
+		resources = append(resources, "service/"+service.Name)
+	}
+
+	secrets, err := clientset.CoreV1().Secrets(namespace).List(listOptions)
+	if err != nil {
+		return resources, err
+	}
+	for _, secret := range secrets.Items {
+		resources = append(resources, "secret/"+secret.Name)
+	}
+
+	if routes != nil {
+		routeList, err := routes.List(listOptions)
+		if err != nil {
+			return resources, err
+		}
+		for _, route := range routeList.Items {
+			resources = append(resources, "route/"+route.Name)
+		}
+	} else {
+		ingresses, err := clientset.ExtensionsV1beta1().Ingresses(namespace).List(listOptions)
+		if err != nil {
+			return resources, err
+		}
+		for _, ingress := range ingresses.Items {
+			resources = append(resources, "ingress/"+ingress.Name)
+		}
+	}
+
+	// The progress of a failed install is kept in a config map and secret of their own
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Get(InstallProgressName, metav1.GetOptions{})
+	if err == nil {
+		resources = append(resources, "configmap/"+InstallProgressName)
+	} else if !k8serrors.IsNotFound(err) {
+		return resources, err
+	}
+	_, err = clientset.CoreV1().Secrets(namespace).Get(InstallProgressName, metav1.GetOptions{})
+	if err == nil {
+		resources = append(resources, "secret/"+InstallProgressName)
+	} else if !k8serrors.IsNotFound(err) {
+		return resources, err
+	}
+
+	if volumes {
+		claims, err := RemoteVolumeClaims(clientset, namespace, workspaceID)
+		if err != nil {
+			return resources, err
+		}
+		for _, claim := range claims {
+			resources = append(resources, "persistentvolumeclaim/"+claim)
+		}
+	}
+	return resources, nil
+}
+
+// StopRemote : Scales the deployments of a Codewind install down to no replicas, returning their names. Scaling
+// them back up restarts Codewind with its projects and configuration as they were
+func StopRemote(clientset kubernetes.Interface, namespace string, workspaceID string) ([]string, error) {
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(metav1.ListOptions{LabelSelector: workspaceLabel + "=" + workspaceID})
+	if err != nil {
+		return nil, err
+	}
+	stopped := []string{}
+	replicas := int32(0)
+	for _, deployment := range deployments.Items {
+		if deployment.Spec.Replicas == nil || *deployment.Spec.Replicas != replicas {
+			logr.Infof("Scaling down %v\n", deployment.Name)
+			deployment.Spec.Replicas = &replicas
+			_, err = clientset.AppsV1().Deployments(namespace).Update(&deployment)
+			if err != nil {
+				return stopped, err
+			}
+		}
+		stopped = append(stopped, deployment.Name)
+	}
+	return stopped, nil
+}
+
+// RemoteVolumeClaims : The persistent volume claims holding the projects of a Codewind install: those labelled with
+// its workspace, and those its deployments mount or name to PFE in PVC_NAME, as PFE deploys projects onto that
+// claim. A claim the deployments of another workspace use too is shared, so it is left out. The deployments are
+// read, so the claims must be found before the install is removed
+func RemoteVolumeClaims(clientset kubernetes.Interface, namespace string, workspaceID string) ([]string, error) {
+	claims, err := clientset.CoreV1().PersistentVolumeClaims(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := clientset.AppsV1().Deployments(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	used, shared := map[string]bool{}, map[string]bool{}
+	for _, deployment := range deployments.Items {
+		isInstall := deployment.Labels[workspaceLabel] == workspaceID
+		for _, claim := range deploymentClaims(deployment) {
+			if isInstall {
+				used[claim] = true
+			} else {
+				shared[claim] = true
+			}
+		}
+	}
+	names := []string{}
+	for _, claim := range claims.Items {
+		if (claim.Labels[workspaceLabel] == workspaceID || used[claim.Name]) && !shared[claim.Name] {
+			names = append(names, claim.Name)
+		}
+	}
+	return names, nil
+}
+
+// deploymentClaims : The persistent volume claims a deployment mounts, and the one it names in PVC_NAME
+func deploymentClaims(deployment appsv1.Deployment) []string {
+	claims := []string{}
+	pod := deployment.Spec.Template.Spec
+	for _, volume := range pod.Volumes {
+		if volume.PersistentVolumeClaim != nil {
+			claims = append(claims, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	for _, container := range pod.Containers {
+		for _, env := range container.Env {
+			if env.Name == "PVC_NAME" && env.Value != "" {
+				claims = append(claims, env.Value)
+			}
+		}
+	}
+	return claims
+}
+
+// RemoveRemoteVolumes : Deletes the persistent volume claims found by RemoteVolumeClaims, returning their names
+func RemoveRemoteVolumes(clientset kubernetes.Interface, namespace string, claims []string) ([]string, error) {
+	removed := []string{}
+	for _, claim := range claims {
+		err := ignoreNotFound(clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(claim, &metav1.DeleteOptions{}))
+		if err != nil {
+			return removed, err
+		}
+		removed = append(removed, claim)
+	}
+	return removed, nil
+}
@@ -0,0 +1,125 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_FindWorkspace(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&extensionsv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: GatekeeperPrefix + "-k1a2b3", Namespace: "codewind", Labels: map[string]string{workspaceLabel: "k1a2b3"}},
+		Spec:       extensionsv1.IngressSpec{Rules: []extensionsv1.IngressRule{{Host: GatekeeperPrefix + "10.0.0.1.nip.io"}}},
+	})
+
+	t.Run("Finds the workspace from the host of its gatekeeper ingress", func(t *testing.T) {
+		workspaceID, err := findWorkspace(nil, clientset, "codewind", GatekeeperPrefix+"10.0.0.1.nip.io", false)
+		assert.Nil(t, err)
+		assert.Equal(t, "k1a2b3", workspaceID)
+	})
+	t.Run("Reports hosts which no install serves", func(t *testing.T) {
+		_, err := findWorkspace(nil, clientset, "other", GatekeeperPrefix+"10.0.0.1.nip.io", false)
+		assert.NotNil(t, err)
+	})
+}
+
+func Test_StopRemote(t *testing.T) {
+	replicas := int32(1)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: PFEPrefix + "-k1a2b3", Namespace: "codewind", Labels: map[string]string{workspaceLabel: "k1a2b3"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+	})
+
+	stopped, err := StopRemote(clientset, "codewind", "k1a2b3")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{PFEPrefix + "-k1a2b3"}, stopped)
+	deployment, _ := clientset.AppsV1().Deployments("codewind").Get(PFEPrefix+"-k1a2b3", metav1.GetOptions{})
+	assert.Equal(t, int32(0), *deployment.Spec.Replicas)
+}
+
+func pfeDeployment(workspaceID string, claim string) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: PFEPrefix + "-" + workspaceID, Namespace: "codewind", Labels: map[string]string{workspaceLabel: workspaceID}}}
+	deployment.Spec.Template.Spec.Containers = []corev1.Container{{Name: PFEPrefix, Env: []corev1.EnvVar{{Name: "PVC_NAME", Value: claim}}}}
+	return deployment
+}
+
+func Test_RemoveRemoteVolumes(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "codewind-workspace-k1a2b3", Namespace: "codewind", Labels: map[string]string{workspaceLabel: "k1a2b3"}}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "codewind-workspace-other", Namespace: "codewind", Labels: map[string]string{workspaceLabel: "other"}}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "projects-k1a2b3", Namespace: "codewind"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "codewind", Namespace: "codewind"}},
+		pfeDeployment("k1a2b3", "projects-k1a2b3"),
+	)
+
+	t.Run("Finds the labelled claims and the claim PFE deploys projects onto", func(t *testing.T) {
+		claims, err := RemoteVolumeClaims(clientset, "codewind", "k1a2b3")
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []string{"codewind-workspace-k1a2b3", "projects-k1a2b3"}, claims)
+
+		removed, err := RemoveRemoteVolumes(clientset, "codewind", claims)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, claims, removed)
+		remaining, _ := clientset.CoreV1().PersistentVolumeClaims("codewind").List(metav1.ListOptions{})
+		assert.Len(t, remaining.Items, 2)
+	})
+
+	t.Run("Leaves out a claim shared with another workspace", func(t *testing.T) {
+		shared := fake.NewSimpleClientset(
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "codewind", Namespace: "codewind"}},
+			pfeDeployment("k1a2b3", "codewind"),
+			pfeDeployment("other", "codewind"),
+		)
+		claims, err := RemoteVolumeClaims(shared, "codewind", "k1a2b3")
+		assert.Nil(t, err)
+		assert.Empty(t, claims)
+	})
+}
+
+func Test_ListRemoteResources(t *testing.T) {
+	labels := map[string]string{workspaceLabel: "k1a2b3"}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: PFEPrefix + "-k1a2b3", Namespace: "codewind", Labels: labels}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: PFEPrefix + "-k1a2b3", Namespace: "codewind", Labels: labels}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: GatekeeperPrefix + "-session-k1a2b3", Namespace: "codewind", Labels: labels}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: InstallProgressName, Namespace: "codewind"}},
+		&extensionsv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: GatekeeperPrefix + "-k1a2b3", Namespace: "codewind", Labels: labels}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "codewind-workspace-k1a2b3", Namespace: "codewind", Labels: labels}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: PFEPrefix + "-other", Namespace: "codewind", Labels: map[string]string{workspaceLabel: "other"}}},
+	)
+
+	t.Run("Lists what remove deletes, without deleting it", func(t *testing.T) {
+		resources, err := ListRemoteResources(clientset, nil, "codewind", "k1a2b3", false)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			"deployment/" + PFEPrefix + "-k1a2b3",
+			"service/" + PFEPrefix + "-k1a2b3",
+			"secret/" + GatekeeperPrefix + "-session-k1a2b3",
+			"ingress/" + GatekeeperPrefix + "-k1a2b3",
+			"secret/" + InstallProgressName,
+		}, resources)
+		deployments, _ := clientset.AppsV1().Deployments("codewind").List(metav1.ListOptions{})
+		assert.Len(t, deployments.Items, 2)
+	})
+	t.Run("Includes the persistent volume claims when they are deleted too", func(t *testing.T) {
+		resources, err := ListRemoteResources(clientset, nil, "codewind", "k1a2b3", true)
+		assert.Nil(t, err)
+		assert.Contains(t, resources, "persistentvolumeclaim/codewind-workspace-k1a2b3")
+	})
+}
@@ -0,0 +1,77 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"io"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logComponentApps : the app label of the pods of each component whose logs can be shown
+var logComponentApps = map[string]string{
+	utils.LogComponentPFE:         PFEPrefix,
+	utils.LogComponentPerformance: PerformancePrefix,
+	utils.LogComponentGatekeeper:  GatekeeperPrefix,
+}
+
+// Logs : Writes the logs of the pods of the install to out
+func (install *RemoteInstall) Logs(options utils.LogOptions, out io.Writer) *RemInstError {
+	streams, err := RemoteLogStreams(install.clientset, install.Namespace, install.WorkspaceID, options)
+	if err != nil {
+		return &RemInstError{errOpLogs, err, err.Error()}
+	}
+	err = utils.CopyLogs(streams, options.Follow, out)
+	if err != nil {
+		return &RemInstError{errOpLogs, err, err.Error()}
+	}
+	return nil
+}
+
+// RemoteLogStreams : The logs of the pods of the components of a Codewind install. Pods which are still pending
+// have no log yet and are left out
+func RemoteLogStreams(clientset kubernetes.Interface, namespace string, workspaceID string, options utils.LogOptions) ([]utils.LogStream, error) {
+	podLogOptions := corev1.PodLogOptions{Follow: options.Follow}
+	if options.Since > 0 {
+		sinceSeconds := int64(options.Since / time.Second)
+		podLogOptions.SinceSeconds = &sinceSeconds
+	}
+	streams := []utils.LogStream{}
+	for _, component := range utils.LogComponents {
+		if !options.Includes(component) {
+			continue
+		}
+		pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{
+			LabelSelector: workspaceLabel + "=" + workspaceID + ",app=" + logComponentApps[component],
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodPending {
+				continue
+			}
+			name := pod.Name
+			streams = append(streams, utils.LogStream{
+				Name: name,
+				Open: func() (io.ReadCloser, error) {
+					return clientset.CoreV1().Pods(namespace).GetLogs(name, &podLogOptions).Stream()
+				},
+			})
+		}
+	}
+	return streams, nil
+}
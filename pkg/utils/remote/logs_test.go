@@ -0,0 +1,55 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_RemoteLogStreams(t *testing.T) {
+	pod := func(name string, app string, workspaceID string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "codewind", Labels: map[string]string{"app": app, workspaceLabel: workspaceID}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		pod("codewind-pfe-k1a2b3-1", PFEPrefix, "k1a2b3", corev1.PodRunning),
+		pod("codewind-performance-k1a2b3-1", PerformancePrefix, "k1a2b3", corev1.PodFailed),
+		pod("codewind-gatekeeper-k1a2b3-1", GatekeeperPrefix, "k1a2b3", corev1.PodPending),
+		pod("codewind-pfe-k4d5e6-1", PFEPrefix, "k4d5e6", corev1.PodRunning),
+	)
+	names := func(streams []utils.LogStream) []string {
+		result := []string{}
+		for _, stream := range streams {
+			result = append(result, stream.Name)
+		}
+		return result
+	}
+
+	t.Run("Shows the pods of the workspace which have started", func(t *testing.T) {
+		streams, err := RemoteLogStreams(clientset, "codewind", "k1a2b3", utils.LogOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"codewind-pfe-k1a2b3-1", "codewind-performance-k1a2b3-1"}, names(streams))
+	})
+	t.Run("Shows only the components given", func(t *testing.T) {
+		streams, err := RemoteLogStreams(clientset, "codewind", "k1a2b3", utils.LogOptions{Components: []string{utils.LogComponentPerformance}})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"codewind-performance-k1a2b3-1"}, names(streams))
+	})
+}
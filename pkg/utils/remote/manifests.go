@@ -0,0 +1,361 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest : A Kubernetes object of a remote install, and the kind and name its file is named after
+type Manifest struct {
+	Kind   string
+	Name   string
+	Object interface{}
+}
+
+// ExportOptions : How the manifests of a remote install are rendered. WorkspaceID is generated when empty, and the
+// TLS certificates, client secret and session secret of Previous, the secrets of an earlier export by name, are
+// kept, so that exporting the same workspace again only changes what its options change
+type ExportOptions struct {
+	WorkspaceID string
+	OnOpenShift bool
+	Previous    map[string]corev1.Secret
+}
+
+// workspaceIDPattern : the workspace IDs which can be given, as they are part of the names of Kubernetes objects
+var workspaceIDPattern = regexp.MustCompile(`^[a-z0-9]{1,16}$`)
+
+// IsValidWorkspaceID : true when id can be given as the workspace ID of an install
+func IsValidWorkspaceID(id string) bool {
+	return workspaceIDPattern.MatchString(id)
+}
+
+// RenderManifests : The Kubernetes objects a remote install of Codewind into deployOptions.Namespace, exposed under
+// deployOptions.IngressDomain, is made of, in the order they are applied: the namespace, the service account PFE
+// deploys projects with and its role, then the secrets, services, deployments and ingresses, or routes on
+// OpenShift, of Keycloak, PFE, the performance dashboard and the gatekeeper. Keycloak imports the realm, client and
+// developer user from a secret when it first starts, and the gatekeeper is given the secret of that client.
+// Nothing is read from or applied to a cluster
+func RenderManifests(deployOptions *DeployOptions, exportOptions ExportOptions) ([]Manifest, *RemInstError) {
+	if deployOptions.IngressDomain == "" {
+		err := errors.New(errNoExportIngress)
+		return nil, &RemInstError{errOpExport, err, err.Error()}
+	}
+	for _, value := range []string{deployOptions.KeycloakUser, deployOptions.KeycloakPassword, deployOptions.KeycloakDevUser, deployOptions.KeycloakDevPassword} {
+		if value == "" {
+			err := errors.New(errNoExportUser)
+			return nil, &RemInstError{errOpExport, err, err.Error()}
+		}
+	}
+	workspaceID := exportOptions.WorkspaceID
+	if workspaceID == "" {
+		workspaceID = strings.ToLower(strconv.FormatInt(utils.CreateTimestamp(), 36))
+	} else if !IsValidWorkspaceID(workspaceID) {
+		err := errors.New(errBadWorkspaceID)
+		return nil, &RemInstError{errOpExport, err, err.Error()}
+	}
+	codewind := newCodewindInstance(deployOptions.Namespace, workspaceID, deployOptions.IngressDomain, deployOptions.Images.withDefaults(), uuid.NewUUID(), exportOptions.OnOpenShift)
+	if deployOptions.KeycloakRealm == "" {
+		deployOptions.KeycloakRealm = "codewind"
+	}
+	if deployOptions.KeycloakClient == "" {
+		deployOptions.KeycloakClient = "codewind-" + workspaceID
+	}
+
+	previous := func(name string, key string) string {
+		return exportOptions.Previous[name+"-"+workspaceID].StringData[key]
+	}
+	for _, secret := range []struct {
+		value *string
+		name  string
+		key   string
+	}{
+		{&deployOptions.ClientSecret, "secret-codewind-client", "client_secret"},
+		{&deployOptions.CodewindSessionSecret, "secret-codewind-session", "session_secret"},
+	} {
+		if *secret.value == "" {
+			*secret.value = previous(secret.name, secret.key)
+		}
+		if *secret.value == "" {
+			var err error
+			*secret.value, err = NewSecret()
+			if err != nil {
+				return nil, &RemInstError{errOpExport, err, err.Error()}
+			}
+		}
+	}
+
+	manifests, err := renderManifests(codewind, deployOptions, true)
+	if err != nil {
+		return nil, &RemInstError{errOpExport, err, err.Error()}
+	}
+	realmSecret, err := createKeycloakRealmSecret(codewind, deployOptions)
+	if err != nil {
+		return nil, &RemInstError{errOpExport, err, err.Error()}
+	}
+	rendered := []Manifest{}
+	for _, manifest := range manifests {
+		switch object := manifest.Object.(type) {
+		case *corev1.Secret:
+			// The certificates are kept along with their keys
+			if kept, ok := exportOptions.Previous[object.Name]; ok && kept.StringData["tls.key"] != "" {
+				object.StringData = kept.StringData
+			}
+		case *appsv1.Deployment:
+			if object.Labels["app"] == KeycloakPrefix {
+				importKeycloakRealm(object, realmSecret)
+				rendered = append(rendered, Manifest{realmSecret.Kind, realmSecret.Name, &realmSecret})
+			}
+		}
+		rendered = append(rendered, manifest)
+	}
+	namespace := createNamespace(codewind)
+	return append([]Manifest{{namespace.Kind, namespace.Name, &namespace}}, rendered...), nil
+}
+
+// ReadExportedSecrets : The secrets of the manifests exported to dir, by name, which is empty when nothing was
+// exported there
+func ReadExportedSecrets(dir string) (map[string]corev1.Secret, *RemInstError) {
+	secrets := map[string]corev1.Secret{}
+	files, err := filepath.Glob(filepath.Join(dir, "*-secret-*.yaml"))
+	if err != nil {
+		return nil, &RemInstError{errOpExport, err, err.Error()}
+	}
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, &RemInstError{errOpExport, err, err.Error()}
+		}
+		secret := corev1.Secret{}
+		err = yaml.Unmarshal(body, &secret)
+		if err != nil {
+			return nil, &RemInstError{errOpExport, err, fmt.Sprintf("Unable to read the exported secret %v: %v", file, err)}
+		}
+		if secret.Kind == "Secret" {
+			secrets[secret.Name] = secret
+		}
+	}
+	return secrets, nil
+}
+
+// renderManifests : The Kubernetes objects of the install of codewind after its namespace, leaving out those of the
+// gatekeeper unless withGatekeeper is set, as the gatekeeper needs the secret of the Keycloak client
+func renderManifests(codewind Codewind, deployOptions *DeployOptions, withGatekeeper bool) ([]Manifest, error) {
+	keycloakKey, keycloakCert, err := createCertificate(KeycloakPrefix+codewind.Ingress, "Codewind Keycloak")
+	if err != nil {
+		return nil, err
+	}
+	gatekeeperKey, gatekeeperCert, err := createCertificate(GatekeeperPrefix+codewind.Ingress, "Codewind Gatekeeper")
+	if err != nil {
+		return nil, err
+	}
+
+	keycloakSecrets := createKeycloakSecrets(codewind, deployOptions)
+	keycloakTLSSecret := createKeycloakTLSSecret(codewind, keycloakKey, keycloakCert)
+	keycloakService := createKeycloakService(codewind)
+	keycloakDeploy := createKeycloakDeploy(codewind)
+	pfeService := createPFEService(codewind)
+	pfeDeploy := createPFEDeploy(codewind)
+	performanceService := createPerformanceService(codewind)
+	performanceDeploy := createPerformanceDeploy(codewind)
+	gatekeeperSecrets := createGatekeeperSecrets(codewind, deployOptions)
+	gatekeeperSessionSecret := createGatekeeperSessionSecret(codewind, deployOptions)
+	gatekeeperTLSSecret := createGatekeeperTLSSecret(codewind, gatekeeperKey, gatekeeperCert)
+	gatekeeperService := createGatekeeperService(codewind)
+	gatekeeperDeploy := createGatekeeperDeploy(codewind, deployOptions)
+
+	serviceAccount := createServiceAccount(codewind)
+	role := createRole(codewind)
+	roleBinding := createRoleBinding(codewind)
+	manifests := []Manifest{
+		{serviceAccount.Kind, serviceAccount.Name, &serviceAccount},
+		{role.Kind, role.Name, &role},
+		{roleBinding.Kind, roleBinding.Name, &roleBinding},
+		{keycloakSecrets.Kind, keycloakSecrets.Name, &keycloakSecrets},
+		{keycloakTLSSecret.Kind, keycloakTLSSecret.Name, &keycloakTLSSecret},
+		{keycloakService.Kind, keycloakService.Name, &keycloakService},
+		{keycloakDeploy.Kind, keycloakDeploy.Name, &keycloakDeploy},
+	}
+	if codewind.OnOpenShift {
+		route := createKeycloakRoute(codewind)
+		route.Namespace = codewind.Namespace
+		manifests = append(manifests, Manifest{route.Kind, route.Name, &route})
+	} else {
+		ingress := createIngressKeycloak(codewind)
+		ingress.Namespace = codewind.Namespace
+		manifests = append(manifests, Manifest{ingress.Kind, ingress.Name, &ingress})
+	}
+	manifests = append(manifests,
+		Manifest{pfeService.Kind, pfeService.Name, &pfeService},
+		Manifest{pfeDeploy.Kind, pfeDeploy.Name, &pfeDeploy},
+		Manifest{performanceService.Kind, performanceService.Name, &performanceService},
+		Manifest{performanceDeploy.Kind, performanceDeploy.Name, &performanceDeploy},
+	)
+	if !withGatekeeper {
+		return manifests, nil
+	}
+	manifests = append(manifests,
+		Manifest{gatekeeperSecrets.Kind, gatekeeperSecrets.Name, &gatekeeperSecrets},
+		Manifest{gatekeeperSessionSecret.Kind, gatekeeperSessionSecret.Name, &gatekeeperSessionSecret},
+		Manifest{gatekeeperTLSSecret.Kind, gatekeeperTLSSecret.Name, &gatekeeperTLSSecret},
+		Manifest{gatekeeperService.Kind, gatekeeperService.Name, &gatekeeperService},
+		Manifest{gatekeeperDeploy.Kind, gatekeeperDeploy.Name, &gatekeeperDeploy},
+	)
+	if codewind.OnOpenShift {
+		route := createRouteGatekeeper(codewind)
+		route.Namespace = codewind.Namespace
+		manifests = append(manifests, Manifest{route.Kind, route.Name, &route})
+	} else {
+		ingress := createIngressGatekeeper(codewind)
+		ingress.Namespace = codewind.Namespace
+		manifests = append(manifests, Manifest{ingress.Kind, ingress.Name, &ingress})
+	}
+	return manifests, nil
+}
+
+// ExportManifests : Writes each manifest as YAML to its own file in dir, numbered in the order they are to be
+// applied, returning the paths written. Secrets are only readable by the user, as they hold the Keycloak admin
+// password and the private keys of the TLS certificates
+func ExportManifests(dir string, manifests []Manifest) ([]string, *RemInstError) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, &RemInstError{errOpExport, err, err.Error()}
+	}
+	files := []string{}
+	for i, manifest := range manifests {
+		body, err := yaml.Marshal(manifest.Object)
+		if err != nil {
+			return nil, &RemInstError{errOpExport, err, err.Error()}
+		}
+		file := filepath.Join(dir, manifestFileName(i, manifest))
+		mode := os.FileMode(0644)
+		if manifest.Kind == "Secret" {
+			mode = 0600
+		}
+		err = ioutil.WriteFile(file, body, mode)
+		if err != nil {
+			return nil, &RemInstError{errOpExport, err, err.Error()}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// manifestFileName : the name of the file of the i'th manifest, numbered so the files sort in the order to apply them
+func manifestFileName(i int, manifest Manifest) string {
+	return fmt.Sprintf("%02d-%v-%v.yaml", i, strings.ToLower(manifest.Kind), manifest.Name)
+}
+
+// createNamespace : the namespace Codewind is installed into
+func createNamespace(codewind Codewind) corev1.Namespace {
+	return corev1.Namespace{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Namespace",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: codewind.Namespace,
+		},
+	}
+}
+
+// createServiceAccount : the service account PFE names in SERVICE_ACCOUNT_NAME for the projects it deploys
+func createServiceAccount(codewind Codewind) corev1.ServiceAccount {
+	return corev1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ServiceAccount",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      codewind.ServiceAccountName,
+			Namespace: codewind.Namespace,
+		},
+	}
+}
+
+// createRole : the permissions PFE needs in its namespace to build, deploy and debug projects
+func createRole(codewind Codewind) rbacv1.Role {
+	exposeGroup, exposeResource := "extensions", "ingresses"
+	if codewind.OnOpenShift {
+		exposeGroup, exposeResource = "route.openshift.io", "routes"
+	}
+	return rbacv1.Role{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Role",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "codewind-" + codewind.WorkspaceID,
+			Namespace: codewind.Namespace,
+			Labels:    map[string]string{workspaceLabel: codewind.WorkspaceID},
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods", "pods/exec", "pods/log", "services", "secrets", "configmaps", "persistentvolumeclaims", "serviceaccounts"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+			{
+				APIGroups: []string{"apps"},
+				Resources: []string{"deployments", "replicasets"},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+			{
+				APIGroups: []string{exposeGroup},
+				Resources: []string{exposeResource},
+				Verbs:     []string{"get", "list", "watch", "create", "update", "patch", "delete"},
+			},
+		},
+	}
+}
+
+// createRoleBinding : binds the role of PFE to its service account
+func createRoleBinding(codewind Codewind) rbacv1.RoleBinding {
+	return rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "RoleBinding",
+			APIVersion: "rbac.authorization.k8s.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "codewind-" + codewind.WorkspaceID,
+			Namespace: codewind.Namespace,
+			Labels:    map[string]string{workspaceLabel: codewind.WorkspaceID},
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      "ServiceAccount",
+				Name:      codewind.ServiceAccountName,
+				Namespace: codewind.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "Role",
+			Name:     "codewind-" + codewind.WorkspaceID,
+		},
+	}
+}
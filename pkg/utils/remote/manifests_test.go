@@ -0,0 +1,168 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func testExportOptions() *DeployOptions {
+	return &DeployOptions{
+		Namespace:           "codewind",
+		IngressDomain:       "10.0.0.1.nip.io",
+		KeycloakUser:        "admin",
+		KeycloakPassword:    "adminpass",
+		KeycloakDevUser:     "developer",
+		KeycloakDevPassword: "devpass",
+	}
+}
+
+func manifestKinds(manifests []Manifest) []string {
+	kinds := []string{}
+	for _, manifest := range manifests {
+		kinds = append(kinds, manifest.Kind)
+	}
+	return kinds
+}
+
+func findSecret(manifests []Manifest, name string) *corev1.Secret {
+	for _, manifest := range manifests {
+		if secret, ok := manifest.Object.(*corev1.Secret); ok && secret.Name == name {
+			return secret
+		}
+	}
+	return nil
+}
+
+func Test_RenderManifests(t *testing.T) {
+	t.Run("Requires the ingress domain", func(t *testing.T) {
+		_, err := RenderManifests(&DeployOptions{Namespace: "codewind"}, ExportOptions{})
+		assert.Equal(t, errOpExport, err.Op)
+	})
+
+	t.Run("Requires the Keycloak users", func(t *testing.T) {
+		deployOptions := testExportOptions()
+		deployOptions.KeycloakDevPassword = ""
+		_, err := RenderManifests(deployOptions, ExportOptions{})
+		assert.Equal(t, errOpExport, err.Op)
+		assert.Equal(t, errNoExportUser, err.Desc)
+	})
+
+	t.Run("Renders the namespace and RBAC before the components", func(t *testing.T) {
+		manifests, err := RenderManifests(testExportOptions(), ExportOptions{})
+		assert.Nil(t, err)
+		kinds := manifestKinds(manifests)
+		assert.Equal(t, []string{"Namespace", "ServiceAccount", "Role", "RoleBinding"}, kinds[:4])
+		assert.Equal(t, 2, strings.Count(strings.Join(kinds, ","), "Ingress"))
+		assert.Equal(t, 4, strings.Count(strings.Join(kinds, ","), "Deployment"))
+		assert.Equal(t, "codewind", manifests[0].Name)
+	})
+
+	t.Run("Renders routes on OpenShift", func(t *testing.T) {
+		manifests, err := RenderManifests(testExportOptions(), ExportOptions{OnOpenShift: true})
+		assert.Nil(t, err)
+		kinds := manifestKinds(manifests)
+		assert.Equal(t, 2, strings.Count(strings.Join(kinds, ","), "Route"))
+		assert.Equal(t, 0, strings.Count(strings.Join(kinds, ","), "Ingress"))
+	})
+
+	t.Run("Keycloak imports the realm with the client secret the gatekeeper is given", func(t *testing.T) {
+		manifests, err := RenderManifests(testExportOptions(), ExportOptions{WorkspaceID: "k1a2b3"})
+		assert.Nil(t, err)
+		clientSecret := findSecret(manifests, "secret-codewind-client-k1a2b3").StringData["client_secret"]
+		assert.NotEmpty(t, clientSecret)
+
+		realm := keycloakRealm{}
+		assert.Nil(t, json.Unmarshal([]byte(findSecret(manifests, "secret-keycloak-realm-k1a2b3").StringData["realm.json"]), &realm))
+		assert.Equal(t, "codewind", realm.Realm)
+		assert.Equal(t, "codewind-k1a2b3", realm.Clients[0].ClientID)
+		assert.Equal(t, clientSecret, realm.Clients[0].Secret)
+		assert.Equal(t, []string{"https://codewind-gatekeeper-k1a2b3-10.0.0.1.nip.io/*"}, realm.Clients[0].RedirectUris)
+		assert.Equal(t, "developer", realm.Users[0].Username)
+		assert.Equal(t, "devpass", realm.Users[0].Credentials[0].Value)
+
+		for _, manifest := range manifests {
+			if deploy, ok := manifest.Object.(*appsv1.Deployment); ok && deploy.Labels["app"] == KeycloakPrefix {
+				assert.Contains(t, deploy.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "KEYCLOAK_IMPORT", Value: keycloakRealmImportDir + "/realm.json"})
+			}
+		}
+	})
+
+	t.Run("Exporting a workspace again keeps its certificates and secrets", func(t *testing.T) {
+		first, err := RenderManifests(testExportOptions(), ExportOptions{WorkspaceID: "k1a2b3"})
+		assert.Nil(t, err)
+		previous := map[string]corev1.Secret{}
+		for _, manifest := range first {
+			if secret, ok := manifest.Object.(*corev1.Secret); ok {
+				previous[secret.Name] = *secret
+			}
+		}
+		second, err := RenderManifests(testExportOptions(), ExportOptions{WorkspaceID: "k1a2b3", Previous: previous})
+		assert.Nil(t, err)
+		for _, name := range []string{"secret-codewind-client-k1a2b3", "secret-codewind-session-k1a2b3", "secret-codewind-tls-k1a2b3", "secret-keycloak-tls-k1a2b3"} {
+			assert.Equal(t, previous[name].StringData, findSecret(second, name).StringData, name)
+		}
+	})
+
+	t.Run("Rejects an invalid workspace ID", func(t *testing.T) {
+		_, err := RenderManifests(testExportOptions(), ExportOptions{WorkspaceID: "Not-Valid"})
+		assert.Equal(t, errBadWorkspaceID, err.Desc)
+	})
+}
+
+func Test_ExportManifests(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cw-manifests")
+	defer os.RemoveAll(dir)
+	namespace := createNamespace(Codewind{Namespace: "codewind"})
+	secret := generateSecrets(Codewind{Namespace: "codewind", WorkspaceID: "k1a2b3"}, "secret-codewind-session", map[string]string{"session_secret": "s"})
+
+	files, err := ExportManifests(filepath.Join(dir, "manifests"), []Manifest{
+		{namespace.Kind, namespace.Name, &namespace},
+		{secret.Kind, secret.Name, &secret},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "manifests", "00-namespace-codewind.yaml"),
+		filepath.Join(dir, "manifests", "01-secret-secret-codewind-session-k1a2b3.yaml"),
+	}, files)
+
+	body, _ := ioutil.ReadFile(files[0])
+	assert.Contains(t, string(body), "kind: Namespace")
+	assert.Contains(t, string(body), "name: codewind")
+	info, _ := os.Stat(files[1])
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func Test_ReadExportedSecrets(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cw-manifests")
+	defer os.RemoveAll(dir)
+
+	secrets, err := ReadExportedSecrets(filepath.Join(dir, "missing"))
+	assert.Nil(t, err)
+	assert.Empty(t, secrets)
+
+	secret := generateSecrets(Codewind{Namespace: "codewind", WorkspaceID: "k1a2b3"}, "secret-codewind-session", map[string]string{"session_secret": "s"})
+	_, err = ExportManifests(dir, []Manifest{{secret.Kind, secret.Name, &secret}})
+	assert.Nil(t, err)
+	secrets, err = ReadExportedSecrets(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, "s", secrets["secret-codewind-session-k1a2b3"].StringData["session_secret"])
+}
@@ -0,0 +1,95 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	oauthv1client "github.com/openshift/client-go/oauth/clientset/versioned/typed/oauth/v1"
+	logr "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	restclient "k8s.io/client-go/rest"
+)
+
+// oauthClientName : the name of the OAuth client of the gatekeeper of a workspace. OAuth clients are not
+// namespaced, so the name is unique to the workspace
+func oauthClientName(workspaceID string) string {
+	return "codewind-" + workspaceID
+}
+
+// DeployOAuthClient : Creates the OpenShift OAuth client the gatekeeper logs users in with, in place of a Keycloak
+// client. Its secret is generated and kept in deployOptions.ClientSecret for the gatekeeper, unless a resumed
+// install already has one
+func DeployOAuthClient(config *restclient.Config, codewind Codewind, deployOptions *DeployOptions) error {
+	if deployOptions.ClientSecret == "" {
+		secret, err := NewSecret()
+		if err != nil {
+			return err
+		}
+		deployOptions.ClientSecret = secret
+	}
+
+	oauthClient := createOAuthClient(codewind, deployOptions)
+	client, err := oauthv1client.NewForConfig(config)
+	if err != nil {
+		logr.Errorf("Error retrieving OAuth client for OpenShift: %v\n", err)
+		return err
+	}
+	logr.Infof("Creating OpenShift OAuth client %v\n", oauthClient.Name)
+	_, err = client.OAuthClients().Create(&oauthClient)
+	if ignoreAlreadyExists(err) != nil {
+		logr.Errorf("Error: Unable to create the OpenShift OAuth client: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// createOAuthClient : the OpenShift OAuth client of the gatekeeper, which may only redirect back to the gatekeeper
+func createOAuthClient(codewind Codewind, deployOptions *DeployOptions) oauthv1.OAuthClient {
+	return oauthv1.OAuthClient{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "OAuthClient",
+			APIVersion: "oauth.openshift.io/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   oauthClientName(codewind.WorkspaceID),
+			Labels: map[string]string{workspaceLabel: codewind.WorkspaceID},
+		},
+		Secret:       deployOptions.ClientSecret,
+		RedirectURIs: []string{"https://" + GatekeeperPrefix + codewind.Ingress},
+		GrantMethod:  oauthv1.GrantHandlerPrompt,
+	}
+}
+
+// removeOAuthClients : Deletes the OpenShift OAuth clients of a workspace. A user who may not list OAuth clients
+// can not have created one, so being refused is ignored
+func removeOAuthClients(config *restclient.Config, workspaceID string) error {
+	client, err := oauthv1client.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	oauthClients, err := client.OAuthClients().List(metav1.ListOptions{LabelSelector: workspaceLabel + "=" + workspaceID})
+	if k8serrors.IsForbidden(err) || k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, oauthClient := range oauthClients.Items {
+		logr.Infof("Removing OpenShift OAuth client %v\n", oauthClient.Name)
+		err = ignoreNotFound(client.OAuthClients().Delete(oauthClient.Name, &metav1.DeleteOptions{}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	oauthv1 "github.com/openshift/api/oauth/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_CreateOAuthClient(t *testing.T) {
+	codewind := Codewind{WorkspaceID: "k1a2b3", Ingress: "-k1a2b3-apps.example.com"}
+	oauthClient := createOAuthClient(codewind, &DeployOptions{ClientSecret: "s3cret"})
+	assert.Equal(t, "codewind-k1a2b3", oauthClient.Name)
+	assert.Equal(t, "k1a2b3", oauthClient.Labels[workspaceLabel])
+	assert.Equal(t, "s3cret", oauthClient.Secret)
+	assert.Equal(t, []string{"https://" + GatekeeperPrefix + "-k1a2b3-apps.example.com"}, oauthClient.RedirectURIs)
+	assert.Equal(t, oauthv1.GrantHandlerPrompt, oauthClient.GrantMethod)
+}
+
+func Test_GatekeeperEnvVarsWithOpenShiftOAuth(t *testing.T) {
+	codewind := Codewind{WorkspaceID: "k1a2b3", Ingress: "-k1a2b3-apps.example.com"}
+	envVar := func(envVars []corev1.EnvVar, name string) string {
+		for _, envVar := range envVars {
+			if envVar.Name == name {
+				return envVar.Value
+			}
+		}
+		return ""
+	}
+
+	envVars := setGatekeeperEnvVars(codewind, &DeployOptions{KeycloakClient: "codewind-backend", KeycloakTLSSecure: true})
+	assert.Equal(t, apiroutes.AuthProviderKeycloak, envVar(envVars, "AUTH_PROVIDER"))
+	assert.Equal(t, "https://"+KeycloakPrefix+codewind.Ingress, envVar(envVars, "AUTH_URL"))
+
+	envVars = setGatekeeperEnvVars(codewind, &DeployOptions{OpenShiftOAuth: true, OAuthServerURL: "https://oauth-openshift.apps.example.com"})
+	assert.Equal(t, apiroutes.AuthProviderOpenShift, envVar(envVars, "AUTH_PROVIDER"))
+	assert.Equal(t, "https://oauth-openshift.apps.example.com", envVar(envVars, "AUTH_URL"))
+	assert.Equal(t, "codewind-k1a2b3", envVar(envVars, "CLIENT_ID"))
+}
@@ -0,0 +1,353 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"errors"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	logr "github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+)
+
+// CodewindInstallResource is the custom resource the operator watches, each one is a remote Codewind install in its namespace
+var CodewindInstallResource = schema.GroupVersionResource{Group: "codewind.eclipse.org", Version: "v1alpha1", Resource: "codewindinstalls"}
+
+// codewindInstallFinalizer keeps a CodewindInstall until the operator has removed the Codewind it installed
+const codewindInstallFinalizer = "codewind.eclipse.org/remove"
+
+// Phases of a CodewindInstall, reported in its status
+const (
+	PhaseInstalling = "Installing"
+	PhaseInstalled  = "Installed"
+	PhaseFailed     = "Failed"
+)
+
+// CodewindInstall : a remote Codewind install managed by the operator
+type CodewindInstall struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              CodewindInstallSpec   `json:"spec,omitempty"`
+	Status            CodewindInstallStatus `json:"status,omitempty"`
+}
+
+// CodewindInstallSpec : the options of cwctl install remote, with the Keycloak credentials read from a Secret
+type CodewindInstallSpec struct {
+	IngressDomain  string `json:"ingressDomain,omitempty"`
+	AddKeycloak    bool   `json:"addKeycloak,omitempty"`
+	KeycloakRealm  string `json:"keycloakRealm,omitempty"`
+	KeycloakClient string `json:"keycloakClient,omitempty"`
+	// CredentialsSecret names a Secret in the same namespace with the keys keycloakAdminUser,
+	// keycloakAdminPassword, keycloakDevUser and keycloakDevPassword
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+	// Images are upgraded in place when they change after the install
+	Images Images `json:"images,omitempty"`
+}
+
+// CodewindInstallStatus : the outcome of the last reconcile of a CodewindInstall
+type CodewindInstallStatus struct {
+	Phase         string `json:"phase,omitempty"`
+	Message       string `json:"message,omitempty"`
+	WorkspaceID   string `json:"workspaceID,omitempty"`
+	GatekeeperURL string `json:"gatekeeperURL,omitempty"`
+	KeycloakURL   string `json:"keycloakURL,omitempty"`
+	Images        Images `json:"images,omitempty"`
+}
+
+// operator : reconciles CodewindInstall resources using the same install, upgrade and remove logic as cwctl
+type operator struct {
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface
+	install   func(options *DeployOptions) (*DeploymentResult, *RemInstError)
+	upgrade   func(namespace string, workspaceID string, images Images) (Images, error)
+	remove    func(namespace string, workspaceID string) error
+}
+
+// RunOperator : Runs in-cluster, installing, upgrading and removing Codewind as CodewindInstall resources are
+// created, changed and deleted. Every CodewindInstall is reconciled again each resync, which retries failed installs.
+// When namespace is empty CodewindInstall resources in every namespace are watched. Only returns if it cannot start
+func RunOperator(namespace string, resync time.Duration) *RemInstError {
+	config, err := restclient.InClusterConfig()
+	if err != nil {
+		return &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return &RemInstError{errOpNotFound, err, err.Error()}
+	}
+	onOpenShift := kube.DetectOpenShift(config)
+
+	op := operator{
+		clientset: clientset,
+		dynamic:   dynamicClient,
+		install: func(options *DeployOptions) (*DeploymentResult, *RemInstError) {
+			return DeployRemoteWithConfig(config, options)
+		},
+		upgrade: func(namespace string, workspaceID string, images Images) (Images, error) {
+			return UpgradeRemote(clientset, namespace, workspaceID, images)
+		},
+		remove: func(namespace string, workspaceID string) error {
+			return RemoveRemote(config, clientset, namespace, workspaceID, onOpenShift)
+		},
+	}
+	logr.Infof("Watching %v in namespace '%v'\n", CodewindInstallResource.Resource, namespace)
+	for {
+		op.watch(namespace, resync)
+	}
+}
+
+// watch : reconciles every CodewindInstall, then each one that changes until resync has passed
+func (op *operator) watch(namespace string, resync time.Duration) {
+	resources := op.dynamic.Resource(CodewindInstallResource).Namespace(namespace)
+	list, err := resources.List(metav1.ListOptions{})
+	if err != nil {
+		logr.Errorf("Unable to list %v: %v\n", CodewindInstallResource.Resource, err)
+		time.Sleep(resync)
+		return
+	}
+	for i := range list.Items {
+		op.reconcileAndLog(&list.Items[i])
+	}
+
+	timeout := int64(resync.Seconds())
+	watcher, err := resources.Watch(metav1.ListOptions{ResourceVersion: list.GetResourceVersion(), TimeoutSeconds: &timeout})
+	if err != nil {
+		logr.Errorf("Unable to watch %v: %v\n", CodewindInstallResource.Resource, err)
+		time.Sleep(resync)
+		return
+	}
+	defer watcher.Stop()
+	for event := range watcher.ResultChan() {
+		if event.Type != watch.Added && event.Type != watch.Modified {
+			continue
+		}
+		if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+			op.reconcileAndLog(obj)
+		}
+	}
+}
+
+func (op *operator) reconcileAndLog(obj *unstructured.Unstructured) {
+	err := op.reconcile(obj)
+	if err != nil {
+		logr.Errorf("Unable to reconcile %v/%v: %v\n", obj.GetNamespace(), obj.GetName(), err)
+	}
+}
+
+// reconcile : installs Codewind for a new CodewindInstall, upgrades it when the images change,
+// and removes it when the CodewindInstall is deleted
+func (op *operator) reconcile(obj *unstructured.Unstructured) error {
+	// Events queue up while an install runs, so always start from the latest version
+	resources := op.dynamic.Resource(CodewindInstallResource).Namespace(obj.GetNamespace())
+	obj, err := resources.Get(obj.GetName(), metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	install := CodewindInstall{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &install)
+	if err != nil {
+		return err
+	}
+
+	if install.DeletionTimestamp != nil {
+		if !containsString(obj.GetFinalizers(), codewindInstallFinalizer) {
+			return nil
+		}
+		// An install which failed part way is removed using the workspace in its saved progress
+		workspaceID := install.Status.WorkspaceID
+		if workspaceID == "" {
+			progress, err := LoadInstallProgress(op.clientset, install.Namespace)
+			if err != nil {
+				return err
+			}
+			if progress != nil {
+				workspaceID = progress.WorkspaceID
+			}
+		}
+		if workspaceID != "" {
+			err = op.remove(install.Namespace, workspaceID)
+			if err != nil {
+				return op.updateStatus(resources, obj, failedStatus(install.Status, err))
+			}
+		}
+		finalizers := []string{}
+		for _, finalizer := range obj.GetFinalizers() {
+			if finalizer != codewindInstallFinalizer {
+				finalizers = append(finalizers, finalizer)
+			}
+		}
+		obj.SetFinalizers(finalizers)
+		_, err = resources.Update(obj, metav1.UpdateOptions{})
+		return err
+	}
+
+	if !containsString(obj.GetFinalizers(), codewindInstallFinalizer) {
+		obj.SetFinalizers(append(obj.GetFinalizers(), codewindInstallFinalizer))
+		obj, err = resources.Update(obj, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	status := install.Status
+	if status.WorkspaceID == "" {
+		conflict, err := op.otherInstall(resources, install.Name)
+		if err != nil {
+			return err
+		}
+		if conflict != "" {
+			err = errors.New("CodewindInstall " + conflict + " already manages Codewind in namespace " + install.Namespace + ", only one is supported per namespace")
+			return op.updateStatus(resources, obj, failedStatus(status, err))
+		}
+		return op.installCodewind(resources, obj, install)
+	}
+
+	images := install.Spec.Images.withDefaults()
+	if images == status.Images && status.Phase == PhaseInstalled {
+		return nil
+	}
+	upgraded, err := op.upgrade(install.Namespace, status.WorkspaceID, install.Spec.Images)
+	if err != nil {
+		return op.updateStatus(resources, obj, failedStatus(status, err))
+	}
+	status.Phase = PhaseInstalled
+	status.Message = ""
+	status.Images = upgraded
+	return op.updateStatus(resources, obj, status)
+}
+
+// installCodewind : runs cwctl install remote for a CodewindInstall, resuming an install that failed part way
+func (op *operator) installCodewind(resources dynamic.ResourceInterface, obj *unstructured.Unstructured, install CodewindInstall) error {
+	options, err := op.deployOptions(install)
+	if err != nil {
+		return op.updateStatus(resources, obj, failedStatus(install.Status, err))
+	}
+	status := CodewindInstallStatus{Phase: PhaseInstalling}
+	err = op.updateStatus(resources, obj, status)
+	if err != nil {
+		return err
+	}
+	obj, err = resources.Get(obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	result, remInstErr := op.install(options)
+	if remInstErr != nil {
+		return op.updateStatus(resources, obj, failedStatus(status, remInstErr.Err))
+	}
+	status = CodewindInstallStatus{
+		Phase:         PhaseInstalled,
+		WorkspaceID:   result.WorkspaceID,
+		GatekeeperURL: result.GatekeeperURL,
+		KeycloakURL:   result.KeycloakURL,
+		Images:        result.Images,
+	}
+	return op.updateStatus(resources, obj, status)
+}
+
+// deployOptions : the options cwctl install remote would be given for a CodewindInstall
+func (op *operator) deployOptions(install CodewindInstall) (*DeployOptions, error) {
+	options := DeployOptions{
+		Namespace:           install.Namespace,
+		IngressDomain:       install.Spec.IngressDomain,
+		InstallKeycloak:     install.Spec.AddKeycloak,
+		KeycloakRealm:       install.Spec.KeycloakRealm,
+		KeycloakClient:      install.Spec.KeycloakClient,
+		GateKeeperTLSSecure: true,
+		KeycloakTLSSecure:   true,
+		Images:              install.Spec.Images,
+	}
+	sessionSecret, err := NewSecret()
+	if err != nil {
+		return nil, err
+	}
+	options.CodewindSessionSecret = sessionSecret
+	if install.Spec.CredentialsSecret != "" {
+		secret, err := op.clientset.CoreV1().Secrets(install.Namespace).Get(install.Spec.CredentialsSecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		options.KeycloakUser = string(secret.Data["keycloakAdminUser"])
+		options.KeycloakPassword = string(secret.Data["keycloakAdminPassword"])
+		options.KeycloakDevUser = string(secret.Data["keycloakDevUser"])
+		options.KeycloakDevPassword = string(secret.Data["keycloakDevPassword"])
+	}
+	progress, err := LoadInstallProgress(op.clientset, install.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	options.Resume = progress != nil
+	return &options, nil
+}
+
+// otherInstall : the name of another CodewindInstall in the namespace which has installed or is installing Codewind,
+// as install progress is saved per namespace
+func (op *operator) otherInstall(resources dynamic.ResourceInterface, name string) (string, error) {
+	list, err := resources.List(metav1.ListOptions{})
+	if err != nil {
+		return "", err
+	}
+	for _, item := range list.Items {
+		if item.GetName() == name {
+			continue
+		}
+		phase, _, _ := unstructured.NestedString(item.Object, "status", "phase")
+		workspaceID, _, _ := unstructured.NestedString(item.Object, "status", "workspaceID")
+		if workspaceID != "" || phase == PhaseInstalling {
+			return item.GetName(), nil
+		}
+	}
+	return "", nil
+}
+
+func (op *operator) updateStatus(resources dynamic.ResourceInterface, obj *unstructured.Unstructured, status CodewindInstallStatus) error {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	obj.Object["status"] = content
+	_, err = resources.UpdateStatus(obj, metav1.UpdateOptions{})
+	return err
+}
+
+// failedStatus : the status after a failure, keeping the workspace so that it is still removed on delete
+func failedStatus(status CodewindInstallStatus, err error) CodewindInstallStatus {
+	status.Phase = PhaseFailed
+	status.Message = err.Error()
+	return status
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newCodewindInstall(name string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "codewind.eclipse.org/v1alpha1",
+		"kind":       "CodewindInstall",
+		"metadata":   map[string]interface{}{"name": name, "namespace": "codewind"},
+		"spec": map[string]interface{}{
+			"ingressDomain":     "10.0.0.1.nip.io",
+			"credentialsSecret": "codewind-credentials",
+		},
+	}}
+}
+
+func Test_Operator(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "codewind-credentials", Namespace: "codewind"},
+		Data:       map[string][]byte{"keycloakAdminUser": []byte("admin"), "keycloakDevPassword": []byte("secret")},
+	})
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), newCodewindInstall("team-a"), newCodewindInstall("team-b"))
+	resources := dynamicClient.Resource(CodewindInstallResource).Namespace("codewind")
+
+	var installed []*DeployOptions
+	var upgraded []Images
+	var removed []string
+	op := operator{
+		clientset: clientset,
+		dynamic:   dynamicClient,
+		install: func(options *DeployOptions) (*DeploymentResult, *RemInstError) {
+			installed = append(installed, options)
+			return &DeploymentResult{WorkspaceID: "k1a2b3", GatekeeperURL: "https://gatekeeper", Images: options.Images.withDefaults()}, nil
+		},
+		upgrade: func(namespace string, workspaceID string, images Images) (Images, error) {
+			upgraded = append(upgraded, images)
+			return images.withDefaults(), nil
+		},
+		remove: func(namespace string, workspaceID string) error {
+			removed = append(removed, workspaceID)
+			return nil
+		},
+	}
+	getInstall := func(name string) CodewindInstall {
+		obj, _ := resources.Get(name, metav1.GetOptions{})
+		install := CodewindInstall{}
+		runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), &install)
+		return install
+	}
+
+	t.Run("Installs Codewind for a new CodewindInstall", func(t *testing.T) {
+		err := op.reconcile(newCodewindInstall("team-a"))
+		assert.Nil(t, err)
+		assert.Len(t, installed, 1)
+		assert.Equal(t, "codewind", installed[0].Namespace)
+		assert.Equal(t, "10.0.0.1.nip.io", installed[0].IngressDomain)
+		assert.Equal(t, "admin", installed[0].KeycloakUser)
+		assert.Equal(t, "secret", installed[0].KeycloakDevPassword)
+
+		install := getInstall("team-a")
+		assert.Equal(t, PhaseInstalled, install.Status.Phase)
+		assert.Equal(t, "k1a2b3", install.Status.WorkspaceID)
+		assert.Contains(t, install.Finalizers, codewindInstallFinalizer)
+	})
+
+	t.Run("Does nothing when the install is up to date", func(t *testing.T) {
+		err := op.reconcile(newCodewindInstall("team-a"))
+		assert.Nil(t, err)
+		assert.Len(t, installed, 1)
+		assert.Len(t, upgraded, 0)
+	})
+
+	t.Run("Refuses a second CodewindInstall in the namespace", func(t *testing.T) {
+		err := op.reconcile(newCodewindInstall("team-b"))
+		assert.Nil(t, err)
+		assert.Len(t, installed, 1)
+		assert.Equal(t, PhaseFailed, getInstall("team-b").Status.Phase)
+	})
+
+	t.Run("Upgrades when the images change", func(t *testing.T) {
+		obj, _ := resources.Get("team-a", metav1.GetOptions{})
+		unstructured.SetNestedField(obj.Object, "eclipse/codewind-pfe-amd64:0.9.0", "spec", "images", "pfe")
+		resources.Update(obj, metav1.UpdateOptions{})
+
+		err := op.reconcile(obj)
+		assert.Nil(t, err)
+		assert.Len(t, upgraded, 1)
+		assert.Equal(t, "eclipse/codewind-pfe-amd64:0.9.0", getInstall("team-a").Status.Images.PFE)
+	})
+
+	t.Run("Removes Codewind when the CodewindInstall is deleted", func(t *testing.T) {
+		obj, _ := resources.Get("team-a", metav1.GetOptions{})
+		now := metav1.Now()
+		obj.SetDeletionTimestamp(&now)
+		resources.Update(obj, metav1.UpdateOptions{})
+
+		err := op.reconcile(obj)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"k1a2b3"}, removed)
+		assert.NotContains(t, getInstall("team-a").Finalizers, codewindInstallFinalizer)
+	})
+}
+
+func Test_UpgradeRemote(t *testing.T) {
+	labels := map[string]string{workspaceLabel: "k1a2b3"}
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: PFEPrefix + "-k1a2b3", Namespace: "codewind", Labels: labels},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: PFEPrefix, Image: "eclipse/codewind-pfe-amd64:0.8.0"}},
+		}}},
+	})
+
+	images, err := UpgradeRemote(clientset, "codewind", "k1a2b3", Images{PFE: "eclipse/codewind-pfe-amd64:0.9.0"})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, images.Gatekeeper)
+
+	deployment, _ := clientset.AppsV1().Deployments("codewind").Get(PFEPrefix+"-k1a2b3", metav1.GetOptions{})
+	assert.Equal(t, "eclipse/codewind-pfe-amd64:0.9.0", deployment.Spec.Template.Spec.Containers[0].Image)
+}
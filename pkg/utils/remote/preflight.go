@@ -0,0 +1,211 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	logr "github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The Kubernetes versions a remote install is known to work on. Later versions are only warned about, as
+// Kubernetes 1.22 stopped serving the extensions/v1beta1 ingresses Codewind is exposed with, but a cluster may
+// still serve them, and OpenShift does not use them as it has routes
+const (
+	minKubeMinor = 11
+	maxKubeMinor = 21
+)
+
+// defaultStorageClassAnnotations : the annotations, current and beta, which mark the default storage class
+var defaultStorageClassAnnotations = []string{
+	"storageclass.kubernetes.io/is-default-class",
+	"storageclass.beta.kubernetes.io/is-default-class",
+}
+
+// ingressControllerNames : parts of the names of the deployments and daemon sets of well known ingress controllers
+var ingressControllerNames = []string{"ingress-nginx", "nginx-ingress", "traefik", "haproxy-ingress", "contour", "kong", "ambassador"}
+
+// preflightPermission : a resource a remote install creates in its namespace
+type preflightPermission struct {
+	group    string
+	resource string
+}
+
+// PreflightCheck : Checks that a cluster can take a remote install into namespace before anything is deployed,
+// returning a description of each problem found with what to do about it. Checks which need permissions the
+// user may not have outside the namespace, such as listing storage classes, are skipped with a warning when
+// refused, rather than failing the install
+func PreflightCheck(clientset kubernetes.Interface, namespace string, ingressDomain string, onOpenShift bool) []string {
+	problems := []string{}
+	problems = append(problems, checkServerVersion(clientset, onOpenShift)...)
+	problems = append(problems, checkPermissions(clientset, namespace, onOpenShift)...)
+	problems = append(problems, checkDefaultStorageClass(clientset)...)
+	if !onOpenShift {
+		problems = append(problems, checkIngressController(clientset, ingressDomain)...)
+	}
+	return problems
+}
+
+// runPreflightCheck : Runs PreflightCheck, logging each problem found and failing the install when there are any.
+// An install using OpenShift OAuth is also checked for permission to create its OAuth client
+func runPreflightCheck(clientset kubernetes.Interface, namespace string, ingressDomain string, onOpenShift bool, openShiftOAuth bool) *RemInstError {
+	problems := PreflightCheck(clientset, namespace, ingressDomain, onOpenShift)
+	if openShiftOAuth {
+		problems = append(problems, checkOAuthClientPermission(clientset)...)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	for _, problem := range problems {
+		logr.Errorln(problem)
+	}
+	err := errors.New(errPreflightFailed + ":\n  " + strings.Join(problems, "\n  "))
+	return &RemInstError{errOpPreflight, err, err.Error()}
+}
+
+func checkServerVersion(clientset kubernetes.Interface, onOpenShift bool) []string {
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return []string{"Unable to reach the Kubernetes API server: " + err.Error() + ". Check the current context of your kubeconfig with `kubectl cluster-info`"}
+	}
+	major, majorErr := strconv.Atoi(strings.TrimRight(info.Major, "+"))
+	minor, minorErr := strconv.Atoi(strings.TrimRight(info.Minor, "+"))
+	if majorErr != nil || minorErr != nil {
+		logr.Warnf("Unable to tell the Kubernetes version from %q, skipping the version check\n", info.GitVersion)
+		return nil
+	}
+	if major < 1 || (major == 1 && minor < minKubeMinor) {
+		return []string{fmt.Sprintf("Kubernetes %v.%v is not supported, upgrade the cluster to Kubernetes 1.%v or later", major, minor, minKubeMinor)}
+	}
+	if !onOpenShift && (major > 1 || minor > maxKubeMinor) {
+		logr.Warnf("Kubernetes %v.%v is newer than the versions Codewind is tested on, 1.%v to 1.%v. Codewind is exposed with extensions/v1beta1 ingresses, so the install cannot be reached unless the cluster still serves them\n", major, minor, minKubeMinor, maxKubeMinor)
+	}
+	return nil
+}
+
+func checkPermissions(clientset kubernetes.Interface, namespace string, onOpenShift bool) []string {
+	permissions := []preflightPermission{
+		{"apps", "deployments"},
+		{"", "services"},
+		{"", "secrets"},
+		{"", "configmaps"},
+	}
+	if onOpenShift {
+		permissions = append(permissions, preflightPermission{"route.openshift.io", "routes"})
+	} else {
+		permissions = append(permissions, preflightPermission{"extensions", "ingresses"})
+	}
+
+	problems := []string{}
+	refused := []string{}
+	for _, permission := range permissions {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace,
+					Verb:      "create",
+					Group:     permission.group,
+					Resource:  permission.resource,
+				},
+			},
+		}
+		result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+		if err != nil {
+			problems = append(problems, "Unable to check whether you may create "+permission.resource+": "+err.Error())
+			continue
+		}
+		if !result.Status.Allowed {
+			refused = append(refused, permission.resource)
+		}
+	}
+	if len(refused) > 0 {
+		problems = append(problems, "You are not allowed to create "+strings.Join(refused, ", ")+" in namespace "+namespace+". Ask a cluster administrator to bind you to a role with these permissions, e.g. `kubectl create rolebinding codewind-admin --clusterrole=admin --user=<you> -n "+namespace+"`")
+	}
+	return problems
+}
+
+func checkDefaultStorageClass(clientset kubernetes.Interface) []string {
+	classes, err := clientset.StorageV1().StorageClasses().List(metav1.ListOptions{})
+	if err != nil {
+		logr.Warnf("Unable to list storage classes, skipping the default storage class check: %v\n", err)
+		return nil
+	}
+	for _, class := range classes.Items {
+		for _, annotation := range defaultStorageClassAnnotations {
+			if class.Annotations[annotation] == "true" {
+				return nil
+			}
+		}
+	}
+	if len(classes.Items) == 0 {
+		return []string{"The cluster has no storage classes, so the Codewind workspace volume cannot be provisioned. Install a storage provisioner and make its storage class the default"}
+	}
+	return []string{"None of the storage classes of the cluster is the default, so the Codewind workspace volume cannot be provisioned. Mark one as the default with `kubectl patch storageclass <name> -p '{\"metadata\":{\"annotations\":{\"storageclass.kubernetes.io/is-default-class\":\"true\"}}}'`"}
+}
+
+func checkIngressController(clientset kubernetes.Interface, ingressDomain string) []string {
+	deployments, err := clientset.AppsV1().Deployments("").List(metav1.ListOptions{})
+	if err != nil {
+		logr.Warnf("Unable to list deployments in all namespaces, skipping the ingress controller check: %v\n", err)
+		return nil
+	}
+	names := []string{}
+	for _, deployment := range deployments.Items {
+		names = append(names, deployment.Name)
+	}
+	daemonSets, err := clientset.AppsV1().DaemonSets("").List(metav1.ListOptions{})
+	if err != nil {
+		logr.Warnf("Unable to list daemon sets in all namespaces, skipping the ingress controller check: %v\n", err)
+		return nil
+	}
+	for _, daemonSet := range daemonSets.Items {
+		names = append(names, daemonSet.Name)
+	}
+	for _, name := range names {
+		for _, controller := range ingressControllerNames {
+			if strings.Contains(name, controller) {
+				return nil
+			}
+		}
+	}
+	if ingressDomain != "" {
+		logr.Warnf("No well known ingress controller was found, make sure one serves %v\n", ingressDomain)
+		return nil
+	}
+	return []string{"No ingress controller was found, so Codewind cannot be reached from outside the cluster. Install one such as ingress-nginx (https://kubernetes.github.io/ingress-nginx/deploy/), or give the domain of the one the cluster has with --ingress"}
+}
+
+func checkOAuthClientPermission(clientset kubernetes.Interface) []string {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:     "create",
+				Group:    "oauth.openshift.io",
+				Resource: "oauthclients",
+			},
+		},
+	}
+	result, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(review)
+	if err != nil {
+		return []string{"Unable to check whether you may create oauthclients: " + err.Error()}
+	}
+	if !result.Status.Allowed {
+		return []string{"You are not allowed to create oauthclients, which are not namespaced, so the gatekeeper can not use OpenShift OAuth. Ask a cluster administrator to grant you this, or install without --openshift-oauth"}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newPreflightClientset : a fake cluster running the given Kubernetes version, which answers access reviews by
+// whether the resource is one of those refused
+func newPreflightClientset(major string, minor string, refused []string, objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{Major: major, Minor: minor}
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		review.Status.Allowed = true
+		for _, resource := range refused {
+			if review.Spec.ResourceAttributes.Resource == resource {
+				review.Status.Allowed = false
+			}
+		}
+		return true, review, nil
+	})
+	return clientset
+}
+
+var defaultStorageClass = &storagev1.StorageClass{
+	ObjectMeta: metav1.ObjectMeta{Name: "standard", Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"}},
+}
+
+var ingressController = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx-ingress-controller", Namespace: "ingress-nginx"}}
+
+func Test_PreflightCheck(t *testing.T) {
+	t.Run("Passes a cluster which can take the install", func(t *testing.T) {
+		clientset := newPreflightClientset("1", "15+", nil, defaultStorageClass, ingressController)
+		assert.Equal(t, []string{}, PreflightCheck(clientset, "codewind", "", false))
+	})
+
+	t.Run("Reports each problem found", func(t *testing.T) {
+		clientset := newPreflightClientset("1", "10", []string{"secrets", "ingresses"})
+		problems := PreflightCheck(clientset, "codewind", "", false)
+		assert.Equal(t, 4, len(problems))
+		assert.Contains(t, problems[0], "Kubernetes 1.10 is not supported")
+		assert.Contains(t, problems[1], "not allowed to create secrets, ingresses in namespace codewind")
+		assert.Contains(t, problems[2], "no storage classes")
+		assert.Contains(t, problems[3], "No ingress controller")
+	})
+
+	t.Run("Checks routes rather than ingresses on OpenShift", func(t *testing.T) {
+		clientset := newPreflightClientset("1", "25", []string{"routes"}, defaultStorageClass)
+		problems := PreflightCheck(clientset, "codewind", "", true)
+		assert.Equal(t, 1, len(problems))
+		assert.Contains(t, problems[0], "not allowed to create routes")
+	})
+}
+
+func Test_CheckServerVersion(t *testing.T) {
+	t.Run("Only warns about a version newer than those tested", func(t *testing.T) {
+		assert.Nil(t, checkServerVersion(newPreflightClientset("1", "22", nil), false))
+		assert.Nil(t, checkServerVersion(newPreflightClientset("1", "28+", nil), false))
+	})
+
+	t.Run("Refuses a version older than those supported", func(t *testing.T) {
+		problems := checkServerVersion(newPreflightClientset("1", "10", nil), true)
+		assert.Equal(t, 1, len(problems))
+		assert.Contains(t, problems[0], "upgrade the cluster to Kubernetes 1.11 or later")
+	})
+
+	t.Run("Skips a version which can not be parsed", func(t *testing.T) {
+		assert.Nil(t, checkServerVersion(newPreflightClientset("", "", nil), false))
+	})
+}
+
+func Test_CheckDefaultStorageClass(t *testing.T) {
+	t.Run("Accepts the beta annotation", func(t *testing.T) {
+		class := &storagev1.StorageClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "gp2", Annotations: map[string]string{"storageclass.beta.kubernetes.io/is-default-class": "true"}},
+		}
+		assert.Nil(t, checkDefaultStorageClass(fake.NewSimpleClientset(class)))
+	})
+
+	t.Run("Reports storage classes none of which is the default", func(t *testing.T) {
+		class := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "slow"}}
+		problems := checkDefaultStorageClass(fake.NewSimpleClientset(class))
+		assert.Equal(t, 1, len(problems))
+		assert.True(t, strings.Contains(problems[0], "kubectl patch storageclass"))
+	})
+
+	t.Run("Skips the check when storage classes can not be listed", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependReactor("list", "storageclasses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, errors.New("forbidden")
+		})
+		assert.Nil(t, checkDefaultStorageClass(clientset))
+	})
+}
+
+func Test_CheckIngressController(t *testing.T) {
+	t.Run("Only warns about a missing controller when the ingress domain is given", func(t *testing.T) {
+		assert.Nil(t, checkIngressController(fake.NewSimpleClientset(), "10.0.0.1.nip.io"))
+	})
+
+	t.Run("Finds a controller run as a daemon set", func(t *testing.T) {
+		daemonSet := &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{Name: "traefik", Namespace: "kube-system"}}
+		assert.Nil(t, checkIngressController(fake.NewSimpleClientset(daemonSet), ""))
+	})
+}
+
+func Test_CheckOAuthClientPermission(t *testing.T) {
+	t.Run("Passes a user who may create OAuth clients", func(t *testing.T) {
+		assert.Nil(t, checkOAuthClientPermission(newPreflightClientset("1", "16", nil)))
+	})
+
+	t.Run("Reports a user who may not create OAuth clients", func(t *testing.T) {
+		problems := checkOAuthClientPermission(newPreflightClientset("1", "16", []string{"oauthclients"}))
+		assert.Equal(t, 1, len(problems))
+		assert.Contains(t, problems[0], "--openshift-oauth")
+	})
+}
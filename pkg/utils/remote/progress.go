@@ -0,0 +1,142 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// InstallProgressName is the name of the ConfigMap and Secret used to checkpoint a remote install
+const InstallProgressName = "codewind-install-progress"
+
+// Remote install steps, in the order they are run
+const (
+	StepDeployKeycloak    = "deploy_keycloak"
+	StepConfigureKeycloak = "configure_keycloak"
+	StepCreateOAuthClient = "create_oauth_client"
+	StepDeployPFE         = "deploy_pfe"
+	StepDeployPerformance = "deploy_performance"
+	StepDeployGatekeeper  = "deploy_gatekeeper"
+)
+
+// InstallProgress records how far a remote install got so that it can be resumed
+type InstallProgress struct {
+	WorkspaceID       string   `json:"workspaceID"`
+	IngressDomain     string   `json:"ingressDomain"`
+	OwnerReferenceUID string   `json:"ownerReferenceUID"`
+	CompletedSteps    []string `json:"completedSteps"`
+	FailedStep        string   `json:"failedStep,omitempty"`
+
+	// Kept in a Secret rather than the ConfigMap
+	ClientSecret  string `json:"-"`
+	SessionSecret string `json:"-"`
+}
+
+// IsComplete : returns whether the given step finished in an earlier run
+func (progress *InstallProgress) IsComplete(step string) bool {
+	for _, completed := range progress.CompletedSteps {
+		if completed == step {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkComplete : records that the given step has finished
+func (progress *InstallProgress) MarkComplete(step string) {
+	if !progress.IsComplete(step) {
+		progress.CompletedSteps = append(progress.CompletedSteps, step)
+	}
+	progress.FailedStep = ""
+}
+
+// LoadInstallProgress : reads the install progress saved in the namespace, returning nil if there is none
+func LoadInstallProgress(clientset kubernetes.Interface, namespace string) (*InstallProgress, error) {
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(InstallProgressName, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	progress := InstallProgress{}
+	err = json.Unmarshal([]byte(configMap.Data["progress"]), &progress)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(InstallProgressName, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		progress.ClientSecret = string(secret.Data["client_secret"])
+		progress.SessionSecret = string(secret.Data["session_secret"])
+	}
+	return &progress, nil
+}
+
+// SaveInstallProgress : creates or updates the install progress saved in the namespace
+func SaveInstallProgress(clientset kubernetes.Interface, namespace string, progress *InstallProgress) error {
+	progressJSON, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: InstallProgressName, Namespace: namespace},
+		Data:       map[string]string{"progress": string(progressJSON)},
+	}
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Create(&configMap)
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().ConfigMaps(namespace).Update(&configMap)
+	}
+	if err != nil {
+		return err
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: InstallProgressName, Namespace: namespace},
+		StringData: map[string]string{
+			"client_secret":  progress.ClientSecret,
+			"session_secret": progress.SessionSecret,
+		},
+	}
+	_, err = clientset.CoreV1().Secrets(namespace).Create(&secret)
+	if k8serrors.IsAlreadyExists(err) {
+		_, err = clientset.CoreV1().Secrets(namespace).Update(&secret)
+	}
+	return err
+}
+
+// DeleteInstallProgress : removes the install progress once an install has finished
+func DeleteInstallProgress(clientset kubernetes.Interface, namespace string) error {
+	err := clientset.CoreV1().ConfigMaps(namespace).Delete(InstallProgressName, &metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	err = clientset.CoreV1().Secrets(namespace).Delete(InstallProgressName, &metav1.DeleteOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ignoreAlreadyExists : lets a resumed step re-create resources that an earlier attempt managed to create
+func ignoreAlreadyExists(err error) error {
+	if k8serrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package remote
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_InstallProgress(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+
+	t.Run("Returns nil when no install has been recorded", func(t *testing.T) {
+		progress, err := LoadInstallProgress(clientset, "codewind")
+		assert.Nil(t, err)
+		assert.Nil(t, progress)
+	})
+
+	t.Run("Saved progress can be loaded and updated", func(t *testing.T) {
+		progress := &InstallProgress{WorkspaceID: "k1a2b3", IngressDomain: "10.0.0.1.nip.io", SessionSecret: "session"}
+		progress.MarkComplete(StepDeployKeycloak)
+		progress.FailedStep = StepConfigureKeycloak
+		err := SaveInstallProgress(clientset, "codewind", progress)
+		assert.Nil(t, err)
+
+		progress.MarkComplete(StepConfigureKeycloak)
+		progress.ClientSecret = "client"
+		err = SaveInstallProgress(clientset, "codewind", progress)
+		assert.Nil(t, err)
+
+		loaded, err := LoadInstallProgress(clientset, "codewind")
+		assert.Nil(t, err)
+		assert.Equal(t, "k1a2b3", loaded.WorkspaceID)
+		assert.True(t, loaded.IsComplete(StepConfigureKeycloak))
+		assert.False(t, loaded.IsComplete(StepDeployPFE))
+		assert.Equal(t, "", loaded.FailedStep)
+	})
+
+	t.Run("Progress is removed once an install finishes", func(t *testing.T) {
+		err := DeleteInstallProgress(clientset, "codewind")
+		assert.Nil(t, err)
+		progress, err := LoadInstallProgress(clientset, "codewind")
+		assert.Nil(t, err)
+		assert.Nil(t, progress)
+	})
+}
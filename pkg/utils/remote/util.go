@@ -17,6 +17,7 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"math/big"
@@ -160,6 +161,7 @@ func generateSecrets(codewind Codewind, name string, secrets map[string]string)
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name + "-" + codewind.WorkspaceID,
 			Namespace: codewind.Namespace,
+			Labels:    map[string]string{workspaceLabel: codewind.WorkspaceID},
 		},
 		StringData: secrets,
 	}
@@ -204,6 +206,16 @@ func generateService(codewind Codewind, name string, port int, labels map[string
 	return service
 }
 
+// NewSecret : a random secret, such as the session secret of the gatekeeper or the secret of its OAuth client
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
 func createCertificate(dnsName string, certTitle string) (string, string, error) {
 	template := x509.Certificate{
 		SerialNumber: big.NewInt(1),
@@ -218,14 +230,14 @@ func createCertificate(dnsName string, certTitle string) (string, string, error)
 		DNSNames:              []string{dnsName},
 	}
 
-	logr.Println("Creating " + dnsName + " server Key")
+	logr.Infoln("Creating " + dnsName + " server Key")
 	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
 		logr.Errorln("Unable to create server key")
 		return "", "", err
 	}
 
-	logr.Println("Creating " + dnsName + " server certificate")
+	logr.Infoln("Creating " + dnsName + " server certificate")
 	certDerBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, publicKey(privateKey), privateKey)
 	if err != nil {
 		logr.Errorf("Failed to create certificate: %s\n", err)
@@ -0,0 +1,258 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// Login flows of sectoken get
+const (
+	FlowPassword = "password"
+	FlowAuthCode = "authcode"
+)
+
+// authCodeTimeout : how long the browser login may take before cwctl gives up waiting for the redirect
+var authCodeTimeout = 5 * time.Minute
+
+// openBrowser : opens the system browser at a URL, replaced in tests
+var openBrowser = func(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	}
+	return exec.Command("xdg-open", url).Start()
+}
+
+// authCodeLogin : the auth server a browser login is made against, and the connection its tokens are cached for
+type authCodeLogin struct {
+	AuthURL      string
+	Realm        string
+	ClientID     string
+	ConnectionID string
+}
+
+// SecAuthenticateAuthCode - logs in through the system browser with Keycloak's authorization code flow and PKCE,
+// so that users of federated SSO or multi-factor authentication can log in. The redirect is captured on a
+// localhost listener. When a connection ID is given its auth server is discovered from its gatekeeper and the
+// tokens are cached in its keyring, otherwise the host, realm and client flags are used
+func SecAuthenticateAuthCode(httpClient utils.HTTPClient, c *cli.Context) (*AuthToken, *SecError) {
+	login := authCodeLogin{
+		AuthURL:  strings.TrimSpace(c.String("host")),
+		Realm:    strings.TrimSpace(c.String("realm")),
+		ClientID: strings.TrimSpace(c.String("client")),
+	}
+	connectionID := strings.TrimSpace(strings.ToLower(c.String("conid")))
+	if connectionID != "" {
+		connection, gatekeeperEnv, secErr := discoverConnectionAuth(httpClient, connectionID)
+		if secErr != nil {
+			return nil, secErr
+		}
+		if gatekeeperEnv.UsesOpenShiftOAuth() {
+			err := errors.New("Connection " + strings.ToUpper(connectionID) + " logs in with OpenShift OAuth, log in with `oc login` or --username and --password instead of the browser")
+			return nil, &SecError{errOpCLICommand, err, err.Error()}
+		}
+		login = authCodeLogin{
+			AuthURL:      gatekeeperEnv.AuthURL,
+			Realm:        gatekeeperEnv.Realm,
+			ClientID:     gatekeeperEnv.ClientID,
+			ConnectionID: connection.ID,
+		}
+	}
+	if login.AuthURL == "" || login.Realm == "" || login.ClientID == "" {
+		err := errors.New("Must supply a connection ID or the host, realm and client to log in with")
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+	return login.authenticate(httpClient)
+}
+
+// authenticate : runs the browser login and exchanges the code it returns for tokens
+func (login *authCodeLogin) authenticate(httpClient utils.HTTPClient) (*AuthToken, *SecError) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, &SecError{errOpAuthCode, err, err.Error()}
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return nil, &SecError{errOpAuthCode, err, err.Error()}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, &SecError{errOpAuthCode, err, "Unable to listen for the login redirect: " + err.Error()}
+	}
+	defer listener.Close()
+	redirectURI := "http://" + listener.Addr().String() + "/callback"
+
+	authorizeURL := login.authorizeURL(redirectURI, state, pkceChallenge(verifier))
+	fmt.Fprintf(os.Stderr, "Log in with your browser. If it does not open, visit:\n%v\n", authorizeURL)
+	if err := openBrowser(authorizeURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to open the browser: %v\n", err)
+	}
+
+	code, secErr := waitForAuthCode(listener, state, authCodeTimeout)
+	if secErr != nil {
+		return nil, secErr
+	}
+	authToken, secErr := login.exchangeCode(httpClient, code, redirectURI, verifier)
+	if secErr != nil {
+		return nil, secErr
+	}
+	if login.ConnectionID != "" {
+		secErr = SecCacheTokens(login.ConnectionID, authToken)
+		if secErr != nil {
+			return authToken, secErr
+		}
+	}
+	return authToken, nil
+}
+
+// authorizeURL : the Keycloak login page which redirects back to redirectURI with a code
+func (login *authCodeLogin) authorizeURL(redirectURI string, state string, challenge string) string {
+	query := url.Values{
+		"client_id":             {login.ClientID},
+		"response_type":         {"code"},
+		"scope":                 {"openid"},
+		"redirect_uri":          {redirectURI},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return login.AuthURL + "/auth/realms/" + login.Realm + "/protocol/openid-connect/auth?" + query.Encode()
+}
+
+// waitForAuthCode : serves the redirect of the browser login on the listener, returning the code it carries once
+// the state matches. The wait ends with an error if the login fails or takes longer than timeout
+func waitForAuthCode(listener net.Listener, state string, timeout time.Duration) (string, *SecError) {
+	type result struct {
+		code string
+		err  error
+	}
+	results := make(chan result, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		var res result
+		switch {
+		case query.Get("state") != state:
+			res.err = errors.New("The login redirect did not come from the login cwctl started")
+		case query.Get("error") != "":
+			res.err = errors.New(strings.TrimSpace(query.Get("error") + " " + query.Get("error_description")))
+		case query.Get("code") == "":
+			res.err = errors.New("The login redirect did not include a code")
+		default:
+			res.code = query.Get("code")
+		}
+		if res.err != nil {
+			http.Error(w, "Login failed: "+res.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Logged in to Codewind, you can close this window")
+		}
+		select {
+		case results <- res:
+		default:
+		}
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	select {
+	case res := <-results:
+		if res.err != nil {
+			return "", &SecError{errOpAuthCode, res.err, res.err.Error()}
+		}
+		return res.code, nil
+	case <-time.After(timeout):
+		err := errors.New("Timed out waiting for the browser login to finish")
+		return "", &SecError{errOpAuthCode, err, err.Error()}
+	}
+}
+
+// exchangeCode : exchanges the code of a browser login and the PKCE verifier for tokens
+func (login *authCodeLogin) exchangeCode(httpClient utils.HTTPClient, code string, redirectURI string, verifier string) (*AuthToken, *SecError) {
+	tokenURL := login.AuthURL + "/auth/realms/" + login.Realm + "/protocol/openid-connect/token"
+	payload := url.Values{
+		"grant_type":    {"authorization_code"},
+		"client_id":     {login.ClientID},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, &SecError{errOpConnection, err, err.Error()}
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Cache-Control", "no-cache")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &SecError{errOpConnection, err, err.Error()}
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+
+	switch httpCode := res.StatusCode; {
+	case httpCode == http.StatusBadRequest, httpCode == http.StatusUnauthorized:
+		keycloakAPIError := parseKeycloakError(string(body), res.StatusCode)
+		kcError := errors.New(string(keycloakAPIError.ErrorDescription))
+		return nil, &SecError{keycloakAPIError.Error, kcError, kcError.Error()}
+	case httpCode != http.StatusOK:
+		err = errors.New(string(body))
+		return nil, &SecError{errOpResponse, err, err.Error()}
+	}
+
+	authToken := AuthToken{}
+	err = json.Unmarshal(body, &authToken)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
+	return &authToken, nil
+}
+
+// randomString : n random bytes, base64url encoded, for a PKCE verifier or a state
+func randomString(n int) (string, error) {
+	bytes := make([]byte, n)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// pkceChallenge : the S256 code challenge of a PKCE verifier
+func pkceChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
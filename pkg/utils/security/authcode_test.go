@@ -0,0 +1,101 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AuthCodeLogin(t *testing.T) {
+	var challenge string
+	keycloak := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "c0de" || pkceChallenge(r.Form.Get("code_verifier")) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Code not valid"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(AuthToken{AccessToken: "access", RefreshToken: "refresh"})
+	}))
+	defer keycloak.Close()
+	login := &authCodeLogin{AuthURL: keycloak.URL, Realm: "codewind", ClientID: "codewind-cli"}
+
+	originalBrowser := openBrowser
+	defer func() { openBrowser = originalBrowser }()
+	// browserWith : follows the login page URL as a browser would once the user has logged in, with the query the
+	// redirect then has
+	browserWith := func(query func(state string) url.Values) func(string) error {
+		return func(loginURL string) error {
+			parsedURL, _ := url.Parse(loginURL)
+			challenge = parsedURL.Query().Get("code_challenge")
+			assert.Equal(t, "S256", parsedURL.Query().Get("code_challenge_method"))
+			assert.Equal(t, "/auth/realms/codewind/protocol/openid-connect/auth", parsedURL.Path)
+			go http.Get(parsedURL.Query().Get("redirect_uri") + "?" + query(parsedURL.Query().Get("state")).Encode())
+			return nil
+		}
+	}
+
+	t.Run("The code of the redirect is exchanged for tokens", func(t *testing.T) {
+		openBrowser = browserWith(func(state string) url.Values {
+			return url.Values{"code": {"c0de"}, "state": {state}}
+		})
+		authToken, secErr := login.authenticate(http.DefaultClient)
+		assert.Nil(t, secErr)
+		assert.Equal(t, "access", authToken.AccessToken)
+	})
+
+	t.Run("A redirect with another state is rejected", func(t *testing.T) {
+		openBrowser = browserWith(func(state string) url.Values {
+			return url.Values{"code": {"c0de"}, "state": {"forged"}}
+		})
+		_, secErr := login.authenticate(http.DefaultClient)
+		assert.Equal(t, errOpAuthCode, secErr.Op)
+	})
+
+	t.Run("A login refused by Keycloak is an error", func(t *testing.T) {
+		openBrowser = browserWith(func(state string) url.Values {
+			return url.Values{"error": {"access_denied"}, "state": {state}}
+		})
+		_, secErr := login.authenticate(http.DefaultClient)
+		assert.Equal(t, errOpAuthCode, secErr.Op)
+		assert.Contains(t, secErr.Desc, "access_denied")
+	})
+
+	t.Run("A code Keycloak does not accept is an error", func(t *testing.T) {
+		openBrowser = browserWith(func(state string) url.Values {
+			return url.Values{"code": {"expired"}, "state": {state}}
+		})
+		_, secErr := login.authenticate(http.DefaultClient)
+		assert.Equal(t, "invalid_grant", secErr.Op)
+	})
+
+	t.Run("The login times out when there is no redirect", func(t *testing.T) {
+		originalTimeout := authCodeTimeout
+		authCodeTimeout = 100 * time.Millisecond
+		defer func() { authCodeTimeout = originalTimeout }()
+		openBrowser = func(string) error { return nil }
+		_, secErr := login.authenticate(http.DefaultClient)
+		assert.Equal(t, errOpAuthCode, secErr.Op)
+	})
+}
+
+func Test_PKCEChallenge(t *testing.T) {
+	// The example of RFC 7636 appendix B
+	assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", pkceChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
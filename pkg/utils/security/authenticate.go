@@ -14,10 +14,12 @@ package security
 import (
 	"encoding/json"
 	"errors"
+	"flag"
 	"io/ioutil"
 	"net/http"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
 	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/urfave/cli"
@@ -25,13 +27,14 @@ import (
 
 // AuthToken from the keycloak server after successfully authenticating
 type AuthToken struct {
-	AccessToken     string `json:"access_token"`
-	ExpiresIn       int    `json:"expires_in"`
-	RefreshToken    string `json:"refresh_token"`
-	TokenType       string `json:"token_type"`
-	NotBeforePolicy int    `json:"not-before-policy"`
-	SessionState    string `json:"session_state"`
-	Scope           string `json:"scope"`
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"`
+	TokenType        string `json:"token_type"`
+	NotBeforePolicy  int    `json:"not-before-policy"`
+	SessionState     string `json:"session_state"`
+	Scope            string `json:"scope"`
 }
 
 // SecAuthenticate - sends credentials to the auth server for a specific realm and returns an AuthToken
@@ -83,10 +86,14 @@ func SecAuthenticate(httpClient utils.HTTPClient, c *cli.Context, connectionReal
 		client = cliClient
 	}
 
-	// When a matching connection exist retrieve secret from the keyring
-	if connection != nil {
+	// Credentials not given on the command line are taken from the environment, then the credentials file, and
+	// for a matching connection, the password from the keyring
+	credentials := LookupCredentials(connectionID, username)
+	username = credentials.Username
+	password = credentials.Password
+	if connection != nil && password == "" && cliPassword == "" {
 		secret, secError := SecKeyGetSecret(connection.ID, username)
-		if secError != nil && cliPassword == "" {
+		if secError != nil {
 			return nil, secError
 		}
 		password = secret
@@ -156,17 +163,13 @@ func SecAuthenticate(httpClient utils.HTTPClient, c *cli.Context, connectionReal
 
 	// store access and refresh tokens in keyring if a connection is known
 	if connection != nil {
-		secErr := SecKeyUpdate(connectionID, "access_token", authToken.AccessToken)
-		if secErr != nil {
-			return &authToken, secErr
-		}
-		secErr = SecKeyUpdate(connectionID, "refresh_token", authToken.RefreshToken)
+		secErr := SecCacheTokens(connectionID, &authToken)
 		if secErr != nil {
 			return &authToken, secErr
 		}
 
-		// login successful, update users password in keyring
-		if password != "" {
+		// login successful, update users password in keyring, unless it came from the environment or credentials file
+		if cliPassword != "" {
 			secErr = SecKeyUpdate(connectionID, username, password)
 			if secErr != nil {
 				return &authToken, secErr
@@ -177,6 +180,77 @@ func SecAuthenticate(httpClient utils.HTTPClient, c *cli.Context, connectionReal
 	return &authToken, nil
 }
 
+// SecAuthenticateConnection - authenticates a user against the auth server of a connection.
+// The auth URL, realm and client are discovered from the connection's gatekeeper, and the password
+// is retrieved from the keyring when one is not supplied. A gatekeeper using OpenShift OAuth is logged
+// in to with SecAuthenticateOpenShift
+func SecAuthenticateConnection(httpClient utils.HTTPClient, connectionID string, username string, password string) (*AuthToken, *SecError) {
+	connectionID = strings.TrimSpace(strings.ToLower(connectionID))
+	credentials := LookupCredentials(connectionID, username)
+	username = credentials.Username
+	if username == "" && !connectionUsesOpenShiftOAuth(connectionID) {
+		err := errors.New(textUsernameRequired)
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+
+	connection, gatekeeperEnv, secErr := discoverConnectionAuth(httpClient, connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	if gatekeeperEnv.UsesOpenShiftOAuth() {
+		if password == "" {
+			password = credentials.Password
+		}
+		if password == "" && username != "" {
+			password, _ = SecKeyGetSecret(connection.ID, username)
+		}
+		return SecAuthenticateOpenShift(httpClient, gatekeeperEnv.AuthURL, connection.ID, username, password)
+	}
+	if username == "" {
+		err := errors.New(textUsernameRequired)
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+
+	set := flag.NewFlagSet("Authentication", 0)
+	set.String("host", gatekeeperEnv.AuthURL, "doc")
+	set.String("realm", gatekeeperEnv.Realm, "doc")
+	set.String("username", username, "doc")
+	set.String("password", password, "doc")
+	set.String("client", gatekeeperEnv.ClientID, "doc")
+	set.String("conid", connection.ID, "doc")
+	c := cli.NewContext(nil, set, nil)
+	return SecAuthenticate(httpClient, c, "", "")
+}
+
+// connectionUsesOpenShiftOAuth : whether the gatekeeper of a connection used OpenShift OAuth when it was last seen,
+// which OpenShift logins need no username for
+func connectionUsesOpenShiftOAuth(connectionID string) bool {
+	connection, conErr := connections.GetConnectionByID(connectionID)
+	return conErr == nil && connection.AuthProvider == apiroutes.AuthProviderOpenShift
+}
+
+// discoverConnectionAuth : the remote connection with the auth server, realm and client discovered from its gatekeeper
+func discoverConnectionAuth(httpClient utils.HTTPClient, connectionID string) (*connections.Connection, *apiroutes.GatekeeperEnvironment, *SecError) {
+	connection, conErr := connections.GetConnectionByID(connectionID)
+	if conErr != nil {
+		return nil, nil, &SecError{errOpConConfig, conErr.Err, conErr.Desc}
+	}
+	if connections.IsLocal(connection.ID) || connection.URL == "" {
+		err := errors.New("Connection " + strings.ToUpper(connectionID) + " is local and does not use authentication")
+		return nil, nil, &SecError{errOpConConfig, err, err.Error()}
+	}
+
+	gatekeeperEnv, err := apiroutes.GetGatekeeperEnvironment(httpClient, connection.URL)
+	if err == nil && (gatekeeperEnv.AuthURL == "" || (gatekeeperEnv.Realm == "" && !gatekeeperEnv.UsesOpenShiftOAuth()) || gatekeeperEnv.ClientID == "") {
+		err = errors.New("gatekeeper environment is incomplete")
+	}
+	if err != nil {
+		discoveryErr := errors.New("Unable to discover the auth server of connection " + strings.ToUpper(connectionID) + " from " + connection.URL + ": " + err.Error())
+		return nil, nil, &SecError{errOpDiscovery, discoveryErr, discoveryErr.Error()}
+	}
+	return connection, gatekeeperEnv, nil
+}
+
 // SecRefreshAccessToken : Obtain an access token using a refresh token
 func SecRefreshAccessToken(httpClient utils.HTTPClient, connection *connections.Connection, refreshToken string) (*AuthToken, *SecError) {
 
@@ -214,22 +288,19 @@ func SecRefreshAccessToken(httpClient utils.HTTPClient, connection *connections.
 	// Parse and return AuthToken
 	authToken := AuthToken{}
 	err = json.Unmarshal([]byte(body), &authToken)
-
 	if err != nil {
-		// re-save the access and refresh token
-		secErr := SecKeyUpdate(connection.ID, "access_token", authToken.AccessToken)
-		if secErr != nil {
-			return &authToken, secErr
-		}
-		secErr = SecKeyUpdate(connection.ID, "refresh_token", authToken.RefreshToken)
-
-		if secErr != nil {
-			return &authToken, secErr
-		}
+		return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
 
-		respErr := errors.New(string(body))
-		return nil, &SecError{errOpResponse, respErr, respErr.Error()}
+	// Keycloak may keep the session's refresh token rather than issue a new one
+	if authToken.RefreshToken == "" {
+		authToken.RefreshToken = refreshToken
 	}
 
+	// re-save the access and refresh token
+	secErr := SecCacheTokens(connection.ID, &authToken)
+	if secErr != nil {
+		return &authToken, secErr
+	}
 	return &authToken, nil
 }
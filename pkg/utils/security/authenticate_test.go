@@ -17,12 +17,10 @@ import (
 	"flag"
 	"io/ioutil"
 	"net/http"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/urfave/cli"
-	"github.com/zalando/go-keyring"
 )
 
 func Test_Authenticate(t *testing.T) {
@@ -77,7 +75,24 @@ func Test_Authenticate(t *testing.T) {
 
 	t.Run("Cleanup stored access_token and refresh_token", func(t *testing.T) {
 		// Clean up test entries
-		keyring.Delete(strings.ToLower(KeyringServiceName+"."+testConnection), "access_token")
-		keyring.Delete(strings.ToLower(KeyringServiceName+"."+testConnection), "refresh_token")
+		SecKeyringDelete(testConnection, "")
+	})
+}
+
+func Test_AuthenticateConnection(t *testing.T) {
+	t.Run("Expect failure - username is required", func(t *testing.T) {
+		_, secError := SecAuthenticateConnection(http.DefaultClient, "local", "", "")
+		assert.Equal(t, errOpCLICommand, secError.Op)
+	})
+
+	t.Run("Expect failure - connection must exist", func(t *testing.T) {
+		_, secError := SecAuthenticateConnection(http.DefaultClient, "unknownconnection", testUsername, "")
+		assert.Equal(t, errOpConConfig, secError.Op)
+	})
+
+	t.Run("Expect failure - local connection does not use authentication", func(t *testing.T) {
+		_, secError := SecAuthenticateConnection(http.DefaultClient, "local", testUsername, "")
+		assert.Equal(t, errOpConConfig, secError.Op)
+		assert.Contains(t, secError.Desc, "does not use authentication")
 	})
 }
@@ -0,0 +1,122 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+)
+
+// The environment variables credentials are given by where there is no keyring, such as in CI. The access token
+// is only used for the connection named by CW_CONNECTION, so it is never sent to another Codewind
+const (
+	EnvUsername    = "CW_USERNAME"
+	EnvPassword    = "CW_PASSWORD"
+	EnvAccessToken = "CW_ACCESS_TOKEN"
+	EnvConnection  = "CW_CONNECTION"
+)
+
+// Credentials : what a connection is authenticated with, as given by the environment or the credentials file
+type Credentials struct {
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	AccessToken string `json:"accessToken,omitempty"`
+}
+
+// CredentialsFile : the credentials file, with the credentials of each connection by connection ID
+type CredentialsFile struct {
+	Connections map[string]Credentials `json:"connections"`
+}
+
+// LookupCredentials : The credentials of a connection, from the username given on the command line, then the
+// environment, then the entry of the connection in the credentials file. A password or access token is only taken
+// from a source which names no username or the same username, so it is never sent for another user. An empty
+// password means the one saved in the keyring is to be used
+func LookupCredentials(connectionID string, username string) Credentials {
+	sources := []Credentials{environmentCredentials(connectionID), fileCredentials(connectionID)}
+	credentials := Credentials{Username: strings.TrimSpace(strings.ToLower(username))}
+	for _, source := range sources {
+		if credentials.Username == "" {
+			credentials.Username = source.Username
+		}
+	}
+	for _, source := range sources {
+		sameUser := source.Username == "" || source.Username == credentials.Username
+		if credentials.Password == "" && source.Password != "" && sameUser {
+			credentials.Password = source.Password
+		}
+		if credentials.AccessToken == "" && source.AccessToken != "" && sameUser {
+			credentials.AccessToken = source.AccessToken
+		}
+	}
+	return credentials
+}
+
+// environmentCredentials : the credentials given by CW_USERNAME, CW_PASSWORD and CW_ACCESS_TOKEN, without the
+// access token unless CW_CONNECTION names the connection
+func environmentCredentials(connectionID string) Credentials {
+	credentials := Credentials{
+		Username: strings.TrimSpace(strings.ToLower(os.Getenv(EnvUsername))),
+		Password: strings.TrimSpace(os.Getenv(EnvPassword)),
+	}
+	tokenConnection := strings.TrimSpace(os.Getenv(EnvConnection))
+	if tokenConnection != "" && strings.EqualFold(tokenConnection, strings.TrimSpace(connectionID)) {
+		credentials.AccessToken = strings.TrimSpace(os.Getenv(EnvAccessToken))
+	} else if os.Getenv(EnvAccessToken) != "" {
+		logr.Debugf("Not using %v for connection %v, as %v does not name it", EnvAccessToken, connectionID, EnvConnection)
+	}
+	return credentials
+}
+
+// fileCredentials : the entry of a connection in the credentials file, empty when there is none or the file
+// cannot be read
+func fileCredentials(connectionID string) Credentials {
+	connectionID = strings.TrimSpace(strings.ToLower(connectionID))
+	if connectionID == "" {
+		return Credentials{}
+	}
+	filename := cliconfig.GetCredentialsFilename()
+	info, err := os.Stat(filename)
+	if err != nil {
+		return Credentials{}
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		logr.Warnf("The credentials file %v can be read by other users, restrict it with chmod 600\n", filename)
+	}
+	body, err := ioutil.ReadFile(filename)
+	if err != nil {
+		logr.Warnf("Unable to read the credentials file %v: %v\n", filename, err)
+		return Credentials{}
+	}
+	file := CredentialsFile{}
+	err = json.Unmarshal(body, &file)
+	if err != nil {
+		logr.Warnf("Unable to parse the credentials file %v: %v\n", filename, err)
+		return Credentials{}
+	}
+	for id, credentials := range file.Connections {
+		if strings.EqualFold(strings.TrimSpace(id), connectionID) {
+			return Credentials{
+				Username:    strings.TrimSpace(strings.ToLower(credentials.Username)),
+				Password:    strings.TrimSpace(credentials.Password),
+				AccessToken: strings.TrimSpace(credentials.AccessToken),
+			}
+		}
+	}
+	return Credentials{}
+}
@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LookupCredentials(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-credentials")
+	defer os.RemoveAll(dir)
+	credentialsFile := filepath.Join(dir, "credentials.json")
+	ioutil.WriteFile(credentialsFile, []byte(`{"connections": {
+		"REMOTE1": {"username": "Developer", "password": "filepass", "accessToken": "filetoken"},
+		"remote2": {"password": "anypass"}
+	}}`), 0600)
+	os.Setenv("CW_CREDENTIALS_FILE", credentialsFile)
+	defer os.Unsetenv("CW_CREDENTIALS_FILE")
+	for _, name := range []string{EnvUsername, EnvPassword, EnvAccessToken, EnvConnection} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+
+	t.Run("The entry of the connection in the credentials file is used", func(t *testing.T) {
+		assert.Equal(t, Credentials{Username: "developer", Password: "filepass", AccessToken: "filetoken"}, LookupCredentials("remote1", ""))
+		assert.Equal(t, Credentials{}, LookupCredentials("remote3", ""))
+	})
+
+	t.Run("The password and access token of another user are not used", func(t *testing.T) {
+		assert.Equal(t, Credentials{Username: "admin"}, LookupCredentials("remote1", "admin"))
+		assert.Equal(t, Credentials{Username: "admin", Password: "anypass"}, LookupCredentials("remote2", "admin"))
+	})
+
+	t.Run("The environment takes precedence over the credentials file", func(t *testing.T) {
+		os.Setenv(EnvUsername, "developer")
+		os.Setenv(EnvPassword, "envpass")
+		os.Setenv(EnvAccessToken, "envtoken")
+		os.Setenv(EnvConnection, "REMOTE1")
+		assert.Equal(t, Credentials{Username: "developer", Password: "envpass", AccessToken: "envtoken"}, LookupCredentials("remote1", ""))
+	})
+
+	t.Run("The access token of the environment is only used for the connection it names", func(t *testing.T) {
+		assert.Equal(t, Credentials{Username: "developer", Password: "envpass"}, LookupCredentials("remote3", ""))
+		os.Unsetenv(EnvConnection)
+		assert.Equal(t, Credentials{Username: "developer", Password: "envpass", AccessToken: "filetoken"}, LookupCredentials("remote1", ""))
+		os.Setenv(EnvConnection, "remote1")
+	})
+
+	t.Run("The username given takes precedence over the environment", func(t *testing.T) {
+		os.Setenv(EnvUsername, "ci")
+		assert.Equal(t, Credentials{Username: "developer", Password: "filepass", AccessToken: "filetoken"}, LookupCredentials("remote1", "Developer"))
+	})
+}
@@ -0,0 +1,149 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// Edit modes of a Keycloak user federation provider
+const (
+	FederationReadOnly = "READ_ONLY"
+	FederationWritable = "WRITABLE"
+	FederationUnsynced = "UNSYNCED"
+)
+
+// UserFederation : A user storage provider, such as LDAP, that the users of a realm are read from
+type UserFederation struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	ProviderID string              `json:"providerId"`
+	Config     map[string][]string `json:"config"`
+}
+
+// EditMode : whether changes to users are written back to the provider, stored only in Keycloak, or refused
+func (f UserFederation) EditMode() string {
+	return f.configValue("editMode")
+}
+
+// SyncRegistrations : whether users created in Keycloak are also created in the provider
+func (f UserFederation) SyncRegistrations() bool {
+	return strings.EqualFold(f.configValue("syncRegistrations"), "true")
+}
+
+func (f UserFederation) configValue(key string) string {
+	if values := f.Config[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// FederationSyncResult : what a sync of one user federation provider did
+type FederationSyncResult struct {
+	Provider string `json:"provider"`
+	Ignored  bool   `json:"ignored"`
+	Added    int    `json:"added"`
+	Updated  int    `json:"updated"`
+	Removed  int    `json:"removed"`
+	Failed   int    `json:"failed"`
+	Status   string `json:"status"`
+}
+
+// SecUserSync : Asks Keycloak to sync the users of every federation provider of the realm, importing users
+// changed since the last sync, or all users when full is set
+func SecUserSync(httpClient utils.HTTPClient, c *cli.Context) ([]FederationSyncResult, *SecError) {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return nil, secErr
+	}
+	providers, secErr := getUserFederation(httpClient, c, accesstoken)
+	if secErr != nil {
+		return nil, secErr
+	}
+	if len(providers) == 0 {
+		err := errors.New("Realm " + strings.TrimSpace(c.String("realm")) + " has no user federation to sync")
+		return nil, &SecError{errOpNotFound, err, err.Error()}
+	}
+	action := "triggerChangedUsersSync"
+	if c.Bool("full") {
+		action = "triggerFullSync"
+	}
+	results := []FederationSyncResult{}
+	for _, provider := range providers {
+		body, secErr := sendAdminRequest(httpClient, c, accesstoken, "POST", "/user-storage/"+provider.ID+"/sync?action="+action, nil, http.StatusOK)
+		if secErr != nil {
+			return nil, secErr
+		}
+		result := FederationSyncResult{}
+		err := json.Unmarshal(body, &result)
+		if err != nil {
+			return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+		}
+		result.Provider = provider.Name
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// getUserFederation : the user federation providers of the realm, none when its users are all stored in Keycloak
+func getUserFederation(httpClient utils.HTTPClient, c *cli.Context, accesstoken string) ([]UserFederation, *SecError) {
+	body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/components?type=org.keycloak.storage.UserStorageProvider", nil, http.StatusOK)
+	if secErr != nil {
+		return nil, secErr
+	}
+	providers := []UserFederation{}
+	err := json.Unmarshal(body, &providers)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
+	return providers, nil
+}
+
+// checkUserCreatable : Keycloak refuses to create a user when a provider which creates new users in the
+// directory is read only, so report that plainly instead of the error Keycloak gives
+func checkUserCreatable(httpClient utils.HTTPClient, c *cli.Context, accesstoken string) *SecError {
+	providers, secErr := getUserFederation(httpClient, c, accesstoken)
+	if secErr != nil {
+		return secErr
+	}
+	for _, provider := range providers {
+		if provider.SyncRegistrations() && provider.EditMode() != FederationWritable {
+			err := errors.New("Users of realm " + strings.TrimSpace(c.String("realm")) + " are created in the read only " + provider.ProviderID + " provider '" + provider.Name + "'. Create the user in the directory, then run 'cwctl secuser sync'")
+			return &SecError{errOpFederated, err, err.Error()}
+		}
+	}
+	return nil
+}
+
+// checkUserWritable : a user imported from a read only provider can only be changed in the directory
+func checkUserWritable(httpClient utils.HTTPClient, c *cli.Context, accesstoken string, user *RegisteredUser) *SecError {
+	if user.FederationLink == "" {
+		return nil
+	}
+	providers, secErr := getUserFederation(httpClient, c, accesstoken)
+	if secErr != nil {
+		return secErr
+	}
+	for _, provider := range providers {
+		if provider.ID == user.FederationLink && provider.EditMode() == FederationReadOnly {
+			err := errors.New("User " + user.Username + " is read from the read only " + provider.ProviderID + " provider '" + provider.Name + "'. Change the user in the directory, then run 'cwctl secuser sync'")
+			return &SecError{errOpFederated, err, err.Error()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"flag"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const mockLDAPProvider = `[{"id":"ldap1","name":"corp-ldap","providerId":"ldap","config":{"editMode":["READ_ONLY"],"syncRegistrations":["true"]}}]`
+
+func Test_UserFederation(t *testing.T) {
+	set := flag.NewFlagSet("tests", 0)
+	set.String("host", "https://mockserver", "doc")
+	set.String("realm", "codewind", "doc")
+	set.String("accesstoken", "admintoken", "doc")
+	set.Bool("full", false, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	t.Run("Refuses to create users in a read only directory", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{mockResponse(http.StatusOK, mockLDAPProvider)}}
+		secErr := checkUserCreatable(mockClient, c, "admintoken")
+		assert.Equal(t, errOpFederated, secErr.Op)
+		assert.Equal(t, "/auth/admin/realms/codewind/components", mockClient.requests[0].URL.Path)
+	})
+
+	t.Run("Allows users to be created in a realm without federation", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{mockResponse(http.StatusOK, `[]`)}}
+		assert.Nil(t, checkUserCreatable(mockClient, c, "admintoken"))
+	})
+
+	t.Run("Refuses to change a user imported from a read only directory", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{mockResponse(http.StatusOK, mockLDAPProvider)}}
+		secErr := checkUserWritable(mockClient, c, "admintoken", &RegisteredUser{ID: "1", Username: "developer", FederationLink: "ldap1"})
+		assert.Equal(t, errOpFederated, secErr.Op)
+	})
+
+	t.Run("Allows local users to be changed without looking up federation", func(t *testing.T) {
+		mockClient := &clientMockSequence{}
+		assert.Nil(t, checkUserWritable(mockClient, c, "admintoken", &RegisteredUser{ID: "1", Username: "developer"}))
+		assert.Empty(t, mockClient.requests)
+	})
+
+	t.Run("Syncs the users changed in each provider", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, mockLDAPProvider),
+			mockResponse(http.StatusOK, `{"ignored":false,"added":2,"updated":1,"removed":0,"failed":0,"status":"2 imported users, 1 updated users"}`),
+		}}
+		results, secErr := SecUserSync(mockClient, c)
+		assert.Nil(t, secErr)
+		assert.Equal(t, []FederationSyncResult{{Provider: "corp-ldap", Added: 2, Updated: 1, Status: "2 imported users, 1 updated users"}}, results)
+		assert.Equal(t, "action=triggerChangedUsersSync", mockClient.requests[1].URL.RawQuery)
+	})
+
+	t.Run("Reports a realm with nothing to sync", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{mockResponse(http.StatusOK, `[]`)}}
+		_, secErr := SecUserSync(mockClient, c)
+		assert.Equal(t, errOpNotFound, secErr.Op)
+	})
+}
@@ -12,9 +12,16 @@
 package security
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
 	"github.com/eclipse/codewind-installer/pkg/utils/connections"
 	"github.com/zalando/go-keyring"
 )
@@ -25,7 +32,28 @@ type KeyringSecret struct {
 	ClientID string `json:"clientId"`
 }
 
-// SecKeyUpdate : Creates or updates a key in the platforms keyring
+// Kinds of secret kept in the keyring for a connection
+const (
+	KeyringKindPassword = "password"
+	KeyringKindToken    = "token"
+	KeyringKindSession  = "session"
+	KeyringKindRegistry = "registry"
+)
+
+// KeyringEntry : A secret kept in the keyring for a connection, without the secret itself. Secrets are kept under a
+// service named by the connection's URL, and an account naming the user, so that a connection recreated with the
+// same ID for another Codewind, or another user of the same Codewind, never finds them
+type KeyringEntry struct {
+	ConnectionID string `json:"connectionId"`
+	URL          string `json:"url"`
+	Username     string `json:"username,omitempty"`
+	Kind         string `json:"kind"`
+	Name         string `json:"name,omitempty"`
+	Service      string `json:"service"`
+	Account      string `json:"account"`
+}
+
+// SecKeyUpdate : Creates or updates a key in the platforms keyring, or the encrypted secrets file when there is no keyring
 func SecKeyUpdate(connectionID string, username string, password string) *SecError {
 
 	conID := strings.TrimSpace(strings.ToLower(connectionID))
@@ -33,28 +61,233 @@ func SecKeyUpdate(connectionID string, username string, password string) *SecErr
 	pass := strings.TrimSpace(password)
 
 	// check connection has been registered
-	_, conErr := connections.GetConnectionByID(conID)
+	connection, conErr := connections.GetConnectionByID(conID)
 	if conErr != nil {
 		err := errors.New("Connection " + strings.ToUpper(conID) + " not found")
 		return &SecError{errOpNotFound, err, conErr.Error()}
 	}
-
-	err := keyring.Set(KeyringServiceName+"."+conID, uName, pass)
-	if err != nil {
-		return &SecError{errOpKeyring, err, err.Error()}
-	}
-	return nil
+	return setConnectionSecret(newKeyringEntry(connection, KeyringKindPassword, uName, ""), pass)
 }
 
-// SecKeyGetSecret : retrieve secret / credentials from the keyring
+// SecKeyGetSecret : retrieve secret / credentials from the keyring. A password kept by an older cwctl under the
+// connection ID is moved to the namespace of the connection's URL
 func SecKeyGetSecret(connectionID string, username string) (string, *SecError) {
 
 	conID := strings.TrimSpace(strings.ToLower(connectionID))
 	uName := strings.TrimSpace(strings.ToLower(username))
 
-	secret, err := keyring.Get(KeyringServiceName+"."+conID, uName)
+	connection, conErr := connections.GetConnectionByID(conID)
+	if conErr != nil {
+		err := errors.New("Connection " + strings.ToUpper(conID) + " not found")
+		return "", &SecError{errOpNotFound, err, conErr.Error()}
+	}
+	entry := newKeyringEntry(connection, KeyringKindPassword, uName, "")
+	secret, err := GetSecret(entry.Service, entry.Account)
+	if err == keyring.ErrNotFound {
+		secret, err = migrateLegacySecret(entry, uName)
+	}
 	if err != nil {
 		return "", &SecError{errOpKeyring, err, err.Error()}
 	}
 	return secret, nil
 }
+
+// SecKeyringList : The secrets kept in the keyring for a connection, or for every connection when connectionID is empty
+func SecKeyringList(connectionID string) ([]KeyringEntry, *SecError) {
+	conID := strings.TrimSpace(connectionID)
+	entries := []KeyringEntry{}
+	for _, entry := range loadKeyringIndex() {
+		if conID == "" || strings.EqualFold(entry.ConnectionID, conID) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ConnectionID != b.ConnectionID {
+			return a.ConnectionID < b.ConnectionID
+		}
+		if a.Username != b.Username {
+			return a.Username < b.Username
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+	return entries, nil
+}
+
+// SecKeyringDelete : Removes the secrets kept in the keyring for a connection, or only those of one user of it when
+// username is given, including any kept by an older cwctl under the connection ID. Returns the entries removed
+func SecKeyringDelete(connectionID string, username string) ([]KeyringEntry, *SecError) {
+	conID := strings.TrimSpace(connectionID)
+	uName := strings.TrimSpace(strings.ToLower(username))
+	if conID == "" {
+		err := errors.New(textInvalidOptions)
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+	entries, secErr := SecKeyringList(conID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	deleted := []KeyringEntry{}
+	for _, entry := range entries {
+		if uName != "" && entry.Username != uName {
+			continue
+		}
+		secErr = deleteConnectionSecret(entry)
+		if secErr != nil {
+			return deleted, secErr
+		}
+		deleted = append(deleted, entry)
+	}
+	legacyAccounts := []string{"access_token", "refresh_token", tokenExpiryKey}
+	if uName != "" {
+		legacyAccounts = []string{uName}
+	}
+	for _, account := range legacyAccounts {
+		DeleteSecret(legacyKeyringService(conID), account)
+	}
+	return deleted, nil
+}
+
+// newKeyringEntry : the entry a secret of a connection is kept under. Token entries are named by the token, and
+// registry entries, which belong to no user, by the address of the registry
+func newKeyringEntry(connection *connections.Connection, kind string, username string, name string) KeyringEntry {
+	entry := KeyringEntry{
+		ConnectionID: connection.ID,
+		URL:          connection.URL,
+		Username:     username,
+		Kind:         kind,
+		Name:         name,
+		Service:      connectionKeyringService(connection),
+	}
+	switch kind {
+	case KeyringKindPassword:
+		entry.Account = kind + ":" + username
+	case KeyringKindToken:
+		entry.Account = kind + ":" + username + ":" + name
+	case KeyringKindRegistry:
+		entry.Account = kind + ":" + name
+	default:
+		entry.Account = kind
+	}
+	return entry
+}
+
+// connectionKeyringService : the keyring service of the secrets of a connection, its URL without the scheme, or for
+// a connection without a URL such as local, its ID
+func connectionKeyringService(connection *connections.Connection) string {
+	namespace := strings.ToLower(strings.TrimRight(strings.TrimSpace(connection.URL), "/"))
+	if i := strings.Index(namespace, "://"); i >= 0 {
+		namespace = namespace[i+3:]
+	}
+	if namespace == "" {
+		namespace = strings.ToLower(connection.ID)
+	}
+	return KeyringServiceName + "/" + namespace
+}
+
+// legacyKeyringService : the keyring service older versions of cwctl kept the secrets of a connection under
+func legacyKeyringService(connectionID string) string {
+	return KeyringServiceName + "." + strings.TrimSpace(strings.ToLower(connectionID))
+}
+
+// migrateLegacySecret : Moves a secret kept by an older cwctl under legacyAccount to entry, returning it
+func migrateLegacySecret(entry KeyringEntry, legacyAccount string) (string, error) {
+	secret, err := GetSecret(legacyKeyringService(entry.ConnectionID), legacyAccount)
+	if err != nil {
+		return "", err
+	}
+	if setConnectionSecret(entry, secret) == nil {
+		DeleteSecret(legacyKeyringService(entry.ConnectionID), legacyAccount)
+	}
+	return secret, nil
+}
+
+func setConnectionSecret(entry KeyringEntry, secret string) *SecError {
+	return updateKeyringIndex(func(index map[string]KeyringEntry) error {
+		err := SetSecret(entry.Service, entry.Account, secret)
+		if err != nil {
+			return err
+		}
+		index[keyringIndexKey(entry)] = entry
+		return nil
+	})
+}
+
+func deleteConnectionSecret(entry KeyringEntry) *SecError {
+	return updateKeyringIndex(func(index map[string]KeyringEntry) error {
+		err := DeleteSecret(entry.Service, entry.Account)
+		if err != nil && err != keyring.ErrNotFound {
+			return err
+		}
+		delete(index, keyringIndexKey(entry))
+		return nil
+	})
+}
+
+func keyringIndexKey(entry KeyringEntry) string {
+	return entry.Service + "\n" + entry.Account
+}
+
+// updateKeyringIndex : Changes the keyring and the index of its entries while holding the keyring lock, so that
+// concurrent cwctl processes neither lose entries from the index nor from the encrypted secrets file. Platform
+// keyrings cannot be listed, so the index is what seckeyring list reads
+func updateKeyringIndex(change func(index map[string]KeyringEntry) error) *SecError {
+	indexFile := cliconfig.GetKeyringIndexFilename()
+	os.MkdirAll(filepath.Dir(indexFile), 0777)
+	unlock, err := connections.LockFile(indexFile+".lock", indexFile)
+	if err != nil {
+		return &SecError{errOpKeyring, err, err.Error()}
+	}
+	defer unlock()
+	index := map[string]KeyringEntry{}
+	for _, entry := range loadKeyringIndex() {
+		index[keyringIndexKey(entry)] = entry
+	}
+	err = change(index)
+	if err != nil {
+		return &SecError{errOpKeyring, err, err.Error()}
+	}
+	entries := []KeyringEntry{}
+	for _, entry := range index {
+		entries = append(entries, entry)
+	}
+	body, _ := json.MarshalIndent(entries, "", "\t")
+	err = ioutil.WriteFile(indexFile, body, 0600)
+	if err != nil {
+		return &SecError{errOpKeyring, err, err.Error()}
+	}
+	return nil
+}
+
+// loadKeyringIndex : the entries recorded in the keyring index, none when it is missing or unreadable
+func loadKeyringIndex() []KeyringEntry {
+	entries := []KeyringEntry{}
+	body, err := ioutil.ReadFile(cliconfig.GetKeyringIndexFilename())
+	if err != nil || json.Unmarshal(body, &entries) != nil {
+		return []KeyringEntry{}
+	}
+	return entries
+}
+
+// tokenUsername : the user an access token was issued to, from its preferred_username claim, empty when it cannot
+// be read. The token is not verified, as it only names where the token is kept
+func tokenUsername(accessToken string) string {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	claims := struct {
+		PreferredUsername string `json:"preferred_username"`
+	}{}
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	return strings.ToLower(claims.PreferredUsername)
+}
@@ -16,7 +16,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/zalando/go-keyring"
 )
 
 const testPassword = "pAss%-w0rd-&'cha*s"
@@ -25,7 +24,7 @@ const testPasswordUpdated = "pAss%-w0rd-&'cha*s-with_more_chars"
 func Test_Keychain(t *testing.T) {
 
 	// remove test key if one exists
-	keyring.Delete(strings.ToLower(KeyringServiceName+"."+testConnection), testUsername)
+	SecKeyringDelete(testConnection, testUsername)
 
 	t.Run("Secret can not be retrieved for an unknown account", func(t *testing.T) {
 		retrievedSecret, err := SecKeyGetSecret(testConnection, testUsername)
@@ -65,11 +64,59 @@ func Test_Keychain(t *testing.T) {
 		assert.Equal(t, testPasswordUpdated, storedSecret)
 	})
 
-	t.Run("Test keyring entry can be removed", func(t *testing.T) {
-		err := keyring.Delete(strings.ToLower(KeyringServiceName+"."+testConnection), testUsername)
-		if err != nil {
-			t.Fail()
+	t.Run("The entry is listed without its secret", func(t *testing.T) {
+		entries, secErr := SecKeyringList(testConnection)
+		assert.Nil(t, secErr)
+		var password *KeyringEntry
+		for i := range entries {
+			if entries[i].Kind == KeyringKindPassword && entries[i].Username == testUsername {
+				password = &entries[i]
+			}
 		}
+		assert.NotNil(t, password)
+		assert.Equal(t, KeyringServiceName+"/local", password.Service)
+		assert.Equal(t, "password:"+testUsername, password.Account)
+	})
+
+	t.Run("Test keyring entry can be removed", func(t *testing.T) {
+		deleted, secErr := SecKeyringDelete(testConnection, testUsername)
+		assert.Nil(t, secErr)
+		assert.Equal(t, 1, len(deleted))
+		_, secErr = SecKeyGetSecret(testConnection, testUsername)
+		assert.NotNil(t, secErr)
 	})
 
+	t.Run("A password kept under the connection ID by an older cwctl is moved", func(t *testing.T) {
+		assert.Nil(t, SetSecret(strings.ToLower(KeyringServiceName+"."+testConnection), testUsername, testPassword))
+		storedSecret, secErr := SecKeyGetSecret(testConnection, testUsername)
+		assert.Nil(t, secErr)
+		assert.Equal(t, testPassword, storedSecret)
+		_, err := GetSecret(strings.ToLower(KeyringServiceName+"."+testConnection), testUsername)
+		assert.NotNil(t, err)
+		SecKeyringDelete(testConnection, testUsername)
+	})
+
+	t.Run("Registry credentials kept under the connection ID by an older cwctl are moved and listed", func(t *testing.T) {
+		legacyService := KeyringServiceName + ".registry." + strings.ToLower(testConnection)
+		assert.Nil(t, SetSecret(legacyService, "docker.io", `{"username":"dev","password":"secret"}`))
+		credentials, secErr := SecRegistrySecretGet(testConnection, "docker.io")
+		assert.Nil(t, secErr)
+		assert.Equal(t, RegistryCredentials{Username: "dev", Password: "secret"}, *credentials)
+		_, err := GetSecret(legacyService, "docker.io")
+		assert.NotNil(t, err)
+
+		entries, _ := SecKeyringList(testConnection)
+		var registry *KeyringEntry
+		for i := range entries {
+			if entries[i].Kind == KeyringKindRegistry {
+				registry = &entries[i]
+			}
+		}
+		assert.NotNil(t, registry)
+		assert.Equal(t, "registry:docker.io", registry.Account)
+		_, secErr = SecKeyringDelete(testConnection, "")
+		assert.Nil(t, secErr)
+		_, secErr = SecRegistrySecretGet(testConnection, "docker.io")
+		assert.NotNil(t, secErr)
+	})
 }
@@ -0,0 +1,101 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	logr "github.com/sirupsen/logrus"
+)
+
+// SecLogout : Ends the session of a connection. The refresh token is revoked by Keycloak and the cached tokens are
+// removed from the keyring. When a username is given its saved password and the registry credentials of the
+// connection are removed too, so that cwctl cannot log back in without them. The keyring is cleared even if Keycloak
+// cannot be reached
+func SecLogout(httpClient utils.HTTPClient, connectionID string, username string) *SecError {
+	connectionID = strings.TrimSpace(strings.ToLower(connectionID))
+	connection, conErr := connections.GetConnectionByID(connectionID)
+	if conErr != nil {
+		return &SecError{errOpConConfig, conErr.Err, conErr.Desc}
+	}
+	if connections.IsLocal(connection.ID) || connection.URL == "" {
+		err := errors.New("Connection " + strings.ToUpper(connectionID) + " is local and does not use authentication")
+		return &SecError{errOpConConfig, err, err.Error()}
+	}
+
+	var revokeErr *SecError
+	refreshToken := SecGetCachedTokens(connectionID).RefreshToken
+	if refreshToken != "" {
+		revokeErr = revokeRefreshToken(httpClient, connection, refreshToken)
+	}
+
+	session := newKeyringEntry(connection, KeyringKindSession, "", "")
+	if sessionUser, err := GetSecret(session.Service, session.Account); err == nil {
+		secErr := deleteSessionTokens(connection, sessionUser)
+		if secErr == nil {
+			secErr = deleteConnectionSecret(session)
+		}
+		if secErr != nil {
+			return secErr
+		}
+	}
+	if strings.TrimSpace(username) != "" {
+		secErr := deleteConnectionSecret(newKeyringEntry(connection, KeyringKindPassword, strings.TrimSpace(strings.ToLower(username)), ""))
+		if secErr != nil {
+			return secErr
+		}
+		for _, entry := range loadKeyringIndex() {
+			if entry.Kind == KeyringKindRegistry && entry.Service == session.Service {
+				secErr = deleteConnectionSecret(entry)
+				if secErr != nil {
+					return secErr
+				}
+			}
+		}
+	}
+	return revokeErr
+}
+
+// revokeRefreshToken : ends the Keycloak session the refresh token belongs to
+func revokeRefreshToken(httpClient utils.HTTPClient, connection *connections.Connection, refreshToken string) *SecError {
+	logoutURL := connection.AuthURL + "/auth/realms/" + connection.Realm + "/protocol/openid-connect/logout"
+	payload := url.Values{"client_id": {connection.ClientID}, "refresh_token": {refreshToken}}
+	req, err := http.NewRequest("POST", logoutURL, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return &SecError{errOpConnection, err, err.Error()}
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Cache-Control", "no-cache")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return &SecError{errOpConnection, err, err.Error()}
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+
+	switch httpCode := res.StatusCode; {
+	case httpCode == http.StatusBadRequest:
+		// The refresh token has already expired or been revoked, so there is no session to end
+		logr.Debugf("Refresh token was not accepted by Keycloak: %v", string(body))
+	case httpCode != http.StatusOK && httpCode != http.StatusNoContent:
+		err = errors.New(string(body))
+		return &SecError{errOpResponse, err, err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Logout(t *testing.T) {
+	connection := &connections.Connection{ID: "remote", AuthURL: "https://mockserver", Realm: "codewind", ClientID: "codewind-cli"}
+
+	t.Run("The local connection has no session to end", func(t *testing.T) {
+		secErr := SecLogout(nil, "local", "")
+		assert.Equal(t, errOpConConfig, secErr.Op)
+	})
+
+	t.Run("A revoked refresh token ends the session", func(t *testing.T) {
+		mockClient := &ClientMockAuthenticate{StatusCode: http.StatusNoContent, Body: ioutil.NopCloser(bytes.NewReader([]byte{}))}
+		assert.Nil(t, revokeRefreshToken(mockClient, connection, "token"))
+	})
+
+	t.Run("An expired refresh token has no session to end", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"error":"invalid_grant"}`)))
+		mockClient := &ClientMockAuthenticate{StatusCode: http.StatusBadRequest, Body: body}
+		assert.Nil(t, revokeRefreshToken(mockClient, connection, "token"))
+	})
+
+	t.Run("Other responses are errors", func(t *testing.T) {
+		body := ioutil.NopCloser(bytes.NewReader([]byte("unavailable")))
+		mockClient := &ClientMockAuthenticate{StatusCode: http.StatusServiceUnavailable, Body: body}
+		secErr := revokeRefreshToken(mockClient, connection, "token")
+		assert.Equal(t, errOpResponse, secErr.Op)
+		assert.Equal(t, "unavailable", secErr.Desc)
+	})
+}
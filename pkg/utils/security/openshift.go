@@ -0,0 +1,164 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/eclipse/codewind-installer/pkg/utils/remote/kube"
+	"k8s.io/client-go/kubernetes"
+)
+
+// openShiftChallengingClient : the OAuth client every OpenShift cluster has for command line tools, which issues
+// tokens for a username and password given with basic auth rather than through a login page
+const openShiftChallengingClient = "openshift-challenging-client"
+
+// kubeconfigToken : the bearer token of the Kubernetes context of a connection, the one recorded for it or else the
+// current one, and the URL of the OAuth server its cluster advertises. Replaced in tests
+var kubeconfigToken = func(connectionID string) (string, string, error) {
+	if connectionID != "" {
+		connections.SelectConnectionKubeContext(connectionID)
+	}
+	config, err := kube.GetKubeClientConfig().ClientConfig()
+	if err != nil {
+		return "", "", err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return "", "", err
+	}
+	issuer, err := kube.DiscoverOAuthServer(clientset)
+	if err != nil {
+		return "", "", err
+	}
+	return config.BearerToken, issuer, nil
+}
+
+// SecAuthenticateOpenShift : Obtains a token for a connection whose gatekeeper uses the OAuth server of its OpenShift
+// cluster at authURL. With a username and password the token is requested from the OAuth server, otherwise the
+// token of the Kubernetes context of the connection, as set by `oc login`, is used, but only when its cluster
+// advertises the OAuth server at authURL, so that a token is never sent to the gatekeeper of another cluster. The
+// token is cached for the connection when connectionID is given
+func SecAuthenticateOpenShift(httpClient utils.HTTPClient, authURL string, connectionID string, username string, password string) (*AuthToken, *SecError) {
+	var authToken *AuthToken
+	var secErr *SecError
+	if username == "" && password == "" {
+		authToken, secErr = openShiftKubeconfigToken(connectionID, authURL)
+	} else if username == "" || password == "" {
+		err := errors.New("Both a username and a password are needed to log in to OpenShift, or neither to use the token of `oc login`")
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	} else {
+		authToken, secErr = requestOpenShiftToken(httpClient, authURL, username, password)
+	}
+	if secErr != nil {
+		return nil, secErr
+	}
+	if connectionID != "" {
+		secErr = SecCacheTokens(connectionID, authToken)
+		if secErr != nil {
+			return authToken, secErr
+		}
+	}
+	return authToken, nil
+}
+
+// openShiftKubeconfigToken : the token the user is logged in to the cluster with, when that cluster is the one
+// whose OAuth server is at authURL
+func openShiftKubeconfigToken(connectionID string, authURL string) (*AuthToken, *SecError) {
+	token, issuer, err := kubeconfigToken(connectionID)
+	if err == nil && !sameOAuthServer(issuer, authURL) {
+		err = errors.New("the cluster of the Kubernetes context logs in with " + issuer + " rather than " + authURL + ", the OAuth server of the connection")
+	}
+	if err == nil && token == "" {
+		err = errors.New("the Kubernetes context has no token")
+	}
+	if err != nil {
+		loginErr := errors.New("Unable to use the OpenShift login of the kubeconfig: " + err.Error() + ". Run `oc login` or give --username and --password")
+		return nil, &SecError{errOpOpenShift, loginErr, loginErr.Error()}
+	}
+	return &AuthToken{AccessToken: token, TokenType: "Bearer"}, nil
+}
+
+// sameOAuthServer : whether the URLs are of the same OAuth server, ignoring the case of the host and trailing slashes
+func sameOAuthServer(issuer string, authURL string) bool {
+	issuerURL, err := url.Parse(strings.TrimRight(issuer, "/"))
+	if err != nil {
+		return false
+	}
+	connectionURL, err := url.Parse(strings.TrimRight(authURL, "/"))
+	if err != nil {
+		return false
+	}
+	return issuerURL.Scheme == connectionURL.Scheme && strings.EqualFold(issuerURL.Host, connectionURL.Host) && issuerURL.Path == connectionURL.Path
+}
+
+// requestOpenShiftToken : requests a token for the user from the OAuth server with the challenging client. The
+// token is returned in the fragment of the URL the server redirects to
+func requestOpenShiftToken(httpClient utils.HTTPClient, authURL string, username string, password string) (*AuthToken, *SecError) {
+	query := url.Values{
+		"response_type": {"token"},
+		"client_id":     {openShiftChallengingClient},
+	}
+	req, err := http.NewRequest("GET", strings.TrimRight(authURL, "/")+"/oauth/authorize?"+query.Encode(), nil)
+	if err != nil {
+		return nil, &SecError{errOpConnection, err, err.Error()}
+	}
+	req.SetBasicAuth(username, password)
+	// The OAuth server refuses basic auth without a CSRF header
+	req.Header.Set("X-CSRF-Token", "1")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &SecError{errOpConnection, err, err.Error()}
+	}
+	defer res.Body.Close()
+
+	fragment := ""
+	switch {
+	case res.StatusCode == http.StatusUnauthorized:
+		err = errors.New("Invalid username or password for OpenShift user " + username)
+		return nil, &SecError{errOpOpenShift, err, err.Error()}
+	case res.StatusCode == http.StatusFound:
+		location, err := url.Parse(res.Header.Get("Location"))
+		if err != nil {
+			return nil, &SecError{errOpResponseFormat, err, err.Error()}
+		}
+		fragment = location.Fragment
+	case res.Request != nil && res.Request.URL != nil:
+		// The client followed the redirect, which keeps the fragment on the URL of the last request
+		fragment = res.Request.URL.Fragment
+	}
+
+	values, err := url.ParseQuery(fragment)
+	if err == nil && values.Get("error") != "" {
+		err = errors.New(strings.TrimSpace(values.Get("error") + " " + values.Get("error_description")))
+	}
+	if err == nil && values.Get("access_token") == "" {
+		err = errors.New("The OpenShift OAuth server did not return a token, it answered with HTTP status " + strconv.Itoa(res.StatusCode))
+	}
+	if err != nil {
+		return nil, &SecError{errOpOpenShift, err, err.Error()}
+	}
+	expiresIn, _ := strconv.Atoi(values.Get("expires_in"))
+	return &AuthToken{
+		AccessToken: values.Get("access_token"),
+		ExpiresIn:   expiresIn,
+		TokenType:   "Bearer",
+		Scope:       values.Get("scope"),
+	}, nil
+}
@@ -0,0 +1,97 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RequestOpenShiftToken(t *testing.T) {
+	oauthServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/oauth/token/implicit" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		assert.Equal(t, "/oauth/authorize", r.URL.Path)
+		assert.Equal(t, openShiftChallengingClient, r.URL.Query().Get("client_id"))
+		assert.Equal(t, "1", r.Header.Get("X-CSRF-Token"))
+		username, password, _ := r.BasicAuth()
+		if username != "developer" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Location", "/oauth/token/implicit#access_token=sha256~t0ken&expires_in=86400&scope=user%3Afull&token_type=Bearer")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer oauthServer.Close()
+
+	t.Run("The token is read from the redirect the server answers with", func(t *testing.T) {
+		noRedirects := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}}
+		authToken, secErr := requestOpenShiftToken(noRedirects, oauthServer.URL, "developer", "secret")
+		assert.Nil(t, secErr)
+		assert.Equal(t, "sha256~t0ken", authToken.AccessToken)
+		assert.Equal(t, 86400, authToken.ExpiresIn)
+		assert.Equal(t, "user:full", authToken.Scope)
+	})
+
+	t.Run("The token is read from the redirect a client has followed", func(t *testing.T) {
+		authToken, secErr := requestOpenShiftToken(http.DefaultClient, oauthServer.URL, "developer", "secret")
+		assert.Nil(t, secErr)
+		assert.Equal(t, "sha256~t0ken", authToken.AccessToken)
+	})
+
+	t.Run("A wrong password is an error", func(t *testing.T) {
+		_, secErr := requestOpenShiftToken(http.DefaultClient, oauthServer.URL, "developer", "wrong")
+		assert.Equal(t, errOpOpenShift, secErr.Op)
+		assert.Contains(t, secErr.Desc, "Invalid username or password")
+	})
+}
+
+func Test_SecAuthenticateOpenShift(t *testing.T) {
+	originalToken := kubeconfigToken
+	defer func() { kubeconfigToken = originalToken }()
+
+	t.Run("Without credentials the token of the kubeconfig is used", func(t *testing.T) {
+		kubeconfigToken = func(string) (string, string, error) { return "sha256~kube", "https://OAuth.example.com/", nil }
+		authToken, secErr := SecAuthenticateOpenShift(http.DefaultClient, "https://oauth.example.com", "", "", "")
+		assert.Nil(t, secErr)
+		assert.Equal(t, "sha256~kube", authToken.AccessToken)
+	})
+
+	t.Run("The token of a context of another cluster is not used", func(t *testing.T) {
+		kubeconfigToken = func(string) (string, string, error) { return "sha256~prod", "https://oauth.prod.example.com", nil }
+		authToken, secErr := SecAuthenticateOpenShift(http.DefaultClient, "https://oauth.example.com", "", "", "")
+		assert.Nil(t, authToken)
+		assert.Equal(t, errOpOpenShift, secErr.Op)
+		assert.Contains(t, secErr.Desc, "--username and --password")
+		assert.NotContains(t, secErr.Desc, "sha256~prod")
+	})
+
+	t.Run("A kubeconfig without a token asks for oc login", func(t *testing.T) {
+		kubeconfigToken = func(string) (string, string, error) { return "", "", errors.New("no context") }
+		_, secErr := SecAuthenticateOpenShift(http.DefaultClient, "https://oauth.example.com", "", "", "")
+		assert.Equal(t, errOpOpenShift, secErr.Op)
+		assert.Contains(t, secErr.Desc, "oc login")
+	})
+
+	t.Run("A username without a password is refused", func(t *testing.T) {
+		_, secErr := SecAuthenticateOpenShift(http.DefaultClient, "https://oauth.example.com", "", "developer", "")
+		assert.Equal(t, errOpCLICommand, secErr.Op)
+	})
+}
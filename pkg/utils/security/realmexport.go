@@ -0,0 +1,183 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// realmExportPageSize is how many users are requested at a time when exporting a realm
+const realmExportPageSize = 100
+
+// RealmImportResult : what a realm import did
+type RealmImportResult struct {
+	Status  string `json:"status"`
+	Realm   string `json:"realm"`
+	Created bool   `json:"created"`
+}
+
+// SecRealmExport : Writes the configuration of a realm, including its clients, roles, groups and users, to a
+// file which SecRealmImport can read. Passwords are never exported and client secrets are left out
+func SecRealmExport(httpClient utils.HTTPClient, c *cli.Context) *SecError {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return secErr
+	}
+	body, secErr := sendAdminRequest(httpClient, c, accesstoken, "POST", "/partial-export?exportClients=true&exportGroupsAndRoles=true", nil, http.StatusOK)
+	if secErr != nil {
+		return secErr
+	}
+	realm := map[string]interface{}{}
+	err := json.Unmarshal(body, &realm)
+	if err != nil {
+		return &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
+	removeClientSecrets(realm)
+
+	users, secErr := getRealmUsers(httpClient, c, accesstoken)
+	if secErr != nil {
+		return secErr
+	}
+	realm["users"] = users
+
+	exported, err := json.MarshalIndent(realm, "", "  ")
+	if err != nil {
+		return &SecError{errOpResponseFormat, err, err.Error()}
+	}
+	err = ioutil.WriteFile(strings.TrimSpace(c.String("file")), exported, 0600)
+	if err != nil {
+		return &SecError{errOpCLICommand, err, err.Error()}
+	}
+	return nil
+}
+
+// SecRealmImport : Creates a realm from a file written by SecRealmExport. If the realm already exists its
+// clients, roles, groups and users are added to it, skipping those which exist unless overwrite is set
+func SecRealmImport(httpClient utils.HTTPClient, c *cli.Context) (*RealmImportResult, *SecError) {
+	file, err := ioutil.ReadFile(strings.TrimSpace(c.String("file")))
+	if err != nil {
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+	realm := map[string]interface{}{}
+	err = json.Unmarshal(file, &realm)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, err.Error()}
+	}
+
+	// a realm given on the command line renames the one in the file
+	realmName := strings.TrimSpace(c.String("realm"))
+	fileRealmName, _ := realm["realm"].(string)
+	if realmName == "" {
+		realmName = fileRealmName
+	}
+	if realmName == "" {
+		err := errors.New("The file does not name a realm, use --realm to give one")
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+	if realmName != fileRealmName {
+		realm["realm"] = realmName
+		delete(realm, "id")
+	}
+
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return nil, secErr
+	}
+	result := RealmImportResult{Status: "OK", Realm: realmName}
+	_, secErr = sendRealmsRequest(httpClient, c, accesstoken, "GET", "/"+url.PathEscape(realmName), nil, http.StatusOK)
+	if secErr != nil && secErr.Op != errOpNotFound {
+		return nil, secErr
+	}
+	if secErr != nil {
+		_, secErr = sendRealmsRequest(httpClient, c, accesstoken, "POST", "", realm, http.StatusCreated)
+		if secErr != nil {
+			return nil, secErr
+		}
+		result.Created = true
+		return &result, nil
+	}
+
+	ifResourceExists := "SKIP"
+	if c.Bool("overwrite") {
+		ifResourceExists = "OVERWRITE"
+	}
+	partialImport := map[string]interface{}{"ifResourceExists": ifResourceExists}
+	for _, key := range []string{"clients", "roles", "groups", "users"} {
+		if value, ok := realm[key]; ok {
+			partialImport[key] = value
+		}
+	}
+	_, secErr = sendRealmsRequest(httpClient, c, accesstoken, "POST", "/"+url.PathEscape(realmName)+"/partialImport", partialImport, http.StatusOK)
+	if secErr != nil {
+		return nil, secErr
+	}
+	return &result, nil
+}
+
+// getRealmUsers : every user of the realm, each with the names of its realm roles
+func getRealmUsers(httpClient utils.HTTPClient, c *cli.Context, accesstoken string) ([]map[string]interface{}, *SecError) {
+	users := []map[string]interface{}{}
+	for first := 0; ; first += realmExportPageSize {
+		body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/users?first="+strconv.Itoa(first)+"&max="+strconv.Itoa(realmExportPageSize), nil, http.StatusOK)
+		if secErr != nil {
+			return nil, secErr
+		}
+		page := []map[string]interface{}{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+		}
+		for _, user := range page {
+			userID, _ := user["id"].(string)
+			body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/users/"+userID+"/role-mappings/realm", nil, http.StatusOK)
+			if secErr != nil {
+				return nil, secErr
+			}
+			roles := []Role{}
+			err := json.Unmarshal(body, &roles)
+			if err != nil {
+				return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+			}
+			roleNames := []string{}
+			for _, role := range roles {
+				roleNames = append(roleNames, role.Name)
+			}
+			user["realmRoles"] = roleNames
+			delete(user, "access")
+			delete(user, "credentials")
+			users = append(users, user)
+		}
+		if len(page) < realmExportPageSize {
+			return users, nil
+		}
+	}
+}
+
+// removeClientSecrets : Keycloak masks client secrets in exports, so they are removed rather than
+// imported as the mask. Confidential clients are given a new secret when imported
+func removeClientSecrets(realm map[string]interface{}) {
+	clients, _ := realm["clients"].([]interface{})
+	for _, client := range clients {
+		if clientMap, ok := client.(map[string]interface{}); ok {
+			delete(clientMap, "secret")
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func Test_RealmExportImport(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-realm")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "realm.json")
+
+	set := flag.NewFlagSet("tests", 0)
+	set.String("host", "https://mockserver", "doc")
+	set.String("realm", "codewind", "doc")
+	set.String("accesstoken", "admintoken", "doc")
+	set.String("file", file, "doc")
+	set.Bool("overwrite", false, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	t.Run("Exports the realm with users and their roles, without secrets", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `{"id":"codewind","realm":"codewind","clients":[{"clientId":"codewind-backend","secret":"**********"}]}`),
+			mockResponse(http.StatusOK, `[{"id":"2","username":"developer","access":{"manage":true}}]`),
+			mockResponse(http.StatusOK, `[{"id":"r1","name":"codewind-workspace"}]`),
+		}}
+		assert.Nil(t, SecRealmExport(mockClient, c))
+		assert.Equal(t, "/auth/admin/realms/codewind/partial-export", mockClient.requests[0].URL.Path)
+		exported, _ := ioutil.ReadFile(file)
+		assert.JSONEq(t, `{
+			"id":"codewind",
+			"realm":"codewind",
+			"clients":[{"clientId":"codewind-backend"}],
+			"users":[{"id":"2","username":"developer","realmRoles":["codewind-workspace"]}]
+		}`, string(exported))
+	})
+
+	t.Run("Creates the realm when it does not exist", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusNotFound, ""),
+			mockResponse(http.StatusCreated, ""),
+		}}
+		result, secErr := SecRealmImport(mockClient, c)
+		assert.Nil(t, secErr)
+		assert.True(t, result.Created)
+		assert.Equal(t, "/auth/admin/realms", mockClient.requests[1].URL.Path)
+	})
+
+	t.Run("Adds to an existing realm", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `{"realm":"codewind"}`),
+			mockResponse(http.StatusOK, `{"added":2}`),
+		}}
+		result, secErr := SecRealmImport(mockClient, c)
+		assert.Nil(t, secErr)
+		assert.False(t, result.Created)
+		partialImport := mockClient.requests[1]
+		assert.Equal(t, "/auth/admin/realms/codewind/partialImport", partialImport.URL.Path)
+		body, _ := ioutil.ReadAll(partialImport.Body)
+		assert.Contains(t, string(body), `"ifResourceExists":"SKIP"`)
+	})
+}
@@ -0,0 +1,164 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/apiroutes"
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/zalando/go-keyring"
+)
+
+// RegistryCredentials : The credentials for an image registry, as kept in the keyring
+type RegistryCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// SecRegistrySecretAdd : Saves the credentials for an image registry in the keyring and gives them to the
+// connection's PFE, so that it can pull from and push to the registry when building projects
+func SecRegistrySecretAdd(httpClient utils.HTTPClient, connectionID string, address string, username string, password string) ([]apiroutes.RegistrySecret, *SecError) {
+	address = strings.TrimSpace(address)
+	if address == "" || strings.TrimSpace(username) == "" || password == "" {
+		err := errors.New(textInvalidOptions)
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+	connection, secErr := getRegistryConnection(connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	client, secErr := getPFEClient(httpClient, connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	registrySecrets, err := client.AddRegistrySecret(address, username, password)
+	if err != nil {
+		return nil, &SecError{errOpResponse, err, err.Error()}
+	}
+	credentials, _ := json.Marshal(RegistryCredentials{Username: username, Password: password})
+	secErr = setConnectionSecret(newKeyringEntry(connection, KeyringKindRegistry, "", address), string(credentials))
+	if secErr != nil {
+		return nil, secErr
+	}
+	DeleteSecret(legacyRegistryKeyringService(connection.ID), address)
+	return registrySecrets, nil
+}
+
+// SecRegistrySecretList : Lists the registries the connection's PFE has credentials for. Credentials kept in the
+// keyring by an older cwctl for those registries are moved to the namespace of the connection's URL
+func SecRegistrySecretList(httpClient utils.HTTPClient, connectionID string) ([]apiroutes.RegistrySecret, *SecError) {
+	connection, secErr := getRegistryConnection(connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	client, secErr := getPFEClient(httpClient, connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	registrySecrets, err := client.GetRegistrySecrets()
+	if err != nil {
+		return nil, &SecError{errOpResponse, err, err.Error()}
+	}
+	for _, registrySecret := range registrySecrets {
+		getRegistrySecret(connection, registrySecret.Address)
+	}
+	return registrySecrets, nil
+}
+
+// SecRegistrySecretRemove : Removes the credentials for an image registry from the connection's PFE and the keyring
+func SecRegistrySecretRemove(httpClient utils.HTTPClient, connectionID string, address string) ([]apiroutes.RegistrySecret, *SecError) {
+	address = strings.TrimSpace(address)
+	connection, secErr := getRegistryConnection(connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	client, secErr := getPFEClient(httpClient, connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	registrySecrets, err := client.RemoveRegistrySecret(address)
+	if err != nil {
+		return nil, &SecError{errOpResponse, err, err.Error()}
+	}
+	// Credentials added before they were kept in the keyring are not there to delete
+	secErr = deleteConnectionSecret(newKeyringEntry(connection, KeyringKindRegistry, "", address))
+	if secErr != nil {
+		return nil, secErr
+	}
+	err = DeleteSecret(legacyRegistryKeyringService(connection.ID), address)
+	if err != nil && err != keyring.ErrNotFound {
+		return nil, &SecError{errOpKeyring, err, err.Error()}
+	}
+	return registrySecrets, nil
+}
+
+// SecRegistrySecretGet : Retrieves the credentials for an image registry from the keyring
+func SecRegistrySecretGet(connectionID string, address string) (*RegistryCredentials, *SecError) {
+	connection, secErr := getRegistryConnection(connectionID)
+	if secErr != nil {
+		return nil, secErr
+	}
+	secret, err := getRegistrySecret(connection, strings.TrimSpace(address))
+	if err != nil {
+		return nil, &SecError{errOpKeyring, err, err.Error()}
+	}
+	credentials := RegistryCredentials{}
+	err = json.Unmarshal([]byte(secret), &credentials)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, err.Error()}
+	}
+	return &credentials, nil
+}
+
+// getRegistrySecret : the credentials kept for a registry of a connection, moving those kept by an older cwctl
+// under the connection ID to the namespace of the connection's URL
+func getRegistrySecret(connection *connections.Connection, address string) (string, error) {
+	entry := newKeyringEntry(connection, KeyringKindRegistry, "", address)
+	secret, err := GetSecret(entry.Service, entry.Account)
+	if err != keyring.ErrNotFound {
+		return secret, err
+	}
+	secret, err = GetSecret(legacyRegistryKeyringService(connection.ID), address)
+	if err != nil {
+		return "", err
+	}
+	if setConnectionSecret(entry, secret) == nil {
+		DeleteSecret(legacyRegistryKeyringService(connection.ID), address)
+	}
+	return secret, nil
+}
+
+// legacyRegistryKeyringService : the keyring service older versions of cwctl kept registry credentials under
+func legacyRegistryKeyringService(connectionID string) string {
+	return KeyringServiceName + ".registry." + strings.TrimSpace(strings.ToLower(connectionID))
+}
+
+func getRegistryConnection(connectionID string) (*connections.Connection, *SecError) {
+	connection, conErr := connections.GetConnectionByID(strings.TrimSpace(connectionID))
+	if conErr != nil {
+		return nil, &SecError{errOpConConfig, conErr.Err, conErr.Desc}
+	}
+	return connection, nil
+}
+
+// getPFEClient : a client of the PFE of a connection, which sends requests through httpClient
+func getPFEClient(httpClient utils.HTTPClient, connectionID string) (*apiroutes.PFEClient, *SecError) {
+	host, conErr := connections.GetPFEOrigin(connectionID)
+	if conErr != nil {
+		return nil, &SecError{errOpConConfig, conErr.Err, conErr.Desc}
+	}
+	return apiroutes.NewPFEClientForHost(httpClient, host), nil
+}
@@ -0,0 +1,190 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// Role : a Keycloak realm role
+type Role struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecRoleCreate : Create a new role in a Keycloak realm
+func SecRoleCreate(httpClient utils.HTTPClient, c *cli.Context) *SecError {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return secErr
+	}
+	role := Role{Name: strings.TrimSpace(c.String("role")), Description: strings.TrimSpace(c.String("description"))}
+	_, secErr = sendAdminRequest(httpClient, c, accesstoken, "POST", "/roles", role, http.StatusCreated)
+	return secErr
+}
+
+// SecRoleAddToUser : Grant a realm role to an existing user
+func SecRoleAddToUser(httpClient utils.HTTPClient, c *cli.Context) *SecError {
+	return updateUserRoleMapping(httpClient, c, "POST")
+}
+
+// SecRoleRemoveFromUser : Revoke a realm role from an existing user
+func SecRoleRemoveFromUser(httpClient utils.HTTPClient, c *cli.Context) *SecError {
+	return updateUserRoleMapping(httpClient, c, "DELETE")
+}
+
+// SecRoleListForUser : List the realm roles granted to an existing user
+func SecRoleListForUser(httpClient utils.HTTPClient, c *cli.Context) ([]Role, *SecError) {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return nil, secErr
+	}
+	userID, secErr := getUserID(httpClient, c, accesstoken)
+	if secErr != nil {
+		return nil, secErr
+	}
+	body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/users/"+userID+"/role-mappings/realm", nil, http.StatusOK)
+	if secErr != nil {
+		return nil, secErr
+	}
+	roles := []Role{}
+	err := json.Unmarshal(body, &roles)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
+	return roles, nil
+}
+
+// updateUserRoleMapping : adds or removes a realm role mapping of a user
+func updateUserRoleMapping(httpClient utils.HTTPClient, c *cli.Context, method string) *SecError {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return secErr
+	}
+	userID, secErr := getUserID(httpClient, c, accesstoken)
+	if secErr != nil {
+		return secErr
+	}
+
+	// Keycloak needs the full role, including its ID, in the mapping
+	body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/roles/"+url.PathEscape(strings.TrimSpace(c.String("role"))), nil, http.StatusOK)
+	if secErr != nil {
+		return secErr
+	}
+	role := Role{}
+	err := json.Unmarshal(body, &role)
+	if err != nil {
+		return &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
+	_, secErr = sendAdminRequest(httpClient, c, accesstoken, method, "/users/"+userID+"/role-mappings/realm", []Role{role}, http.StatusNoContent)
+	return secErr
+}
+
+// getAdminAccessToken : the access token given on the command line, or one obtained by logging in as an admin
+func getAdminAccessToken(httpClient utils.HTTPClient, c *cli.Context) (string, *SecError) {
+	accesstoken := strings.TrimSpace(c.String("accesstoken"))
+	if accesstoken != "" {
+		return accesstoken, nil
+	}
+	authToken, secErr := SecAuthenticate(httpClient, c, KeycloakMasterRealm, KeycloakAdminClientID)
+	if secErr != nil {
+		return "", secErr
+	}
+	return authToken.AccessToken, nil
+}
+
+// getUserID : looks up the ID of the user given by the name flag
+func getUserID(httpClient utils.HTTPClient, c *cli.Context, accesstoken string) (string, *SecError) {
+	user, secErr := getUser(httpClient, c, accesstoken, strings.TrimSpace(c.String("name")))
+	if secErr != nil {
+		return "", secErr
+	}
+	return user.ID, nil
+}
+
+// getUser : looks up the user with the username
+func getUser(httpClient utils.HTTPClient, c *cli.Context, accesstoken string, username string) (*RegisteredUser, *SecError) {
+	body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/users?username="+url.QueryEscape(username), nil, http.StatusOK)
+	if secErr != nil {
+		return nil, secErr
+	}
+	registeredUsers := RegisteredUsers{}
+	err := json.Unmarshal(body, &registeredUsers.Collection)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+	}
+	// the search matches substrings, so look for the exact username
+	for _, user := range registeredUsers.Collection {
+		if strings.EqualFold(user.Username, username) {
+			return &user, nil
+		}
+	}
+	errNotFound := errors.New(textUserNotFound)
+	return nil, &SecError{errOpNotFound, errNotFound, errNotFound.Error()}
+}
+
+// sendAdminRequest : sends a request to the Keycloak admin API of the realm, returning the response body
+func sendAdminRequest(httpClient utils.HTTPClient, c *cli.Context, accesstoken string, method string, path string, payload interface{}, expectedStatus int) ([]byte, *SecError) {
+	realm := strings.TrimSpace(c.String("realm"))
+	return sendRealmsRequest(httpClient, c, accesstoken, method, "/"+realm+path, payload, expectedStatus)
+}
+
+// sendRealmsRequest : sends a request to the Keycloak admin API for realms, where path is relative to /auth/admin/realms
+func sendRealmsRequest(httpClient utils.HTTPClient, c *cli.Context, accesstoken string, method string, path string, payload interface{}, expectedStatus int) ([]byte, *SecError) {
+	hostname := strings.TrimSpace(strings.ToLower(c.String("host")))
+
+	var requestBody *bytes.Reader
+	if payload != nil {
+		jsonPayload, _ := json.Marshal(payload)
+		requestBody = bytes.NewReader(jsonPayload)
+	} else {
+		requestBody = bytes.NewReader([]byte{})
+	}
+	req, err := http.NewRequest(method, hostname+"/auth/admin/realms"+path, requestBody)
+	if err != nil {
+		return nil, &SecError{errOpConnection, err, err.Error()}
+	}
+	req.Header.Add("Authorization", "Bearer "+accesstoken)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Cache-Control", "no-cache")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, &SecError{errOpConnection, err, err.Error()}
+	}
+	defer res.Body.Close()
+	body, _ := ioutil.ReadAll(res.Body)
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		err = errors.New("Not found: " + path)
+		return nil, &SecError{errOpNotFound, err, err.Error()}
+	case res.StatusCode != expectedStatus:
+		keycloakAPIError := parseKeycloakError(string(body), res.StatusCode)
+		err = errors.New(keycloakAPIError.ErrorDescription)
+		if keycloakAPIError.ErrorDescription == "" {
+			err = errors.New(res.Status)
+		}
+		return nil, &SecError{errOpResponse, err, err.Error()}
+	}
+	return body, nil
+}
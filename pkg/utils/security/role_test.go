@@ -0,0 +1,90 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+// clientMockSequence : responds to each request with the next canned response, recording the requests
+type clientMockSequence struct {
+	responses []*http.Response
+	requests  []*http.Request
+}
+
+func (c *clientMockSequence) Do(req *http.Request) (*http.Response, error) {
+	c.requests = append(c.requests, req)
+	response := c.responses[0]
+	c.responses = c.responses[1:]
+	return response, nil
+}
+
+func mockResponse(statusCode int, body string) *http.Response {
+	return &http.Response{StatusCode: statusCode, Status: http.StatusText(statusCode), Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}
+}
+
+func Test_Roles(t *testing.T) {
+	set := flag.NewFlagSet("tests", 0)
+	set.String("host", "https://mockserver", "doc")
+	set.String("realm", "codewind", "doc")
+	set.String("accesstoken", "admintoken", "doc")
+	set.String("name", "developer", "doc")
+	set.String("role", "codewind-workspace", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	t.Run("Creates a realm role", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{mockResponse(http.StatusCreated, "")}}
+		assert.Nil(t, SecRoleCreate(mockClient, c))
+		assert.Equal(t, "https://mockserver/auth/admin/realms/codewind/roles", mockClient.requests[0].URL.String())
+		assert.Equal(t, "Bearer admintoken", mockClient.requests[0].Header.Get("Authorization"))
+	})
+
+	t.Run("Grants a role to the user with that exact name", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `[{"id":"1","username":"developer2"},{"id":"2","username":"developer"}]`),
+			mockResponse(http.StatusOK, `{"id":"r1","name":"codewind-workspace"}`),
+			mockResponse(http.StatusNoContent, ""),
+		}}
+		assert.Nil(t, SecRoleAddToUser(mockClient, c))
+		mapping := mockClient.requests[2]
+		assert.Equal(t, "POST", mapping.Method)
+		assert.Equal(t, "/auth/admin/realms/codewind/users/2/role-mappings/realm", mapping.URL.Path)
+		body, _ := ioutil.ReadAll(mapping.Body)
+		assert.JSONEq(t, `[{"id":"r1","name":"codewind-workspace"}]`, string(body))
+	})
+
+	t.Run("Lists the roles of a user", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `[{"id":"2","username":"developer"}]`),
+			mockResponse(http.StatusOK, `[{"id":"r1","name":"codewind-workspace"}]`),
+		}}
+		roles, secErr := SecRoleListForUser(mockClient, c)
+		assert.Nil(t, secErr)
+		assert.Equal(t, []Role{{ID: "r1", Name: "codewind-workspace"}}, roles)
+	})
+
+	t.Run("Reports an unknown role", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `[{"id":"2","username":"developer"}]`),
+			mockResponse(http.StatusNotFound, `{"error":"Role not found"}`),
+		}}
+		secErr := SecRoleRemoveFromUser(mockClient, c)
+		assert.Equal(t, errOpNotFound, secErr.Op)
+	})
+}
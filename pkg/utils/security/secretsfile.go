@@ -0,0 +1,205 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// SecretsPassphraseEnv : environment variable holding the passphrase for the encrypted secrets file
+const SecretsPassphraseEnv = "CW_SECRETS_PASSPHRASE"
+
+const (
+	keySourcePassphrase = "passphrase"
+	keySourceMachine    = "machine"
+	keyIterations       = 100000
+	keyLength           = 32
+)
+
+// secretsFile : the on disk format of the encrypted secrets file
+type secretsFile struct {
+	KeySource string `json:"keySource"`
+	Salt      []byte `json:"salt"`
+	Nonce     []byte `json:"nonce"`
+	Data      []byte `json:"data"`
+}
+
+// fileKeyring : keeps secrets in a file encrypted with AES-GCM, for machines without a platform keyring.
+// The key is derived from CW_SECRETS_PASSPHRASE when it is set, otherwise from a key unique to the machine and user
+type fileKeyring struct {
+	path string
+}
+
+func (f *fileKeyring) Set(service string, user string, secret string) error {
+	return f.update(func(secrets map[string]map[string]string) error {
+		if secrets[service] == nil {
+			secrets[service] = map[string]string{}
+		}
+		secrets[service][user] = secret
+		return nil
+	})
+}
+
+func (f *fileKeyring) Get(service string, user string) (string, error) {
+	secrets, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	secret, found := secrets[service][user]
+	if !found {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (f *fileKeyring) Delete(service string, user string) error {
+	return f.update(func(secrets map[string]map[string]string) error {
+		if _, found := secrets[service][user]; !found {
+			return keyring.ErrNotFound
+		}
+		delete(secrets[service], user)
+		if len(secrets[service]) == 0 {
+			delete(secrets, service)
+		}
+		return nil
+	})
+}
+
+// update : Loads the secrets, applies change and saves the result while holding the lock of the secrets file, so
+// that changes made by concurrent cwctl processes are not lost
+func (f *fileKeyring) update(change func(secrets map[string]map[string]string) error) error {
+	err := os.MkdirAll(filepath.Dir(f.path), 0700)
+	if err != nil {
+		return err
+	}
+	unlock, err := connections.LockFile(f.path+".lock", f.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	secrets, err := f.load()
+	if err != nil {
+		return err
+	}
+	err = change(secrets)
+	if err != nil {
+		return err
+	}
+	return f.save(secrets)
+}
+
+func (f *fileKeyring) load() (map[string]map[string]string, error) {
+	secrets := map[string]map[string]string{}
+	body, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	file := secretsFile{}
+	err = json.Unmarshal(body, &file)
+	if err != nil {
+		return nil, err
+	}
+	if file.KeySource == keySourcePassphrase && os.Getenv(SecretsPassphraseEnv) == "" {
+		return nil, errors.New("The secrets file " + f.path + " is protected by a passphrase, set " + SecretsPassphraseEnv + " to unlock it")
+	}
+	gcm, err := newSecretsCipher(file.KeySource, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, errors.New("Unable to decrypt the secrets file " + f.path + ", check " + SecretsPassphraseEnv)
+	}
+	err = json.Unmarshal(plaintext, &secrets)
+	if err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func (f *fileKeyring) save(secrets map[string]map[string]string) error {
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	file := secretsFile{KeySource: keySourceMachine, Salt: make([]byte, 16)}
+	if os.Getenv(SecretsPassphraseEnv) != "" {
+		file.KeySource = keySourcePassphrase
+	}
+	_, err = rand.Read(file.Salt)
+	if err != nil {
+		return err
+	}
+	gcm, err := newSecretsCipher(file.KeySource, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	_, err = rand.Read(file.Nonce)
+	if err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plaintext, nil)
+	body, err := json.MarshalIndent(file, "", "\t")
+	if err != nil {
+		return err
+	}
+	return connections.WriteFileAtomically(f.path, body, 0600)
+}
+
+func newSecretsCipher(keySource string, salt []byte) (cipher.AEAD, error) {
+	secret := os.Getenv(SecretsPassphraseEnv)
+	if keySource == keySourceMachine {
+		secret = machineKey()
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(secret), salt, keyIterations, keyLength, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// machineKey : identifies the machine and user, so the secrets file cannot be read when copied elsewhere
+func machineKey() string {
+	parts := []string{}
+	for _, idFile := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+		id, err := ioutil.ReadFile(idFile)
+		if err == nil {
+			parts = append(parts, strings.TrimSpace(string(id)))
+			break
+		}
+	}
+	hostname, _ := os.Hostname()
+	parts = append(parts, hostname)
+	currentUser, err := user.Current()
+	if err == nil {
+		parts = append(parts, currentUser.Uid, currentUser.HomeDir)
+	}
+	return strings.Join(parts, ":")
+}
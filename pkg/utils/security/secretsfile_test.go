@@ -0,0 +1,87 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+)
+
+func Test_SecretsFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-secrets")
+	defer os.RemoveAll(dir)
+	store := &fileKeyring{path: filepath.Join(dir, "cwctl-secrets.json")}
+
+	t.Run("Missing secrets are not found", func(t *testing.T) {
+		_, err := store.Get("service", "user")
+		assert.Equal(t, keyring.ErrNotFound, err)
+	})
+
+	t.Run("Secrets can be saved, retrieved and deleted", func(t *testing.T) {
+		assert.Nil(t, store.Set("service", "user", "pAss%-w0rd"))
+		secret, err := store.Get("service", "user")
+		assert.Nil(t, err)
+		assert.Equal(t, "pAss%-w0rd", secret)
+		assert.Nil(t, store.Delete("service", "user"))
+		assert.Equal(t, keyring.ErrNotFound, store.Delete("service", "user"))
+	})
+
+	t.Run("Secrets are not written in plain text", func(t *testing.T) {
+		assert.Nil(t, store.Set("service", "user", "pAss%-w0rd"))
+		body, _ := ioutil.ReadFile(store.path)
+		assert.NotContains(t, string(body), "pAss%-w0rd")
+		info, _ := os.Stat(store.path)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	})
+
+	t.Run("Concurrent changes are not lost", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(user string) {
+				defer wg.Done()
+				assert.Nil(t, store.Set("concurrent", user, "secret-"+user))
+			}(strconv.Itoa(i))
+		}
+		wg.Wait()
+		for i := 0; i < 5; i++ {
+			secret, err := store.Get("concurrent", strconv.Itoa(i))
+			assert.Nil(t, err)
+			assert.Equal(t, "secret-"+strconv.Itoa(i), secret)
+		}
+	})
+
+	t.Run("A file protected by a passphrase needs the passphrase", func(t *testing.T) {
+		os.Setenv(SecretsPassphraseEnv, "correct horse")
+		assert.Nil(t, store.Set("service", "user", "pAss%-w0rd"))
+		os.Setenv(SecretsPassphraseEnv, "wrong horse")
+		_, err := store.Get("service", "user")
+		assert.NotNil(t, err)
+		os.Unsetenv(SecretsPassphraseEnv)
+		_, err = store.Get("service", "user")
+		assert.Contains(t, err.Error(), SecretsPassphraseEnv)
+	})
+}
+
+func Test_SetSecretBackend(t *testing.T) {
+	defer SetSecretBackend(SecretBackendAuto)
+	assert.Nil(t, SetSecretBackend("FILE"))
+	assert.Equal(t, SecretBackendFile, GetSecretBackend())
+	assert.NotNil(t, SetSecretBackend("vault"))
+}
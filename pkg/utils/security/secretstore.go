@@ -0,0 +1,121 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+	"github.com/zalando/go-keyring"
+)
+
+// Secret backends
+const (
+	SecretBackendAuto    = "auto"
+	SecretBackendKeyring = "keyring"
+	SecretBackendFile    = "file"
+)
+
+// SecretBackends : the backends that can be chosen with --secret-backend
+var SecretBackends = []string{SecretBackendAuto, SecretBackendKeyring, SecretBackendFile}
+
+// secretStore : somewhere secrets can be kept, looked up by service and user
+type secretStore interface {
+	Set(service string, user string, secret string) error
+	Get(service string, user string) (string, error)
+	Delete(service string, user string) error
+}
+
+// osKeyring : the platform keyring
+type osKeyring struct{}
+
+func (osKeyring) Set(service string, user string, secret string) error {
+	return keyring.Set(service, user, secret)
+}
+
+func (osKeyring) Get(service string, user string) (string, error) {
+	return keyring.Get(service, user)
+}
+
+func (osKeyring) Delete(service string, user string) error {
+	return keyring.Delete(service, user)
+}
+
+var secretBackend = SecretBackendAuto
+var store secretStore
+
+// SetSecretBackend : Chooses where secrets are kept. With auto the platform keyring is used when it
+// is available, and the encrypted secrets file otherwise
+func SetSecretBackend(backend string) error {
+	backend = strings.TrimSpace(strings.ToLower(backend))
+	if backend == "" {
+		backend = SecretBackendAuto
+	}
+	switch backend {
+	case SecretBackendAuto, SecretBackendKeyring, SecretBackendFile:
+		secretBackend = backend
+		store = nil
+		return nil
+	}
+	return errors.New("Unknown secret backend " + backend + ", expected one of " + strings.Join(SecretBackends, ", "))
+}
+
+// GetSecretBackend : Returns the backend secrets are kept in, resolving auto to keyring or file
+func GetSecretBackend() string {
+	if _, ok := getSecretStore().(*fileKeyring); ok {
+		return SecretBackendFile
+	}
+	return SecretBackendKeyring
+}
+
+// IsKeyringAvailable : Reports whether the platform keyring can be used, e.g. it is not on a headless Linux without a Secret Service
+func IsKeyringAvailable() bool {
+	_, err := keyring.Get(KeyringServiceName+".probe", "probe")
+	return err == nil || err == keyring.ErrNotFound
+}
+
+func getSecretStore() secretStore {
+	if store != nil {
+		return store
+	}
+	switch secretBackend {
+	case SecretBackendKeyring:
+		store = osKeyring{}
+	case SecretBackendFile:
+		store = &fileKeyring{path: cliconfig.GetSecretsFilename()}
+	default:
+		if IsKeyringAvailable() {
+			store = osKeyring{}
+		} else {
+			logr.Debugf("No keyring is available, using the encrypted secrets file %v", cliconfig.GetSecretsFilename())
+			store = &fileKeyring{path: cliconfig.GetSecretsFilename()}
+		}
+	}
+	return store
+}
+
+// SetSecret : Saves a secret in the chosen backend
+func SetSecret(service string, user string, secret string) error {
+	return getSecretStore().Set(service, user, secret)
+}
+
+// GetSecret : Retrieves a secret from the chosen backend, returning keyring.ErrNotFound when there is none
+func GetSecret(service string, user string) (string, error) {
+	return getSecretStore().Get(service, user)
+}
+
+// DeleteSecret : Removes a secret from the chosen backend, returning keyring.ErrNotFound when there is none
+func DeleteSecret(service string, user string) error {
+	return getSecretStore().Delete(service, user)
+}
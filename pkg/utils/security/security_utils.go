@@ -48,13 +48,18 @@ const (
 	errOpKeyring        = "sec_keyring"         // Keyring operations
 	errOpConConfig      = "sec_con_config"      // Connection configuration errors
 	errOpCLICommand     = "sec_cli_options"     // Invalid command line options
+	errOpDiscovery      = "sec_discovery"       // Auth server discovery failed
+	errOpFederated      = "sec_federated"       // Users are managed by a read only federation provider
+	errOpAuthCode       = "sec_authcode"        // Browser login failed
+	errOpOpenShift      = "sec_openshift"       // OpenShift OAuth login failed
 )
 
 const (
-	textBadPassword    = "Passwords must not contains quoted characters"
-	textUserNotFound   = "Registered User not found"
-	textUnableToParse  = "Unable to parse Keycloak response"
-	textInvalidOptions = "Invalid or missing command line options"
+	textBadPassword      = "Passwords must not contains quoted characters"
+	textUserNotFound     = "Registered User not found"
+	textUnableToParse    = "Unable to parse Keycloak response"
+	textInvalidOptions   = "Invalid or missing command line options"
+	textUsernameRequired = "A username is required, give it with --username or CW_USERNAME"
 )
 
 // SecError : Error formatted in JSON containing an errorOp and a description from
@@ -0,0 +1,60 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/zalando/go-keyring"
+)
+
+// templateProviderKeyringService : the keyring service the providers of template repos are kept under, by the
+// normalized URL of their repo, as they hold credentials
+const templateProviderKeyringService = KeyringServiceName + ".templates"
+
+// SecTemplateProviderSet : Saves the provider the index of a template repo is fetched with in the keyring
+func SecTemplateProviderSet(repoURL string, provider utils.TemplateProvider) *SecError {
+	body, _ := json.Marshal(provider)
+	err := SetSecret(templateProviderKeyringService, utils.NormalizeTemplateRepoURL(repoURL), string(body))
+	if err != nil {
+		return &SecError{errOpKeyring, err, err.Error()}
+	}
+	return nil
+}
+
+// SecTemplateProviderGet : The provider the index of a template repo is fetched with, a plain URL provider when
+// none was saved for it
+func SecTemplateProviderGet(repoURL string) (*utils.TemplateProvider, *SecError) {
+	provider := utils.TemplateProvider{Type: utils.TemplateProviderURL}
+	secret, err := GetSecret(templateProviderKeyringService, utils.NormalizeTemplateRepoURL(repoURL))
+	if err == keyring.ErrNotFound {
+		return &provider, nil
+	}
+	if err != nil {
+		return nil, &SecError{errOpKeyring, err, err.Error()}
+	}
+	err = json.Unmarshal([]byte(secret), &provider)
+	if err != nil {
+		return nil, &SecError{errOpResponseFormat, err, err.Error()}
+	}
+	return &provider, nil
+}
+
+// SecTemplateProviderRemove : Forgets the provider of a template repo, if one was saved
+func SecTemplateProviderRemove(repoURL string) *SecError {
+	err := DeleteSecret(templateProviderKeyringService, utils.NormalizeTemplateRepoURL(repoURL))
+	if err != nil && err != keyring.ErrNotFound {
+		return &SecError{errOpKeyring, err, err.Error()}
+	}
+	return nil
+}
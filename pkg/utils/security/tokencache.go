@@ -0,0 +1,185 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	logr "github.com/sirupsen/logrus"
+	"github.com/zalando/go-keyring"
+)
+
+// tokenExpiryMargin : how long before it expires a token stops being used, so that it does not expire in flight
+const tokenExpiryMargin = 30 * time.Second
+
+// tokenExpiryKey : the name of the token entry the expiry times of a connection's tokens are kept under
+const tokenExpiryKey = "token_expiry"
+
+// timeNow : the current time, replaced in tests
+var timeNow = time.Now
+
+// CachedTokens : the tokens of a connection kept in the keyring, and when they expire. An expiry time is zero
+// when it is not known, e.g. for tokens cached by an earlier version of cwctl
+type CachedTokens struct {
+	AccessToken      string
+	RefreshToken     string
+	AccessExpiresAt  time.Time
+	RefreshExpiresAt time.Time
+}
+
+// tokenExpiry : the expiry times of a connection's tokens as they are kept in the keyring
+type tokenExpiry struct {
+	AccessExpiresAt  int64 `json:"accessExpiresAt,omitempty"`
+	RefreshExpiresAt int64 `json:"refreshExpiresAt,omitempty"`
+}
+
+// HasValidAccessToken : whether the access token can be used without it expiring first
+func (tokens *CachedTokens) HasValidAccessToken() bool {
+	return isUnexpired(tokens.AccessToken, tokens.AccessExpiresAt)
+}
+
+// HasValidRefreshToken : whether the refresh token can still be exchanged for an access token
+func (tokens *CachedTokens) HasValidRefreshToken() bool {
+	return isUnexpired(tokens.RefreshToken, tokens.RefreshExpiresAt)
+}
+
+func isUnexpired(token string, expiresAt time.Time) bool {
+	if token == "" {
+		return false
+	}
+	return expiresAt.IsZero() || timeNow().Add(tokenExpiryMargin).Before(expiresAt)
+}
+
+// SecCacheTokens : Keeps the tokens of an AuthToken in the keyring of a connection, with when they expire, under the
+// user they were issued to. The tokens of any other user previously logged in to the connection are removed
+func SecCacheTokens(connectionID string, authToken *AuthToken) *SecError {
+	now := timeNow()
+	expiry := tokenExpiry{}
+	if authToken.ExpiresIn > 0 {
+		expiry.AccessExpiresAt = now.Add(time.Duration(authToken.ExpiresIn) * time.Second).Unix()
+	}
+	if authToken.RefreshExpiresIn > 0 {
+		expiry.RefreshExpiresAt = now.Add(time.Duration(authToken.RefreshExpiresIn) * time.Second).Unix()
+	}
+	expiryJSON, err := json.Marshal(expiry)
+	if err != nil {
+		return &SecError{errOpKeyring, err, err.Error()}
+	}
+
+	connection, conErr := connections.GetConnectionByID(strings.TrimSpace(strings.ToLower(connectionID)))
+	if conErr != nil {
+		return &SecError{errOpNotFound, conErr.Err, conErr.Error()}
+	}
+	return cacheTokens(connection, tokenUsername(authToken.AccessToken), map[string]string{
+		"access_token":  authToken.AccessToken,
+		"refresh_token": authToken.RefreshToken,
+		tokenExpiryKey:  string(expiryJSON),
+	})
+}
+
+// SecGetCachedTokens : Retrieves the tokens of a connection from the keyring. Tokens which are not cached are empty.
+// Tokens cached by an older cwctl under the connection ID are first moved to the namespace of the connection's URL
+func SecGetCachedTokens(connectionID string) *CachedTokens {
+	tokens := CachedTokens{}
+	connection, conErr := connections.GetConnectionByID(strings.TrimSpace(strings.ToLower(connectionID)))
+	if conErr != nil {
+		return &tokens
+	}
+	session := newKeyringEntry(connection, KeyringKindSession, "", "")
+	username, err := GetSecret(session.Service, session.Account)
+	if err == keyring.ErrNotFound {
+		username, err = migrateLegacyTokens(connection)
+	}
+	if err != nil {
+		return &tokens
+	}
+	getToken := func(name string) (string, error) {
+		entry := newKeyringEntry(connection, KeyringKindToken, username, name)
+		return GetSecret(entry.Service, entry.Account)
+	}
+	tokens.AccessToken, _ = getToken("access_token")
+	tokens.RefreshToken, _ = getToken("refresh_token")
+
+	expiryJSON, err := getToken(tokenExpiryKey)
+	if err != nil {
+		return &tokens
+	}
+	expiry := tokenExpiry{}
+	if err := json.Unmarshal([]byte(expiryJSON), &expiry); err != nil {
+		logr.Debugf("Ignoring unreadable token expiry times of connection %v: %v", connectionID, err)
+		return &tokens
+	}
+	if expiry.AccessExpiresAt > 0 {
+		tokens.AccessExpiresAt = time.Unix(expiry.AccessExpiresAt, 0)
+	}
+	if expiry.RefreshExpiresAt > 0 {
+		tokens.RefreshExpiresAt = time.Unix(expiry.RefreshExpiresAt, 0)
+	}
+	return &tokens
+}
+
+// cacheTokens : Keeps tokens by name for the user they were issued to, who becomes the session user of the connection
+func cacheTokens(connection *connections.Connection, username string, tokens map[string]string) *SecError {
+	session := newKeyringEntry(connection, KeyringKindSession, "", "")
+	if previous, err := GetSecret(session.Service, session.Account); err == nil && previous != username {
+		secErr := deleteSessionTokens(connection, previous)
+		if secErr != nil {
+			return secErr
+		}
+	}
+	for name, token := range tokens {
+		secErr := setConnectionSecret(newKeyringEntry(connection, KeyringKindToken, username, name), token)
+		if secErr != nil {
+			return secErr
+		}
+	}
+	return setConnectionSecret(session, username)
+}
+
+// deleteSessionTokens : Removes the tokens of a user of a connection from the keyring
+func deleteSessionTokens(connection *connections.Connection, username string) *SecError {
+	for _, name := range []string{"access_token", "refresh_token", tokenExpiryKey} {
+		secErr := deleteConnectionSecret(newKeyringEntry(connection, KeyringKindToken, username, name))
+		if secErr != nil {
+			return secErr
+		}
+	}
+	return nil
+}
+
+// migrateLegacyTokens : Moves the tokens an older cwctl kept under the connection ID to the user the access token
+// was issued to, returning the user
+func migrateLegacyTokens(connection *connections.Connection) (string, error) {
+	legacyService := legacyKeyringService(connection.ID)
+	tokens := map[string]string{}
+	for _, name := range []string{"access_token", "refresh_token", tokenExpiryKey} {
+		token, err := GetSecret(legacyService, name)
+		if err == nil {
+			tokens[name] = token
+		}
+	}
+	if tokens["access_token"] == "" && tokens["refresh_token"] == "" {
+		return "", keyring.ErrNotFound
+	}
+	username := tokenUsername(tokens["access_token"])
+	secErr := cacheTokens(connection, username, tokens)
+	if secErr != nil {
+		return "", secErr.Err
+	}
+	for name := range tokens {
+		DeleteSecret(legacyService, name)
+	}
+	return username, nil
+}
@@ -0,0 +1,96 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/connections"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TokenCache(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-tokens")
+	defer os.RemoveAll(dir)
+	originalStore := store
+	store = &fileKeyring{path: filepath.Join(dir, "cwctl-secrets.json")}
+	defer func() { store = originalStore }()
+
+	now := time.Date(2019, 12, 1, 12, 0, 0, 0, time.UTC)
+	timeNow = func() time.Time { return now }
+	defer func() { timeNow = time.Now }()
+
+	t.Run("Cached tokens are returned with when they expire", func(t *testing.T) {
+		secErr := SecCacheTokens(testConnection, &AuthToken{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 300, RefreshExpiresIn: 1800})
+		assert.Nil(t, secErr)
+
+		tokens := SecGetCachedTokens(testConnection)
+		assert.Equal(t, "access", tokens.AccessToken)
+		assert.Equal(t, "refresh", tokens.RefreshToken)
+		assert.True(t, tokens.AccessExpiresAt.Equal(now.Add(5*time.Minute)))
+		assert.True(t, tokens.RefreshExpiresAt.Equal(now.Add(30*time.Minute)))
+		assert.True(t, tokens.HasValidAccessToken())
+		assert.True(t, tokens.HasValidRefreshToken())
+	})
+
+	t.Run("An access token about to expire is not valid", func(t *testing.T) {
+		tokens := &CachedTokens{AccessToken: "access", AccessExpiresAt: now.Add(tokenExpiryMargin - time.Second)}
+		assert.False(t, tokens.HasValidAccessToken())
+		tokens.AccessExpiresAt = now.Add(tokenExpiryMargin + time.Second)
+		assert.True(t, tokens.HasValidAccessToken())
+	})
+
+	t.Run("A token without an expiry time is valid, a missing token is not", func(t *testing.T) {
+		tokens := &CachedTokens{AccessToken: "access"}
+		assert.True(t, tokens.HasValidAccessToken())
+		assert.False(t, tokens.HasValidRefreshToken())
+	})
+
+	t.Run("Tokens cached without expiry times are still returned", func(t *testing.T) {
+		connection, _ := connections.GetConnectionByID(testConnection)
+		deleteConnectionSecret(newKeyringEntry(connection, KeyringKindToken, "", tokenExpiryKey))
+		tokens := SecGetCachedTokens(testConnection)
+		assert.Equal(t, "access", tokens.AccessToken)
+		assert.True(t, tokens.AccessExpiresAt.IsZero())
+		assert.True(t, tokens.HasValidAccessToken())
+	})
+
+	t.Run("Tokens are kept under the user they were issued to", func(t *testing.T) {
+		claims := base64.RawURLEncoding.EncodeToString([]byte(`{"preferred_username":"Developer"}`))
+		secErr := SecCacheTokens(testConnection, &AuthToken{AccessToken: "header." + claims + ".signature", RefreshToken: "refresh"})
+		assert.Nil(t, secErr)
+		connection, _ := connections.GetConnectionByID(testConnection)
+		entry := newKeyringEntry(connection, KeyringKindToken, "developer", "refresh_token")
+		token, err := GetSecret(entry.Service, entry.Account)
+		assert.Nil(t, err)
+		assert.Equal(t, "refresh", token)
+		assert.Equal(t, "refresh", SecGetCachedTokens(testConnection).RefreshToken)
+	})
+
+	t.Run("Tokens kept under the connection ID by an older cwctl are moved", func(t *testing.T) {
+		SecKeyringDelete(testConnection, "")
+		legacyService := strings.ToLower(KeyringServiceName + "." + testConnection)
+		SetSecret(legacyService, "access_token", "access")
+		SetSecret(legacyService, "refresh_token", "refresh")
+		tokens := SecGetCachedTokens(testConnection)
+		assert.Equal(t, "access", tokens.AccessToken)
+		assert.Equal(t, "refresh", tokens.RefreshToken)
+		_, err := GetSecret(legacyService, "access_token")
+		assert.NotNil(t, err)
+	})
+}
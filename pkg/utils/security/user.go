@@ -16,8 +16,11 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 
+	"github.com/eclipse/codewind-installer/pkg/utils"
 	"github.com/urfave/cli"
 )
 
@@ -30,8 +33,15 @@ type RegisteredUsers struct {
 type RegisteredUser struct {
 	ID       string `json:"id"`
 	Username string `json:"username"`
+	Email    string `json:"email,omitempty"`
+	Enabled  bool   `json:"enabled"`
+	// FederationLink is the ID of the federation provider, such as LDAP, the user was imported from
+	FederationLink string `json:"federationLink,omitempty"`
 }
 
+// userPageSize is how many users are requested at a time when listing the users of a realm
+const userPageSize = 100
+
 // SecUserCreate : Create a new realm in Keycloak
 func SecUserCreate(c *cli.Context) *SecError {
 
@@ -49,6 +59,11 @@ func SecUserCreate(c *cli.Context) *SecError {
 		accesstoken = authToken.AccessToken
 	}
 
+	secErr := checkUserCreatable(http.DefaultClient, c, accesstoken)
+	if secErr != nil {
+		return secErr
+	}
+
 	// build REST request
 	url := hostname + "/auth/admin/realms/" + realm + "/users"
 
@@ -174,6 +189,10 @@ func SecUserSetPW(c *cli.Context) *SecError {
 	if secError != nil {
 		return secError
 	}
+	secError = checkUserWritable(http.DefaultClient, c, accesstoken, registeredUser)
+	if secError != nil {
+		return secError
+	}
 
 	// build REST request
 	url := hostname + "/auth/admin/realms/" + realm + "/users/" + registeredUser.ID + "/reset-password"
@@ -212,3 +231,55 @@ func SecUserSetPW(c *cli.Context) *SecError {
 
 	return nil
 }
+
+// SecUserList : List the users of a realm, only those whose username, email or name contains the search flag when
+// it is given
+func SecUserList(httpClient utils.HTTPClient, c *cli.Context) ([]RegisteredUser, *SecError) {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return nil, secErr
+	}
+	return listUsers(httpClient, c, accesstoken, strings.TrimSpace(c.String("search")))
+}
+
+// SecUserDelete : Delete an existing user from a realm
+func SecUserDelete(httpClient utils.HTTPClient, c *cli.Context) *SecError {
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return secErr
+	}
+	user, secErr := getUser(httpClient, c, accesstoken, strings.TrimSpace(c.String("name")))
+	if secErr != nil {
+		return secErr
+	}
+	secErr = checkUserWritable(httpClient, c, accesstoken, user)
+	if secErr != nil {
+		return secErr
+	}
+	_, secErr = sendAdminRequest(httpClient, c, accesstoken, "DELETE", "/users/"+user.ID, nil, http.StatusNoContent)
+	return secErr
+}
+
+// listUsers : the users of the realm, a page at a time as Keycloak only returns a limited number per request
+func listUsers(httpClient utils.HTTPClient, c *cli.Context, accesstoken string, search string) ([]RegisteredUser, *SecError) {
+	users := []RegisteredUser{}
+	for first := 0; ; first += userPageSize {
+		query := "/users?first=" + strconv.Itoa(first) + "&max=" + strconv.Itoa(userPageSize)
+		if search != "" {
+			query += "&search=" + url.QueryEscape(search)
+		}
+		body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", query, nil, http.StatusOK)
+		if secErr != nil {
+			return nil, secErr
+		}
+		page := []RegisteredUser{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, &SecError{errOpResponseFormat, err, textUnableToParse}
+		}
+		users = append(users, page...)
+		if len(page) < userPageSize {
+			return users, nil
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func Test_Users(t *testing.T) {
+	set := flag.NewFlagSet("tests", 0)
+	set.String("host", "https://mockserver", "doc")
+	set.String("realm", "codewind", "doc")
+	set.String("accesstoken", "admintoken", "doc")
+	set.String("name", "developer", "doc")
+	set.String("search", "", "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	t.Run("Lists every user, a page at a time", func(t *testing.T) {
+		page := []RegisteredUser{}
+		for i := 0; i < userPageSize; i++ {
+			page = append(page, RegisteredUser{ID: strconv.Itoa(i), Username: "user" + strconv.Itoa(i), Enabled: true})
+		}
+		fullPage, _ := json.Marshal(page)
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, string(fullPage)),
+			mockResponse(http.StatusOK, `[{"id":"x","username":"developer","email":"dev@example.com","enabled":true}]`),
+		}}
+		users, secErr := SecUserList(mockClient, c)
+		assert.Nil(t, secErr)
+		assert.Len(t, users, userPageSize+1)
+		assert.Equal(t, RegisteredUser{ID: "x", Username: "developer", Email: "dev@example.com", Enabled: true}, users[userPageSize])
+		assert.Equal(t, "first=0&max=100", mockClient.requests[0].URL.RawQuery)
+		assert.Equal(t, "first=100&max=100", mockClient.requests[1].URL.RawQuery)
+	})
+
+	t.Run("Searches the users", func(t *testing.T) {
+		c.Set("search", "dev ops")
+		defer c.Set("search", "")
+		mockClient := &clientMockSequence{responses: []*http.Response{mockResponse(http.StatusOK, `[]`)}}
+		users, secErr := SecUserList(mockClient, c)
+		assert.Nil(t, secErr)
+		assert.Empty(t, users)
+		assert.Equal(t, "dev ops", mockClient.requests[0].URL.Query().Get("search"))
+	})
+
+	t.Run("Deletes the user with that exact name", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `[{"id":"1","username":"developer2"},{"id":"2","username":"developer"}]`),
+			mockResponse(http.StatusNoContent, ""),
+		}}
+		assert.Nil(t, SecUserDelete(mockClient, c))
+		assert.Equal(t, "DELETE", mockClient.requests[1].Method)
+		assert.Equal(t, "/auth/admin/realms/codewind/users/2", mockClient.requests[1].URL.Path)
+	})
+
+	t.Run("Does not delete a user of a read only directory", func(t *testing.T) {
+		mockClient := &clientMockSequence{responses: []*http.Response{
+			mockResponse(http.StatusOK, `[{"id":"2","username":"developer","federationLink":"ldap1"}]`),
+			mockResponse(http.StatusOK, mockLDAPProvider),
+		}}
+		secErr := SecUserDelete(mockClient, c)
+		assert.Equal(t, errOpFederated, secErr.Op)
+		assert.Len(t, mockClient.requests, 2)
+	})
+}
@@ -0,0 +1,222 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse/codewind-installer/pkg/utils"
+	"github.com/urfave/cli"
+)
+
+// Outcomes of importing a user
+const (
+	UserImportCreated = "created"
+	UserImportExists  = "exists"
+	UserImportFailed  = "failed"
+)
+
+// UserImport : A user to create, read from a row of the import file
+type UserImport struct {
+	Username  string
+	Password  string
+	Email     string
+	FirstName string
+	LastName  string
+	Roles     []string
+}
+
+// UserImportResult : The outcome of importing one user
+type UserImportResult struct {
+	Username string   `json:"username"`
+	Status   string   `json:"status"`
+	Roles    []string `json:"roles,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// importColumns are the columns an import file may have, username and password are required
+var importColumns = []string{"username", "password", "email", "firstName", "lastName", "roles"}
+
+// ReadUserImports : Reads the users to import from a CSV file with a header row naming its columns, any of
+// username, password, email, firstName, lastName and roles. Roles are separated by semicolons
+func ReadUserImports(reader io.Reader) ([]UserImport, error) {
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("The file is empty, the first row must name the columns, e.g. username,password,roles")
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		name = strings.TrimSpace(name)
+		known := false
+		for _, column := range importColumns {
+			if strings.EqualFold(name, column) {
+				columns[column] = i
+				known = true
+			}
+		}
+		if !known {
+			return nil, errors.New("Unknown column '" + name + "', must be one of: " + strings.Join(importColumns, ", "))
+		}
+	}
+	for _, required := range []string{"username", "password"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.New("The file has no " + required + " column")
+		}
+	}
+
+	value := func(row []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	users := []UserImport{}
+	for line, row := range rows[1:] {
+		user := UserImport{
+			Username:  value(row, "username"),
+			Password:  value(row, "password"),
+			Email:     value(row, "email"),
+			FirstName: value(row, "firstName"),
+			LastName:  value(row, "lastName"),
+			Roles:     []string{},
+		}
+		if user.Username == "" || user.Password == "" {
+			return nil, errors.New("Row " + strconv.Itoa(line+2) + " needs both a username and a password")
+		}
+		for _, role := range strings.Split(value(row, "roles"), ";") {
+			if role = strings.TrimSpace(role); role != "" {
+				user.Roles = append(user.Roles, role)
+			}
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// SecUserImport : Creates the users of the import file given by the file flag, with their initial passwords and
+// roles. With the temporary flag the users must change their password when they first log in. Users which already
+// exist are left as they are, and a user which fails does not stop the others from being imported, so the result
+// of each user is returned with an error when any failed
+func SecUserImport(httpClient utils.HTTPClient, c *cli.Context) ([]UserImportResult, *SecError) {
+	file, err := os.Open(strings.TrimSpace(c.String("file")))
+	if err != nil {
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+	defer file.Close()
+	users, err := ReadUserImports(file)
+	if err != nil {
+		return nil, &SecError{errOpCLICommand, err, err.Error()}
+	}
+
+	accesstoken, secErr := getAdminAccessToken(httpClient, c)
+	if secErr != nil {
+		return nil, secErr
+	}
+	secErr = checkUserCreatable(httpClient, c, accesstoken)
+	if secErr != nil {
+		return nil, secErr
+	}
+	existing, secErr := listUsers(httpClient, c, accesstoken, "")
+	if secErr != nil {
+		return nil, secErr
+	}
+	existingNames := map[string]bool{}
+	for _, user := range existing {
+		existingNames[strings.ToLower(user.Username)] = true
+	}
+
+	results := []UserImportResult{}
+	roles := map[string]Role{}
+	failed := 0
+	for _, user := range users {
+		result := UserImportResult{Username: user.Username, Status: UserImportCreated, Roles: user.Roles}
+		if existingNames[strings.ToLower(user.Username)] {
+			result.Status = UserImportExists
+			result.Roles = nil
+		} else {
+			secErr = importUser(httpClient, c, accesstoken, user, c.Bool("temporary"), roles)
+			if secErr != nil {
+				result.Status = UserImportFailed
+				result.Error = secErr.Desc
+				failed++
+			}
+			existingNames[strings.ToLower(user.Username)] = true
+		}
+		results = append(results, result)
+	}
+	if failed > 0 {
+		err = errors.New(strconv.Itoa(failed) + " of " + strconv.Itoa(len(users)) + " users could not be imported")
+		return results, &SecError{errOpCreate, err, err.Error()}
+	}
+	return results, nil
+}
+
+// importUser : Creates a user with its password, then grants it its roles. The roles of the realm are looked up
+// once each and kept in roles
+func importUser(httpClient utils.HTTPClient, c *cli.Context, accesstoken string, user UserImport, temporary bool, roles map[string]Role) *SecError {
+	type credential struct {
+		Type      string `json:"type"`
+		Value     string `json:"value"`
+		Temporary bool   `json:"temporary"`
+	}
+	payload := struct {
+		Enabled     bool         `json:"enabled"`
+		Username    string       `json:"username"`
+		Email       string       `json:"email,omitempty"`
+		FirstName   string       `json:"firstName,omitempty"`
+		LastName    string       `json:"lastName,omitempty"`
+		Credentials []credential `json:"credentials"`
+	}{true, user.Username, user.Email, user.FirstName, user.LastName, []credential{{"password", user.Password, temporary}}}
+	_, secErr := sendAdminRequest(httpClient, c, accesstoken, "POST", "/users", payload, http.StatusCreated)
+	if secErr != nil || len(user.Roles) == 0 {
+		return secErr
+	}
+
+	grants := []Role{}
+	for _, name := range user.Roles {
+		role, ok := roles[name]
+		if !ok {
+			body, secErr := sendAdminRequest(httpClient, c, accesstoken, "GET", "/roles/"+url.PathEscape(name), nil, http.StatusOK)
+			if secErr != nil {
+				return &SecError{secErr.Op, secErr.Err, "Created without its roles, role " + name + ": " + secErr.Desc}
+			}
+			err := json.Unmarshal(body, &role)
+			if err != nil {
+				return &SecError{errOpResponseFormat, err, textUnableToParse}
+			}
+			roles[name] = role
+		}
+		grants = append(grants, role)
+	}
+	created, secErr := getUser(httpClient, c, accesstoken, user.Username)
+	if secErr != nil {
+		return secErr
+	}
+	_, secErr = sendAdminRequest(httpClient, c, accesstoken, "POST", "/users/"+created.ID+"/role-mappings/realm", grants, http.StatusNoContent)
+	if secErr != nil {
+		return &SecError{secErr.Op, secErr.Err, "Created without its roles: " + secErr.Desc}
+	}
+	return nil
+}
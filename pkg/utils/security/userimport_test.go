@@ -0,0 +1,84 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package security
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func Test_ReadUserImports(t *testing.T) {
+	t.Run("Reads the columns named by the header in any order", func(t *testing.T) {
+		users, err := ReadUserImports(strings.NewReader("roles,username,password,email\ncodewind-workspace; admin ,alice,s3cret,alice@example.com\n,bob,pw,\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []UserImport{
+			{Username: "alice", Password: "s3cret", Email: "alice@example.com", Roles: []string{"codewind-workspace", "admin"}},
+			{Username: "bob", Password: "pw", Roles: []string{}},
+		}, users)
+	})
+	t.Run("Requires the username and password columns", func(t *testing.T) {
+		_, err := ReadUserImports(strings.NewReader("username,roles\nalice,admin\n"))
+		assert.EqualError(t, err, "The file has no password column")
+	})
+	t.Run("Rejects unknown columns", func(t *testing.T) {
+		_, err := ReadUserImports(strings.NewReader("username,password,group\n"))
+		assert.NotNil(t, err)
+	})
+	t.Run("Names the row missing a password", func(t *testing.T) {
+		_, err := ReadUserImports(strings.NewReader("username,password\nalice,pw\nbob,\n"))
+		assert.EqualError(t, err, "Row 3 needs both a username and a password")
+	})
+}
+
+func Test_SecUserImport(t *testing.T) {
+	file, _ := ioutil.TempFile("", "users*.csv")
+	defer os.Remove(file.Name())
+	file.WriteString("username,password,roles\nalice,pw1,codewind-workspace\nbob,pw2,\ncarol,pw3,codewind-workspace\n")
+	file.Close()
+
+	set := flag.NewFlagSet("tests", 0)
+	set.String("host", "https://mockserver", "doc")
+	set.String("realm", "codewind", "doc")
+	set.String("accesstoken", "admintoken", "doc")
+	set.String("file", file.Name(), "doc")
+	set.Bool("temporary", true, "doc")
+	c := cli.NewContext(nil, set, nil)
+
+	mockClient := &clientMockSequence{responses: []*http.Response{
+		mockResponse(http.StatusOK, `[]`),
+		mockResponse(http.StatusOK, `[{"id":"b","username":"bob"}]`),
+		mockResponse(http.StatusCreated, ""),
+		mockResponse(http.StatusOK, `{"id":"r1","name":"codewind-workspace"}`),
+		mockResponse(http.StatusOK, `[{"id":"a","username":"alice"}]`),
+		mockResponse(http.StatusNoContent, ""),
+		mockResponse(http.StatusConflict, `{"errorMessage":"User exists with same email"}`),
+	}}
+	results, secErr := SecUserImport(mockClient, c)
+	assert.Equal(t, errOpCreate, secErr.Op)
+	assert.Equal(t, "1 of 3 users could not be imported", secErr.Desc)
+	assert.Equal(t, []UserImportResult{
+		{Username: "alice", Status: UserImportCreated, Roles: []string{"codewind-workspace"}},
+		{Username: "bob", Status: UserImportExists},
+		{Username: "carol", Status: UserImportFailed, Roles: []string{"codewind-workspace"}, Error: "User exists with same email"},
+	}, results)
+
+	create, _ := ioutil.ReadAll(mockClient.requests[2].Body)
+	assert.JSONEq(t, `{"enabled":true,"username":"alice","credentials":[{"type":"password","value":"pw1","temporary":true}]}`, string(create))
+	assert.Equal(t, "/auth/admin/realms/codewind/users/a/role-mappings/realm", mockClient.requests[5].URL.Path)
+}
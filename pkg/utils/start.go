@@ -0,0 +1,269 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	logr "github.com/sirupsen/logrus"
+)
+
+// Phases of starting Codewind, in the order they run
+const (
+	PhaseComposeUp         = "compose-up"
+	PhaseNetwork           = "network"
+	PhasePFEHealth         = "pfe-health"
+	PhasePerformanceHealth = "performance-health"
+)
+
+// Outcomes of a start phase
+const (
+	PhaseStatusOK       = "ok"
+	PhaseStatusFailed   = "failed"
+	PhaseStatusTimedOut = "timedout"
+	PhaseStatusSkipped  = "skipped"
+)
+
+const startPollInterval = time.Second
+
+// StartTimeouts : how long each phase of starting Codewind may take
+type StartTimeouts struct {
+	ComposeUp         time.Duration
+	Network           time.Duration
+	PFEHealth         time.Duration
+	PerformanceHealth time.Duration
+}
+
+// DefaultStartTimeouts : the timeouts used unless overridden
+func DefaultStartTimeouts() StartTimeouts {
+	return StartTimeouts{
+		ComposeUp:         5 * time.Minute,
+		Network:           30 * time.Second,
+		PFEHealth:         2 * time.Minute,
+		PerformanceHealth: time.Minute,
+	}
+}
+
+// StartOptions : how long starting Codewind may take, and whether to wait for it to be ready
+type StartOptions struct {
+	Timeouts StartTimeouts
+	// Timeout limits the whole start when set, cutting short the phase running when it is reached
+	Timeout time.Duration
+	// NoWait returns once the containers are created, skipping the phases that wait for them to be ready
+	NoWait bool
+	// Progress receives a line as each phase starts and finishes, when set
+	Progress io.Writer
+}
+
+// StartPhase : the outcome of one phase of starting Codewind
+type StartPhase struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Timeout  string `json:"timeout"`
+	Error    string `json:"error,omitempty"`
+}
+
+// StartReport : the outcome of each phase of starting Codewind, and the state of the containers if one failed
+type StartReport struct {
+	Status     string               `json:"status"`
+	URL        string               `json:"url,omitempty"`
+	Phases     []StartPhase         `json:"phases"`
+	Containers []ContainerDiagnosis `json:"containers,omitempty"`
+}
+
+// StartCodewind : Starts the Codewind containers from a generated docker-compose file, then waits for the network, PFE and the
+// performance container in turn. Each phase has its own timeout, and the phases after one that fails are skipped.
+// When a phase fails, the containers are described in the report with the last log lines of the failing ones
+func StartCodewind(composeFile string, ports PortConfig, healthEndpoint string, options StartOptions) *StartReport {
+	report := StartReport{Status: PhaseStatusOK, Phases: []StartPhase{}}
+	timeouts := options.Timeouts
+	pfeURL := ""
+	phases := []struct {
+		name    string
+		timeout time.Duration
+		run     func(ctx context.Context) error
+	}{
+		{PhaseComposeUp, timeouts.ComposeUp, func(ctx context.Context) error {
+			return DockerCompose(ctx, composeFile)
+		}},
+		{PhaseNetwork, timeouts.Network, waitForCodewindNetwork},
+		{PhasePFEHealth, timeouts.PFEHealth, func(ctx context.Context) error {
+			var err error
+			pfeURL, err = waitForPFEHealth(ctx, healthEndpoint)
+			return err
+		}},
+		{PhasePerformanceHealth, timeouts.PerformanceHealth, func(ctx context.Context) error {
+			return waitForHTTP(ctx, ports.PerformanceURL(), func(statusCode int) bool { return statusCode < 500 })
+		}},
+	}
+
+	var deadline time.Time
+	if options.Timeout > 0 {
+		deadline = time.Now().Add(options.Timeout)
+	}
+	failedPhase := ""
+	for _, phase := range phases {
+		result := StartPhase{Name: phase.name, Status: PhaseStatusSkipped, Timeout: phase.timeout.String()}
+		waits := phase.name != PhaseComposeUp
+		if report.Status == PhaseStatusOK && !(options.NoWait && waits) {
+			timeout := phase.timeout
+			if !deadline.IsZero() && time.Until(deadline) < timeout {
+				timeout = time.Until(deadline)
+			}
+			progress(options.Progress, "Waiting for %v (timeout %v)", phase.name, timeout.Round(time.Second))
+			if timeout <= 0 {
+				result = StartPhase{Name: phase.name, Status: PhaseStatusTimedOut, Duration: "0s", Timeout: "0s",
+					Error: "the start timeout of " + options.Timeout.String() + " was reached"}
+			} else {
+				result = runStartPhase(phase.name, timeout, phase.run)
+			}
+			if result.Status != PhaseStatusOK {
+				report.Status = PhaseStatusFailed
+				failedPhase = phase.name
+				progress(options.Progress, "%v %v after %v: %v", phase.name, result.Status, result.Duration, result.Error)
+			} else {
+				progress(options.Progress, "%v ok after %v", phase.name, result.Duration)
+			}
+		}
+		report.Phases = append(report.Phases, result)
+	}
+
+	if options.NoWait && report.Status == PhaseStatusOK {
+		// PFE is published as soon as its container is created, so its URL is known without waiting
+		hostname, port := GetPFEHostAndPort()
+		if port != "" {
+			pfeURL = "http://" + net.JoinHostPort(hostname, port)
+		}
+	}
+	if failedPhase != "" {
+		containers, err := DiagnoseContainers(ActiveProfile(), failedPhase)
+		if err != nil {
+			logr.Debugf("Unable to describe the Codewind containers: %v", err)
+		}
+		report.Containers = containers
+	}
+	report.URL = pfeURL
+	return &report
+}
+
+func progress(out io.Writer, format string, args ...interface{}) {
+	if out != nil {
+		fmt.Fprintf(out, format+"\n", args...)
+	}
+}
+
+func runStartPhase(name string, timeout time.Duration, run func(ctx context.Context) error) StartPhase {
+	logr.Debugf("Starting phase %v with a timeout of %v", name, timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	started := time.Now()
+	err := run(ctx)
+	result := StartPhase{
+		Name:     name,
+		Status:   PhaseStatusOK,
+		Duration: time.Since(started).Round(time.Millisecond).String(),
+		Timeout:  timeout.String(),
+	}
+	if err != nil {
+		result.Status = PhaseStatusFailed
+		result.Error = err.Error()
+		if ctx.Err() == context.DeadlineExceeded {
+			result.Status = PhaseStatusTimedOut
+		}
+	}
+	logr.Debugf("Phase %v finished after %v: %v", name, result.Duration, result.Status)
+	return result
+}
+
+// pollUntil : calls check every second until it reports done, or the context ends. On timeout the last
+// error from check is included, as it usually explains what was being waited for
+func pollUntil(ctx context.Context, check func() (bool, error)) error {
+	for {
+		done, err := check()
+		if done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("%v, last error: %v", ctx.Err(), err)
+			}
+			return ctx.Err()
+		case <-time.After(startPollInterval):
+		}
+	}
+}
+
+func waitForCodewindNetwork(ctx context.Context) error {
+	networkPrefix := ActiveProfile().NetworkPrefix()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	return pollUntil(ctx, func() (bool, error) {
+		networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, network := range networks {
+			if strings.HasPrefix(network.Name, networkPrefix) {
+				return true, nil
+			}
+		}
+		return false, errors.New("network " + networkPrefix + " not found")
+	})
+}
+
+func waitForPFEHealth(ctx context.Context, healthEndpoint string) (string, error) {
+	pfeURL := ""
+	err := pollUntil(ctx, func() (bool, error) {
+		hostname, port := GetPFEHostAndPort()
+		if port == "" {
+			return false, errors.New("the PFE container has no published port")
+		}
+		pfeURL = "http://" + net.JoinHostPort(hostname, port)
+		return checkHTTP(ctx, pfeURL+healthEndpoint, func(statusCode int) bool { return statusCode == http.StatusOK })
+	})
+	return pfeURL, err
+}
+
+func waitForHTTP(ctx context.Context, url string, healthy func(statusCode int) bool) error {
+	return pollUntil(ctx, func() (bool, error) {
+		return checkHTTP(ctx, url, healthy)
+	})
+}
+
+func checkHTTP(ctx context.Context, url string, healthy func(statusCode int) bool) (bool, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return true, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if !healthy(resp.StatusCode) {
+		return false, fmt.Errorf("%v responded with %v", url, resp.Status)
+	}
+	return true, nil
+}
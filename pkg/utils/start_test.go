@@ -0,0 +1,63 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RunStartPhase(t *testing.T) {
+	t.Run("A phase that succeeds is ok", func(t *testing.T) {
+		result := runStartPhase(PhaseNetwork, time.Second, func(ctx context.Context) error { return nil })
+		assert.Equal(t, PhaseStatusOK, result.Status)
+		assert.Equal(t, "1s", result.Timeout)
+		assert.Empty(t, result.Error)
+	})
+
+	t.Run("A phase that fails reports its error", func(t *testing.T) {
+		result := runStartPhase(PhaseComposeUp, time.Second, func(ctx context.Context) error { return errors.New("compose failed") })
+		assert.Equal(t, PhaseStatusFailed, result.Status)
+		assert.Equal(t, "compose failed", result.Error)
+	})
+
+	t.Run("A phase that runs out of time is timed out, with the last error", func(t *testing.T) {
+		result := runStartPhase(PhasePFEHealth, 10*time.Millisecond, func(ctx context.Context) error {
+			return pollUntil(ctx, func() (bool, error) { return false, errors.New("not yet") })
+		})
+		assert.Equal(t, PhaseStatusTimedOut, result.Status)
+		assert.Contains(t, result.Error, "not yet")
+	})
+}
+
+func Test_WaitForHTTP(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := waitForHTTP(ctx, server.URL, func(statusCode int) bool { return statusCode == http.StatusOK })
+	assert.Nil(t, err)
+	assert.Equal(t, 2, requests)
+}
@@ -0,0 +1,126 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// StartLogLines : how many of the last log lines of a failing container are included in a start report
+const StartLogLines = 20
+
+// ContainerDiagnosis : the state of a Codewind container when starting failed, with its last log lines if it is
+// the container that failed
+type ContainerDiagnosis struct {
+	Name    string   `json:"name"`
+	Image   string   `json:"image"`
+	State   string   `json:"state"`
+	Status  string   `json:"status"`
+	Failing bool     `json:"failing"`
+	Logs    []string `json:"logs,omitempty"`
+}
+
+// DiagnoseContainers : Describes the containers of a profile after the phase failedPhase failed. A container is
+// failing when it is not running, reports itself unhealthy, or is the one the failed phase waited for
+func DiagnoseContainers(profile LocalProfile, failedPhase string) ([]ContainerDiagnosis, error) {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return nil, err
+	}
+	// Include stopped containers, as a container which exited is the usual reason for a start failing
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+	diagnoses := []ContainerDiagnosis{}
+	for _, container := range containers {
+		if !profile.Owns(container) || CodewindService(container) == "" {
+			continue
+		}
+		diagnosis := diagnoseContainer(container, failedPhase)
+		if diagnosis.Failing {
+			logs, err := cli.ContainerLogs(ctx, container.ID, types.ContainerLogsOptions{
+				ShowStdout: true,
+				ShowStderr: true,
+				Tail:       strconv.Itoa(StartLogLines),
+			})
+			if err == nil {
+				diagnosis.Logs = lastLogLines(logs, StartLogLines)
+				logs.Close()
+			}
+		}
+		diagnoses = append(diagnoses, diagnosis)
+	}
+	return diagnoses, nil
+}
+
+func diagnoseContainer(container types.Container, failedPhase string) ContainerDiagnosis {
+	name := container.ID
+	if len(container.Names) > 0 {
+		name = strings.TrimPrefix(container.Names[0], "/")
+	}
+	awaited := (failedPhase == PhasePFEHealth && strings.HasPrefix(container.Image, "codewind-pfe")) ||
+		(failedPhase == PhasePerformanceHealth && strings.HasPrefix(container.Image, "codewind-performance"))
+	return ContainerDiagnosis{
+		Name:    name,
+		Image:   container.Image,
+		State:   container.State,
+		Status:  container.Status,
+		Failing: container.State != "running" || strings.Contains(container.Status, "unhealthy") || awaited,
+	}
+}
+
+// lastLogLines : the last max lines of a container log. Logs of containers without a terminal are sent as frames with
+// an 8 byte header holding the stream and frame length, which are removed when present
+func lastLogLines(logs io.Reader, max int) []string {
+	raw, err := ioutil.ReadAll(logs)
+	if err != nil && len(raw) == 0 {
+		return nil
+	}
+	text := raw
+	if isLogFrame(raw) {
+		text = []byte{}
+		for len(raw) >= 8 && isLogFrame(raw) {
+			size := int(binary.BigEndian.Uint32(raw[4:8]))
+			end := 8 + size
+			if end > len(raw) {
+				end = len(raw)
+			}
+			text = append(text, raw[8:end]...)
+			raw = raw[end:]
+		}
+	}
+	lines := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if len(lines) > max {
+		lines = lines[len(lines)-max:]
+	}
+	return lines
+}
+
+func isLogFrame(raw []byte) bool {
+	return len(raw) >= 8 && raw[0] <= 2 && raw[1] == 0 && raw[2] == 0 && raw[3] == 0
+}
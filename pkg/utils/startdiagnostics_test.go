@@ -0,0 +1,56 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func logFrame(stream byte, text string) []byte {
+	header := []byte{stream, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(header[4:], uint32(len(text)))
+	return append(header, text...)
+}
+
+func Test_LastLogLines(t *testing.T) {
+	t.Run("Removes the headers of multiplexed logs", func(t *testing.T) {
+		logs := append(logFrame(1, "starting\n"), logFrame(2, "Error: port in use\n")...)
+		assert.Equal(t, []string{"starting", "Error: port in use"}, lastLogLines(bytes.NewReader(logs), 20))
+	})
+	t.Run("Reads the logs of containers with a terminal as they are", func(t *testing.T) {
+		logs := strings.NewReader("one\r\ntwo\r\nthree\r\n")
+		assert.Equal(t, []string{"two", "three"}, lastLogLines(logs, 2))
+	})
+}
+
+func Test_DiagnoseContainer(t *testing.T) {
+	pfe := types.Container{Names: []string{"/codewind-pfe"}, Image: "codewind-pfe-amd64:latest", State: "running", Status: "Up 2 minutes"}
+	performance := types.Container{Names: []string{"/codewind-performance"}, Image: "codewind-performance-amd64:latest", State: "exited", Status: "Exited (1) 1 minute ago"}
+
+	t.Run("The container a failed phase waited for is failing", func(t *testing.T) {
+		diagnosis := diagnoseContainer(pfe, PhasePFEHealth)
+		assert.Equal(t, "codewind-pfe", diagnosis.Name)
+		assert.True(t, diagnosis.Failing)
+		assert.False(t, diagnoseContainer(pfe, PhasePerformanceHealth).Failing)
+	})
+	t.Run("A container which stopped or is unhealthy is failing", func(t *testing.T) {
+		assert.True(t, diagnoseContainer(performance, PhasePFEHealth).Failing)
+		pfe.Status = "Up 2 minutes (unhealthy)"
+		assert.True(t, diagnoseContainer(pfe, PhaseNetwork).Failing)
+	})
+}
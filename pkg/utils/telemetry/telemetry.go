@@ -0,0 +1,181 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+)
+
+// Endpoint is where usage reports are sent. It is empty unless a release sets it with -ldflags, e.g.
+// go build -ldflags "-X github.com/eclipse/codewind-installer/pkg/utils/telemetry.Endpoint=<url>", and
+// nothing is recorded without it
+var Endpoint = ""
+
+// ResultSuccess is the result of a command which succeeded, failed commands report the code of their error category
+const ResultSuccess = "success"
+
+const (
+	// batchSize events are sent together, so that most commands do not make a request
+	batchSize = 20
+	// maxSpooled events are kept while they cannot be sent, the oldest are dropped beyond it
+	maxSpooled  = 500
+	sendTimeout = 3 * time.Second
+)
+
+// Event : An anonymous report of one command run. Only the name of the command is recorded, never its arguments,
+// flags or output, so no project contents, names or URLs are sent
+type Event struct {
+	Command    string `json:"command"`
+	DurationMs int64  `json:"durationMs"`
+	Result     string `json:"result"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	Version    string `json:"version"`
+}
+
+// NewEvent : The event of a command of cwctl version which ran for duration, with its result
+func NewEvent(command string, duration time.Duration, result string, version string) Event {
+	return Event{
+		Command:    command,
+		DurationMs: int64(duration / time.Millisecond),
+		Result:     result,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Version:    version,
+	}
+}
+
+// Enabled : true when the user has opted in with `cwctl config set telemetry on`, and the build has an endpoint
+func Enabled() bool {
+	if Endpoint == "" {
+		return false
+	}
+	cliConfig, configErr := cliconfig.LoadEffectiveConfig()
+	return configErr == nil && strings.EqualFold(cliConfig.Telemetry, "on")
+}
+
+// Record : Spools the event when telemetry is enabled, sending the spooled events once there is a batch of them.
+// Telemetry never fails a command, so problems are only logged at debug level, and events which cannot be sent,
+// such as when offline, stay spooled until the next batch
+func Record(event Event) {
+	if !Enabled() {
+		return
+	}
+	err := record(spoolFilename(), event, &http.Client{Timeout: sendTimeout}, Endpoint)
+	if err != nil {
+		logr.Debugln("Unable to report usage: " + err.Error())
+	}
+}
+
+// Discard : Deletes the spooled events, once the user has opted out
+func Discard() error {
+	return removeSpool(spoolFilename())
+}
+
+func spoolFilename() string {
+	return path.Join(cliconfig.GetStateDir(), "telemetry.jsonl")
+}
+
+func record(spool string, event Event, client *http.Client, endpoint string) error {
+	events, err := readSpool(spool)
+	if err != nil {
+		return err
+	}
+	events = append(events, event)
+	if len(events) > maxSpooled {
+		events = events[len(events)-maxSpooled:]
+	}
+	if len(events) >= batchSize {
+		err = send(client, endpoint, events)
+		if err == nil {
+			return removeSpool(spool)
+		}
+		logr.Debugln("Unable to send usage reports, keeping them for later: " + err.Error())
+	}
+	return writeSpool(spool, events)
+}
+
+// readSpool : The events spooled as one JSON object per line, skipping any line which cannot be parsed
+func readSpool(spool string) ([]Event, error) {
+	events := []Event{}
+	file, err := os.Open(spool)
+	if os.IsNotExist(err) {
+		return events, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		event := Event{}
+		if json.Unmarshal(scanner.Bytes(), &event) == nil {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+func removeSpool(spool string) error {
+	err := os.Remove(spool)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func writeSpool(spool string, events []Event) error {
+	var body bytes.Buffer
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		body.Write(line)
+		body.WriteString("\n")
+	}
+	err := os.MkdirAll(path.Dir(spool), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(spool, body.Bytes(), 0600)
+}
+
+// send : Posts the events to the endpoint as {"events": [...]}
+func send(client *http.Client, endpoint string, events []Event) error {
+	body, err := json.Marshal(map[string][]Event{"events": events})
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v responded with status %v", endpoint, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package telemetry
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Record(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "cwctl-telemetry")
+	defer os.RemoveAll(dir)
+	spool := path.Join(dir, "state", "telemetry.jsonl")
+
+	received := [][]Event{}
+	online := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body := map[string][]Event{}
+		json.NewDecoder(r.Body).Decode(&body)
+		received = append(received, body["events"])
+	}))
+	defer server.Close()
+
+	event := NewEvent("project bind", 1500*time.Millisecond, ResultSuccess, "0.7.0")
+
+	t.Run("Only records the name of the command", func(t *testing.T) {
+		body, _ := json.Marshal(event)
+		assert.Contains(t, string(body), `"command":"project bind","durationMs":1500,"result":"success"`)
+	})
+	t.Run("Spools events until there is a batch", func(t *testing.T) {
+		for i := 0; i < batchSize-1; i++ {
+			assert.Nil(t, record(spool, event, server.Client(), server.URL))
+		}
+		assert.Empty(t, received)
+		events, err := readSpool(spool)
+		assert.Nil(t, err)
+		assert.Len(t, events, batchSize-1)
+	})
+	t.Run("Sends the batch and empties the spool", func(t *testing.T) {
+		assert.Nil(t, record(spool, event, server.Client(), server.URL))
+		assert.Len(t, received, 1)
+		assert.Len(t, received[0], batchSize)
+		_, err := os.Stat(spool)
+		assert.True(t, os.IsNotExist(err))
+	})
+	t.Run("Keeps the events while they cannot be sent, up to a limit", func(t *testing.T) {
+		online = false
+		for i := 0; i < maxSpooled+10; i++ {
+			assert.Nil(t, record(spool, event, server.Client(), server.URL))
+		}
+		events, _ := readSpool(spool)
+		assert.Len(t, events, maxSpooled)
+
+		online = true
+		assert.Nil(t, record(spool, event, server.Client(), server.URL))
+		assert.Len(t, received, 2)
+		assert.Len(t, received[1], maxSpooled)
+	})
+}
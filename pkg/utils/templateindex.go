@@ -0,0 +1,102 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// templateIndexEntry : one template in the index of a template repo. SHA256 is the optional hex encoded checksum
+// of the archive at location, which project create verifies the download against
+type templateIndexEntry struct {
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	ProjectType string `json:"projectType"`
+	Location    string `json:"location"`
+	SHA256      string `json:"sha256,omitempty"`
+}
+
+// ValidateTemplateIndex : Fetches the index of a template repo and checks it is a JSON array of templates, each
+// with a name, language, project type and location, so that a broken repo is not added to PFE. Returns the
+// number of templates in the index
+func ValidateTemplateIndex(httpClient HTTPClient, url string) (int, error) {
+	templates, err := fetchTemplateIndex(httpClient, url)
+	if err != nil {
+		return 0, err
+	}
+	if len(templates) == 0 {
+		return 0, fmt.Errorf("The template index at %s has no templates", url)
+	}
+	for i, template := range templates {
+		var missing []string
+		for _, field := range []struct{ name, value string }{
+			{"displayName", template.DisplayName},
+			{"language", template.Language},
+			{"projectType", template.ProjectType},
+			{"location", template.Location},
+		} {
+			if strings.TrimSpace(field.value) == "" {
+				missing = append(missing, field.name)
+			}
+		}
+		if len(missing) > 0 {
+			return 0, fmt.Errorf("Template %d of the index at %s has no %s", i+1, url, strings.Join(missing, ", "))
+		}
+	}
+	return len(templates), nil
+}
+
+// FindTemplateChecksum : The sha256 the index of a template repo gives for the template at location, empty
+// when the index does not list the template or gives no checksum for it
+func FindTemplateChecksum(httpClient HTTPClient, indexURL string, location string) (string, error) {
+	templates, err := fetchTemplateIndex(httpClient, indexURL)
+	if err != nil {
+		return "", err
+	}
+	for _, template := range templates {
+		if strings.TrimSuffix(template.Location, "/") == strings.TrimSuffix(location, "/") {
+			return strings.TrimSpace(template.SHA256), nil
+		}
+	}
+	return "", nil
+}
+
+func fetchTemplateIndex(httpClient HTTPClient, url string) ([]templateIndexEntry, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to fetch the template index from %s: %v", url, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("The template index at %s responded with status code %d", url, res.StatusCode)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []templateIndexEntry
+	err = json.Unmarshal(body, &templates)
+	if err != nil {
+		return nil, fmt.Errorf("The template index at %s is not a JSON array of templates: %v", url, err)
+	}
+	return templates, nil
+}
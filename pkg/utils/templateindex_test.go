@@ -0,0 +1,67 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateTemplateIndex(t *testing.T) {
+	responses := map[string]string{
+		"/valid":    `[{"displayName":"Node.js Express","language":"nodejs","projectType":"nodejs","location":"https://example.com/node.zip"}]`,
+		"/invalid":  `{"templates":[]}`,
+		"/empty":    `[]`,
+		"/missing":  `[{"displayName":"Node.js Express","language":"nodejs"}]`,
+		"/checksum": `[{"displayName":"Node.js Express","language":"nodejs","projectType":"nodejs","location":"https://example.com/node.tar.gz","sha256":"abc123"}]`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	t.Run("Counts the templates of a valid index", func(t *testing.T) {
+		count, err := ValidateTemplateIndex(http.DefaultClient, server.URL+"/valid")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+	})
+	t.Run("Rejects an index which is missing", func(t *testing.T) {
+		_, err := ValidateTemplateIndex(http.DefaultClient, server.URL+"/gone")
+		assert.Contains(t, err.Error(), "status code 404")
+	})
+	t.Run("Rejects an index which is not an array of templates", func(t *testing.T) {
+		_, err := ValidateTemplateIndex(http.DefaultClient, server.URL+"/invalid")
+		assert.Contains(t, err.Error(), "not a JSON array")
+		_, err = ValidateTemplateIndex(http.DefaultClient, server.URL+"/empty")
+		assert.Contains(t, err.Error(), "no templates")
+	})
+	t.Run("Names the fields a template is missing", func(t *testing.T) {
+		_, err := ValidateTemplateIndex(http.DefaultClient, server.URL+"/missing")
+		assert.Contains(t, err.Error(), "has no projectType, location")
+	})
+	t.Run("Finds the checksum of a template", func(t *testing.T) {
+		checksum, err := FindTemplateChecksum(http.DefaultClient, server.URL+"/checksum", "https://example.com/node.tar.gz")
+		assert.Nil(t, err)
+		assert.Equal(t, "abc123", checksum)
+		checksum, err = FindTemplateChecksum(http.DefaultClient, server.URL+"/valid", "https://example.com/node.zip")
+		assert.Nil(t, err)
+		assert.Equal(t, "", checksum)
+	})
+}
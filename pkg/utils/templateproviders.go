@@ -0,0 +1,280 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Template providers, which say how the index of a template repo is fetched
+const (
+	TemplateProviderURL    = "url"
+	TemplateProviderBasic  = "basic"
+	TemplateProviderAPIKey = "apikey"
+	TemplateProviderS3     = "s3"
+)
+
+// TemplateProviders : the providers that can be chosen with templates repos add --provider
+var TemplateProviders = []string{TemplateProviderURL, TemplateProviderBasic, TemplateProviderAPIKey, TemplateProviderS3}
+
+// DefaultAPIKeyHeader : the header an API key is sent in unless another is given, as Artifactory expects
+const DefaultAPIKeyHeader = "X-JFrog-Art-Api"
+
+// emptyPayloadHash : the hex encoded sha256 of an empty body, which S3 requests without a body are signed with
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// TemplateProvider : How the index of a template repo hosted in an artifact repository is fetched. Artifactory
+// and Nexus take basic authentication or an API key header, and S3 either a presigned URL, which needs no
+// credentials, or requests signed with the access keys of an IAM user or role
+type TemplateProvider struct {
+	Type            string `json:"type"`
+	Username        string `json:"username,omitempty"`
+	Password        string `json:"password,omitempty"`
+	APIKeyHeader    string `json:"apiKeyHeader,omitempty"`
+	APIKey          string `json:"apiKey,omitempty"`
+	Region          string `json:"region,omitempty"`
+	AccessKeyID     string `json:"accessKeyId,omitempty"`
+	SecretAccessKey string `json:"secretAccessKey,omitempty"`
+	SessionToken    string `json:"sessionToken,omitempty"`
+}
+
+// s3Credentials : the access keys S3 requests are signed with
+type s3Credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+}
+
+// Validate : Checks that the provider is known and has the credentials it needs for the index at repoURL
+func (provider TemplateProvider) Validate(repoURL string) error {
+	switch provider.Type {
+	case "", TemplateProviderURL:
+		return nil
+	case TemplateProviderBasic:
+		if strings.TrimSpace(provider.Username) == "" || provider.Password == "" {
+			return errors.New("The basic provider needs a --username and --password")
+		}
+		return nil
+	case TemplateProviderAPIKey:
+		if provider.APIKey == "" {
+			return errors.New("The apikey provider needs an --api-key")
+		}
+		return nil
+	case TemplateProviderS3:
+		if (provider.AccessKeyID == "") != (provider.SecretAccessKey == "") {
+			return errors.New("The s3 provider needs both an --access-key-id and a --secret-access-key, or neither")
+		}
+		if provider.AccessKeyID == "" && !isPresignedS3URL(repoURL) && os.Getenv("AWS_ACCESS_KEY_ID") == "" {
+			return errors.New("The s3 provider needs a presigned URL, or access keys given with --access-key-id and --secret-access-key or in AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+		}
+		return nil
+	}
+	return errors.New("Unknown template provider " + provider.Type + ", expected one of " + strings.Join(TemplateProviders, ", "))
+}
+
+// NeedsCredentials : true when the provider has credentials to keep, rather than fetching the index as it is
+func (provider TemplateProvider) NeedsCredentials() bool {
+	return provider.Type != "" && provider.Type != TemplateProviderURL
+}
+
+// Authorize : Adds the provider's credentials to a request for the index of its repo. S3 requests for a presigned
+// URL are left as they are, as the URL carries its own signature
+func (provider TemplateProvider) Authorize(req *http.Request) error {
+	return provider.authorize(req, time.Now())
+}
+
+func (provider TemplateProvider) authorize(req *http.Request, now time.Time) error {
+	switch provider.Type {
+	case TemplateProviderBasic:
+		req.SetBasicAuth(provider.Username, provider.Password)
+	case TemplateProviderAPIKey:
+		header := provider.APIKeyHeader
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		req.Header.Set(header, provider.APIKey)
+	case TemplateProviderS3:
+		if isPresignedS3URL(req.URL.String()) {
+			return nil
+		}
+		credentials := s3Credentials{provider.AccessKeyID, provider.SecretAccessKey, provider.SessionToken}
+		if credentials.accessKeyID == "" {
+			// The keys of an IAM role are short lived, so those in the environment are read for each request
+			credentials = s3Credentials{os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_SESSION_TOKEN")}
+		}
+		if credentials.accessKeyID == "" || credentials.secretAccessKey == "" {
+			return errors.New("No AWS access keys are available to fetch " + req.URL.String() + " with")
+		}
+		signS3Request(req, credentials, provider.s3Region(req.URL.Hostname()), now)
+	}
+	return nil
+}
+
+// s3Region : the region given for the provider, else in the environment, else in an s3.<region>.amazonaws.com
+// host name, else us-east-1
+func (provider TemplateProvider) s3Region(host string) string {
+	for _, region := range []string{provider.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")} {
+		if region != "" {
+			return region
+		}
+	}
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i := 0; i+3 < len(labels); i++ {
+		if labels[i] == "s3" && labels[i+2] == "amazonaws" {
+			return labels[i+1]
+		}
+	}
+	return "us-east-1"
+}
+
+// PFECredentials : The provider as PFE is given it, to fetch the index with itself. S3 access keys are taken from
+// the environment when none were given, as PFE can not read the environment of cwctl
+func (provider TemplateProvider) PFECredentials() TemplateProvider {
+	if provider.Type == TemplateProviderS3 && provider.AccessKeyID == "" {
+		provider.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		provider.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		provider.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	return provider
+}
+
+// credentialHeaders : the headers Authorize puts the credentials of the provider in
+func (provider TemplateProvider) credentialHeaders() []string {
+	switch provider.Type {
+	case TemplateProviderAPIKey:
+		if provider.APIKeyHeader != "" {
+			return []string{provider.APIKeyHeader}
+		}
+		return []string{DefaultAPIKeyHeader}
+	case TemplateProviderS3:
+		return []string{"Authorization", "X-Amz-Date", "X-Amz-Content-Sha256", "X-Amz-Security-Token"}
+	}
+	return []string{"Authorization"}
+}
+
+// Client : An HTTP client which authorizes each request with the provider before sending it with httpClient. When
+// httpClient is an *http.Client, the credentials are dropped from a request redirected to another host, as the
+// http package only drops the Authorization header and would send an API key on
+func (provider TemplateProvider) Client(httpClient HTTPClient) HTTPClient {
+	if client, ok := httpClient.(*http.Client); ok {
+		redirecting := *client
+		redirecting.CheckRedirect = provider.checkRedirect(client.CheckRedirect)
+		httpClient = &redirecting
+	}
+	return &templateProviderClient{httpClient, provider}
+}
+
+// checkRedirect : removes the credential headers from a request redirected to a host other than the one first
+// requested, then applies the redirect policy of the client, or the default policy of at most 10 redirects
+func (provider TemplateProvider) checkRedirect(policy func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > 0 && !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			for _, header := range provider.credentialHeaders() {
+				req.Header.Del(header)
+			}
+		}
+		if policy != nil {
+			return policy(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+type templateProviderClient struct {
+	httpClient HTTPClient
+	provider   TemplateProvider
+}
+
+func (client *templateProviderClient) Do(req *http.Request) (*http.Response, error) {
+	err := client.provider.Authorize(req)
+	if err != nil {
+		return nil, err
+	}
+	return client.httpClient.Do(req)
+}
+
+func isPresignedS3URL(repoURL string) bool {
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return false
+	}
+	query := parsed.Query()
+	return query.Get("X-Amz-Signature") != "" || query.Get("Signature") != ""
+}
+
+// signS3Request : Signs a request without a body with AWS Signature Version 4, signing its host and x-amz headers
+func signS3Request(req *http.Request, credentials s3Credentials, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if credentials.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	canonicalHeaders := ""
+	for _, name := range names {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// Spaces are encoded as %20 rather than +
+	query := strings.Replace(req.URL.Query().Encode(), "+", "%20", -1)
+	canonicalRequest := strings.Join([]string{req.Method, path, query, canonicalHeaders, signedHeaders, emptyPayloadHash}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex(canonicalRequest)
+	key := []byte("AWS4" + credentials.secretAccessKey)
+	for _, part := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+credentials.accessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,106 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NormalizeTemplateRepoURL : The form of a template repo URL that equivalent URLs share: the scheme and host in
+// lower case, without the default port of the scheme, trailing slashes or fragment. http and https URLs are
+// different repos, as a server need not serve both
+func NormalizeTemplateRepoURL(repoURL string) string {
+	repoURL = strings.TrimSpace(repoURL)
+	parsed, err := url.Parse(repoURL)
+	if err != nil || parsed.Host == "" {
+		return strings.TrimRight(repoURL, "/")
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	if port := parsed.Port(); port != "" && port != defaultPorts[scheme] {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	normalized := scheme + "://" + host + strings.TrimRight(parsed.EscapedPath(), "/")
+	if parsed.RawQuery != "" {
+		normalized += "?" + parsed.RawQuery
+	}
+	return normalized
+}
+
+// defaultPorts : the port a URL of each scheme is served on when it gives none
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// SameTemplateRepoURL : true when two template repo URLs are equivalent forms of the same URL
+func SameTemplateRepoURL(a string, b string) bool {
+	return NormalizeTemplateRepoURL(a) == NormalizeTemplateRepoURL(b)
+}
+
+// ResolveTemplateRepoURL : The URL a template repo URL redirects to, or the URL itself when it does not redirect
+// or cannot be fetched
+func ResolveTemplateRepoURL(httpClient HTTPClient, repoURL string) string {
+	req, err := http.NewRequest("GET", repoURL, nil)
+	if err != nil {
+		return repoURL
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return repoURL
+	}
+	defer res.Body.Close()
+	if res.Request == nil || res.Request.URL == nil {
+		return repoURL
+	}
+	return res.Request.URL.String()
+}
+
+// FindTemplateRepo : The repo whose name is nameOrURL, ignoring case, or else whose URL is equivalent to it. nil
+// when there is none
+func FindTemplateRepo(repos []TemplateRepo, nameOrURL string) *TemplateRepo {
+	nameOrURL = strings.TrimSpace(nameOrURL)
+	for i := range repos {
+		if repos[i].Name != "" && strings.EqualFold(repos[i].Name, nameOrURL) {
+			return &repos[i]
+		}
+	}
+	for i := range repos {
+		if SameTemplateRepoURL(repos[i].URL, nameOrURL) {
+			return &repos[i]
+		}
+	}
+	return nil
+}
+
+// CheckNewTemplateRepo : Checks that a repo is not already added under any of the equivalent URLs it is known
+// by, such as the URL it was given by and the one it redirects to, and that no other repo has its name
+func CheckNewTemplateRepo(repos []TemplateRepo, urls []string, name string) error {
+	for _, repo := range repos {
+		for _, repoURL := range urls {
+			if SameTemplateRepoURL(repo.URL, repoURL) {
+				added := repo.URL
+				if repo.Name != "" {
+					added = repo.Name + " (" + repo.URL + ")"
+				}
+				return errors.New("The template repo " + urls[0] + " is already added as " + added)
+			}
+		}
+		if name != "" && strings.EqualFold(repo.Name, name) {
+			return errors.New("The name " + name + " is already used by the template repo " + repo.URL + ", choose another with --name")
+		}
+	}
+	return nil
+}
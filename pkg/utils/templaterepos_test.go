@@ -0,0 +1,83 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SameTemplateRepoURL(t *testing.T) {
+	index := "https://raw.githubusercontent.com/codewind-resources/codewind-templates/master/devfiles/index.json"
+	for _, equivalent := range []string{
+		index,
+		index + "/",
+		"HTTPS://Raw.GitHubUserContent.com:443/codewind-resources/codewind-templates/master/devfiles/index.json",
+		index + "#templates",
+	} {
+		assert.True(t, SameTemplateRepoURL(index, equivalent), equivalent)
+	}
+	for _, different := range []string{
+		"https://raw.githubusercontent.com/codewind-resources/codewind-templates/master/devfiles/other.json",
+		"https://raw.githubusercontent.com:8443/codewind-resources/codewind-templates/master/devfiles/index.json",
+		index + "?ref=0.7.0",
+		"http://raw.githubusercontent.com/codewind-resources/codewind-templates/master/devfiles/index.json",
+		"https://raw.githubusercontent.com:80/codewind-resources/codewind-templates/master/devfiles/index.json",
+	} {
+		assert.False(t, SameTemplateRepoURL(index, different), different)
+	}
+	assert.True(t, SameTemplateRepoURL("http://templates.example.com/index.json", "http://templates.example.com:80/index.json"))
+	assert.False(t, SameTemplateRepoURL("http://templates.example.com/index.json", "http://templates.example.com:443/index.json"))
+	assert.True(t, SameTemplateRepoURL("https://[fd00::1]/index.json", "https://[FD00::1]:443/index.json"))
+}
+
+func Test_ResolveTemplateRepoURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old/index.json" {
+			http.Redirect(w, r, "/new/index.json", http.StatusMovedPermanently)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	assert.Equal(t, server.URL+"/new/index.json", ResolveTemplateRepoURL(http.DefaultClient, server.URL+"/old/index.json"))
+	assert.Equal(t, server.URL+"/new/index.json", ResolveTemplateRepoURL(http.DefaultClient, server.URL+"/new/index.json"))
+	assert.Equal(t, "not a url", ResolveTemplateRepoURL(http.DefaultClient, "not a url"))
+}
+
+func Test_TemplateRepoLookup(t *testing.T) {
+	repos := []TemplateRepo{
+		{Name: "Standard templates", URL: "https://raw.githubusercontent.com/codewind-resources/codewind-templates/master/devfiles/index.json"},
+		{Name: "team", URL: "https://templates.example.com/index.json"},
+	}
+
+	t.Run("Finds repos by name or URL", func(t *testing.T) {
+		assert.Equal(t, &repos[1], FindTemplateRepo(repos, "TEAM"))
+		assert.Equal(t, &repos[1], FindTemplateRepo(repos, "HTTPS://templates.example.com/index.json/"))
+		assert.Nil(t, FindTemplateRepo(repos, "other"))
+	})
+	t.Run("Refuses repos which are already added", func(t *testing.T) {
+		err := CheckNewTemplateRepo(repos, []string{"https://templates.example.com/old.json", "https://templates.example.com/index.json"}, "")
+		assert.EqualError(t, err, "The template repo https://templates.example.com/old.json is already added as team (https://templates.example.com/index.json)")
+	})
+	t.Run("Refuses names which are taken", func(t *testing.T) {
+		err := CheckNewTemplateRepo(repos, []string{"https://templates.example.com/other.json"}, "Team")
+		assert.NotNil(t, err)
+	})
+	t.Run("Accepts new repos", func(t *testing.T) {
+		assert.Nil(t, CheckNewTemplateRepo(repos, []string{"https://templates.example.com/other.json"}, "other"))
+	})
+}
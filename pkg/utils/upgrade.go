@@ -0,0 +1,417 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// Steps of upgrading a local Codewind deployment, in the order they run
+const (
+	UpgradeStepPull     = "pull"
+	UpgradeStepStop     = "stop"
+	UpgradeStepBackup   = "backup"
+	UpgradeStepStart    = "start"
+	UpgradeStepRollback = "rollback"
+)
+
+// Outcomes of an upgrade
+const (
+	UpgradeStatusOK = "ok"
+	// UpgradeStatusUnchanged is reported when Codewind already runs the images, so nothing was done
+	UpgradeStatusUnchanged = "unchanged"
+	// UpgradeStatusRolledBack is reported when the new images failed to start and the previous ones were restarted
+	UpgradeStatusRolledBack = "rolledback"
+	// UpgradeStatusFailed is reported when the new images could not be pulled, leaving Codewind as it was, or when
+	// the rollback failed too
+	UpgradeStatusFailed = "failed"
+)
+
+// workspaceBackupSuffix ends the name of the volume the workspace volume is copied to before an upgrade
+const workspaceBackupSuffix = "-backup"
+
+// rollbackTagSuffix ends the tag the previous local images are kept under while an upgrade runs, as the new
+// images may be tagged with the same local names
+const rollbackTagSuffix = "-rollback"
+
+// DeploymentUpgrade : The local Codewind deployment to upgrade, the images it runs and those it is upgraded to
+type DeploymentUpgrade struct {
+	Profile        LocalProfile
+	From           ImageConfig
+	To             ImageConfig
+	Ports          PortConfig
+	HealthEndpoint string
+	StartOptions   StartOptions
+}
+
+// UpgradeReport : The outcome of each step of an upgrade, with the reports of starting the new images and, when
+// they failed, of starting the previous images again
+type UpgradeReport struct {
+	Status   string       `json:"status"`
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Backup   string       `json:"backup,omitempty"`
+	Steps    []StartPhase `json:"steps"`
+	Start    *StartReport `json:"start,omitempty"`
+	Rollback *StartReport `json:"rollback,omitempty"`
+}
+
+// upgradeSteps : The docker operations an upgrade is made of, replaced in tests. The workspace is copied in a
+// container of the new PFE image, as it has just been pulled
+type upgradeSteps struct {
+	keep    func(images ImageConfig) error
+	unkeep  func(images ImageConfig) error
+	pull    func(images ImageConfig) error
+	stop    func(profile LocalProfile) error
+	backup  func(profile LocalProfile, image string) (string, error)
+	restore func(profile LocalProfile, backup string, image string) error
+	start   func(upgrade DeploymentUpgrade, images ImageConfig) *StartReport
+}
+
+var dockerUpgradeSteps = upgradeSteps{
+	keep:    keepImages,
+	unkeep:  restoreImages,
+	pull:    pullImages,
+	stop:    stopProfileContainers,
+	backup:  backupWorkspace,
+	restore: restoreWorkspace,
+	start:   startImages,
+}
+
+// UpgradeDeployment : Upgrades the local Codewind of a profile. The previous local images are kept under rollback
+// tags and the new images are pulled while the old ones still run, then Codewind is stopped, its workspace volume
+// is backed up, as PFE migrates the workspace when it starts, and the new images are started and checked for
+// health. If they fail to start, the workspace is restored from the backup and the previous images are tagged
+// back and started again. Nothing is done when Codewind already runs the new images
+func UpgradeDeployment(upgrade DeploymentUpgrade) *UpgradeReport {
+	return upgradeDeployment(upgrade, dockerUpgradeSteps)
+}
+
+func upgradeDeployment(upgrade DeploymentUpgrade, steps upgradeSteps) *UpgradeReport {
+	report := &UpgradeReport{
+		Status: UpgradeStatusOK,
+		From:   upgrade.From.PFERunReference(),
+		To:     upgrade.To.PFERunReference(),
+		Steps:  []StartPhase{},
+	}
+	if report.From == report.To && upgrade.From.PerformanceRunReference() == upgrade.To.PerformanceRunReference() {
+		report.Status = UpgradeStatusUnchanged
+		progress(upgrade.StartOptions.Progress, "Codewind already runs %v", report.To)
+		return report
+	}
+	progress(upgrade.StartOptions.Progress, "Upgrading Codewind from %v to %v", report.From, report.To)
+
+	pull := runUpgradeStep(upgrade, UpgradeStepPull, func() error {
+		err := steps.keep(upgrade.From)
+		if err != nil {
+			return fmt.Errorf("Unable to keep the images of %v: %v", report.From, err)
+		}
+		return steps.pull(upgrade.To)
+	})
+	report.Steps = append(report.Steps, pull)
+	if pull.Status != PhaseStatusOK {
+		// Nothing has changed yet, so there is nothing to roll back
+		report.Status = UpgradeStatusFailed
+		return report
+	}
+
+	stop := runUpgradeStep(upgrade, UpgradeStepStop, func() error { return steps.stop(upgrade.Profile) })
+	report.Steps = append(report.Steps, stop)
+	failed := stop.Status != PhaseStatusOK
+
+	backup := StartPhase{Name: UpgradeStepBackup, Status: PhaseStatusSkipped}
+	if !failed {
+		backup = runUpgradeStep(upgrade, UpgradeStepBackup, func() error {
+			var err error
+			report.Backup, err = steps.backup(upgrade.Profile, upgrade.To.PFERunReference())
+			return err
+		})
+		failed = backup.Status != PhaseStatusOK
+	}
+	report.Steps = append(report.Steps, backup)
+
+	start := StartPhase{Name: UpgradeStepStart, Status: PhaseStatusSkipped}
+	if !failed {
+		start = runUpgradeStep(upgrade, UpgradeStepStart, func() error {
+			report.Start = steps.start(upgrade, upgrade.To)
+			if report.Start.Status != PhaseStatusOK {
+				return fmt.Errorf("Codewind %v failed to start, see the start report", report.To)
+			}
+			return nil
+		})
+		failed = start.Status != PhaseStatusOK
+	}
+	report.Steps = append(report.Steps, start)
+	if !failed {
+		return report
+	}
+
+	rollback := runUpgradeStep(upgrade, UpgradeStepRollback, func() error {
+		err := steps.stop(upgrade.Profile)
+		if err != nil {
+			return err
+		}
+		if report.Backup != "" {
+			err = steps.restore(upgrade.Profile, report.Backup, upgrade.To.PFERunReference())
+			if err != nil {
+				return fmt.Errorf("Unable to restore the workspace from %v: %v", report.Backup, err)
+			}
+		}
+		err = steps.unkeep(upgrade.From)
+		if err != nil {
+			return fmt.Errorf("Unable to restore the images of %v: %v", report.From, err)
+		}
+		report.Rollback = steps.start(upgrade, upgrade.From)
+		if report.Rollback.Status != PhaseStatusOK {
+			return fmt.Errorf("Codewind %v failed to start again, see the rollback report", report.From)
+		}
+		return nil
+	})
+	report.Steps = append(report.Steps, rollback)
+	report.Status = UpgradeStatusRolledBack
+	if rollback.Status != PhaseStatusOK {
+		report.Status = UpgradeStatusFailed
+	}
+	return report
+}
+
+// runUpgradeStep : Runs a step, timing it and reporting its progress. Steps are not timed out themselves, as
+// starting Codewind has its own timeouts
+func runUpgradeStep(upgrade DeploymentUpgrade, name string, run func() error) StartPhase {
+	progress(upgrade.StartOptions.Progress, "Running %v", name)
+	started := time.Now()
+	err := run()
+	result := StartPhase{Name: name, Status: PhaseStatusOK, Duration: time.Since(started).Round(time.Millisecond).String()}
+	if err != nil {
+		result.Status = PhaseStatusFailed
+		result.Error = err.Error()
+		progress(upgrade.StartOptions.Progress, "%v failed after %v: %v", name, result.Duration, result.Error)
+	} else {
+		progress(upgrade.StartOptions.Progress, "%v ok after %v", name, result.Duration)
+	}
+	return result
+}
+
+// RunningImageConfig : The images the running Codewind of a profile was started from, taken as the tags or
+// digests of its containers' images applied to the configured images. The configured images are returned, and
+// false, when the profile's Codewind is not running
+func RunningImageConfig(profile LocalProfile, configured ImageConfig) (ImageConfig, bool) {
+	running := configured
+	isRunning := false
+	for _, container := range GetContainerList() {
+		if !profile.Owns(container) {
+			continue
+		}
+		service := CodewindService(container)
+		if service == "" {
+			continue
+		}
+		isRunning = true
+		digest := ""
+		if index := strings.LastIndex(container.Image, "@"); index != -1 {
+			digest = container.Image[index+1:]
+		} else if tag := imageTag(container.Image); tag != "" {
+			running.Tag = tag
+		}
+		if service == ServicePFE {
+			running.PFEDigest = digest
+		} else {
+			running.PerformanceDigest = digest
+		}
+	}
+	return running, isRunning
+}
+
+// pullImages : Pulls the images and tags them with the names start runs them as, checking they are built for the
+// architecture of the images
+func pullImages(images ImageConfig) error {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	for _, reference := range []string{images.PFEPullReference(), images.PerformancePullReference()} {
+		out, err := cli.ImagePull(ctx, reference, types.ImagePullOptions{})
+		if err != nil {
+			return fmt.Errorf("Unable to pull %v: %v", reference, describePullError(reference, err))
+		}
+		io.Copy(ioutil.Discard, out)
+		out.Close()
+	}
+	for source, target := range map[string]string{
+		images.PFEPullReference():         images.LocalPFEImageName(),
+		images.PerformancePullReference(): images.LocalPerformanceImageName(),
+	} {
+		err = cli.ImageTag(ctx, source, target)
+		if err != nil {
+			return fmt.Errorf("Unable to tag %v as %v: %v", source, target, err)
+		}
+	}
+	return VerifyImageArchitectures(images)
+}
+
+// keptImages : The local images the images run as, mapped to the tags they are kept under during an upgrade.
+// Images pinned to digests are run by their digests, which pulling other images does not change
+func keptImages(images ImageConfig) map[string]string {
+	kept := map[string]string{}
+	if images.PFEDigest == "" {
+		kept[images.LocalPFEImageName()] = images.LocalPFEImageName() + rollbackTagSuffix
+	}
+	if images.PerformanceDigest == "" {
+		kept[images.LocalPerformanceImageName()] = images.LocalPerformanceImageName() + rollbackTagSuffix
+	}
+	return kept
+}
+
+// keepImages : Tags the local images under their rollback tags, so that pulling new images tagged with the same
+// names does not lose them. Images which are not there, as Codewind has not run them, are skipped
+func keepImages(images ImageConfig) error {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	for source, target := range keptImages(images) {
+		err = cli.ImageTag(ctx, source, target)
+		if err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("Unable to tag %v as %v: %v", source, target, err)
+		}
+	}
+	return nil
+}
+
+// restoreImages : Tags the images kept under their rollback tags back with their local names
+func restoreImages(images ImageConfig) error {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	for target, source := range keptImages(images) {
+		err = cli.ImageTag(ctx, source, target)
+		if err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("Unable to tag %v as %v: %v", source, target, err)
+		}
+	}
+	return nil
+}
+
+// stopProfileContainers : Stops and removes the Codewind containers of a profile and its network, forgetting any
+// deployment it adopted as it is replaced
+func stopProfileContainers(profile LocalProfile) error {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	containers, err := cli.ContainerList(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return err
+	}
+	for _, container := range containers {
+		if !profile.Owns(container) || CodewindService(container) == "" {
+			continue
+		}
+		err = cli.ContainerRemove(ctx, container.ID, types.ContainerRemoveOptions{Force: true})
+		if err != nil && !client.IsErrNotFound(err) {
+			return fmt.Errorf("Unable to remove container %v: %v", containerName(container), err)
+		}
+	}
+	networks, err := cli.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, network := range networks {
+		if strings.HasPrefix(network.Name, profile.NetworkPrefix()) {
+			err = cli.NetworkRemove(ctx, network.ID)
+			if err != nil && !client.IsErrNotFound(err) {
+				return fmt.Errorf("Unable to remove network %v: %v", network.Name, err)
+			}
+		}
+	}
+	return profile.ReleaseAdoptedDeployment()
+}
+
+// backupWorkspace : Copies the workspace volume of a profile to its backup volume, replacing an earlier backup,
+// and returns the name of the backup volume
+func backupWorkspace(profile LocalProfile, image string) (string, error) {
+	backup := profile.WorkspaceVolume() + workspaceBackupSuffix
+	err := RemoveVolume(backup)
+	if err != nil {
+		return "", err
+	}
+	return backup, copyVolume(image, profile.WorkspaceVolume(), backup)
+}
+
+// restoreWorkspace : Replaces the content of the workspace volume of a profile with its backup
+func restoreWorkspace(profile LocalProfile, backup string, image string) error {
+	return copyVolume(image, backup, profile.WorkspaceVolume())
+}
+
+// copyVolume : Replaces the content of one volume with that of another, in a container of the image
+func copyVolume(image string, from string, to string) error {
+	ctx := context.Background()
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	created, err := cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:      image,
+			User:       "root",
+			Entrypoint: []string{"sh", "-c"},
+			Cmd:        []string{"find /to -mindepth 1 -delete && cp -a /from/. /to/"},
+		},
+		&container.HostConfig{Binds: []string{from + ":/from:ro", to + ":/to"}},
+		nil, "")
+	if err != nil {
+		return err
+	}
+	defer cli.ContainerRemove(ctx, created.ID, types.ContainerRemoveOptions{Force: true})
+
+	err = cli.ContainerStart(ctx, created.ID, types.ContainerStartOptions{})
+	if err != nil {
+		return err
+	}
+	results, errs := cli.ContainerWait(ctx, created.ID, container.WaitConditionNotRunning)
+	select {
+	case result := <-results:
+		if result.StatusCode != 0 {
+			return fmt.Errorf("Copying volume %v to %v exited with status %d", from, to, result.StatusCode)
+		}
+	case err := <-errs:
+		return err
+	}
+	return nil
+}
+
+// startImages : Starts the profile's Codewind from the images, with a docker-compose file generated for them
+func startImages(upgrade DeploymentUpgrade, images ImageConfig) *StartReport {
+	composeFile := ComposeFilePath(upgrade.Profile)
+	err := WriteComposeFile(composeFile, images, upgrade.Ports, upgrade.Profile.Workspace(), false)
+	if err != nil {
+		return &StartReport{
+			Status: PhaseStatusFailed,
+			Phases: []StartPhase{{Name: PhaseComposeUp, Status: PhaseStatusFailed, Error: "Unable to write the docker-compose file: " + err.Error()}},
+		}
+	}
+	return StartCodewind(composeFile, upgrade.Ports, upgrade.HealthEndpoint, upgrade.StartOptions)
+}
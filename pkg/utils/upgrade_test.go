@@ -0,0 +1,147 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeUpgradeSteps : upgrade steps which record what they were called with, failing as configured
+type fakeUpgradeSteps struct {
+	calls       []string
+	pullErr     error
+	failedStart map[string]bool
+}
+
+func (fake *fakeUpgradeSteps) steps() upgradeSteps {
+	return upgradeSteps{
+		keep: func(images ImageConfig) error {
+			fake.calls = append(fake.calls, "keep "+images.Tag)
+			return nil
+		},
+		unkeep: func(images ImageConfig) error {
+			fake.calls = append(fake.calls, "unkeep "+images.Tag)
+			return nil
+		},
+		pull: func(images ImageConfig) error {
+			fake.calls = append(fake.calls, "pull "+images.Tag)
+			return fake.pullErr
+		},
+		stop: func(profile LocalProfile) error {
+			fake.calls = append(fake.calls, "stop")
+			return nil
+		},
+		backup: func(profile LocalProfile, image string) (string, error) {
+			fake.calls = append(fake.calls, "backup with "+image)
+			return profile.WorkspaceVolume() + workspaceBackupSuffix, nil
+		},
+		restore: func(profile LocalProfile, backup string, image string) error {
+			fake.calls = append(fake.calls, "restore "+backup)
+			return nil
+		},
+		start: func(upgrade DeploymentUpgrade, images ImageConfig) *StartReport {
+			fake.calls = append(fake.calls, "start "+images.Tag)
+			if fake.failedStart[images.Tag] {
+				return &StartReport{Status: PhaseStatusFailed}
+			}
+			return &StartReport{Status: PhaseStatusOK, URL: "http://127.0.0.1:10000"}
+		},
+	}
+}
+
+func testUpgrade(from string, to string) DeploymentUpgrade {
+	images := DefaultImageConfig()
+	upgrade := DeploymentUpgrade{From: images, To: images}
+	upgrade.From.Tag = from
+	upgrade.To.Tag = to
+	return upgrade
+}
+
+func stepStatuses(report *UpgradeReport) map[string]string {
+	statuses := map[string]string{}
+	for _, step := range report.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+func TestUpgradeDeployment(t *testing.T) {
+	t.Run("Backs up the workspace and starts the new images", func(t *testing.T) {
+		fake := &fakeUpgradeSteps{}
+		report := upgradeDeployment(testUpgrade("0.6.0", "0.7.0"), fake.steps())
+		assert.Equal(t, UpgradeStatusOK, report.Status)
+		assert.Equal(t, []string{"keep 0.6.0", "pull 0.7.0", "stop", "backup with codewind-pfe-amd64:0.7.0", "start 0.7.0"}, fake.calls)
+		assert.Equal(t, "codewind_cw-workspace-backup", report.Backup)
+		assert.Equal(t, "codewind-pfe-amd64:0.6.0", report.From)
+		assert.Equal(t, "codewind-pfe-amd64:0.7.0", report.To)
+		assert.Nil(t, report.Rollback)
+	})
+
+	t.Run("Does nothing when Codewind already runs the images", func(t *testing.T) {
+		fake := &fakeUpgradeSteps{}
+		report := upgradeDeployment(testUpgrade("latest", "latest"), fake.steps())
+		assert.Equal(t, UpgradeStatusUnchanged, report.Status)
+		assert.Empty(t, report.Steps)
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("Upgrades the same tag pinned to a digest", func(t *testing.T) {
+		fake := &fakeUpgradeSteps{}
+		upgrade := testUpgrade("latest", "latest")
+		upgrade.To.PFEDigest = "sha256:" + strings.Repeat("a", 64)
+		report := upgradeDeployment(upgrade, fake.steps())
+		assert.Equal(t, UpgradeStatusOK, report.Status)
+		assert.Equal(t, PhaseStatusOK, stepStatuses(report)[UpgradeStepBackup])
+		assert.Equal(t, "keep latest", fake.calls[0])
+	})
+
+	t.Run("Leaves Codewind running when the new images cannot be pulled", func(t *testing.T) {
+		fake := &fakeUpgradeSteps{pullErr: errors.New("manifest unknown")}
+		report := upgradeDeployment(testUpgrade("0.6.0", "0.7.0"), fake.steps())
+		assert.Equal(t, UpgradeStatusFailed, report.Status)
+		assert.Equal(t, []string{"keep 0.6.0", "pull 0.7.0"}, fake.calls)
+		assert.Equal(t, "manifest unknown", report.Steps[0].Error)
+	})
+
+	t.Run("Restores the workspace and starts the previous images when the new ones fail", func(t *testing.T) {
+		fake := &fakeUpgradeSteps{failedStart: map[string]bool{"0.7.0": true}}
+		report := upgradeDeployment(testUpgrade("0.6.0", "0.7.0"), fake.steps())
+		assert.Equal(t, UpgradeStatusRolledBack, report.Status)
+		assert.Equal(t, []string{"keep 0.6.0", "pull 0.7.0", "stop", "backup with codewind-pfe-amd64:0.7.0", "start 0.7.0", "stop", "restore codewind_cw-workspace-backup", "unkeep 0.6.0", "start 0.6.0"}, fake.calls)
+		assert.Equal(t, PhaseStatusFailed, stepStatuses(report)[UpgradeStepStart])
+		assert.Equal(t, PhaseStatusOK, stepStatuses(report)[UpgradeStepRollback])
+		assert.Equal(t, PhaseStatusOK, report.Rollback.Status)
+	})
+
+	t.Run("Fails when the previous images do not start again either", func(t *testing.T) {
+		fake := &fakeUpgradeSteps{failedStart: map[string]bool{"0.6.0": true, "0.7.0": true}}
+		report := upgradeDeployment(testUpgrade("0.6.0", "0.7.0"), fake.steps())
+		assert.Equal(t, UpgradeStatusFailed, report.Status)
+		assert.Equal(t, PhaseStatusFailed, stepStatuses(report)[UpgradeStepRollback])
+	})
+}
+
+func TestKeptImages(t *testing.T) {
+	images := DefaultImageConfig()
+	images.Tag = "0.6.0"
+	assert.Equal(t, map[string]string{
+		"codewind-pfe-amd64:0.6.0":         "codewind-pfe-amd64:0.6.0-rollback",
+		"codewind-performance-amd64:0.6.0": "codewind-performance-amd64:0.6.0-rollback",
+	}, keptImages(images))
+
+	images.PFEDigest = "sha256:" + strings.Repeat("a", 64)
+	assert.Equal(t, map[string]string{"codewind-performance-amd64:0.6.0": "codewind-performance-amd64:0.6.0-rollback"}, keptImages(images))
+}
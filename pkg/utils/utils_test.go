@@ -14,9 +14,11 @@ package utils
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -84,16 +86,19 @@ func TestCreateTempFile(t *testing.T) {
 	os.Remove("./TestFile.yaml")
 }
 
-func TestWriteToComposeFile(t *testing.T) {
-	os.Create("TestFile.yaml")
-	got := WriteToComposeFile("TestFile.yaml", false)
-	assert.Equal(t, got, true, "should return true: should write data to a temp file")
-	os.Remove("TestFile.yaml")
+func TestWriteComposeFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "compose")
+	defer os.RemoveAll(dir)
+	composeFile := filepath.Join(dir, "state", "codewind-docker-compose.yaml")
+	err := WriteComposeFile(composeFile, DefaultImageConfig(), DefaultPortConfig(), WorkspaceConfig{}, false)
+	assert.Nil(t, err, "should write the compose file, creating its directory")
+	content, _ := ioutil.ReadFile(composeFile)
+	assert.Contains(t, string(content), ActiveProfile().PFEContainerName())
 }
 
-func TestWriteToComposeFileFail(t *testing.T) {
-	writeToFile := WriteToComposeFile("", false)
-	assert.Equal(t, writeToFile, false, "should return false: should fail to write data")
+func TestWriteComposeFileFail(t *testing.T) {
+	err := WriteComposeFile("", DefaultImageConfig(), DefaultPortConfig(), WorkspaceConfig{}, false)
+	assert.NotNil(t, err, "should fail to write without a file")
 }
 
 func TestDeleteTempFile(t *testing.T) {
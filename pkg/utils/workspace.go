@@ -0,0 +1,99 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/eclipse/codewind-installer/pkg/utils/cliconfig"
+	logr "github.com/sirupsen/logrus"
+)
+
+// workspaceFileSuffix ends the name of the file in the state directory that a profile's workspace config is kept in
+const workspaceFileSuffix = "-workspace.json"
+
+// dataVolume is the docker-compose volume a profile keeps its workspace in when it uses a named volume
+const dataVolume = "cw-data"
+
+// WorkspaceConfig : Where a profile keeps the workspace mounted into its PFE container. The zero value is the
+// profile's default host directory
+type WorkspaceConfig struct {
+	// Directory is the host directory of the workspace, empty for the profile's default
+	Directory string `json:"directory,omitempty"`
+	// NamedVolume keeps the workspace in a docker named volume rather than a host directory, which avoids the
+	// slow file sharing of bind mounts on macOS
+	NamedVolume bool `json:"namedVolume,omitempty"`
+}
+
+// Workspace : The workspace config saved for the profile by start, the zero value when none was saved
+func (profile LocalProfile) Workspace() WorkspaceConfig {
+	workspace := WorkspaceConfig{}
+	body, err := ioutil.ReadFile(profile.workspaceFile())
+	if err == nil {
+		err = json.Unmarshal(body, &workspace)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		logr.Warnf("Ignoring the unreadable workspace config of %v: %v", profile.ComposeProject(), err)
+		return WorkspaceConfig{}
+	}
+	return workspace
+}
+
+// SaveWorkspace : Saves the workspace config of the profile, so later starts and upgrades use it too. Saving the
+// zero value restores the default
+func (profile LocalProfile) SaveWorkspace(workspace WorkspaceConfig) error {
+	if workspace == (WorkspaceConfig{}) {
+		err := os.Remove(profile.workspaceFile())
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	body, err := json.MarshalIndent(workspace, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(cliconfig.GetStateDir(), 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(profile.workspaceFile(), body, 0600)
+}
+
+// WorkspaceDirectory : The host directory mounted into the instance's PFE container, the one saved with
+// --workspace or else the default of the profile
+func (profile LocalProfile) WorkspaceDirectory() string {
+	return profile.workspaceDirectory(profile.Workspace())
+}
+
+// DataVolume : The docker volume the instance keeps its workspace in when it uses a named volume
+func (profile LocalProfile) DataVolume() string {
+	return profile.ComposeProject() + "_" + dataVolume
+}
+
+func (profile LocalProfile) workspaceDirectory(workspace WorkspaceConfig) string {
+	if workspace.Directory != "" {
+		return workspace.Directory
+	}
+	if runtime.GOOS == "windows" {
+		return profile.withName("C:\\codewind-data")
+	}
+	return filepath.Join(os.Getenv("HOME"), profile.withName("codewind-data"))
+}
+
+func (profile LocalProfile) workspaceFile() string {
+	return filepath.Join(cliconfig.GetStateDir(), profile.ComposeProject()+workspaceFileSuffix)
+}
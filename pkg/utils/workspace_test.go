@@ -0,0 +1,78 @@
+/*******************************************************************************
+ * Copyright (c) 2019 IBM Corporation and others.
+ * All rights reserved. This program and the accompanying materials
+ * are made available under the terms of the Eclipse Public License v2.0
+ * which accompanies this distribution, and is available at
+ * http://www.eclipse.org/legal/epl-v20.html
+ *
+ * Contributors:
+ *     IBM Corporation - initial API and implementation
+ *******************************************************************************/
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkspace(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the default workspace directory is not under HOME on Windows")
+	}
+	home, _ := ioutil.TempDir("", "workspace")
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	profile := LocalProfile{Name: "demo"}
+
+	t.Run("Defaults to the directory of the profile", func(t *testing.T) {
+		assert.Equal(t, WorkspaceConfig{}, profile.Workspace())
+		assert.Equal(t, filepath.Join(home, "codewind-data-demo"), profile.WorkspaceDirectory())
+		assert.Equal(t, filepath.Join(home, "codewind-data"), LocalProfile{}.WorkspaceDirectory())
+	})
+
+	t.Run("Uses the directory saved for the profile only", func(t *testing.T) {
+		directory := filepath.Join(home, "drive", "workspace")
+		assert.Nil(t, profile.SaveWorkspace(WorkspaceConfig{Directory: directory}))
+		assert.Equal(t, directory, profile.WorkspaceDirectory())
+		assert.Equal(t, filepath.Join(home, "codewind-data"), LocalProfile{}.WorkspaceDirectory())
+	})
+
+	t.Run("Reads a named volume back", func(t *testing.T) {
+		assert.Nil(t, profile.SaveWorkspace(WorkspaceConfig{NamedVolume: true}))
+		assert.Equal(t, WorkspaceConfig{NamedVolume: true}, profile.Workspace())
+		assert.Equal(t, "codewind-demo_cw-data", profile.DataVolume())
+	})
+
+	t.Run("Saving the default removes the saved config", func(t *testing.T) {
+		assert.Nil(t, profile.SaveWorkspace(WorkspaceConfig{}))
+		_, err := os.Stat(profile.workspaceFile())
+		assert.True(t, os.IsNotExist(err))
+		assert.Nil(t, profile.SaveWorkspace(WorkspaceConfig{}))
+	})
+}
+
+func TestNewComposeConfigWorkspace(t *testing.T) {
+	ports := PortConfig{PFEPort: "10000", PerformancePort: DefaultPerformancePort, HostInterface: DefaultHostInterface}
+
+	t.Run("Mounts the host directory given", func(t *testing.T) {
+		config := NewComposeConfig(DefaultImageConfig(), ports, WorkspaceConfig{Directory: "/data/codewind"})
+		assert.Equal(t, "/data/codewind", config.PFE.Environment["HOST_WORKSPACE_DIRECTORY"])
+		assert.Contains(t, config.PFE.Volumes, "/data/codewind:/mounted-workspace")
+		assert.Equal(t, []string{"cw-workspace"}, config.Volumes)
+	})
+
+	t.Run("Mounts and declares the named volume", func(t *testing.T) {
+		config := NewComposeConfig(DefaultImageConfig(), ports, WorkspaceConfig{NamedVolume: true})
+		// Without Docker the volume is assumed to be in the default data root
+		assert.Equal(t, "/var/lib/docker/volumes/"+ActiveProfile().DataVolume()+"/_data", config.PFE.Environment["HOST_WORKSPACE_DIRECTORY"])
+		assert.Contains(t, config.PFE.Volumes, "cw-data:/mounted-workspace")
+		assert.Equal(t, []string{"cw-workspace", "cw-data"}, config.Volumes)
+	})
+}